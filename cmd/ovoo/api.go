@@ -1,37 +1,59 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/Burmuley/ovoo/internal/applications/rest"
+	"github.com/Burmuley/ovoo/internal/cache"
 	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/health"
+	"github.com/Burmuley/ovoo/internal/mailer"
+	"github.com/Burmuley/ovoo/internal/notifications"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
 	"github.com/Burmuley/ovoo/internal/services"
+	"github.com/Burmuley/ovoo/internal/tracing"
 )
 
-func makeServices(repoFactory *factory.RepoFactory, dict []string) (*services.ServiceGateway, error) {
+func makeServices(repoFactory *factory.RepoFactory, dict []string, m mailer.Mailer, cfg *config.APIConfig) (*services.ServiceGateway, error) {
 	aliases, err := services.NewAliasesService(dict, repoFactory)
 	if err != nil {
 		return nil, fmt.Errorf("initializing aliases service: %w", err)
 	}
 
-	prAddrs, err := services.NewProtectedAddrService(repoFactory)
+	prAddrs, err := services.NewProtectedAddrService(repoFactory, m, cfg.Verification)
 	if err != nil {
 		return nil, fmt.Errorf("initializing protected addresses service: %w", err)
 	}
 
-	chains, err := services.NewChainsService(repoFactory)
+	reputationPolicy, err := services.LoadReputationPolicy(cfg.Reputation)
+	if err != nil {
+		return nil, fmt.Errorf("loading reputation policy: %w", err)
+	}
+
+	chains, err := services.NewChainsService(repoFactory, reputationPolicy)
 	if err != nil {
 		return nil, fmt.Errorf("initializing chains service: %w", err)
 	}
 
-	users, err := services.NewUsersService(repoFactory)
+	policy, err := services.LoadPasswordPolicy(cfg.Passwords)
+	if err != nil {
+		return nil, fmt.Errorf("loading password policy: %w", err)
+	}
+
+	users, err := services.NewUsersService(repoFactory, policy)
 	if err != nil {
 		return nil, fmt.Errorf("initializing users service: %w", err)
 	}
 
+	passwords, err := services.NewPasswordsService(repoFactory, policy, m, cfg.Passwords, cfg.Verification)
+	if err != nil {
+		return nil, fmt.Errorf("initializing passwords service: %w", err)
+	}
+
 	tokens, err := services.NewApiTokensService(repoFactory)
 	if err != nil {
 		return nil, fmt.Errorf("initializing api tokens service: %w", err)
@@ -42,7 +64,44 @@ func makeServices(repoFactory *factory.RepoFactory, dict []string) (*services.Se
 		return nil, fmt.Errorf("initializing domains service: %w", err)
 	}
 
-	svcGw, err := services.New(aliases, prAddrs, chains, users, tokens, domainsSvc)
+	roles, err := services.NewRolesService(repoFactory)
+	if err != nil {
+		return nil, fmt.Errorf("initializing roles service: %w", err)
+	}
+
+	svcAccs, err := services.NewServiceAccountsService(repoFactory)
+	if err != nil {
+		return nil, fmt.Errorf("initializing service accounts service: %w", err)
+	}
+
+	mfa, err := services.NewMFAService(repoFactory, cfg.MFA)
+	if err != nil {
+		return nil, fmt.Errorf("initializing mfa service: %w", err)
+	}
+
+	sessions, err := services.NewSessionsService(repoFactory, cfg.Sessions)
+	if err != nil {
+		return nil, fmt.Errorf("initializing sessions service: %w", err)
+	}
+
+	notifs, err := services.NewNotificationsService(repoFactory)
+	if err != nil {
+		return nil, fmt.Errorf("initializing notifications service: %w", err)
+	}
+
+	// the SMTP mailer relays the messages released from the quarantine
+	relayer, _ := m.(mailer.Relayer)
+	quarantine, err := services.NewQuarantineService(repoFactory, relayer, cfg.Quarantine, reputationPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("initializing quarantine service: %w", err)
+	}
+
+	reputation, err := services.NewReputationService(repoFactory, reputationPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("initializing reputation service: %w", err)
+	}
+
+	svcGw, err := services.New(aliases, prAddrs, chains, users, tokens, domainsSvc, roles, svcAccs, mfa, sessions, notifs, passwords, quarantine, reputation)
 	if err != nil {
 		return nil, fmt.Errorf("initializing services gateway: %w", err)
 	}
@@ -60,6 +119,17 @@ func startApi(cfg *config.APIConfig) error {
 	))
 	slog.SetDefault(logger)
 
+	// tracing configuration
+	shutdownTracing, err := tracing.Setup(cfg.Tracing, "ovoo-api")
+	if err != nil {
+		return fmt.Errorf("error initializing tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("error flushing traces", "error", err)
+		}
+	}()
+
 	// load words dictionary
 	dict, err := loadDict()
 	if err != nil {
@@ -73,19 +143,72 @@ func startApi(cfg *config.APIConfig) error {
 		return fmt.Errorf("error initializing repository: %w", err)
 	}
 
+	// outgoing mail, protected addresses are not verified, notifications are not sent and mail is not held without it
+	var m mailer.Mailer
+	if cfg.SMTP != nil {
+		smtpMailer, err := mailer.New(*cfg.SMTP)
+		if err != nil {
+			return fmt.Errorf("error initializing mailer: %w", err)
+		}
+		m = smtpMailer
+	} else {
+		logger.Warn("smtp is not configured, protected addresses are not verified, notifications are not sent and quarantine is disabled")
+		repos.Notifications = nil
+		repos.Quarantine = nil
+	}
+
 	// initialize services
-	svcGw, err := makeServices(repos, dict)
+	svcGw, err := makeServices(repos, dict, m, cfg)
 	if err != nil {
 		return fmt.Errorf("error initializing services gateway: %w", err)
 	}
 
+	// email notifications are delivered in the background
+	if m != nil {
+		worker, err := notifications.NewWorker(repos.Notifications, m, cfg.Notifications, logger)
+		if err != nil {
+			return fmt.Errorf("error initializing notifications: %w", err)
+		}
+
+		worker.AddCheck("domains", svcGw.Domains.RecheckVerified)
+		worker.AddCheck("quarantine", svcGw.Quarantine.PurgeExpired)
+		worker.AddCheck("reputation", svcGw.Reputation.PurgeFaded)
+		if cfg.Notifications.TokenExpiryWarning >= 0 {
+			warning := services.DefaultTokenExpiryWarning
+			if cfg.Notifications.TokenExpiryWarning > 0 {
+				warning = time.Duration(cfg.Notifications.TokenExpiryWarning) * time.Second
+			}
+			worker.AddCheck("tokens", func(ctx context.Context) error {
+				return svcGw.Tokens.NotifyExpiring(ctx, warning)
+			})
+		}
+		if cfg.Notifications.KeyExpiryWarning >= 0 {
+			warning := services.DefaultKeyExpiryWarning
+			if cfg.Notifications.KeyExpiryWarning > 0 {
+				warning = time.Duration(cfg.Notifications.KeyExpiryWarning) * time.Second
+			}
+			worker.AddCheck("pgp_keys", func(ctx context.Context) error {
+				return svcGw.PrAddrs.NotifyKeysExpiring(ctx, warning)
+			})
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go worker.Run(ctx)
+	}
+
 	// initialize REST controller
 	listen_addr := cfg.ListenAddr
 	if len(listen_addr) == 0 {
 		listen_addr = rest.DefaultListenAddr
 	}
 
-	app, err := rest.New(listen_addr, logger, svcGw, cfg.TLS.Key, cfg.TLS.Cert, cfg.OIDC, cfg.Version, cfg.SysInfo)
+	// readiness checks, the OIDC providers are added by the REST controller
+	checker := health.New(time.Duration(cfg.ShutdownDelay) * time.Second)
+	checker.Add("database", repos.Database.Ping)
+	checker.Add("cache", func(ctx context.Context) error { return cache.Ping(ctx, repos.Cache) })
+
+	app, err := rest.New(listen_addr, cfg.MetricsListenAddr, logger, svcGw, repos.Cache, checker, cfg.TLS.Key, cfg.TLS.Cert, cfg.OIDC, cfg.Version, cfg.SysInfo)
 	if err != nil {
 		return fmt.Errorf("error initializing rest api: %w", err)
 	}
@@ -31,8 +31,10 @@ func main() {
 	sockMapCmd := flag.NewFlagSet("socketmap", flag.ExitOnError)
 	sockMapCfgName := sockMapCmd.String("config", defaultConfigName, "path to the configuration file")
 
+	svcAccCmd, svcAccFlags := newServiceAccountCmd()
+
 	if len(os.Args) < 2 {
-		printUsage(apiCmd, milterCmd, sockMapCmd)
+		printUsage(apiCmd, milterCmd, sockMapCmd, svcAccCmd)
 	}
 
 	switch os.Args[1] {
@@ -81,13 +83,23 @@ func main() {
 		if err := startSocketmap(cfg); err != nil {
 			slog.Error(err.Error())
 		}
+	case "serviceaccount":
+		if len(os.Args) < 3 {
+			printUsage(svcAccCmd)
+		}
+		if err := svcAccCmd.Parse(os.Args[3:]); err != nil {
+			log.Fatal(err)
+		}
+		if err := runServiceAccount(os.Args[2], svcAccFlags); err != nil {
+			log.Fatal(err)
+		}
 	default:
 		printUsage(apiCmd, milterCmd)
 	}
 }
 
 func printUsage(flags ...*flag.FlagSet) {
-	fmt.Println("Supported commands: api, milter, version")
+	fmt.Println("Supported commands: api, milter, socketmap, serviceaccount, version")
 	for _, f := range flags {
 		f.Usage()
 	}
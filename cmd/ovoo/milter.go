@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/Burmuley/ovoo/internal/applications/milter"
 	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+	"github.com/Burmuley/ovoo/internal/cache"
 	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/tracing"
 )
 
 func startMilter(cfg *config.MilterConfig) error {
@@ -22,6 +25,17 @@ func startMilter(cfg *config.MilterConfig) error {
 	))
 	slog.SetDefault(logger)
 
+	// tracing configuration
+	shutdownTracing, err := tracing.Setup(cfg.Tracing, "ovoo-milter")
+	if err != nil {
+		return fmt.Errorf("error initializing tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("error flushing traces", "error", err)
+		}
+	}()
+
 	// initialize Milter controller
 	listen_addr := cfg.ListenAddr
 	if len(listen_addr) == 0 {
@@ -30,19 +44,45 @@ func startMilter(cfg *config.MilterConfig) error {
 
 	apiAddr := cfg.Api.Addr
 	apiToken := cfg.Api.AuthToken
-	if apiToken == "" {
-		return errors.New("missing 'auth_token' configuration parameter")
+	if apiToken == "" && cfg.Api.AuthTokenFile == "" {
+		return errors.New("missing 'auth_token' or 'auth_token_file' configuration parameter")
 	}
 	// displayName := cfg.MailDisplayName
-	client, err := ovooclient.NewClient(
-		apiAddr,
-		apiToken,
-		cfg.Api.TLSSkipVerify,
-		time.Duration(cfg.Api.Timeout)*time.Second,
-	)
+	var client ovooclient.Client
+	if cfg.Api.AuthTokenFile != "" {
+		client, err = ovooclient.NewClientWithTokenFile(
+			apiAddr,
+			cfg.Api.AuthTokenFile,
+			cfg.Api.TLSSkipVerify,
+			time.Duration(cfg.Api.Timeout)*time.Second,
+		)
+	} else {
+		client, err = ovooclient.NewClient(
+			apiAddr,
+			apiToken,
+			cfg.Api.TLSSkipVerify,
+			time.Duration(cfg.Api.Timeout)*time.Second,
+		)
+	}
 	if err != nil {
 		return fmt.Errorf("error creating Ovoo API client: %w", err)
 	}
-	app, _ := milter.New(listen_addr, logger, client)
+	client = client.WithResilience(ovooclient.Resilience{
+		Retries:          cfg.Api.Retries,
+		BreakerThreshold: cfg.Api.BreakerThreshold,
+		BreakerCooldown:  time.Duration(cfg.Api.BreakerCooldown) * time.Second,
+		StaleTTL:         time.Duration(cfg.Api.StaleTTL) * time.Second,
+	})
+
+	clientCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		return fmt.Errorf("error initializing cache: %w", err)
+	}
+	client = client.WithCache(clientCache)
+	webhooks := milter.WebhookConfig{
+		Timeout:              time.Duration(cfg.Webhook.Timeout) * time.Second,
+		AllowPrivateNetworks: cfg.Webhook.AllowPrivateNetworks,
+	}
+	app, _ := milter.New(listen_addr, cfg.MetricsListenAddr, cfg.HealthListenAddr, time.Duration(cfg.ShutdownDelay)*time.Second, cfg.TrackerDomains, webhooks, logger, client)
 	return app.Start()
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// serviceAccountFlags holds the command line parameters of the serviceaccount command
+type serviceAccountFlags struct {
+	cfgName     string
+	admin       string
+	login       string
+	description string
+	expireIn    int
+	overlap     time.Duration
+	tokenFile   string
+}
+
+func newServiceAccountCmd() (*flag.FlagSet, *serviceAccountFlags) {
+	flags := &serviceAccountFlags{}
+	cmd := flag.NewFlagSet("serviceaccount", flag.ExitOnError)
+	cmd.Usage = func() {
+		fmt.Fprintf(cmd.Output(), "Usage of serviceaccount: serviceaccount <list|create|rotate> [flags]\n")
+		cmd.PrintDefaults()
+	}
+	cmd.StringVar(&flags.cfgName, "config", defaultConfigName, "path to the configuration file")
+	cmd.StringVar(&flags.admin, "admin", "", "login of the admin user to act as (defaults to the 'default_admin' login)")
+	cmd.StringVar(&flags.login, "login", "", "service account login")
+	cmd.StringVar(&flags.description, "description", "", "service account description")
+	cmd.IntVar(&flags.expireIn, "expire-in", services.DefaultServiceTokenExpireIn, "API token validity in days")
+	cmd.DurationVar(&flags.overlap, "overlap", services.DefaultServiceTokenOverlap, "period the previous API tokens remain valid for after rotation")
+	cmd.StringVar(&flags.tokenFile, "token-file", "", "write the new API token to the file instead of stdout")
+	return cmd, flags
+}
+
+// runServiceAccount executes the serviceaccount command action against the API database
+func runServiceAccount(action string, flags *serviceAccountFlags) error {
+	cfg, err := config.LoadConfig[config.APIConfig](config.APISection, flags.cfgName)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repos, err := factory.New(cfg.Database, cfg.Cache, cfg.DefaultAdmin, logger)
+	if err != nil {
+		return fmt.Errorf("error initializing repository: %w", err)
+	}
+
+	svcAccs, err := services.NewServiceAccountsService(repos)
+	if err != nil {
+		return err
+	}
+
+	users, err := services.NewUsersService(repos, entities.PasswordPolicy{})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	adminLogin := flags.admin
+	if adminLogin == "" && cfg.DefaultAdmin != nil {
+		adminLogin = cfg.DefaultAdmin.Login
+	}
+	if adminLogin == "" {
+		return errors.New("admin user login is not set, use '-admin' flag or 'default_admin' configuration")
+	}
+
+	admin, err := users.GetByLogin(ctx, adminLogin)
+	if err != nil {
+		return fmt.Errorf("getting admin user '%s': %w", adminLogin, err)
+	}
+
+	switch action {
+	case "list":
+		accounts, _, err := svcAccs.GetAll(ctx, admin, entities.UserFilter{})
+		if err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			fmt.Printf("%s\t%s\tactive=%t\t%s\n", account.ID, account.Login, account.Active, account.FirstName)
+		}
+		return nil
+	case "create":
+		_, token, err := svcAccs.Create(ctx, admin, services.ServiceAccountCreateCmd{
+			Login:       flags.login,
+			Description: flags.description,
+			ExpireIn:    flags.expireIn,
+		})
+		if err != nil {
+			return err
+		}
+		return outputToken(token, flags.tokenFile)
+	case "rotate":
+		account, err := users.GetByLogin(ctx, flags.login)
+		if err != nil {
+			return fmt.Errorf("getting service account '%s': %w", flags.login, err)
+		}
+		token, err := svcAccs.RotateToken(ctx, admin, services.ServiceAccountRotateCmd{
+			AccountId: account.ID,
+			ExpireIn:  flags.expireIn,
+			Overlap:   flags.overlap,
+		})
+		if err != nil {
+			return err
+		}
+		return outputToken(token, flags.tokenFile)
+	default:
+		return fmt.Errorf("unknown serviceaccount action '%s'", action)
+	}
+}
+
+// outputToken prints the clear text API token or atomically replaces the token file with it,
+// so the clients reloading the file never read a partially written token.
+func outputToken(token entities.ApiToken, tokenFile string) error {
+	if tokenFile == "" {
+		fmt.Printf("API token (expires %s): %s\n", token.Expiration.Format(time.RFC3339), token.Token)
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(tokenFile), ".ovoo-token-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.WriteString(token.Token + "\n"); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), tokenFile); err != nil {
+		return err
+	}
+
+	fmt.Printf("API token (expires %s) written to %s\n", token.Expiration.Format(time.RFC3339), tokenFile)
+	return nil
+}
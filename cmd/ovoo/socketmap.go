@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
@@ -8,7 +10,9 @@ import (
 
 	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
 	"github.com/Burmuley/ovoo/internal/applications/socketmap"
+	"github.com/Burmuley/ovoo/internal/cache"
 	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/tracing"
 )
 
 func startSocketmap(cfg *config.SocketMapConfig) error {
@@ -21,6 +25,17 @@ func startSocketmap(cfg *config.SocketMapConfig) error {
 	))
 	slog.SetDefault(logger)
 
+	// tracing configuration
+	shutdownTracing, err := tracing.Setup(cfg.Tracing, "ovoo-socketmap")
+	if err != nil {
+		return fmt.Errorf("error initializing tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("error flushing traces", "error", err)
+		}
+	}()
+
 	var network string
 	var addr string
 
@@ -34,12 +49,29 @@ func startSocketmap(cfg *config.SocketMapConfig) error {
 		addr = socketmap.DefaultSocketmapAddr
 	}
 
-	cli, err := ovooclient.NewClient(cfg.Api.Addr, cfg.Api.AuthToken, cfg.Api.TLSSkipVerify, time.Duration(cfg.Api.Timeout))
+	var cli ovooclient.Client
+	if cfg.Api.AuthTokenFile != "" {
+		cli, err = ovooclient.NewClientWithTokenFile(cfg.Api.Addr, cfg.Api.AuthTokenFile, cfg.Api.TLSSkipVerify, time.Duration(cfg.Api.Timeout))
+	} else {
+		cli, err = ovooclient.NewClient(cfg.Api.Addr, cfg.Api.AuthToken, cfg.Api.TLSSkipVerify, time.Duration(cfg.Api.Timeout))
+	}
+	if err != nil {
+		return err
+	}
+	cli = cli.WithResilience(ovooclient.Resilience{
+		Retries:          cfg.Api.Retries,
+		BreakerThreshold: cfg.Api.BreakerThreshold,
+		BreakerCooldown:  time.Duration(cfg.Api.BreakerCooldown) * time.Second,
+		StaleTTL:         time.Duration(cfg.Api.StaleTTL) * time.Second,
+	})
+
+	cliCache, err := cache.New(cfg.Cache)
 	if err != nil {
 		return err
 	}
+	cli = cli.WithCache(cliCache)
 
-	app, err := socketmap.New(cfg.Network, cfg.ListenAddr, cli)
+	app, err := socketmap.New(cfg.Network, cfg.ListenAddr, cfg.MetricsListenAddr, cfg.HealthListenAddr, time.Duration(cfg.ShutdownDelay)*time.Second, cli)
 	if err != nil {
 		return err
 	}
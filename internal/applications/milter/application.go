@@ -9,23 +9,40 @@ import (
 	"time"
 
 	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+	"github.com/Burmuley/ovoo/internal/health"
+	"github.com/Burmuley/ovoo/internal/metrics"
 	"github.com/d--j/go-milter/mailfilter"
 )
 
 const DefaultListenAddr string = "127.0.0.1:6785"
 
 type Application struct {
-	listenAddr string
-	ovooCli    ovooclient.Client
-	logger     *slog.Logger
+	listenAddr  string
+	metricsAddr string
+	healthAddr  string
+	health      *health.Checker
+	ovooCli     ovooclient.Client
+	trackers    []string
+	webhooks    WebhookConfig
+	logger      *slog.Logger
 }
 
-func New(listenAddr string, logger *slog.Logger, ovooCli ovooclient.Client) (*Application, error) {
+// New creates the milter application, metrics are served on metricsAddr and the health probes
+// on healthAddr when they are not empty. The milter is ready when the Ovoo API is reachable, and
+// keeps running for shutdownDelay after the readiness probe starts failing on shutdown. The tracker domains extend
+// the built-in list of the privacy filter, the webhook configuration applies to the aliases delivering to webhooks.
+func New(listenAddr, metricsAddr, healthAddr string, shutdownDelay time.Duration, trackerDomains []string, webhooks WebhookConfig, logger *slog.Logger, ovooCli ovooclient.Client) (*Application, error) {
 	ctrl := &Application{
-		listenAddr: listenAddr,
-		ovooCli:    ovooCli,
-		logger:     logger,
+		listenAddr:  listenAddr,
+		metricsAddr: metricsAddr,
+		healthAddr:  healthAddr,
+		health:      health.New(shutdownDelay),
+		ovooCli:     ovooCli,
+		trackers:    trackerDomains,
+		webhooks:    webhooks,
+		logger:      logger,
 	}
+	ctrl.health.Add("api", ovooCli.Ping)
 
 	return ctrl, nil
 }
@@ -38,7 +55,7 @@ func (m *Application) Start() error {
 	server, err := mailfilter.New(
 		"tcp",
 		m.listenAddr,
-		AddressRewriter(m.ovooCli),
+		AddressRewriter(m.ovooCli, m.trackers, m.webhooks),
 		mailfilter.WithDecisionAt(mailfilter.DecisionAtEndOfMessage),
 		mailfilter.WithErrorHandling(mailfilter.RejectWhenError),
 	)
@@ -47,6 +64,17 @@ func (m *Application) Start() error {
 		return err
 	}
 
+	if m.metricsAddr != "" {
+		go metrics.Serve(ctx, m.metricsAddr, m.logger)
+	}
+
+	// the probes are served until the milter is stopped, so the instance can be drained first
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	if m.healthAddr != "" {
+		go m.health.Serve(healthCtx, m.healthAddr, m.logger)
+	}
+
 	go func() {
 		m.logger.Info("starting Ovoo Milter server", server.Addr().Network(), server.Addr().String())
 		server.Wait()
@@ -54,6 +82,11 @@ func (m *Application) Start() error {
 	}()
 
 	<-ctx.Done()
+	if m.healthAddr != "" {
+		m.logger.Info("draining Ovoo Milter server")
+		m.health.Drain(context.Background())
+	}
+
 	m.logger.Info("shutting down Ovoo Milter server")
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
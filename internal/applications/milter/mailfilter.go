@@ -2,25 +2,68 @@ package milter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/mail"
 	"strings"
 
 	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+	"github.com/Burmuley/ovoo/internal/metrics"
+	"github.com/Burmuley/ovoo/internal/tracing"
 	"github.com/d--j/go-milter/mailfilter"
 	"github.com/d--j/go-milter/mailfilter/addr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func AddressRewriter(cli ovooclient.Client) func(ctx context.Context, trx mailfilter.Trx) (mailfilter.Decision, error) {
+// milter decision outcomes recorded in the metrics and traces
+const (
+	outcomeRewritten         = "rewritten"
+	outcomeEncrypted         = "encrypted"   // rewritten and encrypted with the pgp key of the protected address
+	outcomeQuarantined       = "quarantined" // held in the quarantine of the alias instead of being forwarded
+	outcomeDiscarded         = "discarded"   // dropped by the rules of the alias or the protected address
+	outcomeWebhook           = "webhook"     // posted to the webhook of the alias instead of being forwarded
+	outcomePassed            = "passed"      // no recipient in the Ovoo domains, left to the MTA
+	outcomeTooManyRecipients = "too_many_recipients"
+	outcomeRejected          = "rejected"
+	outcomeTempFailed        = "tempfailed" // the Ovoo API is unavailable or encryption failed, the MTA retries later
+)
+
+// AddressRewriter returns the milter rewriting the addresses of the mail to and from the aliases, the tracker domains
+// extend the built-in list of the privacy filter. The mail of the aliases delivering to webhooks is posted to them.
+func AddressRewriter(cli ovooclient.Client, trackerDomains []string, webhooks WebhookConfig) func(ctx context.Context, trx mailfilter.Trx) (mailfilter.Decision, error) {
+	rewrite := addressRewriter(cli, newTrackerList(trackerDomains), newWebhookSender(webhooks))
 	return func(ctx context.Context, trx mailfilter.Trx) (mailfilter.Decision, error) {
+		ctx, span := tracing.Start(ctx, "milter.rewrite", trace.WithSpanKind(trace.SpanKindServer))
+		decision, outcome, err := rewrite(ctx, trx)
+		metrics.ObserveMilterDecision(outcome)
+		span.SetAttributes(attribute.String("milter.outcome", outcome))
+		tracing.End(span, err)
+		if outcome == outcomeTempFailed {
+			// errors make the milter reject the message, the temporary failure is returned without it
+			slog.Warn("temporarily failing message", "error", err.Error())
+			return decision, nil
+		}
+		return decision, err
+	}
+}
+
+// addressRewriter returns the milter decision with its outcome for the metrics.
+func addressRewriter(cli ovooclient.Client, trackers trackerList, webhooks *webhookSender) func(ctx context.Context, trx mailfilter.Trx) (mailfilter.Decision, string, error) {
+	return func(ctx context.Context, trx mailfilter.Trx) (mailfilter.Decision, string, error) {
 		curFrom, err := getHeaderAddr("from", trx)
 		if err != nil {
-			return mailfilter.Reject, err
+			return mailfilter.Reject, outcomeRejected, err
 		}
 
 		domains, err := cli.GetDomains(ctx)
+		if errors.Is(err, ovooclient.ErrUnavailable) {
+			return mailfilter.TempFail, outcomeTempFailed, err
+		}
 		if err != nil {
-			return mailfilter.Reject, err
+			return mailfilter.Reject, outcomeRejected, err
 		}
 
 		// check recipients matching any of our configured domains
@@ -36,18 +79,37 @@ func AddressRewriter(cli ovooclient.Client) func(ctx context.Context, trx mailfi
 
 		// let MTA decide if no recipients matching domain present
 		if len(matchingRcpts) == 0 {
-			return mailfilter.Accept, nil
+			return mailfilter.Accept, outcomePassed, nil
 		}
 
 		// only allow single matching recipient per message
 		if len(matchingRcpts) > 1 {
-			return mailfilter.CustomErrorResponse(522, "5.5.3 Too many recipients"), fmt.Errorf("too many recipients")
+			return mailfilter.CustomErrorResponse(522, "5.5.3 Too many recipients"), outcomeTooManyRecipients, fmt.Errorf("too many recipients")
 		}
 
 		rcpt := matchingRcpts[0]
 		chain, err := cli.CreateChain(ctx, trx.MailFrom().Addr, rcpt.Addr)
+		if errors.Is(err, ovooclient.ErrUnavailable) {
+			return mailfilter.TempFail, outcomeTempFailed, fmt.Errorf("error creating chain: %w", err)
+		}
 		if err != nil {
-			return mailfilter.Reject, fmt.Errorf("error creating chain: %w", err)
+			return mailfilter.Reject, outcomeRejected, fmt.Errorf("error creating chain: %w", err)
+		}
+
+		// the rules run on the message as it was received, before it is rewritten
+		if chain.Rules != "" && !filterMessage(trx, chain) {
+			return mailfilter.Discard, outcomeDiscarded, nil
+		}
+
+		// the mail of the aliases delivering to webhooks is posted as it was received, there is no one to reply to
+		if chain.Webhook != nil {
+			if chain.PrivacyFilter {
+				if _, err := stripTrackers(trx, trackers); err != nil {
+					slog.Warn("posting message without privacy filter", "error", err.Error())
+				}
+			}
+
+			return webhooks.deliver(ctx, trx, chain)
 		}
 
 		var nto mail.Address
@@ -95,10 +157,53 @@ func AddressRewriter(cli ovooclient.Client) func(ctx context.Context, trx mailfi
 		// delete Received-SPF header for privacy
 		trx.Headers().Set("Received-SPF", "")
 
-		return mailfilter.Accept, nil
+		// the trackers are stripped before the message is encrypted, a message which can not be parsed
+		// is forwarded as it is, retrying would not help
+		if chain.PrivacyFilter {
+			if _, err := stripTrackers(trx, trackers); err != nil {
+				slog.Warn("forwarding message without privacy filter", "error", err.Error())
+			}
+		}
+
+		// mail forwarded to protected addresses with a pgp key leaves encrypted
+		if chain.PGPKey != "" {
+			encrypted, err := encryptMessage(trx, chain.PGPKey)
+			if err != nil {
+				return mailfilter.TempFail, outcomeTempFailed, fmt.Errorf("encrypting message: %w", err)
+			}
+
+			if encrypted && !chain.Quarantine {
+				return mailfilter.Accept, outcomeEncrypted, nil
+			}
+		}
+
+		// held mail is stored as it would be forwarded and discarded, the API delivers it when released
+		if chain.Quarantine {
+			return quarantine(ctx, cli, trx, chain.Hash)
+		}
+
+		return mailfilter.Accept, outcomeRewritten, nil
 	}
 }
 
+// quarantine holds the rewritten message in the quarantine of the alias and discards its delivery.
+func quarantine(ctx context.Context, cli ovooclient.Client, trx mailfilter.Trx, hash string) (mailfilter.Decision, string, error) {
+	data, err := io.ReadAll(trx.Data())
+	if err != nil {
+		return mailfilter.TempFail, outcomeTempFailed, fmt.Errorf("reading message: %w", err)
+	}
+
+	err = cli.QuarantineMessage(ctx, hash, data)
+	if errors.Is(err, ovooclient.ErrUnavailable) {
+		return mailfilter.TempFail, outcomeTempFailed, fmt.Errorf("holding message: %w", err)
+	}
+	if err != nil {
+		return mailfilter.Reject, outcomeRejected, fmt.Errorf("holding message: %w", err)
+	}
+
+	return mailfilter.Discard, outcomeQuarantined, nil
+}
+
 func getHeaderAddr(header string, trx mailfilter.Trx) (*mail.Address, error) {
 	hdr, err := trx.Headers().Text(header)
 	if err != nil {
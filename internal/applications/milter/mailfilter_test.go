@@ -1,9 +1,11 @@
 package milter
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/mail"
@@ -13,6 +15,7 @@ import (
 	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
 	"github.com/d--j/go-milter/mailfilter"
 	"github.com/d--j/go-milter/mailfilter/addr"
+	"github.com/d--j/go-milter/mailfilter/testtrx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -39,8 +42,8 @@ func chainServer(t *testing.T, chain ovooclient.ChainData) ovooclient.Client {
 	return cli
 }
 
-// errorServer creates an httptest.Server that responds 200 to GetDomains and 500 to everything else.
-func errorServer(t *testing.T) ovooclient.Client {
+// errorServer creates an httptest.Server that responds 200 to GetDomains and the given status to everything else.
+func errorServer(t *testing.T, status int) ovooclient.Client {
 	t.Helper()
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -51,8 +54,8 @@ func errorServer(t *testing.T) ovooclient.Client {
 			})
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = w.Write([]byte(`{"errors":[{"status":"error","detail":"internal error"}]}`))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"errors":[{"status":"error","detail":"chain error"}]}`))
 	}))
 	t.Cleanup(srv.Close)
 	cli, err := ovooclient.NewClient(srv.URL, "test-token", false, 5*time.Second)
@@ -114,7 +117,7 @@ func TestAddressRewriter_InvalidFromHeader_WithOvooClientRcpt(t *testing.T) {
 	cli := stubClient()
 	trx := newMockTrx("", "sender@ext.com", addr.NewRcptTo("alias@ovoo.com", "", ""))
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	assert.True(t, mailfilter.Reject.Equal(decision))
 	assert.Error(t, err)
@@ -126,7 +129,7 @@ func TestAddressRewriter_InvalidFromHeader_NoovooclientRcpt(t *testing.T) {
 	cli := stubClient()
 	trx := newMockTrx("", "sender@ext.com", addr.NewRcptTo("user@external.com", "", ""))
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	assert.True(t, mailfilter.Reject.Equal(decision))
 	assert.Error(t, err)
@@ -136,7 +139,7 @@ func TestAddressRewriter_EmptyRcptList(t *testing.T) {
 	cli := domainsServer(t)
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com") // no recipients
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	assert.True(t, mailfilter.Accept.Equal(decision))
 	assert.NoError(t, err)
@@ -148,7 +151,7 @@ func TestAddressRewriter_NoMatchingRecipients(t *testing.T) {
 	rcpt := addr.NewRcptTo("user@external.com", "", "")
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	assert.True(t, mailfilter.Accept.Equal(decision))
 	assert.NoError(t, err)
@@ -164,7 +167,7 @@ func TestAddressRewriter_MultipleMatchingRecipients(t *testing.T) {
 	rcpt2 := addr.NewRcptTo("alias2@ovoo.com", "", "")
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt1, rcpt2)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	expected := mailfilter.CustomErrorResponse(522, "5.5.3 Too many recipients")
 	assert.True(t, expected.Equal(decision))
@@ -175,17 +178,46 @@ func TestAddressRewriter_MultipleMatchingRecipients(t *testing.T) {
 // --- AddressRewriter: CreateChain failure ---
 
 func TestAddressRewriter_CreateChainError(t *testing.T) {
-	cli := errorServer(t)
+	cli := errorServer(t, http.StatusBadRequest)
 	rcpt := addr.NewRcptTo("alias@ovoo.com", "", "")
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	assert.True(t, mailfilter.Reject.Equal(decision))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error creating chain")
 }
 
+// An unavailable API must not bounce the message, the MTA is asked to retry later.
+func TestAddressRewriter_CreateChainUnavailable(t *testing.T) {
+	cli := errorServer(t, http.StatusInternalServerError)
+	rcpt := addr.NewRcptTo("alias@ovoo.com", "", "")
+	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt)
+
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
+
+	assert.True(t, mailfilter.TempFail.Equal(decision))
+	assert.NoError(t, err, "errors make the milter reject the message")
+	assert.Empty(t, trx.changeMailFromCalls)
+}
+
+func TestAddressRewriter_GetDomainsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	cli, err := ovooclient.NewClient(srv.URL, "test-token", false, 5*time.Second)
+	require.NoError(t, err)
+	cli = cli.WithResilience(ovooclient.Resilience{Retries: -1})
+	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", addr.NewRcptTo("alias@ovoo.com", "", ""))
+
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
+
+	assert.True(t, mailfilter.TempFail.Equal(decision))
+	assert.NoError(t, err)
+}
+
 // --- AddressRewriter: forward chain (OrigToAddress.Type != "reply_alias") ---
 
 func TestAddressRewriter_ForwardChain(t *testing.T) {
@@ -199,7 +231,7 @@ func TestAddressRewriter_ForwardChain(t *testing.T) {
 	rcpt := addr.NewRcptTo("alias@ovoo.com", "SIZE=1000", "")
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	require.NoError(t, err)
 	assert.True(t, mailfilter.Accept.Equal(decision))
@@ -234,7 +266,7 @@ func TestAddressRewriter_ForwardChain_SenderNoName(t *testing.T) {
 	rcpt := addr.NewRcptTo("alias@ovoo.com", "", "")
 	trx := newMockTrx("sender@ext.com", "sender@ext.com", rcpt) // bare address, no name
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	require.NoError(t, err)
 	assert.True(t, mailfilter.Accept.Equal(decision))
@@ -257,7 +289,7 @@ func TestAddressRewriter_MailFromArgsPassthrough(t *testing.T) {
 	mf := addr.NewMailFrom("sender@ext.com", "SIZE=500 BODY=8BITMIME", "", "", "")
 	trx.mailFrom = &mf
 
-	_, err := AddressRewriter(cli)(context.Background(), trx)
+	_, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 	require.NoError(t, err)
 
 	require.Len(t, trx.changeMailFromCalls, 1)
@@ -277,7 +309,7 @@ func TestAddressRewriter_ReplyChain(t *testing.T) {
 	rcpt := addr.NewRcptTo("reply-alias@ovoo.com", "", "")
 	trx := newMockTrx("User <user@gmail.com>", "user@gmail.com", rcpt)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	require.NoError(t, err)
 	assert.True(t, mailfilter.Accept.Equal(decision))
@@ -306,7 +338,7 @@ func TestAddressRewriter_ReplyToPresent(t *testing.T) {
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt)
 	trx.headers.textValues["reply-to"] = "Sender <sender@ext.com>"
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	require.NoError(t, err)
 	assert.True(t, mailfilter.Accept.Equal(decision))
@@ -328,7 +360,7 @@ func TestAddressRewriter_ReplyToAbsent(t *testing.T) {
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt)
 	// textValues["reply-to"] not set → Text("reply-to") returns ("", nil)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	require.NoError(t, err)
 	assert.True(t, mailfilter.Accept.Equal(decision))
@@ -353,7 +385,7 @@ func TestAddressRewriter_ReplyToParseError(t *testing.T) {
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt)
 	trx.headers.textErrors["reply-to"] = errors.New("charset decode error")
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	require.NoError(t, err)
 	assert.True(t, mailfilter.Accept.Equal(decision))
@@ -379,7 +411,7 @@ func TestAddressRewriter_MixedRecipients_OneDomainMatch(t *testing.T) {
 	extRcpt := addr.NewRcptTo("other@external.com", "", "")
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", ovooRcpt, extRcpt)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	require.NoError(t, err)
 	assert.True(t, mailfilter.Accept.Equal(decision))
@@ -398,9 +430,79 @@ func TestAddressRewriter_MixedRecipients_TwoDomainMatches(t *testing.T) {
 	ext := addr.NewRcptTo("user@external.com", "", "")
 	trx := newMockTrx("Sender <sender@ext.com>", "sender@ext.com", rcpt1, ext, rcpt2)
 
-	decision, err := AddressRewriter(cli)(context.Background(), trx)
+	decision, err := AddressRewriter(cli, nil, WebhookConfig{})(context.Background(), trx)
 
 	expected := mailfilter.CustomErrorResponse(522, "5.5.3 Too many recipients")
 	assert.True(t, expected.Equal(decision))
 	assert.Error(t, err)
 }
+
+// Held mail is rewritten, stored in the quarantine and discarded.
+func TestAddressRewriter_ForwardChain_Quarantine(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantDecision mailfilter.Decision
+		wantOutcome  string
+		wantErr      bool
+	}{
+		{name: "message is held", status: http.StatusCreated, wantDecision: mailfilter.Discard, wantOutcome: outcomeQuarantined},
+		{name: "message is refused", status: http.StatusBadRequest, wantDecision: mailfilter.Reject, wantOutcome: outcomeRejected, wantErr: true},
+		{name: "api is unavailable", status: http.StatusServiceUnavailable, wantDecision: mailfilter.TempFail, wantOutcome: outcomeTempFailed, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var held ovooclient.HoldMessageRequestBody
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/api/v1/domains":
+					_ = json.NewEncoder(w).Encode(ovooclient.GetDomainsResponse{
+						Domains: []ovooclient.DomainData{{Id: "1", Name: "ovoo.com"}},
+					})
+				case "/private/api/v1/chains":
+					w.WriteHeader(http.StatusCreated)
+					_ = json.NewEncoder(w).Encode(ovooclient.ChainData{
+						Hash:          "h1",
+						FromEmail:     "reply@ovoo.com",
+						ToEmail:       "user@gmail.com",
+						OrigToAddress: ovooclient.ChainAddressData{Email: "alias@ovoo.com", Type: "alias"},
+						Quarantine:    true,
+					})
+				case "/private/api/v1/quarantine":
+					_ = json.NewDecoder(r.Body).Decode(&held)
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte(`{"errors":[{"status":"error","detail":"quarantine error"}]}`))
+				}
+			}))
+			t.Cleanup(srv.Close)
+			cli, err := ovooclient.NewClient(srv.URL, "test-token", false, 5*time.Second)
+			require.NoError(t, err)
+
+			trx := (&testtrx.Trx{}).
+				SetMailFrom(addr.NewMailFrom("sender@ext.com", "", "", "", "")).
+				SetRcptTosList("alias@ovoo.com").
+				SetHeadersRaw([]byte("From: Sender <sender@ext.com>\r\nTo: alias@ovoo.com\r\nSubject: Hello\r\n\r\n")).
+				SetBodyBytes([]byte("Hello there\r\n"))
+
+			decision, outcome, err := addressRewriter(cli, nil, nil)(context.Background(), trx)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.True(t, tt.wantDecision.Equal(decision))
+			assert.Equal(t, tt.wantOutcome, outcome)
+
+			// the message is held as it would be forwarded
+			assert.Equal(t, "h1", held.Hash)
+			msg, err := mail.ReadMessage(bytes.NewReader(held.Message))
+			require.NoError(t, err)
+			assert.Equal(t, `"Sender" <reply@ovoo.com>`, msg.Header.Get("From"))
+			body, err := io.ReadAll(msg.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "Hello there\r\n", string(body))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package milter
 
 import (
+	"bytes"
 	"io"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/d--j/go-milter/mailfilter"
 	"github.com/d--j/go-milter/mailfilter/addr"
 	milterheader "github.com/d--j/go-milter/mailfilter/header"
+	"github.com/d--j/go-milter/mailfilter/testtrx"
 )
 
 // setCall records a single call to mockHeader.Set.
@@ -118,3 +120,22 @@ func (m *mockTrx) Body() io.ReadSeeker          { return nil }
 func (m *mockTrx) ReplaceBody(r io.Reader)      {}
 func (m *mockTrx) QueueId() string              { return "" }
 func (m *mockTrx) Data() io.Reader              { return nil }
+
+// replacingTrx is a testtrx.Trx whose body can be replaced more than once, like in the milter transaction:
+// testtrx.Trx keeps returning the first replacement from Data once it was read.
+type replacingTrx struct {
+	*testtrx.Trx
+	replacement []byte
+}
+
+func (t *replacingTrx) ReplaceBody(r io.Reader) {
+	t.replacement, _ = io.ReadAll(r)
+}
+
+func (t *replacingTrx) Data() io.Reader {
+	if t.replacement == nil {
+		return t.Trx.Data()
+	}
+
+	return io.MultiReader(t.Headers().Reader(), bytes.NewReader(t.replacement))
+}
@@ -0,0 +1,155 @@
+package milter
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/d--j/go-milter/mailfilter"
+)
+
+// pgpMessageArmor starts an inline PGP encrypted message
+const pgpMessageArmor = "-----BEGIN PGP MESSAGE-----"
+
+// encryptMessage replaces the body of the message with its PGP/MIME (RFC 3156) version encrypted to the armored key.
+// The Content-* header fields move into the encrypted part with the body, the other fields, e.g. the rewritten
+// From and To, are kept as they are. Messages already encrypted, with PGP/MIME or inline PGP, are left untouched.
+// It reports whether the message was encrypted.
+func encryptMessage(trx mailfilter.Trx, armoredKey string) (bool, error) {
+	hdr := trx.Headers()
+	mediaType, _, _ := mime.ParseMediaType(hdr.UnfoldedValue("Content-Type"))
+	if mediaType == "multipart/encrypted" {
+		return false, nil
+	}
+
+	// the trackers may have been stripped from the body already
+	raw, err := currentBody(trx)
+	if err != nil {
+		return false, err
+	}
+
+	if (mediaType == "" || strings.HasPrefix(mediaType, "text/")) && bytes.Contains(raw, []byte(pgpMessageArmor)) {
+		return false, nil
+	}
+
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKey))
+	if err != nil {
+		return false, fmt.Errorf("parsing pgp key: %w", err)
+	}
+
+	// the mail is never forwarded unencrypted, the owner has to replace or remove an expired key
+	now := time.Now()
+	for _, key := range keys {
+		if _, ok := key.EncryptionKey(now); !ok {
+			return false, fmt.Errorf("pgp key %X expired or can not encrypt", key.PrimaryKey.Fingerprint)
+		}
+	}
+
+	// the encrypted part is a MIME entity of its own, the content fields describe the original body
+	inner := &bytes.Buffer{}
+	hasType := false
+	for fields := hdr.Fields(); fields.Next(); {
+		if fields.IsDeleted() || !strings.HasPrefix(fields.CanonicalKey(), "Content-") {
+			continue
+		}
+
+		hasType = hasType || fields.CanonicalKey() == "Content-Type"
+		inner.Write(fields.Raw())
+		inner.WriteString("\r\n")
+		fields.Del()
+	}
+
+	if !hasType {
+		inner.WriteString("Content-Type: text/plain; charset=us-ascii\r\n")
+	}
+	inner.WriteString("\r\n")
+	inner.Write(raw)
+
+	encrypted := &bytes.Buffer{}
+	aw, err := armor.Encode(encrypted, "PGP MESSAGE", nil)
+	if err != nil {
+		return false, err
+	}
+
+	pw, err := openpgp.Encrypt(aw, keys, nil, &openpgp.FileHints{IsBinary: true}, nil)
+	if err != nil {
+		return false, fmt.Errorf("encrypting message: %w", err)
+	}
+
+	if _, err := pw.Write(inner.Bytes()); err != nil {
+		return false, fmt.Errorf("encrypting message: %w", err)
+	}
+
+	if err := pw.Close(); err != nil {
+		return false, fmt.Errorf("encrypting message: %w", err)
+	}
+
+	if err := aw.Close(); err != nil {
+		return false, err
+	}
+
+	boundary, err := mimeBoundary()
+	if err != nil {
+		return false, err
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("This is an OpenPGP/MIME encrypted message (RFC 3156)\r\n")
+	out.WriteString("--" + boundary + "\r\n")
+	out.WriteString("Content-Type: application/pgp-encrypted\r\n")
+	out.WriteString("Content-Description: PGP/MIME version identification\r\n\r\n")
+	out.WriteString("Version: 1\r\n\r\n")
+	out.WriteString("--" + boundary + "\r\n")
+	out.WriteString("Content-Type: application/octet-stream; name=\"encrypted.asc\"\r\n")
+	out.WriteString("Content-Description: OpenPGP encrypted message\r\n")
+	out.WriteString("Content-Disposition: inline; filename=\"encrypted.asc\"\r\n\r\n")
+	out.Write(bytes.ReplaceAll(bytes.TrimRight(encrypted.Bytes(), "\n"), []byte("\n"), []byte("\r\n")))
+	out.WriteString("\r\n--" + boundary + "--\r\n")
+
+	hdr.Set("MIME-Version", "1.0")
+	hdr.Set("Content-Type", fmt.Sprintf("multipart/encrypted; protocol=\"application/pgp-encrypted\"; boundary=\"%s\"", boundary))
+	trx.ReplaceBody(out)
+
+	return true, nil
+}
+
+// currentBody returns the body the message is forwarded with: the replacement of a previous step when there is
+// one, the received body otherwise. Body always returns the received body, the replacement is only read by Data.
+func currentBody(trx mailfilter.Trx) ([]byte, error) {
+	if trx.Body() == nil {
+		return nil, fmt.Errorf("message body is not available")
+	}
+
+	header, err := io.ReadAll(trx.Headers().Reader())
+	if err != nil {
+		return nil, fmt.Errorf("reading message header: %w", err)
+	}
+
+	data, err := io.ReadAll(trx.Data())
+	if err != nil {
+		return nil, fmt.Errorf("reading message body: %w", err)
+	}
+
+	if !bytes.HasPrefix(data, header) {
+		return nil, fmt.Errorf("message data does not start with its header")
+	}
+
+	return data[len(header):], nil
+}
+
+// mimeBoundary returns a random multipart boundary
+func mimeBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "ovoo-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,223 @@
+package milter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/d--j/go-milter/mailfilter"
+	"github.com/d--j/go-milter/mailfilter/addr"
+	"github.com/d--j/go-milter/mailfilter/testtrx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+)
+
+const testHeaders = "From: Sender <sender@ext.com>\r\n" +
+	"To: alias@ovoo.com\r\n" +
+	"Subject: Hello\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: 8bit\r\n\r\n"
+
+// testKey returns a generated key and its armored public key
+func testKey(t *testing.T) (*openpgp.Entity, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test", "", "user@gmail.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return entity, buf.String()
+}
+
+// decryptMessage checks the PGP/MIME structure of the message and returns its decrypted part
+func decryptMessage(t *testing.T, data io.Reader, key *openpgp.Entity) (*mail.Message, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(data)
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/encrypted", mediaType)
+	require.Equal(t, "application/pgp-encrypted", params["protocol"])
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	version, err := mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "application/pgp-encrypted", version.Header.Get("Content-Type"))
+	versionBody, err := io.ReadAll(version)
+	require.NoError(t, err)
+	assert.Contains(t, string(versionBody), "Version: 1")
+
+	payload, err := mr.NextPart()
+	require.NoError(t, err)
+	block, err := armor.Decode(payload)
+	require.NoError(t, err)
+
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{key}, nil, nil)
+	require.NoError(t, err)
+	inner, err := io.ReadAll(md.UnverifiedBody)
+	require.NoError(t, err)
+	return msg, string(inner)
+}
+
+func TestEncryptMessage(t *testing.T) {
+	key, armored := testKey(t)
+	trx := (&testtrx.Trx{}).SetHeadersRaw([]byte(testHeaders)).SetBodyBytes([]byte("Hello there\r\n"))
+
+	encrypted, err := encryptMessage(trx, armored)
+	require.NoError(t, err)
+	require.True(t, encrypted)
+
+	msg, inner := decryptMessage(t, trx.Data(), key)
+	// the other fields are kept, the content fields move into the encrypted part
+	assert.Equal(t, "Sender <sender@ext.com>", msg.Header.Get("From"))
+	assert.Equal(t, "Hello", msg.Header.Get("Subject"))
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+	assert.Empty(t, msg.Header.Get("Content-Transfer-Encoding"))
+	assert.Equal(t, "Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\nHello there\r\n", inner)
+}
+
+func TestEncryptMessage_NoContentType(t *testing.T) {
+	key, armored := testKey(t)
+	trx := (&testtrx.Trx{}).SetHeadersRaw([]byte("From: sender@ext.com\r\nSubject: Hello\r\n\r\n")).SetBodyBytes([]byte("Hello\r\n"))
+
+	encrypted, err := encryptMessage(trx, armored)
+	require.NoError(t, err)
+	require.True(t, encrypted)
+
+	_, inner := decryptMessage(t, trx.Data(), key)
+	assert.True(t, strings.HasPrefix(inner, "Content-Type: text/plain; charset=us-ascii\r\n\r\n"))
+}
+
+func TestEncryptMessage_AlreadyEncrypted(t *testing.T) {
+	_, armored := testKey(t)
+	tests := map[string]struct {
+		headers string
+		body    string
+	}{
+		"pgp/mime": {
+			headers: "From: sender@ext.com\r\nContent-Type: multipart/encrypted; protocol=\"application/pgp-encrypted\"; boundary=b\r\n\r\n",
+			body:    "--b\r\n...\r\n--b--\r\n",
+		},
+		"inline": {
+			headers: testHeaders,
+			body:    "-----BEGIN PGP MESSAGE-----\r\n\r\nwcBMA...\r\n-----END PGP MESSAGE-----\r\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			trx := (&testtrx.Trx{}).SetHeadersRaw([]byte(tt.headers)).SetBodyBytes([]byte(tt.body))
+
+			encrypted, err := encryptMessage(trx, armored)
+			require.NoError(t, err)
+			assert.False(t, encrypted)
+			assert.Empty(t, trx.Modifications())
+		})
+	}
+}
+
+func TestAddressRewriter_ForwardChain_ExpiredKey(t *testing.T) {
+	created := time.Now().Add(-48 * time.Hour)
+	entity, err := openpgp.NewEntity("Test", "", "user@gmail.com", &packet.Config{
+		Algorithm:       packet.PubKeyAlgoEdDSA,
+		Time:            func() time.Time { return created },
+		KeyLifetimeSecs: 3600,
+	})
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	cli := chainServer(t, ovooclient.ChainData{
+		FromEmail:     "reply@ovoo.com",
+		ToEmail:       "user@gmail.com",
+		OrigToAddress: ovooclient.ChainAddressData{Email: "alias@ovoo.com", Type: "alias"},
+		PGPKey:        buf.String(),
+	})
+
+	trx := (&testtrx.Trx{}).
+		SetMailFrom(addr.NewMailFrom("sender@ext.com", "", "", "", "")).
+		SetRcptTosList("alias@ovoo.com").
+		SetHeadersRaw([]byte(testHeaders)).
+		SetBodyBytes([]byte("Hello there\r\n"))
+
+	// the mail is held by the MTA rather than forwarded unencrypted
+	decision, outcome, err := addressRewriter(cli, nil, nil)(context.Background(), trx)
+	assert.ErrorContains(t, err, "expired")
+	assert.True(t, mailfilter.TempFail.Equal(decision))
+	assert.Equal(t, outcomeTempFailed, outcome)
+}
+
+func TestAddressRewriter_ForwardChain_Encrypted(t *testing.T) {
+	key, armored := testKey(t)
+	cli := chainServer(t, ovooclient.ChainData{
+		FromEmail:     "reply@ovoo.com",
+		ToEmail:       "user@gmail.com",
+		OrigToAddress: ovooclient.ChainAddressData{Email: "alias@ovoo.com", Type: "alias"},
+		PGPKey:        armored,
+	})
+
+	trx := (&testtrx.Trx{}).
+		SetMailFrom(addr.NewMailFrom("sender@ext.com", "", "", "", "")).
+		SetRcptTosList("alias@ovoo.com").
+		SetHeadersRaw([]byte(testHeaders)).
+		SetBodyBytes([]byte("Hello there\r\n"))
+
+	decision, outcome, err := addressRewriter(cli, nil, nil)(context.Background(), trx)
+	require.NoError(t, err)
+	assert.True(t, mailfilter.Accept.Equal(decision))
+	assert.Equal(t, outcomeEncrypted, outcome)
+
+	// the rewritten addresses stay readable
+	msg, inner := decryptMessage(t, trx.Data(), key)
+	assert.Equal(t, `"Sender" <reply@ovoo.com>`, msg.Header.Get("From"))
+	assert.Equal(t, `"Ovoo Hidden Mail" <alias@ovoo.com>`, msg.Header.Get("To"))
+	assert.Contains(t, inner, "Hello there")
+}
+
+func TestAddressRewriter_ForwardChain_PrivacyFilterEncrypted(t *testing.T) {
+	key, armored := testKey(t)
+	cli := chainServer(t, ovooclient.ChainData{
+		FromEmail:     "reply@ovoo.com",
+		ToEmail:       "user@gmail.com",
+		OrigToAddress: ovooclient.ChainAddressData{Email: "alias@ovoo.com", Type: "alias"},
+		PrivacyFilter: true,
+		PGPKey:        armored,
+	})
+
+	// the body is replaced twice, by the privacy filter and by the encryption
+	trx := &replacingTrx{Trx: (&testtrx.Trx{}).
+		SetMailFrom(addr.NewMailFrom("sender@ext.com", "", "", "", "")).
+		SetRcptTosList("alias@ovoo.com").
+		SetHeadersRaw([]byte("From: Sender <sender@ext.com>\r\nTo: alias@ovoo.com\r\nContent-Type: text/html\r\n\r\n")).
+		SetBodyBytes([]byte("<p>Hello</p><img src=\"https://tracker.example/p.gif\">\r\n"))}
+
+	decision, outcome, err := addressRewriter(cli, newTrackerList([]string{"tracker.example"}), nil)(context.Background(), trx)
+	require.NoError(t, err)
+	assert.True(t, mailfilter.Accept.Equal(decision))
+	assert.Equal(t, outcomeEncrypted, outcome)
+
+	// the filtered body is encrypted, not the received one
+	msg, inner := decryptMessage(t, trx.Data(), key)
+	assert.Equal(t, "images=1 links=0", msg.Header.Get(trackersHeader))
+	assert.Contains(t, inner, "<p>Hello</p>")
+	assert.NotContains(t, inner, "tracker.example")
+}
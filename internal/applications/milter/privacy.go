@@ -0,0 +1,425 @@
+package milter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/d--j/go-milter/mailfilter"
+	"github.com/emersion/go-message/textproto"
+	"golang.org/x/net/html"
+)
+
+// trackersHeader summarizes the trackers removed from the forwarded message
+const trackersHeader = "X-Ovoo-Trackers-Removed"
+
+// defaultTrackerDomains are the domains of the well known email open tracking and click redirect services,
+// the subdomains match as well
+var defaultTrackerDomains = []string{
+	"list-manage.com",     // Mailchimp
+	"mandrillapp.com",     // Mandrill
+	"sendgrid.net",        // SendGrid
+	"sparkpostmail.com",   // SparkPost
+	"awstrack.me",         // Amazon SES
+	"exct.net",            // Salesforce Marketing Cloud
+	"rs6.net",             // Constant Contact
+	"hubspotlinks.com",    // HubSpot
+	"klclick.com",         // Klaviyo
+	"mlsend.com",          // MailerLite
+	"convertkit-mail.com", // ConvertKit
+	"links.iterable.com",  // Iterable
+	"mailtrack.io",
+	"emltrk.com",
+	"bananatag.com",
+	"google-analytics.com",
+	"doubleclick.net",
+}
+
+// trackerList is a set of tracker domains
+type trackerList map[string]struct{}
+
+// newTrackerList returns the default tracker domains extended with the extra ones
+func newTrackerList(extra []string) trackerList {
+	trackers := trackerList{}
+	for _, domain := range slices.Concat(defaultTrackerDomains, extra) {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."); domain != "" {
+			trackers[domain] = struct{}{}
+		}
+	}
+
+	return trackers
+}
+
+// matches reports whether the host is one of the tracker domains or their subdomain
+func (t trackerList) matches(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for host != "" {
+		if _, ok := t[host]; ok {
+			return true
+		}
+
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			return false
+		}
+		host = parent
+	}
+
+	return false
+}
+
+// trackerStats counts the trackers removed from a message
+type trackerStats struct {
+	images int
+	links  int
+}
+
+func (s *trackerStats) add(o trackerStats) {
+	s.images += o.images
+	s.links += o.links
+}
+
+func (s trackerStats) empty() bool {
+	return s.images == 0 && s.links == 0
+}
+
+func (s trackerStats) String() string {
+	return fmt.Sprintf("images=%d links=%d", s.images, s.links)
+}
+
+// stripTrackers removes the tracking images from the HTML parts of the message and points the links through
+// the click tracking services to their targets. Only the changed parts are encoded again, the rest of the MIME tree
+// is kept as it is, signed and encrypted parts are left untouched. The X-Ovoo-Trackers-Removed header summarizes
+// the changes when the message had trackers.
+func stripTrackers(trx mailfilter.Trx, trackers trackerList) (trackerStats, error) {
+	hdr := trx.Headers()
+	body := trx.Body()
+	if body == nil {
+		return trackerStats{}, fmt.Errorf("message body is not available")
+	}
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return trackerStats{}, fmt.Errorf("reading message body: %w", err)
+	}
+
+	var header textproto.Header
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := hdr.UnfoldedValue(key); value != "" {
+			header.Set(key, value)
+		}
+	}
+
+	filtered, stats, err := filterEntity(header, raw, trackers)
+	if err != nil {
+		return trackerStats{}, err
+	}
+
+	if filtered == nil || stats.empty() {
+		return trackerStats{}, nil
+	}
+
+	hdr.Set(trackersHeader, stats.String())
+	trx.ReplaceBody(bytes.NewReader(filtered))
+	return stats, nil
+}
+
+// filterEntity filters the raw body of the MIME entity with the header, it returns nil when the body is unchanged
+func filterEntity(header textproto.Header, body []byte, trackers trackerList) ([]byte, trackerStats, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// plain text is assumed for the entities without a valid type
+		return nil, trackerStats{}, nil
+	}
+
+	switch {
+	case mediaType == "multipart/signed" || mediaType == "multipart/encrypted":
+		return nil, trackerStats{}, nil
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return filterMultipart(body, params["boundary"], trackers)
+	case mediaType == "text/html":
+		return filterHTMLEntity(header, body, params["charset"], trackers)
+	}
+
+	return nil, trackerStats{}, nil
+}
+
+// filterMultipart filters the parts of the multipart body, the unchanged parts are copied as they are
+func filterMultipart(body []byte, boundary string, trackers trackerList) ([]byte, trackerStats, error) {
+	if boundary == "" {
+		return nil, trackerStats{}, fmt.Errorf("multipart entity without boundary")
+	}
+
+	type part struct {
+		header textproto.Header
+		body   []byte
+	}
+
+	var parts []part
+	var stats trackerStats
+	mr := textproto.NewMultipartReader(bytes.NewReader(body), boundary)
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, trackerStats{}, fmt.Errorf("reading multipart entity: %w", err)
+		}
+
+		partBody, err := io.ReadAll(p)
+		if err != nil {
+			return nil, trackerStats{}, fmt.Errorf("reading multipart entity: %w", err)
+		}
+
+		filtered, partStats, err := filterEntity(p.Header, partBody, trackers)
+		if err != nil {
+			return nil, trackerStats{}, err
+		}
+
+		if filtered != nil {
+			partBody = filtered
+			stats.add(partStats)
+		}
+		parts = append(parts, part{header: p.Header, body: partBody})
+	}
+
+	if stats.empty() {
+		return nil, trackerStats{}, nil
+	}
+
+	out := &bytes.Buffer{}
+	for _, p := range parts {
+		out.WriteString("--" + boundary + "\r\n")
+		if err := textproto.WriteHeader(out, p.header); err != nil {
+			return nil, trackerStats{}, err
+		}
+		out.Write(p.body)
+		out.WriteString("\r\n")
+	}
+	out.WriteString("--" + boundary + "--\r\n")
+
+	return out.Bytes(), stats, nil
+}
+
+// filterHTMLEntity filters the HTML document of the entity and encodes it back with the entity transfer encoding.
+// The document is filtered in its own charset, only the ASCII markup is changed, the charsets which are not
+// ASCII compatible are skipped.
+func filterHTMLEntity(header textproto.Header, body []byte, charset string, trackers trackerList) ([]byte, trackerStats, error) {
+	if charset = strings.ToLower(charset); strings.HasPrefix(charset, "utf-16") || strings.HasPrefix(charset, "utf-32") {
+		return nil, trackerStats{}, nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding")))
+	doc, err := decodeBody(encoding, body)
+	if err != nil {
+		return nil, trackerStats{}, fmt.Errorf("decoding html entity: %w", err)
+	}
+
+	filtered, stats := filterHTML(doc, trackers)
+	if stats.empty() {
+		return nil, trackerStats{}, nil
+	}
+
+	return encodeBody(encoding, filtered), stats, nil
+}
+
+// filterHTML removes the tracking images from the document and rewrites the tracking links,
+// the rest of the markup is copied as it is
+func filterHTML(doc []byte, trackers trackerList) ([]byte, trackerStats) {
+	var stats trackerStats
+	out := &bytes.Buffer{}
+	z := html.NewTokenizer(bytes.NewReader(doc))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		// reading the token unescapes the attributes in the tokenizer buffer
+		raw := bytes.Clone(z.Raw())
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			tok := z.Token()
+			switch tok.Data {
+			case "img":
+				if trackingImage(tok, trackers) {
+					stats.images++
+					continue
+				}
+			case "a", "area":
+				if untrackLink(&tok, trackers) {
+					stats.links++
+					out.WriteString(tok.String())
+					continue
+				}
+			}
+		}
+		out.Write(raw)
+	}
+
+	return out.Bytes(), stats
+}
+
+// trackingImage reports whether the image is loaded from a tracker domain, or is a remote image of a pixel size
+func trackingImage(tok html.Token, trackers trackerList) bool {
+	src, err := url.Parse(strings.TrimSpace(tokenAttr(tok, "src")))
+	if err != nil || src.Host == "" || (src.Scheme != "" && src.Scheme != "http" && src.Scheme != "https") {
+		return false
+	}
+
+	if trackers.matches(src.Hostname()) {
+		return true
+	}
+
+	width, height := tokenAttr(tok, "width"), tokenAttr(tok, "height")
+	for _, decl := range strings.Split(tokenAttr(tok, "style"), ";") {
+		prop, value, _ := strings.Cut(decl, ":")
+		switch strings.ToLower(strings.TrimSpace(prop)) {
+		case "width":
+			width = value
+		case "height":
+			height = value
+		}
+	}
+
+	return pixelSize(width) && pixelSize(height)
+}
+
+// pixelSize reports whether the image dimension is one pixel at most
+func pixelSize(value string) bool {
+	value = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "px")
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err == nil && n <= 1
+}
+
+// untrackLink points the link through a tracker domain to its target, if the target is found in the link.
+// It reports whether the link was rewritten.
+func untrackLink(tok *html.Token, trackers trackerList) bool {
+	for i, attr := range tok.Attr {
+		if attr.Namespace != "" || attr.Key != "href" {
+			continue
+		}
+
+		target, ok := linkTarget(attr.Val, trackers)
+		if !ok {
+			return false
+		}
+
+		tok.Attr[i].Val = target
+		return true
+	}
+
+	return false
+}
+
+// maxRedirects limits the redirects through tracker domains followed in a link
+const maxRedirects = 5
+
+// linkTarget returns the target of a link through the tracker domains, the redirects are followed while the target
+// itself points to a tracker domain. The target is looked up in the query parameters and the path segments.
+func linkTarget(href string, trackers trackerList) (string, bool) {
+	target := strings.TrimSpace(href)
+	for range maxRedirects {
+		u, err := url.Parse(target)
+		if err != nil || !trackers.matches(u.Hostname()) {
+			break
+		}
+
+		next, ok := embeddedURL(u)
+		if !ok {
+			break
+		}
+		target = next
+	}
+
+	if target == strings.TrimSpace(href) {
+		return "", false
+	}
+
+	return target, true
+}
+
+// embeddedURL returns the first web address found in the query parameters or the path segments of the address
+func embeddedURL(u *url.URL) (string, bool) {
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		_, value, _ := strings.Cut(param, "=")
+		if value, err := url.QueryUnescape(value); err == nil && webURL(value) {
+			return value, true
+		}
+	}
+
+	for _, segment := range strings.Split(u.EscapedPath(), "/") {
+		if value, err := url.PathUnescape(segment); err == nil && webURL(value) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// webURL reports whether the value is an absolute http or https address
+func webURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// tokenAttr returns the value of the token attribute, empty when it is not set
+func tokenAttr(tok html.Token, key string) string {
+	for _, attr := range tok.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+// decodeBody decodes the entity body from its transfer encoding
+func decodeBody(encoding string, body []byte) ([]byte, error) {
+	switch encoding {
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(bytes.NewReader(body)))
+	case "base64":
+		// the decoder skips the line breaks, the other whitespace is removed first
+		clean := bytes.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, body)
+		return io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(clean)))
+	case "", "7bit", "8bit", "binary":
+		return body, nil
+	}
+
+	return nil, fmt.Errorf("unknown transfer encoding %q", encoding)
+}
+
+// encodeBody encodes the entity body with the transfer encoding, base64 in lines of 76 characters
+func encodeBody(encoding string, body []byte) []byte {
+	out := &bytes.Buffer{}
+	switch encoding {
+	case "quoted-printable":
+		w := quotedprintable.NewWriter(out)
+		w.Write(body)
+		w.Close()
+	case "base64":
+		encoded := base64.StdEncoding.EncodeToString(body)
+		for len(encoded) > 76 {
+			out.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		out.WriteString(encoded + "\r\n")
+	default:
+		return body
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,243 @@
+package milter
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/d--j/go-milter/mailfilter"
+	"github.com/d--j/go-milter/mailfilter/addr"
+	"github.com/d--j/go-milter/mailfilter/testtrx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+)
+
+const trackedHTML = `<html><body><p class="x">Hello &amp; welcome</p>` +
+	`<img src="https://cdn.shop.com/logo.png" width="120" height="40">` +
+	`<img src="https://shop.com/open?id=1" width="1" height="1" alt="">` +
+	`<img src="https://shop.com/o.gif" style="width: 0px; height:1px; border:0">` +
+	`<img src="https://shop.us1.list-manage.com/track/open.php?u=1" />` +
+	`<img src="cid:logo@shop" width="1" height="1">` +
+	`<a href="https://shop.us1.list-manage.com/track/click?u=1&amp;url=https%3A%2F%2Fshop.com%2Fsale%3Fa%3D1%26b%3D2">Sale</a>` +
+	`<a href="https://shop.com/redirect?url=https%3A%2F%2Fother.com%2F">Other</a>` +
+	`<a href="https://sendgrid.net/ls/click?upn=opaque">Opaque</a>` +
+	`</body></html>`
+
+func TestTrackerList_Matches(t *testing.T) {
+	trackers := newTrackerList([]string{" Tracker.Example. "})
+
+	assert.True(t, trackers.matches("list-manage.com"))
+	assert.True(t, trackers.matches("shop.us1.list-manage.com"))
+	assert.True(t, trackers.matches("tracker.example"))
+	assert.True(t, trackers.matches("a.tracker.example."))
+	assert.False(t, trackers.matches("example"))
+	assert.False(t, trackers.matches("notlist-manage.com"))
+	assert.False(t, trackers.matches(""))
+}
+
+func TestFilterHTML(t *testing.T) {
+	filtered, stats := filterHTML([]byte(trackedHTML), newTrackerList(nil))
+	doc := string(filtered)
+
+	assert.Equal(t, trackerStats{images: 3, links: 1}, stats)
+	// the untouched markup is copied as it is
+	assert.Contains(t, doc, `<p class="x">Hello &amp; welcome</p>`)
+	assert.Contains(t, doc, `<img src="https://cdn.shop.com/logo.png" width="120" height="40">`)
+	assert.Contains(t, doc, `<img src="cid:logo@shop" width="1" height="1">`)
+	assert.NotContains(t, doc, "shop.com/open")
+	assert.NotContains(t, doc, "o.gif")
+	assert.NotContains(t, doc, "open.php")
+	// only the links through the tracker domains with a target are rewritten
+	assert.Contains(t, doc, `<a href="https://shop.com/sale?a=1&amp;b=2">Sale</a>`)
+	assert.Contains(t, doc, `<a href="https://shop.com/redirect?url=https%3A%2F%2Fother.com%2F">Other</a>`)
+	assert.Contains(t, doc, `<a href="https://sendgrid.net/ls/click?upn=opaque">Opaque</a>`)
+}
+
+func TestLinkTarget(t *testing.T) {
+	trackers := newTrackerList(nil)
+	tests := map[string]struct {
+		href   string
+		target string
+		ok     bool
+	}{
+		"query": {
+			href:   "https://click.exct.net/?qs=abc&u=https%3A%2F%2Fshop.com%2F",
+			target: "https://shop.com/",
+			ok:     true,
+		},
+		"path segment": {
+			href:   "https://abc.r.us-east-1.awstrack.me/L0/https:%2F%2Fshop.com%2Fitem%3Fid=2/1/0100",
+			target: "https://shop.com/item?id=2",
+			ok:     true,
+		},
+		"nested redirects": {
+			href:   "https://r20.rs6.net/tn.jsp?t=1&l=https%3A%2F%2Fmailtrack.io%2Ftrace%3Furl%3Dhttps%253A%252F%252Fshop.com%252F",
+			target: "https://shop.com/",
+			ok:     true,
+		},
+		"no target":   {href: "https://sendgrid.net/ls/click?upn=opaque"},
+		"not tracker": {href: "https://shop.com/?url=https%3A%2F%2Fother.com%2F"},
+		"mailto":      {href: "mailto:someone@list-manage.com"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			target, ok := linkTarget(tt.href, trackers)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.target, target)
+		})
+	}
+}
+
+func TestStripTrackers_Multipart(t *testing.T) {
+	var qp strings.Builder
+	w := quotedprintable.NewWriter(&qp)
+	_, err := w.Write([]byte(trackedHTML))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	textPart := "Content-Type: text/plain; charset=utf-8\r\n\r\nHello & welcome\r\n"
+	body := "This is a multi-part message in MIME format.\r\n" +
+		"--outer\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"inner\"\r\n\r\n" +
+		"--inner\r\n" + textPart +
+		"--inner\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n\r\n" +
+		qp.String() + "\r\n" +
+		"--inner--\r\n" +
+		"--outer\r\n" +
+		"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" +
+		"JVBERi0xLjQK\r\n" +
+		"--outer--\r\n"
+	headers := "From: sender@ext.com\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=\"outer\"\r\n\r\n"
+	trx := (&testtrx.Trx{}).SetHeadersRaw([]byte(headers)).SetBodyBytes([]byte(body))
+
+	stats, err := stripTrackers(trx, newTrackerList(nil))
+	require.NoError(t, err)
+	assert.Equal(t, trackerStats{images: 3, links: 1}, stats)
+
+	msg, err := mail.ReadMessage(trx.Data())
+	require.NoError(t, err)
+	assert.Equal(t, "images=3 links=1", msg.Header.Get(trackersHeader))
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	outer := multipart.NewReader(msg.Body, params["boundary"])
+
+	alternative, err := outer.NextPart()
+	require.NoError(t, err)
+	_, params, err = mime.ParseMediaType(alternative.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	inner := multipart.NewReader(alternative, params["boundary"])
+
+	text, err := inner.NextPart()
+	require.NoError(t, err)
+	textBody, err := io.ReadAll(text)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello & welcome", string(textBody))
+
+	// the multipart reader decodes the quoted-printable part
+	html, err := inner.NextPart()
+	require.NoError(t, err)
+	htmlBody, err := io.ReadAll(html)
+	require.NoError(t, err)
+	assert.Contains(t, string(htmlBody), `<a href="https://shop.com/sale?a=1&amp;b=2">Sale</a>`)
+	assert.NotContains(t, string(htmlBody), "open.php")
+
+	attachment, err := outer.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "base64", attachment.Header.Get("Content-Transfer-Encoding"))
+	pdf, err := io.ReadAll(attachment)
+	require.NoError(t, err)
+	assert.Equal(t, "JVBERi0xLjQK", string(pdf))
+}
+
+func TestStripTrackers_Base64(t *testing.T) {
+	headers := "From: sender@ext.com\r\nContent-Type: text/html; charset=iso-8859-1\r\nContent-Transfer-Encoding: base64\r\n\r\n"
+	// the document is filtered in its own charset
+	doc := "<p>Caf\xe9</p><img src=\"https://shop.com/p.gif\" width=1 height=1>"
+	trx := (&testtrx.Trx{}).
+		SetHeadersRaw([]byte(headers)).
+		SetBodyBytes([]byte(base64.StdEncoding.EncodeToString([]byte(doc)) + "\r\n"))
+
+	stats, err := stripTrackers(trx, newTrackerList(nil))
+	require.NoError(t, err)
+	assert.Equal(t, trackerStats{images: 1}, stats)
+
+	msg, err := mail.ReadMessage(trx.Data())
+	require.NoError(t, err)
+	assert.Equal(t, "images=1 links=0", msg.Header.Get(trackersHeader))
+	encoded, err := io.ReadAll(msg.Body)
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Caf\xe9</p>", string(decoded))
+}
+
+func TestStripTrackers_Untouched(t *testing.T) {
+	tests := map[string]struct {
+		headers string
+		body    string
+	}{
+		"no trackers": {
+			headers: "Content-Type: text/html\r\n\r\n",
+			body:    "<p>Hello</p><img src=\"https://shop.com/logo.png\">\r\n",
+		},
+		"plain text": {
+			headers: testHeaders,
+			body:    "<img src=\"https://shop.com/p.gif\" width=1 height=1>\r\n",
+		},
+		"signed": {
+			headers: "Content-Type: multipart/signed; protocol=\"application/pgp-signature\"; boundary=b\r\n\r\n",
+			body:    "--b\r\nContent-Type: text/html\r\n\r\n<img src=\"https://shop.com/p.gif\" width=1 height=1>\r\n--b--\r\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			trx := (&testtrx.Trx{}).SetHeadersRaw([]byte(tt.headers)).SetBodyBytes([]byte(tt.body))
+
+			stats, err := stripTrackers(trx, newTrackerList(nil))
+			require.NoError(t, err)
+			assert.True(t, stats.empty())
+			assert.Empty(t, trx.Modifications())
+		})
+	}
+}
+
+func TestAddressRewriter_ForwardChain_PrivacyFilter(t *testing.T) {
+	cli := chainServer(t, ovooclient.ChainData{
+		FromEmail:     "reply@ovoo.com",
+		ToEmail:       "user@gmail.com",
+		OrigToAddress: ovooclient.ChainAddressData{Email: "alias@ovoo.com", Type: "alias"},
+		PrivacyFilter: true,
+	})
+
+	trx := (&testtrx.Trx{}).
+		SetMailFrom(addr.NewMailFrom("sender@ext.com", "", "", "", "")).
+		SetRcptTosList("alias@ovoo.com").
+		SetHeadersRaw([]byte("From: Sender <sender@ext.com>\r\nTo: alias@ovoo.com\r\nContent-Type: text/html\r\n\r\n")).
+		SetBodyBytes([]byte("<p>Hello</p><img src=\"https://tracker.example/p.gif\">\r\n"))
+
+	decision, outcome, err := addressRewriter(cli, newTrackerList([]string{"tracker.example"}), nil)(context.Background(), trx)
+	require.NoError(t, err)
+	assert.True(t, mailfilter.Accept.Equal(decision))
+	assert.Equal(t, outcomeRewritten, outcome)
+
+	msg, err := mail.ReadMessage(trx.Data())
+	require.NoError(t, err)
+	assert.Equal(t, "images=1 links=0", msg.Header.Get(trackersHeader))
+	body, err := io.ReadAll(msg.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Hello</p>\r\n", string(body))
+}
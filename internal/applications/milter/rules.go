@@ -0,0 +1,110 @@
+package milter
+
+import (
+	"io"
+	"log/slog"
+	"mime"
+	"net/textproto"
+	"strings"
+
+	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+	"github.com/Burmuley/ovoo/internal/sieve"
+	"github.com/d--j/go-milter/mailfilter"
+)
+
+// ruleMessage is the message of the transaction the rules run on
+type ruleMessage struct {
+	trx  mailfilter.Trx
+	size int64
+}
+
+// Header returns the raw values of the header fields with the name which are not deleted
+func (m *ruleMessage) Header(name string) []string {
+	key := textproto.CanonicalMIMEHeaderKey(name)
+	var values []string
+	for fields := m.trx.Headers().Fields(); fields.Next(); {
+		if !fields.IsDeleted() && fields.CanonicalKey() == key {
+			values = append(values, fields.Value())
+		}
+	}
+
+	return values
+}
+
+// Size returns the size of the header and the body of the message, it is read once
+func (m *ruleMessage) Size() int64 {
+	if m.size >= 0 {
+		return m.size
+	}
+
+	m.size, _ = io.Copy(io.Discard, m.trx.Headers().Reader())
+	if body := m.trx.Body(); body != nil {
+		if n, err := body.Seek(0, io.SeekEnd); err == nil {
+			m.size += n
+		}
+		_, _ = body.Seek(0, io.SeekStart)
+	}
+
+	return m.size
+}
+
+// filterMessage runs the rules of the chain on the message and adds the header fields set by them.
+// A redirect replaces the protected address the chain forwards the mail to, it is only followed to the
+// addresses resolved by the API and ignored for the mail held in the quarantine or posted to a webhook.
+// Failing rules keep the message as it is. It reports whether the message is delivered at all.
+func filterMessage(trx mailfilter.Trx, chain *ovooclient.ChainData) bool {
+	script, err := sieve.Parse(chain.Rules)
+	if err != nil {
+		slog.Warn("forwarding message without rules", "hash", chain.Hash, "error", err.Error())
+		return true
+	}
+
+	result, err := script.Run(&ruleMessage{trx: trx, size: -1})
+	if err != nil {
+		slog.Warn("forwarding message without rules", "hash", chain.Hash, "error", err.Error())
+		return true
+	}
+
+	if result.Discarded() {
+		return false
+	}
+
+	if result.Redirect != "" {
+		redirectMessage(chain, result.Redirect)
+	}
+
+	hdr := trx.Headers()
+	for _, field := range result.Headers {
+		value := mime.QEncoding.Encode("utf-8", field.Value)
+		if fields := hdr.Fields(); !field.Last && fields.Next() {
+			fields.InsertBefore(field.Name, value)
+			continue
+		}
+		hdr.Add(field.Name, value)
+	}
+
+	return true
+}
+
+// redirectMessage forwards the mail of the chain to the protected address the rules redirect it to
+func redirectMessage(chain *ovooclient.ChainData, email string) {
+	if chain.Quarantine {
+		slog.Warn("ignoring redirect of held message", "hash", chain.Hash, "redirect", email)
+		return
+	}
+
+	if chain.Webhook != nil {
+		slog.Warn("ignoring redirect of webhook message", "hash", chain.Hash, "redirect", email)
+		return
+	}
+
+	for _, target := range chain.Redirects {
+		if strings.EqualFold(target.Email, email) {
+			chain.ToEmail = target.Email
+			chain.PGPKey = target.PGPKey
+			return
+		}
+	}
+
+	slog.Warn("ignoring redirect to unknown protected address", "hash", chain.Hash, "redirect", email)
+}
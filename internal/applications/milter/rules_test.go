@@ -0,0 +1,166 @@
+package milter
+
+import (
+	"context"
+	"mime"
+	"net/mail"
+	"testing"
+
+	"github.com/d--j/go-milter/mailfilter"
+	"github.com/d--j/go-milter/mailfilter/addr"
+	"github.com/d--j/go-milter/mailfilter/testtrx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+)
+
+// rulesTrx returns a transaction of a message from the shop to the alias
+func rulesTrx() *testtrx.Trx {
+	return (&testtrx.Trx{}).
+		SetMailFrom(addr.NewMailFrom("news@shop.example", "", "", "", "")).
+		SetRcptTosList("alias@ovoo.com").
+		SetHeadersRaw([]byte("From: Shop <news@shop.example>\r\nTo: alias@ovoo.com\r\nSubject: Big sale\r\n\r\n")).
+		SetBodyBytes([]byte("Hello there\r\n"))
+}
+
+func rulesChain(rules string) ovooclient.ChainData {
+	return ovooclient.ChainData{
+		Hash:          "h1",
+		FromEmail:     "reply@ovoo.com",
+		ToEmail:       "user@gmail.com",
+		OrigToAddress: ovooclient.ChainAddressData{Email: "alias@ovoo.com", Type: "alias"},
+		Rules:         rules,
+		Redirects:     []ovooclient.ChainRedirectData{{Email: "work@gmail.com"}},
+	}
+}
+
+func rcptAddrs(trx *testtrx.Trx) []string {
+	var rcpts []string
+	for _, rcpt := range trx.RcptTos() {
+		rcpts = append(rcpts, rcpt.Addr)
+	}
+
+	return rcpts
+}
+
+func TestAddressRewriter_Rules(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        string
+		wantDecision mailfilter.Decision
+		wantOutcome  string
+		wantRcpt     string
+	}{
+		{
+			name:         "discarded",
+			rules:        `if header :contains "subject" "sale" { discard; }`,
+			wantDecision: mailfilter.Discard,
+			wantOutcome:  outcomeDiscarded,
+		},
+		{
+			name:         "not matching",
+			rules:        `if address :domain "from" "other.example" { discard; }`,
+			wantDecision: mailfilter.Accept,
+			wantOutcome:  outcomeRewritten,
+			wantRcpt:     "user@gmail.com",
+		},
+		{
+			name:         "redirected",
+			rules:        `if address :domain "from" "shop.example" { redirect "Work@gmail.com"; }`,
+			wantDecision: mailfilter.Accept,
+			wantOutcome:  outcomeRewritten,
+			wantRcpt:     "work@gmail.com",
+		},
+		{
+			name:         "redirect to unknown address",
+			rules:        `redirect "other@gmail.com";`,
+			wantDecision: mailfilter.Accept,
+			wantOutcome:  outcomeRewritten,
+			wantRcpt:     "user@gmail.com",
+		},
+		{
+			name:         "invalid rules",
+			rules:        `fileinto "Shop";`,
+			wantDecision: mailfilter.Accept,
+			wantOutcome:  outcomeRewritten,
+			wantRcpt:     "user@gmail.com",
+		},
+		{
+			name:         "failing rules",
+			rules:        `redirect "work@gmail.com"; redirect "other@gmail.com";`,
+			wantDecision: mailfilter.Accept,
+			wantOutcome:  outcomeRewritten,
+			wantRcpt:     "user@gmail.com",
+		},
+		{
+			name:         "small message",
+			rules:        `if size :under 1K { discard; }`,
+			wantDecision: mailfilter.Discard,
+			wantOutcome:  outcomeDiscarded,
+		},
+		{
+			name:         "large message",
+			rules:        `if size :over 1K { discard; }`,
+			wantDecision: mailfilter.Accept,
+			wantOutcome:  outcomeRewritten,
+			wantRcpt:     "user@gmail.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := chainServer(t, rulesChain(tt.rules))
+			trx := rulesTrx()
+
+			decision, outcome, err := addressRewriter(cli, nil, nil)(context.Background(), trx)
+			require.NoError(t, err)
+			assert.True(t, tt.wantDecision.Equal(decision))
+			assert.Equal(t, tt.wantOutcome, outcome)
+			if tt.wantRcpt != "" {
+				assert.Equal(t, []string{tt.wantRcpt}, rcptAddrs(trx))
+			}
+		})
+	}
+}
+
+func TestAddressRewriter_Rules_AddHeader(t *testing.T) {
+	cli := chainServer(t, rulesChain(`require "editheader";
+		addheader "X-Tag" "shop";
+		addheader "X-Tag" "sale";
+		addheader :last "X-Filtered" "größe";`))
+	trx := rulesTrx()
+
+	decision, _, err := addressRewriter(cli, nil, nil)(context.Background(), trx)
+	require.NoError(t, err)
+	assert.True(t, mailfilter.Accept.Equal(decision))
+
+	msg, err := mail.ReadMessage(trx.Data())
+	require.NoError(t, err)
+	// the fields added on top come in reverse order
+	assert.Equal(t, []string{"sale", "shop"}, msg.Header["X-Tag"])
+	filtered, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("X-Filtered"))
+	require.NoError(t, err)
+	assert.Equal(t, "größe", filtered)
+
+	var keys []string
+	for fields := trx.Headers().Fields(); fields.Next(); {
+		keys = append(keys, fields.CanonicalKey())
+	}
+	assert.Equal(t, "X-Tag", keys[0])
+	assert.Equal(t, "X-Filtered", keys[len(keys)-1])
+}
+
+// Held mail is stored for the protected address of the chain, the redirect is ignored.
+func TestAddressRewriter_Rules_HeldIgnoresRedirect(t *testing.T) {
+	chain := rulesChain(`redirect "work@gmail.com";`)
+	chain.Quarantine = true
+	cli := chainServer(t, chain)
+	trx := rulesTrx()
+
+	decision, outcome, err := addressRewriter(cli, nil, nil)(context.Background(), trx)
+	require.NoError(t, err)
+	assert.True(t, mailfilter.Discard.Equal(decision))
+	assert.Equal(t, outcomeQuarantined, outcome)
+	assert.Equal(t, []string{"user@gmail.com"}, rcptAddrs(trx))
+}
@@ -0,0 +1,243 @@
+package milter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+	"github.com/d--j/go-milter/mailfilter"
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+)
+
+const (
+	// webhookSignatureHeader carries the timestamp and the HMAC-SHA256 signature of the webhook request
+	webhookSignatureHeader = "X-Ovoo-Signature"
+	defaultWebhookTimeout  = 10 * time.Second
+)
+
+// errWebhookAddress is returned when the webhook host resolves to an address the milter does not post to
+var errWebhookAddress = errors.New("webhook address is not allowed")
+
+// WebhookConfig configures the delivery of the mail of the aliases to their webhooks
+type WebhookConfig struct {
+	// Timeout of a webhook request, 10 seconds when not set
+	Timeout time.Duration
+	// AllowPrivateNetworks allows the webhooks on loopback, private and link-local addresses
+	AllowPrivateNetworks bool
+}
+
+// webhookSender posts the messages to the webhooks
+type webhookSender struct {
+	client *http.Client
+}
+
+// newWebhookSender returns the sender of the webhook requests. The requests are not redirected and
+// are not sent through a proxy, so that only the addresses checked by the dialer are reached.
+func newWebhookSender(cfg WebhookConfig) *webhookSender {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultWebhookTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = publicAddressOnly
+	}
+
+	return &webhookSender{
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: cfg.Timeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// publicAddressOnly rejects the connections to the addresses which are not public unicast ones
+func publicAddressOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if ip = ip.Unmap(); !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return fmt.Errorf("%w: %s", errWebhookAddress, ip)
+	}
+
+	return nil
+}
+
+// webhookHeader is a header field of the message posted to a webhook
+type webhookHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// webhookAttachment is a part of the message posted to a webhook which is not its text,
+// the content is base64 encoded
+type webhookAttachment struct {
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// webhookPayload is the message posted to a webhook
+type webhookPayload struct {
+	Hash        string              `json:"hash"`
+	Alias       string              `json:"alias"`
+	MailFrom    string              `json:"mail_from"`
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	Subject     string              `json:"subject"`
+	Date        string              `json:"date,omitempty"`
+	Headers     []webhookHeader     `json:"headers"`
+	Text        string              `json:"text,omitempty"`
+	HTML        string              `json:"html,omitempty"`
+	Attachments []webhookAttachment `json:"attachments"`
+}
+
+// newWebhookPayload serializes the message of the transaction, the first plain text and HTML parts
+// are its text and all other parts are attachments. The text of parts in unknown charsets is posted as it is.
+func newWebhookPayload(trx mailfilter.Trx, chain *ovooclient.ChainData) (webhookPayload, error) {
+	payload := webhookPayload{
+		Hash:        chain.Hash,
+		Alias:       chain.OrigToAddress.Email,
+		MailFrom:    trx.MailFrom().Addr,
+		Headers:     []webhookHeader{},
+		Attachments: []webhookAttachment{},
+	}
+
+	for fields := trx.Headers().Fields(); fields.Next(); {
+		if !fields.IsDeleted() {
+			payload.Headers = append(payload.Headers, webhookHeader{Name: fields.Key(), Value: strings.TrimSpace(fields.UnfoldedValue())})
+		}
+	}
+
+	mr, err := mail.CreateReader(trx.Data())
+	if err != nil && !message.IsUnknownCharset(err) {
+		return webhookPayload{}, fmt.Errorf("parsing message: %w", err)
+	}
+
+	// the fields are posted as they are when their encoded words can not be decoded
+	payload.Date = mr.Header.Get("Date")
+	for field, value := range map[string]*string{"From": &payload.From, "To": &payload.To, "Subject": &payload.Subject} {
+		if *value, err = mr.Header.Text(field); err != nil {
+			*value = mr.Header.Get(field)
+		}
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !message.IsUnknownCharset(err) {
+			return webhookPayload{}, fmt.Errorf("parsing message: %w", err)
+		}
+
+		content, err := io.ReadAll(part.Body)
+		if err != nil {
+			return webhookPayload{}, fmt.Errorf("reading message part: %w", err)
+		}
+
+		switch h := part.Header.(type) {
+		case *mail.InlineHeader:
+			mediaType, _, _ := h.ContentType()
+			switch {
+			case (mediaType == "" || mediaType == "text/plain") && payload.Text == "":
+				payload.Text = string(content)
+				continue
+			case mediaType == "text/html" && payload.HTML == "":
+				payload.HTML = string(content)
+				continue
+			}
+
+			payload.Attachments = append(payload.Attachments, webhookAttachment{ContentType: mediaType, Content: content})
+		case *mail.AttachmentHeader:
+			mediaType, _, _ := h.ContentType()
+			filename, _ := h.Filename()
+			payload.Attachments = append(payload.Attachments, webhookAttachment{Filename: filename, ContentType: mediaType, Content: content})
+		}
+	}
+
+	return payload, nil
+}
+
+// webhookSignature returns the signature header value of the webhook request body sent at the time,
+// the HMAC-SHA256 of the unix timestamp and the body joined with a dot
+func webhookSignature(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts the message to the webhook of the chain and discards its delivery by the MTA. The message
+// is failed temporarily while the webhook is unreachable or responds with a server error, so that the MTA
+// retries it later, and rejected when the webhook refuses it.
+func (s *webhookSender) deliver(ctx context.Context, trx mailfilter.Trx, chain *ovooclient.ChainData) (mailfilter.Decision, string, error) {
+	payload, err := newWebhookPayload(trx, chain)
+	if err != nil {
+		return mailfilter.Reject, outcomeRejected, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return mailfilter.Reject, outcomeRejected, fmt.Errorf("encoding webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, chain.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return mailfilter.Reject, outcomeRejected, fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Ovoo-Webhook")
+	req.Header.Set(webhookSignatureHeader, webhookSignature(chain.Webhook.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if errors.Is(err, errWebhookAddress) {
+		return mailfilter.Reject, outcomeRejected, fmt.Errorf("posting to webhook: %w", err)
+	}
+	if err != nil {
+		return mailfilter.TempFail, outcomeTempFailed, fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return mailfilter.Discard, outcomeWebhook, nil
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusRequestTimeout:
+		return mailfilter.TempFail, outcomeTempFailed, fmt.Errorf("posting to webhook: unexpected status %d", resp.StatusCode)
+	default:
+		return mailfilter.Reject, outcomeRejected, fmt.Errorf("posting to webhook: unexpected status %d", resp.StatusCode)
+	}
+}
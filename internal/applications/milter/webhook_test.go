@@ -0,0 +1,189 @@
+package milter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d--j/go-milter/mailfilter"
+	"github.com/d--j/go-milter/mailfilter/addr"
+	"github.com/d--j/go-milter/mailfilter/testtrx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+)
+
+// webhookBody is a multipart message body with a text, an HTML and an attachment part
+const webhookBody = "--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+	"Hello there\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n\r\n" +
+	"<p>Hello there</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"Content-Disposition: attachment; filename=invoice.pdf\r\n" +
+	"Content-Transfer-Encoding: base64\r\n\r\n" +
+	"JVBERi0=\r\n" +
+	"--outer--\r\n"
+
+// webhookTrx returns a transaction of a multipart message from the shop to the alias
+func webhookTrx() *testtrx.Trx {
+	return (&testtrx.Trx{}).
+		SetMailFrom(addr.NewMailFrom("bounce@shop.example", "", "", "", "")).
+		SetRcptTosList("alias@ovoo.com").
+		SetHeadersRaw([]byte("From: Shop <news@shop.example>\r\nTo: alias@ovoo.com\r\nSubject: =?utf-8?q?Gro=C3=9Fe_Sale?=\r\nMIME-Version: 1.0\r\n" +
+			"Content-Type: multipart/mixed; boundary=outer\r\n\r\n")).
+		SetBodyBytes([]byte(webhookBody))
+}
+
+func webhookChain(url string) ovooclient.ChainData {
+	return ovooclient.ChainData{
+		Hash:          "h1",
+		FromEmail:     "reply@ovoo.com",
+		OrigToAddress: ovooclient.ChainAddressData{Email: "alias@ovoo.com", Type: "alias"},
+		Webhook:       &ovooclient.WebhookData{URL: url, Secret: "secret"},
+	}
+}
+
+// webhookServer creates an httptest.Server responding with the status and passing the requests it receives
+func webhookServer(t *testing.T, status int) (*httptest.Server, <-chan *http.Request) {
+	t.Helper()
+	reqs := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		reqs <- r
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, reqs
+}
+
+func TestAddressRewriter_Webhook(t *testing.T) {
+	srv, reqs := webhookServer(t, http.StatusNoContent)
+	cli := chainServer(t, webhookChain(srv.URL+"/mail"))
+	trx := webhookTrx()
+
+	decision, outcome, err := addressRewriter(cli, nil, newWebhookSender(WebhookConfig{AllowPrivateNetworks: true}))(context.Background(), trx)
+	require.NoError(t, err)
+	assert.Equal(t, mailfilter.Discard, decision)
+	assert.Equal(t, outcomeWebhook, outcome)
+	// the message is not rewritten
+	assert.Equal(t, []string{"alias@ovoo.com"}, rcptAddrs(trx))
+
+	req := <-reqs
+	assert.Equal(t, "/mail", req.URL.Path)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+
+	// the signature is the HMAC of the timestamp and the body
+	ts, sig, ok := strings.Cut(req.Header.Get(webhookSignatureHeader), ",v1=")
+	require.True(t, ok)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(strings.TrimPrefix(ts, "t=") + "."))
+	mac.Write(body)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), sig)
+
+	payload := webhookPayload{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "h1", payload.Hash)
+	assert.Equal(t, "alias@ovoo.com", payload.Alias)
+	assert.Equal(t, "bounce@shop.example", payload.MailFrom)
+	assert.Equal(t, "Große Sale", payload.Subject)
+	assert.Equal(t, "Shop <news@shop.example>", payload.From)
+	assert.Contains(t, payload.Headers, webhookHeader{Name: "To", Value: "alias@ovoo.com"})
+	assert.Equal(t, "Hello there", payload.Text)
+	assert.Equal(t, "<p>Hello there</p>", payload.HTML)
+	require.Len(t, payload.Attachments, 1)
+	assert.Equal(t, webhookAttachment{Filename: "invoice.pdf", ContentType: "application/pdf", Content: []byte("%PDF-")}, payload.Attachments[0])
+}
+
+func TestAddressRewriter_Webhook_Failures(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantDecision mailfilter.Decision
+		wantOutcome  string
+	}{
+		{name: "server error", status: http.StatusBadGateway, wantDecision: mailfilter.TempFail, wantOutcome: outcomeTempFailed},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantDecision: mailfilter.TempFail, wantOutcome: outcomeTempFailed},
+		{name: "refused", status: http.StatusForbidden, wantDecision: mailfilter.Reject, wantOutcome: outcomeRejected},
+		{name: "redirected", status: http.StatusFound, wantDecision: mailfilter.Reject, wantOutcome: outcomeRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := webhookServer(t, tt.status)
+			cli := chainServer(t, webhookChain(srv.URL))
+
+			decision, outcome, err := addressRewriter(cli, nil, newWebhookSender(WebhookConfig{AllowPrivateNetworks: true}))(context.Background(), webhookTrx())
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantDecision, decision)
+			assert.Equal(t, tt.wantOutcome, outcome)
+		})
+	}
+}
+
+// A webhook which can not be reached is retried later.
+func TestAddressRewriter_Webhook_Unreachable(t *testing.T) {
+	srv, _ := webhookServer(t, http.StatusOK)
+	srv.Close()
+	cli := chainServer(t, webhookChain(srv.URL))
+
+	decision, outcome, err := addressRewriter(cli, nil, newWebhookSender(WebhookConfig{AllowPrivateNetworks: true, Timeout: time.Second}))(context.Background(), webhookTrx())
+	assert.Error(t, err)
+	assert.Equal(t, mailfilter.TempFail, decision)
+	assert.Equal(t, outcomeTempFailed, outcome)
+}
+
+// The webhooks on the private networks are rejected unless allowed.
+func TestAddressRewriter_Webhook_PrivateNetwork(t *testing.T) {
+	srv, reqs := webhookServer(t, http.StatusOK)
+	cli := chainServer(t, webhookChain(srv.URL))
+
+	decision, outcome, err := addressRewriter(cli, nil, newWebhookSender(WebhookConfig{}))(context.Background(), webhookTrx())
+	assert.ErrorIs(t, err, errWebhookAddress)
+	assert.Equal(t, mailfilter.Reject, decision)
+	assert.Equal(t, outcomeRejected, outcome)
+	assert.Empty(t, reqs)
+}
+
+func TestPublicAddressOnly(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34:443":      true,
+		"[2606:2800:220::1]:443": true,
+		"127.0.0.1:80":           false,
+		"10.1.2.3:80":            false,
+		"192.168.1.1:80":         false,
+		"169.254.169.254:80":     false,
+		"0.0.0.0:80":             false,
+		"[::1]:80":               false,
+		"[fd00::1]:80":           false,
+		"[::ffff:127.0.0.1]:80":  false,
+	}
+
+	for address, allowed := range tests {
+		t.Run(address, func(t *testing.T) {
+			err := publicAddressOnly("tcp", address, nil)
+			if allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errWebhookAddress)
+			}
+		})
+	}
+}
@@ -5,33 +5,41 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Burmuley/ovoo/internal/cache"
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/metrics"
+	"github.com/Burmuley/ovoo/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	domainCacheTTL = 5 * time.Minute
+	// minimal interval between checks of the token file for changes
+	tokenFileCheckInterval = 10 * time.Second
+	// cache keys of the active domains list and of the known domain names
+	domainsCacheKey      = "ovooclient:domains"
+	domainsStaleCacheKey = "ovooclient:domains:stale"
+	domainNameKeyPrefix  = "ovooclient:domain_name:"
+	// the reputation of the senders is cached briefly, the MTA looks up every message
+	senderCacheTTL       = time.Minute
+	senderCacheKeyPrefix = "ovooclient:sender:"
 )
 
-// in-memory cache for domains value
-var domainCache sync.Map
-
-type cachedActiveDomainsInfo struct {
-	domains   []string
-	expiresAt time.Time
-}
-
-type cachedDomainNameInfo struct {
-	domain_name string
-	expiresAt   time.Time
-}
-
 type PaginationMetadata struct {
 	CurrentPage  int `json:"current_page"`
 	FirstPage    int `json:"first_page"`
@@ -61,6 +69,31 @@ type ChainData struct {
 	ToEmail         string           `json:"to_email"`
 	OrigFromAddress ChainAddressData `json:"orig_from_address"`
 	OrigToAddress   ChainAddressData `json:"orig_to_address"`
+	// PGPKey is the armored public key of the protected address the mail is forwarded to, if it has one
+	PGPKey string `json:"pgp_key,omitempty"`
+	// PrivacyFilter is set when the trackers are stripped from the mail forwarded by the alias
+	PrivacyFilter bool `json:"privacy_filter,omitempty"`
+	// Quarantine is set when the mail of the chain is held in the quarantine instead of being forwarded
+	Quarantine bool `json:"quarantine,omitempty"`
+	// Rules is the rules script run on the mail of the chain before it is rewritten, if there is one
+	Rules string `json:"rules,omitempty"`
+	// Redirects are the protected addresses the rules can redirect the mail to
+	Redirects []ChainRedirectData `json:"redirects,omitempty"`
+	// Webhook is the HTTP endpoint the mail of the alias is posted to instead of a protected address
+	Webhook *WebhookData `json:"webhook,omitempty"`
+}
+
+// ChainRedirectData is a protected address the rules of a chain can redirect the mail to
+type ChainRedirectData struct {
+	Email string `json:"email"`
+	// PGPKey is the armored public key of the protected address, if it has one
+	PGPKey string `json:"pgp_key,omitempty"`
+}
+
+// WebhookData is the webhook endpoint of a chain and the secret its requests are signed with
+type WebhookData struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
 }
 
 type ChainCreateRequestBody struct {
@@ -68,6 +101,19 @@ type ChainCreateRequestBody struct {
 	ToEmail   string `json:"to_email"`
 }
 
+type HoldMessageRequestBody struct {
+	Hash    string `json:"hash"`
+	Message []byte `json:"message"`
+}
+
+// SenderLookupData is the reputation of a sender: whether its mail is rejected or held
+type SenderLookupData struct {
+	Sender string `json:"sender"`
+	Listed bool   `json:"listed"`
+	// Action is "reject" or "quarantine"
+	Action string `json:"action"`
+}
+
 type ErrorBody struct {
 	Status string `json:"status"`
 	Detail string `json:"detail"`
@@ -78,9 +124,71 @@ type Error struct {
 }
 
 type Client struct {
-	client *http.Client
-	server string
-	token  string
+	client    *http.Client
+	server    string
+	token     string
+	tokenFile *tokenFile
+	// cache keeps the domains received from the API for domainCacheTTL,
+	// and the last domains list for staleTTL to serve while the API is unavailable
+	cache      cache.Cache
+	staleTTL   time.Duration
+	retries    int
+	retryDelay time.Duration
+	breaker    *breaker
+}
+
+// tokenFile keeps the API token read from a file and reloads it when the file
+// changes, so a rotated token is picked up without restarting the client.
+// It is shared between all copies of the Client.
+type tokenFile struct {
+	mu        sync.Mutex
+	path      string
+	token     string
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// load reads the token from the file if it was modified since the last read.
+func (t *tokenFile) load() error {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return err
+	}
+
+	if info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return fmt.Errorf("token file '%s' is empty", t.path)
+	}
+
+	t.token = token
+	t.modTime = info.ModTime()
+	return nil
+}
+
+// get returns the current token, checking the file for changes at most
+// once per tokenFileCheckInterval. The last known token is kept if the file
+// can not be read.
+func (t *tokenFile) get() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if time.Since(t.checkedAt) >= tokenFileCheckInterval {
+		t.checkedAt = time.Now()
+		if err := t.load(); err != nil {
+			slog.Error("reloading api token file", "path", t.path, "error", err.Error())
+		}
+	}
+
+	return t.token
 }
 
 func NewClient(server string, authToken string, tlsSkipVerify bool, timeout time.Duration) (Client, error) {
@@ -91,11 +199,52 @@ func NewClient(server string, authToken string, tlsSkipVerify bool, timeout time
 		Timeout: timeout,
 	}
 
-	return Client{
+	domainCache, err := memory.New()
+	if err != nil {
+		return Client{}, err
+	}
+
+	cli := Client{
 		client: client,
 		server: server,
 		token:  authToken,
-	}, nil
+		cache:  domainCache,
+	}
+
+	return cli.WithResilience(Resilience{}), nil
+}
+
+// WithCache returns a copy of the Client keeping the domains in the given cache instead
+// of the memory of the process, e.g. in Redis shared by several milter and socketmap instances.
+func (o Client) WithCache(c cache.Cache) Client {
+	o.cache = c
+	return o
+}
+
+// NewClientWithTokenFile creates a Client which reads the API token from the given file
+// and reloads it whenever the file is modified, e.g. after the token rotation.
+func NewClientWithTokenFile(server string, tokenPath string, tlsSkipVerify bool, timeout time.Duration) (Client, error) {
+	client, err := NewClient(server, "", tlsSkipVerify, timeout)
+	if err != nil {
+		return Client{}, err
+	}
+
+	tf := &tokenFile{path: tokenPath, checkedAt: time.Now()}
+	if err := tf.load(); err != nil {
+		return Client{}, fmt.Errorf("reading api token file: %w", err)
+	}
+
+	client.tokenFile = tf
+	return client, nil
+}
+
+// authToken returns the API token to authenticate requests with.
+func (o Client) authToken() string {
+	if o.tokenFile != nil {
+		return o.tokenFile.get()
+	}
+
+	return o.token
 }
 
 func (o Client) createRequest(ctx context.Context, server, path, method string, body io.Reader, headers map[string]string, queryParams map[string]string) (*http.Request, error) {
@@ -130,6 +279,95 @@ func (o Client) createRequest(ctx context.Context, server, path, method string,
 	return req, nil
 }
 
+// do sends the request recording its latency in the metrics under the operation name.
+// Idempotent requests are retried with jittered exponential backoff while the API is unavailable,
+// and no request is sent while the circuit is open. When the API is unavailable the returned
+// error wraps ErrUnavailable, responses with other status codes are returned to the caller.
+func (o Client) do(req *http.Request, operation string) (*http.Response, error) {
+	if !o.breaker.allow() {
+		metrics.ObserveAPIClientRequest(operation, "circuit_open", 0)
+		return nil, fmt.Errorf("%w: circuit is open after repeated failures", ErrUnavailable)
+	}
+
+	ctx := req.Context()
+	attempts := 1
+	if idempotent(req) {
+		attempts += o.retries
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := range attempts {
+		resp, err = o.send(req, operation, attempt)
+		retry := (err != nil && ctx.Err() == nil) || (err == nil && unavailableStatus(resp.StatusCode))
+		if !retry || attempt == attempts-1 {
+			break
+		}
+
+		if sleep(ctx, o.backoff(attempt+1)) != nil {
+			break
+		}
+
+		if resp != nil {
+			drainBody(resp)
+		}
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		o.breaker.release()
+		return nil, err
+	case err != nil:
+		o.breaker.record(true)
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	case unavailableStatus(resp.StatusCode):
+		o.breaker.record(true)
+		drainBody(resp)
+		return nil, fmt.Errorf("%w: api responded with status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	o.breaker.record(false)
+	return resp, nil
+}
+
+// send sends the request once. The request is traced as a client span and carries its
+// trace context to the API.
+func (o Client) send(req *http.Request, operation string, attempt int) (*http.Response, error) {
+	ctx, span := tracing.Start(req.Context(), "ovooclient."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	if attempt > 0 {
+		span.SetAttributes(attribute.Int("http.request.resend_count", attempt))
+	}
+	req = req.WithContext(ctx)
+	tracing.Inject(ctx, req.Header)
+
+	start := time.Now()
+	resp, err := o.client.Do(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	metrics.ObserveAPIClientRequest(operation, status, time.Since(start))
+	tracing.End(span, err)
+
+	return resp, err
+}
+
+// drainBody reads the rest of the response body and closes it, so the connection can be reused.
+func drainBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
 func (o Client) parseChainData(resp *http.Response) (*ChainData, error) {
 	data := ChainData{}
 	body, err := io.ReadAll(resp.Body)
@@ -193,7 +431,7 @@ func (o Client) CreateChain(ctx context.Context, fromEmail, toEmail string) (*Ch
 
 	headers := map[string]string{
 		"Content-Type":  "application/json",
-		"Authorization": fmt.Sprintf("Bearer %s", o.token),
+		"Authorization": fmt.Sprintf("Bearer %s", o.authToken()),
 	}
 	req, err := o.createRequest(
 		ctx,
@@ -207,7 +445,7 @@ func (o Client) CreateChain(ctx context.Context, fromEmail, toEmail string) (*Ch
 	if err != nil {
 		return nil, err
 	}
-	resp, err := o.client.Do(req)
+	resp, err := o.do(req, "create_chain")
 	if err != nil {
 		return nil, err
 	}
@@ -222,60 +460,198 @@ func (o Client) CreateChain(ctx context.Context, fromEmail, toEmail string) (*Ch
 	return o.parseChainData(resp)
 }
 
-func (o Client) GetDomains(ctx context.Context) ([]string, error) {
-	if val, ok := domainCache.Load("domains"); ok {
-		if entry := val.(cachedActiveDomainsInfo); time.Now().Before(entry.expiresAt) {
-			return entry.domains, nil
+// QuarantineMessage holds the message of the chain in the quarantine of the alias. The message
+// is not sent again when the API is unavailable, as it could have been held already.
+func (o Client) QuarantineMessage(ctx context.Context, hash string, data []byte) error {
+	bodyBytes, err := json.Marshal(&HoldMessageRequestBody{Hash: hash, Message: data})
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", o.authToken()),
+	}
+	req, err := o.createRequest(
+		ctx,
+		o.server,
+		"/private/api/v1/quarantine",
+		http.MethodPost,
+		bytes.NewReader(bodyBytes),
+		headers,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	resp, err := o.do(req, "quarantine_message")
+	if err != nil {
+		return err
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode != http.StatusCreated {
+		return o.parseError(resp)
+	}
+
+	return nil
+}
+
+// LookupSender returns the reputation of the sender. The keys which are not full addresses, as the
+// domains and the null sender the MTA looks up too, are not listed.
+func (o Client) LookupSender(ctx context.Context, sender string) (SenderLookupData, error) {
+	sender = strings.ToLower(strings.TrimSpace(sender))
+	if at := strings.LastIndex(sender, "@"); at <= 0 || at == len(sender)-1 {
+		return SenderLookupData{Sender: sender}, nil
+	}
+
+	data := SenderLookupData{}
+	if cached, err := o.cache.Get(ctx, senderCacheKeyPrefix+sender); err == nil {
+		if err := json.Unmarshal(cached, &data); err == nil {
+			return data, nil
 		}
 	}
 
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", o.authToken()),
+	}
+	req, err := o.createRequest(
+		ctx,
+		o.server,
+		"/private/api/v1/reputation/senders/"+url.PathEscape(sender),
+		http.MethodGet,
+		nil,
+		headers,
+		nil,
+	)
+	if err != nil {
+		return SenderLookupData{}, err
+	}
+	resp, err := o.do(req, "lookup_sender")
+	if err != nil {
+		return SenderLookupData{}, err
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return SenderLookupData{}, o.parseError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return SenderLookupData{}, err
+	}
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return SenderLookupData{}, err
+	}
+
+	if err := o.cache.Set(ctx, senderCacheKeyPrefix+sender, body, senderCacheTTL); err != nil {
+		slog.Error("caching sender reputation", "sender", sender, "error", err.Error())
+	}
+
+	return data, nil
+}
+
+// GetDomains returns the names of the active and verified domains. While the API is unavailable
+// the last list received from it is served, for the stale TTL at most.
+func (o Client) GetDomains(ctx context.Context) ([]string, error) {
+	if domains, err := o.cachedDomains(ctx, domainsCacheKey); err == nil {
+		return domains, nil
+	}
+
 	domains, err := o.getDomainsNetwork(ctx, "")
 	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			if stale, staleErr := o.cachedDomains(ctx, domainsStaleCacheKey); staleErr == nil {
+				slog.Warn("serving stale domains", "error", err.Error())
+				return stale, nil
+			}
+		}
 		return nil, err
 	}
 
-	domainCache.Store("domains", cachedActiveDomainsInfo{
-		domains:   domains,
-		expiresAt: time.Now().Add(domainCacheTTL),
-	})
+	if data, err := json.Marshal(domains); err == nil {
+		if err := o.cache.Set(ctx, domainsCacheKey, data, domainCacheTTL); err != nil {
+			slog.Error("caching domains", "error", err.Error())
+		}
+		if o.staleTTL > 0 {
+			if err := o.cache.Set(ctx, domainsStaleCacheKey, data, o.staleTTL); err != nil {
+				slog.Error("caching stale domains", "error", err.Error())
+			}
+		}
+	}
 
 	return domains, nil
 }
 
+// cachedDomains returns the domains list stored in the cache under key.
+func (o Client) cachedDomains(ctx context.Context, key string) ([]string, error) {
+	data, err := o.cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0)
+	if err := json.Unmarshal(data, &domains); err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+// GetDomainByName reports whether the domain is an active and verified domain,
+// it is false when the API can not be queried.
 func (o Client) GetDomainByName(ctx context.Context, domain_name string) bool {
+	found, err := o.LookupDomain(ctx, domain_name)
+	if err != nil {
+		slog.Error("looking up domain", "domain", strings.TrimSpace(domain_name), "error", err.Error())
+		return false
+	}
+
+	return found
+}
+
+// LookupDomain reports whether the domain is an active and verified domain. While the API is
+// unavailable the domain is looked up in the last domains list received from it, and the error
+// wraps ErrUnavailable when there is none.
+func (o Client) LookupDomain(ctx context.Context, domain_name string) (bool, error) {
 	domain_name = strings.TrimSpace(domain_name)
 	if len(domain_name) == 0 {
-		return false
+		return false, nil
 	}
 
-	if val, ok := domainCache.Load("domain_name:" + domain_name); ok {
-		if entry := val.(cachedDomainNameInfo); time.Now().Before(entry.expiresAt) {
-			return true
-		}
+	if _, err := o.cache.Get(ctx, domainNameKeyPrefix+domain_name); err == nil {
+		return true, nil
 	}
 
 	domain, err := o.getDomainsNetwork(ctx, domain_name)
 	if err != nil {
-		fmt.Printf("getDomainsNetwork err: %s\n", err.Error())
-		return false
+		if errors.Is(err, ErrUnavailable) {
+			if stale, staleErr := o.cachedDomains(ctx, domainsStaleCacheKey); staleErr == nil {
+				slog.Warn("looking up domain in stale domains", "domain", domain_name, "error", err.Error())
+				return slices.Contains(stale, domain_name), nil
+			}
+		}
+		return false, err
 	}
 
 	if len(domain) == 0 {
-		return false
+		return false, nil
 	}
 
-	domainCache.Store("domain_name:"+domain_name, cachedDomainNameInfo{
-		domain_name: domain_name,
-		expiresAt:   time.Now().Add(domainCacheTTL),
-	})
+	if err := o.cache.Set(ctx, domainNameKeyPrefix+domain_name, []byte(domain_name), domainCacheTTL); err != nil {
+		slog.Error("caching domain", "domain", domain_name, "error", err.Error())
+	}
 
-	return true
+	return true, nil
 }
 
 func (o Client) getDomainsNetwork(ctx context.Context, domain_name string) ([]string, error) {
 	headers := map[string]string{
 		"Content-Type":  "application/json",
-		"Authorization": fmt.Sprintf("Bearer %s", o.token),
+		"Authorization": fmt.Sprintf("Bearer %s", o.authToken()),
 	}
 	query_params := map[string]string{
 		"active":    "true",
@@ -289,7 +665,7 @@ func (o Client) getDomainsNetwork(ctx context.Context, domain_name string) ([]st
 
 	// http request helper to reduce code burden
 	getDomains := func(req *http.Request) ([]string, PaginationMetadata, error) {
-		resp, err := o.client.Do(req)
+		resp, err := o.do(req, "get_domains")
 		if err != nil {
 			return nil, PaginationMetadata{}, err
 		}
@@ -337,3 +713,23 @@ func (o Client) getDomainsNetwork(ctx context.Context, domain_name string) ([]st
 
 	return domains, nil
 }
+
+// Ping checks that the Ovoo API is reachable and serving requests, using its liveness probe.
+func (o Client) Ping(ctx context.Context) error {
+	req, err := o.createRequest(ctx, o.server, "/healthz", http.MethodGet, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := o.do(req, "ping")
+	if err != nil {
+		return err
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("api responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
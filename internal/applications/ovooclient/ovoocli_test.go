@@ -2,16 +2,26 @@ package ovooclient
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
 )
 
 // roundTripFn is a function-based http.RoundTripper for injecting controlled responses.
@@ -33,6 +43,7 @@ func ovooCLIWith(rt http.RoundTripper) Client {
 		client: &http.Client{Transport: rt},
 		server: "http://example.com",
 		token:  "test-token",
+		cache:  testCache,
 	}
 }
 
@@ -44,6 +55,7 @@ func TestNewClient_ValidParams(t *testing.T) {
 	assert.Equal(t, "http://localhost", cli.server)
 	assert.Equal(t, "mytoken", cli.token)
 	assert.Equal(t, 3*time.Second, cli.client.Timeout)
+	assert.NotNil(t, cli.cache)
 }
 
 // --- createRequest ---
@@ -193,14 +205,72 @@ func TestCreateChain_ContentTypeHeader(t *testing.T) {
 	assert.Equal(t, "application/json", gotCT)
 }
 
+func TestCreateChain_PropagatesTraceContext(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	var gotTraceparent string
+	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
+		gotTraceparent = r.Header.Get("traceparent")
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body: io.NopCloser(strings.NewReader(
+				`{"hash":"","from_email":"","to_email":"",` +
+					`"orig_from_address":{"email":"","type":""},` +
+					`"orig_to_address":{"email":"","type":""}}`)),
+		}, nil
+	}))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "milter")
+	_, err := cli.CreateChain(ctx, "a@b.com", "c@ovoo.com")
+	parent.End()
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	client := spans[0]
+	assert.Equal(t, "ovooclient.create_chain", client.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), client.Parent.SpanID())
+	assert.Equal(t, fmt.Sprintf("00-%s-%s-01", client.SpanContext.TraceID(), client.SpanContext.SpanID()), gotTraceparent)
+}
+
 // --- helpers for domain tests ---
 
-// clearDomainCache resets the package-level cache between tests.
+// testCache is the cache of the clients created with ovooCLIWith.
+var testCache, _ = memory.New()
+
+// clearDomainCache resets the cache between tests.
 func clearDomainCache() {
-	domainCache.Range(func(k, _ any) bool {
-		domainCache.Delete(k)
-		return true
-	})
+	testCache, _ = memory.New()
+}
+
+// setCachedDomains stores the active domains list in the cache.
+func setCachedDomains(t *testing.T, domains []string, ttl time.Duration) {
+	t.Helper()
+	data, err := json.Marshal(domains)
+	require.NoError(t, err)
+	require.NoError(t, testCache.Set(context.Background(), domainsCacheKey, data, ttl))
+}
+
+// cachedDomains returns the active domains list from the cache, false if not cached or expired.
+func cachedDomains(t *testing.T) ([]string, bool) {
+	t.Helper()
+	data, err := testCache.Get(context.Background(), domainsCacheKey)
+	if err != nil {
+		return nil, false
+	}
+	domains := make([]string, 0)
+	require.NoError(t, json.Unmarshal(data, &domains))
+	return domains, true
+}
+
+// cachedDomainName reports whether the domain name is cached and not expired.
+func cachedDomainName(name string) bool {
+	_, err := testCache.Get(context.Background(), domainNameKeyPrefix+name)
+	return err == nil
 }
 
 // domainsBody builds a GetDomainsResponse JSON string.
@@ -357,10 +427,7 @@ func TestGetDomainsNetwork_NoDomainNameFilter(t *testing.T) {
 
 func TestGetDomains_CacheHit(t *testing.T) {
 	clearDomainCache()
-	domainCache.Store("domains", cachedActiveDomainsInfo{
-		domains:   []string{"cached.com"},
-		expiresAt: time.Now().Add(5 * time.Minute),
-	})
+	setCachedDomains(t, []string{"cached.com"}, 5*time.Minute)
 	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
 		t.Fatal("network should not be called on cache hit")
 		return nil, nil
@@ -372,10 +439,7 @@ func TestGetDomains_CacheHit(t *testing.T) {
 
 func TestGetDomains_CacheExpired(t *testing.T) {
 	clearDomainCache()
-	domainCache.Store("domains", cachedActiveDomainsInfo{
-		domains:   []string{"stale.com"},
-		expiresAt: time.Now().Add(-time.Second),
-	})
+	setCachedDomains(t, []string{"stale.com"}, -time.Second)
 	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{
 			StatusCode: http.StatusOK,
@@ -385,11 +449,9 @@ func TestGetDomains_CacheExpired(t *testing.T) {
 	domains, err := cli.GetDomains(context.Background())
 	require.NoError(t, err)
 	assert.Equal(t, []string{"fresh.com"}, domains)
-	val, ok := domainCache.Load("domains")
+	cached, ok := cachedDomains(t)
 	require.True(t, ok)
-	entry := val.(cachedActiveDomainsInfo)
-	assert.Equal(t, []string{"fresh.com"}, entry.domains)
-	assert.True(t, entry.expiresAt.After(time.Now()))
+	assert.Equal(t, []string{"fresh.com"}, cached)
 }
 
 func TestGetDomains_CacheMiss(t *testing.T) {
@@ -403,11 +465,9 @@ func TestGetDomains_CacheMiss(t *testing.T) {
 	domains, err := cli.GetDomains(context.Background())
 	require.NoError(t, err)
 	assert.Equal(t, []string{"new.com"}, domains)
-	val, ok := domainCache.Load("domains")
+	cached, ok := cachedDomains(t)
 	require.True(t, ok)
-	entry := val.(cachedActiveDomainsInfo)
-	assert.Equal(t, []string{"new.com"}, entry.domains)
-	assert.True(t, entry.expiresAt.After(time.Now()))
+	assert.Equal(t, []string{"new.com"}, cached)
 }
 
 func TestGetDomains_NetworkError(t *testing.T) {
@@ -418,7 +478,7 @@ func TestGetDomains_NetworkError(t *testing.T) {
 	result, err := cli.GetDomains(context.Background())
 	assert.Nil(t, result)
 	assert.Error(t, err)
-	_, ok := domainCache.Load("domains")
+	_, ok := cachedDomains(t)
 	assert.False(t, ok)
 }
 
@@ -437,6 +497,35 @@ func TestGetDomains_NoDomainNameParam(t *testing.T) {
 	assert.Empty(t, capturedReq.URL.Query().Get("domain_name"))
 }
 
+func TestGetDomains_SharedCache(t *testing.T) {
+	shared, err := memory.New()
+	require.NoError(t, err)
+	calls := 0
+	rt := roundTripFn(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(domainsBody([]string{"shared.com"}, 1, 1))),
+		}, nil
+	})
+
+	// clients of different instances using the same cache share the cached domains
+	first := ovooCLIWith(rt).WithCache(shared)
+	second := ovooCLIWith(rt).WithCache(shared)
+	_, err = first.GetDomains(context.Background())
+	require.NoError(t, err)
+	domains, err := second.GetDomains(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared.com"}, domains)
+	assert.Equal(t, 1, calls)
+
+	// evicting on one instance takes effect for all of them
+	require.NoError(t, shared.Delete(context.Background(), domainsCacheKey))
+	_, err = second.GetDomains(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
 // --- GetDomainByName ---
 
 func TestGetDomainByName_EmptyString(t *testing.T) {
@@ -459,10 +548,7 @@ func TestGetDomainByName_WhitespaceOnly(t *testing.T) {
 
 func TestGetDomainByName_CacheHit(t *testing.T) {
 	clearDomainCache()
-	domainCache.Store("domain_name:hit.com", cachedDomainNameInfo{
-		domain_name: "hit.com",
-		expiresAt:   time.Now().Add(5 * time.Minute),
-	})
+	require.NoError(t, testCache.Set(context.Background(), domainNameKeyPrefix+"hit.com", []byte("hit.com"), 5*time.Minute))
 	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
 		t.Fatal("network should not be called on cache hit")
 		return nil, nil
@@ -472,10 +558,7 @@ func TestGetDomainByName_CacheHit(t *testing.T) {
 
 func TestGetDomainByName_CacheExpired(t *testing.T) {
 	clearDomainCache()
-	domainCache.Store("domain_name:old.com", cachedDomainNameInfo{
-		domain_name: "old.com",
-		expiresAt:   time.Now().Add(-time.Second),
-	})
+	require.NoError(t, testCache.Set(context.Background(), domainNameKeyPrefix+"old.com", []byte("old.com"), -time.Second))
 	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{
 			StatusCode: http.StatusOK,
@@ -483,10 +566,7 @@ func TestGetDomainByName_CacheExpired(t *testing.T) {
 		}, nil
 	}))
 	assert.True(t, cli.GetDomainByName(context.Background(), "old.com"))
-	val, ok := domainCache.Load("domain_name:old.com")
-	require.True(t, ok)
-	entry := val.(cachedDomainNameInfo)
-	assert.True(t, entry.expiresAt.After(time.Now()))
+	assert.True(t, cachedDomainName("old.com"))
 }
 
 func TestGetDomainByName_FoundViaNetwork(t *testing.T) {
@@ -498,11 +578,7 @@ func TestGetDomainByName_FoundViaNetwork(t *testing.T) {
 		}, nil
 	}))
 	assert.True(t, cli.GetDomainByName(context.Background(), "found.com"))
-	val, ok := domainCache.Load("domain_name:found.com")
-	require.True(t, ok)
-	entry := val.(cachedDomainNameInfo)
-	assert.Equal(t, "found.com", entry.domain_name)
-	assert.True(t, entry.expiresAt.After(time.Now()))
+	assert.True(t, cachedDomainName("found.com"))
 }
 
 func TestGetDomainByName_NotFoundViaNetwork(t *testing.T) {
@@ -514,8 +590,7 @@ func TestGetDomainByName_NotFoundViaNetwork(t *testing.T) {
 		}, nil
 	}))
 	assert.False(t, cli.GetDomainByName(context.Background(), "missing.com"))
-	_, ok := domainCache.Load("domain_name:missing.com")
-	assert.False(t, ok)
+	assert.False(t, cachedDomainName("missing.com"))
 }
 
 func TestGetDomainByName_NetworkError(t *testing.T) {
@@ -524,8 +599,7 @@ func TestGetDomainByName_NetworkError(t *testing.T) {
 		return nil, errors.New("refused")
 	}))
 	assert.False(t, cli.GetDomainByName(context.Background(), "err.com"))
-	_, ok := domainCache.Load("domain_name:err.com")
-	assert.False(t, ok)
+	assert.False(t, cachedDomainName("err.com"))
 }
 
 func TestGetDomainByName_DomainNameSentAsQueryParam(t *testing.T) {
@@ -554,8 +628,194 @@ func TestGetDomainByName_TrimsWhitespaceBeforeLookup(t *testing.T) {
 	}))
 	assert.True(t, cli.GetDomainByName(context.Background(), "  trim.com  "))
 	assert.Equal(t, "trim.com", capturedReq.URL.Query().Get("domain_name"))
-	_, ok := domainCache.Load("domain_name:trim.com")
-	assert.True(t, ok)
-	_, ok2 := domainCache.Load("domain_name:  trim.com  ")
-	assert.False(t, ok2)
+	assert.True(t, cachedDomainName("trim.com"))
+	assert.False(t, cachedDomainName("  trim.com  "))
+}
+
+// --- NewClientWithTokenFile ---
+
+func TestNewClientWithTokenFile_MissingFile(t *testing.T) {
+	_, err := NewClientWithTokenFile("http://localhost", filepath.Join(t.TempDir(), "missing"), false, time.Second)
+	assert.Error(t, err)
+}
+
+func TestNewClientWithTokenFile_EmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("\n"), 0o600))
+
+	_, err := NewClientWithTokenFile("http://localhost", path, false, time.Second)
+	assert.Error(t, err)
+}
+
+func TestNewClientWithTokenFile_ReloadsRotatedToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("old-token\n"), 0o600))
+
+	cli, err := NewClientWithTokenFile("http://localhost", path, false, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "old-token", cli.authToken())
+
+	var gotAuth string
+	cli.client.Transport = roundTripFn(func(r *http.Request) (*http.Response, error) {
+		gotAuth = r.Header.Get("Authorization")
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body: io.NopCloser(strings.NewReader(
+				`{"hash":"","from_email":"","to_email":"",` +
+					`"orig_from_address":{"email":"","type":""},` +
+					`"orig_to_address":{"email":"","type":""}}`)),
+		}, nil
+	})
+
+	// rotate the token and make the modification visible to the client
+	require.NoError(t, os.WriteFile(path, []byte("new-token\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+	// file is not checked again before the check interval passes
+	assert.Equal(t, "old-token", cli.authToken())
+
+	cli.tokenFile.checkedAt = time.Time{}
+	_, _ = cli.CreateChain(context.Background(), "a@b.com", "c@ovoo.com")
+	assert.Equal(t, "Bearer new-token", gotAuth)
+
+	// the last known token is kept when the file becomes unreadable
+	require.NoError(t, os.Remove(path))
+	cli.tokenFile.checkedAt = time.Time{}
+	assert.Equal(t, "new-token", cli.authToken())
+}
+
+// --- Ping ---
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		err     error
+		wantErr bool
+	}{
+		{name: "api is serving", status: http.StatusOK},
+		{name: "api responds with error", status: http.StatusBadGateway, wantErr: true},
+		{name: "api is unreachable", err: errors.New("connection refused"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotAuth string
+			cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
+				gotPath = r.URL.Path
+				gotAuth = r.Header.Get("Authorization")
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(`{"status":"ok"}`))}, nil
+			}))
+
+			err := cli.Ping(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, "/healthz", gotPath)
+			assert.Empty(t, gotAuth)
+		})
+	}
+}
+
+// --- QuarantineMessage ---
+
+func TestQuarantineMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		err             error
+		wantErr         bool
+		wantUnavailable bool
+	}{
+		{name: "message is held", status: http.StatusCreated},
+		{name: "chain mail is not held", status: http.StatusBadRequest, wantErr: true},
+		{name: "api is unreachable", err: errors.New("connection refused"), wantErr: true, wantUnavailable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotMethod string
+			var gotBody HoldMessageRequestBody
+			cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
+				gotPath = r.URL.Path
+				gotMethod = r.Method
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &http.Response{
+					StatusCode: tt.status,
+					Body:       io.NopCloser(strings.NewReader(`{"errors":[{"status":"error","detail":"invalid"}]}`)),
+				}, nil
+			}))
+
+			err := cli.QuarantineMessage(context.Background(), "h1", []byte("Subject: hi\r\n\r\nbody\r\n"))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantUnavailable, errors.Is(err, ErrUnavailable))
+			assert.Equal(t, "/private/api/v1/quarantine", gotPath)
+			assert.Equal(t, http.MethodPost, gotMethod)
+			assert.Equal(t, "h1", gotBody.Hash)
+			assert.Equal(t, "Subject: hi\r\n\r\nbody\r\n", string(gotBody.Message))
+		})
+	}
+}
+
+// --- LookupSender ---
+
+func TestLookupSender_Listed(t *testing.T) {
+	calls := 0
+	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
+		calls++
+		assert.Equal(t, "/private/api/v1/reputation/senders/listed@spam.com", r.URL.Path)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"sender":"listed@spam.com","listed":true,"action":"reject"}`)),
+		}, nil
+	}))
+
+	data, err := cli.LookupSender(context.Background(), " Listed@Spam.com")
+	require.NoError(t, err)
+	assert.True(t, data.Listed)
+	assert.Equal(t, "reject", data.Action)
+
+	// the reputation is served from the cache
+	data, err = cli.LookupSender(context.Background(), "listed@spam.com")
+	require.NoError(t, err)
+	assert.True(t, data.Listed)
+	assert.Equal(t, 1, calls)
+}
+
+func TestLookupSender_NotAnAddress(t *testing.T) {
+	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
+		t.Fatal("network should not be called for keys which are not addresses")
+		return nil, nil
+	}))
+
+	for _, key := range []string{"", "<>", "spam.com", "user@", "@spam.com"} {
+		data, err := cli.LookupSender(context.Background(), key)
+		require.NoError(t, err)
+		assert.False(t, data.Listed)
+	}
+}
+
+func TestLookupSender_ErrorResponse(t *testing.T) {
+	cli := ovooCLIWith(roundTripFn(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Body:       io.NopCloser(strings.NewReader(`{"errors":[{"status":"403","detail":"forbidden"}]}`)),
+		}, nil
+	}))
+
+	_, err := cli.LookupSender(context.Background(), "forbidden@spam.com")
+	assert.Error(t, err)
 }
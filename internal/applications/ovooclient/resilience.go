@@ -0,0 +1,164 @@
+package ovooclient
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRetries is the number of retries of the idempotent requests when the API is unavailable
+	DefaultRetries = 2
+	// DefaultBreakerThreshold is the number of consecutive failed requests opening the circuit
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerCooldown is the time requests fail fast once the circuit is open
+	DefaultBreakerCooldown = 30 * time.Second
+	// DefaultStaleTTL is how long the last domains list received from the API is served
+	// while the API is unavailable
+	DefaultStaleTTL = 24 * time.Hour
+	// delays between the retries, growing exponentially with full jitter
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 2 * time.Second
+)
+
+// ErrUnavailable is returned when the API can not serve the request for now: it is unreachable,
+// times out, responds with a 5xx or 429 status code, or the circuit is open after repeated failures.
+// Callers should fail temporarily, e.g. with a 4xx SMTP reply, so the request is retried later.
+var ErrUnavailable = errors.New("ovoo api is unavailable")
+
+// Resilience configures how the Client handles an unavailable API.
+// Zero values select the defaults, negative values disable the feature.
+type Resilience struct {
+	Retries          int           // retries of the idempotent requests
+	BreakerThreshold int           // consecutive failed requests opening the circuit
+	BreakerCooldown  time.Duration // time requests fail fast once the circuit is open
+	StaleTTL         time.Duration // time the last domains list is served while the API is unavailable
+}
+
+// WithResilience returns a copy of the Client handling an unavailable API as configured.
+// The copy gets its own circuit breaker.
+func (o Client) WithResilience(r Resilience) Client {
+	o.retries = orDefault(r.Retries, DefaultRetries)
+	o.retryDelay = retryBaseDelay
+	o.staleTTL = orDefault(r.StaleTTL, DefaultStaleTTL)
+	o.breaker = nil
+	if threshold := orDefault(r.BreakerThreshold, DefaultBreakerThreshold); threshold > 0 {
+		o.breaker = &breaker{threshold: threshold, cooldown: orDefault(r.BreakerCooldown, DefaultBreakerCooldown)}
+	}
+
+	return o
+}
+
+// orDefault returns def when v is zero and zero when v is negative.
+func orDefault[T int | time.Duration](v, def T) T {
+	if v == 0 {
+		return def
+	}
+
+	return max(v, 0)
+}
+
+// breaker is a circuit breaker: once threshold consecutive requests failed because the API is
+// unavailable, requests fail fast for cooldown, then a single request probes the API and closes
+// the circuit when it succeeds. It is shared between all copies of the Client.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// allow reports whether a request can be sent. A nil breaker allows every request.
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	b.probing = true
+	return true
+}
+
+// record updates the state of the circuit with the outcome of an allowed request.
+func (b *breaker) record(unavailable bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !unavailable {
+		if b.failures >= b.threshold {
+			slog.Info("ovoo api circuit closed")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			slog.Warn("ovoo api circuit opened", "failures", b.failures, "cooldown", b.cooldown.String())
+		}
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends a probe without an outcome, e.g. when the caller gave up on the request.
+func (b *breaker) release() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// idempotent reports whether the request can be sent again without side effects.
+func idempotent(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// unavailableStatus reports whether the response status code means the API can not serve requests for now.
+func unavailableStatus(code int) bool {
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// backoff returns the delay before the retry, the attempt counts from 1.
+func (o Client) backoff(attempt int) time.Duration {
+	delay := min(o.retryDelay<<(attempt-1), retryMaxDelay)
+	if delay <= 0 {
+		return 0
+	}
+
+	return rand.N(delay) + 1
+}
+
+// sleep waits for d, it returns the error of ctx when it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
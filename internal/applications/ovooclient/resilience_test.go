@@ -0,0 +1,242 @@
+package ovooclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+)
+
+// resilientCLIWith returns a client with the given resilience using the RoundTripper,
+// an empty cache of its own and short retry delays.
+func resilientCLIWith(t *testing.T, rt http.RoundTripper, r Resilience) Client {
+	t.Helper()
+	c, err := memory.New()
+	require.NoError(t, err)
+	cli := ovooCLIWith(rt).WithCache(c).WithResilience(r)
+	cli.retryDelay = time.Millisecond
+	return cli
+}
+
+// statusResponses responds with the statuses in order, then with the last one.
+func statusResponses(calls *int, body string, statuses ...int) roundTripFn {
+	return func(r *http.Request) (*http.Response, error) {
+		status := statuses[min(*calls, len(statuses)-1)]
+		*calls++
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	assert.Equal(t, 3, orDefault(0, 3))
+	assert.Equal(t, 0, orDefault(-1, 3))
+	assert.Equal(t, 7, orDefault(7, 3))
+}
+
+func TestBackoff_Bounded(t *testing.T) {
+	cli := Client{retryDelay: retryBaseDelay}
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := cli.backoff(attempt)
+		assert.Positive(t, d)
+		assert.LessOrEqual(t, d, retryMaxDelay)
+	}
+	assert.Zero(t, Client{}.backoff(1))
+}
+
+func TestDo_RetriesIdempotentRequests(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, domainsBody([]string{"ok.com"}, 1, 1),
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK), Resilience{Retries: 2})
+
+	domains, err := cli.GetDomains(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ok.com"}, domains)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_RetriesExhausted(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, "", http.StatusServiceUnavailable), Resilience{Retries: 2})
+
+	_, err := cli.GetDomains(context.Background())
+	require.ErrorIs(t, err, ErrUnavailable)
+	assert.Contains(t, err.Error(), "503")
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_NetworkErrorIsUnavailable(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, roundTripFn(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	}), Resilience{Retries: 1})
+
+	err := cli.Ping(context.Background())
+	require.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 2, calls)
+}
+
+func TestDo_DoesNotRetryNonIdempotentRequests(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, "", http.StatusServiceUnavailable), Resilience{Retries: 2})
+
+	_, err := cli.CreateChain(context.Background(), "a@b.com", "c@ovoo.com")
+	require.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_ClientErrorsAreNotRetried(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, `{"errors":[{"status":"forbidden","detail":"token invalid"}]}`,
+		http.StatusForbidden), Resilience{Retries: 2})
+
+	_, err := cli.GetDomains(context.Background())
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_CancelledContextIsNotUnavailable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cli := resilientCLIWith(t, roundTripFn(func(r *http.Request) (*http.Response, error) {
+		cancel()
+		return nil, context.Canceled
+	}), Resilience{Retries: 2, BreakerThreshold: 1})
+
+	err := cli.Ping(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrUnavailable)
+	assert.True(t, cli.breaker.allow(), "a cancelled request must not open the circuit")
+}
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	calls := 0
+	up := false
+	cli := resilientCLIWith(t, roundTripFn(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if !up {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"status":"ok"}`))}, nil
+	}), Resilience{Retries: -1, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+
+	for range 2 {
+		require.ErrorIs(t, cli.Ping(context.Background()), ErrUnavailable)
+	}
+	require.Equal(t, 2, calls)
+
+	// the circuit is open, requests fail fast without reaching the API
+	err := cli.Ping(context.Background())
+	require.ErrorIs(t, err, ErrUnavailable)
+	assert.Contains(t, err.Error(), "circuit is open")
+	assert.Equal(t, 2, calls)
+
+	// after the cooldown a single request probes the API and closes the circuit
+	up = true
+	cli.breaker.openedAt = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, cli.Ping(context.Background()))
+	require.NoError(t, cli.Ping(context.Background()))
+	assert.Equal(t, 4, calls)
+}
+
+func TestBreaker_HalfOpenAllowsSingleProbe(t *testing.T) {
+	b := &breaker{threshold: 1, cooldown: time.Hour}
+	b.record(true)
+	assert.False(t, b.allow())
+
+	b.openedAt = time.Now().Add(-2 * time.Hour)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow(), "only one request probes the API")
+
+	b.record(true)
+	assert.False(t, b.allow(), "a failed probe opens the circuit again")
+}
+
+func TestBreaker_SharedBetweenCopies(t *testing.T) {
+	cli := resilientCLIWith(t, roundTripFn(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), Resilience{Retries: -1, BreakerThreshold: 1})
+	other := cli.WithCache(cli.cache)
+
+	require.ErrorIs(t, cli.Ping(context.Background()), ErrUnavailable)
+	assert.False(t, other.breaker.allow())
+}
+
+func TestGetDomains_ServesStaleWhenUnavailable(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, domainsBody([]string{"stale.com"}, 1, 1),
+		http.StatusOK, http.StatusServiceUnavailable), Resilience{Retries: -1})
+
+	_, err := cli.GetDomains(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, cli.cache.Delete(context.Background(), domainsCacheKey)) // the fresh copy expired
+
+	domains, err := cli.GetDomains(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale.com"}, domains)
+	assert.Equal(t, 2, calls)
+}
+
+func TestGetDomains_NoStaleCopy(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, "", http.StatusServiceUnavailable), Resilience{Retries: -1})
+
+	domains, err := cli.GetDomains(context.Background())
+	assert.Nil(t, domains)
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestGetDomains_StaleDisabled(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, domainsBody([]string{"a.com"}, 1, 1), http.StatusOK),
+		Resilience{Retries: -1, StaleTTL: -1})
+
+	_, err := cli.GetDomains(context.Background())
+	require.NoError(t, err)
+	_, err = cli.cache.Get(context.Background(), domainsStaleCacheKey)
+	assert.Error(t, err)
+}
+
+func TestLookupDomain(t *testing.T) {
+	calls := 0
+	cli := resilientCLIWith(t, statusResponses(&calls, domainsBody([]string{"known.com"}, 1, 1),
+		http.StatusOK, http.StatusServiceUnavailable), Resilience{Retries: -1})
+
+	// no stale copy yet
+	calls = 1
+	found, err := cli.LookupDomain(context.Background(), "known.com")
+	assert.False(t, found)
+	require.ErrorIs(t, err, ErrUnavailable)
+
+	// the stale copy answers while the API is unavailable
+	calls = 0
+	_, err = cli.GetDomains(context.Background())
+	require.NoError(t, err)
+	found, err = cli.LookupDomain(context.Background(), "known.com")
+	require.NoError(t, err)
+	assert.True(t, found)
+	found, err = cli.LookupDomain(context.Background(), "other.com")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestNewClient_DefaultResilience(t *testing.T) {
+	cli, err := NewClient("http://localhost", "token", false, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRetries, cli.retries)
+	assert.Equal(t, DefaultStaleTTL, cli.staleTTL)
+	require.NotNil(t, cli.breaker)
+	assert.Equal(t, DefaultBreakerThreshold, cli.breaker.threshold)
+	assert.Equal(t, DefaultBreakerCooldown, cli.breaker.cooldown)
+
+	cli = cli.WithResilience(Resilience{BreakerThreshold: -1})
+	assert.Nil(t, cli.breaker)
+}
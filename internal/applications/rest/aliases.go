@@ -80,6 +80,18 @@ func (a *Application) CreateAlias(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the alias delivers either to a protected address or to a webhook
+	praddrId := ""
+	if req.ProtectedAddressId != nil {
+		praddrId = *req.ProtectedAddressId
+	}
+
+	quarantine, err := quarantineTSetting(req.Quarantine)
+	if err != nil {
+		a.errorLogNResponse(w, "parsing chain create request", err)
+		return
+	}
+
 	alias, err := a.svcGw.Aliases.Create(r.Context(), cuser, services.AliasCreateCmd{
 		Metadata: struct {
 			Comment     *string
@@ -88,9 +100,14 @@ func (a *Application) CreateAlias(w http.ResponseWriter, r *http.Request) {
 			Comment:     req.Metadata.Comment,
 			ServiceName: req.Metadata.ServiceName,
 		},
-		ProtectedAddressId: req.ProtectedAddressId,
+		ProtectedAddressId: praddrId,
+		Webhook:            req.Webhook,
 		DomainId:           entities.Id(req.DomainId),
 		Prefix:             req.CustomPrefix,
+		PrivacyFilter:      (*string)(req.PrivacyFilter),
+		Quarantine:         quarantine,
+		Rules:              req.Rules,
+		LeakRestrict:       req.LeakRestrict,
 	})
 
 	if err != nil {
@@ -127,10 +144,22 @@ func (a *Application) UpdateAlias(w http.ResponseWriter, r *http.Request) {
 		metadata.Comment = req.Metadata.Comment
 		metadata.ServiceName = req.Metadata.ServiceName
 	}
+
+	quarantine, err := quarantineTSetting(req.Quarantine)
+	if err != nil {
+		a.errorLogNResponse(w, "parsing alias update request", err)
+		return
+	}
 	alias, err := a.svcGw.Aliases.Update(r.Context(), cuser, services.AliasUpdateCmd{
-		AliasId:  aliasId,
-		Metadata: metadata,
-		Active:   req.Active,
+		AliasId:       aliasId,
+		Metadata:      metadata,
+		Active:        req.Active,
+		PrivacyFilter: (*string)(req.PrivacyFilter),
+		Quarantine:    quarantine,
+		Rules:         req.Rules,
+		Webhook:       req.Webhook,
+		LeakRestrict:  req.LeakRestrict,
+		ResolveLeak:   req.ResolveLeak,
 	})
 	if err != nil {
 		a.errorLogNResponse(w, "updating alias", err)
@@ -89,6 +89,12 @@ func (a *Application) CreateApiToken(w http.ResponseWriter, r *http.Request) {
 	if req.Description != nil {
 		cmd.Description = *req.Description
 	}
+	if req.Scopes != nil {
+		cmd.Scopes = permissionsFStrings(*req.Scopes)
+	}
+	if req.Domains != nil {
+		cmd.Domains = *req.Domains
+	}
 	token, err := a.svcGw.Tokens.Create(r.Context(), cuser, cmd)
 	if err != nil {
 		a.errorLogNResponse(w, "creating new api token", err)
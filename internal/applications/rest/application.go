@@ -20,7 +20,10 @@ import (
 
 	"github.com/Burmuley/ovoo/internal/applications"
 	"github.com/Burmuley/ovoo/internal/applications/rest/middleware"
+	"github.com/Burmuley/ovoo/internal/cache"
 	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/health"
+	"github.com/Burmuley/ovoo/internal/metrics"
 	"github.com/Burmuley/ovoo/internal/services"
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/lpar/gzipped/v2"
@@ -43,11 +46,13 @@ var webuiData embed.FS
 type Application struct {
 	svcGw           *services.ServiceGateway
 	listenAddr      string
+	metricsAddr     string
 	logger          *slog.Logger
 	authSkipURIs    []string
 	tls_cert        string
 	tls_key         string
 	providerConfigs map[string]middleware.OIDCProvider
+	health          *health.Checker
 	version         config.SystemVersion
 	sysInfo         config.SystemInfo
 }
@@ -57,8 +62,11 @@ type Application struct {
 //
 // Parameters:
 //   - listenAddr: Network address to listen on (uses DefaultListenAddr if empty)
+//   - metricsAddr: Network address of the metrics listener, metrics are not served if empty
 //   - logger: Structured logger for application logging
 //   - svcGw: Service gateway containing business logic implementations
+//   - appCache: Cache for OIDC UserInfo results and logouts, shared between instances when Redis is used
+//   - checker: Readiness checks of the dependencies served on /readyz, the OIDC providers are added to them
 //   - tls_key: Path to TLS private key file
 //   - tls_cert: Path to TLS certificate file
 //   - providersConfig: Map of OIDC provider configurations
@@ -68,21 +76,26 @@ type Application struct {
 //   - error: Non-nil if initialization fails
 func New(
 	listenAddr string,
+	metricsAddr string,
 	logger *slog.Logger,
 	svcGw *services.ServiceGateway,
+	appCache cache.Cache,
+	checker *health.Checker,
 	tls_key, tls_cert string,
 	providersConfig map[string]config.ConfigOIDC,
 	version config.SystemVersion,
 	sysInfo config.SystemInfo,
 ) (applications.Application, error) {
 	ctrl := &Application{
-		svcGw:      svcGw,
-		listenAddr: listenAddr,
-		logger:     logger,
-		tls_key:    tls_key,
-		tls_cert:   tls_cert,
-		version:    version,
-		sysInfo:    sysInfo,
+		svcGw:       svcGw,
+		listenAddr:  listenAddr,
+		metricsAddr: metricsAddr,
+		logger:      logger,
+		health:      checker,
+		tls_key:     tls_key,
+		tls_cert:    tls_cert,
+		version:     version,
+		sysInfo:     sysInfo,
 	}
 
 	if len(listenAddr) < 1 {
@@ -104,7 +117,12 @@ func New(
 		return nil, errors.New("logger must be set")
 	}
 
-	ctrl.authSkipURIs = []string{"/index.html", "/assets"}
+	if ctrl.health == nil {
+		ctrl.health = health.New(0)
+	}
+
+	// the confirmation links of protected addresses and the password resets are used without a login
+	ctrl.authSkipURIs = []string{"/index.html", "/assets", services.VerificationConfirmPath, passwordForgotPath, services.PasswordResetPath}
 
 	{
 		var err error
@@ -116,10 +134,18 @@ func New(
 		}
 	}
 
+	for name, p := range ctrl.providerConfigs {
+		ctrl.health.Add("oidc_"+name, oidcDiscoveryCheck(http.DefaultClient, p.Issuer))
+	}
+
 	if err := middleware.SetLogger(logger); err != nil {
 		return nil, err
 	}
 
+	if err := middleware.SetCache(appCache); err != nil {
+		return nil, err
+	}
+
 	return ctrl, nil
 }
 
@@ -159,6 +185,32 @@ func (a *Application) Start() error {
 	mux.HandleFunc("PATCH /api/v1/users/{id}", a.UpdateUser)
 	mux.HandleFunc("DELETE /api/v1/users/{id}", a.DeleteUser)
 
+	// two-factor authentication routes
+	mux.HandleFunc("GET /api/v1/users/mfa", a.GetMFAStatus)
+	mux.HandleFunc("POST /api/v1/users/mfa/totp", a.EnrollTOTP)
+	mux.HandleFunc("POST /api/v1/users/mfa/totp/confirm", a.ConfirmTOTP)
+	mux.HandleFunc("POST /api/v1/users/mfa/totp/disable", a.DisableTOTP)
+	mux.HandleFunc("POST /api/v1/users/mfa/recovery-codes", a.RegenerateRecoveryCodes)
+	mux.HandleFunc("POST /api/v1/users/mfa/webauthn/register/begin", a.BeginWebAuthnRegistration)
+	mux.HandleFunc("POST /api/v1/users/mfa/webauthn/register/finish", a.FinishWebAuthnRegistration)
+	mux.HandleFunc("DELETE /api/v1/users/mfa/webauthn/{id}", a.DeleteWebAuthnCredential)
+	mux.HandleFunc("POST /api/v1/users/{id}/mfa/reset", a.ResetUserMFA)
+
+	// email notifications routes
+	mux.HandleFunc("GET /api/v1/users/notifications", a.GetNotificationPrefs)
+	mux.HandleFunc("PATCH /api/v1/users/notifications", a.UpdateNotificationPrefs)
+
+	// sessions routes, the password login is handled by the authentication middleware
+	mux.HandleFunc("GET /api/v1/auth/sessions", a.GetSessions)
+	mux.HandleFunc("DELETE /api/v1/auth/sessions/{id}", a.DeleteSession)
+
+	// password routes, the forgotten passwords are reset without a login
+	mux.HandleFunc("POST /api/v1/users/profile/password", a.ChangePassword)
+	mux.HandleFunc("POST /api/v1/users/{id}/password", a.ResetUserPassword)
+	mux.HandleFunc("POST "+passwordForgotPath, a.ForgotPassword)
+	mux.HandleFunc("GET "+services.PasswordResetPath, a.GetPasswordReset)
+	mux.HandleFunc("POST "+services.PasswordResetPath, a.ResetPassword)
+
 	// api tokens routes
 	mux.HandleFunc("GET /api/v1/users/apitokens", a.GetApiTokens)
 	mux.HandleFunc("GET /api/v1/users/apitokens/{id}", a.GetApiTokenById)
@@ -173,6 +225,19 @@ func (a *Application) Start() error {
 	mux.HandleFunc("DELETE /api/v1/domains/{id}", a.DeleteDomain)
 	mux.HandleFunc("POST /api/v1/domains/{id}/verify", a.VerifyDomain)
 
+	// roles routes
+	mux.HandleFunc("GET /api/v1/roles", a.GetRoles)
+	mux.HandleFunc("GET /api/v1/roles/permissions", a.GetPermissions)
+	mux.HandleFunc("GET /api/v1/roles/{id}", a.GetRoleById)
+	mux.HandleFunc("POST /api/v1/roles", a.CreateRole)
+	mux.HandleFunc("PATCH /api/v1/roles/{id}", a.UpdateRole)
+	mux.HandleFunc("DELETE /api/v1/roles/{id}", a.DeleteRole)
+
+	// service accounts routes
+	mux.HandleFunc("GET /api/v1/serviceaccounts", a.GetServiceAccounts)
+	mux.HandleFunc("POST /api/v1/serviceaccounts", a.CreateServiceAccount)
+	mux.HandleFunc("POST /api/v1/serviceaccounts/{id}/rotate", a.RotateServiceAccountToken)
+
 	// aliases routes
 	mux.HandleFunc("GET /api/v1/aliases", a.GetAliases)
 	mux.HandleFunc("GET /api/v1/aliases/{id}", a.GetAliaseById)
@@ -180,17 +245,36 @@ func (a *Application) Start() error {
 	mux.HandleFunc("PATCH /api/v1/aliases/{id}", a.UpdateAlias)
 	mux.HandleFunc("DELETE /api/v1/aliases/{id}", a.DeleteAlias)
 
+	// quarantine routes
+	mux.HandleFunc("GET /api/v1/quarantine", a.GetQuarantine)
+	mux.HandleFunc("GET /api/v1/quarantine/{id}", a.GetQuarantinedMessage)
+	mux.HandleFunc("POST /api/v1/quarantine/{id}/release", a.ReleaseQuarantinedMessage)
+	mux.HandleFunc("DELETE /api/v1/quarantine/{id}", a.DeleteQuarantinedMessage)
+
+	// reputation routes
+	mux.HandleFunc("GET /api/v1/reputation/traps", a.GetTraps)
+	mux.HandleFunc("POST /api/v1/reputation/traps", a.CreateTrap)
+	mux.HandleFunc("DELETE /api/v1/reputation/traps/{id}", a.DeleteTrap)
+	mux.HandleFunc("GET /api/v1/reputation/senders", a.GetSenderReputation)
+	mux.HandleFunc("PUT /api/v1/reputation/senders/{sender}", a.OverrideSenderReputation)
+	mux.HandleFunc("DELETE /api/v1/reputation/senders/{sender}", a.DeleteSenderReputation)
+
 	// protected addresses routes
 	mux.HandleFunc("GET /api/v1/praddrs", a.GetAllPrAddrs)
 	mux.HandleFunc("GET /api/v1/praddrs/{id}", a.GetPrAddrById)
 	mux.HandleFunc("POST /api/v1/praddrs", a.CreatePrAddr)
 	mux.HandleFunc("PATCH /api/v1/praddrs/{id}", a.UpdatePrAddr)
 	mux.HandleFunc("DELETE /api/v1/praddrs/{id}", a.DeletePrAddr)
+	mux.HandleFunc("POST /api/v1/praddrs/{id}/verification", a.SendPrAddrVerification)
+	mux.HandleFunc("GET "+services.VerificationConfirmPath, a.GetPrAddrConfirmation)
+	mux.HandleFunc("POST "+services.VerificationConfirmPath, a.ConfirmPrAddr)
 
 	// chains routes
 	mux.HandleFunc("GET /private/api/v1/chains/{hash}", a.getChainByHash)
 	mux.HandleFunc("POST /private/api/v1/chains", a.CreateChain)
 	mux.HandleFunc("DELETE /private/api/v1/chains/{hash}", a.DeleteChain)
+	mux.HandleFunc("POST /private/api/v1/quarantine", a.HoldMessage)
+	mux.HandleFunc("GET /private/api/v1/reputation/senders/{sender}", a.LookupSenderReputation)
 
 	// version
 	mux.HandleFunc("GET /api/v1/version", func(w http.ResponseWriter, r *http.Request) {
@@ -230,17 +314,34 @@ func (a *Application) Start() error {
 
 	handler := middleware.Adapt(mux,
 		middleware.SecurityHeaders(),
-		middleware.Logging(a.logger),
+		middleware.Tracing(mux),
+		middleware.Logging(a.logger, mux),
 		middleware.Authentication(a.authSkipURIs, a.svcGw),
+		middleware.RestrictServiceAccounts(),
 	)
+
+	// the probes are served outside of the middlewares, without authentication and logging
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", a.health.Liveness)
+	root.HandleFunc("GET /readyz", a.health.Readiness)
+	root.Handle("/", handler)
+
+	// requests keep being served while the instance is drained on shutdown,
+	// their context is canceled once the server is stopped
+	srvCtx, cancelSrv := context.WithCancel(context.Background())
+	defer cancelSrv()
 	srv := &http.Server{
 		Addr:                         a.listenAddr,
-		Handler:                      handler,
+		Handler:                      root,
 		DisableGeneralOptionsHandler: false,
 		ReadTimeout:                  10 * time.Second,
 		WriteTimeout:                 15 * time.Second,
 		IdleTimeout:                  60 * time.Second,
-		BaseContext:                  func(net.Listener) context.Context { return ctx },
+		BaseContext:                  func(net.Listener) context.Context { return srvCtx },
+	}
+
+	if a.metricsAddr != "" {
+		go metrics.Serve(ctx, a.metricsAddr, a.logger)
 	}
 
 	go func() {
@@ -253,6 +354,9 @@ func (a *Application) Start() error {
 	}()
 
 	<-ctx.Done()
+	a.logger.Info("draining Ovoo API server")
+	a.health.Drain(context.Background())
+
 	a.logger.Info("shutting down Ovoo API server")
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -316,6 +420,41 @@ func parseProvidersCfg(cfg map[string]config.ConfigOIDC) (map[string]middleware.
 			ClientID: config.ClientId,
 		}
 
+		logoutMeta := struct {
+			EndSessionEndpoint string `json:"end_session_endpoint"`
+			RevocationEndpoint string `json:"revocation_endpoint"`
+		}{}
+		if err := p.OIDCProvider.Claims(&logoutMeta); err != nil {
+			return nil, fmt.Errorf("oidc provider '%s': parsing discovery document: %w", name, err)
+		}
+		p.EndSessionEndpoint = logoutMeta.EndSessionEndpoint
+		p.RevocationEndpoint = logoutMeta.RevocationEndpoint
+		p.PostLogoutRedirectURL = config.PostLogoutRedirectURL
+
+		switch config.AccessTokenValidation {
+		case "", middleware.AccessTokenValidationUserInfo:
+		case middleware.AccessTokenValidationJWT:
+			// the audience is checked against all accepted audiences by the middleware
+			p.AccessTokenVerifier = p.OIDCProvider.Verifier(&oidc.Config{SkipClientIDCheck: true})
+			p.Audiences = config.Audiences
+			if len(p.Audiences) == 0 {
+				p.Audiences = []string{config.ClientId}
+			}
+			p.EmailClaims = config.EmailClaims
+			if len(p.EmailClaims) == 0 {
+				p.EmailClaims = middleware.DefaultOIDCEmailClaims
+			}
+		default:
+			return nil, fmt.Errorf("oidc provider '%s': unknown access_token_validation '%s'", name, config.AccessTokenValidation)
+		}
+
+		if prov := config.Provisioning; prov != nil {
+			if prov.SyncUserType && len(prov.AdminValues) == 0 {
+				return nil, fmt.Errorf("oidc provider '%s': sync_user_type requires admin_values", name)
+			}
+			p.Provisioning = prov
+		}
+
 		providers[name] = p
 	}
 
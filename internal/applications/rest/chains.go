@@ -20,7 +20,7 @@ func (a *Application) getChainByHash(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := GetEmailChainDetailsResponse(chainTChainData(chain))
+	resp := GetEmailChainDetailsResponse(a.chainData(chain))
 	a.successResponse(w, resp, http.StatusOK)
 }
 
@@ -50,7 +50,7 @@ func (a *Application) CreateChain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := CreateEmailChainResponse(chainTChainData(chain))
+	resp := CreateEmailChainResponse(a.chainData(chain))
 	a.successResponse(w, resp, http.StatusCreated)
 }
 
@@ -69,3 +69,13 @@ func (a *Application) DeleteChain(w http.ResponseWriter, r *http.Request) {
 
 	a.successResponse(w, "", http.StatusNoContent)
 }
+
+// chainData converts the chain to its response, the mail is only held when the quarantine is available.
+func (a *Application) chainData(chain entities.Chain) ChainData {
+	data := chainTChainData(chain)
+	if data.Quarantine != nil && !a.svcGw.Quarantine.Available() {
+		data.Quarantine = nil
+	}
+
+	return data
+}
@@ -238,6 +238,28 @@ func TestCreateAlias_Success_WithMetadata(t *testing.T) {
 	ta.addrRepo.AssertExpectations(t)
 }
 
+func TestCreateAlias_Webhook(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	domainId := entities.NewId()
+
+	ta.addrRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Twice()
+
+	body := bytes.NewBufferString(`{"webhook": "https://hooks.example.com/mail", "domain_id": "` + domainId.String() + `", "metadata": {}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/aliases", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.CreateAlias(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	resp := AliasData{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Webhook)
+	assert.Equal(t, "https://hooks.example.com/mail", resp.Webhook.Url)
+	assert.NotEmpty(t, resp.Webhook.Secret)
+	ta.addrRepo.AssertExpectations(t)
+}
+
 // --- DeleteAlias ---
 
 func TestDeleteAlias_NoUser(t *testing.T) {
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -208,6 +209,43 @@ func TestCreateApiToken_Success(t *testing.T) {
 	ta.tokensRepo.AssertExpectations(t)
 }
 
+func TestCreateApiToken_Scoped(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUserFull()
+	ta.tokensRepo.On("Create", mock.Anything, mock.MatchedBy(func(tok entities.ApiToken) bool {
+		return len(tok.Scopes) == 1 && tok.Scopes[0] == entities.PermAliasesRead &&
+			len(tok.Domains) == 1 && tok.Domains[0] == "example.com"
+	})).Return(nil)
+
+	body := bytes.NewBufferString(`{"name": "mytoken", "expire_in": 30, "scopes": ["aliases:read"], "domains": ["example.com"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.CreateApiToken(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var resp ApiTokenDataOnCreate
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Scopes)
+	assert.Equal(t, []string{"aliases:read"}, *resp.Scopes)
+	require.NotNil(t, resp.Domains)
+	assert.Equal(t, []string{"example.com"}, *resp.Domains)
+	ta.tokensRepo.AssertExpectations(t)
+}
+
+func TestCreateApiToken_UnknownScope(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUserFull()
+
+	body := bytes.NewBufferString(`{"name": "mytoken", "expire_in": 30, "scopes": ["aliases:delete"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.CreateApiToken(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 // --- UpdateApiToken ---
 
 func TestUpdateApiToken_NoUser(t *testing.T) {
@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// --- GetMFAStatus ---
+
+func TestGetMFAStatus_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.MFA = entities.UserMFA{
+		TOTPSecret:    "JBSWY3DPEHPK3PXP",
+		TOTPEnabled:   true,
+		RecoveryCodes: []entities.Hash{"a", "b"},
+		WebAuthn:      []entities.WebAuthnCredential{{ID: entities.NewId(), Name: "key", CreatedAt: time.Now()}},
+	}
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/mfa", nil)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.GetMFAStatus(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "JBSWY3DPEHPK3PXP")
+	resp := GetMFAStatusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Enabled)
+	assert.True(t, resp.TotpEnabled)
+	assert.Equal(t, 2, resp.RecoveryCodesLeft)
+	require.Len(t, resp.WebauthnCredentials, 1)
+	assert.Equal(t, "key", resp.WebauthnCredentials[0].Name)
+	assert.Nil(t, resp.WebauthnCredentials[0].LastUsedAt)
+}
+
+// --- EnrollTOTP / ConfirmTOTP ---
+
+func TestEnrollTOTP_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	ta.usersRepo.On("Update", mock.Anything, mock.AnythingOfType("entities.User")).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/mfa/totp", nil)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.EnrollTOTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp := EnrollTOTPResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Secret)
+	assert.Contains(t, resp.ProvisioningUri, "otpauth://totp/")
+}
+
+func TestConfirmTOTP_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	secret, err := entities.NewTOTPSecret()
+	require.NoError(t, err)
+	user.MFA.TOTPSecret = secret
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	ta.usersRepo.On("Update", mock.Anything, mock.AnythingOfType("entities.User")).Return(nil)
+
+	code, err := entities.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	body := bytes.NewBufferString(`{"code": "` + code + `"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/mfa/totp/confirm", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.ConfirmTOTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := RecoveryCodesResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.RecoveryCodes, 10)
+}
+
+func TestConfirmTOTP_InvalidCode(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.MFA.TOTPSecret = "JBSWY3DPEHPK3PXP"
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	body := bytes.NewBufferString(`{"code": "abcdef"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/mfa/totp/confirm", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.ConfirmTOTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// --- WebAuthn ---
+
+func TestBeginWebAuthnRegistration_NotConfigured(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/mfa/webauthn/register/begin", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.BeginWebAuthnRegistration(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// --- ResetUserMFA ---
+
+func TestResetUserMFA_Success(t *testing.T) {
+	ta := newTestApp(t)
+	target := entities.User{ID: entities.NewId(), Login: "user", MFA: entities.UserMFA{TOTPEnabled: true}}
+	ta.usersRepo.On("GetById", mock.Anything, target.ID).Return(target, nil)
+	ta.usersRepo.On("Update", mock.Anything, mock.MatchedBy(func(u entities.User) bool {
+		return u.ID == target.ID && !u.MFAEnabled()
+	})).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/"+target.ID.String()+"/mfa/reset", nil)
+	req.SetPathValue("id", target.ID.String())
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.ResetUserMFA(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	ta.usersRepo.AssertExpectations(t)
+}
+
+func TestResetUserMFA_Forbidden(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.Type = entities.RegularUser
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/x/mfa/reset", nil)
+	req.SetPathValue("id", entities.NewId().String())
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.ResetUserMFA(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
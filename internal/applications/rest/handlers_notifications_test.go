@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// --- GetNotificationPrefs ---
+
+func TestGetNotificationPrefs_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.Notifications = user.Notifications.Set(entities.NotificationNewSender, false)
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/notifications", nil)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.GetNotificationPrefs(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := NotificationPrefsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	// no queue is configured in the test app
+	assert.False(t, resp.Available)
+	assert.Equal(t, []NotificationPrefData{
+		{Event: NewSender, Enabled: false},
+		{Event: TokenExpiring, Enabled: true},
+		{Event: DomainVerificationLost, Enabled: true},
+		{Event: PgpKeyExpiring, Enabled: true},
+		{Event: AliasLeaked, Enabled: true},
+	}, resp.Events)
+}
+
+func TestGetNotificationPrefs_NoUser(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/notifications", nil)
+	w := httptest.NewRecorder()
+	ta.app.GetNotificationPrefs(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// --- UpdateNotificationPrefs ---
+
+func TestUpdateNotificationPrefs_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	ta.usersRepo.On("Update", mock.Anything, mock.MatchedBy(func(u entities.User) bool {
+		return !u.Notifications.Enabled(entities.NotificationTokenExpiring)
+	})).Return(nil)
+
+	body := bytes.NewBufferString(`{"events": {"token_expiring": false}}`)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/notifications", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.UpdateNotificationPrefs(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := NotificationPrefsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Contains(t, resp.Events, NotificationPrefData{Event: TokenExpiring, Enabled: false})
+	ta.usersRepo.AssertExpectations(t)
+}
+
+func TestUpdateNotificationPrefs_UnknownEvent(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	body := bytes.NewBufferString(`{"events": {"alias_deleted": false}}`)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/notifications", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.UpdateNotificationPrefs(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ta.usersRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateNotificationPrefs_InvalidBody(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/notifications", bytes.NewBufferString(`{`))
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.UpdateNotificationPrefs(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,227 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// withPasswordResets replaces the passwords service of the test app with one mailing reset links.
+func withPasswordResets(t *testing.T, ta *testApp) *recordingMailer {
+	t.Helper()
+	m := &recordingMailer{}
+	repof := &factory.RepoFactory{Users: ta.usersRepo, ApiTokens: ta.tokensRepo, Cache: ta.cache}
+	svc, err := services.NewPasswordsService(repof, entities.PasswordPolicy{}, m, config.ConfigPasswords{}, config.ConfigVerification{
+		BaseURL: "https://ovoo.example.com",
+		Secret:  "test-secret",
+	})
+	require.NoError(t, err)
+	ta.app.svcGw.Passwords = svc
+	return m
+}
+
+// testPasswordUser returns an active user logging in with the password
+func testPasswordUser(t *testing.T, password string) entities.User {
+	t.Helper()
+	user := testUserFull()
+	user.Active = true
+	var err error
+	user.PasswordHash, err = entities.NewPasswordHash(password)
+	require.NoError(t, err)
+	return user
+}
+
+func resetToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	link := regexp.MustCompile(`https://ovoo\.example\.com/api/v1/auth/password/reset\?token=\S+`).FindString(msg.Body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+// --- ChangePassword ---
+
+func TestChangePassword_NoUser(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/profile/password", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	ta.app.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestChangePassword_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testPasswordUser(t, "old password 1")
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	ta.usersRepo.On("Update", mock.Anything, mock.MatchedBy(func(u entities.User) bool {
+		return entities.ValidPassword("new password 1", u.PasswordHash)
+	})).Return(nil).Once()
+
+	body := `{"current_password":"old password 1","new_password":"new password 1"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/profile/password", strings.NewReader(body))
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	ta.usersRepo.AssertExpectations(t)
+}
+
+func TestChangePassword_WrongCurrent(t *testing.T) {
+	ta := newTestApp(t)
+	user := testPasswordUser(t, "old password 1")
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	body := `{"current_password":"wrong password","new_password":"new password 1"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/profile/password", strings.NewReader(body))
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ta.usersRepo.AssertNotCalled(t, "Update")
+}
+
+// --- ResetUserPassword ---
+
+func TestResetUserPassword_Success(t *testing.T) {
+	ta := newTestApp(t)
+	admin := testUser()
+	user := testPasswordUser(t, "old password 1")
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+	ta.usersRepo.On("Update", mock.Anything, mock.MatchedBy(func(u entities.User) bool {
+		return entities.ValidPassword("new password 1", u.PasswordHash)
+	})).Return(nil).Once()
+	ta.tokensRepo.On("BatchDeleteForUser", mock.Anything, admin, user.ID).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/"+user.ID.String()+"/password", strings.NewReader(`{"password":"new password 1"}`))
+	req.SetPathValue("id", user.ID.String())
+	req = withUser(req, admin)
+	w := httptest.NewRecorder()
+	ta.app.ResetUserPassword(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	ta.usersRepo.AssertExpectations(t)
+	ta.tokensRepo.AssertExpectations(t)
+}
+
+func TestResetUserPassword_NoLinks(t *testing.T) {
+	ta := newTestApp(t)
+	user := testPasswordUser(t, "old password 1")
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/"+user.ID.String()+"/password", strings.NewReader(`{}`))
+	req.SetPathValue("id", user.ID.String())
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.ResetUserPassword(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ta.usersRepo.AssertNotCalled(t, "Update")
+}
+
+// --- ForgotPassword / ResetPassword ---
+
+func TestForgotPassword_NotConfigured(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", strings.NewReader(`{"login":"admin@test.com"}`))
+	w := httptest.NewRecorder()
+	ta.app.ForgotPassword(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestForgotPassword_UnknownUser(t *testing.T) {
+	ta := newTestApp(t)
+	m := withPasswordResets(t, ta)
+	ta.usersRepo.On("GetByLogin", mock.Anything, "nobody@test.com").Return(entities.User{}, entities.ErrNotFound)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", strings.NewReader(`{"login":"nobody@test.com"}`))
+	w := httptest.NewRecorder()
+	ta.app.ForgotPassword(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, m.sent)
+}
+
+func TestGetPasswordReset(t *testing.T) {
+	ta := newTestApp(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/password/reset?token=abc%22def", nil)
+	w := httptest.NewRecorder()
+	ta.app.GetPasswordReset(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `action="/api/v1/auth/password/reset"`)
+	assert.Contains(t, w.Body.String(), `value="abc&#34;def"`)
+}
+
+func TestResetPassword_Form(t *testing.T) {
+	ta := newTestApp(t)
+	m := withPasswordResets(t, ta)
+	user := testPasswordUser(t, "old password 1")
+	ta.usersRepo.On("GetByLogin", mock.Anything, user.Login).Return(user, nil)
+	ta.usersRepo.On("GetById", mock.Anything, user.ID).Return(user, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", strings.NewReader(`{"login":"admin@test.com"}`))
+	w := httptest.NewRecorder()
+	ta.app.ForgotPassword(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Len(t, m.sent, 1)
+	token := resetToken(t, m.sent[0])
+
+	// a password not meeting the policy shows the form again
+	form := url.Values{"token": {token}, "password": {"short"}}
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/reset", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	ta.app.ResetPassword(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "at least")
+	assert.Contains(t, w.Body.String(), `name="token"`)
+
+	ta.usersRepo.On("Update", mock.Anything, mock.MatchedBy(func(u entities.User) bool {
+		return entities.ValidPassword("new password 1", u.PasswordHash)
+	})).Return(nil).Once()
+	ta.tokensRepo.On("BatchDeleteForUser", mock.Anything, mock.Anything, user.ID).Return(nil).Once()
+
+	form.Set("password", "new password 1")
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/reset", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	ta.app.ResetPassword(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Password changed")
+	ta.usersRepo.AssertExpectations(t)
+	ta.tokensRepo.AssertExpectations(t)
+}
+
+func TestResetPassword_InvalidToken(t *testing.T) {
+	ta := newTestApp(t)
+	withPasswordResets(t, ta)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/reset", strings.NewReader(`{"token":"bogus","password":"new password 1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ta.app.ResetPassword(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ta.usersRepo.AssertNotCalled(t, "Update")
+}
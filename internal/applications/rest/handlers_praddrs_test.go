@@ -2,16 +2,24 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+	"github.com/Burmuley/ovoo/internal/services"
 )
 
 // --- GetAllPrAddrs ---
@@ -196,6 +204,40 @@ func TestCreatePrAddr_Success(t *testing.T) {
 	ta.addrRepo.AssertExpectations(t)
 }
 
+func TestCreatePrAddr_InvalidPGPKey(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+
+	ta.addrRepo.On("GetByEmail", mock.Anything, entities.Email("new@example.com")).
+		Return([]entities.Address{}, nil)
+
+	body := bytes.NewBufferString(`{"email": "new@example.com", "metadata": {}, "pgp_key": "not a key"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/praddrs", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.CreatePrAddr(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ta.addrRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreatePrAddr_InvalidRules(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+
+	ta.addrRepo.On("GetByEmail", mock.Anything, entities.Email("new@example.com")).
+		Return([]entities.Address{}, nil)
+
+	body := bytes.NewBufferString(`{"email": "new@example.com", "metadata": {}, "rules": "fileinto \"Shop\";"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/praddrs", body)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.CreatePrAddr(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ta.addrRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 // --- DeletePrAddr ---
 
 func TestDeletePrAddr_NoUser(t *testing.T) {
@@ -306,3 +348,135 @@ func TestUpdatePrAddr_InvalidBody(t *testing.T) {
 	ta.app.UpdatePrAddr(w, req)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+// --- protected address verification ---
+
+type recordingMailer struct{ sent []mailer.Message }
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// withVerification replaces the protected addresses service of the test app with one
+// mailing confirmation links.
+func withVerification(t *testing.T, ta *testApp) *recordingMailer {
+	t.Helper()
+	m := &recordingMailer{}
+	svc, err := services.NewProtectedAddrService(&factory.RepoFactory{Address: ta.addrRepo}, m, config.ConfigVerification{
+		BaseURL: "https://ovoo.example.com",
+		Secret:  "test-secret",
+	})
+	require.NoError(t, err)
+	ta.app.svcGw.PrAddrs = svc
+	return m
+}
+
+// sendPendingPrAddrVerification requests the confirmation link of a pending address and returns the address.
+func sendPendingPrAddrVerification(t *testing.T, ta *testApp, m *recordingMailer) entities.Address {
+	t.Helper()
+	user := testUser()
+	prAddr := testProtectedAddr(user.ID)
+	prAddr.Pending = true
+	ta.addrRepo.On("GetById", mock.Anything, prAddr.ID).Return(prAddr, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/praddrs/"+prAddr.ID.String()+"/verification", nil)
+	req.SetPathValue("id", prAddr.ID.String())
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.SendPrAddrVerification(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Len(t, m.sent, 1)
+
+	return prAddr
+}
+
+func confirmToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	link := regexp.MustCompile(`https://ovoo\.example\.com/api/v1/praddrs/confirm\?token=\S+`).FindString(msg.Body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func TestSendPrAddrVerification_NotConfigured(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	prAddr := testProtectedAddr(user.ID)
+	prAddr.Pending = true
+	ta.addrRepo.On("GetById", mock.Anything, prAddr.ID).Return(prAddr, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/praddrs/"+prAddr.ID.String()+"/verification", nil)
+	req.SetPathValue("id", prAddr.ID.String())
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.SendPrAddrVerification(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetPrAddrConfirmation(t *testing.T) {
+	ta := newTestApp(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/praddrs/confirm?token=abc%22def", nil)
+	w := httptest.NewRecorder()
+	ta.app.GetPrAddrConfirmation(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `action="/api/v1/praddrs/confirm"`)
+	assert.Contains(t, w.Body.String(), `value="abc&#34;def"`)
+	ta.addrRepo.AssertNotCalled(t, "Update")
+}
+
+func TestConfirmPrAddr_Form(t *testing.T) {
+	ta := newTestApp(t)
+	m := withVerification(t, ta)
+	prAddr := sendPendingPrAddrVerification(t, ta, m)
+	ta.addrRepo.On("Update", mock.Anything, mock.MatchedBy(func(a entities.Address) bool { return !a.Pending })).Return(nil).Once()
+
+	form := url.Values{"token": {confirmToken(t, m.sent[0])}}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/praddrs/confirm", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	ta.app.ConfirmPrAddr(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "Address confirmed")
+	assert.Contains(t, w.Body.String(), string(prAddr.Email))
+	ta.addrRepo.AssertExpectations(t)
+}
+
+func TestConfirmPrAddr_JSON(t *testing.T) {
+	ta := newTestApp(t)
+	m := withVerification(t, ta)
+	sendPendingPrAddrVerification(t, ta, m)
+	ta.addrRepo.On("Update", mock.Anything, mock.MatchedBy(func(a entities.Address) bool { return !a.Pending })).Return(nil).Once()
+
+	body := fmt.Sprintf(`{"token":%q}`, confirmToken(t, m.sent[0]))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/praddrs/confirm", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ta.app.ConfirmPrAddr(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	ta.addrRepo.AssertExpectations(t)
+}
+
+func TestConfirmPrAddr_InvalidToken(t *testing.T) {
+	ta := newTestApp(t)
+	withVerification(t, ta)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/praddrs/confirm", strings.NewReader(`{"token":"bogus"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ta.app.ConfirmPrAddr(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/praddrs/confirm", strings.NewReader("token=bogus"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	ta.app.ConfirmPrAddr(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Confirmation failed")
+	ta.addrRepo.AssertNotCalled(t, "Update")
+}
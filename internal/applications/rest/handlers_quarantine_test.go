@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// The test app has no outgoing mail configured, so the quarantine is disabled.
+
+func TestGetQuarantine_Unavailable(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/quarantine", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetQuarantine(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := GetQuarantineResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Messages)
+}
+
+func TestGetQuarantinedMessage_NotFound(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/quarantine/x", nil)
+	req.SetPathValue("id", entities.NewId().String())
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetQuarantinedMessage(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHoldMessage_Unavailable(t *testing.T) {
+	ta := newTestApp(t)
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter"}
+
+	body, err := json.Marshal(HoldMessageRequest{
+		Hash:    entities.NewHash("sender@ext.com", "alias@test.com").String(),
+		Message: []byte("Subject: Hello\r\n\r\nHello\r\n"),
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/private/api/v1/quarantine", bytes.NewReader(body))
+	req = withUser(req, milter)
+	w := httptest.NewRecorder()
+	ta.app.HoldMessage(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHoldMessage_NotAuthorized(t *testing.T) {
+	ta := newTestApp(t)
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser}
+	req := httptest.NewRequest(http.MethodPost, "/private/api/v1/quarantine", bytes.NewReader([]byte(`{"hash":"h","message":""}`)))
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.HoldMessage(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
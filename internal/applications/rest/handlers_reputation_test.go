@@ -0,0 +1,179 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// memoryReputationRepo keeps the sender reputation in memory.
+type memoryReputationRepo struct {
+	senders map[entities.Email]entities.SenderReputation
+}
+
+func (r *memoryReputationRepo) GetBySender(ctx context.Context, sender entities.Email) (entities.SenderReputation, error) {
+	rep, ok := r.senders[sender]
+	if !ok {
+		return entities.SenderReputation{}, entities.ErrNotFound
+	}
+	return rep, nil
+}
+
+func (r *memoryReputationRepo) GetAll(ctx context.Context, filter entities.ReputationFilter) ([]entities.SenderReputation, entities.PaginationMetadata, error) {
+	reps := make([]entities.SenderReputation, 0, len(r.senders))
+	for _, rep := range r.senders {
+		reps = append(reps, rep)
+	}
+	return reps, entities.PaginationMetadata{}, nil
+}
+
+func (r *memoryReputationRepo) Save(ctx context.Context, rep entities.SenderReputation) error {
+	r.senders[rep.Sender] = rep
+	return nil
+}
+
+func (r *memoryReputationRepo) Delete(ctx context.Context, sender entities.Email) error {
+	if _, ok := r.senders[sender]; !ok {
+		return entities.ErrNotFound
+	}
+	delete(r.senders, sender)
+	return nil
+}
+
+func (r *memoryReputationRepo) DeleteFaded(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestCreateTrap(t *testing.T) {
+	ta := newTestApp(t)
+	ta.domainRepo.On("GetByName", mock.Anything, "example.com").Return(entities.CustomDomain{Name: "example.com", Active: true, Verified: true}, nil)
+	ta.addrRepo.On("GetByEmail", mock.Anything, entities.Email("trap@example.com")).Return(nil, entities.ErrNotFound)
+	ta.addrRepo.On("Create", mock.Anything, mock.AnythingOfType("entities.Address")).Return(nil)
+
+	body, err := json.Marshal(CreateTrapRequest{Email: "trap@example.com", Comment: new("scraped pages")})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/reputation/traps", bytes.NewReader(body))
+	req = withUser(req, testUserFull())
+	w := httptest.NewRecorder()
+	ta.app.CreateTrap(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp := TrapResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "trap@example.com", string(resp.Email))
+	assert.Equal(t, "scraped pages", *resp.Comment)
+	assert.True(t, resp.Active)
+}
+
+func TestCreateTrap_NotAuthorized(t *testing.T) {
+	ta := newTestApp(t)
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/reputation/traps", bytes.NewReader([]byte(`{"email":"trap@example.com"}`)))
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.CreateTrap(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestOverrideSenderReputation(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/reputation/senders/x", bytes.NewReader([]byte(`{"override":"block","comment":"spammer"}`)))
+	req.SetPathValue("sender", "Spam@Bulk.com")
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.OverrideSenderReputation(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := SenderReputationResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "spam@bulk.com", resp.Sender)
+	assert.Equal(t, Block, resp.Override)
+	assert.True(t, resp.Listed)
+	assert.Contains(t, ta.reputation.senders, entities.Email("spam@bulk.com"))
+}
+
+func TestGetSenderReputation(t *testing.T) {
+	ta := newTestApp(t)
+	ta.reputation.senders["spam@bulk.com"] = entities.SenderReputation{Sender: "spam@bulk.com", Hits: 2, Score: 2, LastHitAt: time.Now()}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reputation/senders?override=none", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetSenderReputation(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := GetSenderReputationResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Senders, 1)
+	assert.Equal(t, 2, resp.Senders[0].Hits)
+	assert.Equal(t, None, resp.Senders[0].Override)
+	assert.True(t, resp.Senders[0].Listed)
+}
+
+func TestGetSenderReputation_BadFilter(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reputation/senders?override=ignore", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetSenderReputation(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteSenderReputation_NotFound(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/reputation/senders/x", nil)
+	req.SetPathValue("sender", "news@shop.com")
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.DeleteSenderReputation(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLookupSenderReputation(t *testing.T) {
+	ta := newTestApp(t)
+	ta.reputation.senders["spam@bulk.com"] = entities.SenderReputation{Sender: "spam@bulk.com", Hits: 1, Score: 1, LastHitAt: time.Now()}
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter"}
+
+	tests := []struct {
+		sender     string
+		wantListed bool
+	}{
+		{sender: "spam@bulk.com", wantListed: true},
+		{sender: "news@shop.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sender, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/private/api/v1/reputation/senders/x", nil)
+			req.SetPathValue("sender", tt.sender)
+			req = withUser(req, milter)
+			w := httptest.NewRecorder()
+			ta.app.LookupSenderReputation(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			resp := SenderLookupResponse{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.sender, resp.Sender)
+			assert.Equal(t, tt.wantListed, resp.Listed)
+			assert.Equal(t, Reject, resp.Action)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+func testRole() entities.Role {
+	return entities.Role{
+		ID:          entities.NewId(),
+		Name:        "auditor",
+		Permissions: []entities.Permission{entities.PermAliasesReadAll},
+	}
+}
+
+// --- GetRoles ---
+
+func TestGetRoles_Success(t *testing.T) {
+	ta := newTestApp(t)
+	ta.rolesRepo.On("GetAll", mock.Anything, mock.AnythingOfType("entities.RoleFilter")).
+		Return([]entities.Role{testRole()}, entities.PaginationMetadata{TotalRecords: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetRoles(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := GetRolesResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Roles, 1)
+}
+
+func TestGetRoles_Forbidden(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.Type = entities.RegularUser
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.GetRoles(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// --- GetPermissions ---
+
+func TestGetPermissions_Success(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles/permissions", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetPermissions(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := GetPermissionsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp, len(entities.AllPermissions()))
+}
+
+// --- CreateRole ---
+
+func TestCreateRole_Success(t *testing.T) {
+	ta := newTestApp(t)
+	ta.rolesRepo.On("GetByName", mock.Anything, "auditor").Return(entities.Role{}, entities.ErrNotFound)
+	ta.rolesRepo.On("Create", mock.Anything, mock.AnythingOfType("entities.Role")).Return(nil)
+
+	body := `{"name":"auditor","permissions":["aliases:read:all"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/roles", bytes.NewBufferString(body))
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.CreateRole(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	ta.rolesRepo.AssertExpectations(t)
+}
+
+func TestCreateRole_UnknownPermission(t *testing.T) {
+	ta := newTestApp(t)
+
+	body := `{"name":"auditor","permissions":["aliases:destroy"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/roles", bytes.NewBufferString(body))
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.CreateRole(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// --- DeleteRole ---
+
+func TestDeleteRole_Assigned(t *testing.T) {
+	ta := newTestApp(t)
+	role := testRole()
+	ta.usersRepo.On("GetAll", mock.Anything, mock.AnythingOfType("entities.UserFilter")).
+		Return([]entities.User{testUserFull()}, entities.PaginationMetadata{TotalRecords: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/roles/"+role.ID.String(), nil)
+	req.SetPathValue("id", role.ID.String())
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.DeleteRole(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ta.rolesRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// --- UpdateUser with role ---
+
+func TestUpdateUser_AssignRole(t *testing.T) {
+	ta := newTestApp(t)
+	role := testRole()
+	target := testUserFull()
+	target.Type = entities.RegularUser
+	ta.usersRepo.On("GetById", mock.Anything, target.ID).Return(target, nil)
+	ta.rolesRepo.On("GetById", mock.Anything, role.ID).Return(role, nil)
+	ta.usersRepo.On("Update", mock.Anything, mock.MatchedBy(func(u entities.User) bool {
+		return u.Role != nil && u.Role.ID == role.ID
+	})).Return(nil)
+
+	body := `{"role_id":"` + role.ID.String() + `"}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+target.ID.String(), bytes.NewBufferString(body))
+	req.SetPathValue("id", target.ID.String())
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.UpdateUser(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := UserData{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Role)
+	assert.Equal(t, role.Name, resp.Role.Name)
+	ta.usersRepo.AssertExpectations(t)
+}
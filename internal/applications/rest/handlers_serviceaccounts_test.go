@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// --- GetServiceAccounts ---
+
+func TestGetServiceAccounts_Success(t *testing.T) {
+	ta := newTestApp(t)
+	account := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter"}
+	ta.usersRepo.On("GetAll", mock.Anything, mock.AnythingOfType("entities.UserFilter")).
+		Return([]entities.User{account}, entities.PaginationMetadata{TotalRecords: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/serviceaccounts", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetServiceAccounts(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := GetServiceAccountsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.ServiceAccounts, 1)
+	assert.Equal(t, "milter", resp.ServiceAccounts[0].Login)
+}
+
+func TestGetServiceAccounts_Forbidden(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.Type = entities.RegularUser
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/serviceaccounts", nil)
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.GetServiceAccounts(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// --- CreateServiceAccount ---
+
+func TestCreateServiceAccount_Success(t *testing.T) {
+	ta := newTestApp(t)
+	ta.usersRepo.On("GetByLogin", mock.Anything, "milter").Return(entities.User{}, entities.ErrNotFound)
+	ta.usersRepo.On("Create", mock.Anything, mock.AnythingOfType("entities.User")).Return(nil)
+	ta.tokensRepo.On("Create", mock.Anything, mock.AnythingOfType("entities.ApiToken")).Return(nil)
+
+	body := bytes.NewBufferString(`{"login": "milter", "description": "Milter", "expire_in": 30}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/serviceaccounts", body)
+	req = withUser(req, testUserFull())
+	w := httptest.NewRecorder()
+	ta.app.CreateServiceAccount(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	resp := CreateServiceAccountResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "milter", resp.ServiceAccount.Login)
+	assert.Equal(t, "milter", resp.ServiceAccount.Type)
+	assert.NotEmpty(t, resp.ApiToken.ApiToken)
+}
+
+func TestCreateServiceAccount_InvalidBody(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/serviceaccounts", bytes.NewBufferString(`{`))
+	req = withUser(req, testUserFull())
+	w := httptest.NewRecorder()
+	ta.app.CreateServiceAccount(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// --- RotateServiceAccountToken ---
+
+func TestRotateServiceAccountToken_Success(t *testing.T) {
+	ta := newTestApp(t)
+	account := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter", Active: true}
+	old := entities.ApiToken{ID: entities.NewId(), Owner: account, Active: true, Expiration: time.Now().Add(time.Hour * 48)}
+	ta.usersRepo.On("GetById", mock.Anything, account.ID).Return(account, nil)
+	ta.tokensRepo.On("GetAll", mock.Anything, mock.AnythingOfType("entities.ApiTokenFilter")).Return([]entities.ApiToken{old}, nil)
+	ta.tokensRepo.On("Create", mock.Anything, mock.AnythingOfType("entities.ApiToken")).Return(nil)
+	ta.tokensRepo.On("Update", mock.Anything, mock.MatchedBy(func(tok entities.ApiToken) bool {
+		return tok.ID == old.ID && tok.Active && tok.Expiration.Before(time.Now().Add(time.Hour))
+	})).Return(old, nil)
+
+	body := bytes.NewBufferString(`{"overlap": 600}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/serviceaccounts/"+account.ID.String()+"/rotate", body)
+	req.SetPathValue("id", account.ID.String())
+	req = withUser(req, testUserFull())
+	w := httptest.NewRecorder()
+	ta.app.RotateServiceAccountToken(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	ta.tokensRepo.AssertExpectations(t)
+}
@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/applications/rest/middleware"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// --- GetSessions ---
+
+func TestGetSessions_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.Active = true
+	current, _, err := ta.app.svcGw.Sessions.Create(context.Background(), user, services.SessionCreateCmd{UserAgent: "browser"})
+	require.NoError(t, err)
+	_, _, err = ta.app.svcGw.Sessions.Create(context.Background(), user, services.SessionCreateCmd{})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions", nil)
+	req = withUser(req, user)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.SessionContextKey, current.ID))
+	w := httptest.NewRecorder()
+	ta.app.GetSessions(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := GetSessionsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Sessions, 2)
+	assert.Equal(t, current.ID.String(), resp.Sessions[0].Id)
+	assert.True(t, resp.Sessions[0].Current)
+	require.NotNil(t, resp.Sessions[0].UserAgent)
+	assert.Equal(t, "browser", *resp.Sessions[0].UserAgent)
+	assert.False(t, resp.Sessions[1].Current)
+	assert.Nil(t, resp.Sessions[1].UserAgent)
+}
+
+func TestGetSessions_Empty(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions", nil)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.GetSessions(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"sessions": []}`, w.Body.String())
+}
+
+func TestGetSessions_NoUser(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions", nil)
+	w := httptest.NewRecorder()
+	ta.app.GetSessions(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// --- DeleteSession ---
+
+func TestDeleteSession_Success(t *testing.T) {
+	ta := newTestApp(t)
+	user := testUser()
+	user.Active = true
+	session, _, err := ta.app.svcGw.Sessions.Create(context.Background(), user, services.SessionCreateCmd{})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/"+session.ID.String(), nil)
+	req.SetPathValue("id", session.ID.String())
+	req = withUser(req, user)
+	w := httptest.NewRecorder()
+	ta.app.DeleteSession(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	sessions, err := ta.app.svcGw.Sessions.GetAll(context.Background(), user)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestDeleteSession_NotFound(t *testing.T) {
+	ta := newTestApp(t)
+	id := entities.SimpleHash("unknown").String()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/"+id, nil)
+	req.SetPathValue("id", id)
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.DeleteSession(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteSession_InvalidId(t *testing.T) {
+	ta := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/bad", nil)
+	req.SetPathValue("id", "bad")
+	req = withUser(req, testUser())
+	w := httptest.NewRecorder()
+	ta.app.DeleteSession(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/Burmuley/ovoo/internal/applications/rest/middleware"
+	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
 	"github.com/Burmuley/ovoo/internal/services"
@@ -84,6 +85,9 @@ func (m *mockChainRepo) Delete(ctx context.Context, cuser entities.User, hash en
 func (m *mockChainRepo) BatchDelete(ctx context.Context, cuser entities.User, hashes []entities.Hash) error {
 	return m.Called(ctx, cuser, hashes).Error(0)
 }
+func (m *mockChainRepo) Update(ctx context.Context, chain entities.Chain) error {
+	return m.Called(ctx, chain).Error(0)
+}
 
 type mockUsersRepo struct{ mock.Mock }
 
@@ -181,6 +185,31 @@ func newMockDomainRepo() *mockDomainRepo {
 	return dr
 }
 
+type mockRolesRepo struct{ mock.Mock }
+
+func (m *mockRolesRepo) GetById(ctx context.Context, id entities.Id) (entities.Role, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(entities.Role), args.Error(1)
+}
+func (m *mockRolesRepo) GetByName(ctx context.Context, name string) (entities.Role, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(entities.Role), args.Error(1)
+}
+func (m *mockRolesRepo) GetAll(ctx context.Context, filter entities.RoleFilter) ([]entities.Role, entities.PaginationMetadata, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entities.Role), args.Get(1).(entities.PaginationMetadata), args.Error(2)
+}
+func (m *mockRolesRepo) Create(ctx context.Context, role entities.Role) error {
+	return m.Called(ctx, role).Error(0)
+}
+func (m *mockRolesRepo) Update(ctx context.Context, role entities.Role) (entities.Role, error) {
+	args := m.Called(ctx, role)
+	return args.Get(0).(entities.Role), args.Error(1)
+}
+func (m *mockRolesRepo) Delete(ctx context.Context, cuser entities.User, id entities.Id) error {
+	return m.Called(ctx, cuser, id).Error(0)
+}
+
 // --- test helpers ---
 
 func buildTestApplication(t *testing.T, addrRepo *mockAddressRepo) *Application {
@@ -195,11 +224,11 @@ func buildTestApplication(t *testing.T, addrRepo *mockAddressRepo) *Application
 
 	aliasesSvc, err := services.NewAliasesService([]string{"alpha", "bravo", "charlie"}, repof)
 	require.NoError(t, err)
-	prAddrsSvc, err := services.NewProtectedAddrService(repof)
+	prAddrsSvc, err := services.NewProtectedAddrService(repof, nil, config.ConfigVerification{Disabled: true})
 	require.NoError(t, err)
-	usersSvc, err := services.NewUsersService(repof)
+	usersSvc, err := services.NewUsersService(repof, entities.PasswordPolicy{})
 	require.NoError(t, err)
-	chainsSvc, err := services.NewChainsService(repof)
+	chainsSvc, err := services.NewChainsService(repof, entities.ReputationPolicy{})
 	require.NoError(t, err)
 	tokensSvc, err := services.NewApiTokensService(repof)
 	require.NoError(t, err)
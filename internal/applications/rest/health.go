@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Burmuley/ovoo/internal/health"
+)
+
+// oidcDiscoveryPath is the path of the discovery document of an OIDC provider relative to its issuer
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// oidcDiscoveryCheck returns the readiness check of an OIDC provider, users can not sign in
+// with the provider when its discovery document is not reachable.
+func oidcDiscoveryCheck(client *http.Client, issuer string) health.Check {
+	url := strings.TrimSuffix(issuer, "/") + oidcDiscoveryPath
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("discovery document responded with status %d", resp.StatusCode)
+		}
+
+		return nil
+	}
+}
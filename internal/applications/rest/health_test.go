@@ -0,0 +1,27 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCDiscoveryCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realms/ovoo"+oidcDiscoveryPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"issuer":"` + "http://" + r.Host + `/realms/ovoo"}`))
+	}))
+	defer srv.Close()
+
+	assert.NoError(t, oidcDiscoveryCheck(srv.Client(), srv.URL+"/realms/ovoo/")(context.Background()))
+	assert.ErrorContains(t, oidcDiscoveryCheck(srv.Client(), srv.URL+"/realms/other")(context.Background()), "status 404")
+
+	srv.Close()
+	assert.Error(t, oidcDiscoveryCheck(srv.Client(), srv.URL+"/realms/ovoo")(context.Background()))
+}
@@ -0,0 +1,208 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// GetMFAStatus returns the second factors configured for the current user.
+func (a *Application) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting mfa status: identifying user", err)
+		return
+	}
+
+	mfa, err := a.svcGw.MFA.Get(r.Context(), cuser)
+	if err != nil {
+		a.errorLogNResponse(w, "getting mfa status", err)
+		return
+	}
+
+	resp := GetMFAStatusResponse{
+		Enabled:             mfa.Enabled(),
+		TotpEnabled:         mfa.TOTPEnabled,
+		TotpPending:         !mfa.TOTPEnabled && mfa.TOTPSecret != "",
+		RecoveryCodesLeft:   len(mfa.RecoveryCodes),
+		WebauthnAvailable:   new(a.svcGw.MFA.WebAuthnAvailable()),
+		WebauthnCredentials: make([]WebAuthnCredentialData, 0, len(mfa.WebAuthn)),
+	}
+	for _, cred := range mfa.WebAuthn {
+		resp.WebauthnCredentials = append(resp.WebauthnCredentials, webAuthnCredTData(cred))
+	}
+
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// EnrollTOTP starts TOTP enrollment for the current user.
+func (a *Application) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "enrolling totp: identifying user", err)
+		return
+	}
+
+	enrollment, err := a.svcGw.MFA.EnrollTOTP(r.Context(), cuser)
+	if err != nil {
+		a.errorLogNResponse(w, "enrolling totp", err)
+		return
+	}
+
+	resp := EnrollTOTPResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningUri: enrollment.URI,
+	}
+	a.successResponse(w, resp, http.StatusCreated)
+}
+
+// ConfirmTOTP enables the pending TOTP secret of the current user.
+func (a *Application) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "confirming totp: identifying user", err)
+		return
+	}
+
+	req := MfaCodeRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "confirming totp: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	codes, err := a.svcGw.MFA.ConfirmTOTP(r.Context(), cuser, req.Code)
+	if err != nil {
+		a.errorLogNResponse(w, "confirming totp", err)
+		return
+	}
+
+	a.successResponse(w, RecoveryCodesResponse{RecoveryCodes: recoveryCodesTList(codes)}, http.StatusOK)
+}
+
+// DisableTOTP removes the TOTP secret of the current user.
+func (a *Application) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "disabling totp: identifying user", err)
+		return
+	}
+
+	req := MfaCodeRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "disabling totp: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	if err := a.svcGw.MFA.DisableTOTP(r.Context(), cuser, req.Code); err != nil {
+		a.errorLogNResponse(w, "disabling totp", err)
+		return
+	}
+
+	a.successResponse(w, struct{}{}, http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user.
+func (a *Application) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "regenerating recovery codes: identifying user", err)
+		return
+	}
+
+	codes, err := a.svcGw.MFA.RegenerateRecoveryCodes(r.Context(), cuser)
+	if err != nil {
+		a.errorLogNResponse(w, "regenerating recovery codes", err)
+		return
+	}
+
+	a.successResponse(w, RecoveryCodesResponse{RecoveryCodes: recoveryCodesTList(codes)}, http.StatusOK)
+}
+
+// BeginWebAuthnRegistration starts registration of a WebAuthn credential for the current user.
+func (a *Application) BeginWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "beginning webauthn registration: identifying user", err)
+		return
+	}
+
+	creation, err := a.svcGw.MFA.BeginWebAuthnRegistration(r.Context(), cuser)
+	if err != nil {
+		a.errorLogNResponse(w, "beginning webauthn registration", err)
+		return
+	}
+
+	a.successResponse(w, creation, http.StatusOK)
+}
+
+// FinishWebAuthnRegistration verifies and stores the WebAuthn credential of the current user.
+func (a *Application) FinishWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "finishing webauthn registration: identifying user", err)
+		return
+	}
+
+	req := FinishWebAuthnRegistrationRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "finishing webauthn registration: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	credential, err := json.Marshal(req.Credential)
+	if err != nil {
+		a.errorLogNResponse(w, "finishing webauthn registration: parsing credential", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	var name string
+	if req.Name != nil {
+		name = *req.Name
+	}
+
+	cred, codes, err := a.svcGw.MFA.FinishWebAuthnRegistration(r.Context(), cuser, name, credential)
+	if err != nil {
+		a.errorLogNResponse(w, "finishing webauthn registration", err)
+		return
+	}
+
+	resp := FinishWebAuthnRegistrationResponse{
+		Credential:    webAuthnCredTData(cred),
+		RecoveryCodes: recoveryCodesTList(codes),
+	}
+	a.successResponse(w, resp, http.StatusCreated)
+}
+
+// DeleteWebAuthnCredential removes the WebAuthn credential of the current user.
+func (a *Application) DeleteWebAuthnCredential(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "deleting webauthn credential: identifying user", err)
+		return
+	}
+
+	if err := a.svcGw.MFA.DeleteWebAuthnCredential(r.Context(), cuser, entities.Id(r.PathValue("id"))); err != nil {
+		a.errorLogNResponse(w, "deleting webauthn credential", err)
+		return
+	}
+
+	a.successResponse(w, struct{}{}, http.StatusNoContent)
+}
+
+// ResetUserMFA removes all second factors of the user.
+func (a *Application) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "resetting user mfa: identifying user", err)
+		return
+	}
+
+	if err := a.svcGw.MFA.Reset(r.Context(), cuser, entities.Id(r.PathValue("id"))); err != nil {
+		a.errorLogNResponse(w, "resetting user mfa", err)
+		return
+	}
+
+	a.successResponse(w, struct{}{}, http.StatusNoContent)
+}
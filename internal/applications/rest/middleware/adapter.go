@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"net/http"
 	"slices"
+
+	"github.com/Burmuley/ovoo/internal/cache"
 )
 
 // logger is the shared logger instance used across middleware components.
@@ -23,6 +25,21 @@ func SetLogger(l *slog.Logger) error {
 	return nil
 }
 
+// oidcCache keeps cached OIDC UserInfo results and logouts, shared between the API instances
+// when a shared cache is configured. It must be initialized using SetCache.
+var oidcCache cache.Cache
+
+// SetCache sets the cache instance for the middleware package.
+// It returns an error if the provided cache is nil.
+func SetCache(c cache.Cache) error {
+	if c == nil {
+		return fmt.Errorf("middleware cache can not be nil")
+	}
+
+	oidcCache = c
+	return nil
+}
+
 // Adapter is a function type that transforms http.Handler into another http.Handler.
 // It allows middleware to wrap HTTP handlers with additional functionality.
 type Adapter func(http.Handler) http.Handler
@@ -25,6 +25,10 @@ const (
 	apiTokenCookieName = "ovoo_key"
 	authProvidersUrl   = "/auth/providers"
 	authLogoutUrl      = "/auth/logout"
+
+	// server-side session constants
+	sessionCookieName = "ovoo_session"
+	authLoginUrl      = "/api/v1/auth/login"
 )
 
 var oidcConfigs map[string]OIDCProvider
@@ -32,21 +36,29 @@ var oidcProviderNames []string
 
 type ContextKey string
 
-const UserContextKey ContextKey = "user"
+const (
+	UserContextKey    ContextKey = "user"
+	SessionContextKey ContextKey = "session" // id of the server-side session the request is authenticated with
+)
 
 // Authentication creates a middleware adapter for handling user authentication.
 // It supports multiple authentication methods and tries them in the following order:
 //   - OIDC/OAuth2 via access token in the Authorization header
 //   - OIDC/OAuth2 via access token and refresh token in HttpOnly cookies
-//   - Basic authentication (username/password)
+//   - Basic authentication (username/password), followed by the second factor
+//     exchange at /auth/mfa for users with two-factor authentication enabled
+//   - Server-side session cookie issued by the password login at /api/v1/auth/login
 //   - API token via Authorization header or cookie
 //
 // OIDC cookie flow:
-//  1. ovoo_access + ovoo_provider cookies present -> validate via UserInfo endpoint.
+//  1. ovoo_access + ovoo_provider cookies present -> validate via UserInfo endpoint or JWKS.
 //  2. Access token invalid -> ovoo_refresh cookie present -> refresh -> set new cookies -> validate.
 //
 // OIDC Bearer flow:
-//  1. Authorization: Bearer {access_token} -> resolve provider -> validate via UserInfo.
+//  1. Authorization: Bearer {access_token} -> resolve provider -> validate via UserInfo or JWKS.
+//
+// OIDC users are created on their first login when provisioning is enabled for the provider.
+// Providers end user sessions by posting a logout token to /auth/{provider}/backchannel-logout.
 //
 // Parameters:
 //   - skipUris: URI path prefixes that bypass authentication entirely
@@ -66,7 +78,17 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 			}
 
 			if r.URL.Path == authLogoutUrl {
-				logout(w, r)
+				logout(w, r, svcGw)
+				return
+			}
+
+			if r.URL.Path == authMFAUrl {
+				handleMFAVerify(w, r, svcGw)
+				return
+			}
+
+			if r.URL.Path == authLoginUrl {
+				handleLogin(w, r, svcGw)
 				return
 			}
 
@@ -106,7 +128,7 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 					return
 				}
 
-				handleOIDCCallback(w, r, provider, match[1])
+				handleOIDCCallback(w, r, provider, match[1], svcGw)
 				return
 			}
 
@@ -122,6 +144,18 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 				return
 			}
 
+			if match := oidcBackchannelUriReg.FindStringSubmatch(r.URL.Path); match != nil {
+				provider, ok := oidcConfigs[match[1]]
+				if !ok {
+					logger.Error("unknown OIDC provider back-channel logout URI", "src", r.RemoteAddr, "value", match[1])
+					http.Error(w, "unknown OIDC provider back-channel logout URI", http.StatusBadRequest)
+					return
+				}
+
+				handleOIDCBackchannelLogout(w, r, provider, match[1])
+				return
+			}
+
 			// Process Basic authentication (username/password) header
 			if username, password, ok := r.BasicAuth(); ok {
 				user, err := validateBasicAuth(r.Context(), username, password, svcGw)
@@ -131,11 +165,41 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 					return
 				}
 
+				// the password is only the first factor, the session is issued after
+				// the second factor exchange at authMFAUrl
+				if user.MFAEnabled() {
+					requireMFA(w, r, user, svcGw)
+					return
+				}
+
+				if mfaEnrollmentRequired(w, r, user, svcGw) {
+					return
+				}
+
 				r = r.WithContext(context.WithValue(r.Context(), UserContextKey, user))
 				h.ServeHTTP(w, r)
 				return
 			}
 
+			// Process server-side session cookie issued at authLoginUrl
+			if sessionCookie, err := r.Cookie(sessionCookieName); err == nil {
+				user, session, err := svcGw.Sessions.Validate(r.Context(), sessionCookie.Value)
+				if err == nil {
+					if mfaEnrollmentRequired(w, r, user, svcGw) {
+						return
+					}
+
+					ctx := context.WithValue(r.Context(), UserContextKey, user)
+					r = r.WithContext(context.WithValue(ctx, SessionContextKey, session.ID))
+					h.ServeHTTP(w, r)
+					return
+				}
+
+				// expired or revoked session, try other authentication methods
+				logger.Error("invalid session", "src", r.RemoteAddr, "error", err.Error())
+				setSecureCookie(w, r, sessionCookieName, "", -1, "/")
+			}
+
 			// Process ApiToken authorization header
 			apiToken := getApiToken(r)
 			if apiToken != "" {
@@ -161,16 +225,16 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 						return
 					}
 
-					email, err := validateAccessTokenViaUserInfo(r.Context(), bearerToken, prov)
+					claims, err := validateAccessToken(r.Context(), bearerToken, prov)
 					if err != nil {
 						logger.Error("access token validation failed", "src", r.RemoteAddr, "error", err.Error())
 						http.Error(w, "invalid OAuth2 credentials provided", http.StatusUnauthorized)
 						return
 					}
 
-					user, err := svcGw.Users.GetByLogin(r.Context(), email)
+					user, err := oidcUser(r.Context(), svcGw, prov, claims)
 					if err != nil {
-						logger.Error("user from OAuth2 token not found in database", "src", r.RemoteAddr, "error", err.Error())
+						logger.Error("cannot resolve user from OAuth2 token", "src", r.RemoteAddr, "error", err.Error())
 						http.Error(w, "invalid OAuth2 credentials provided", http.StatusUnauthorized)
 						return
 					}
@@ -192,10 +256,10 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 
 					// try current access token
 					if accessCookie, err := r.Cookie(accessCookieName); err == nil {
-						if email, err := validateAccessTokenViaUserInfo(r.Context(), accessCookie.Value, prov); err == nil {
-							user, err := svcGw.Users.GetByLogin(r.Context(), email)
+						if claims, err := validateAccessToken(r.Context(), accessCookie.Value, prov); err == nil {
+							user, err := oidcUser(r.Context(), svcGw, prov, claims)
 							if err != nil {
-								logger.Error("user from OAuth2 token not found in database", "src", r.RemoteAddr, "error", err.Error())
+								logger.Error("cannot resolve user from OAuth2 token", "src", r.RemoteAddr, "error", err.Error())
 								http.Error(w, "invalid OAuth2 credentials provided", http.StatusUnauthorized)
 								return
 							}
@@ -226,7 +290,7 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 						setNewOIDCCookies(w, r, newToken, providerCookie.Value)
 						w.Header().Set("X-Access-Token", newToken.AccessToken)
 
-						email, err := validateAccessTokenViaUserInfo(r.Context(), newToken.AccessToken, prov)
+						claims, err := validateAccessToken(r.Context(), newToken.AccessToken, prov)
 						if err != nil {
 							logger.Error("refreshed access token validation failed", "src", r.RemoteAddr, "error", err.Error())
 							clearOIDCCookies(w, r)
@@ -234,9 +298,9 @@ func Authentication(skipUris []string, svcGw *services.ServiceGateway) Adapter {
 							return
 						}
 
-						user, err := svcGw.Users.GetByLogin(r.Context(), email)
+						user, err := oidcUser(r.Context(), svcGw, prov, claims)
 						if err != nil {
-							logger.Error("user from refreshed OAuth2 token not found in database", "src", r.RemoteAddr, "error", err.Error())
+							logger.Error("cannot resolve user from refreshed OAuth2 token", "src", r.RemoteAddr, "error", err.Error())
 							http.Error(w, "invalid OAuth2 credentials provided", http.StatusUnauthorized)
 							return
 						}
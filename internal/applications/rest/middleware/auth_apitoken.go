@@ -66,5 +66,7 @@ func validateApiToken(ctx context.Context, svcGw *services.ServiceGateway, apiTo
 		return entities.User{}, errors.New("invalid, expired or inactive token or user account")
 	}
 
-	return token.Owner, nil
+	owner := token.Owner
+	owner.Scope = token.Scope()
+	return owner, nil
 }
@@ -37,5 +37,9 @@ func validateBasicAuth(ctx context.Context, username, password string, svcGw *se
 		return entities.User{}, fmt.Errorf("inactive user")
 	}
 
+	if user.IsServiceAccount() {
+		return entities.User{}, fmt.Errorf("service accounts can only authenticate with api tokens")
+	}
+
 	return user, nil
 }
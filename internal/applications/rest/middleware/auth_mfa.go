@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+const (
+	authMFAUrl          = "/auth/mfa"
+	mfaEnrollmentPrefix = "/api/v1/users/mfa"
+	mfaProfileUri       = "/api/v1/users/profile"
+	mfaMaxRequestSize   = 64 << 10
+)
+
+// mfaChallengeResponse is returned with 401 Unauthorized when the password check
+// succeeded, but the user has to provide the second factor.
+type mfaChallengeResponse struct {
+	MFARequired bool                          `json:"mfa_required"`
+	MFAToken    string                        `json:"mfa_token"`
+	Methods     []string                      `json:"methods"`
+	WebAuthn    *protocol.CredentialAssertion `json:"webauthn,omitempty"`
+	ExpiresAt   time.Time                     `json:"expires_at"`
+}
+
+// mfaVerifyRequest is the body of the second factor exchange request.
+type mfaVerifyRequest struct {
+	MFAToken     string          `json:"mfa_token"`
+	Code         string          `json:"code,omitempty"`
+	RecoveryCode string          `json:"recovery_code,omitempty"`
+	WebAuthn     json.RawMessage `json:"webauthn,omitempty"`
+}
+
+// requireMFA issues a second factor challenge for the user who passed the password check
+// and responds with 401 Unauthorized containing the challenge.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - r: HTTP request
+//   - user: user who passed the password check
+//   - svcGw: service gateway providing the MFA service
+func requireMFA(w http.ResponseWriter, r *http.Request, user entities.User, svcGw *services.ServiceGateway) {
+	challenge, err := svcGw.MFA.BeginLogin(r.Context(), user)
+	if err != nil {
+		logger.Error("issuing mfa challenge", "src", r.RemoteAddr, "user", user.Login, "error", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusUnauthorized, mfaChallengeResponse{
+		MFARequired: true,
+		MFAToken:    challenge.Token,
+		Methods:     challenge.Methods,
+		WebAuthn:    challenge.WebAuthn,
+		ExpiresAt:   challenge.ExpiresAt,
+	})
+}
+
+// handleMFAVerify exchanges the MFA challenge token and the second factor for a server-side
+// session stored in the ovoo_session HttpOnly cookie, like the one started by handleLogin.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - r: HTTP request with JSON encoded mfaVerifyRequest body
+//   - svcGw: service gateway providing the MFA and sessions services
+func handleMFAVerify(w http.ResponseWriter, r *http.Request, svcGw *services.ServiceGateway) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := mfaVerifyRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, mfaMaxRequestSize)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := verifyMFA(w, r, req, svcGw)
+	if !ok {
+		return
+	}
+
+	startSession(w, r, user, svcGw)
+}
+
+// verifyMFA checks the second factor provided for the MFA challenge and returns the authenticated user.
+// On failure the error response is written and false is returned.
+func verifyMFA(w http.ResponseWriter, r *http.Request, req mfaVerifyRequest, svcGw *services.ServiceGateway) (entities.User, bool) {
+	user, err := svcGw.MFA.VerifyLogin(r.Context(), services.MFAVerifyCmd{
+		Token:        req.MFAToken,
+		Code:         req.Code,
+		RecoveryCode: req.RecoveryCode,
+		WebAuthn:     req.WebAuthn,
+	})
+	if err != nil {
+		logger.Error("second factor verification failed", "src", r.RemoteAddr, "error", err.Error())
+		if errors.Is(err, entities.ErrValidation) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return entities.User{}, false
+		}
+
+		http.Error(w, "invalid second factor", http.StatusUnauthorized)
+		return entities.User{}, false
+	}
+
+	return user, true
+}
+
+// mfaEnrollmentRequired responds with 403 Forbidden and returns true when the user has to
+// enroll a second factor before accessing anything but the enrollment endpoints.
+func mfaEnrollmentRequired(w http.ResponseWriter, r *http.Request, user entities.User, svcGw *services.ServiceGateway) bool {
+	if !svcGw.MFA.EnforcementRequired(user) || mfaEnrollmentAllowed(r) {
+		return false
+	}
+
+	logger.Error("two-factor authentication enrollment required", "src", r.RemoteAddr, "user", user.Login, "path", r.URL.Path)
+	http.Error(w, "two-factor authentication enrollment is required", http.StatusForbidden)
+	return true
+}
+
+// mfaEnrollmentAllowed checks if the request targets an endpoint available to admin users
+// who have to enroll a second factor before using the rest of the API.
+func mfaEnrollmentAllowed(r *http.Request) bool {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	return path == mfaProfileUri || path == mfaEnrollmentPrefix || strings.HasPrefix(path, mfaEnrollmentPrefix+"/")
+}
+
+// writeJSON writes the value as JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("marshaling response", "error", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(resp); err != nil {
+		logger.Error("response write", "err", err.Error())
+	}
+}
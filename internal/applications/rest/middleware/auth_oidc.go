@@ -9,10 +9,11 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
-	"sync"
 	"time"
 
+	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
 	"github.com/coreos/go-oidc/v3/oidc"
 	"golang.org/x/oauth2"
 )
@@ -20,19 +21,22 @@ import (
 const (
 	refreshTokenMaxAge = 30 * 24 * 3600 // 30 days in seconds
 	userInfoCacheTTL   = 60 * time.Second
+	// userInfoKeyPrefix prefixes cached UserInfo results keyed by the access token hash
+	userInfoKeyPrefix = "oidc:userinfo:"
 )
 
+// cachedUserInfo is the UserInfo result stored in oidcCache.
 type cachedUserInfo struct {
-	email     string
-	expiresAt time.Time
+	Claims   json.RawMessage `json:"claims"`
+	CachedAt time.Time       `json:"cached_at"`
 }
 
-var userInfoCache sync.Map
-
 var (
 	oidcLoginUriReg    = regexp.MustCompile(`/auth/(\w+)/login`)
 	oidcCallbackUriReg = regexp.MustCompile(`/auth/(\w+)/callback`)
 	oidcRefreshUriReg  = regexp.MustCompile(`/auth/(\w+)/refresh`)
+	// oidcBackchannelUriReg is the OpenID Connect Back-Channel Logout endpoint called by the provider
+	oidcBackchannelUriReg = regexp.MustCompile(`/auth/(\w+)/backchannel-logout`)
 )
 
 type OIDCProvider struct {
@@ -42,6 +46,17 @@ type OIDCProvider struct {
 	Issuer         string
 	ExtraScopes    []string
 	ExtraURLParams map[string]string
+	// Provisioning enables creating users on their first login, nil disables it
+	Provisioning *config.ConfigOIDCProvisioning
+	// EndSessionEndpoint and RevocationEndpoint are taken from the provider discovery
+	// document, logout skips the corresponding step when empty
+	EndSessionEndpoint    string
+	RevocationEndpoint    string
+	PostLogoutRedirectURL string
+	// AccessTokenVerifier verifies JWT access tokens locally, nil validates them via UserInfo
+	AccessTokenVerifier *oidc.IDTokenVerifier
+	Audiences           []string // accepted audiences of JWT access tokens
+	EmailClaims         []string // JWT access token claims holding the user email
 }
 
 // SetOIDCConfigs stores the OIDC provider configurations and populates the list
@@ -58,8 +73,9 @@ func SetOIDCConfigs(configs map[string]OIDCProvider) {
 }
 
 // validateAccessTokenViaUserInfo validates an access token by calling the OIDC provider's
-// UserInfo endpoint and returns the user's email address. Results are cached for
-// userInfoCacheTTL seconds to avoid per-request network calls to the provider.
+// UserInfo endpoint and returns the user's claims. Results are cached in oidcCache for
+// userInfoCacheTTL to avoid per-request network calls to the provider. Cached results
+// older than a back-channel logout of the user are validated with the provider again.
 //
 // Parameters:
 //   - ctx: context for the UserInfo request
@@ -67,30 +83,88 @@ func SetOIDCConfigs(configs map[string]OIDCProvider) {
 //   - prov: the OIDCProvider configuration to use for the UserInfo call
 //
 // Returns:
-//   - string: the email address from the UserInfo response
-//   - error: an error if the UserInfo call fails or the response contains no email
-func validateAccessTokenViaUserInfo(ctx context.Context, accessToken string, prov OIDCProvider) (string, error) {
-	if v, ok := userInfoCache.Load(accessToken); ok {
-		if entry := v.(cachedUserInfo); time.Now().Before(entry.expiresAt) {
-			return entry.email, nil
+//   - oidcClaims: the claims from the UserInfo response
+//   - error: an error if the token is revoked, the UserInfo call fails or the response contains no email
+func validateAccessTokenViaUserInfo(ctx context.Context, accessToken string, prov OIDCProvider) (oidcClaims, error) {
+	revoked, err := accessTokenRevoked(ctx, accessToken)
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	if revoked {
+		return oidcClaims{}, errors.New("access token is revoked")
+	}
+
+	key := userInfoKey(accessToken)
+	if claims, cachedAt, err := loadUserInfo(ctx, key); err == nil {
+		loggedOut, err := oidcLoggedOut(ctx, prov.Issuer, claims, cachedAt)
+		if err != nil {
+			return oidcClaims{}, err
+		}
+
+		if !loggedOut {
+			return claims, nil
 		}
 	}
 
 	userInfo, err := prov.OIDCProvider.UserInfo(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken}))
 	if err != nil {
-		return "", err
+		return oidcClaims{}, err
+	}
+
+	entry := cachedUserInfo{CachedAt: time.Now()}
+	if err := userInfo.Claims(&entry.Claims); err != nil {
+		return oidcClaims{}, fmt.Errorf("parsing userinfo claims: %w", err)
+	}
+
+	claims, err := parseOIDCClaims(jsonDecoder(entry.Claims))
+	if err != nil {
+		return oidcClaims{}, fmt.Errorf("parsing userinfo claims: %w", err)
 	}
 
-	if userInfo.Email == "" {
-		return "", errors.New("userinfo response contains no email")
+	if claims.Email == "" {
+		return oidcClaims{}, errors.New("userinfo response contains no email")
 	}
 
-	userInfoCache.Store(accessToken, cachedUserInfo{
-		email:     userInfo.Email,
-		expiresAt: time.Now().Add(userInfoCacheTTL),
-	})
+	if data, err := json.Marshal(entry); err == nil {
+		if err := oidcCache.Set(ctx, key, data, userInfoCacheTTL); err != nil {
+			logger.Error("caching OIDC userinfo", "error", err.Error())
+		}
+	}
 
-	return userInfo.Email, nil
+	return claims, nil
+}
+
+// loadUserInfo returns the cached UserInfo claims and the time they were cached.
+func loadUserInfo(ctx context.Context, key string) (oidcClaims, time.Time, error) {
+	data, err := oidcCache.Get(ctx, key)
+	if err != nil {
+		return oidcClaims{}, time.Time{}, err
+	}
+
+	entry := cachedUserInfo{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return oidcClaims{}, time.Time{}, err
+	}
+
+	claims, err := parseOIDCClaims(jsonDecoder(entry.Claims))
+	if err != nil {
+		return oidcClaims{}, time.Time{}, err
+	}
+
+	return claims, entry.CachedAt, nil
+}
+
+// userInfoKey returns the oidcCache key of the UserInfo result, access tokens are not stored in the cache.
+func userInfoKey(accessToken string) string {
+	return userInfoKeyPrefix + entities.SimpleHash(accessToken).String()
+}
+
+// jsonDecoder returns a parseOIDCClaims decoder of the JSON encoded claims.
+func jsonDecoder(data []byte) func(v any) error {
+	return func(v any) error {
+		return json.Unmarshal(data, v)
+	}
 }
 
 // getBearerToken extracts an OAuth2 access token from the Authorization header.
@@ -204,24 +278,27 @@ func clearOIDCCookies(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleOIDCCallback completes the OAuth2 authorization code exchange initiated by
-// handleOIDCLogin. The ID token is verified only to validate the nonce; it is not
-// stored. The access token and refresh token are stored in HttpOnly cookies.
+// handleOIDCLogin. The ID token is verified to validate the nonce and, when provisioning
+// is enabled for the provider, to create or update the user; it is not stored.
+// The access token and refresh token are stored in HttpOnly cookies.
 //
 // The function performs the following steps:
 // 1. Verifies the state query parameter against the ovoo_state cookie.
 // 2. Exchanges the authorization code for an OAuth2 token.
 // 3. Verifies the ID token signature and nonce for replay protection.
-// 4. Stores the access token, refresh token, and provider name in HttpOnly cookies.
-// 5. Redirects the browser to RootPageURI.
+// 4. Provisions the user from the ID token claims if enabled for the provider.
+// 5. Stores the access token, refresh token, and provider name in HttpOnly cookies.
+// 6. Redirects the browser to RootPageURI.
 //
 // Parameters:
 //   - w: HTTP response writer used to set cookies and issue the redirect
 //   - r: HTTP request containing the state and code query parameters
 //   - prov: the OIDCProvider configuration to use for token exchange and verification
 //   - providerName: the provider key stored in the ovoo_provider cookie
+//   - svcGw: service gateway providing the users service for provisioning
 //
 // The function handles errors by writing appropriate HTTP error responses.
-func handleOIDCCallback(w http.ResponseWriter, r *http.Request, prov OIDCProvider, providerName string) {
+func handleOIDCCallback(w http.ResponseWriter, r *http.Request, prov OIDCProvider, providerName string, svcGw *services.ServiceGateway) {
 	state, err := r.Cookie(stateCookieName)
 	if err != nil {
 		http.Error(w, "state not found", http.StatusBadRequest)
@@ -268,6 +345,21 @@ func handleOIDCCallback(w http.ResponseWriter, r *http.Request, prov OIDCProvide
 		return
 	}
 
+	// create or update the user from the ID token claims on login
+	if prov.Provisioning != nil {
+		claims, err := parseOIDCClaims(idToken.Claims)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to parse id token claims: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := oidcUser(r.Context(), svcGw, prov, claims); err != nil {
+			logger.Error("provisioning OIDC user", "src", r.RemoteAddr, "provider", providerName, "email", claims.Email, "error", err.Error())
+			http.Error(w, "sign-in is not allowed for this account", http.StatusForbidden)
+			return
+		}
+	}
+
 	if oauth2Token.RefreshToken == "" {
 		logger.Warn("OIDC provider returned no refresh token")
 	}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+const (
+	// AccessTokenValidationUserInfo validates access tokens with the provider UserInfo endpoint
+	AccessTokenValidationUserInfo = "userinfo"
+	// AccessTokenValidationJWT verifies JWT access tokens locally against the provider JWKS
+	AccessTokenValidationJWT = "jwt"
+	// oidcRevocationTTL is how long logouts are remembered, should exceed the access token
+	// lifetime of the providers
+	oidcRevocationTTL = 24 * time.Hour
+	// revokedTokenKeyPrefix prefixes access tokens revoked on logout, keyed by the token hash
+	revokedTokenKeyPrefix = "oidc:revoked:"
+	// logoutKeyPrefix prefixes back-channel logouts of provider subjects and sessions
+	logoutKeyPrefix = "oidc:logout:"
+)
+
+// DefaultOIDCEmailClaims are the JWT access token claims holding the user email when none are configured
+var DefaultOIDCEmailClaims = []string{"email"}
+
+// validateAccessToken validates the access token with the method configured for the provider.
+//
+// Parameters:
+//   - ctx: context for the provider requests
+//   - accessToken: the OAuth2 access token to validate
+//   - prov: the OIDCProvider which issued the token
+//
+// Returns:
+//   - oidcClaims: the claims of the token owner
+//   - error: an error if the token is invalid
+func validateAccessToken(ctx context.Context, accessToken string, prov OIDCProvider) (oidcClaims, error) {
+	if prov.AccessTokenVerifier != nil {
+		return validateAccessTokenJWT(ctx, accessToken, prov)
+	}
+
+	return validateAccessTokenViaUserInfo(ctx, accessToken, prov)
+}
+
+// validateAccessTokenJWT verifies a JWT access token locally: the signature against the provider
+// JWKS, which is fetched again when the token is signed with an unknown key, the "iss" and
+// "exp" claims, and the "aud" claim against the accepted audiences. The email is taken from the
+// first present claim of the configured email claims.
+//
+// Parameters:
+//   - ctx: context for the JWKS request
+//   - accessToken: the JWT access token to validate
+//   - prov: the OIDCProvider which issued the token
+//
+// Returns:
+//   - oidcClaims: the claims from the access token
+//   - error: an error if the token is invalid, revoked or contains no email
+func validateAccessTokenJWT(ctx context.Context, accessToken string, prov OIDCProvider) (oidcClaims, error) {
+	token, err := prov.AccessTokenVerifier.Verify(ctx, accessToken)
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	if !slices.ContainsFunc(token.Audience, func(aud string) bool { return slices.Contains(prov.Audiences, aud) }) {
+		return oidcClaims{}, fmt.Errorf("access token audience %v is not accepted", token.Audience)
+	}
+
+	claims, err := parseOIDCClaims(token.Claims)
+	if err != nil {
+		return oidcClaims{}, fmt.Errorf("parsing access token claims: %w", err)
+	}
+
+	claims.Email = ""
+	for _, name := range prov.EmailClaims {
+		if email, ok := claims.Raw[name].(string); ok && email != "" {
+			claims.Email = email
+			break
+		}
+	}
+
+	if claims.Email == "" {
+		return oidcClaims{}, errors.New("access token contains no email")
+	}
+
+	revoked, err := accessTokenRevoked(ctx, accessToken)
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	loggedOut, err := oidcLoggedOut(ctx, prov.Issuer, claims, token.IssuedAt)
+	if err != nil {
+		return oidcClaims{}, err
+	}
+
+	if revoked || loggedOut {
+		return oidcClaims{}, errors.New("access token is revoked")
+	}
+
+	return claims, nil
+}
+
+// revokeAccessToken remembers the logout of the access token, so it is rejected even if
+// the provider can not revoke it or the token is validated locally.
+func revokeAccessToken(ctx context.Context, accessToken string) error {
+	return storeLogout(ctx, revokedTokenKeyPrefix+entities.SimpleHash(accessToken).String(), time.Now())
+}
+
+// revokeOIDCSession remembers the back-channel logout of the provider subject or session.
+func revokeOIDCSession(ctx context.Context, issuer, subject, sid string) error {
+	now := time.Now()
+	for _, key := range logoutKeys(issuer, subject, sid) {
+		if err := storeLogout(ctx, key, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// accessTokenRevoked reports whether the access token was revoked on logout.
+func accessTokenRevoked(ctx context.Context, accessToken string) (bool, error) {
+	_, found, err := loadLogout(ctx, revokedTokenKeyPrefix+entities.SimpleHash(accessToken).String())
+	return found, err
+}
+
+// oidcLoggedOut reports whether the subject or the provider session of the claims
+// logged out at or after the given time, e.g. when the token was issued.
+func oidcLoggedOut(ctx context.Context, issuer string, claims oidcClaims, at time.Time) (bool, error) {
+	for _, key := range logoutKeys(issuer, claims.Subject, claims.SessionID) {
+		logoutAt, found, err := loadLogout(ctx, key)
+		if err != nil {
+			return false, err
+		}
+
+		if found && !at.After(logoutAt) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// logoutKeys returns the oidcCache keys of the logouts of the subject and the provider session.
+func logoutKeys(issuer, subject, sid string) []string {
+	keys := make([]string, 0, 2)
+	if subject != "" {
+		keys = append(keys, logoutKeyPrefix+"sub:"+entities.SimpleHash(issuer+" "+subject).String())
+	}
+
+	if sid != "" {
+		keys = append(keys, logoutKeyPrefix+"sid:"+entities.SimpleHash(issuer+" "+sid).String())
+	}
+
+	return keys
+}
+
+func storeLogout(ctx context.Context, key string, at time.Time) error {
+	data, err := at.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	return oidcCache.Set(ctx, key, data, oidcRevocationTTL)
+}
+
+func loadLogout(ctx context.Context, key string) (time.Time, bool, error) {
+	data, err := oidcCache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("checking OIDC logouts: %w", err)
+	}
+
+	at := time.Time{}
+	if err := at.UnmarshalText(data); err != nil {
+		return time.Time{}, false, fmt.Errorf("checking OIDC logouts: %w", err)
+	}
+
+	return at, true, nil
+}
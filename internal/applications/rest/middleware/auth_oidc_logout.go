@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+const (
+	// backchannelLogoutEvent is the member of the logout token "events" claim identifying a logout
+	backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+	// backchannelMaxRequestSize limits the size of the back-channel logout request body
+	backchannelMaxRequestSize = 64 * 1024
+)
+
+// oidcHTTPClient is used for the calls to the provider token revocation endpoint
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// logoutTokenClaims holds the Back-Channel Logout token claims not exposed by oidc.IDToken.
+type logoutTokenClaims struct {
+	SessionID string                     `json:"sid"`
+	Events    map[string]json.RawMessage `json:"events"`
+	Nonce     *string                    `json:"nonce"`
+}
+
+// handleOIDCBackchannelLogout implements the OpenID Connect Back-Channel Logout endpoint.
+// The provider posts a signed logout_token when the user session ends at the provider.
+// The logout is recorded in oidcCache: cached UserInfo results of the session older than
+// the logout are validated with the provider again, and locally validated JWT access tokens
+// issued before the logout are rejected.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - r: HTTP request with form encoded logout_token
+//   - prov: the OIDCProvider the endpoint belongs to
+//   - providerName: the provider key used for logging
+func handleOIDCBackchannelLogout(w http.ResponseWriter, r *http.Request, prov OIDCProvider, providerName string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, backchannelMaxRequestSize)
+	subject, sid, err := verifyLogoutToken(r.Context(), prov, r.PostFormValue("logout_token"))
+	if err != nil {
+		logger.Error("invalid OIDC logout token", "src", r.RemoteAddr, "provider", providerName, "error", err.Error())
+		writeJSON(w, http.StatusBadRequest, struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}{"invalid_request", err.Error()})
+		return
+	}
+
+	if err := revokeOIDCSession(r.Context(), prov.Issuer, subject, sid); err != nil {
+		logger.Error("recording OIDC back-channel logout", "provider", providerName, "error", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("OIDC back-channel logout", "provider", providerName, "sub", subject, "sid", sid)
+	w.WriteHeader(http.StatusOK)
+}
+
+// verifyLogoutToken validates the logout token as required by the OpenID Connect
+// Back-Channel Logout specification: signature, issuer, audience and expiry like an ID token,
+// the logout event, the subject or session identifier, and no nonce.
+//
+// Returns:
+//   - string: the "sub" claim, may be empty
+//   - string: the "sid" claim, may be empty
+//   - error: an error if the token is invalid
+func verifyLogoutToken(ctx context.Context, prov OIDCProvider, rawToken string) (string, string, error) {
+	if rawToken == "" {
+		return "", "", errors.New("logout_token is required")
+	}
+
+	token, err := prov.OIDCProvider.Verifier(prov.OIDCConfig).Verify(ctx, rawToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := logoutTokenClaims{}
+	if err := token.Claims(&claims); err != nil {
+		return "", "", fmt.Errorf("parsing logout token claims: %w", err)
+	}
+
+	if _, ok := claims.Events[backchannelLogoutEvent]; !ok {
+		return "", "", errors.New("logout token has no logout event")
+	}
+
+	if claims.Nonce != nil {
+		return "", "", errors.New("logout token must not contain nonce")
+	}
+
+	if token.Subject == "" && claims.SessionID == "" {
+		return "", "", errors.New("logout token has neither sub nor sid")
+	}
+
+	return token.Subject, claims.SessionID, nil
+}
+
+// oidcLogout ends the provider session of the browser on logout: the refresh and access
+// tokens from the cookies are revoked at the provider (RFC 7009), the cached UserInfo
+// result is dropped and the access token is recorded as revoked in oidcCache.
+// It returns the URL the browser should be redirected to, which is the provider
+// end_session_endpoint for RP-initiated logout when the provider supports it.
+//
+// Parameters:
+//   - r: HTTP request carrying the OIDC cookies
+//   - prov: the OIDCProvider from the ovoo_provider cookie
+//
+// Returns:
+//   - string: the provider logout URL, or RootPageURI
+func oidcLogout(r *http.Request, prov OIDCProvider) string {
+	if refreshCookie, err := r.Cookie(refreshCookieName); err == nil && refreshCookie.Value != "" {
+		if err := revokeOIDCToken(r.Context(), prov, refreshCookie.Value, "refresh_token"); err != nil {
+			logger.Error("revoking OIDC refresh token", "src", r.RemoteAddr, "error", err.Error())
+		}
+	}
+
+	if accessCookie, err := r.Cookie(accessCookieName); err == nil && accessCookie.Value != "" {
+		if err := oidcCache.Delete(r.Context(), userInfoKey(accessCookie.Value)); err != nil && !errors.Is(err, entities.ErrNotFound) {
+			logger.Error("dropping cached OIDC userinfo", "src", r.RemoteAddr, "error", err.Error())
+		}
+
+		if err := revokeAccessToken(r.Context(), accessCookie.Value); err != nil {
+			logger.Error("recording revoked OIDC access token", "src", r.RemoteAddr, "error", err.Error())
+		}
+
+		if err := revokeOIDCToken(r.Context(), prov, accessCookie.Value, "access_token"); err != nil {
+			logger.Error("revoking OIDC access token", "src", r.RemoteAddr, "error", err.Error())
+		}
+	}
+
+	if prov.EndSessionEndpoint == "" {
+		return RootPageURI
+	}
+
+	endSession, err := url.Parse(prov.EndSessionEndpoint)
+	if err != nil {
+		logger.Error("invalid OIDC end_session_endpoint", "value", prov.EndSessionEndpoint, "error", err.Error())
+		return RootPageURI
+	}
+
+	postLogoutUrl := prov.PostLogoutRedirectURL
+	if postLogoutUrl == "" {
+		postLogoutUrl = absoluteURL(r, RootPageURI)
+	}
+
+	query := endSession.Query()
+	query.Set("client_id", prov.OAuth2Config.ClientID)
+	query.Set("post_logout_redirect_uri", postLogoutUrl)
+	endSession.RawQuery = query.Encode()
+
+	return endSession.String()
+}
+
+// revokeOIDCToken revokes the token at the provider revocation endpoint as defined by RFC 7009.
+// Confidential clients authenticate with HTTP Basic authentication, public clients send
+// their client_id. Does nothing when the provider has no revocation endpoint.
+//
+// Parameters:
+//   - ctx: context for the revocation request
+//   - prov: the OIDCProvider which issued the token
+//   - token: the token to revoke
+//   - hint: the token_type_hint, "access_token" or "refresh_token"
+func revokeOIDCToken(ctx context.Context, prov OIDCProvider, token, hint string) error {
+	if prov.RevocationEndpoint == "" {
+		return nil
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {hint}}
+	if prov.OAuth2Config.ClientSecret == "" {
+		form.Set("client_id", prov.OAuth2Config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, prov.RevocationEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if prov.OAuth2Config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(prov.OAuth2Config.ClientID), url.QueryEscape(prov.OAuth2Config.ClientSecret))
+	}
+
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("revocation endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
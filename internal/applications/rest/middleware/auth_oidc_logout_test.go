@@ -0,0 +1,330 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logoutClaims returns the claims of a back-channel logout token of the stub IdP for the subject and session.
+func (idp *stubIdP) logoutClaims(sub, sid string) map[string]any {
+	now := time.Now()
+	claims := map[string]any{
+		"iss":    idp.srv.URL,
+		"aud":    "ovoo",
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Minute).Unix(),
+		"jti":    "logout-1",
+		"events": map[string]any{backchannelLogoutEvent: map[string]any{}},
+	}
+	if sub != "" {
+		claims["sub"] = sub
+	}
+	if sid != "" {
+		claims["sid"] = sid
+	}
+
+	return claims
+}
+
+// postLogoutToken posts the logout token to the back-channel logout endpoint of the provider.
+func postLogoutToken(t *testing.T, prov OIDCProvider, token string) *httptest.ResponseRecorder {
+	require.NoError(t, SetLogger(slog.New(slog.DiscardHandler)))
+
+	form := url.Values{"logout_token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/stub/backchannel-logout", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handleOIDCBackchannelLogout(w, req, prov, "stub")
+
+	return w
+}
+
+// revocationRequest is a request received by the stub revocation endpoint
+type revocationRequest struct {
+	form      url.Values
+	user      string
+	password  string
+	basicAuth bool
+}
+
+// revocationServer records the requests to a token revocation endpoint answering with the status.
+func revocationServer(t *testing.T, status int) (*httptest.Server, func() []revocationRequest) {
+	var (
+		mu       sync.Mutex
+		requests []revocationRequest
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		user, password, ok := r.BasicAuth()
+
+		mu.Lock()
+		requests = append(requests, revocationRequest{form: r.PostForm, user: user, password: password, basicAuth: ok})
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []revocationRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestVerifyLogoutToken_Valid(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+
+	sub, sid, err := verifyLogoutToken(t.Context(), prov, idp.sign(t, idp.logoutClaims("alice", "session-1")))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", sub)
+	assert.Equal(t, "session-1", sid)
+
+	// either claim identifies the logout
+	sub, sid, err = verifyLogoutToken(t.Context(), prov, idp.sign(t, idp.logoutClaims("", "session-2")))
+	require.NoError(t, err)
+	assert.Empty(t, sub)
+	assert.Equal(t, "session-2", sid)
+}
+
+func TestVerifyLogoutToken_Invalid(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	other := newStubIdP(t)
+
+	tests := []struct {
+		name   string
+		token  func() string
+		errMsg string
+	}{
+		{
+			name:   "missing",
+			token:  func() string { return "" },
+			errMsg: "logout_token is required",
+		},
+		{
+			name:   "signed by unknown key",
+			token:  func() string { return other.sign(t, idp.logoutClaims("alice", "")) },
+			errMsg: "signature",
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				c := idp.logoutClaims("alice", "")
+				c["aud"] = "other-client"
+				return idp.sign(t, c)
+			},
+			errMsg: "audience",
+		},
+		{
+			name: "no events",
+			token: func() string {
+				c := idp.logoutClaims("alice", "")
+				delete(c, "events")
+				return idp.sign(t, c)
+			},
+			errMsg: "no logout event",
+		},
+		{
+			name: "no back-channel logout event",
+			token: func() string {
+				c := idp.logoutClaims("alice", "")
+				c["events"] = map[string]any{"http://schemas.openid.net/event/other": map[string]any{}}
+				return idp.sign(t, c)
+			},
+			errMsg: "no logout event",
+		},
+		{
+			name: "nonce",
+			token: func() string {
+				c := idp.logoutClaims("alice", "")
+				c["nonce"] = "n-0S6_WzA2Mj"
+				return idp.sign(t, c)
+			},
+			errMsg: "nonce",
+		},
+		{
+			name:   "neither sub nor sid",
+			token:  func() string { return idp.sign(t, idp.logoutClaims("", "")) },
+			errMsg: "neither sub nor sid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := verifyLogoutToken(t.Context(), prov, tt.token())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestHandleOIDCBackchannelLogout_MethodNotAllowed(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+
+	w := httptest.NewRecorder()
+	handleOIDCBackchannelLogout(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/stub/backchannel-logout", nil), prov, "stub")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandleOIDCBackchannelLogout_InvalidToken(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	token := idp.sign(t, idp.claims("alice"))
+
+	c := idp.logoutClaims("alice", "")
+	c["nonce"] = "n-0S6_WzA2Mj"
+	w := postLogoutToken(t, prov, idp.sign(t, c))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_request")
+
+	// nothing is revoked
+	_, err := validateAccessToken(t.Context(), token, prov)
+	assert.NoError(t, err)
+}
+
+func TestHandleOIDCBackchannelLogout_Success(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	userInfoProv := prov
+	userInfoProv.AccessTokenVerifier = nil
+	idp.userInfo["opaque-1"] = map[string]any{"sub": "alice", "email": "alice@example.com"}
+	idp.userInfo["opaque-2"] = map[string]any{"sub": "bob", "email": "bob@example.com"}
+
+	aliceToken := idp.sign(t, idp.claims("alice"))
+	bobToken := idp.sign(t, idp.claims("bob"))
+	for _, token := range []string{aliceToken, bobToken} {
+		_, err := validateAccessToken(t.Context(), token, prov)
+		require.NoError(t, err)
+	}
+	for _, token := range []string{"opaque-1", "opaque-2"} {
+		_, err := validateAccessToken(t.Context(), token, userInfoProv)
+		require.NoError(t, err)
+	}
+
+	w := postLogoutToken(t, prov, idp.sign(t, idp.logoutClaims("alice", "")))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// the JWT issued before the logout is rejected
+	_, err := validateAccessToken(t.Context(), aliceToken, prov)
+	assert.ErrorContains(t, err, "revoked")
+
+	// the cached UserInfo result is validated with the provider again, which ended the session
+	delete(idp.userInfo, "opaque-1")
+	_, err = validateAccessToken(t.Context(), "opaque-1", userInfoProv)
+	assert.Error(t, err)
+
+	// other subjects are not logged out
+	_, err = validateAccessToken(t.Context(), bobToken, prov)
+	assert.NoError(t, err)
+	_, err = validateAccessToken(t.Context(), "opaque-2", userInfoProv)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, idp.userInfoCalls)
+}
+
+func TestOIDCLogout_EndSession(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	require.NoError(t, SetLogger(slog.New(slog.DiscardHandler)))
+	srv, requests := revocationServer(t, http.StatusOK)
+	prov.RevocationEndpoint = srv.URL
+	prov.EndSessionEndpoint = idp.srv.URL + "/logout?ui_locales=en"
+
+	req := httptest.NewRequest(http.MethodPost, "http://ovoo.example.com/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: refreshCookieName, Value: "refresh-1"})
+	req.AddCookie(&http.Cookie{Name: accessCookieName, Value: "access-1"})
+
+	target, err := url.Parse(oidcLogout(req, prov))
+	require.NoError(t, err)
+	assert.Equal(t, idp.srv.URL+"/logout", target.Scheme+"://"+target.Host+target.Path)
+	assert.Equal(t, url.Values{
+		"ui_locales":               {"en"},
+		"client_id":                {"ovoo"},
+		"post_logout_redirect_uri": {"http://ovoo.example.com/"},
+	}, target.Query())
+
+	// both tokens are revoked at the provider, the access token locally as well
+	got := requests()
+	require.Len(t, got, 2)
+	assert.Equal(t, "refresh-1", got[0].form.Get("token"))
+	assert.Equal(t, "refresh_token", got[0].form.Get("token_type_hint"))
+	assert.Equal(t, "access-1", got[1].form.Get("token"))
+	assert.Equal(t, "access_token", got[1].form.Get("token_type_hint"))
+	revoked, err := accessTokenRevoked(t.Context(), "access-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// the configured redirect is used when set
+	prov.PostLogoutRedirectURL = "https://app.example.com/bye"
+	target, err = url.Parse(oidcLogout(httptest.NewRequest(http.MethodPost, "/auth/logout", nil), prov))
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/bye", target.Query().Get("post_logout_redirect_uri"))
+}
+
+func TestOIDCLogout_NoEndSession(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	require.NoError(t, SetLogger(slog.New(slog.DiscardHandler)))
+
+	assert.Equal(t, RootPageURI, oidcLogout(httptest.NewRequest(http.MethodPost, "/auth/logout", nil), prov))
+
+	prov.EndSessionEndpoint = "://invalid"
+	assert.Equal(t, RootPageURI, oidcLogout(httptest.NewRequest(http.MethodPost, "/auth/logout", nil), prov))
+}
+
+func TestRevokeOIDCToken(t *testing.T) {
+	idp := newStubIdP(t)
+
+	t.Run("confidential client", func(t *testing.T) {
+		prov := idp.provider(t)
+		srv, requests := revocationServer(t, http.StatusOK)
+		prov.RevocationEndpoint = srv.URL
+		prov.OAuth2Config.ClientID = "ovoo app"
+		prov.OAuth2Config.ClientSecret = "s3cr:t"
+
+		require.NoError(t, revokeOIDCToken(t.Context(), prov, "refresh-1", "refresh_token"))
+		got := requests()
+		require.Len(t, got, 1)
+		// the credentials are form encoded before the Basic authentication (RFC 6749, section 2.3.1)
+		assert.True(t, got[0].basicAuth)
+		assert.Equal(t, "ovoo+app", got[0].user)
+		assert.Equal(t, "s3cr%3At", got[0].password)
+		assert.Equal(t, url.Values{"token": {"refresh-1"}, "token_type_hint": {"refresh_token"}}, got[0].form)
+	})
+
+	t.Run("public client", func(t *testing.T) {
+		prov := idp.provider(t)
+		srv, requests := revocationServer(t, http.StatusOK)
+		prov.RevocationEndpoint = srv.URL
+
+		require.NoError(t, revokeOIDCToken(t.Context(), prov, "access-1", "access_token"))
+		got := requests()
+		require.Len(t, got, 1)
+		assert.False(t, got[0].basicAuth)
+		assert.Equal(t, url.Values{"token": {"access-1"}, "token_type_hint": {"access_token"}, "client_id": {"ovoo"}}, got[0].form)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		prov := idp.provider(t)
+		srv, _ := revocationServer(t, http.StatusBadRequest)
+		prov.RevocationEndpoint = srv.URL
+
+		assert.ErrorContains(t, revokeOIDCToken(t.Context(), prov, "access-1", "access_token"), "400")
+	})
+
+	t.Run("no revocation endpoint", func(t *testing.T) {
+		assert.NoError(t, revokeOIDCToken(t.Context(), idp.provider(t), "access-1", "access_token"))
+	})
+}
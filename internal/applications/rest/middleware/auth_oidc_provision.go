@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// DefaultOIDCRoleClaim is the claim mapped to the user type when none is configured
+const DefaultOIDCRoleClaim = "groups"
+
+// oidcClaims holds the ID token or UserInfo claims used to identify and provision users.
+type oidcClaims struct {
+	Subject       string `json:"sub"`
+	SessionID     string `json:"sid"`
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	// Raw holds all claims for the role claim lookup
+	Raw map[string]any `json:"-"`
+}
+
+// parseOIDCClaims decodes the claims with the given decoder, e.g. oidc.IDToken.Claims.
+func parseOIDCClaims(decode func(v any) error) (oidcClaims, error) {
+	claims := oidcClaims{}
+	if err := decode(&claims); err != nil {
+		return oidcClaims{}, err
+	}
+
+	if err := decode(&claims.Raw); err != nil {
+		return oidcClaims{}, err
+	}
+
+	return claims, nil
+}
+
+// oidcUser resolves the user authenticated by the OIDC provider. When provisioning is
+// enabled for the provider the user is created on the first login and the profile
+// is synchronized with the claims, otherwise the user has to exist already.
+//
+// Parameters:
+//   - ctx: request context
+//   - svcGw: service gateway providing the users service
+//   - prov: the OIDC provider which authenticated the user
+//   - claims: claims of the authenticated user
+//
+// Returns:
+//   - entities.User: the active user matching the claims
+//   - error: an error if the user does not exist and can not be created, or is inactive
+func oidcUser(ctx context.Context, svcGw *services.ServiceGateway, prov OIDCProvider, claims oidcClaims) (entities.User, error) {
+	var user entities.User
+	var err error
+	if prov.Provisioning == nil {
+		user, err = svcGw.Users.GetByLogin(ctx, claims.Email)
+	} else {
+		user, err = svcGw.Users.Provision(ctx, oidcProvisionCmd(prov.Provisioning, claims))
+	}
+
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	if !user.Active {
+		return entities.User{}, errors.New("inactive user")
+	}
+
+	return user, nil
+}
+
+// oidcProvisionCmd applies the provisioning policy of the provider to the claims.
+// Sign-up is only allowed for verified emails from the allowed domains, the user type
+// is synchronized only when the role claim is present.
+func oidcProvisionCmd(cfg *config.ConfigOIDCProvisioning, claims oidcClaims) services.UserProvisionCmd {
+	cmd := services.UserProvisionCmd{
+		Login:     claims.Email,
+		FirstName: claims.GivenName,
+		LastName:  claims.FamilyName,
+		Type:      entities.RegularUser,
+	}
+
+	_, domain, _ := strings.Cut(claims.Email, "@")
+	cmd.AllowCreate = (claims.EmailVerified == nil || *claims.EmailVerified) &&
+		(len(cfg.AllowedDomains) == 0 || slices.ContainsFunc(cfg.AllowedDomains, func(d string) bool {
+			return strings.EqualFold(d, domain)
+		}))
+
+	roles, ok := claimValues(claims.Raw, cfg.RoleClaim)
+	if slices.ContainsFunc(roles, func(r string) bool { return slices.Contains(cfg.AdminValues, r) }) {
+		cmd.Type = entities.AdminUser
+	}
+	cmd.SyncType = cfg.SyncUserType && ok
+
+	return cmd
+}
+
+// claimValues returns the string or list of strings claim value, false if the claim is absent.
+func claimValues(raw map[string]any, name string) ([]string, bool) {
+	if name == "" {
+		name = DefaultOIDCRoleClaim
+	}
+
+	switch v := raw[name].(type) {
+	case string:
+		return []string{v}, true
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
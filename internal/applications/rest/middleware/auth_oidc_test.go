@@ -0,0 +1,326 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// stubIdP is a minimal OpenID provider serving the discovery document and the JWKS,
+// and signing tokens with its current key.
+type stubIdP struct {
+	srv           *httptest.Server
+	mu            sync.Mutex
+	key           *rsa.PrivateKey
+	kid           string
+	jwksCalls     int
+	userInfoCalls int
+	// userInfo maps opaque access tokens to the UserInfo claims
+	userInfo map[string]map[string]any
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	idp := &stubIdP{userInfo: make(map[string]map[string]any)}
+	idp.rotate(t, "key-1")
+
+	idp.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"issuer":                                idp.srv.URL,
+				"authorization_endpoint":                idp.srv.URL + "/authorize",
+				"token_endpoint":                        idp.srv.URL + "/token",
+				"jwks_uri":                              idp.srv.URL + "/jwks",
+				"userinfo_endpoint":                     idp.srv.URL + "/userinfo",
+				"id_token_signing_alg_values_supported": []string{"RS256"},
+			})
+		case "/jwks":
+			idp.mu.Lock()
+			defer idp.mu.Unlock()
+			idp.jwksCalls++
+			_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: &idp.key.PublicKey, KeyID: idp.kid, Algorithm: string(jose.RS256), Use: "sig"},
+			}})
+		case "/userinfo":
+			idp.mu.Lock()
+			defer idp.mu.Unlock()
+			idp.userInfoCalls++
+			claims, ok := idp.userInfo[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(claims)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(idp.srv.Close)
+
+	return idp
+}
+
+// rotate replaces the signing key, the previous key is no longer published.
+func (idp *stubIdP) rotate(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.key, idp.kid = key, kid
+}
+
+func (idp *stubIdP) sign(t *testing.T, claims map[string]any) string {
+	idp.mu.Lock()
+	key, kid := idp.key, idp.kid
+	idp.mu.Unlock()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: kid}}, nil)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	jws, err := signer.Sign(payload)
+	require.NoError(t, err)
+
+	token, err := jws.CompactSerialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func (idp *stubIdP) claims(sub string) map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss": idp.srv.URL,
+		"sub": sub,
+		"aud": "ovoo-api",
+		"iat": now.Add(-time.Second).Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+		"upn": sub + "@example.com",
+	}
+}
+
+// provider configures the stub IdP the same way parseProvidersCfg does for JWT validation,
+// logouts are recorded in a new memory cache.
+func (idp *stubIdP) provider(t *testing.T) OIDCProvider {
+	p, err := oidc.NewProvider(t.Context(), idp.srv.URL)
+	require.NoError(t, err)
+
+	c, err := memory.New()
+	require.NoError(t, err)
+	require.NoError(t, SetCache(c))
+
+	return OIDCProvider{
+		Issuer:              idp.srv.URL,
+		OIDCProvider:        p,
+		OIDCConfig:          &oidc.Config{ClientID: "ovoo"},
+		OAuth2Config:        &oauth2.Config{ClientID: "ovoo", Endpoint: p.Endpoint()},
+		AccessTokenVerifier: p.Verifier(&oidc.Config{SkipClientIDCheck: true}),
+		Audiences:           []string{"ovoo", "ovoo-api"},
+		EmailClaims:         []string{"email", "upn"},
+	}
+}
+
+func TestValidateAccessTokenJWT_Valid(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+
+	claims, err := validateAccessToken(t.Context(), idp.sign(t, idp.claims("alice")), prov)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject)
+	// "email" is absent, the next configured claim is used
+	assert.Equal(t, "alice@example.com", claims.Email)
+
+	withEmail := idp.claims("alice")
+	withEmail["email"] = "alice@mail.example.com"
+	claims, err = validateAccessToken(t.Context(), idp.sign(t, withEmail), prov)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@mail.example.com", claims.Email)
+}
+
+func TestValidateAccessTokenJWT_Invalid(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	other := newStubIdP(t)
+
+	tests := []struct {
+		name   string
+		token  func() string
+		errMsg string
+	}{
+		{
+			name: "audience not accepted",
+			token: func() string {
+				c := idp.claims("alice")
+				c["aud"] = "other-api"
+				return idp.sign(t, c)
+			},
+			errMsg: "audience",
+		},
+		{
+			name: "expired",
+			token: func() string {
+				c := idp.claims("alice")
+				c["exp"] = time.Now().Add(-time.Minute).Unix()
+				return idp.sign(t, c)
+			},
+			errMsg: "expired",
+		},
+		{
+			name: "foreign issuer",
+			token: func() string {
+				c := idp.claims("alice")
+				c["iss"] = other.srv.URL
+				return idp.sign(t, c)
+			},
+			errMsg: "different provider",
+		},
+		{
+			name: "signed by unknown key",
+			token: func() string {
+				c := idp.claims("alice")
+				return other.sign(t, c)
+			},
+			errMsg: "signature",
+		},
+		{
+			name: "no email",
+			token: func() string {
+				c := idp.claims("alice")
+				delete(c, "upn")
+				return idp.sign(t, c)
+			},
+			errMsg: "no email",
+		},
+		{
+			name:   "not a JWT",
+			token:  func() string { return "opaque-access-token" },
+			errMsg: "malformed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateAccessToken(t.Context(), tt.token(), prov)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestValidateAccessTokenJWT_KeyRotation(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+
+	_, err := validateAccessToken(t.Context(), idp.sign(t, idp.claims("alice")), prov)
+	require.NoError(t, err)
+	_, err = validateAccessToken(t.Context(), idp.sign(t, idp.claims("alice")), prov)
+	require.NoError(t, err)
+	assert.Equal(t, 1, idp.jwksCalls, "keys should be cached")
+
+	// a token signed with the new key triggers a refresh of the key set
+	idp.rotate(t, "key-2")
+	_, err = validateAccessToken(t.Context(), idp.sign(t, idp.claims("alice")), prov)
+	require.NoError(t, err)
+	assert.Equal(t, 2, idp.jwksCalls)
+}
+
+func TestValidateAccessTokenJWT_Revoked(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+
+	bobToken := idp.sign(t, idp.claims("bob"))
+	carolToken := idp.sign(t, idp.claims("carol"))
+	require.NoError(t, revokeAccessToken(t.Context(), carolToken))
+	sessionClaims := idp.claims("dave")
+	sessionClaims["sid"] = "session-1"
+	daveToken := idp.sign(t, sessionClaims)
+
+	// back-channel logout of the subject and the provider session
+	require.NoError(t, revokeOIDCSession(t.Context(), prov.Issuer, "bob", ""))
+	require.NoError(t, revokeOIDCSession(t.Context(), prov.Issuer, "", "session-1"))
+
+	for _, token := range []string{bobToken, carolToken, daveToken} {
+		_, err := validateAccessToken(t.Context(), token, prov)
+		assert.ErrorContains(t, err, "revoked")
+	}
+
+	// tokens issued after the logout are accepted
+	fresh := idp.claims("bob")
+	fresh["iat"] = time.Now().Add(2 * time.Second).Unix()
+	_, err := validateAccessToken(t.Context(), idp.sign(t, fresh), prov)
+	assert.NoError(t, err)
+
+	// logouts at other providers do not apply
+	require.NoError(t, revokeOIDCSession(t.Context(), "https://other.example.com", "erin", ""))
+	_, err = validateAccessToken(t.Context(), idp.sign(t, idp.claims("erin")), prov)
+	assert.NoError(t, err)
+}
+
+func TestValidateAccessTokenViaUserInfo_Cached(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	prov.AccessTokenVerifier = nil
+	idp.userInfo["opaque-1"] = map[string]any{"sub": "alice", "email": "alice@example.com", "groups": []string{"admins"}}
+
+	for range 2 {
+		claims, err := validateAccessToken(t.Context(), "opaque-1", prov)
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com", claims.Email)
+		// all claims are kept for the provisioning role claim lookup
+		assert.Equal(t, []any{"admins"}, claims.Raw["groups"])
+	}
+	assert.Equal(t, 1, idp.userInfoCalls, "userinfo should be cached")
+
+	// the access token itself is never used as the cache key
+	_, err := oidcCache.Get(t.Context(), userInfoKeyPrefix+"opaque-1")
+	assert.Error(t, err)
+
+	_, err = validateAccessToken(t.Context(), "unknown", prov)
+	assert.Error(t, err)
+}
+
+func TestValidateAccessTokenViaUserInfo_Logout(t *testing.T) {
+	idp := newStubIdP(t)
+	prov := idp.provider(t)
+	prov.AccessTokenVerifier = nil
+	idp.userInfo["opaque-1"] = map[string]any{"sub": "alice", "email": "alice@example.com"}
+	idp.userInfo["opaque-2"] = map[string]any{"sub": "bob", "email": "bob@example.com"}
+
+	for _, token := range []string{"opaque-1", "opaque-2"} {
+		_, err := validateAccessToken(t.Context(), token, prov)
+		require.NoError(t, err)
+	}
+
+	// the back-channel logout invalidates cached results of the subject only,
+	// the provider ended the session and no longer accepts its token
+	require.NoError(t, revokeOIDCSession(t.Context(), prov.Issuer, "alice", ""))
+	delete(idp.userInfo, "opaque-1")
+
+	_, err := validateAccessToken(t.Context(), "opaque-1", prov)
+	assert.Error(t, err)
+	_, err = validateAccessToken(t.Context(), "opaque-2", prov)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, idp.userInfoCalls)
+
+	// tokens revoked on logout are rejected even if the provider still accepts them
+	require.NoError(t, revokeAccessToken(t.Context(), "opaque-2"))
+	_, err = validateAccessToken(t.Context(), "opaque-2", prov)
+	assert.ErrorContains(t, err, "revoked")
+}
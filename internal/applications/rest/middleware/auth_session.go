@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// loginRequest is the body of the password login request. The second step of the login
+// for users with two-factor authentication enabled only carries the MFA challenge fields.
+type loginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	mfaVerifyRequest
+}
+
+// loginResponse is returned after a successful password login.
+type loginResponse struct {
+	SessionID   entities.Hash `json:"session_id"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	IdleTimeout int           `json:"idle_timeout"`
+}
+
+// handleLogin authenticates the user with login and password and starts a server-side
+// session stored in the ovoo_session HttpOnly cookie.
+//
+// Users with two-factor authentication enabled receive the MFA challenge with
+// 401 Unauthorized first and have to repeat the request with the challenge token
+// and the second factor.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - r: HTTP request with JSON encoded loginRequest body
+//   - svcGw: service gateway providing the users, MFA and sessions services
+func handleLogin(w http.ResponseWriter, r *http.Request, svcGw *services.ServiceGateway) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := loginRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, mfaMaxRequestSize)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var user entities.User
+	if req.MFAToken != "" {
+		var ok bool
+		if user, ok = verifyMFA(w, r, req.mfaVerifyRequest, svcGw); !ok {
+			return
+		}
+	} else {
+		var err error
+		user, err = validateBasicAuth(r.Context(), req.Login, req.Password, svcGw)
+		if err != nil {
+			logger.Error("invalid login credentials", "src", r.RemoteAddr, "msg", err.Error())
+			http.Error(w, "invalid login credentials", http.StatusUnauthorized)
+			return
+		}
+
+		if user.MFAEnabled() {
+			requireMFA(w, r, user, svcGw)
+			return
+		}
+	}
+
+	startSession(w, r, user, svcGw)
+}
+
+// startSession starts a server-side session for the authenticated user, sets the session
+// cookie and writes the loginResponse.
+func startSession(w http.ResponseWriter, r *http.Request, user entities.User, svcGw *services.ServiceGateway) {
+	session, value, err := svcGw.Sessions.Create(r.Context(), user, services.SessionCreateCmd{
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
+	if err != nil {
+		logger.Error("creating session", "src", r.RemoteAddr, "user", user.Login, "error", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	setSecureCookie(w, r, sessionCookieName, value, int(svcGw.Sessions.AbsoluteTimeout().Seconds()), "/")
+	writeJSON(w, http.StatusOK, loginResponse{
+		SessionID:   session.ID,
+		ExpiresAt:   session.ExpiresAt,
+		IdleTimeout: int(svcGw.Sessions.IdleTimeout().Seconds()),
+	})
+}
@@ -3,9 +3,16 @@ package middleware
 import (
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/Burmuley/ovoo/internal/metrics"
 )
 
+// unmatchedRoute is the route label of requests matching no route of the router
+const unmatchedRoute = "unmatched"
+
 // loggingResponseWriter is a custom http.ResponseWriter that tracks the status code and response size.
 type loggingResponseWriter struct {
 	http.ResponseWriter
@@ -30,7 +37,9 @@ func (rw *loggingResponseWriter) WriteHeader(s int) {
 
 // Logging is a middleware adapter that logs information about incoming HTTP requests.
 // It captures details such as request method, URI, response status, size, and duration,
-// and logs them using the provided slog.Logger.
+// and logs them using the provided slog.Logger. The request count and latency are also
+// recorded in the metrics by the pattern of the route matched by the router, so the
+// metrics do not grow with the number of distinct URIs.
 //
 // The log level is determined by the HTTP status code:
 // - For 2xx-3xx status codes, information is logged at the INFO level.
@@ -38,16 +47,19 @@ func (rw *loggingResponseWriter) WriteHeader(s int) {
 //
 // Parameters:
 //   - logger: The structured logger used to record the request information.
+//   - routes: The router of the application, used to find the route of the request.
 //
 // Returns:
 //   - An Adapter function that wraps an http.Handler with logging functionality.
-func Logging(logger *slog.Logger) Adapter {
+func Logging(logger *slog.Logger, routes *http.ServeMux) Adapter {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			route := routeLabel(routes, r)
 			lrw := loggingResponseWriter{ResponseWriter: w, status: http.StatusOK, size: 0}
 			h.ServeHTTP(&lrw, r)
 			duration := time.Since(start)
+			metrics.ObserveHTTPRequest(r.Method, route, strconv.Itoa(lrw.status), duration)
 			logLevel := slog.LevelInfo
 			if lrw.status < http.StatusOK || lrw.status > 399 {
 				logLevel = slog.LevelError
@@ -62,3 +74,17 @@ func Logging(logger *slog.Logger) Adapter {
 		})
 	}
 }
+
+// routeLabel returns the path of the route pattern matching the request, without the method.
+func routeLabel(routes *http.ServeMux, r *http.Request) string {
+	_, pattern := routes.Handler(r)
+	if pattern == "" {
+		return unmatchedRoute
+	}
+
+	if _, path, found := strings.Cut(pattern, " "); found {
+		return path
+	}
+
+	return pattern
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Burmuley/ovoo/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteLabel(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/users/{id}", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("/api/docs", func(http.ResponseWriter, *http.Request) {})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   string
+	}{
+		{name: "pattern with wildcard", method: http.MethodGet, target: "/api/v1/users/01HXYZ", want: "/api/v1/users/{id}"},
+		{name: "pattern without method", method: http.MethodPost, target: "/api/docs", want: "/api/docs"},
+		{name: "no matching route", method: http.MethodGet, target: "/unknown", want: unmatchedRoute},
+		{name: "method not allowed", method: http.MethodDelete, target: "/api/v1/users/01HXYZ", want: unmatchedRoute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, routeLabel(mux, httptest.NewRequest(tt.method, tt.target, nil)))
+		})
+	}
+}
+
+func TestLogging_RecordsMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /teapot", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	rec := httptest.NewRecorder()
+	Logging(slog.New(slog.DiscardHandler), mux)(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/teapot", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+
+	scrape := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, scrape.Body.String(), `ovoo_http_requests_total{method="GET",route="/teapot",status="418"} 1`)
+}
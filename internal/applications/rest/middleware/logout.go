@@ -1,15 +1,38 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
 
-// logout clears all OIDC and session cookies and redirects the browser to the root page.
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// logout ends the server-side session, clears all OIDC and session cookies
+// and redirects the browser to the root page. Browsers signed in with an OIDC provider
+// have their tokens revoked at the provider and are redirected to the provider logout
+// page when it supports RP-initiated logout.
 //
 // Parameters:
 //   - w: HTTP response writer used to set the expired cookies and issue the redirect
 //   - r: HTTP request used to determine the Secure flag and domain for cookie expiry
-func logout(w http.ResponseWriter, r *http.Request) {
+//   - svcGw: service gateway providing the sessions service
+func logout(w http.ResponseWriter, r *http.Request, svcGw *services.ServiceGateway) {
+	if sessionCookie, err := r.Cookie(sessionCookieName); err == nil {
+		if err := svcGw.Sessions.Revoke(r.Context(), sessionCookie.Value); err != nil {
+			logger.Error("revoking session", "src", r.RemoteAddr, "error", err.Error())
+		}
+	}
+
+	redirectUrl := RootPageURI
+	if providerCookie, err := r.Cookie(providerCookieName); err == nil {
+		if prov, ok := oidcConfigs[providerCookie.Value]; ok {
+			redirectUrl = oidcLogout(r, prov)
+		}
+	}
+
 	clearOIDCCookies(w, r)
+	setSecureCookie(w, r, sessionCookieName, "", -1, "/")
+	setSecureCookie(w, r, apiTokenCookieName, "", -1, "/")
 	setSecureCookie(w, r, stateCookieName, "", -1, "")
 	setSecureCookie(w, r, nonceCookieName, "", -1, "")
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	http.Redirect(w, r, redirectUrl, http.StatusSeeOther)
 }
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+const (
+	serviceAccountPrivateApiPrefix = "/private/api/v1/"
+	serviceAccountDomainsUri       = "/api/v1/domains"
+)
+
+// RestrictServiceAccounts creates a middleware adapter limiting requests authenticated
+// as a service account to the private API endpoints and the domains listing.
+// Service accounts are only meant to be used by the milter and socketmap, so any other
+// request made with their credentials is rejected with 403 Forbidden.
+//
+// The adapter must be applied after the Authentication adapter, requests without
+// a user in the context are passed through unchanged.
+func RestrictServiceAccounts() Adapter {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(UserContextKey).(entities.User)
+			if !ok || !user.IsServiceAccount() || serviceAccountAllowed(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Error("service account request outside of allowed endpoints",
+				"src", r.RemoteAddr, "user", user.Login, "method", r.Method, "path", r.URL.Path)
+			http.Error(w, "service accounts are not allowed to access this endpoint", http.StatusForbidden)
+		})
+	}
+}
+
+// serviceAccountAllowed checks if the request targets an endpoint available for service accounts.
+func serviceAccountAllowed(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, serviceAccountPrivateApiPrefix) {
+		return true
+	}
+
+	return r.Method == http.MethodGet && strings.TrimSuffix(r.URL.Path, "/") == serviceAccountDomainsUri
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Burmuley/ovoo/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing is a middleware adapter that traces incoming HTTP requests as server spans.
+// The span continues the trace of the caller, e.g. the milter, when the request carries
+// its trace context (W3C traceparent header), and is named after the method and the
+// pattern of the route matched by the router. Responses with 5xx status codes mark
+// the span as failed.
+//
+// Parameters:
+//   - routes: The router of the application, used to find the route of the request.
+//
+// Returns:
+//   - An Adapter function that wraps an http.Handler with tracing functionality.
+func Tracing(routes *http.ServeMux) Adapter {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeLabel(routes, r)
+			ctx := tracing.Extract(r.Context(), r.Header)
+			ctx, span := tracing.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			lrw := loggingResponseWriter{ResponseWriter: w, status: http.StatusOK, size: 0}
+			h.ServeHTTP(&lrw, r.WithContext(ctx))
+			span.SetAttributes(attribute.Int("http.response.status_code", lrw.status))
+			if lrw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(lrw.status))
+			}
+		})
+	}
+}
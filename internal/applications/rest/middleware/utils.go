@@ -57,12 +57,19 @@ func setSecureCookie(w http.ResponseWriter, r *http.Request, name, value string,
 func formatRedirectURL(r *http.Request, prov OIDCProvider) string {
 	redirectUrl := prov.OAuth2Config.RedirectURL
 	if !strings.HasPrefix(redirectUrl, "http") {
-		scheme := "https"
-		if r.TLS == nil {
-			scheme = "http"
-		}
-		redirectUrl, _ = url.JoinPath(fmt.Sprintf("%s://%s", scheme, r.Host), prov.OAuth2Config.RedirectURL)
+		redirectUrl = absoluteURL(r, prov.OAuth2Config.RedirectURL)
 	}
 
 	return redirectUrl
 }
+
+// absoluteURL joins the path with the scheme derived from the request's TLS state and the request Host.
+func absoluteURL(r *http.Request, path string) string {
+	scheme := "https"
+	if r.TLS == nil {
+		scheme = "http"
+	}
+	absUrl, _ := url.JoinPath(fmt.Sprintf("%s://%s", scheme, r.Host), path)
+
+	return absUrl
+}
@@ -13,6 +13,7 @@ const (
 	ApiTokenScopes            apiTokenContextKey            = "ApiToken.Scopes"
 	BasicAuthenticationScopes basicAuthenticationContextKey = "BasicAuthentication.Scopes"
 	OAuth2Scopes              oAuth2ContextKey              = "OAuth2.Scopes"
+	SessionCookieScopes       sessionCookieContextKey       = "SessionCookie.Scopes"
 )
 
 // Defines values for DomainType.
@@ -69,6 +70,114 @@ func (e DomainVerificationType) Valid() bool {
 	}
 }
 
+// Defines values for NotificationEvent.
+const (
+	AliasLeaked            NotificationEvent = "alias_leaked"
+	DomainVerificationLost NotificationEvent = "domain_verification_lost"
+	NewSender              NotificationEvent = "new_sender"
+	PgpKeyExpiring         NotificationEvent = "pgp_key_expiring"
+	TokenExpiring          NotificationEvent = "token_expiring"
+)
+
+// Valid indicates whether the value is a known member of the NotificationEvent enum.
+func (e NotificationEvent) Valid() bool {
+	switch e {
+	case AliasLeaked:
+		return true
+	case DomainVerificationLost:
+		return true
+	case NewSender:
+		return true
+	case PgpKeyExpiring:
+		return true
+	case TokenExpiring:
+		return true
+	default:
+		return false
+	}
+}
+
+// Defines values for PrivacyFilterMode.
+const (
+	Disabled PrivacyFilterMode = "disabled"
+	Enabled  PrivacyFilterMode = "enabled"
+	Inherit  PrivacyFilterMode = "inherit"
+)
+
+// Valid indicates whether the value is a known member of the PrivacyFilterMode enum.
+func (e PrivacyFilterMode) Valid() bool {
+	switch e {
+	case Disabled:
+		return true
+	case Enabled:
+		return true
+	case Inherit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Defines values for QuarantineMode.
+const (
+	All        QuarantineMode = "all"
+	NewSenders QuarantineMode = "new_senders"
+	Off        QuarantineMode = "off"
+)
+
+// Valid indicates whether the value is a known member of the QuarantineMode enum.
+func (e QuarantineMode) Valid() bool {
+	switch e {
+	case All:
+		return true
+	case NewSenders:
+		return true
+	case Off:
+		return true
+	default:
+		return false
+	}
+}
+
+// Defines values for ReputationAction.
+const (
+	Quarantine ReputationAction = "quarantine"
+	Reject     ReputationAction = "reject"
+)
+
+// Valid indicates whether the value is a known member of the ReputationAction enum.
+func (e ReputationAction) Valid() bool {
+	switch e {
+	case Quarantine:
+		return true
+	case Reject:
+		return true
+	default:
+		return false
+	}
+}
+
+// Defines values for ReputationOverride.
+const (
+	Allow ReputationOverride = "allow"
+	Block ReputationOverride = "block"
+	None  ReputationOverride = "none"
+)
+
+// Valid indicates whether the value is a known member of the ReputationOverride enum.
+func (e ReputationOverride) Valid() bool {
+	switch e {
+	case Allow:
+		return true
+	case Block:
+		return true
+	case None:
+		return true
+	default:
+		return false
+	}
+}
+
 // AddressMetadata defines model for addressMetadata.
 type AddressMetadata struct {
 	Comment     *string `json:"comment,omitempty"`
@@ -83,13 +192,42 @@ type AliasData struct {
 	// Email Email of the Alias
 	Email openapi_types.Email `json:"email"`
 
-	// ForwardEmail forward email for alias address
-	ForwardEmail openapi_types.Email `json:"forward_email"`
+	// ForwardEmail forward email for alias address, not set for aliases delivering to a webhook
+	ForwardEmail *openapi_types.Email `json:"forward_email,omitempty"`
 
 	// Id alias address id
-	Id       string          `json:"id"`
-	Metadata AddressMetadata `json:"metadata"`
-	Owner    UserData        `json:"owner"`
+	Id string `json:"id"`
+
+	// Leak Set when the Alias is flagged as possibly leaked: a sender matching neither the service name of the Alias nor its known sender domains wrote to it
+	Leak *AliasLeakData `json:"leak,omitempty"`
+
+	// LeakRestrict Indicates whether the Alias refuses the mail of unknown senders once it is flagged as possibly leaked
+	LeakRestrict *bool           `json:"leak_restrict,omitempty"`
+	Metadata     AddressMetadata `json:"metadata"`
+	Owner        UserData        `json:"owner"`
+
+	// PrivacyFilter Whether trackers are stripped from the HTML mail forwarded by the Alias, "inherit" follows the default of the Alias owner
+	PrivacyFilter *PrivacyFilterMode `json:"privacy_filter,omitempty"`
+
+	// Quarantine Which mail of the Alias is held in the quarantine instead of being forwarded, "new_senders" holds the mail of a sender writing for the first time until one of their messages is released
+	Quarantine *QuarantineMode `json:"quarantine,omitempty"`
+
+	// Rules Rules filtering the mail forwarded by the Alias, written in a Sieve subset (RFC 5228); they take precedence over the rules of the Protected Address
+	Rules *string `json:"rules,omitempty"`
+
+	// SenderDomains Registered domains of the senders known to the Alias, the first sender is recorded when the Alias receives its first mail unless it does not match the service name of the Alias
+	SenderDomains *[]string `json:"sender_domains,omitempty"`
+
+	// Webhook HTTP endpoint the mail of the Alias is posted to instead of a Protected Address
+	Webhook *WebhookData `json:"webhook,omitempty"`
+}
+
+// AliasLeakData Set when the Alias is flagged as possibly leaked: a sender matching neither the service name of the Alias nor its known sender domains wrote to it
+type AliasLeakData struct {
+	DetectedAt time.Time `json:"detected_at"`
+
+	// Sender the sender revealing the leak
+	Sender openapi_types.Email `json:"sender"`
 }
 
 // ApiTokenData defines model for apiTokenData.
@@ -100,6 +238,9 @@ type ApiTokenData struct {
 	// Description Optional details about the API token
 	Description *string `json:"description,omitempty"`
 
+	// Domains Domains the API token is limited to; empty list allows all domains available to the token owner
+	Domains *[]string `json:"domains,omitempty"`
+
 	// Expiration Time of expiration of the API token
 	Expiration time.Time `json:"expiration"`
 
@@ -108,6 +249,9 @@ type ApiTokenData struct {
 
 	// Name Name of the API token
 	Name string `json:"name"`
+
+	// Scopes Permissions the API token is limited to; empty list grants all permissions of the token owner
+	Scopes *[]string `json:"scopes,omitempty"`
 }
 
 // ApiTokenDataOnCreate defines model for apiTokenDataOnCreate.
@@ -121,6 +265,9 @@ type ApiTokenDataOnCreate struct {
 	// Description Optional details about the API token
 	Description *string `json:"description,omitempty"`
 
+	// Domains Domains the API token is limited to; empty list allows all domains available to the token owner
+	Domains *[]string `json:"domains,omitempty"`
+
 	// Expiration Time of expiration of the API token
 	Expiration time.Time `json:"expiration"`
 
@@ -129,6 +276,9 @@ type ApiTokenDataOnCreate struct {
 
 	// Name Name of the API token
 	Name string `json:"name"`
+
+	// Scopes Permissions the API token is limited to; empty list grants all permissions of the token owner
+	Scopes *[]string `json:"scopes,omitempty"`
 }
 
 // BasicAuthForm defines model for basicAuthForm.
@@ -151,7 +301,33 @@ type ChainData struct {
 	Hash            string           `json:"hash"`
 	OrigFromAddress ChainAddressData `json:"orig_from_address"`
 	OrigToAddress   ChainAddressData `json:"orig_to_address"`
-	ToEmail         string           `json:"to_email"`
+
+	// PgpKey ASCII armored public key of the Protected Address the mail is forwarded to, the milter encrypts the message with it; not set when the address has no valid key
+	PgpKey *string `json:"pgp_key,omitempty"`
+
+	// PrivacyFilter Indicates whether the milter strips trackers from the HTML mail forwarded by the Alias
+	PrivacyFilter *bool `json:"privacy_filter,omitempty"`
+
+	// Quarantine Indicates whether the milter holds the mail of the chain in the quarantine instead of forwarding it
+	Quarantine *bool `json:"quarantine,omitempty"`
+
+	// Redirects Protected Addresses of the owner the rules can redirect the mail to
+	Redirects *[]ChainRedirectData `json:"redirects,omitempty"`
+
+	// Rules Rules the milter runs on the mail of the chain before rewriting it, the rules of the Alias or else the rules of the Protected Address; not set when there are none
+	Rules   *string `json:"rules,omitempty"`
+	ToEmail string  `json:"to_email"`
+
+	// Webhook HTTP endpoint the mail of the Alias is posted to instead of a Protected Address
+	Webhook *WebhookData `json:"webhook,omitempty"`
+}
+
+// ChainRedirectData defines model for chainRedirectData.
+type ChainRedirectData struct {
+	Email string `json:"email"`
+
+	// PgpKey ASCII armored public key of the Protected Address, not set when the address has no valid key
+	PgpKey *string `json:"pgp_key,omitempty"`
 }
 
 // DomainData defines model for domainData.
@@ -204,6 +380,15 @@ type Error struct {
 	Status string `json:"status"`
 }
 
+// NotificationEvent defines model for notificationEvent.
+type NotificationEvent string
+
+// NotificationPrefData defines model for notificationPrefData.
+type NotificationPrefData struct {
+	Enabled bool              `json:"enabled"`
+	Event   NotificationEvent `json:"event"`
+}
+
 // PaginationMetadata defines model for paginationMetadata.
 type PaginationMetadata struct {
 	CurrentPage  int `json:"current_page"`
@@ -213,6 +398,21 @@ type PaginationMetadata struct {
 	TotalRecords int `json:"total_records"`
 }
 
+// PgpKeyData OpenPGP public key the mail forwarded to the Protected Address is encrypted with
+type PgpKeyData struct {
+	// ExpiresAt Time the key can not encrypt anymore, not set when it does not expire; mail is forwarded unencrypted once the key expired
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Fingerprint Fingerprint of the primary key in upper case hex
+	Fingerprint string `json:"fingerprint"`
+
+	// Key ASCII armored public key
+	Key string `json:"key"`
+}
+
+// PrivacyFilterMode Whether trackers are stripped from the HTML mail forwarded by the Alias, "inherit" follows the default of the Alias owner
+type PrivacyFilterMode string
+
 // ProtectedAddressData defines model for protectedAddressData.
 type ProtectedAddressData struct {
 	// Active Indicates whether the Protected Address is active and can be used
@@ -221,6 +421,97 @@ type ProtectedAddressData struct {
 	Id       string              `json:"id"`
 	Metadata *AddressMetadata    `json:"metadata,omitempty"`
 	Owner    UserData            `json:"owner"`
+
+	// Pending Indicates the owner of the mailbox did not confirm the Protected Address yet; no aliases can be created and no mail is forwarded until it is confirmed
+	Pending *bool `json:"pending,omitempty"`
+
+	// PgpKey OpenPGP public key the mail forwarded to the Protected Address is encrypted with
+	PgpKey *PgpKeyData `json:"pgp_key,omitempty"`
+
+	// Rules Rules filtering the mail forwarded to the Protected Address by the aliases without own rules, written in a Sieve subset (RFC 5228)
+	Rules *string `json:"rules,omitempty"`
+}
+
+// QuarantineMode Which mail of the Alias is held in the quarantine instead of being forwarded, "new_senders" holds the mail of a sender writing for the first time until one of their messages is released
+type QuarantineMode string
+
+// QuarantinedMessageData defines model for quarantinedMessageData.
+type QuarantinedMessageData struct {
+	// Alias Address of type "alias" data structure
+	Alias     AliasData `json:"alias"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt Time the message is removed if not released
+	ExpiresAt time.Time `json:"expires_at"`
+	Id        string    `json:"id"`
+
+	// Sender Email of the sender of the message
+	Sender string `json:"sender"`
+
+	// Size Size of the message in bytes
+	Size    int    `json:"size"`
+	Subject string `json:"subject"`
+}
+
+// ReputationAction What happens to the mail of the listed senders
+type ReputationAction string
+
+// ReputationOverride Decision of an admin on a sender taking precedence over its score, `block` always lists the sender, `allow` never does and `none` lists it by its score
+type ReputationOverride string
+
+// RoleData defines model for roleData.
+type RoleData struct {
+	Description *string `json:"description,omitempty"`
+
+	// Id Role ULID
+	Id string `json:"id"`
+
+	// Name Unique role name
+	Name string `json:"name"`
+
+	// Permissions List of permissions granted by the role
+	Permissions []string `json:"permissions"`
+}
+
+// SenderReputationData defines model for senderReputationData.
+type SenderReputationData struct {
+	Comment *string `json:"comment,omitempty"`
+
+	// Hits Number of messages of the sender to the trap addresses
+	Hits      int        `json:"hits"`
+	LastHitAt *time.Time `json:"last_hit_at,omitempty"`
+
+	// Listed Indicates whether the mail of the sender is rejected or held
+	Listed bool `json:"listed"`
+
+	// Override Decision of an admin on a sender taking precedence over its score, `block` always lists the sender, `allow` never does and `none` lists it by its score
+	Override ReputationOverride `json:"override"`
+
+	// Score Score of the sender decayed until now
+	Score float64 `json:"score"`
+
+	// Sender Email of the sender
+	Sender    string     `json:"sender"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SessionData defines model for sessionData.
+type SessionData struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// Current Indicates the session the request is authenticated with
+	Current bool `json:"current"`
+
+	// ExpiresAt Absolute expiration of the session
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Id Session ID
+	Id         string    `json:"id"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+
+	// RemoteAddr Address the session was started from
+	RemoteAddr *string `json:"remote_addr,omitempty"`
+	UserAgent  *string `json:"user_agent,omitempty"`
 }
 
 // SystemInfoData defines model for systemInfoData.
@@ -244,6 +535,14 @@ type SystemVersionData struct {
 	Version string `json:"version"`
 }
 
+// TrapData defines model for trapData.
+type TrapData struct {
+	Active  bool                `json:"active"`
+	Comment *string             `json:"comment,omitempty"`
+	Email   openapi_types.Email `json:"email"`
+	Id      string              `json:"id"`
+}
+
 // UserData defines model for userData.
 type UserData struct {
 	// Active Indicates whether the user is active
@@ -267,10 +566,37 @@ type UserData struct {
 	// Login user login (for OIDC support should be formatted as email)
 	Login string `json:"login"`
 
+	// MfaEnabled Indicates whether the user has a second factor configured
+	MfaEnabled *bool `json:"mfa_enabled,omitempty"`
+
+	// Permissions Effective permissions of the user, only returned for the current user profile
+	Permissions *[]string `json:"permissions,omitempty"`
+
+	// PrivacyFilter Indicates whether trackers are stripped from the mail received by the user aliases by default
+	PrivacyFilter *bool     `json:"privacy_filter,omitempty"`
+	Role          *RoleData `json:"role,omitempty"`
+
 	// Type user type
 	Type string `json:"type"`
 }
 
+// WebAuthnCredentialData defines model for webAuthnCredentialData.
+type WebAuthnCredentialData struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	Id         string     `json:"id"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Name       string     `json:"name"`
+}
+
+// WebhookData HTTP endpoint the mail of the Alias is posted to instead of a Protected Address
+type WebhookData struct {
+	// Secret Key of the HMAC-SHA256 signature of the posted messages, sent in the X-Ovoo-Signature header
+	Secret string `json:"secret"`
+
+	// Url URL the messages are posted to
+	Url string `json:"url"`
+}
+
 // HTTP400 defines model for HTTP400.
 type HTTP400 = Error
 
@@ -295,6 +621,9 @@ type BasicAuthenticationTokenResponse struct {
 	Token string `json:"token"`
 }
 
+// BeginWebAuthnRegistrationResponse defines model for beginWebAuthnRegistrationResponse.
+type BeginWebAuthnRegistrationResponse map[string]interface{}
+
 // CreateAliasResponse Address of type "alias" data structure
 type CreateAliasResponse = AliasData
 
@@ -310,14 +639,38 @@ type CreateEmailChainResponse = ChainData
 // CreatePrAddrResponse defines model for createPrAddrResponse.
 type CreatePrAddrResponse = ProtectedAddressData
 
+// CreateRoleResponse defines model for createRoleResponse.
+type CreateRoleResponse = RoleData
+
+// CreateServiceAccountResponse defines model for createServiceAccountResponse.
+type CreateServiceAccountResponse struct {
+	ApiToken       ApiTokenDataOnCreate `json:"api_token"`
+	ServiceAccount UserData             `json:"service_account"`
+}
+
 // CreateUserResponse defines model for createUserResponse.
 type CreateUserResponse = UserData
 
+// EnrollTOTPResponse defines model for enrollTOTPResponse.
+type EnrollTOTPResponse struct {
+	// ProvisioningUri otpauth:// URI to render as QR code
+	ProvisioningUri string `json:"provisioning_uri"`
+
+	// Secret Base32 encoded secret for manual entry
+	Secret string `json:"secret"`
+}
+
 // ErrorResponse defines model for errorResponse.
 type ErrorResponse struct {
 	Errors []Error `json:"errors"`
 }
 
+// FinishWebAuthnRegistrationResponse defines model for finishWebAuthnRegistrationResponse.
+type FinishWebAuthnRegistrationResponse struct {
+	Credential    WebAuthnCredentialData `json:"credential"`
+	RecoveryCodes []string               `json:"recovery_codes"`
+}
+
 // GetAliasDetailsResponse Address of type "alias" data structure
 type GetAliasDetailsResponse = AliasData
 
@@ -342,6 +695,23 @@ type GetDomainsResponse struct {
 // GetEmailChainDetailsResponse defines model for getEmailChainDetailsResponse.
 type GetEmailChainDetailsResponse = ChainData
 
+// GetMFAStatusResponse defines model for getMFAStatusResponse.
+type GetMFAStatusResponse struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+	TotpEnabled       bool `json:"totp_enabled"`
+
+	// TotpPending TOTP enrollment was started but not confirmed yet
+	TotpPending bool `json:"totp_pending"`
+
+	// WebauthnAvailable Indicates whether WebAuthn is configured on the server
+	WebauthnAvailable   *bool                    `json:"webauthn_available,omitempty"`
+	WebauthnCredentials []WebAuthnCredentialData `json:"webauthn_credentials"`
+}
+
+// GetPermissionsResponse defines model for getPermissionsResponse.
+type GetPermissionsResponse = []string
+
 // GetPrAddrDetailsResponse defines model for getPrAddrDetailsResponse.
 type GetPrAddrDetailsResponse = ProtectedAddressData
 
@@ -351,12 +721,50 @@ type GetPrAddrsResponse struct {
 	ProtectedAddresses []ProtectedAddressData `json:"protected_addresses"`
 }
 
+// GetQuarantineResponse defines model for getQuarantineResponse.
+type GetQuarantineResponse struct {
+	Messages           []QuarantinedMessageData `json:"messages"`
+	PaginationMetadata PaginationMetadata       `json:"pagination_metadata"`
+}
+
+// GetRoleDetailsResponse defines model for getRoleDetailsResponse.
+type GetRoleDetailsResponse = RoleData
+
+// GetRolesResponse defines model for getRolesResponse.
+type GetRolesResponse struct {
+	PaginationMetadata PaginationMetadata `json:"pagination_metadata"`
+	Roles              []RoleData         `json:"roles"`
+}
+
+// GetSenderReputationResponse defines model for getSenderReputationResponse.
+type GetSenderReputationResponse struct {
+	PaginationMetadata PaginationMetadata     `json:"pagination_metadata"`
+	Senders            []SenderReputationData `json:"senders"`
+}
+
+// GetServiceAccountsResponse defines model for getServiceAccountsResponse.
+type GetServiceAccountsResponse struct {
+	PaginationMetadata PaginationMetadata `json:"pagination_metadata"`
+	ServiceAccounts    []UserData         `json:"service_accounts"`
+}
+
+// GetSessionsResponse defines model for getSessionsResponse.
+type GetSessionsResponse struct {
+	Sessions []SessionData `json:"sessions"`
+}
+
 // GetSystemInfoResponse defines model for getSystemInfoResponse.
 type GetSystemInfoResponse = SystemInfoData
 
 // GetSystemVersionResponse defines model for getSystemVersionResponse.
 type GetSystemVersionResponse = SystemVersionData
 
+// GetTrapsResponse defines model for getTrapsResponse.
+type GetTrapsResponse struct {
+	PaginationMetadata PaginationMetadata `json:"pagination_metadata"`
+	Traps              []TrapData         `json:"traps"`
+}
+
 // GetUserDetailsResponse defines model for getUserDetailsResponse.
 type GetUserDetailsResponse = UserData
 
@@ -366,6 +774,69 @@ type GetUsersResponse struct {
 	Users              []UserData         `json:"users"`
 }
 
+// LoginResponse defines model for loginResponse.
+type LoginResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// IdleTimeout Period in seconds after which an unused session expires
+	IdleTimeout int    `json:"idle_timeout"`
+	SessionId   string `json:"session_id"`
+}
+
+// MfaChallengeResponse defines model for mfaChallengeResponse.
+type MfaChallengeResponse struct {
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Methods     *[]string  `json:"methods,omitempty"`
+	MfaRequired *bool      `json:"mfa_required,omitempty"`
+	MfaToken    *string    `json:"mfa_token,omitempty"`
+
+	// Webauthn WebAuthn assertion options (CredentialAssertion)
+	Webauthn *map[string]interface{} `json:"webauthn,omitempty"`
+}
+
+// NotificationPrefsResponse defines model for notificationPrefsResponse.
+type NotificationPrefsResponse struct {
+	// Available Indicates whether notifications are sent by the server
+	Available bool                   `json:"available"`
+	Events    []NotificationPrefData `json:"events"`
+}
+
+// QuarantinedMessagePreviewResponse defines model for quarantinedMessagePreviewResponse.
+type QuarantinedMessagePreviewResponse struct {
+	Date    string                 `json:"date"`
+	From    string                 `json:"from"`
+	Message QuarantinedMessageData `json:"message"`
+
+	// Text First plain text part of the message, empty when there is none
+	Text string `json:"text"`
+
+	// TextTruncated Indicates whether the text is longer than returned
+	TextTruncated bool   `json:"text_truncated"`
+	To            string `json:"to"`
+}
+
+// QuarantinedMessageResponse defines model for quarantinedMessageResponse.
+type QuarantinedMessageResponse = QuarantinedMessageData
+
+// RecoveryCodesResponse defines model for recoveryCodesResponse.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SenderLookupResponse defines model for senderLookupResponse.
+type SenderLookupResponse struct {
+	// Action What happens to the mail of the listed senders
+	Action ReputationAction `json:"action"`
+	Listed bool             `json:"listed"`
+	Sender string           `json:"sender"`
+}
+
+// SenderReputationResponse defines model for senderReputationResponse.
+type SenderReputationResponse = SenderReputationData
+
+// TrapResponse defines model for trapResponse.
+type TrapResponse = TrapData
+
 // UpdateAliasResponse Address of type "alias" data structure
 type UpdateAliasResponse = AliasData
 
@@ -378,25 +849,59 @@ type UpdateDomainResponse = DomainData
 // UpdatePrAddrResponse defines model for updatePrAddrResponse.
 type UpdatePrAddrResponse = ProtectedAddressData
 
+// UpdateRoleResponse defines model for updateRoleResponse.
+type UpdateRoleResponse = RoleData
+
 // UpdateUserResponse defines model for updateUserResponse.
 type UpdateUserResponse = UserData
 
+// ChangePasswordRequest defines model for changePasswordRequest.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ConfirmProtectedAddressRequest defines model for confirmProtectedAddressRequest.
+type ConfirmProtectedAddressRequest struct {
+	// Token Confirmation token from the link mailed to the protected address
+	Token string `json:"token"`
+}
+
 // CreateAliasRequest defines model for createAliasRequest.
 type CreateAliasRequest struct {
 	// CustomPrefix Custom prefix to be used when generating new alias
 	CustomPrefix *string `json:"custom_prefix,omitempty"`
 
 	// DomainId Target domain ID for alias generation
-	DomainId           string          `json:"domain_id"`
-	Metadata           AddressMetadata `json:"metadata"`
-	ProtectedAddressId string          `json:"protected_address_id"`
+	DomainId string `json:"domain_id"`
+
+	// LeakRestrict Refuse the mail of unknown senders once the Alias is flagged as possibly leaked
+	LeakRestrict *bool           `json:"leak_restrict,omitempty"`
+	Metadata     AddressMetadata `json:"metadata"`
+
+	// PrivacyFilter Whether trackers are stripped from the HTML mail forwarded by the Alias, "inherit" follows the default of the Alias owner
+	PrivacyFilter *PrivacyFilterMode `json:"privacy_filter,omitempty"`
+
+	// ProtectedAddressId Protected Address the mail is forwarded to, either it or webhook should be set
+	ProtectedAddressId *string `json:"protected_address_id,omitempty"`
+
+	// Quarantine Which mail of the Alias is held in the quarantine instead of being forwarded, "new_senders" holds the mail of a sender writing for the first time until one of their messages is released
+	Quarantine *QuarantineMode `json:"quarantine,omitempty"`
+
+	// Rules Rules filtering the mail forwarded by the Alias, written in a Sieve subset (RFC 5228); they can only redirect the mail to the Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+
+	// Webhook URL of the HTTP endpoint the mail is posted to instead of a Protected Address; a secret signing the posted messages is generated for it
+	Webhook *string `json:"webhook,omitempty"`
 }
 
 // CreateApiToken defines model for createApiToken.
 type CreateApiToken struct {
-	Description *string `json:"description,omitempty"`
-	ExpireIn    int     `json:"expire_in"`
-	Name        string  `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	Domains     *[]string `json:"domains,omitempty"`
+	ExpireIn    int       `json:"expire_in"`
+	Name        string    `json:"name"`
+	Scopes      *[]string `json:"scopes,omitempty"`
 }
 
 // CreateDomainRequest defines model for createDomainRequest.
@@ -421,6 +926,36 @@ type CreateEmailChain struct {
 type CreateProtectedAddressRequest struct {
 	Email    string          `json:"email"`
 	Metadata AddressMetadata `json:"metadata"`
+
+	// PgpKey ASCII armored OpenPGP public key, mail forwarded to the address is encrypted with it; the key should not be revoked or expired and should be usable for encryption
+	PgpKey *string `json:"pgp_key,omitempty"`
+
+	// Rules Rules filtering the mail forwarded to the address, written in a Sieve subset (RFC 5228); they can only redirect the mail to the other Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+}
+
+// CreateRoleRequest defines model for createRoleRequest.
+type CreateRoleRequest struct {
+	Description *string  `json:"description,omitempty"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// CreateServiceAccountRequest defines model for createServiceAccountRequest.
+type CreateServiceAccountRequest struct {
+	Description *string `json:"description,omitempty"`
+
+	// ExpireIn Validity of the API token in days, 365 if not set
+	ExpireIn *int   `json:"expire_in,omitempty"`
+	Login    string `json:"login"`
+}
+
+// CreateTrapRequest defines model for createTrapRequest.
+type CreateTrapRequest struct {
+	Comment *string `json:"comment,omitempty"`
+
+	// Email Address in an active domain not used by another address
+	Email openapi_types.Email `json:"email"`
 }
 
 // CreateUserRequest defines model for createUserRequest.
@@ -429,13 +964,109 @@ type CreateUserRequest struct {
 	LastName  string  `json:"last_name"`
 	Login     string  `json:"login"`
 	Password  *string `json:"password,omitempty"`
-	Type      string  `json:"type"`
+
+	// RoleId ID of the custom role to assign to the user
+	RoleId *string `json:"role_id,omitempty"`
+	Type   string  `json:"type"`
+}
+
+// FinishWebAuthnRegistrationRequest defines model for finishWebAuthnRegistrationRequest.
+type FinishWebAuthnRegistrationRequest struct {
+	// Credential PublicKeyCredential returned by `navigator.credentials.create()`
+	Credential map[string]interface{} `json:"credential"`
+
+	// Name Name of the credential to tell it apart from others
+	Name *string `json:"name,omitempty"`
+}
+
+// ForgotPasswordRequest defines model for forgotPasswordRequest.
+type ForgotPasswordRequest struct {
+	Login string `json:"login"`
+}
+
+// HoldMessageRequest defines model for holdMessageRequest.
+type HoldMessageRequest struct {
+	// Hash Hash of the chain the message belongs to
+	Hash string `json:"hash"`
+
+	// Message Base64 encoded message as it would be forwarded
+	Message []byte `json:"message"`
+}
+
+// LoginRequest defines model for loginRequest.
+type LoginRequest struct {
+	// Code TOTP code
+	Code  *string `json:"code,omitempty"`
+	Login *string `json:"login,omitempty"`
+
+	// MfaToken Second factor challenge token returned by the first step of the login
+	MfaToken     *string `json:"mfa_token,omitempty"`
+	Password     *string `json:"password,omitempty"`
+	RecoveryCode *string `json:"recovery_code,omitempty"`
+
+	// Webauthn WebAuthn assertion (PublicKeyCredential) for the challenge
+	Webauthn *map[string]interface{} `json:"webauthn,omitempty"`
+}
+
+// MfaCodeRequest defines model for mfaCodeRequest.
+type MfaCodeRequest struct {
+	// Code TOTP code, or a recovery code where accepted
+	Code string `json:"code"`
+}
+
+// OverrideSenderReputationRequest defines model for overrideSenderReputationRequest.
+type OverrideSenderReputationRequest struct {
+	Comment *string `json:"comment,omitempty"`
+
+	// Override Decision of an admin on a sender taking precedence over its score, `block` always lists the sender, `allow` never does and `none` lists it by its score
+	Override ReputationOverride `json:"override"`
+}
+
+// ResetPasswordRequest defines model for resetPasswordRequest.
+type ResetPasswordRequest struct {
+	Password string `json:"password"`
+
+	// Token Reset token from the link mailed to the user
+	Token string `json:"token"`
+}
+
+// ResetUserPasswordRequest defines model for resetUserPasswordRequest.
+type ResetUserPasswordRequest struct {
+	// Password New password of the user, a reset link is mailed to the user when not set
+	Password *string `json:"password,omitempty"`
+}
+
+// RotateServiceAccountTokenRequest defines model for rotateServiceAccountTokenRequest.
+type RotateServiceAccountTokenRequest struct {
+	// ExpireIn Validity of the new API token in days, 365 if not set
+	ExpireIn *int `json:"expire_in,omitempty"`
+
+	// Overlap Number of seconds the currently active tokens remain valid for, 86400 if not set; 0 revokes them immediately
+	Overlap *int `json:"overlap,omitempty"`
 }
 
 // UpdateAliasRequest defines model for updateAliasRequest.
 type UpdateAliasRequest struct {
-	Active   *bool            `json:"active,omitempty"`
-	Metadata *AddressMetadata `json:"metadata,omitempty"`
+	Active *bool `json:"active,omitempty"`
+
+	// LeakRestrict Refuse the mail of unknown senders once the Alias is flagged as possibly leaked
+	LeakRestrict *bool            `json:"leak_restrict,omitempty"`
+	Metadata     *AddressMetadata `json:"metadata,omitempty"`
+
+	// PrivacyFilter Whether trackers are stripped from the HTML mail forwarded by the Alias, "inherit" follows the default of the Alias owner
+	PrivacyFilter *PrivacyFilterMode `json:"privacy_filter,omitempty"`
+
+	// Quarantine Which mail of the Alias is held in the quarantine instead of being forwarded, "new_senders" holds the mail of a sender writing for the first time until one of their messages is released
+	Quarantine *QuarantineMode `json:"quarantine,omitempty"`
+
+	// ResolveLeak Clear the leak flag of the Alias, the domain of the sender revealing the leak becomes a known sender domain
+	ResolveLeak *bool `json:"resolve_leak,omitempty"`
+
+	// Rules Rules replacing the current ones, an empty value removes them; they can only redirect the mail to the Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+
+	// Webhook URL replacing the endpoint of an Alias delivering to a webhook, the secret is kept
+	Webhook *string `json:"webhook,omitempty"`
 }
 
 // UpdateApiToken defines model for updateApiToken.
@@ -450,10 +1081,29 @@ type UpdateDomainRequest struct {
 	Active *bool `json:"active,omitempty"`
 }
 
+// UpdateNotificationPrefsRequest defines model for updateNotificationPrefsRequest.
+type UpdateNotificationPrefsRequest struct {
+	// Events Events to enable (true) or disable (false), e.g. `{"new_sender": false}`
+	Events map[string]bool `json:"events"`
+}
+
 // UpdateProtectedAddressRequest defines model for updateProtectedAddressRequest.
 type UpdateProtectedAddressRequest struct {
 	Active   *bool            `json:"active,omitempty"`
 	Metadata *AddressMetadata `json:"metadata,omitempty"`
+
+	// PgpKey ASCII armored OpenPGP public key replacing the current one, an empty value removes the key and mail is forwarded unencrypted
+	PgpKey *string `json:"pgp_key,omitempty"`
+
+	// Rules Rules replacing the current ones, an empty value removes them; they can only redirect the mail to the other Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+}
+
+// UpdateRoleRequest defines model for updateRoleRequest.
+type UpdateRoleRequest struct {
+	Description *string   `json:"description,omitempty"`
+	Name        *string   `json:"name,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"`
 }
 
 // UpdateUserRequest defines model for updateUserRequest.
@@ -461,7 +1111,13 @@ type UpdateUserRequest struct {
 	Active    *bool   `json:"active,omitempty"`
 	FirstName *string `json:"first_name,omitempty"`
 	LastName  *string `json:"last_name,omitempty"`
-	Type      *string `json:"type,omitempty"`
+
+	// PrivacyFilter Strip trackers from the mail received by the user aliases by default
+	PrivacyFilter *bool `json:"privacy_filter,omitempty"`
+
+	// RoleId ID of the custom role to assign to the user, empty string removes the role
+	RoleId *string `json:"role_id,omitempty"`
+	Type   *string `json:"type,omitempty"`
 }
 
 // apiTokenContextKey is the context key for ApiToken security scheme
@@ -473,6 +1129,9 @@ type basicAuthenticationContextKey string
 // oAuth2ContextKey is the context key for OAuth2 security scheme
 type oAuth2ContextKey string
 
+// sessionCookieContextKey is the context key for SessionCookie security scheme
+type sessionCookieContextKey string
+
 // GetAliasesParams defines parameters for GetAliases.
 type GetAliasesParams struct {
 	// Owner owner ID to fetch parameters for
@@ -489,6 +1148,9 @@ type GetAliasesParams struct {
 
 	// Q partial-match search across email, service name, and comment
 	Q *string `form:"q,omitempty" json:"q,omitempty"`
+
+	// Leaked allows to lookup the aliases flagged as possibly leaked (true) or not flagged (false)
+	Leaked *bool `form:"leaked,omitempty" json:"leaked,omitempty"`
 }
 
 // CreateAliasJSONBody defines parameters for CreateAlias.
@@ -497,15 +1159,90 @@ type CreateAliasJSONBody struct {
 	CustomPrefix *string `json:"custom_prefix,omitempty"`
 
 	// DomainId Target domain ID for alias generation
-	DomainId           string          `json:"domain_id"`
-	Metadata           AddressMetadata `json:"metadata"`
-	ProtectedAddressId string          `json:"protected_address_id"`
+	DomainId string `json:"domain_id"`
+
+	// LeakRestrict Refuse the mail of unknown senders once the Alias is flagged as possibly leaked
+	LeakRestrict *bool           `json:"leak_restrict,omitempty"`
+	Metadata     AddressMetadata `json:"metadata"`
+
+	// PrivacyFilter Whether trackers are stripped from the HTML mail forwarded by the Alias, "inherit" follows the default of the Alias owner
+	PrivacyFilter *PrivacyFilterMode `json:"privacy_filter,omitempty"`
+
+	// ProtectedAddressId Protected Address the mail is forwarded to, either it or webhook should be set
+	ProtectedAddressId *string `json:"protected_address_id,omitempty"`
+
+	// Quarantine Which mail of the Alias is held in the quarantine instead of being forwarded, "new_senders" holds the mail of a sender writing for the first time until one of their messages is released
+	Quarantine *QuarantineMode `json:"quarantine,omitempty"`
+
+	// Rules Rules filtering the mail forwarded by the Alias, written in a Sieve subset (RFC 5228); they can only redirect the mail to the Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+
+	// Webhook URL of the HTTP endpoint the mail is posted to instead of a Protected Address; a secret signing the posted messages is generated for it
+	Webhook *string `json:"webhook,omitempty"`
 }
 
 // UpdateAliasJSONBody defines parameters for UpdateAlias.
 type UpdateAliasJSONBody struct {
-	Active   *bool            `json:"active,omitempty"`
-	Metadata *AddressMetadata `json:"metadata,omitempty"`
+	Active *bool `json:"active,omitempty"`
+
+	// LeakRestrict Refuse the mail of unknown senders once the Alias is flagged as possibly leaked
+	LeakRestrict *bool            `json:"leak_restrict,omitempty"`
+	Metadata     *AddressMetadata `json:"metadata,omitempty"`
+
+	// PrivacyFilter Whether trackers are stripped from the HTML mail forwarded by the Alias, "inherit" follows the default of the Alias owner
+	PrivacyFilter *PrivacyFilterMode `json:"privacy_filter,omitempty"`
+
+	// Quarantine Which mail of the Alias is held in the quarantine instead of being forwarded, "new_senders" holds the mail of a sender writing for the first time until one of their messages is released
+	Quarantine *QuarantineMode `json:"quarantine,omitempty"`
+
+	// ResolveLeak Clear the leak flag of the Alias, the domain of the sender revealing the leak becomes a known sender domain
+	ResolveLeak *bool `json:"resolve_leak,omitempty"`
+
+	// Rules Rules replacing the current ones, an empty value removes them; they can only redirect the mail to the Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+
+	// Webhook URL replacing the endpoint of an Alias delivering to a webhook, the secret is kept
+	Webhook *string `json:"webhook,omitempty"`
+}
+
+// LoginJSONBody defines parameters for Login.
+type LoginJSONBody struct {
+	// Code TOTP code
+	Code  *string `json:"code,omitempty"`
+	Login *string `json:"login,omitempty"`
+
+	// MfaToken Second factor challenge token returned by the first step of the login
+	MfaToken     *string `json:"mfa_token,omitempty"`
+	Password     *string `json:"password,omitempty"`
+	RecoveryCode *string `json:"recovery_code,omitempty"`
+
+	// Webauthn WebAuthn assertion (PublicKeyCredential) for the challenge
+	Webauthn *map[string]interface{} `json:"webauthn,omitempty"`
+}
+
+// ForgotPasswordJSONBody defines parameters for ForgotPassword.
+type ForgotPasswordJSONBody struct {
+	Login string `json:"login"`
+}
+
+// GetPasswordResetParams defines parameters for GetPasswordReset.
+type GetPasswordResetParams struct {
+	// Token Reset token from the link
+	Token string `form:"token" json:"token"`
+}
+
+// ResetPasswordJSONBody defines parameters for ResetPassword.
+type ResetPasswordJSONBody struct {
+	Password string `json:"password"`
+
+	// Token Reset token from the link mailed to the user
+	Token string `json:"token"`
+}
+
+// ResetPasswordFormdataBody defines parameters for ResetPassword.
+type ResetPasswordFormdataBody struct {
+	Password string `form:"password" json:"password"`
+	Token    string `form:"token" json:"token"`
 }
 
 // GetDomainsParams defines parameters for GetDomains.
@@ -553,12 +1290,119 @@ type GetPrAddrsParams struct {
 type CreatePrAddrJSONBody struct {
 	Email    string          `json:"email"`
 	Metadata AddressMetadata `json:"metadata"`
+
+	// PgpKey ASCII armored OpenPGP public key, mail forwarded to the address is encrypted with it; the key should not be revoked or expired and should be usable for encryption
+	PgpKey *string `json:"pgp_key,omitempty"`
+
+	// Rules Rules filtering the mail forwarded to the address, written in a Sieve subset (RFC 5228); they can only redirect the mail to the other Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+}
+
+// GetPrAddrConfirmationParams defines parameters for GetPrAddrConfirmation.
+type GetPrAddrConfirmationParams struct {
+	// Token Confirmation token from the link
+	Token string `form:"token" json:"token"`
+}
+
+// ConfirmPrAddrJSONBody defines parameters for ConfirmPrAddr.
+type ConfirmPrAddrJSONBody struct {
+	// Token Confirmation token from the link mailed to the protected address
+	Token string `json:"token"`
+}
+
+// ConfirmPrAddrFormdataBody defines parameters for ConfirmPrAddr.
+type ConfirmPrAddrFormdataBody struct {
+	Token string `form:"token" json:"token"`
 }
 
 // UpdatePrAddrJSONBody defines parameters for UpdatePrAddr.
 type UpdatePrAddrJSONBody struct {
 	Active   *bool            `json:"active,omitempty"`
 	Metadata *AddressMetadata `json:"metadata,omitempty"`
+
+	// PgpKey ASCII armored OpenPGP public key replacing the current one, an empty value removes the key and mail is forwarded unencrypted
+	PgpKey *string `json:"pgp_key,omitempty"`
+
+	// Rules Rules replacing the current ones, an empty value removes them; they can only redirect the mail to the other Protected Addresses of the owner
+	Rules *string `json:"rules,omitempty"`
+}
+
+// GetQuarantineParams defines parameters for GetQuarantine.
+type GetQuarantineParams struct {
+	// AliasId alias id to list the held messages of
+	AliasId *string `form:"alias_id,omitempty" json:"alias_id,omitempty"`
+
+	// Owner owner ID to list the held messages of
+	Owner *string `form:"owner,omitempty" json:"owner,omitempty"`
+}
+
+// GetSenderReputationParams defines parameters for GetSenderReputation.
+type GetSenderReputationParams struct {
+	// Sender email of the sender
+	Sender *string `form:"sender,omitempty" json:"sender,omitempty"`
+
+	// Override override of the senders, `none` for the senders listed by their score
+	Override *ReputationOverride `form:"override,omitempty" json:"override,omitempty"`
+}
+
+// OverrideSenderReputationJSONBody defines parameters for OverrideSenderReputation.
+type OverrideSenderReputationJSONBody struct {
+	Comment *string `json:"comment,omitempty"`
+
+	// Override Decision of an admin on a sender taking precedence over its score, `block` always lists the sender, `allow` never does and `none` lists it by its score
+	Override ReputationOverride `json:"override"`
+}
+
+// CreateTrapJSONBody defines parameters for CreateTrap.
+type CreateTrapJSONBody struct {
+	Comment *string `json:"comment,omitempty"`
+
+	// Email Address in an active domain not used by another address
+	Email openapi_types.Email `json:"email"`
+}
+
+// GetRolesParams defines parameters for GetRoles.
+type GetRolesParams struct {
+	// Name filter roles by name
+	Name *string `form:"name,omitempty" json:"name,omitempty"`
+}
+
+// CreateRoleJSONBody defines parameters for CreateRole.
+type CreateRoleJSONBody struct {
+	Description *string  `json:"description,omitempty"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleJSONBody defines parameters for UpdateRole.
+type UpdateRoleJSONBody struct {
+	Description *string   `json:"description,omitempty"`
+	Name        *string   `json:"name,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"`
+}
+
+// GetServiceAccountsParams defines parameters for GetServiceAccounts.
+type GetServiceAccountsParams struct {
+	// Login filter service accounts by login
+	Login *string `form:"login,omitempty" json:"login,omitempty"`
+}
+
+// CreateServiceAccountJSONBody defines parameters for CreateServiceAccount.
+type CreateServiceAccountJSONBody struct {
+	Description *string `json:"description,omitempty"`
+
+	// ExpireIn Validity of the API token in days, 365 if not set
+	ExpireIn *int   `json:"expire_in,omitempty"`
+	Login    string `json:"login"`
+}
+
+// RotateServiceAccountTokenJSONBody defines parameters for RotateServiceAccountToken.
+type RotateServiceAccountTokenJSONBody struct {
+	// ExpireIn Validity of the new API token in days, 365 if not set
+	ExpireIn *int `json:"expire_in,omitempty"`
+
+	// Overlap Number of seconds the currently active tokens remain valid for, 86400 if not set; 0 revokes them immediately
+	Overlap *int `json:"overlap,omitempty"`
 }
 
 // GetUsersParams defines parameters for GetUsers.
@@ -579,14 +1423,19 @@ type CreateUserJSONBody struct {
 	LastName  string  `json:"last_name"`
 	Login     string  `json:"login"`
 	Password  *string `json:"password,omitempty"`
-	Type      string  `json:"type"`
+
+	// RoleId ID of the custom role to assign to the user
+	RoleId *string `json:"role_id,omitempty"`
+	Type   string  `json:"type"`
 }
 
 // CreateApiTokenJSONBody defines parameters for CreateApiToken.
 type CreateApiTokenJSONBody struct {
-	Description *string `json:"description,omitempty"`
-	ExpireIn    int     `json:"expire_in"`
-	Name        string  `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	Domains     *[]string `json:"domains,omitempty"`
+	ExpireIn    int       `json:"expire_in"`
+	Name        string    `json:"name"`
+	Scopes      *[]string `json:"scopes,omitempty"`
 }
 
 // UpdateApiTokenJSONBody defines parameters for UpdateApiToken.
@@ -596,12 +1445,57 @@ type UpdateApiTokenJSONBody struct {
 	Name        *string `json:"name,omitempty"`
 }
 
+// ConfirmTOTPJSONBody defines parameters for ConfirmTOTP.
+type ConfirmTOTPJSONBody struct {
+	// Code TOTP code, or a recovery code where accepted
+	Code string `json:"code"`
+}
+
+// DisableTOTPJSONBody defines parameters for DisableTOTP.
+type DisableTOTPJSONBody struct {
+	// Code TOTP code, or a recovery code where accepted
+	Code string `json:"code"`
+}
+
+// FinishWebAuthnRegistrationJSONBody defines parameters for FinishWebAuthnRegistration.
+type FinishWebAuthnRegistrationJSONBody struct {
+	// Credential PublicKeyCredential returned by `navigator.credentials.create()`
+	Credential map[string]interface{} `json:"credential"`
+
+	// Name Name of the credential to tell it apart from others
+	Name *string `json:"name,omitempty"`
+}
+
+// UpdateNotificationPrefsJSONBody defines parameters for UpdateNotificationPrefs.
+type UpdateNotificationPrefsJSONBody struct {
+	// Events Events to enable (true) or disable (false), e.g. `{"new_sender": false}`
+	Events map[string]bool `json:"events"`
+}
+
+// ChangePasswordJSONBody defines parameters for ChangePassword.
+type ChangePasswordJSONBody struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
 // UpdateUserJSONBody defines parameters for UpdateUser.
 type UpdateUserJSONBody struct {
 	Active    *bool   `json:"active,omitempty"`
 	FirstName *string `json:"first_name,omitempty"`
 	LastName  *string `json:"last_name,omitempty"`
-	Type      *string `json:"type,omitempty"`
+
+	// PrivacyFilter Strip trackers from the mail received by the user aliases by default
+	PrivacyFilter *bool `json:"privacy_filter,omitempty"`
+
+	// RoleId ID of the custom role to assign to the user, empty string removes the role
+	RoleId *string `json:"role_id,omitempty"`
+	Type   *string `json:"type,omitempty"`
+}
+
+// ResetUserPasswordJSONBody defines parameters for ResetUserPassword.
+type ResetUserPasswordJSONBody struct {
+	// Password New password of the user, a reset link is mailed to the user when not set
+	Password *string `json:"password,omitempty"`
 }
 
 // CreateChainJSONBody defines parameters for CreateChain.
@@ -610,12 +1504,33 @@ type CreateChainJSONBody struct {
 	ToEmail   openapi_types.Email `json:"to_email"`
 }
 
+// HoldMessageJSONBody defines parameters for HoldMessage.
+type HoldMessageJSONBody struct {
+	// Hash Hash of the chain the message belongs to
+	Hash string `json:"hash"`
+
+	// Message Base64 encoded message as it would be forwarded
+	Message []byte `json:"message"`
+}
+
 // CreateAliasJSONRequestBody defines body for CreateAlias for application/json ContentType.
 type CreateAliasJSONRequestBody CreateAliasJSONBody
 
 // UpdateAliasJSONRequestBody defines body for UpdateAlias for application/json ContentType.
 type UpdateAliasJSONRequestBody UpdateAliasJSONBody
 
+// LoginJSONRequestBody defines body for Login for application/json ContentType.
+type LoginJSONRequestBody LoginJSONBody
+
+// ForgotPasswordJSONRequestBody defines body for ForgotPassword for application/json ContentType.
+type ForgotPasswordJSONRequestBody ForgotPasswordJSONBody
+
+// ResetPasswordJSONRequestBody defines body for ResetPassword for application/json ContentType.
+type ResetPasswordJSONRequestBody ResetPasswordJSONBody
+
+// ResetPasswordFormdataRequestBody defines body for ResetPassword for application/x-www-form-urlencoded ContentType.
+type ResetPasswordFormdataRequestBody ResetPasswordFormdataBody
+
 // CreateDomainJSONRequestBody defines body for CreateDomain for application/json ContentType.
 type CreateDomainJSONRequestBody CreateDomainJSONBody
 
@@ -625,9 +1540,33 @@ type UpdateDomainJSONRequestBody UpdateDomainJSONBody
 // CreatePrAddrJSONRequestBody defines body for CreatePrAddr for application/json ContentType.
 type CreatePrAddrJSONRequestBody CreatePrAddrJSONBody
 
+// ConfirmPrAddrJSONRequestBody defines body for ConfirmPrAddr for application/json ContentType.
+type ConfirmPrAddrJSONRequestBody ConfirmPrAddrJSONBody
+
+// ConfirmPrAddrFormdataRequestBody defines body for ConfirmPrAddr for application/x-www-form-urlencoded ContentType.
+type ConfirmPrAddrFormdataRequestBody ConfirmPrAddrFormdataBody
+
 // UpdatePrAddrJSONRequestBody defines body for UpdatePrAddr for application/json ContentType.
 type UpdatePrAddrJSONRequestBody UpdatePrAddrJSONBody
 
+// OverrideSenderReputationJSONRequestBody defines body for OverrideSenderReputation for application/json ContentType.
+type OverrideSenderReputationJSONRequestBody OverrideSenderReputationJSONBody
+
+// CreateTrapJSONRequestBody defines body for CreateTrap for application/json ContentType.
+type CreateTrapJSONRequestBody CreateTrapJSONBody
+
+// CreateRoleJSONRequestBody defines body for CreateRole for application/json ContentType.
+type CreateRoleJSONRequestBody CreateRoleJSONBody
+
+// UpdateRoleJSONRequestBody defines body for UpdateRole for application/json ContentType.
+type UpdateRoleJSONRequestBody UpdateRoleJSONBody
+
+// CreateServiceAccountJSONRequestBody defines body for CreateServiceAccount for application/json ContentType.
+type CreateServiceAccountJSONRequestBody CreateServiceAccountJSONBody
+
+// RotateServiceAccountTokenJSONRequestBody defines body for RotateServiceAccountToken for application/json ContentType.
+type RotateServiceAccountTokenJSONRequestBody RotateServiceAccountTokenJSONBody
+
 // CreateUserJSONRequestBody defines body for CreateUser for application/json ContentType.
 type CreateUserJSONRequestBody CreateUserJSONBody
 
@@ -637,8 +1576,29 @@ type CreateApiTokenJSONRequestBody CreateApiTokenJSONBody
 // UpdateApiTokenJSONRequestBody defines body for UpdateApiToken for application/json ContentType.
 type UpdateApiTokenJSONRequestBody UpdateApiTokenJSONBody
 
+// ConfirmTOTPJSONRequestBody defines body for ConfirmTOTP for application/json ContentType.
+type ConfirmTOTPJSONRequestBody ConfirmTOTPJSONBody
+
+// DisableTOTPJSONRequestBody defines body for DisableTOTP for application/json ContentType.
+type DisableTOTPJSONRequestBody DisableTOTPJSONBody
+
+// FinishWebAuthnRegistrationJSONRequestBody defines body for FinishWebAuthnRegistration for application/json ContentType.
+type FinishWebAuthnRegistrationJSONRequestBody FinishWebAuthnRegistrationJSONBody
+
+// UpdateNotificationPrefsJSONRequestBody defines body for UpdateNotificationPrefs for application/json ContentType.
+type UpdateNotificationPrefsJSONRequestBody UpdateNotificationPrefsJSONBody
+
+// ChangePasswordJSONRequestBody defines body for ChangePassword for application/json ContentType.
+type ChangePasswordJSONRequestBody ChangePasswordJSONBody
+
 // UpdateUserJSONRequestBody defines body for UpdateUser for application/json ContentType.
 type UpdateUserJSONRequestBody UpdateUserJSONBody
 
+// ResetUserPasswordJSONRequestBody defines body for ResetUserPassword for application/json ContentType.
+type ResetUserPasswordJSONRequestBody ResetUserPasswordJSONBody
+
 // CreateChainJSONRequestBody defines body for CreateChain for application/json ContentType.
 type CreateChainJSONRequestBody CreateChainJSONBody
+
+// HoldMessageJSONRequestBody defines body for HoldMessage for application/json ContentType.
+type HoldMessageJSONRequestBody HoldMessageJSONBody
@@ -1,7 +1,9 @@
 package rest
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
@@ -46,6 +48,8 @@ func addrTypeTStr(t entities.AddressType) string {
 		entities.ExternalAddress:   "external",
 		entities.ProtectedAddress:  "protected_address",
 		entities.ReplyAliasAddress: "reply_alias",
+		entities.WebhookAddress:    "webhook",
+		entities.TrapAddress:       "trap",
 	}
 
 	if tp, ok := amap[t]; ok {
@@ -58,51 +62,169 @@ func addrTypeTStr(t entities.AddressType) string {
 // userTResponse converts an entities.User to a UserData response.
 // It maps fields from the internal user entity to the API response structure.
 func userTResponse(u entities.User) UserData {
-	return UserData{
-		FirstName: u.FirstName,
-		Id:        string(u.ID),
-		LastName:  u.LastName,
-		Login:     u.Login,
-		Type:      userTypeTStr(u.Type),
-		Active:    &u.Active,
+	ud := UserData{
+		FirstName:     u.FirstName,
+		Id:            string(u.ID),
+		LastName:      u.LastName,
+		Login:         u.Login,
+		Type:          userTypeTStr(u.Type),
+		Active:        &u.Active,
+		MfaEnabled:    new(u.MFAEnabled()),
+		PrivacyFilter: &u.PrivacyFilter,
 	}
+
+	if u.Role != nil {
+		ud.Role = new(roleTRoleData(*u.Role))
+	}
+
+	return ud
+}
+
+// roleTRoleData converts an entities.Role to a RoleData response.
+func roleTRoleData(r entities.Role) RoleData {
+	return RoleData{
+		Id:          r.ID.String(),
+		Name:        r.Name,
+		Description: &r.Description,
+		Permissions: permissionsTStrings(r.Permissions),
+	}
+}
+
+// permissionsTStrings converts a list of entities.Permission to a list of strings.
+func permissionsTStrings(perms []entities.Permission) []string {
+	res := make([]string, 0, len(perms))
+	for _, p := range perms {
+		res = append(res, string(p))
+	}
+
+	return res
+}
+
+// permissionsFStrings converts a list of strings to a list of entities.Permission.
+func permissionsFStrings(perms []string) []entities.Permission {
+	res := make([]entities.Permission, 0, len(perms))
+	for _, p := range perms {
+		res = append(res, entities.Permission(p))
+	}
+
+	return res
 }
 
 // addressTAliasData converts an entities.Address to an AliasData response.
 // This function is used for email alias representations in the API.
 func addressTAliasData(alias entities.Address) AliasData {
-	return AliasData{
-		Email:        types.Email(alias.Email),
-		ForwardEmail: types.Email(alias.ForwardAddress.Email),
-		Id:           alias.ID.String(),
+	data := AliasData{
+		Email: types.Email(alias.Email),
+		Id:    alias.ID.String(),
 		Metadata: AddressMetadata{
 			Comment:     &alias.Metadata.Comment,
 			ServiceName: &alias.Metadata.ServiceName,
 		},
-		Owner:  userTResponse(alias.Owner),
-		Active: &alias.Active,
+		Owner:         userTResponse(alias.Owner),
+		Active:        &alias.Active,
+		PrivacyFilter: new(privacyFilterTMode(alias.PrivacyFilter)),
+		Quarantine:    new(quarantineTMode(alias.Quarantine)),
+		LeakRestrict:  &alias.LeakRestrict,
+	}
+
+	if alias.Rules != nil {
+		data.Rules = &alias.Rules.Script
+	}
+
+	if len(alias.SenderDomains) > 0 {
+		data.SenderDomains = &alias.SenderDomains
+	}
+
+	if alias.Leak != nil {
+		data.Leak = &AliasLeakData{Sender: types.Email(alias.Leak.Sender), DetectedAt: alias.Leak.DetectedAt}
+	}
+
+	if webhook := alias.ForwardAddress.Webhook; webhook != nil {
+		data.Webhook = &WebhookData{Url: webhook.URL, Secret: webhook.Secret}
+	} else {
+		data.ForwardEmail = new(types.Email(alias.ForwardAddress.Email))
+	}
+
+	return data
+}
+
+// privacyFilterTMode converts the privacy filter setting of an alias to its mode
+func privacyFilterTMode(setting *bool) PrivacyFilterMode {
+	switch {
+	case setting == nil:
+		return Inherit
+	case *setting:
+		return Enabled
+	default:
+		return Disabled
 	}
 }
 
+// quarantineModes maps the quarantine modes of the API to the settings of the aliases
+var quarantineModes = map[QuarantineMode]entities.QuarantineMode{
+	Off:        entities.QuarantineOff,
+	NewSenders: entities.QuarantineNewSenders,
+	All:        entities.QuarantineAll,
+}
+
+// quarantineTMode converts the quarantine setting of an alias to its mode
+func quarantineTMode(setting entities.QuarantineMode) QuarantineMode {
+	for mode, s := range quarantineModes {
+		if s == setting {
+			return mode
+		}
+	}
+
+	return Off
+}
+
+// quarantineTSetting converts the quarantine mode of a request to the setting of the alias
+func quarantineTSetting(mode *QuarantineMode) (*entities.QuarantineMode, error) {
+	if mode == nil {
+		return nil, nil
+	}
+
+	setting, ok := quarantineModes[*mode]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown quarantine mode %q", entities.ErrValidation, *mode)
+	}
+
+	return &setting, nil
+}
+
 // addressTPrAddrData converts an entities.Address to a ProtectedAddressData response.
 // This is used for protected email address representations in the API.
 func addressTPrAddrData(praddr entities.Address) ProtectedAddressData {
-	return ProtectedAddressData{
+	data := ProtectedAddressData{
 		Email: types.Email(praddr.Email),
 		Id:    praddr.ID.String(),
 		Metadata: &AddressMetadata{
 			Comment:     &praddr.Metadata.Comment,
 			ServiceName: &praddr.Metadata.ServiceName,
 		},
-		Owner:  userTResponse(praddr.Owner),
-		Active: &praddr.Active,
+		Owner:   userTResponse(praddr.Owner),
+		Active:  &praddr.Active,
+		Pending: &praddr.Pending,
 	}
+
+	if praddr.PGPKey != nil {
+		data.PgpKey = &PgpKeyData{Key: praddr.PGPKey.Armored, Fingerprint: praddr.PGPKey.Fingerprint}
+		if !praddr.PGPKey.ExpiresAt.IsZero() {
+			data.PgpKey.ExpiresAt = &praddr.PGPKey.ExpiresAt
+		}
+	}
+
+	if praddr.Rules != nil {
+		data.Rules = &praddr.Rules.Script
+	}
+
+	return data
 }
 
 // chainTChainData converts an entities.Chain to a ChainData response.
 // This function transforms the internal chain entity to the API response format.
 func chainTChainData(chain entities.Chain) ChainData {
-	return ChainData{
+	data := ChainData{
 		Hash:      chain.Hash.String(),
 		FromEmail: string(chain.FromAddress.Email),
 		ToEmail:   string(chain.ToAddress.Email),
@@ -115,6 +237,94 @@ func chainTChainData(chain entities.Chain) ChainData {
 			Type:  addrTypeTStr(chain.OrigToAddress.Type),
 		},
 	}
+
+	// the milter encrypts the mail forwarded to protected addresses with a key, the mail is failed
+	// temporarily once the key expired rather than forwarded unencrypted
+	if key := chain.ToAddress.PGPKey; chain.ToAddress.Type == entities.ProtectedAddress && key != nil {
+		data.PgpKey = &key.Armored
+	}
+
+	// trackers are stripped from the mail received by aliases, not from the replies to external senders
+	if chain.OrigToAddress.Type == entities.AliasAddress && chain.OrigToAddress.PrivacyFilterEnabled() {
+		data.PrivacyFilter = new(true)
+	}
+
+	if chain.Held() {
+		data.Quarantine = new(true)
+	}
+
+	if webhook := chain.ToAddress.Webhook; chain.ToAddress.Type == entities.WebhookAddress && webhook != nil {
+		data.Webhook = &WebhookData{Url: webhook.URL, Secret: webhook.Secret}
+	}
+
+	if rules := chain.Rules(); rules != nil {
+		data.Rules = &rules.Script
+		redirects := make([]ChainRedirectData, 0, len(chain.Redirects))
+		for _, praddr := range chain.Redirects {
+			redirect := ChainRedirectData{Email: string(praddr.Email)}
+			if key := praddr.PGPKey; key != nil {
+				redirect.PgpKey = &key.Armored
+			}
+			redirects = append(redirects, redirect)
+		}
+		data.Redirects = &redirects
+	}
+
+	return data
+}
+
+// quarantinedTMessageData converts an entities.QuarantinedMessage to a QuarantinedMessageData response.
+func quarantinedTMessageData(msg entities.QuarantinedMessage) QuarantinedMessageData {
+	return QuarantinedMessageData{
+		Id:        msg.ID.String(),
+		Alias:     addressTAliasData(msg.Alias),
+		Sender:    msg.Sender.String(),
+		Subject:   msg.Subject,
+		Size:      msg.Size,
+		CreatedAt: msg.CreatedAt,
+		ExpiresAt: msg.ExpiresAt,
+	}
+}
+
+// trapTTrapData converts an entities.Address of a trap to a TrapData response.
+func trapTTrapData(trap entities.Address) TrapData {
+	data := TrapData{
+		Id:     trap.ID.String(),
+		Email:  types.Email(trap.Email),
+		Active: trap.Active,
+	}
+
+	if trap.Metadata.Comment != "" {
+		data.Comment = new(trap.Metadata.Comment)
+	}
+
+	return data
+}
+
+// senderReputationTData converts an entities.SenderReputation to a SenderReputationData response,
+// the score is decayed until the time.
+func senderReputationTData(rep entities.SenderReputation, policy entities.ReputationPolicy, at time.Time) SenderReputationData {
+	data := SenderReputationData{
+		Sender:   rep.Sender.String(),
+		Hits:     rep.Hits,
+		Score:    policy.Score(rep, at),
+		Override: reputationTOverride(rep.Override),
+		Listed:   policy.Listed(rep, at),
+	}
+
+	if !rep.LastHitAt.IsZero() {
+		data.LastHitAt = new(rep.LastHitAt)
+	}
+
+	if !rep.UpdatedAt.IsZero() {
+		data.UpdatedAt = new(rep.UpdatedAt)
+	}
+
+	if rep.Comment != "" {
+		data.Comment = new(rep.Comment)
+	}
+
+	return data
 }
 
 // tokenTApiTokenData converts an entities.ApiToken to an ApiTokenData response.
@@ -126,6 +336,8 @@ func tokenTApiTokenData(token entities.ApiToken) ApiTokenData {
 		Description: &token.Description,
 		Expiration:  token.Expiration,
 		Name:        token.Name,
+		Scopes:      new(permissionsTStrings(token.Scopes)),
+		Domains:     new(append([]string{}, token.Domains...)),
 	}
 }
 
@@ -138,6 +350,8 @@ func tokenTApiTokenDataOnCreate(token entities.ApiToken) ApiTokenDataOnCreate {
 		Description: &token.Description,
 		Expiration:  token.Expiration,
 		Name:        token.Name,
+		Scopes:      new(permissionsTStrings(token.Scopes)),
+		Domains:     new(append([]string{}, token.Domains...)),
 		ApiToken:    token.Token,
 	}
 }
@@ -202,3 +416,77 @@ func sysInfoTSysInfoResponse(info config.SystemInfo) GetSystemInfoResponse {
 		DkimSelector: info.DKIMSelector,
 	}
 }
+
+// webAuthnCredTData converts an entities.WebAuthnCredential to a WebAuthnCredentialData response.
+func webAuthnCredTData(c entities.WebAuthnCredential) WebAuthnCredentialData {
+	data := WebAuthnCredentialData{
+		Id:        c.ID.String(),
+		Name:      c.Name,
+		CreatedAt: c.CreatedAt,
+	}
+
+	if !c.LastUsedAt.IsZero() {
+		data.LastUsedAt = &c.LastUsedAt
+	}
+
+	return data
+}
+
+// recoveryCodesTList makes sure the recovery codes are rendered as an empty list rather than null.
+func recoveryCodesTList(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+
+	return codes
+}
+
+// sessionTData converts an entities.Session to a SessionData response,
+// current is the id of the session the request is authenticated with.
+func sessionTData(s entities.Session, current entities.Hash) SessionData {
+	data := SessionData{
+		Id:         s.ID.String(),
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    s.ID == current,
+	}
+
+	if s.RemoteAddr != "" {
+		data.RemoteAddr = &s.RemoteAddr
+	}
+
+	if s.UserAgent != "" {
+		data.UserAgent = &s.UserAgent
+	}
+
+	return data
+}
+
+// reputationOverrides maps the overrides of the API to the overrides of the senders
+var reputationOverrides = map[ReputationOverride]entities.ReputationOverride{
+	None:  entities.ReputationOverrideNone,
+	Block: entities.ReputationBlock,
+	Allow: entities.ReputationAllow,
+}
+
+// reputationTOverride converts the override of a sender to its API value
+func reputationTOverride(override entities.ReputationOverride) ReputationOverride {
+	for value, o := range reputationOverrides {
+		if o == override {
+			return value
+		}
+	}
+
+	return None
+}
+
+// reputationTEntityOverride converts the override of a request to the override of the sender
+func reputationTEntityOverride(value ReputationOverride) (entities.ReputationOverride, error) {
+	override, ok := reputationOverrides[value]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown reputation override %q", entities.ErrValidation, value)
+	}
+
+	return override, nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/Burmuley/ovoo/internal/entities"
 )
@@ -55,6 +56,7 @@ func TestAddrTypeTStr(t *testing.T) {
 		{entities.ExternalAddress, "external"},
 		{entities.ProtectedAddress, "protected_address"},
 		{entities.ReplyAliasAddress, "reply_alias"},
+		{entities.WebhookAddress, "webhook"},
 		{99, "unknown"},
 	}
 	for _, tt := range tests {
@@ -64,6 +66,34 @@ func TestAddrTypeTStr(t *testing.T) {
 	}
 }
 
+func TestQuarantineTSetting(t *testing.T) {
+	for mode, setting := range quarantineModes {
+		got, err := quarantineTSetting(&mode)
+		require.NoError(t, err)
+		assert.Equal(t, setting, *got)
+		assert.Equal(t, mode, quarantineTMode(setting))
+	}
+
+	got, err := quarantineTSetting(nil)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	_, err = quarantineTSetting(new(QuarantineMode("always")))
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestReputationTEntityOverride(t *testing.T) {
+	for value, override := range reputationOverrides {
+		got, err := reputationTEntityOverride(value)
+		require.NoError(t, err)
+		assert.Equal(t, override, got)
+		assert.Equal(t, value, reputationTOverride(override))
+	}
+
+	_, err := reputationTEntityOverride("ignore")
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
 func TestPgmTMetadata(t *testing.T) {
 	pgm := entities.PaginationMetadata{
 		CurrentPage:  2,
@@ -139,7 +169,8 @@ func TestAddressTAliasData(t *testing.T) {
 	}
 	result := addressTAliasData(alias)
 	assert.Equal(t, "alias@test.com", string(result.Email))
-	assert.Equal(t, "protected@example.com", string(result.ForwardEmail))
+	require.NotNil(t, result.ForwardEmail)
+	assert.Equal(t, "protected@example.com", string(*result.ForwardEmail))
 	assert.Equal(t, alias.ID.String(), result.Id)
 	assert.NotNil(t, result.Metadata.Comment)
 	assert.Equal(t, "test comment", *result.Metadata.Comment)
@@ -149,6 +180,33 @@ func TestAddressTAliasData(t *testing.T) {
 	assert.True(t, *result.Active)
 }
 
+func TestAddressTAliasData_Leak(t *testing.T) {
+	detectedAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	alias := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          "alias@test.com",
+		ForwardAddress: &entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com"},
+		SenderDomains:  []string{"shop.com"},
+		Leak:           &entities.AliasLeak{Sender: "offers@spam.com", DetectedAt: detectedAt},
+		LeakRestrict:   true,
+	}
+
+	result := addressTAliasData(alias)
+	require.NotNil(t, result.SenderDomains)
+	assert.Equal(t, []string{"shop.com"}, *result.SenderDomains)
+	require.NotNil(t, result.Leak)
+	assert.Equal(t, AliasLeakData{Sender: "offers@spam.com", DetectedAt: detectedAt}, *result.Leak)
+	require.NotNil(t, result.LeakRestrict)
+	assert.True(t, *result.LeakRestrict)
+
+	// aliases which are not leaked have neither a leak nor sender domains
+	alias.SenderDomains, alias.Leak = nil, nil
+	result = addressTAliasData(alias)
+	assert.Nil(t, result.SenderDomains)
+	assert.Nil(t, result.Leak)
+}
+
 func TestAddressTPrAddrData(t *testing.T) {
 	ownerID := entities.NewId()
 	prAddr := entities.Address{
@@ -160,7 +218,8 @@ func TestAddressTPrAddrData(t *testing.T) {
 			Comment:     "note",
 			ServiceName: "acme",
 		},
-		Active: true,
+		Active:  true,
+		Pending: true,
 	}
 	result := addressTPrAddrData(prAddr)
 	assert.Equal(t, "protected@example.com", string(result.Email))
@@ -170,6 +229,17 @@ func TestAddressTPrAddrData(t *testing.T) {
 	assert.Equal(t, "acme", *result.Metadata.ServiceName)
 	assert.NotNil(t, result.Active)
 	assert.True(t, *result.Active)
+	assert.NotNil(t, result.Pending)
+	assert.True(t, *result.Pending)
+	assert.Nil(t, result.PgpKey)
+
+	expires := time.Now().Add(time.Hour)
+	prAddr.PGPKey = &entities.PGPKey{Armored: "armored key", Fingerprint: "ABCDEF", ExpiresAt: expires}
+	result = addressTPrAddrData(prAddr)
+	assert.NotNil(t, result.PgpKey)
+	assert.Equal(t, "armored key", result.PgpKey.Key)
+	assert.Equal(t, "ABCDEF", result.PgpKey.Fingerprint)
+	assert.Equal(t, &expires, result.PgpKey.ExpiresAt)
 }
 
 func TestChainTChainData(t *testing.T) {
@@ -202,6 +272,122 @@ func TestChainTChainData(t *testing.T) {
 	assert.Equal(t, "alias", result.OrigToAddress.Type)
 }
 
+func TestChainTChainData_PGPKey(t *testing.T) {
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser}
+	praddr := entities.Address{
+		ID: entities.NewId(), Email: "protected@example.com",
+		Type:   entities.ProtectedAddress,
+		Owner:  owner,
+		PGPKey: &entities.PGPKey{Armored: "armored key", Fingerprint: "ABCDEF"},
+	}
+	chain := entities.Chain{ToAddress: praddr}
+
+	result := chainTChainData(chain)
+	assert.NotNil(t, result.PgpKey)
+	assert.Equal(t, "armored key", *result.PgpKey)
+
+	// an expired key is passed on, so the milter does not forward the mail unencrypted
+	chain.ToAddress.PGPKey = &entities.PGPKey{Armored: "armored key", Fingerprint: "ABCDEF", ExpiresAt: time.Now().Add(-time.Hour)}
+	assert.NotNil(t, chainTChainData(chain).PgpKey)
+}
+
+func TestChainTChainData_PrivacyFilter(t *testing.T) {
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, PrivacyFilter: true}
+	alias := entities.Address{ID: entities.NewId(), Email: "alias@example.com", Type: entities.AliasAddress, Owner: owner}
+	chain := entities.Chain{OrigToAddress: alias}
+
+	// the alias inherits the owner default
+	result := chainTChainData(chain)
+	assert.NotNil(t, result.PrivacyFilter)
+	assert.True(t, *result.PrivacyFilter)
+
+	chain.OrigToAddress.PrivacyFilter = new(false)
+	assert.Nil(t, chainTChainData(chain).PrivacyFilter)
+
+	// replies sent to external addresses are not filtered
+	chain.OrigToAddress = entities.Address{Email: "reply@example.com", Type: entities.ReplyAliasAddress, Owner: owner}
+	assert.Nil(t, chainTChainData(chain).PrivacyFilter)
+}
+
+func TestChainTChainData_Quarantine(t *testing.T) {
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser}
+	praddr := entities.Address{ID: entities.NewId(), Email: "protected@example.com", Type: entities.ProtectedAddress, Owner: owner}
+	alias := entities.Address{
+		ID: entities.NewId(), Email: "alias@example.com",
+		Type:           entities.AliasAddress,
+		ForwardAddress: &praddr,
+		Owner:          owner,
+		Quarantine:     entities.QuarantineNewSenders,
+	}
+	chain := entities.Chain{OrigToAddress: alias, Quarantined: true}
+
+	result := chainTChainData(chain)
+	assert.NotNil(t, result.Quarantine)
+	assert.True(t, *result.Quarantine)
+	assert.Equal(t, NewSenders, *addressTAliasData(alias).Quarantine)
+
+	// the mail of released senders is forwarded
+	chain.Quarantined = false
+	assert.Nil(t, chainTChainData(chain).Quarantine)
+}
+
+func TestChainTChainData_Rules(t *testing.T) {
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser}
+	praddr := entities.Address{
+		ID: entities.NewId(), Email: "protected@example.com",
+		Type:  entities.ProtectedAddress,
+		Owner: owner,
+		Rules: &entities.Rules{Script: `redirect "work@example.com";`},
+	}
+	work := entities.Address{
+		ID: entities.NewId(), Email: "work@example.com",
+		Type:   entities.ProtectedAddress,
+		Owner:  owner,
+		PGPKey: &entities.PGPKey{Armored: "armored key"},
+	}
+	alias := entities.Address{ID: entities.NewId(), Email: "alias@example.com", Type: entities.AliasAddress, ForwardAddress: &praddr, Owner: owner}
+	chain := entities.Chain{ToAddress: praddr, OrigToAddress: alias, Redirects: []entities.Address{work}}
+
+	// the alias without own rules inherits the rules of the protected address
+	result := chainTChainData(chain)
+	require.NotNil(t, result.Rules)
+	assert.Equal(t, praddr.Rules.Script, *result.Rules)
+	require.NotNil(t, result.Redirects)
+	require.Len(t, *result.Redirects, 1)
+	assert.Equal(t, "work@example.com", (*result.Redirects)[0].Email)
+	assert.Equal(t, "armored key", *(*result.Redirects)[0].PgpKey)
+	assert.Nil(t, addressTAliasData(alias).Rules)
+	assert.Equal(t, praddr.Rules.Script, *addressTPrAddrData(praddr).Rules)
+
+	// replies are not filtered
+	chain.OrigToAddress.Type = entities.ReplyAliasAddress
+	result = chainTChainData(chain)
+	assert.Nil(t, result.Rules)
+	assert.Nil(t, result.Redirects)
+}
+
+func TestChainTChainData_Webhook(t *testing.T) {
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser}
+	webhook := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.WebhookAddress,
+		Owner:   owner,
+		Webhook: &entities.Webhook{URL: "https://hooks.example.com/mail", Secret: "secret"},
+	}
+	alias := entities.Address{ID: entities.NewId(), Email: "alias@example.com", Type: entities.AliasAddress, ForwardAddress: &webhook, Owner: owner}
+	chain := entities.Chain{ToAddress: webhook, OrigToAddress: alias}
+
+	result := chainTChainData(chain)
+	require.NotNil(t, result.Webhook)
+	assert.Equal(t, "https://hooks.example.com/mail", result.Webhook.Url)
+	assert.Equal(t, "secret", result.Webhook.Secret)
+
+	aliasData := addressTAliasData(alias)
+	assert.Nil(t, aliasData.ForwardEmail)
+	require.NotNil(t, aliasData.Webhook)
+	assert.Equal(t, "https://hooks.example.com/mail", aliasData.Webhook.Url)
+}
+
 func TestTokenTApiTokenData(t *testing.T) {
 	expiration := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
 	token := entities.ApiToken{
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// GetNotificationPrefs returns the email notification settings of the current user.
+func (a *Application) GetNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting notification settings: identifying user", err)
+		return
+	}
+
+	prefs, err := a.svcGw.Notifications.GetPrefs(r.Context(), cuser)
+	if err != nil {
+		a.errorLogNResponse(w, "getting notification settings", err)
+		return
+	}
+
+	a.successResponse(w, a.notificationPrefsResponse(prefs), http.StatusOK)
+}
+
+// UpdateNotificationPrefs enables or disables the email notifications of the current user.
+func (a *Application) UpdateNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "updating notification settings: identifying user", err)
+		return
+	}
+
+	req := UpdateNotificationPrefsRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "updating notification settings: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	cmd := services.NotificationPrefsUpdateCmd{Events: make(map[entities.NotificationEvent]bool, len(req.Events))}
+	for event, enabled := range req.Events {
+		cmd.Events[entities.NotificationEvent(event)] = enabled
+	}
+
+	prefs, err := a.svcGw.Notifications.UpdatePrefs(r.Context(), cuser, cmd)
+	if err != nil {
+		a.errorLogNResponse(w, "updating notification settings", err)
+		return
+	}
+
+	a.successResponse(w, a.notificationPrefsResponse(prefs), http.StatusOK)
+}
+
+// notificationPrefsResponse lists all events with their state in the settings.
+func (a *Application) notificationPrefsResponse(prefs entities.NotificationPrefs) NotificationPrefsResponse {
+	resp := NotificationPrefsResponse{
+		Available: a.svcGw.Notifications.Available(),
+		Events:    make([]NotificationPrefData, 0, len(entities.NotificationEvents)),
+	}
+	for _, event := range entities.NotificationEvents {
+		resp.Events = append(resp.Events, NotificationPrefData{
+			Event:   NotificationEvent(event),
+			Enabled: prefs.Enabled(event),
+		})
+	}
+
+	return resp
+}
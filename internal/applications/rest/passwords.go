@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// passwordForgotPath is the API path requesting password reset links, it is used without a login
+const passwordForgotPath = "/api/v1/auth/password/forgot"
+
+// passwordResetPage is the HTML page the password reset links open, it shows the form
+// submitting the new password with the token until the password is set.
+var passwordResetPage = template.Must(template.New("password_reset").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Ovoo - reset password</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 4em auto;">
+{{- if .Done }}
+<h1>Password changed</h1>
+<p>You can log in with the new password now.</p>
+{{- else }}
+<h1>Reset password</h1>
+{{- if .Error }}
+<p><b>{{ .Error }}</b></p>
+{{- end }}
+<form method="post" action="{{ .Action }}">
+<input type="hidden" name="token" value="{{ .Token }}">
+<p><label>New password <input type="password" name="password" autocomplete="new-password" required></label></p>
+<button type="submit">Set password</button>
+</form>
+{{- end }}
+</body>
+</html>
+`))
+
+type passwordResetPageData struct {
+	Action string
+	Token  string
+	Done   bool
+	Error  string
+}
+
+// ChangePassword sets a new password of the current user.
+func (a *Application) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "changing password: identifying user", err)
+		return
+	}
+
+	req := ChangePasswordRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "changing password: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	cmd := services.PasswordChangeCmd{CurrentPassword: req.CurrentPassword, NewPassword: req.NewPassword}
+	if err := a.svcGw.Passwords.Change(r.Context(), cuser, cmd); err != nil {
+		a.errorLogNResponse(w, "changing password", err)
+		return
+	}
+
+	a.successResponse(w, struct{}{}, http.StatusNoContent)
+}
+
+// ResetUserPassword sets or removes the password of a user on behalf of an administrator.
+func (a *Application) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "resetting user password: identifying user", err)
+		return
+	}
+
+	req := ResetUserPasswordRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "resetting user password: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	cmd := services.PasswordResetCmd{UserID: entities.Id(r.PathValue("id")), Password: req.Password}
+	if err := a.svcGw.Passwords.Reset(r.Context(), cuser, cmd); err != nil {
+		a.errorLogNResponse(w, "resetting user password", err)
+		return
+	}
+
+	a.successResponse(w, struct{}{}, http.StatusNoContent)
+}
+
+// ForgotPassword mails a password reset link to the user with the login of the request.
+// The response does not tell whether the user exists.
+func (a *Application) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	req := ForgotPasswordRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "requesting password reset: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	if err := a.svcGw.Passwords.RequestReset(r.Context(), req.Login); err != nil {
+		a.errorLogNResponse(w, "requesting password reset", err)
+		return
+	}
+
+	a.successResponse(w, "", http.StatusNoContent)
+}
+
+// GetPasswordReset renders the page of the password reset link.
+func (a *Application) GetPasswordReset(w http.ResponseWriter, r *http.Request) {
+	a.renderPasswordResetPage(w, passwordResetPageData{
+		Action: services.PasswordResetPath,
+		Token:  r.URL.Query().Get("token"),
+	}, http.StatusOK)
+}
+
+// ResetPassword sets the new password with the token of a reset link. The token and the password
+// are read from a JSON body, or from the form of the reset page which receives the result as an HTML page.
+func (a *Application) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		token := r.PostFormValue("token")
+		if err := a.svcGw.Passwords.ConfirmReset(r.Context(), token, r.PostFormValue("password")); err != nil {
+			a.logger.Error("resetting password", "error", err.Error())
+			a.renderPasswordResetPage(w, passwordResetPageData{
+				Action: services.PasswordResetPath,
+				Token:  token,
+				Error:  err.Error(),
+			}, statusFErr(err))
+			return
+		}
+
+		a.renderPasswordResetPage(w, passwordResetPageData{Done: true}, http.StatusOK)
+		return
+	}
+
+	req := ResetPasswordRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "parsing password reset request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	if err := a.svcGw.Passwords.ConfirmReset(r.Context(), req.Token, req.Password); err != nil {
+		a.errorLogNResponse(w, "resetting password", err)
+		return
+	}
+
+	a.successResponse(w, "", http.StatusNoContent)
+}
+
+func (a *Application) renderPasswordResetPage(w http.ResponseWriter, data passwordResetPageData, status int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := passwordResetPage.Execute(w, data); err != nil {
+		a.logger.Error("rendering password reset page", "error", err.Error())
+	}
+}
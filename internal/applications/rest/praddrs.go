@@ -1,12 +1,45 @@
 package rest
 
 import (
+	"html/template"
+	"mime"
 	"net/http"
 
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/services"
 )
 
+// confirmPage is the HTML page the confirmation links of protected addresses open.
+// Without a result it shows the form submitting the token, so that link scanners
+// of mail providers following the link do not confirm the address.
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Ovoo - confirm protected address</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 4em auto;">
+{{- if .Error }}
+<h1>Confirmation failed</h1>
+<p>{{ .Error }}</p>
+{{- else if .Email }}
+<h1>Address confirmed</h1>
+<p>Mail to aliases of <b>{{ .Email }}</b> is forwarded from now on.</p>
+{{- else }}
+<h1>Confirm protected address</h1>
+<form method="post" action="{{ .Action }}">
+<input type="hidden" name="token" value="{{ .Token }}">
+<button type="submit">Confirm</button>
+</form>
+{{- end }}
+</body>
+</html>
+`))
+
+type confirmPageData struct {
+	Action string
+	Token  string
+	Email  string
+	Error  string
+}
+
 // GetAllPrAddrs retrieves all protected addresses for the current user.
 // Supports filtering by ID and email through query parameters.
 func (a *Application) GetAllPrAddrs(w http.ResponseWriter, r *http.Request) {
@@ -84,6 +117,8 @@ func (a *Application) CreatePrAddr(w http.ResponseWriter, r *http.Request) {
 				Comment:     req.Metadata.Comment,
 				ServiceName: req.Metadata.ServiceName,
 			},
+			PGPKey: req.PgpKey,
+			Rules:  req.Rules,
 		},
 	)
 	if err != nil {
@@ -123,6 +158,8 @@ func (a *Application) UpdatePrAddr(w http.ResponseWriter, r *http.Request) {
 		PrAddrId: praddrId,
 		Metadata: metadata,
 		Active:   req.Active,
+		PGPKey:   req.PgpKey,
+		Rules:    req.Rules,
 	})
 	if err != nil {
 		a.errorLogNResponse(w, "updating protected address", err)
@@ -149,3 +186,68 @@ func (a *Application) DeletePrAddr(w http.ResponseWriter, r *http.Request) {
 
 	a.successResponse(w, "", http.StatusNoContent)
 }
+
+// SendPrAddrVerification sends a new confirmation link to a pending protected address.
+func (a *Application) SendPrAddrVerification(w http.ResponseWriter, r *http.Request) {
+	user, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "sending protected address verification: identifying user", err)
+		return
+	}
+
+	prAddrId := entities.Id(r.PathValue("id"))
+	if err := a.svcGw.PrAddrs.SendVerification(r.Context(), user, prAddrId); err != nil {
+		a.errorLogNResponse(w, "sending protected address verification", err)
+		return
+	}
+
+	a.successResponse(w, "", http.StatusNoContent)
+}
+
+// GetPrAddrConfirmation renders the page of the confirmation link, the address is confirmed
+// once the page form is submitted to ConfirmPrAddr.
+func (a *Application) GetPrAddrConfirmation(w http.ResponseWriter, r *http.Request) {
+	a.renderConfirmPage(w, confirmPageData{
+		Action: services.VerificationConfirmPath,
+		Token:  r.URL.Query().Get("token"),
+	}, http.StatusOK)
+}
+
+// ConfirmPrAddr confirms a protected address with the token of its confirmation link.
+// The token is read from a JSON body, or from the form of the confirmation page which
+// receives the result as an HTML page.
+func (a *Application) ConfirmPrAddr(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		praddr, err := a.svcGw.PrAddrs.Confirm(r.Context(), r.PostFormValue("token"))
+		if err != nil {
+			a.logger.Error("confirming protected address", "error", err.Error())
+			a.renderConfirmPage(w, confirmPageData{Error: err.Error()}, statusFErr(err))
+			return
+		}
+
+		a.renderConfirmPage(w, confirmPageData{Email: string(praddr.Email)}, http.StatusOK)
+		return
+	}
+
+	req := ConfirmProtectedAddressRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "parsing protected address confirm request", err)
+		return
+	}
+
+	if _, err := a.svcGw.PrAddrs.Confirm(r.Context(), req.Token); err != nil {
+		a.errorLogNResponse(w, "confirming protected address", err)
+		return
+	}
+
+	a.successResponse(w, "", http.StatusNoContent)
+}
+
+func (a *Application) renderConfirmPage(w http.ResponseWriter, data confirmPageData, status int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := confirmPage.Execute(w, data); err != nil {
+		a.logger.Error("rendering confirmation page", "error", err.Error())
+	}
+}
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// HoldMessage stores a message of the chain in the quarantine, called by the milter
+// instead of forwarding the message.
+func (a *Application) HoldMessage(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "holding message: identifying user", err)
+		return
+	}
+
+	req := HoldMessageRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "parsing hold message request", err)
+		return
+	}
+
+	msg, err := a.svcGw.Quarantine.Hold(r.Context(), cuser, services.QuarantineHoldCmd{
+		ChainHash: entities.Hash(req.Hash),
+		Data:      req.Message,
+	})
+	if err != nil {
+		a.errorLogNResponse(w, "holding message", err)
+		return
+	}
+
+	resp := QuarantinedMessageResponse(quarantinedTMessageData(msg))
+	a.successResponse(w, resp, http.StatusCreated)
+}
+
+// GetQuarantine retrieves the held messages of the aliases of the current user.
+// Supports filtering by alias and owner through query parameters.
+func (a *Application) GetQuarantine(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting quarantine: identifying user", err)
+		return
+	}
+
+	filters, err := entities.NewQuarantineFilter(r.URL.Query())
+	if err != nil {
+		a.errorLogNResponse(w, "reading quarantine filters", err)
+		return
+	}
+
+	msgs, pgm, err := a.svcGw.Quarantine.GetAll(r.Context(), cuser, filters)
+	if err != nil {
+		a.errorLogNResponse(w, "getting quarantine", err)
+		return
+	}
+
+	msgsData := make([]QuarantinedMessageData, 0, len(msgs))
+	for _, msg := range msgs {
+		msgsData = append(msgsData, quarantinedTMessageData(msg))
+	}
+
+	resp := GetQuarantineResponse{
+		Messages:           msgsData,
+		PaginationMetadata: pgmTMetadata(pgm),
+	}
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// GetQuarantinedMessage retrieves a held message by its ID with the preview of its content.
+func (a *Application) GetQuarantinedMessage(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting quarantined message: identifying user", err)
+		return
+	}
+
+	msg, err := a.svcGw.Quarantine.GetById(r.Context(), cuser, entities.Id(r.PathValue("id")))
+	if err != nil {
+		a.errorLogNResponse(w, "getting quarantined message", err)
+		return
+	}
+
+	preview, err := msg.Preview()
+	if err != nil {
+		a.errorLogNResponse(w, "previewing quarantined message", fmt.Errorf("%w: %w", entities.ErrGeneral, err))
+		return
+	}
+
+	resp := QuarantinedMessagePreviewResponse{
+		Message:       quarantinedTMessageData(msg),
+		From:          preview.From,
+		To:            preview.To,
+		Date:          preview.Date,
+		Text:          preview.Text,
+		TextTruncated: preview.Truncated,
+	}
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// ReleaseQuarantinedMessage delivers a held message to the protected address of its alias.
+func (a *Application) ReleaseQuarantinedMessage(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "releasing quarantined message: identifying user", err)
+		return
+	}
+
+	msg, err := a.svcGw.Quarantine.Release(r.Context(), cuser, entities.Id(r.PathValue("id")))
+	if err != nil {
+		a.errorLogNResponse(w, "releasing quarantined message", err)
+		return
+	}
+
+	resp := QuarantinedMessageResponse(quarantinedTMessageData(msg))
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// DeleteQuarantinedMessage removes a held message without delivering it.
+func (a *Application) DeleteQuarantinedMessage(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "deleting quarantined message: identifying user", err)
+		return
+	}
+
+	if err := a.svcGw.Quarantine.Delete(r.Context(), cuser, entities.Id(r.PathValue("id"))); err != nil {
+		a.errorLogNResponse(w, "deleting quarantined message", err)
+		return
+	}
+
+	a.successResponse(w, "", http.StatusNoContent)
+}
@@ -0,0 +1,197 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// GetTraps retrieves the trap addresses.
+func (a *Application) GetTraps(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting traps: identifying user", err)
+		return
+	}
+
+	filters, err := entities.NewAddressFilter(r.URL.Query())
+	if err != nil {
+		a.errorLogNResponse(w, "reading traps filters", err)
+		return
+	}
+
+	traps, pgm, err := a.svcGw.Reputation.GetTraps(r.Context(), cuser, filters)
+	if err != nil {
+		a.errorLogNResponse(w, "getting traps", err)
+		return
+	}
+
+	trapsData := make([]TrapData, 0, len(traps))
+	for _, trap := range traps {
+		trapsData = append(trapsData, trapTTrapData(trap))
+	}
+
+	resp := GetTrapsResponse{
+		Traps:              trapsData,
+		PaginationMetadata: pgmTMetadata(pgm),
+	}
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// CreateTrap creates a trap address, the senders writing to it are added to the sender reputation.
+func (a *Application) CreateTrap(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "creating trap: identifying user", err)
+		return
+	}
+
+	req := CreateTrapRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "parsing create trap request", err)
+		return
+	}
+
+	trap, err := a.svcGw.Reputation.CreateTrap(r.Context(), cuser, services.TrapCreateCmd{
+		Email:   string(req.Email),
+		Comment: req.Comment,
+	})
+	if err != nil {
+		a.errorLogNResponse(w, "creating trap", err)
+		return
+	}
+
+	resp := TrapResponse(trapTTrapData(trap))
+	a.successResponse(w, resp, http.StatusCreated)
+}
+
+// DeleteTrap deletes a trap address by its ID.
+func (a *Application) DeleteTrap(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "deleting trap: identifying user", err)
+		return
+	}
+
+	if err := a.svcGw.Reputation.DeleteTrap(r.Context(), cuser, entities.Id(r.PathValue("id"))); err != nil {
+		a.errorLogNResponse(w, "deleting trap", err)
+		return
+	}
+
+	a.successResponse(w, "", http.StatusNoContent)
+}
+
+// GetSenderReputation retrieves the senders of the sender reputation.
+// Supports filtering by sender and override through query parameters.
+func (a *Application) GetSenderReputation(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting sender reputation: identifying user", err)
+		return
+	}
+
+	filters, err := entities.NewReputationFilter(r.URL.Query())
+	if err != nil {
+		a.errorLogNResponse(w, "reading sender reputation filters", err)
+		return
+	}
+
+	reps, pgm, err := a.svcGw.Reputation.GetAll(r.Context(), cuser, filters)
+	if err != nil {
+		a.errorLogNResponse(w, "getting sender reputation", err)
+		return
+	}
+
+	now := time.Now()
+	policy := a.svcGw.Reputation.Policy()
+	repsData := make([]SenderReputationData, 0, len(reps))
+	for _, rep := range reps {
+		repsData = append(repsData, senderReputationTData(rep, policy, now))
+	}
+
+	resp := GetSenderReputationResponse{
+		Senders:            repsData,
+		PaginationMetadata: pgmTMetadata(pgm),
+	}
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// OverrideSenderReputation blocks or allows a sender whatever its score.
+func (a *Application) OverrideSenderReputation(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "overriding sender reputation: identifying user", err)
+		return
+	}
+
+	req := OverrideSenderReputationRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "parsing override sender reputation request", err)
+		return
+	}
+
+	override, err := reputationTEntityOverride(req.Override)
+	if err != nil {
+		a.errorLogNResponse(w, "parsing override sender reputation request", err)
+		return
+	}
+
+	rep, err := a.svcGw.Reputation.Override(r.Context(), cuser, services.ReputationOverrideCmd{
+		Sender:   r.PathValue("sender"),
+		Override: override,
+		Comment:  req.Comment,
+	})
+	if err != nil {
+		a.errorLogNResponse(w, "overriding sender reputation", err)
+		return
+	}
+
+	resp := SenderReputationResponse(senderReputationTData(rep, a.svcGw.Reputation.Policy(), time.Now()))
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// DeleteSenderReputation removes a sender from the sender reputation.
+func (a *Application) DeleteSenderReputation(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "deleting sender reputation: identifying user", err)
+		return
+	}
+
+	if err := a.svcGw.Reputation.Delete(r.Context(), cuser, r.PathValue("sender")); err != nil {
+		a.errorLogNResponse(w, "deleting sender reputation", err)
+		return
+	}
+
+	a.successResponse(w, "", http.StatusNoContent)
+}
+
+// LookupSenderReputation reports whether a sender is listed by the sender reputation, called by the socketmap.
+func (a *Application) LookupSenderReputation(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "looking up sender reputation: identifying user", err)
+		return
+	}
+
+	rep, err := a.svcGw.Reputation.Lookup(r.Context(), cuser, r.PathValue("sender"))
+	if err != nil {
+		a.errorLogNResponse(w, "looking up sender reputation", err)
+		return
+	}
+
+	policy := a.svcGw.Reputation.Policy()
+	action := Reject
+	if !policy.Reject() {
+		action = Quarantine
+	}
+
+	resp := SenderLookupResponse{
+		Sender: rep.Sender.String(),
+		Listed: policy.Listed(rep, time.Now()),
+		Action: action,
+	}
+	a.successResponse(w, resp, http.StatusOK)
+}
@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// GetRoles retrieves all roles matching the query filters.
+func (a *Application) GetRoles(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting roles: identifying user", err)
+		return
+	}
+
+	filters, err := entities.NewRoleFilter(r.URL.Query())
+	if err != nil {
+		a.errorLogNResponse(w, "reading roles filters", err)
+		return
+	}
+
+	roles, pgm, err := a.svcGw.Roles.GetAll(r.Context(), cuser, filters)
+	if err != nil {
+		a.errorLogNResponse(w, "getting roles", err)
+		return
+	}
+
+	resp := GetRolesResponse{
+		Roles:              make([]RoleData, 0, len(roles)),
+		PaginationMetadata: pgmTMetadata(pgm),
+	}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, roleTRoleData(role))
+	}
+
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// GetRoleById retrieves a role by its ID.
+func (a *Application) GetRoleById(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting role by id: identifying user", err)
+		return
+	}
+
+	role, err := a.svcGw.Roles.GetById(r.Context(), cuser, entities.Id(r.PathValue("id")))
+	if err != nil {
+		a.errorLogNResponse(w, "getting role by id", err)
+		return
+	}
+
+	resp := GetRoleDetailsResponse(roleTRoleData(role))
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// GetPermissions returns the list of all permissions which can be granted by roles.
+func (a *Application) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting permissions: identifying user", err)
+		return
+	}
+
+	perms, err := a.svcGw.Roles.Permissions(r.Context(), cuser)
+	if err != nil {
+		a.errorLogNResponse(w, "getting permissions", err)
+		return
+	}
+
+	resp := GetPermissionsResponse(permissionsTStrings(perms))
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// CreateRole creates a new role.
+func (a *Application) CreateRole(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "creating role: identifying user", err)
+		return
+	}
+
+	req := CreateRoleRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "creating role: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	cmd := services.RoleCreateCmd{
+		Name:        req.Name,
+		Permissions: permissionsFStrings(req.Permissions),
+	}
+	if req.Description != nil {
+		cmd.Description = *req.Description
+	}
+
+	role, err := a.svcGw.Roles.Create(r.Context(), cuser, cmd)
+	if err != nil {
+		a.errorLogNResponse(w, "creating role", err)
+		return
+	}
+
+	resp := CreateRoleResponse(roleTRoleData(role))
+	a.successResponse(w, resp, http.StatusCreated)
+}
+
+// UpdateRole updates an existing role.
+func (a *Application) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "updating role: identifying user", err)
+		return
+	}
+
+	req := UpdateRoleRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "updating role: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	cmd := services.RoleUpdateCmd{
+		RoleId:      entities.Id(r.PathValue("id")),
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if req.Permissions != nil {
+		cmd.Permissions = new(permissionsFStrings(*req.Permissions))
+	}
+
+	role, err := a.svcGw.Roles.Update(r.Context(), cuser, cmd)
+	if err != nil {
+		a.errorLogNResponse(w, "updating role", err)
+		return
+	}
+
+	resp := UpdateRoleResponse(roleTRoleData(role))
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// DeleteRole deletes a role by its ID.
+func (a *Application) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "deleting role: identifying user", err)
+		return
+	}
+
+	if err := a.svcGw.Roles.Delete(r.Context(), cuser, entities.Id(r.PathValue("id"))); err != nil {
+		a.errorLogNResponse(w, "deleting role", err)
+		return
+	}
+
+	a.successResponse(w, struct{}{}, http.StatusNoContent)
+}
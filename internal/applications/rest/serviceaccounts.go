@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/services"
+)
+
+// GetServiceAccounts retrieves all service accounts matching the query filters.
+func (a *Application) GetServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting service accounts: identifying user", err)
+		return
+	}
+
+	filters, err := entities.NewUserFilter(r.URL.Query())
+	if err != nil {
+		a.errorLogNResponse(w, "reading service accounts filters", err)
+		return
+	}
+
+	accounts, pgm, err := a.svcGw.SvcAccs.GetAll(r.Context(), cuser, filters)
+	if err != nil {
+		a.errorLogNResponse(w, "getting service accounts", err)
+		return
+	}
+
+	resp := GetServiceAccountsResponse{
+		ServiceAccounts:    make([]UserData, 0, len(accounts)),
+		PaginationMetadata: pgmTMetadata(pgm),
+	}
+	for _, account := range accounts {
+		resp.ServiceAccounts = append(resp.ServiceAccounts, userTResponse(account))
+	}
+
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// CreateServiceAccount creates a new service account and returns it along with its first API token.
+func (a *Application) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "creating service account: identifying user", err)
+		return
+	}
+
+	req := CreateServiceAccountRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "creating service account: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	cmd := services.ServiceAccountCreateCmd{
+		Login: req.Login,
+	}
+	if req.Description != nil {
+		cmd.Description = *req.Description
+	}
+	if req.ExpireIn != nil {
+		cmd.ExpireIn = *req.ExpireIn
+	}
+
+	account, token, err := a.svcGw.SvcAccs.Create(r.Context(), cuser, cmd)
+	if err != nil {
+		a.errorLogNResponse(w, "creating service account", err)
+		return
+	}
+
+	resp := CreateServiceAccountResponse{
+		ServiceAccount: userTResponse(account),
+		ApiToken:       tokenTApiTokenDataOnCreate(token),
+	}
+	a.successResponse(w, resp, http.StatusCreated)
+}
+
+// RotateServiceAccountToken issues a new API token for the service account.
+func (a *Application) RotateServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "rotating service account token: identifying user", err)
+		return
+	}
+
+	req := RotateServiceAccountTokenRequest{}
+	if err := readBody(r.Body, &req); err != nil {
+		a.errorLogNResponse(w, "rotating service account token: parsing request", fmt.Errorf("%w: %w", entities.ErrValidation, err))
+		return
+	}
+
+	cmd := services.ServiceAccountRotateCmd{
+		AccountId: entities.Id(r.PathValue("id")),
+		Overlap:   services.DefaultServiceTokenOverlap,
+	}
+	if req.ExpireIn != nil {
+		cmd.ExpireIn = *req.ExpireIn
+	}
+	if req.Overlap != nil {
+		cmd.Overlap = time.Duration(*req.Overlap) * time.Second
+	}
+
+	token, err := a.svcGw.SvcAccs.RotateToken(r.Context(), cuser, cmd)
+	if err != nil {
+		a.errorLogNResponse(w, "rotating service account token", err)
+		return
+	}
+
+	resp := CreateApiTokenResponse(tokenTApiTokenDataOnCreate(token))
+	a.successResponse(w, resp, http.StatusCreated)
+}
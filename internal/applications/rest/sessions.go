@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Burmuley/ovoo/internal/applications/rest/middleware"
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// GetSessions returns active server-side sessions of the current user.
+func (a *Application) GetSessions(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "getting sessions: identifying user", err)
+		return
+	}
+
+	sessions, err := a.svcGw.Sessions.GetAll(r.Context(), cuser)
+	if err != nil {
+		a.errorLogNResponse(w, "getting sessions", err)
+		return
+	}
+
+	// session the request is authenticated with, if any
+	current, _ := r.Context().Value(middleware.SessionContextKey).(entities.Hash)
+	resp := GetSessionsResponse{Sessions: make([]SessionData, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, sessionTData(session, current))
+	}
+
+	a.successResponse(w, resp, http.StatusOK)
+}
+
+// DeleteSession revokes the server-side session of the current user.
+func (a *Application) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	cuser, err := userFromContext(r)
+	if err != nil {
+		a.errorLogNResponse(w, "deleting session: identifying user", err)
+		return
+	}
+
+	if err := a.svcGw.Sessions.Delete(r.Context(), cuser, entities.Hash(r.PathValue("id"))); err != nil {
+		a.errorLogNResponse(w, "deleting session", err)
+		return
+	}
+
+	a.successResponse(w, struct{}{}, http.StatusNoContent)
+}
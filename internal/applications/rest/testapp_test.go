@@ -8,6 +8,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
 	"github.com/Burmuley/ovoo/internal/services"
@@ -20,6 +22,9 @@ type testApp struct {
 	usersRepo  *mockUsersRepo
 	tokensRepo *mockTokensRepo
 	domainRepo *mockDomainRepo
+	rolesRepo  *mockRolesRepo
+	reputation *memoryReputationRepo
+	cache      *memory.MemoryCache
 }
 
 func newTestApp(t *testing.T) *testApp {
@@ -30,31 +35,61 @@ func newTestApp(t *testing.T) *testApp {
 		usersRepo:  new(mockUsersRepo),
 		tokensRepo: new(mockTokensRepo),
 		domainRepo: newMockDomainRepo(),
+		rolesRepo:  new(mockRolesRepo),
+		reputation: &memoryReputationRepo{senders: map[entities.Email]entities.SenderReputation{}},
 	}
+	ta.cache, _ = memory.New()
 	repof := &factory.RepoFactory{
-		Address:   ta.addrRepo,
-		Chain:     ta.chainRepo,
-		Users:     ta.usersRepo,
-		ApiTokens: ta.tokensRepo,
-		Domain:    ta.domainRepo,
+		Address:    ta.addrRepo,
+		Chain:      ta.chainRepo,
+		Users:      ta.usersRepo,
+		ApiTokens:  ta.tokensRepo,
+		Domain:     ta.domainRepo,
+		Roles:      ta.rolesRepo,
+		Reputation: ta.reputation,
+		Cache:      ta.cache,
 	}
 	aliasesSvc, err := services.NewAliasesService([]string{"alpha", "bravo", "charlie"}, repof)
 	require.NoError(t, err)
-	prAddrsSvc, err := services.NewProtectedAddrService(repof)
+	prAddrsSvc, err := services.NewProtectedAddrService(repof, nil, config.ConfigVerification{Disabled: true})
 	require.NoError(t, err)
-	usersSvc, err := services.NewUsersService(repof)
+	usersSvc, err := services.NewUsersService(repof, entities.PasswordPolicy{})
 	require.NoError(t, err)
-	chainsSvc, err := services.NewChainsService(repof)
+	chainsSvc, err := services.NewChainsService(repof, entities.ReputationPolicy{})
 	require.NoError(t, err)
 	tokensSvc, err := services.NewApiTokensService(repof)
 	require.NoError(t, err)
+	rolesSvc, err := services.NewRolesService(repof)
+	require.NoError(t, err)
+	svcAccsSvc, err := services.NewServiceAccountsService(repof)
+	require.NoError(t, err)
+	mfaSvc, err := services.NewMFAService(repof, config.ConfigMFA{})
+	require.NoError(t, err)
+	sessionsSvc, err := services.NewSessionsService(repof, config.ConfigSessions{})
+	require.NoError(t, err)
+	notificationsSvc, err := services.NewNotificationsService(repof)
+	require.NoError(t, err)
+	passwordsSvc, err := services.NewPasswordsService(repof, entities.PasswordPolicy{}, nil, config.ConfigPasswords{}, config.ConfigVerification{})
+	require.NoError(t, err)
+	quarantineSvc, err := services.NewQuarantineService(repof, nil, config.ConfigQuarantine{}, entities.ReputationPolicy{})
+	require.NoError(t, err)
+	reputationSvc, err := services.NewReputationService(repof, entities.ReputationPolicy{})
+	require.NoError(t, err)
 
 	gw := &services.ServiceGateway{
-		Aliases: aliasesSvc,
-		Users:   usersSvc,
-		PrAddrs: prAddrsSvc,
-		Chains:  chainsSvc,
-		Tokens:  tokensSvc,
+		Aliases:       aliasesSvc,
+		Users:         usersSvc,
+		PrAddrs:       prAddrsSvc,
+		Chains:        chainsSvc,
+		Tokens:        tokensSvc,
+		Roles:         rolesSvc,
+		SvcAccs:       svcAccsSvc,
+		MFA:           mfaSvc,
+		Sessions:      sessionsSvc,
+		Notifications: notificationsSvc,
+		Passwords:     passwordsSvc,
+		Quarantine:    quarantineSvc,
+		Reputation:    reputationSvc,
 	}
 	ta.app = &Application{
 		svcGw:  gw,
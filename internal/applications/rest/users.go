@@ -49,6 +49,7 @@ func (a *Application) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := userTResponse(cuser)
+	resp.Permissions = new(permissionsTStrings(cuser.Permissions()))
 	a.successResponse(w, resp, http.StatusOK)
 }
 
@@ -85,13 +86,17 @@ func (a *Application) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := a.svcGw.Users.Create(r.Context(), cuser, services.UserCreateCmd{
+	cmd := services.UserCreateCmd{
 		Login:     req.Login,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Type:      entities.UserType(userTypeFStr(req.Type)),
 		Password:  req.Password,
-	})
+	}
+	if req.RoleId != nil {
+		cmd.RoleId = new(entities.Id(*req.RoleId))
+	}
+	user, err := a.svcGw.Users.Create(r.Context(), cuser, cmd)
 
 	if err != nil {
 		a.errorLogNResponse(w, "creating user", err)
@@ -118,15 +123,19 @@ func (a *Application) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cmd := services.UserUpdateCmd{
-		UserID:    entities.Id(userId),
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Active:    req.Active,
+		UserID:        entities.Id(userId),
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Active:        req.Active,
+		PrivacyFilter: req.PrivacyFilter,
 	}
 	if req.Type != nil {
 		utyp := userTypeFStr(*req.Type)
 		cmd.Type = &utyp
 	}
+	if req.RoleId != nil {
+		cmd.RoleId = new(entities.Id(*req.RoleId))
+	}
 	user, err := a.svcGw.Users.Update(r.Context(), cuser, cmd)
 	if err != nil {
 		a.errorLogNResponse(w, "updating user by id", fmt.Errorf("updating user: %w", err))
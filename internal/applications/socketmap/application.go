@@ -2,12 +2,18 @@ package socketmap
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+	"github.com/Burmuley/ovoo/internal/health"
+	"github.com/Burmuley/ovoo/internal/metrics"
+	"github.com/Burmuley/ovoo/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -15,18 +21,36 @@ const (
 	DefaultSocketmapAddr    = "/tmp/ovoo_socketmap.sock"
 )
 
+// socketmap lookup results recorded in the metrics
+const (
+	lookupFound      = "found"
+	lookupNotFound   = "not_found"
+	lookupUnknown    = "unknown"
+	lookupTempFailed = "tempfailed" // the Ovoo API is unavailable, the MTA retries later
+)
+
 type Application struct {
-	network string
-	addr    string
-	cli     ovooclient.Client
+	network     string
+	addr        string
+	metricsAddr string
+	healthAddr  string
+	health      *health.Checker
+	cli         ovooclient.Client
 }
 
-func New(network, listenAddr string, ovooCli ovooclient.Client) (*Application, error) {
+// New creates the socketmap application, metrics are served on metricsAddr and the health probes
+// on healthAddr when they are not empty. The socketmap is ready when the Ovoo API is reachable, and
+// keeps running for shutdownDelay after the readiness probe starts failing on shutdown.
+func New(network, listenAddr, metricsAddr, healthAddr string, shutdownDelay time.Duration, ovooCli ovooclient.Client) (*Application, error) {
 	ctrl := &Application{
-		network: network,
-		addr:    listenAddr,
-		cli:     ovooCli,
+		network:     network,
+		addr:        listenAddr,
+		metricsAddr: metricsAddr,
+		healthAddr:  healthAddr,
+		health:      health.New(shutdownDelay),
+		cli:         ovooCli,
 	}
+	ctrl.health.Add("api", ovooCli.Ping)
 
 	return ctrl, nil
 }
@@ -41,6 +65,17 @@ func (m *Application) Start() error {
 		return err
 	}
 
+	if m.metricsAddr != "" {
+		go metrics.Serve(ctx, m.metricsAddr, slog.Default())
+	}
+
+	// the probes are served until the socketmap is stopped, so the instance can be drained first
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	if m.healthAddr != "" {
+		go m.health.Serve(healthCtx, m.healthAddr, slog.Default())
+	}
+
 	go func() {
 		slog.Info("starting Ovoo Socketmap server", m.network, m.addr)
 		srv.Wait(ovooHandler(m.cli))
@@ -48,6 +83,11 @@ func (m *Application) Start() error {
 	}()
 
 	<-ctx.Done()
+	if m.healthAddr != "" {
+		slog.Info("draining Ovoo Socketmap server")
+		m.health.Drain(context.Background())
+	}
+
 	slog.Info("shutting down Ovoo Socketmap server")
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -63,16 +103,54 @@ func (m *Application) Start() error {
 func ovooHandler(cli ovooclient.Client) func(ctx context.Context, lookup, key string) (result string, found bool, err error) {
 	return func(ctx context.Context, lookup, key string) (result string, found bool, err error) {
 		slog.Info("handler call: lookup=" + lookup + " key=" + key)
+		ctx, span := tracing.Start(ctx, "socketmap.lookup",
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("socketmap.lookup", lookup)),
+		)
+		defer func() {
+			span.SetAttributes(attribute.Bool("socketmap.found", found))
+			tracing.End(span, err)
+		}()
+
 		switch lookup {
 		case "relay_domain":
-			hasDomain := cli.GetDomainByName(ctx, key)
+			hasDomain, err := cli.LookupDomain(ctx, key)
+			if errors.Is(err, ovooclient.ErrUnavailable) {
+				metrics.ObserveSocketmapLookup(lookup, lookupTempFailed)
+				return "", false, TempError{Reason: err.Error()}
+			}
+			if err != nil {
+				slog.Error("looking up domain", "domain", key, "error", err.Error())
+			}
 			if hasDomain {
+				metrics.ObserveSocketmapLookup(lookup, lookupFound)
 				return key, true, nil
 			}
 
+			metrics.ObserveSocketmapLookup(lookup, lookupNotFound)
+			return "", false, nil
+		case "sender_access":
+			// exports the senders rejected by the sender reputation as a Postfix access map,
+			// the mail of the senders held in the quarantine is left to the milter
+			rep, err := cli.LookupSender(ctx, key)
+			if errors.Is(err, ovooclient.ErrUnavailable) {
+				metrics.ObserveSocketmapLookup(lookup, lookupTempFailed)
+				return "", false, TempError{Reason: err.Error()}
+			}
+			if err != nil {
+				slog.Error("looking up sender", "sender", key, "error", err.Error())
+			}
+			if rep.Listed && rep.Action == "reject" {
+				metrics.ObserveSocketmapLookup(lookup, lookupFound)
+				return "REJECT 5.7.1 sender is listed by the sender reputation", true, nil
+			}
+
+			metrics.ObserveSocketmapLookup(lookup, lookupNotFound)
 			return "", false, nil
 		}
 
+		// the lookup name is not used as a label, so unknown lookups can not grow the metrics
+		metrics.ObserveSocketmapLookup(lookupUnknown, lookupUnknown)
 		return "", false, PermanentError{Reason: "unknown lookup " + lookup}
 	}
 }
@@ -0,0 +1,120 @@
+package socketmap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/applications/ovooclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiClient returns a client of an API responding to the domains requests with status and
+// the given domains, without retries.
+func apiClient(t *testing.T, status int, domains ...string) ovooclient.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		resp := ovooclient.GetDomainsResponse{}
+		for _, d := range domains {
+			resp.Domains = append(resp.Domains, ovooclient.DomainData{Name: d})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	cli, err := ovooclient.NewClient(srv.URL, "test-token", false, 5*time.Second)
+	require.NoError(t, err)
+	return cli.WithResilience(ovooclient.Resilience{Retries: -1})
+}
+
+func TestOvooHandler_RelayDomain(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		domains   []string
+		wantFound bool
+		wantErr   error
+	}{
+		{name: "found", status: http.StatusOK, domains: []string{"ovoo.com"}, wantFound: true},
+		{name: "not found", status: http.StatusOK},
+		{name: "api error", status: http.StatusForbidden},
+		{name: "api unavailable", status: http.StatusServiceUnavailable, wantErr: TempError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := ovooHandler(apiClient(t, tt.status, tt.domains...))
+			result, found, err := handler(context.Background(), "relay_domain", "ovoo.com")
+			assert.Equal(t, tt.wantFound, found)
+			if tt.wantErr != nil {
+				assert.IsType(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.wantFound {
+				assert.Equal(t, "ovoo.com", result)
+			}
+		})
+	}
+}
+
+func TestOvooHandler_UnknownLookup(t *testing.T) {
+	handler := ovooHandler(apiClient(t, http.StatusOK))
+	_, found, err := handler(context.Background(), "unknown", "key")
+	assert.False(t, found)
+	assert.IsType(t, PermanentError{}, err)
+}
+
+func TestOvooHandler_SenderAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantResult string
+		wantFound  bool
+		wantErr    error
+	}{
+		{
+			name:       "rejected",
+			status:     http.StatusOK,
+			body:       `{"sender":"spam@bulk.com","listed":true,"action":"reject"}`,
+			wantResult: "REJECT 5.7.1 sender is listed by the sender reputation",
+			wantFound:  true,
+		},
+		{name: "held by the milter", status: http.StatusOK, body: `{"sender":"spam@bulk.com","listed":true,"action":"quarantine"}`},
+		{name: "not listed", status: http.StatusOK, body: `{"sender":"spam@bulk.com","listed":false,"action":"reject"}`},
+		{name: "api error", status: http.StatusForbidden, body: `{"errors":[]}`},
+		{name: "api unavailable", status: http.StatusServiceUnavailable, body: `{}`, wantErr: TempError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/private/api/v1/reputation/senders/spam@bulk.com", r.URL.Path)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			cli, err := ovooclient.NewClient(srv.URL, "test-token", false, 5*time.Second)
+			require.NoError(t, err)
+
+			handler := ovooHandler(cli.WithResilience(ovooclient.Resilience{Retries: -1}))
+			result, found, err := handler(context.Background(), "sender_access", "spam@bulk.com")
+			assert.Equal(t, tt.wantFound, found)
+			if tt.wantErr != nil {
+				assert.IsType(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResult, result)
+		})
+	}
+}
@@ -2,7 +2,14 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"time"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/cache/drivers/redis"
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/metrics"
 )
 
 type Cache interface {
@@ -10,4 +17,60 @@ type Cache interface {
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
 	DeleteByPrefix(ctx context.Context, prefix string) error
+	KeysByPrefix(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Pinger is implemented by the caches kept by an external service, e.g. Redis.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that the cache is usable, the caches in memory of the process always are.
+func Ping(ctx context.Context, c Cache) error {
+	if p, ok := c.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+// New creates the cache configured by the "cache" section of the application configuration.
+// An in-memory cache of the current process is created when no cache is configured,
+// it is bounded with LRU eviction when the memory driver limits are set. The "tiered" driver
+// keeps such an in-memory cache in front of Redis and invalidates it on all nodes.
+func New(cfg *config.ConfigCache) (Cache, error) {
+	if cfg == nil {
+		return memory.New()
+	}
+
+	switch cfg.CacheDriver {
+	case "memory":
+		return newMemory(cfg)
+	case "redis":
+		return redis.New(*cfg)
+	case "tiered":
+		local, err := newMemory(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewTiered(*cfg, local)
+	default:
+		return nil, fmt.Errorf("%w: unknown cache driver '%s'", entities.ErrConfiguration, cfg.CacheDriver)
+	}
+}
+
+// newMemory creates the in-memory cache, bounded when the memory driver limits are set.
+// The counters of the bounded cache are exported as metrics.
+func newMemory(cfg *config.ConfigCache) (Cache, error) {
+	if mem := cfg.Config.Memory; mem != nil && (mem.MaxEntries != 0 || mem.MaxBytes != 0) {
+		lru, err := memory.NewLRU(mem.MaxEntries, mem.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics.RegisterLocalCache(lru)
+		return lru, nil
+	}
+
+	return memory.New()
 }
@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// lruEntry is the value of an LRUCache list element.
+type lruEntry struct {
+	key   string
+	value []byte
+	ttl   time.Time
+}
+
+// size is the number of bytes accounted for the entry against the MaxBytes limit.
+func (e *lruEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+// Stats holds the counters of an LRUCache.
+type Stats struct {
+	Hits      uint64 // Get calls returning a value
+	Misses    uint64 // Get calls returning entities.ErrNotFound
+	Evictions uint64 // entries removed to stay within the size limits
+	Entries   int    // current number of entries, including expired ones not yet removed
+	Bytes     int64  // current size of keys and values
+}
+
+// LRUCache is an in-process, goroutine-safe cache.Cache implementation bounded by
+// the number of entries and/or the total size of keys and values. When a limit is
+// exceeded the least recently used entries are evicted. Expired entries are removed
+// lazily on Get and by a sweep on Set, like in MemoryCache.
+//
+// Keys are indexed by their colon separated segments, so DeleteByPrefix only visits
+// the matching keys.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	items      map[string]*list.Element
+	order      *list.List // front is the most recently used
+	index      *prefixIndex
+	bytes      int64
+	sweptAt    time.Time
+	hits       uint64
+	misses     uint64
+	evictions  uint64
+}
+
+// NewLRU allocates an empty LRUCache holding at most maxEntries entries and maxBytes
+// bytes of keys and values. A zero limit is not enforced, but at least one limit is required.
+func NewLRU(maxEntries int, maxBytes int64) (*LRUCache, error) {
+	if maxEntries < 0 || maxBytes < 0 {
+		return nil, fmt.Errorf("%w: memory cache limits can not be negative", entities.ErrConfiguration)
+	}
+
+	if maxEntries == 0 && maxBytes == 0 {
+		return nil, fmt.Errorf("%w: memory cache requires max_entries or max_bytes", entities.ErrConfiguration)
+	}
+
+	return &LRUCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		index:      newPrefixIndex(),
+		sweptAt:    time.Now(),
+	}, nil
+}
+
+// Get returns the value stored under key and marks it as the most recently used.
+// It returns entities.ErrNotFound when the key is absent or its TTL has elapsed;
+// in the latter case the entry is also deleted.
+// Returns ctx.Err() immediately if the context is already done.
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, entities.ErrNotFound
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.ttl) {
+		c.removeElement(elem)
+		c.misses++
+		return nil, entities.ErrNotFound
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return entry.value, nil
+}
+
+// Set stores value under key, replacing any previous entry, and evicts the least recently
+// used entries while the limits are exceeded. A value larger than max_bytes is not stored.
+// The entry expires at now+ttl; a zero or negative ttl makes it immediately expired.
+// Returns ctx.Err() immediately if the context is already done.
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.sweptAt) >= sweepInterval {
+		c.sweep(now)
+	}
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+
+	entry := &lruEntry{key: key, value: value, ttl: now.Add(ttl)}
+	if c.maxBytes > 0 && entry.size() > c.maxBytes {
+		c.evictions++
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(entry)
+	c.index.add(key)
+	c.bytes += entry.size()
+
+	for (c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+
+	return nil
+}
+
+// Delete removes key from the cache.
+// Returns entities.ErrNotFound if the key does not exist. Returns ctx.Err() if the context is done.
+func (c *LRUCache) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return entities.ErrNotFound
+	}
+
+	c.removeElement(elem)
+	return nil
+}
+
+// DeleteByPrefix removes all keys that start with prefix using the prefix index.
+// Returns entities.ErrNotFound when no matching keys are found.
+// An empty prefix matches every key. Returns ctx.Err() if the context is done.
+func (c *LRUCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.index.withPrefix(prefix)
+	if len(keys) == 0 {
+		return entities.ErrNotFound
+	}
+
+	for _, key := range keys {
+		c.removeElement(c.items[key])
+	}
+
+	return nil
+}
+
+// KeysByPrefix returns the keys that start with prefix using the prefix index, expired
+// entries are skipped. The entries are not marked as used.
+// An empty prefix matches every key. Returns ctx.Err() if the context is done.
+func (c *LRUCache) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	keys := c.index.withPrefix(prefix)
+	return slices.DeleteFunc(keys, func(key string) bool {
+		return now.After(c.items[key].Value.(*lruEntry).ttl)
+	}), nil
+}
+
+// Stats returns the current counters of the cache.
+func (c *LRUCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   c.order.Len(),
+		Bytes:     c.bytes,
+	}
+}
+
+// removeElement drops the entry from the list, the map and the prefix index,
+// should be called with c.mu held.
+func (c *LRUCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*lruEntry)
+	delete(c.items, entry.key)
+	c.index.remove(entry.key)
+	c.bytes -= entry.size()
+}
+
+// sweep removes all expired entries, should be called with c.mu held.
+func (c *LRUCache) sweep(now time.Time) {
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*lruEntry).ttl) {
+			c.removeElement(elem)
+		}
+		elem = prev
+	}
+	c.sweptAt = now
+}
@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLRU(t *testing.T, maxEntries int, maxBytes int64) *LRUCache {
+	t.Helper()
+	c, err := NewLRU(maxEntries, maxBytes)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNewLRU_InvalidLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+	}{
+		{name: "no limits", maxEntries: 0, maxBytes: 0},
+		{name: "negative entries", maxEntries: -1, maxBytes: 0},
+		{name: "negative bytes", maxEntries: 10, maxBytes: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLRU(tt.maxEntries, tt.maxBytes)
+			assert.ErrorIs(t, err, entities.ErrConfiguration)
+		})
+	}
+}
+
+func TestLRU_SetGetDelete(t *testing.T) {
+	c := newLRU(t, 10, 0)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), longTTL))
+	got, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v"), got)
+
+	require.NoError(t, c.Delete(ctx, "k"))
+	_, err = c.Get(ctx, "k")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	assert.ErrorIs(t, c.Delete(ctx, "k"), entities.ErrNotFound)
+}
+
+func TestLRU_ExpiredEntry(t *testing.T) {
+	c := newLRU(t, 10, 0)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), shortTTL))
+	require.NoError(t, c.Set(ctx, "zero", []byte("v"), 0))
+	time.Sleep(shortSleep)
+
+	_, err := c.Get(ctx, "k")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	_, err = c.Get(ctx, "zero")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	assert.Zero(t, c.Stats().Entries, "expired entries read by Get must be removed")
+}
+
+func TestLRU_EvictsLeastRecentlyUsedByEntries(t *testing.T) {
+	c := newLRU(t, 2, 0)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), longTTL))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), longTTL))
+	_, err := c.Get(ctx, "a") // b is now the least recently used
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), longTTL))
+
+	_, err = c.Get(ctx, "b")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	for _, key := range []string{"a", "c"} {
+		_, err = c.Get(ctx, key)
+		assert.NoError(t, err, key)
+	}
+
+	assert.Equal(t, uint64(1), c.Stats().Evictions)
+}
+
+func TestLRU_EvictsByBytes(t *testing.T) {
+	c := newLRU(t, 0, 10)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1234"), longTTL)) // 5 bytes
+	require.NoError(t, c.Set(ctx, "b", []byte("1234"), longTTL)) // 10 bytes
+	require.NoError(t, c.Set(ctx, "c", []byte("12"), longTTL))   // 13 bytes, evicts a
+
+	_, err := c.Get(ctx, "a")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+
+	stats := c.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(8), stats.Bytes)
+	assert.Equal(t, uint64(1), stats.Evictions)
+}
+
+func TestLRU_OverwriteUpdatesSize(t *testing.T) {
+	c := newLRU(t, 0, 100)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "k", []byte("12345"), longTTL))
+	require.NoError(t, c.Set(ctx, "k", []byte("12"), longTTL))
+
+	stats := c.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(3), stats.Bytes)
+}
+
+func TestLRU_ValueLargerThanMaxBytesIsNotStored(t *testing.T) {
+	c := newLRU(t, 0, 4)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "s", []byte("1"), longTTL))
+	require.NoError(t, c.Set(ctx, "k", []byte("12345"), longTTL))
+
+	_, err := c.Get(ctx, "k")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	_, err = c.Get(ctx, "s")
+	assert.NoError(t, err, "oversized value must not evict other entries")
+}
+
+func TestLRU_HitMissCounters(t *testing.T) {
+	c := newLRU(t, 10, 0)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), longTTL))
+	_, _ = c.Get(ctx, "k")
+	_, _ = c.Get(ctx, "k")
+	_, _ = c.Get(ctx, "missing")
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+}
+
+func TestLRU_DeleteByPrefix(t *testing.T) {
+	keys := []string{
+		"session:u1:s1",
+		"session:u1:s2",
+		"session:u10:s1",
+		"session:u2:s1",
+		"sessions",
+		"addr:a@example.com",
+		"plain",
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		deleted []string
+	}{
+		{name: "full segments", prefix: "session:u1:", deleted: []string{"session:u1:s1", "session:u1:s2"}},
+		{name: "partial segment", prefix: "session:u1", deleted: []string{"session:u1:s1", "session:u1:s2", "session:u10:s1"}},
+		{name: "first segment", prefix: "session", deleted: []string{"session:u1:s1", "session:u1:s2", "session:u10:s1", "session:u2:s1", "sessions"}},
+		{name: "exact key", prefix: "addr:a@example.com", deleted: []string{"addr:a@example.com"}},
+		{name: "partial key", prefix: "pl", deleted: []string{"plain"}},
+		{name: "empty prefix", prefix: "", deleted: keys},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRU(t, 100, 0)
+			ctx := context.Background()
+			for _, key := range keys {
+				require.NoError(t, c.Set(ctx, key, []byte("v"), longTTL))
+			}
+
+			require.NoError(t, c.DeleteByPrefix(ctx, tt.prefix))
+
+			for _, key := range keys {
+				_, err := c.Get(ctx, key)
+				if slices.Contains(tt.deleted, key) {
+					assert.ErrorIs(t, err, entities.ErrNotFound, key)
+				} else {
+					assert.NoError(t, err, key)
+				}
+			}
+			assert.Equal(t, len(keys)-len(tt.deleted), c.Stats().Entries)
+		})
+	}
+}
+
+func TestLRU_DeleteByPrefix_NoMatch(t *testing.T) {
+	c := newLRU(t, 10, 0)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.DeleteByPrefix(ctx, "x"), entities.ErrNotFound)
+	require.NoError(t, c.Set(ctx, "session:u1:s1", []byte("v"), longTTL))
+	assert.ErrorIs(t, c.DeleteByPrefix(ctx, "session:u2"), entities.ErrNotFound)
+	assert.ErrorIs(t, c.DeleteByPrefix(ctx, "ession"), entities.ErrNotFound)
+}
+
+func TestLRU_KeysByPrefix(t *testing.T) {
+	c := newLRU(t, 10, 0)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "session:u1:s1", []byte("v"), longTTL))
+	require.NoError(t, c.Set(ctx, "session:u1:s2", []byte("v"), shortTTL))
+	require.NoError(t, c.Set(ctx, "session:u10:s1", []byte("v"), longTTL))
+	time.Sleep(shortSleep)
+
+	keys, err := c.KeysByPrefix(ctx, "session:u1:")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"session:u1:s1"}, keys)
+
+	keys, err = c.KeysByPrefix(ctx, "session:u2:")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestLRU_PrefixIndexPrunedOnEviction(t *testing.T) {
+	c := newLRU(t, 1, 0)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a:b:c", []byte("v"), longTTL))
+	require.NoError(t, c.Set(ctx, "d", []byte("v"), longTTL))
+
+	assert.ErrorIs(t, c.DeleteByPrefix(ctx, "a"), entities.ErrNotFound)
+	assert.NotContains(t, c.index.root.children, "a")
+}
+
+func TestLRU_CancelledContext(t *testing.T) {
+	c := newLRU(t, 10, 0)
+	ctx := cancelledCtx()
+
+	_, err := c.Get(ctx, "k")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, c.Set(ctx, "k", []byte("v"), longTTL), context.Canceled)
+	assert.ErrorIs(t, c.Delete(ctx, "k"), context.Canceled)
+	assert.ErrorIs(t, c.DeleteByPrefix(ctx, "k"), context.Canceled)
+}
@@ -9,6 +9,9 @@ import (
 	"github.com/Burmuley/ovoo/internal/entities"
 )
 
+// sweepInterval is the minimal interval between removals of all expired entries on Set
+const sweepInterval = time.Minute
+
 // memValue holds a cached byte slice together with its absolute expiry instant.
 type memValue struct {
 	value []byte
@@ -17,16 +20,17 @@ type memValue struct {
 
 // MemoryCache is an in-process, goroutine-safe cache.Cache implementation.
 // Entries are stored in a plain Go map and expire lazily on the next Get.
-// It carries no background goroutine, so unused entries are only evicted
-// when they are accessed after their TTL has elapsed.
+// It carries no background goroutine; entries which are never accessed again
+// are removed by a sweep of expired entries on Set, at most once per sweepInterval.
 type MemoryCache struct {
-	mu    sync.RWMutex
-	cache map[string]memValue
+	mu      sync.RWMutex
+	cache   map[string]memValue
+	sweptAt time.Time
 }
 
 // New allocates and returns an empty MemoryCache ready for use.
 func New() (*MemoryCache, error) {
-	return &MemoryCache{cache: make(map[string]memValue)}, nil
+	return &MemoryCache{cache: make(map[string]memValue), sweptAt: time.Now()}, nil
 }
 
 // Get returns the value stored under key.
@@ -56,22 +60,37 @@ func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
 
 // Set stores value under key, replacing any previous entry.
 // The entry expires at now+ttl; a zero or negative ttl makes it immediately expired.
+// Expired entries of other keys are removed if sweepInterval passed since the last sweep.
 // Returns ctx.Err() immediately if the context is already done.
 func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 
+	now := time.Now()
 	c.mu.Lock()
+	if now.Sub(c.sweptAt) >= sweepInterval {
+		c.sweep(now)
+	}
 	c.cache[key] = memValue{
 		value: value,
-		ttl:   time.Now().Add(ttl),
+		ttl:   now.Add(ttl),
 	}
 	c.mu.Unlock()
 
 	return nil
 }
 
+// sweep removes all expired entries, should be called with c.mu held for writing.
+func (c *MemoryCache) sweep(now time.Time) {
+	for key, item := range c.cache {
+		if now.After(item.ttl) {
+			delete(c.cache, key)
+		}
+	}
+	c.sweptAt = now
+}
+
 // Delete removes key from the cache.
 // Returns entities.ErrNotFound if the key does not exist (including already-expired entries
 // that have already been evicted). Returns ctx.Err() if the context is done.
@@ -117,3 +136,24 @@ func (c *MemoryCache) DeleteByPrefix(ctx context.Context, prefix string) error {
 
 	return nil
 }
+
+// KeysByPrefix returns the keys that start with prefix, expired entries are skipped.
+// An empty prefix matches every key. Returns ctx.Err() if the context is done.
+func (c *MemoryCache) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]string, 0)
+	for key, item := range c.cache {
+		if strings.HasPrefix(key, prefix) && !now.After(item.ttl) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
@@ -96,6 +96,32 @@ func TestGet_ExpiredEntry_IsEvicted(t *testing.T) {
 	assert.False(t, stillPresent, "expired entry should be removed from map after Get")
 }
 
+func TestSet_SweepsExpiredEntries(t *testing.T) {
+	c := newCache(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "expired", []byte("v"), shortTTL))
+	require.NoError(t, c.Set(ctx, "live", []byte("v"), time.Minute))
+	time.Sleep(shortSleep)
+
+	// no sweep until sweepInterval passed
+	require.NoError(t, c.Set(ctx, "other", []byte("v"), time.Minute))
+	c.mu.RLock()
+	assert.Len(t, c.cache, 3)
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	c.sweptAt = time.Now().Add(-sweepInterval)
+	c.mu.Unlock()
+	require.NoError(t, c.Set(ctx, "other", []byte("v"), time.Minute))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, stillPresent := c.cache["expired"]
+	assert.False(t, stillPresent, "expired entry should be removed by the sweep")
+	assert.Len(t, c.cache, 2)
+}
+
 func TestGet_ZeroTTL_ImmediatelyExpired(t *testing.T) {
 	c := newCache(t)
 	ctx := context.Background()
@@ -354,6 +380,28 @@ func TestDeleteByPrefix_IncludesExpiredEntries(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// ---------------------------------------------------------------------------
+// KeysByPrefix
+// ---------------------------------------------------------------------------
+
+func TestKeysByPrefix_SkipsExpiredEntries(t *testing.T) {
+	c := newCache(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "user:1", []byte("a"), longTTL))
+	require.NoError(t, c.Set(ctx, "user:2", []byte("b"), shortTTL))
+	require.NoError(t, c.Set(ctx, "token:1", []byte("c"), longTTL))
+	time.Sleep(shortSleep)
+
+	keys, err := c.KeysByPrefix(ctx, "user:")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user:1"}, keys)
+
+	keys, err = c.KeysByPrefix(ctx, "session:")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
 // ---------------------------------------------------------------------------
 // Context cancellation
 // ---------------------------------------------------------------------------
@@ -0,0 +1,108 @@
+package memory
+
+import "strings"
+
+// keySeparator splits cache keys into the segments of the prefix index, cache keys
+// are namespaced with colons, e.g. "session:<user id>:<session id>"
+const keySeparator = ":"
+
+// prefixIndex is a trie of key segments used to find keys by prefix without scanning
+// all keys. DeleteByPrefix visits only the subtrees matching the prefix: full segments
+// of the prefix are looked up directly, the trailing partial segment is matched against
+// the children of the last node.
+type prefixIndex struct {
+	root *prefixNode
+}
+
+type prefixNode struct {
+	children map[string]*prefixNode
+	leaf     bool // a key ends at this node
+}
+
+func newPrefixIndex() *prefixIndex {
+	return &prefixIndex{root: &prefixNode{}}
+}
+
+// add inserts the key into the index.
+func (idx *prefixIndex) add(key string) {
+	node := idx.root
+	for _, seg := range strings.Split(key, keySeparator) {
+		if node.children == nil {
+			node.children = make(map[string]*prefixNode)
+		}
+
+		child, ok := node.children[seg]
+		if !ok {
+			child = &prefixNode{}
+			node.children[seg] = child
+		}
+		node = child
+	}
+	node.leaf = true
+}
+
+// remove deletes the key from the index and prunes the nodes left empty.
+func (idx *prefixIndex) remove(key string) {
+	segs := strings.Split(key, keySeparator)
+	path := make([]*prefixNode, 0, len(segs)+1)
+	node := idx.root
+	path = append(path, node)
+	for _, seg := range segs {
+		child, ok := node.children[seg]
+		if !ok {
+			return
+		}
+		node = child
+		path = append(path, node)
+	}
+	node.leaf = false
+
+	for i := len(segs) - 1; i >= 0; i-- {
+		child := path[i+1]
+		if child.leaf || len(child.children) > 0 {
+			break
+		}
+		delete(path[i].children, segs[i])
+	}
+}
+
+// withPrefix returns all keys starting with the prefix.
+func (idx *prefixIndex) withPrefix(prefix string) []string {
+	segs := strings.Split(prefix, keySeparator)
+	partial := segs[len(segs)-1]
+	node := idx.root
+	for _, seg := range segs[:len(segs)-1] {
+		child, ok := node.children[seg]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+
+	base := strings.Join(segs[:len(segs)-1], keySeparator)
+	if len(segs) > 1 {
+		base += keySeparator
+	}
+
+	keys := make([]string, 0)
+	for seg, child := range node.children {
+		if strings.HasPrefix(seg, partial) {
+			keys = child.collect(base+seg, keys)
+		}
+	}
+
+	return keys
+}
+
+// collect appends the keys of the subtree, key is the key of the node.
+func (n *prefixNode) collect(key string, keys []string) []string {
+	if n.leaf {
+		keys = append(keys, key)
+	}
+
+	for seg, child := range n.children {
+		keys = child.collect(key+keySeparator+seg, keys)
+	}
+
+	return keys
+}
@@ -96,6 +96,27 @@ func (c *RedisCache) DeleteByPrefix(ctx context.Context, prefix string) error {
 	return nil
 }
 
+// KeysByPrefix returns the keys whose name starts with prefix using SCAN,
+// the keys are not locked and can expire or change meanwhile.
+func (c *RedisCache) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	iter := c.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, wrapRedisErr(err)
+	}
+
+	return keys, nil
+}
+
+// Ping checks that Redis is reachable, it is used by the readiness probe of the application.
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return wrapRedisErr(c.client.Ping(ctx).Err())
+}
+
 // wrapRedisErr maps Redis-specific errors to domain errors:
 //   - nil → nil
 //   - context.Canceled / context.DeadlineExceeded → returned as-is
@@ -297,6 +297,27 @@ func TestDeleteByPrefix_EmptyPrefix_WipesCache(t *testing.T) {
 	assert.ErrorIs(t, err2, entities.ErrNotFound)
 }
 
+// ---------------------------------------------------------------------------
+// KeysByPrefix
+// ---------------------------------------------------------------------------
+
+func TestKeysByPrefix_MatchingKeys(t *testing.T) {
+	c, _ := newTestCache(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "user:1", []byte("a"), longTTL))
+	require.NoError(t, c.Set(ctx, "user:2", []byte("b"), longTTL))
+	require.NoError(t, c.Set(ctx, "token:1", []byte("c"), longTTL))
+
+	keys, err := c.KeysByPrefix(ctx, "user:")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys)
+
+	keys, err = c.KeysByPrefix(ctx, "session:")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
 // ---------------------------------------------------------------------------
 // Context cancellation
 // ---------------------------------------------------------------------------
@@ -336,3 +357,11 @@ func TestSet_CancelledContext_DoesNotWrite(t *testing.T) {
 	_, err := c.Get(ctx, "k")
 	assert.ErrorIs(t, err, entities.ErrNotFound)
 }
+
+func TestPing(t *testing.T) {
+	c, mr := newTestCache(t)
+	assert.NoError(t, c.Ping(context.Background()))
+
+	mr.Close()
+	assert.ErrorIs(t, c.Ping(context.Background()), entities.ErrDatabase)
+}
@@ -0,0 +1,269 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultInvalidationChannel is the pub/sub channel of the invalidation messages when none is configured
+	DefaultInvalidationChannel = "ovoo:cache:invalidate"
+	// DefaultLocalTTL bounds how long an entry is served from the local tier when none is configured,
+	// so a lost invalidation message leaves stale entries only for a short time
+	DefaultLocalTTL = 30 * time.Second
+	// resubscribeDelay is the pause between attempts to receive messages while Redis is unavailable
+	resubscribeDelay = time.Second
+)
+
+// invalidation operations
+const (
+	invalidateKey    = "key"
+	invalidatePrefix = "prefix"
+)
+
+// localCache is the in-process tier of a TieredCache, e.g. a memory.MemoryCache or memory.LRUCache.
+type localCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	DeleteByPrefix(ctx context.Context, prefix string) error
+}
+
+// invalidation is the message published to the other nodes when keys change.
+type invalidation struct {
+	Node string `json:"node"`
+	Op   string `json:"op"`
+	Key  string `json:"key"`
+}
+
+// TieredCache is a cache.Cache implementation keeping a local in-process copy of the entries
+// in front of Redis. Reads are served from the local tier and fall back to Redis; writes and
+// deletions go to both tiers and are published over Redis pub/sub, so every node drops its local
+// copy of the changed keys.
+//
+// Pub/sub delivers messages at most once: the local tier is flushed whenever the subscription
+// is (re)established, and local entries expire after the local TTL at the latest.
+type TieredCache struct {
+	remote   *RedisCache
+	local    localCache
+	localTTL time.Duration
+	channel  string
+	node     string
+	pubsub   *redis.PubSub
+	// gen is incremented on every invalidation, a value read from Redis is only copied into the
+	// local tier when no invalidation happened meanwhile
+	gen        atomic.Uint64
+	subscribed chan struct{}
+	subOnce    sync.Once
+	closed     chan struct{}
+	done       chan struct{}
+}
+
+// NewTiered creates a TieredCache with the Redis configuration of cfg in front of which the
+// local cache is placed, and starts listening for invalidation messages of the other nodes.
+// Like New, it does not wait for Redis to be reachable.
+func NewTiered(cfg config.ConfigCache, local localCache) (*TieredCache, error) {
+	if cfg.Config.Redis == nil {
+		return nil, fmt.Errorf("%w: redis configuration is required for the tiered cache", entities.ErrConfiguration)
+	}
+
+	if local == nil {
+		return nil, fmt.Errorf("%w: local cache can not be empty", entities.ErrConfiguration)
+	}
+
+	remote, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := DefaultInvalidationChannel
+	localTTL := DefaultLocalTTL
+	if tieredCfg := cfg.Config.Tiered; tieredCfg != nil {
+		if tieredCfg.Channel != "" {
+			channel = tieredCfg.Channel
+		}
+
+		if tieredCfg.LocalTTL < 0 {
+			return nil, fmt.Errorf("%w: local_ttl can not be negative", entities.ErrConfiguration)
+		}
+
+		if tieredCfg.LocalTTL > 0 {
+			localTTL = time.Duration(tieredCfg.LocalTTL) * time.Second
+		}
+	}
+
+	node := make([]byte, 16)
+	if _, err := rand.Read(node); err != nil {
+		return nil, fmt.Errorf("generating cache node id: %w", err)
+	}
+
+	c := &TieredCache{
+		remote:     remote,
+		local:      local,
+		localTTL:   localTTL,
+		channel:    channel,
+		node:       hex.EncodeToString(node),
+		pubsub:     remote.client.Subscribe(context.Background(), channel),
+		subscribed: make(chan struct{}),
+		closed:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go c.listen()
+
+	return c, nil
+}
+
+// Get returns the value from the local tier, or from Redis copying it to the local tier
+// for the rest of its TTL, but not longer than the local TTL.
+// It returns entities.ErrNotFound when the key does not exist or has expired.
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if value, err := c.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	gen := c.gen.Load()
+	pipe := c.remote.client.Pipeline()
+	getCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, wrapRedisErr(err)
+	}
+
+	value := []byte(getCmd.Val())
+	ttl := min(ttlCmd.Val(), c.localTTL)
+	if ttlCmd.Val() < 0 { // no expiration
+		ttl = c.localTTL
+	}
+
+	if ttl > 0 && c.gen.Load() == gen {
+		_ = c.local.Set(ctx, key, value, ttl)
+	}
+
+	return value, nil
+}
+
+// Set stores value under key in Redis and the local tier, and invalidates the key on the other nodes.
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.gen.Add(1)
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		_ = c.local.Delete(ctx, key)
+		return err
+	}
+
+	if err := c.local.Set(ctx, key, value, min(ttl, c.localTTL)); err != nil {
+		return err
+	}
+
+	return c.publish(ctx, invalidateKey, key)
+}
+
+// Delete removes key from Redis and the local tier of every node.
+// Like RedisCache.Delete it returns nil when the key does not exist.
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	c.gen.Add(1)
+	_ = c.local.Delete(ctx, key)
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	return c.publish(ctx, invalidateKey, key)
+}
+
+// DeleteByPrefix removes every key starting with prefix from Redis and the local tier of every node.
+// Like RedisCache.DeleteByPrefix it returns nil when no keys match.
+func (c *TieredCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	c.gen.Add(1)
+	_ = c.local.DeleteByPrefix(ctx, prefix)
+	if err := c.remote.DeleteByPrefix(ctx, prefix); err != nil {
+		return err
+	}
+
+	return c.publish(ctx, invalidatePrefix, prefix)
+}
+
+// KeysByPrefix returns the keys starting with prefix from Redis, the local tier only holds
+// a part of them.
+func (c *TieredCache) KeysByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	return c.remote.KeysByPrefix(ctx, prefix)
+}
+
+// Ping checks that Redis is reachable, the local tier alone does not keep the nodes consistent.
+func (c *TieredCache) Ping(ctx context.Context) error {
+	return c.remote.Ping(ctx)
+}
+
+// Close stops listening for invalidation messages and closes the Redis connections.
+func (c *TieredCache) Close() error {
+	close(c.closed)
+	err := c.pubsub.Close()
+	<-c.done
+	return errors.Join(err, c.remote.client.Close())
+}
+
+// publish sends the invalidation to the other nodes.
+func (c *TieredCache) publish(ctx context.Context, op, key string) error {
+	msg, err := json.Marshal(invalidation{Node: c.node, Op: op, Key: key})
+	if err != nil {
+		return err
+	}
+
+	return wrapRedisErr(c.remote.client.Publish(ctx, c.channel, msg).Err())
+}
+
+// listen applies the invalidation messages of the other nodes to the local tier until the cache is closed.
+func (c *TieredCache) listen() {
+	defer close(c.done)
+	ctx := context.Background()
+	for {
+		msg, err := c.pubsub.Receive(ctx)
+		if err != nil {
+			select {
+			case <-c.closed:
+				return
+			case <-time.After(resubscribeDelay):
+				continue
+			}
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// messages could have been missed while not subscribed
+			c.gen.Add(1)
+			_ = c.local.DeleteByPrefix(ctx, "")
+			c.subOnce.Do(func() { close(c.subscribed) })
+		case *redis.Message:
+			c.apply(ctx, msg.Payload)
+		}
+	}
+}
+
+// apply drops the keys of the invalidation message from the local tier.
+func (c *TieredCache) apply(ctx context.Context, payload string) {
+	inv := invalidation{}
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil || inv.Node == c.node {
+		return
+	}
+
+	c.gen.Add(1)
+	switch inv.Op {
+	case invalidateKey:
+		_ = c.local.Delete(ctx, inv.Key)
+	case invalidatePrefix:
+		_ = c.local.DeleteByPrefix(ctx, inv.Key)
+	}
+}
@@ -0,0 +1,199 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNode creates a TieredCache connected to mr, waiting until it listens for invalidations.
+func newTestNode(t *testing.T, mr *miniredis.Miniredis) (*TieredCache, *memory.MemoryCache) {
+	t.Helper()
+	addr := mr.Addr()
+	cfg := config.ConfigCache{
+		Config: config.ConfigCacheDriver{
+			Redis: &config.ConfigCacheDriverRedis{Addr: &addr},
+		},
+	}
+
+	local, err := memory.New()
+	require.NoError(t, err)
+	c, err := NewTiered(cfg, local)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	select {
+	case <-c.subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("tiered cache did not subscribe to invalidations")
+	}
+
+	return c, local
+}
+
+// assertLocalEvicted waits until the key is dropped from the local tier.
+func assertLocalEvicted(t *testing.T, local *memory.MemoryCache, key string) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		_, err := local.Get(context.Background(), key)
+		return err != nil
+	}, time.Second, 5*time.Millisecond, "key %q was not evicted from the local tier", key)
+}
+
+func TestNewTiered_InvalidConfig(t *testing.T) {
+	local, err := memory.New()
+	require.NoError(t, err)
+
+	_, err = NewTiered(config.ConfigCache{}, local)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	addr := "127.0.0.1:6379"
+	_, err = NewTiered(config.ConfigCache{Config: config.ConfigCacheDriver{
+		Redis:  &config.ConfigCacheDriverRedis{Addr: &addr},
+		Tiered: &config.ConfigCacheDriverTiered{LocalTTL: -1},
+	}}, local)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestTiered_GetFillsLocalTier(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, local := newTestNode(t, mr)
+	ctx := context.Background()
+
+	require.NoError(t, mr.Set("k", "v"))
+
+	got, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v"), got)
+
+	got, err = local.Get(ctx, "k")
+	require.NoError(t, err, "value read from redis must be copied to the local tier")
+	assert.Equal(t, []byte("v"), got)
+
+	mr.Del("k")
+	got, err = c.Get(ctx, "k")
+	require.NoError(t, err, "local tier must serve the value without redis")
+	assert.Equal(t, []byte("v"), got)
+}
+
+func TestTiered_GetMissingKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, _ := newTestNode(t, mr)
+
+	_, err := c.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func TestTiered_SetInvalidatesOtherNodes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a, _ := newTestNode(t, mr)
+	b, bLocal := newTestNode(t, mr)
+	ctx := context.Background()
+
+	require.NoError(t, mr.Set("k", "old"))
+	_, err := b.Get(ctx, "k")
+	require.NoError(t, err)
+
+	require.NoError(t, a.Set(ctx, "k", []byte("new"), longTTL))
+	assertLocalEvicted(t, bLocal, "k")
+
+	got, err := b.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), got)
+}
+
+func TestTiered_DeleteInvalidatesOtherNodes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a, _ := newTestNode(t, mr)
+	b, bLocal := newTestNode(t, mr)
+	ctx := context.Background()
+
+	require.NoError(t, mr.Set("k", "v"))
+	_, err := b.Get(ctx, "k")
+	require.NoError(t, err)
+
+	require.NoError(t, a.Delete(ctx, "k"))
+	assertLocalEvicted(t, bLocal, "k")
+
+	_, err = b.Get(ctx, "k")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	assert.NoError(t, a.Delete(ctx, "k"), "deleting a missing key must not fail")
+}
+
+func TestTiered_DeleteByPrefixInvalidatesOtherNodes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a, _ := newTestNode(t, mr)
+	b, bLocal := newTestNode(t, mr)
+	ctx := context.Background()
+
+	for _, key := range []string{"addr:list:1", "addr:list:2", "addr:id:1"} {
+		require.NoError(t, mr.Set(key, "v"))
+		_, err := b.Get(ctx, key)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, a.DeleteByPrefix(ctx, "addr:list:"))
+	assertLocalEvicted(t, bLocal, "addr:list:1")
+	assertLocalEvicted(t, bLocal, "addr:list:2")
+
+	_, err := bLocal.Get(ctx, "addr:id:1")
+	assert.NoError(t, err, "keys outside the prefix must stay in the local tier")
+	assert.False(t, mr.Exists("addr:list:1"))
+	assert.True(t, mr.Exists("addr:id:1"))
+}
+
+func TestTiered_LocalTTLBoundedByRedisTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, local := newTestNode(t, mr)
+	ctx := context.Background()
+
+	require.NoError(t, mr.Set("k", "v"))
+	mr.SetTTL("k", 50*time.Millisecond)
+
+	_, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = local.Get(ctx, "k")
+	assert.ErrorIs(t, err, entities.ErrNotFound, "local copy must not outlive the redis TTL")
+}
+
+func TestTiered_ResubscribeFlushesLocalTier(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, local := newTestNode(t, mr)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), longTTL))
+
+	// invalidations published while disconnected are lost
+	mr.Close()
+	require.NoError(t, mr.Restart())
+	require.NoError(t, mr.Set("k", "changed"))
+
+	assert.Eventually(t, func() bool {
+		_, err := local.Get(ctx, "k")
+		return err != nil
+	}, 3*time.Second, 10*time.Millisecond, "local tier must be flushed after resubscribing")
+	assert.Eventually(t, func() bool {
+		got, err := c.Get(ctx, "k")
+		return err == nil && string(got) == "changed"
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestTiered_CancelledContext(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, _ := newTestNode(t, mr)
+	ctx := cancelledCtx()
+
+	_, err := c.Get(ctx, "k")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, c.Set(ctx, "k", []byte("v"), longTTL), context.Canceled)
+	assert.ErrorIs(t, c.Delete(ctx, "k"), context.Canceled)
+}
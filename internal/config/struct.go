@@ -3,15 +3,26 @@ package config
 // Ovoo API configuration
 
 type APIConfig struct {
-	Cache        *ConfigCache          `koanf:"cache"`
-	Database     ConfigDB              `koanf:"database"`
-	DefaultAdmin *ConfigDefaultAdmin   `koanf:"default_admin"`
-	ListenAddr   string                `koanf:"listen_addr"`
-	Log          ConfigLogging         `koanf:"logging"`
-	OIDC         map[string]ConfigOIDC `koanf:"oidc"`
-	TLS          ConfigTLS             `koanf:"tls"`
-	SysInfo      SystemInfo            `koanf:"sysinfo"`
-	Version      SystemVersion
+	Cache             *ConfigCache          `koanf:"cache"`
+	Database          ConfigDB              `koanf:"database"`
+	DefaultAdmin      *ConfigDefaultAdmin   `koanf:"default_admin"`
+	ListenAddr        string                `koanf:"listen_addr"`
+	Log               ConfigLogging         `koanf:"logging"`
+	MFA               ConfigMFA             `koanf:"mfa"`
+	MetricsListenAddr string                `koanf:"metrics_listen_addr"` // Prometheus metrics listener, disabled when empty
+	Notifications     ConfigNotifications   `koanf:"notifications"`       // email notifications of users, sent when smtp is set
+	Passwords         ConfigPasswords       `koanf:"passwords"`           // password policy and resets of users logging in with a password
+	Quarantine        ConfigQuarantine      `koanf:"quarantine"`          // mail held for the aliases, available when smtp is set
+	Reputation        ConfigReputation      `koanf:"reputation"`          // global reputation of the senders writing to the trap addresses
+	ShutdownDelay     int                   `koanf:"shutdown_delay"`      // seconds of serving after /readyz fails on shutdown, 5 when not set, none when negative
+	OIDC              map[string]ConfigOIDC `koanf:"oidc"`
+	Sessions          ConfigSessions        `koanf:"sessions"`
+	SMTP              *ConfigSMTP           `koanf:"smtp"` // outgoing mail of the API, e.g. confirmations of protected addresses
+	TLS               ConfigTLS             `koanf:"tls"`
+	Tracing           *ConfigTracing        `koanf:"tracing"` // OpenTelemetry tracing, disabled when not set
+	SysInfo           SystemInfo            `koanf:"sysinfo"`
+	Verification      ConfigVerification    `koanf:"verification"` // ownership verification of protected addresses
+	Version           SystemVersion
 }
 
 type SystemInfo struct {
@@ -48,6 +59,91 @@ type ConfigOIDC struct {
 	Issuer         string            `koanf:"issuer"`
 	ExtraScopes    []string          `koanf:"extra_scopes"`     // extra scopes to include in request
 	ExtraURLParams map[string]string `koanf:"extra_url_params"` // extra parameters to include in authorization URL
+	// Provisioning creates users on their first login, only existing users can log in when not set
+	Provisioning *ConfigOIDCProvisioning `koanf:"provisioning"`
+	// PostLogoutRedirectURL is where the provider returns the browser after logout, the root page by default
+	PostLogoutRedirectURL string `koanf:"post_logout_redirect_url"`
+	// AccessTokenValidation selects how access tokens are validated: "userinfo" (default) calls
+	// the UserInfo endpoint, "jwt" verifies JWT access tokens locally against the provider JWKS
+	AccessTokenValidation string   `koanf:"access_token_validation"`
+	Audiences             []string `koanf:"audiences"`    // accepted "aud" values of JWT access tokens, the client id by default
+	EmailClaims           []string `koanf:"email_claims"` // JWT access token claims holding the user email in order of preference, "email" by default
+}
+
+type ConfigOIDCProvisioning struct {
+	AllowedDomains []string `koanf:"allowed_domains"` // email domains allowed to sign up, any domain when empty
+	RoleClaim      string   `koanf:"role_claim"`      // claim with groups or roles of the user, "groups" by default
+	AdminValues    []string `koanf:"admin_values"`    // role claim values mapping to the admin user type
+	SyncUserType   bool     `koanf:"sync_user_type"`  // update the user type from the role claim on each login
+}
+
+type ConfigMFA struct {
+	Issuer        string          `koanf:"issuer"`         // issuer shown in authenticator apps
+	EnforceAdmins bool            `koanf:"enforce_admins"` // admin users must enroll a second factor
+	WebAuthn      *ConfigWebAuthn `koanf:"webauthn"`
+}
+
+type ConfigWebAuthn struct {
+	RPID          string   `koanf:"rp_id"`
+	RPDisplayName string   `koanf:"rp_display_name"`
+	RPOrigins     []string `koanf:"rp_origins"`
+}
+
+type ConfigSessions struct {
+	IdleTimeout     int `koanf:"idle_timeout"`     // session expires when not used for this period, in seconds
+	AbsoluteTimeout int `koanf:"absolute_timeout"` // session expires after this period since the login, in seconds
+}
+
+type ConfigSMTP struct {
+	Address  string `koanf:"address"`  // host:port of the SMTP server, e.g. smtp.example.com:587
+	From     string `koanf:"from"`     // sender address of the messages
+	Username string `koanf:"username"` // authentication is disabled when empty
+	Password string `koanf:"password"`
+	TLS      string `koanf:"tls"`     // "starttls" (default), "tls" for implicit TLS or "none"
+	Timeout  int    `koanf:"timeout"` // seconds, 10 when not set
+}
+
+type ConfigNotifications struct {
+	TemplatesDir       string `koanf:"templates_dir"`        // overrides the built-in templates with the files of the directory
+	Interval           int    `koanf:"interval"`             // seconds between the deliveries of the queued notifications, 30 when not set
+	CheckInterval      int    `koanf:"check_interval"`       // seconds between the checks of expiring tokens and keys and verified domains, 3600 when not set
+	BatchSize          int    `koanf:"batch_size"`           // notifications sent per delivery at most, 50 when not set
+	MaxAttempts        int    `koanf:"max_attempts"`         // delivery attempts of a notification before it is given up, 10 when not set
+	Retention          int    `koanf:"retention"`            // seconds sent and failed notifications are kept, 604800 when not set
+	TokenExpiryWarning int    `koanf:"token_expiry_warning"` // seconds before the expiration of an API token its owner is warned, 604800 when not set, disabled when negative
+	KeyExpiryWarning   int    `koanf:"key_expiry_warning"`   // seconds before the expiration of the pgp key of a protected address its owner is warned, 1209600 when not set, disabled when negative
+}
+
+type ConfigPasswords struct {
+	MinLength    int    `koanf:"min_length"`    // characters of a new password at least, 10 when not set
+	BreachedList string `koanf:"breached_list"` // file of passwords rejected as breached, one per line in plain text or as SHA-1 hex digests
+	ResetTTL     int    `koanf:"reset_ttl"`     // seconds a password reset link is valid, 3600 when not set
+}
+
+type ConfigQuarantine struct {
+	MaxSize   int `koanf:"max_size"`  // bytes of a held message at most, larger messages are rejected, 10485760 when not set
+	Retention int `koanf:"retention"` // seconds a held message is kept unless released or deleted, 1209600 when not set
+}
+
+type ConfigReputation struct {
+	HalfLife  int     `koanf:"half_life"` // seconds the score of a sender takes to decay to its half, 1209600 when not set
+	Threshold float64 `koanf:"threshold"` // score listing a sender, every message to a trap address adds 1, 0.5 when not set listing the sender of a single message for a half life
+	Action    string  `koanf:"action"`    // "reject" (default) or "quarantine" the mail of the listed senders
+}
+
+type ConfigVerification struct {
+	Disabled bool   `koanf:"disabled"` // new protected addresses are confirmed right away, without a confirmation link
+	BaseURL  string `koanf:"base_url"` // public URL of the API in the confirmation links, e.g. https://ovoo.example.com
+	Secret   string `koanf:"secret"`   // key signing the confirmation tokens, random on every start when not set
+	TTL      int    `koanf:"ttl"`      // seconds a confirmation link is valid, 86400 when not set
+}
+
+type ConfigTracing struct {
+	Endpoint    string            `koanf:"endpoint"`     // OTLP/HTTP endpoint of the collector, e.g. http://127.0.0.1:4318
+	Headers     map[string]string `koanf:"headers"`      // added to the export requests, e.g. for authentication
+	SampleRatio *float64          `koanf:"sample_ratio"` // share of the traces started by the application to record, 1 when not set
+	ServiceName string            `koanf:"service_name"` // overrides the service name of the application, e.g. ovoo-api
+	Timeout     int               `koanf:"timeout"`      // seconds, 10 when not set
 }
 
 type ConfigCache struct {
@@ -58,7 +154,19 @@ type ConfigCache struct {
 }
 
 type ConfigCacheDriver struct {
-	Redis *ConfigCacheDriverRedis `koanf:"redis"`
+	Memory *ConfigCacheDriverMemory `koanf:"memory"`
+	Redis  *ConfigCacheDriverRedis  `koanf:"redis"`
+	Tiered *ConfigCacheDriverTiered `koanf:"tiered"`
+}
+
+type ConfigCacheDriverMemory struct {
+	MaxEntries int   `koanf:"max_entries"` // LRU eviction above the number of entries, unbounded when 0
+	MaxBytes   int64 `koanf:"max_bytes"`   // LRU eviction above the size of keys and values, unbounded when 0
+}
+
+type ConfigCacheDriverTiered struct {
+	Channel  string `koanf:"channel"`   // pub/sub channel of the invalidation messages
+	LocalTTL int    `koanf:"local_ttl"` // seconds an entry is kept in memory of the node at most
 }
 
 type ConfigCacheDriverRedis struct {
@@ -87,31 +195,59 @@ type ConfigDBDriverGORM struct {
 // Ovoo Milter configuration
 
 type MilterConfig struct {
-	Api             ConfigMilterAPIConn `koanf:"api"`
-	ListenAddr      string              `koanf:"listen_addr"`
-	Log             ConfigLogging       `koanf:"log"`
-	MailDisplayName string              `koanf:"mail_display_name"`
+	Api               ConfigMilterAPIConn `koanf:"api"`
+	Cache             *ConfigCache        `koanf:"cache"` // cache of the API responses, in memory of the process when not set
+	ListenAddr        string              `koanf:"listen_addr"`
+	Log               ConfigLogging       `koanf:"log"`
+	MailDisplayName   string              `koanf:"mail_display_name"`
+	MetricsListenAddr string              `koanf:"metrics_listen_addr"` // Prometheus metrics listener, disabled when empty
+	HealthListenAddr  string              `koanf:"health_listen_addr"`  // /healthz and /readyz listener, disabled when empty
+	ShutdownDelay     int                 `koanf:"shutdown_delay"`      // seconds of serving after /readyz fails on shutdown, 5 when not set, none when negative
+	Tracing           *ConfigTracing      `koanf:"tracing"`             // OpenTelemetry tracing, disabled when not set
+	TrackerDomains    []string            `koanf:"tracker_domains"`     // domains stripped by the privacy filter in addition to the built-in ones
+	Webhook           ConfigMilterWebhook `koanf:"webhook"`
+}
+
+// ConfigMilterWebhook configures the delivery of the mail of the aliases to their webhooks
+type ConfigMilterWebhook struct {
+	Timeout              int  `koanf:"timeout"`                // seconds a webhook request may take, 10 when not set
+	AllowPrivateNetworks bool `koanf:"allow_private_networks"` // allows webhooks on loopback, private and link-local addresses
 }
 
 type ConfigMilterAPIConn struct {
-	Addr          string `koanf:"addr"`
-	AuthToken     string `koanf:"auth_token"`
-	TLSSkipVerify bool   `koanf:"tls_skip_verify"`
-	Timeout       int    `koanf:"client_timeout"`
+	Addr             string `koanf:"addr"`
+	AuthToken        string `koanf:"auth_token"`
+	AuthTokenFile    string `koanf:"auth_token_file"` // reloaded on change, takes precedence over auth_token
+	TLSSkipVerify    bool   `koanf:"tls_skip_verify"`
+	Timeout          int    `koanf:"client_timeout"`
+	Retries          int    `koanf:"retries"`           // retries of the idempotent requests while the API is unavailable, 2 when not set, none when negative
+	BreakerThreshold int    `koanf:"breaker_threshold"` // consecutive failed requests opening the circuit, 5 when not set, disabled when negative
+	BreakerCooldown  int    `koanf:"breaker_cooldown"`  // seconds requests fail fast once the circuit is open, 30 when not set
+	StaleTTL         int    `koanf:"stale_ttl"`         // seconds the last domains list is served while the API is unavailable, 86400 when not set, disabled when negative
 }
 
 // Ovoo Socketmap server configuration
 
 type SocketMapConfig struct {
-	Api        ConfigSocketMapAPIConn `koanf:"api"`
-	Log        ConfigLogging          `koanf:"log"`
-	ListenAddr string                 `koanf:"listen_addr"`
-	Network    string                 `koanf:"network"`
+	Api               ConfigSocketMapAPIConn `koanf:"api"`
+	Cache             *ConfigCache           `koanf:"cache"` // cache of the API responses, in memory of the process when not set
+	Log               ConfigLogging          `koanf:"log"`
+	ListenAddr        string                 `koanf:"listen_addr"`
+	Network           string                 `koanf:"network"`
+	MetricsListenAddr string                 `koanf:"metrics_listen_addr"` // Prometheus metrics listener, disabled when empty
+	HealthListenAddr  string                 `koanf:"health_listen_addr"`  // /healthz and /readyz listener, disabled when empty
+	ShutdownDelay     int                    `koanf:"shutdown_delay"`      // seconds of serving after /readyz fails on shutdown, 5 when not set, none when negative
+	Tracing           *ConfigTracing         `koanf:"tracing"`             // OpenTelemetry tracing, disabled when not set
 }
 
 type ConfigSocketMapAPIConn struct {
-	Addr          string `koanf:"addr"`
-	AuthToken     string `koanf:"auth_token"`
-	TLSSkipVerify bool   `koanf:"tls_skip_verify"`
-	Timeout       int    `koanf:"client_timeout"`
+	Addr             string `koanf:"addr"`
+	AuthToken        string `koanf:"auth_token"`
+	AuthTokenFile    string `koanf:"auth_token_file"` // reloaded on change, takes precedence over auth_token
+	TLSSkipVerify    bool   `koanf:"tls_skip_verify"`
+	Timeout          int    `koanf:"client_timeout"`
+	Retries          int    `koanf:"retries"`           // retries of the idempotent requests while the API is unavailable, 2 when not set, none when negative
+	BreakerThreshold int    `koanf:"breaker_threshold"` // consecutive failed requests opening the circuit, 5 when not set, disabled when negative
+	BreakerCooldown  int    `koanf:"breaker_cooldown"`  // seconds requests fail fast once the circuit is open, 30 when not set
+	StaleTTL         int    `koanf:"stale_ttl"`         // seconds the last domains list is served while the API is unavailable, 86400 when not set, disabled when negative
 }
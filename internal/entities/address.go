@@ -12,8 +12,36 @@ const (
 	ReplyAliasAddress
 	ProtectedAddress
 	ExternalAddress
+	// WebhookAddress is the target of an alias delivering its mail to an HTTP endpoint, it has no email
+	WebhookAddress
+	// TrapAddress is an address of the Ovoo domains which is never given out, the senders writing to it
+	// are added to the sender reputation
+	TrapAddress
 )
 
+// QuarantineMode selects the mail received by an alias which is held in the quarantine instead of being forwarded.
+type QuarantineMode string
+
+const (
+	// QuarantineOff forwards all mail of the alias
+	QuarantineOff QuarantineMode = ""
+	// QuarantineNewSenders holds the mail of senders writing to the alias for the first time until
+	// their first message is released
+	QuarantineNewSenders QuarantineMode = "new_senders"
+	// QuarantineAll holds all mail of the alias
+	QuarantineAll QuarantineMode = "all"
+)
+
+// Validate checks if the quarantine mode is known.
+func (m QuarantineMode) Validate() error {
+	switch m {
+	case QuarantineOff, QuarantineNewSenders, QuarantineAll:
+		return nil
+	}
+
+	return fmt.Errorf("unknown quarantine mode %q", m)
+}
+
 type AddressBulkUpdateFields struct {
 	MetadataComment     *string
 	MetadataServiceName *string
@@ -42,6 +70,28 @@ type Address struct {
 	UpdatedAt      time.Time
 	UpdatedBy      User
 	Active         bool
+	// Pending is set on protected addresses until the owner of the mailbox confirms them,
+	// no mail is forwarded to pending addresses
+	Pending bool
+	// PGPKey is set on protected addresses receiving the forwarded mail encrypted
+	PGPKey *PGPKey
+	// PrivacyFilter overrides the owner default for stripping trackers from the mail received by an alias,
+	// nil inherits the owner setting
+	PrivacyFilter *bool
+	// Quarantine selects the mail of an alias held in the quarantine
+	Quarantine QuarantineMode
+	// Rules filter the mail forwarded to an alias or a protected address, the rules of an alias take precedence
+	// over the rules of its protected address
+	Rules *Rules
+	// Webhook is the endpoint of a webhook address
+	Webhook *Webhook
+	// SenderDomains are the registered domains of the senders known to an alias: the domain of its first
+	// sender and the domains accepted by the owner after a leak
+	SenderDomains []string
+	// Leak is set on aliases which received mail from a sender unrelated to the service they were given to
+	Leak *AliasLeak
+	// LeakRestrict makes an alias refuse the mail of unknown senders once it is leaked
+	LeakRestrict bool
 }
 
 // Validate checks if the Address object is valid according to the defined rules.
@@ -62,12 +112,73 @@ func (a *Address) Validate() error {
 		return fmt.Errorf("external address can not have forward email set")
 	}
 
-	// Emails should be valid email
-	if err := a.Email.Validate(); err != nil {
-		return fmt.Errorf("validating address email: %w", err)
+	// webhook address can not have ForwardEmail set
+	if a.Type == WebhookAddress && a.ForwardAddress != nil {
+		return fmt.Errorf("webhook address can not have forward email set")
+	}
+
+	// trap address receives no mail
+	if a.Type == TrapAddress && a.ForwardAddress != nil {
+		return fmt.Errorf("trap address can not have forward email set")
+	}
+
+	if (a.Webhook != nil) != (a.Type == WebhookAddress) {
+		return fmt.Errorf("only webhook addresses should have a webhook set")
+	}
+
+	if a.Webhook != nil {
+		if err := ValidateWebhookURL(a.Webhook.URL); err != nil {
+			return err
+		}
+	}
+
+	if a.PGPKey != nil && a.Type != ProtectedAddress {
+		return fmt.Errorf("only protected addresses can have a pgp key")
+	}
+
+	if a.PrivacyFilter != nil && a.Type != AliasAddress {
+		return fmt.Errorf("only aliases can have the privacy filter set")
+	}
+
+	if err := a.Quarantine.Validate(); err != nil {
+		return err
 	}
 
-	if a.Type != ProtectedAddress && a.Type != ExternalAddress {
+	if a.Quarantine != QuarantineOff && a.Type != AliasAddress {
+		return fmt.Errorf("only aliases can have the quarantine set")
+	}
+
+	// held mail is released to a mailbox
+	if a.Quarantine != QuarantineOff && a.ForwardAddress != nil && a.ForwardAddress.Type == WebhookAddress {
+		return fmt.Errorf("aliases delivering to a webhook can not have the quarantine set")
+	}
+
+	if a.Rules != nil && a.Type != AliasAddress && a.Type != ProtectedAddress {
+		return fmt.Errorf("only aliases and protected addresses can have rules")
+	}
+
+	if (len(a.SenderDomains) != 0 || a.Leak != nil || a.LeakRestrict) && a.Type != AliasAddress {
+		return fmt.Errorf("only aliases can have sender domains and leaks")
+	}
+
+	if err := validateSenderDomains(a.SenderDomains); err != nil {
+		return err
+	}
+
+	if a.Leak != nil {
+		if err := a.Leak.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Emails should be valid email, webhook addresses have none
+	if a.Type != WebhookAddress {
+		if err := a.Email.Validate(); err != nil {
+			return fmt.Errorf("validating address email: %w", err)
+		}
+	}
+
+	if a.Type != ProtectedAddress && a.Type != ExternalAddress && a.Type != WebhookAddress && a.Type != TrapAddress {
 		if err := a.ForwardAddress.Validate(); err != nil {
 			return fmt.Errorf("validating address forward email: %w", err)
 		}
@@ -80,3 +191,13 @@ func (a *Address) Validate() error {
 
 	return nil
 }
+
+// PrivacyFilterEnabled reports whether trackers are stripped from the mail received by the address,
+// the alias setting takes precedence over the owner default.
+func (a Address) PrivacyFilterEnabled() bool {
+	if a.PrivacyFilter != nil {
+		return *a.PrivacyFilter
+	}
+
+	return a.Owner.PrivacyFilter
+}
@@ -163,3 +163,165 @@ func TestAddress_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestAddress_PrivacyFilterEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		alias  *bool
+		owner  bool
+		wanted bool
+	}{
+		{name: "inherits disabled", alias: nil, owner: false, wanted: false},
+		{name: "inherits enabled", alias: nil, owner: true, wanted: true},
+		{name: "alias enables", alias: new(true), owner: false, wanted: true},
+		{name: "alias disables", alias: new(false), owner: true, wanted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Address{PrivacyFilter: tt.alias, Owner: User{PrivacyFilter: tt.owner}}
+			if got := a.PrivacyFilterEnabled(); got != tt.wanted {
+				t.Errorf("Address.PrivacyFilterEnabled() = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestAddress_Validate_Quarantine(t *testing.T) {
+	tests := []struct {
+		name     string
+		addrType AddressType
+		mode     QuarantineMode
+		wantErr  bool
+	}{
+		{name: "alias holding new senders", addrType: AliasAddress, mode: QuarantineNewSenders},
+		{name: "alias holding all mail", addrType: AliasAddress, mode: QuarantineAll},
+		{name: "unknown mode", addrType: AliasAddress, mode: "some", wantErr: true},
+		{name: "protected address", addrType: ProtectedAddress, mode: QuarantineAll, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Address{
+				ID:         NewId(),
+				Type:       tt.addrType,
+				Email:      "alias@ovoo.com",
+				Owner:      User{ID: NewId()},
+				Quarantine: tt.mode,
+			}
+			if tt.addrType == AliasAddress {
+				a.ForwardAddress = &Address{ID: NewId(), Email: "user@gmail.com", Type: ProtectedAddress, Owner: a.Owner}
+			}
+			if err := a.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Address.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddress_Validate_Rules(t *testing.T) {
+	tests := []struct {
+		name     string
+		addrType AddressType
+		wantErr  bool
+	}{
+		{name: "alias", addrType: AliasAddress},
+		{name: "protected address", addrType: ProtectedAddress},
+		{name: "reply alias", addrType: ReplyAliasAddress, wantErr: true},
+		{name: "external address", addrType: ExternalAddress, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Address{
+				ID:    NewId(),
+				Type:  tt.addrType,
+				Email: "alias@ovoo.com",
+				Owner: User{ID: NewId()},
+				Rules: &Rules{Script: "discard;"},
+			}
+			if tt.addrType == AliasAddress || tt.addrType == ReplyAliasAddress {
+				a.ForwardAddress = &Address{ID: NewId(), Email: "user@gmail.com", Type: ProtectedAddress, Owner: a.Owner}
+			}
+			if err := a.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Address.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddress_Validate_Webhook(t *testing.T) {
+	owner := User{ID: NewId()}
+	webhook := Address{ID: NewId(), Type: WebhookAddress, Owner: owner, Webhook: &Webhook{URL: "https://hooks.example.com/mail", Secret: "secret"}}
+
+	tests := []struct {
+		name    string
+		addr    Address
+		wantErr bool
+	}{
+		{name: "webhook address", addr: webhook},
+		{
+			name: "alias delivering to webhook",
+			addr: Address{ID: NewId(), Type: AliasAddress, Email: "alias@ovoo.com", Owner: owner, ForwardAddress: &webhook},
+		},
+		{
+			name:    "webhook address without webhook",
+			addr:    Address{ID: NewId(), Type: WebhookAddress, Owner: owner},
+			wantErr: true,
+		},
+		{
+			name:    "webhook on protected address",
+			addr:    Address{ID: NewId(), Type: ProtectedAddress, Email: "user@gmail.com", Owner: owner, Webhook: webhook.Webhook},
+			wantErr: true,
+		},
+		{
+			name:    "invalid webhook url",
+			addr:    Address{ID: NewId(), Type: WebhookAddress, Owner: owner, Webhook: &Webhook{URL: "ftp://example.com"}},
+			wantErr: true,
+		},
+		{
+			name: "alias delivering to webhook with quarantine",
+			addr: Address{
+				ID: NewId(), Type: AliasAddress, Email: "alias@ovoo.com", Owner: owner,
+				ForwardAddress: &webhook,
+				Quarantine:     QuarantineAll,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.addr.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Address.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddress_Validate_Trap(t *testing.T) {
+	owner := User{ID: NewId()}
+	praddr := &Address{ID: NewId(), Email: "user@gmail.com", Type: ProtectedAddress, Owner: owner}
+
+	tests := []struct {
+		name    string
+		addr    Address
+		wantErr bool
+	}{
+		{name: "trap address", addr: Address{ID: NewId(), Type: TrapAddress, Email: "trap@ovoo.com", Owner: owner}},
+		{name: "trap address without email", addr: Address{ID: NewId(), Type: TrapAddress, Owner: owner}, wantErr: true},
+		{
+			name:    "trap address forwarding mail",
+			addr:    Address{ID: NewId(), Type: TrapAddress, Email: "trap@ovoo.com", Owner: owner, ForwardAddress: praddr},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.addr.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Address.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
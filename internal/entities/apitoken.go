@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
@@ -30,6 +31,61 @@ type ApiToken struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	UpdatedBy   User
+	Scopes      []Permission // empty list grants full rights of the owner
+	Domains     []string     // empty list allows all domains available to the owner
+}
+
+// ApiTokenScope restricts the rights a scoped API token grants to its owner.
+type ApiTokenScope struct {
+	Permissions []Permission
+	Domains     []string
+}
+
+// Allows checks if the scope covers the given permission.
+// A scope entry covers the permission with the same name and all its
+// broader variants, e.g. "aliases:read" covers "aliases:read:all".
+// Empty permissions list covers all permissions.
+func (s ApiTokenScope) Allows(p Permission) bool {
+	if len(s.Permissions) == 0 {
+		return true
+	}
+
+	for _, sp := range s.Permissions {
+		if p == sp || strings.HasPrefix(string(p), string(sp)+":") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsDomain checks if the scope covers the given domain name.
+// Empty domains list covers all domains.
+func (s ApiTokenScope) AllowsDomain(domain string) bool {
+	if len(s.Domains) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.Domains, strings.ToLower(domain))
+}
+
+// AllowsEmail checks if the domain part of the given email is covered by the scope.
+func (s ApiTokenScope) AllowsEmail(email Email) bool {
+	if len(s.Domains) == 0 {
+		return true
+	}
+
+	_, domain, ok := strings.Cut(email.String(), "@")
+	return ok && s.AllowsDomain(domain)
+}
+
+// Scope returns the restrictions of the token or nil if the token is not scoped.
+func (t *ApiToken) Scope() *ApiTokenScope {
+	if len(t.Scopes) == 0 && len(t.Domains) == 0 {
+		return nil
+	}
+
+	return &ApiTokenScope{Permissions: t.Scopes, Domains: t.Domains}
 }
 
 // NewToken creates a new ApiToken with the given expiration, description, and owner.
@@ -77,6 +133,18 @@ func (t *ApiToken) Validate() error {
 		return fmt.Errorf("token value can not be empty")
 	}
 
+	for _, scope := range t.Scopes {
+		if err := scope.Validate(); err != nil {
+			return fmt.Errorf("validating token scopes: %w", err)
+		}
+	}
+
+	for _, domain := range t.Domains {
+		if !fqdnRe.MatchString(domain) {
+			return fmt.Errorf("validating token domains: '%s' must be FQDN", domain)
+		}
+	}
+
 	return nil
 }
 
@@ -205,3 +205,78 @@ func TestApiToken_Expired(t *testing.T) {
 		})
 	}
 }
+
+func TestApiToken_Scope(t *testing.T) {
+	owner := User{ID: NewId(), Login: "test_owner", Type: RegularUser}
+	token, _ := NewToken(time.Now().Add(time.Hour), "test token", "", owner)
+	if token.Scope() != nil {
+		t.Errorf("ApiToken.Scope() = %v, want nil for unrestricted token", token.Scope())
+	}
+
+	token.Scopes = []Permission{PermAliasesRead}
+	token.Domains = []string{"example.com"}
+	scope := token.Scope()
+	if scope == nil {
+		t.Fatal("ApiToken.Scope() = nil, want scope")
+	}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{name: "same permission", got: scope.Allows(PermAliasesRead), want: true},
+		{name: "broader variant", got: scope.Allows(PermAliasesReadAll), want: true},
+		{name: "other permission", got: scope.Allows(PermAliasesWrite), want: false},
+		{name: "similar prefix", got: (ApiTokenScope{Permissions: []Permission{"aliases:read"}}).Allows("aliases:readonly"), want: false},
+		{name: "empty scope", got: (ApiTokenScope{}).Allows(PermUsersWrite), want: true},
+		{name: "allowed domain", got: scope.AllowsDomain("Example.COM"), want: true},
+		{name: "other domain", got: scope.AllowsDomain("example.org"), want: false},
+		{name: "allowed email", got: scope.AllowsEmail("alias@example.com"), want: true},
+		{name: "subdomain email", got: scope.AllowsEmail("alias@sub.example.com"), want: false},
+		{name: "invalid email", got: scope.AllowsEmail("example.com"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	owner.Scope = scope
+	if owner.HasPermission(PermAliasesWrite) {
+		t.Error("User.HasPermission() = true for permission outside of the token scope")
+	}
+	if !owner.HasPermission(PermAliasesRead) {
+		t.Error("User.HasPermission() = false for permission within the token scope")
+	}
+	if got := owner.Permissions(); len(got) != 1 || got[0] != PermAliasesRead {
+		t.Errorf("User.Permissions() = %v, want [%s]", got, PermAliasesRead)
+	}
+}
+
+func TestApiToken_ValidateScopes(t *testing.T) {
+	owner := User{ID: NewId(), Login: "test_owner", Type: RegularUser}
+	tests := []struct {
+		name    string
+		scopes  []Permission
+		domains []string
+		wantErr bool
+	}{
+		{name: "unrestricted", wantErr: false},
+		{name: "valid scope", scopes: []Permission{PermAliasesRead, PermChainsCreate}, domains: []string{"example.com"}, wantErr: false},
+		{name: "unknown permission", scopes: []Permission{"aliases:delete"}, wantErr: true},
+		{name: "invalid domain", domains: []string{"not a domain"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _ := NewToken(time.Now().Add(time.Hour), "test token", "", owner)
+			token.Scopes = tt.scopes
+			token.Domains = tt.domains
+			if err := token.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ApiToken.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
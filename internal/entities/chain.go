@@ -12,9 +12,18 @@ type Chain struct {
 	ToAddress       Address
 	OrigFromAddress Address
 	OrigToAddress   Address
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	UpdatedBy       User
+	// Quarantined is set on the forward chain of a sender writing to an alias holding the mail of new senders,
+	// it is cleared once a message of the chain is released from the quarantine
+	Quarantined bool
+	// Listed is set on the forward chain of a sender listed by the sender reputation when its mail is held,
+	// it is resolved with the chain and not stored
+	Listed bool
+	// Redirects are the protected addresses of the owner the rules of the chain can redirect the mail to,
+	// they are resolved when the chain is created and not stored
+	Redirects []Address
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UpdatedBy User
 }
 
 // Validate checks the integrity of the Chain structure.
@@ -41,3 +50,37 @@ func (c Chain) Validate() error {
 
 	return nil
 }
+
+// Held reports whether the mail of the chain is held in the quarantine instead of being forwarded.
+func (c Chain) Held() bool {
+	if c.OrigToAddress.Type != AliasAddress {
+		return false
+	}
+
+	if c.Listed {
+		return true
+	}
+
+	switch c.OrigToAddress.Quarantine {
+	case QuarantineAll:
+		return true
+	case QuarantineNewSenders:
+		return c.Quarantined
+	}
+
+	return false
+}
+
+// Rules returns the rules filtering the mail of the chain, the rules of the alias take precedence over the rules
+// of the protected address it forwards to. Only the chains forwarding the mail of an alias have rules.
+func (c Chain) Rules() *Rules {
+	if c.OrigToAddress.Type != AliasAddress {
+		return nil
+	}
+
+	if c.OrigToAddress.Rules != nil {
+		return c.OrigToAddress.Rules
+	}
+
+	return c.ToAddress.Rules
+}
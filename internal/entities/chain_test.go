@@ -154,3 +154,70 @@ func TestChain_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestChain_Held(t *testing.T) {
+	tests := []struct {
+		name        string
+		addrType    AddressType
+		mode        QuarantineMode
+		quarantined bool
+		listed      bool
+		wanted      bool
+	}{
+		{name: "quarantine off", addrType: AliasAddress, mode: QuarantineOff, quarantined: true, wanted: false},
+		{name: "all mail held", addrType: AliasAddress, mode: QuarantineAll, wanted: true},
+		{name: "new sender held", addrType: AliasAddress, mode: QuarantineNewSenders, quarantined: true, wanted: true},
+		{name: "released sender", addrType: AliasAddress, mode: QuarantineNewSenders, quarantined: false, wanted: false},
+		{name: "reply chain", addrType: ReplyAliasAddress, mode: QuarantineAll, quarantined: true, wanted: false},
+		{name: "listed sender", addrType: AliasAddress, mode: QuarantineOff, listed: true, wanted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Chain{OrigToAddress: Address{Type: tt.addrType, Quarantine: tt.mode}, Quarantined: tt.quarantined, Listed: tt.listed}
+			if got := c.Held(); got != tt.wanted {
+				t.Errorf("Chain.Held() = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestChain_Rules(t *testing.T) {
+	aliasRules := &Rules{Script: "discard;"}
+	praddrRules := &Rules{Script: "stop;"}
+
+	tests := []struct {
+		name   string
+		chain  Chain
+		wanted *Rules
+	}{
+		{
+			name:   "alias rules",
+			chain:  Chain{OrigToAddress: Address{Type: AliasAddress, Rules: aliasRules}, ToAddress: Address{Rules: praddrRules}},
+			wanted: aliasRules,
+		},
+		{
+			name:   "protected address rules",
+			chain:  Chain{OrigToAddress: Address{Type: AliasAddress}, ToAddress: Address{Rules: praddrRules}},
+			wanted: praddrRules,
+		},
+		{
+			name:   "no rules",
+			chain:  Chain{OrigToAddress: Address{Type: AliasAddress}},
+			wanted: nil,
+		},
+		{
+			name:   "reply chain",
+			chain:  Chain{OrigToAddress: Address{Type: ReplyAliasAddress}, ToAddress: Address{Rules: praddrRules}},
+			wanted: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.chain.Rules(); got != tt.wanted {
+				t.Errorf("Chain.Rules() = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package entities
 import (
 	"fmt"
 	"strconv"
+	"time"
 )
 
 const (
@@ -69,6 +70,11 @@ type AddressFilter struct {
 	ForwardAddressIds []Id
 	Active            *bool
 	Search            string
+	Domains           []string
+	// KeyExpiresBefore selects the addresses with a pgp key expiring before the time
+	KeyExpiresBefore *time.Time
+	// Leaked selects the aliases flagged as possibly leaked, or the ones which are not
+	Leaked *bool
 }
 
 // NewAddressFilter parses and returns an AddressFilter from the given input map.
@@ -89,7 +95,7 @@ func NewAddressFilter(input map[string][]string) (AddressFilter, error) {
 			types := make([]AddressType, 0, len(vals))
 			for _, val := range vals {
 				atype, err := strconv.Atoi(val)
-				if err != nil || AddressType(atype) > ExternalAddress {
+				if err != nil || AddressType(atype) > TrapAddress {
 					return AddressFilter{}, fmt.Errorf("%w: unsupported address type '%s'", ErrValidation, val)
 				}
 				types = append(types, AddressType(atype))
@@ -117,6 +123,12 @@ func NewAddressFilter(input map[string][]string) (AddressFilter, error) {
 				return AddressFilter{}, fmt.Errorf("%w: value for 'active' field must be boolean", ErrValidation)
 			}
 			af.Active = &active
+		case "leaked":
+			leaked, err := strconv.ParseBool(vals[len(vals)-1]) // include last value only
+			if err != nil {
+				return AddressFilter{}, fmt.Errorf("%w: value for 'leaked' field must be boolean", ErrValidation)
+			}
+			af.Leaked = &leaked
 		case "q":
 			if len(vals) > 0 && vals[0] != "" {
 				af.Search = vals[0]
@@ -129,9 +141,10 @@ func NewAddressFilter(input map[string][]string) (AddressFilter, error) {
 
 type UserFilter struct {
 	Filter
-	Types  []UserType
-	Logins []string
-	Active *bool
+	Types   []UserType
+	Logins  []string
+	Active  *bool
+	RoleIds []Id
 }
 
 // NewUserFilter parses and returns a UserFilter from the given input map.
@@ -167,6 +180,12 @@ func NewUserFilter(input map[string][]string) (UserFilter, error) {
 				return UserFilter{}, fmt.Errorf("%w: value for 'active' field must be boolean", ErrValidation)
 			}
 			uf.Active = &active
+		case "role":
+			ids := make([]Id, 0, len(vals))
+			for _, val := range vals {
+				ids = append(ids, Id(val))
+			}
+			uf.RoleIds = ids
 		}
 	}
 
@@ -177,6 +196,8 @@ type ApiTokenFilter struct {
 	Filter
 	UserIds []Id
 	Active  *bool
+	// ExpiresBefore selects the tokens of all users expiring before the time when UserIds is empty
+	ExpiresBefore *time.Time
 }
 
 // NewApiTokensFilter constructs an ApiTokenFilter using the provided input map.
@@ -217,6 +238,28 @@ type ChainFilter struct {
 	ToAddrIds       []Id
 }
 
+type RoleFilter struct {
+	Filter
+	Names []string
+}
+
+// NewRoleFilter parses and returns a RoleFilter from the given input map.
+// Populates the Names field from the "name" filter key.
+func NewRoleFilter(input map[string][]string) (RoleFilter, error) {
+	rf := RoleFilter{}
+	filter, err := NewFilter(input)
+	if err != nil {
+		return RoleFilter{}, err
+	}
+
+	rf.Filter = filter
+	if vals, ok := input["name"]; ok {
+		rf.Names = vals
+	}
+
+	return rf, nil
+}
+
 type CustomDomainFilter struct {
 	Filter
 	Active        *bool
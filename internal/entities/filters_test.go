@@ -95,6 +95,19 @@ func TestNewAddressFilter(t *testing.T) {
 			input:   map[string][]string{"active": {"notabool"}},
 			wantErr: ErrValidation,
 		},
+		{
+			name:  "leaked true",
+			input: map[string][]string{"leaked": {"true"}},
+			want: AddressFilter{
+				Filter: Filter{Page: DefaulPageNumber, PageSize: DefaultPageSize},
+				Leaked: func() *bool { v := true; return &v }(),
+			},
+		},
+		{
+			name:    "invalid leaked value",
+			input:   map[string][]string{"leaked": {"notabool"}},
+			wantErr: ErrValidation,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,6 +146,13 @@ func TestNewAddressFilter(t *testing.T) {
 						t.Errorf("Active = %v, want %v", *got.Active, *tt.want.Active)
 					}
 				}
+				if tt.want.Leaked != nil {
+					if got.Leaked == nil {
+						t.Errorf("Leaked = nil, want %v", *tt.want.Leaked)
+					} else if *got.Leaked != *tt.want.Leaked {
+						t.Errorf("Leaked = %v, want %v", *got.Leaked, *tt.want.Leaked)
+					}
+				}
 			}
 		})
 	}
@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxSenderDomains limits the known sender domains of an alias
+const maxSenderDomains = 100
+
+// AliasLeak records the sender revealing that an alias was possibly leaked: its domain matches
+// neither the service name of the alias nor the domains of the senders known to it.
+type AliasLeak struct {
+	Sender     Email
+	DetectedAt time.Time
+}
+
+// Validate checks if the AliasLeak is valid.
+func (l AliasLeak) Validate() error {
+	if err := l.Sender.Validate(); err != nil {
+		return fmt.Errorf("validating leak sender: %w", err)
+	}
+
+	if l.DetectedAt.IsZero() {
+		return fmt.Errorf("leak detection time should be set")
+	}
+
+	return nil
+}
+
+// SenderDomain returns the registered domain of the email, e.g. shop.example for news@mail.shop.example,
+// so that the senders of an organization match whichever subdomain they send from. It returns the whole
+// domain when it has no registered part and an empty string for emails without a domain.
+func SenderDomain(email Email) string {
+	at := strings.LastIndex(email.String(), "@")
+	if at < 0 {
+		return ""
+	}
+
+	domain := strings.TrimSuffix(strings.ToLower(email.String()[at+1:]), ".")
+	if registered, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil {
+		return registered
+	}
+
+	return domain
+}
+
+// matchesServiceName reports whether the registered domain belongs to the service: the service name
+// is the domain itself or the name of the domain, e.g. "Shop" or "shop.example" for shop.example.
+func matchesServiceName(domain, serviceName string) bool {
+	serviceName = strings.ToLower(strings.TrimSpace(serviceName))
+	if serviceName == "" || domain == "" {
+		return false
+	}
+
+	if strings.Contains(serviceName, ".") {
+		return SenderDomain(Email("@"+serviceName)) == domain
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			return r
+		}
+		return -1
+	}, serviceName)
+	label, _, _ := strings.Cut(domain, ".")
+	return name == label
+}
+
+// KnownSender reports whether the domain of the sender matches the service name of the alias
+// or one of the sender domains known to it.
+func (a Address) KnownSender(sender Email) bool {
+	domain := SenderDomain(sender)
+	for _, known := range a.SenderDomains {
+		if known == domain {
+			return true
+		}
+	}
+
+	return matchesServiceName(domain, a.Metadata.ServiceName)
+}
+
+// AcceptsSender reports whether the alias receives the mail of the sender, a leaked alias restricted
+// to its known senders refuses the others.
+func (a Address) AcceptsSender(sender Email) bool {
+	return a.Leak == nil || !a.LeakRestrict || a.KnownSender(sender)
+}
+
+// validateSenderDomains checks the sender domains known to an alias
+func validateSenderDomains(domains []string) error {
+	if len(domains) > maxSenderDomains {
+		return fmt.Errorf("an alias can not have more than %d sender domains", maxSenderDomains)
+	}
+
+	for _, domain := range domains {
+		if domain == "" || strings.ContainsAny(domain, "@ \t\r\n") {
+			return fmt.Errorf("invalid sender domain %q", domain)
+		}
+	}
+
+	return nil
+}
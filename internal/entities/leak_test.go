@@ -0,0 +1,127 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSenderDomain(t *testing.T) {
+	tests := map[Email]string{
+		"news@shop.example.com":   "example.com",
+		"news@Mail.Shop.co.uk":    "shop.co.uk",
+		"news@shop.example.com.":  "example.com",
+		"news@localhost":          "localhost",
+		`"a@b"@mail.shop.com`:     "shop.com",
+		"no-domain":               "",
+		"bounce+123@em.amazon.de": "amazon.de",
+		"user@sub.github.io":      "sub.github.io",
+	}
+
+	for email, want := range tests {
+		if got := SenderDomain(email); got != want {
+			t.Errorf("SenderDomain(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestAddress_KnownSender(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceName   string
+		senderDomains []string
+		sender        Email
+		want          bool
+	}{
+		{name: "unknown sender", sender: "news@shop.com"},
+		{name: "known sender domain", senderDomains: []string{"shop.com"}, sender: "news@mail.shop.com", want: true},
+		{name: "other sender domain", senderDomains: []string{"shop.com"}, sender: "spam@spam.com"},
+		{name: "service name", serviceName: "Shop", sender: "news@em.shop.co.uk", want: true},
+		{name: "service name with spaces", serviceName: " my-shop ", sender: "news@my-shop.com", want: true},
+		{name: "service domain", serviceName: "www.shop.com", sender: "news@mail.shop.com", want: true},
+		{name: "other service", serviceName: "Shop", sender: "spam@shopping.com"},
+		{name: "service name of several words", serviceName: "Big Shop", sender: "news@big.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Address{Type: AliasAddress, Metadata: AddressMetadata{ServiceName: tt.serviceName}, SenderDomains: tt.senderDomains}
+			if got := a.KnownSender(tt.sender); got != tt.want {
+				t.Errorf("Address.KnownSender() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddress_AcceptsSender(t *testing.T) {
+	leak := &AliasLeak{Sender: "spam@spam.com", DetectedAt: time.Now()}
+	tests := []struct {
+		name     string
+		leak     *AliasLeak
+		restrict bool
+		sender   Email
+		want     bool
+	}{
+		{name: "not leaked", restrict: true, sender: "spam@spam.com", want: true},
+		{name: "leaked without restriction", leak: leak, sender: "spam@spam.com", want: true},
+		{name: "leaked and restricted", leak: leak, restrict: true, sender: "spam@spam.com"},
+		{name: "known sender of restricted alias", leak: leak, restrict: true, sender: "news@shop.com", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Address{Type: AliasAddress, SenderDomains: []string{"shop.com"}, Leak: tt.leak, LeakRestrict: tt.restrict}
+			if got := a.AcceptsSender(tt.sender); got != tt.want {
+				t.Errorf("Address.AcceptsSender() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddress_Validate_Leak(t *testing.T) {
+	owner := User{ID: NewId()}
+	praddr := &Address{ID: NewId(), Email: "user@gmail.com", Type: ProtectedAddress, Owner: owner}
+	tests := []struct {
+		name    string
+		addr    Address
+		wantErr bool
+	}{
+		{
+			name: "leaked alias",
+			addr: Address{
+				ID: NewId(), Type: AliasAddress, Email: "alias@ovoo.com", Owner: owner, ForwardAddress: praddr,
+				SenderDomains: []string{"shop.com"},
+				Leak:          &AliasLeak{Sender: "spam@spam.com", DetectedAt: time.Now()},
+				LeakRestrict:  true,
+			},
+		},
+		{
+			name:    "sender domains of protected address",
+			addr:    Address{ID: NewId(), Type: ProtectedAddress, Email: "user@gmail.com", Owner: owner, SenderDomains: []string{"shop.com"}},
+			wantErr: true,
+		},
+		{
+			name: "invalid sender domain",
+			addr: Address{
+				ID: NewId(), Type: AliasAddress, Email: "alias@ovoo.com", Owner: owner, ForwardAddress: praddr,
+				SenderDomains: []string{"news@shop.com"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "leak without detection time",
+			addr: Address{
+				ID: NewId(), Type: AliasAddress, Email: "alias@ovoo.com", Owner: owner, ForwardAddress: praddr,
+				Leak: &AliasLeak{Sender: "spam@spam.com"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.addr.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Address.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
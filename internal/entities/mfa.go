@@ -0,0 +1,227 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	MFAMethodTOTP         = "totp"
+	MFAMethodRecoveryCode = "recovery_code"
+	MFAMethodWebAuthn     = "webauthn"
+
+	// TOTP parameters as recommended by RFC 6238 and supported by all authenticator apps
+	totpPeriod     = 30
+	totpDigits     = 6
+	totpSkew       = 1 // number of periods accepted before and after the current one
+	totpSecretSize = 20
+
+	recoveryCodesCount = 10
+	recoveryCodeSize   = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// UserMFA holds the second factor settings of a user.
+type UserMFA struct {
+	TOTPSecret    string // base32 encoded, set on enrollment and kept pending until confirmed
+	TOTPEnabled   bool
+	TOTPLastStep  int64  // last accepted time step, prevents code reuse
+	RecoveryCodes []Hash // hashes of unused recovery codes
+	WebAuthn      []WebAuthnCredential
+}
+
+// WebAuthnCredential represents a WebAuthn public key credential registered by a user.
+type WebAuthnCredential struct {
+	ID              Id
+	Name            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Transports      []string
+	AAGUID          []byte
+	SignCount       uint32
+	BackupEligible  bool
+	BackupState     bool
+	CreatedAt       time.Time
+	LastUsedAt      time.Time
+}
+
+// Enabled reports whether at least one second factor is configured.
+func (m UserMFA) Enabled() bool {
+	return m.TOTPEnabled || len(m.WebAuthn) > 0
+}
+
+// Methods returns the list of second factor methods available to the user.
+func (m UserMFA) Methods() []string {
+	methods := make([]string, 0, 3)
+	if m.TOTPEnabled {
+		methods = append(methods, MFAMethodTOTP)
+	}
+
+	if len(m.WebAuthn) > 0 {
+		methods = append(methods, MFAMethodWebAuthn)
+	}
+
+	if m.Enabled() && len(m.RecoveryCodes) > 0 {
+		methods = append(methods, MFAMethodRecoveryCode)
+	}
+
+	return methods
+}
+
+// UseRecoveryCode checks the code against the unused recovery codes
+// and removes it from the list when it matches.
+func (m *UserMFA) UseRecoveryCode(code string) bool {
+	hash := recoveryCodeHash(code)
+	idx := slices.IndexFunc(m.RecoveryCodes, func(h Hash) bool {
+		return subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1
+	})
+
+	if idx < 0 {
+		return false
+	}
+
+	m.RecoveryCodes = slices.Delete(m.RecoveryCodes, idx, idx+1)
+	return true
+}
+
+// VerifyTOTP validates the code against the confirmed or pending TOTP secret
+// and records the accepted time step, so the same code can not be used twice.
+func (m *UserMFA) VerifyTOTP(code string, t time.Time) bool {
+	if m.TOTPSecret == "" {
+		return false
+	}
+
+	step, ok := ValidateTOTP(m.TOTPSecret, code, t)
+	if !ok || step <= m.TOTPLastStep {
+		return false
+	}
+
+	m.TOTPLastStep = step
+	return true
+}
+
+// MFAEnabled reports whether the user has a second factor configured.
+func (u User) MFAEnabled() bool {
+	return u.MFA.Enabled()
+}
+
+// NewTOTPSecret generates a new random base32 encoded TOTP secret.
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: generating totp secret: %w", ErrGeneral, err)
+	}
+
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPCode computes the RFC 6238 code (HMAC-SHA1, 30 seconds period, 6 digits)
+// for the base32 encoded secret at the given time.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpCode(key, t.Unix()/totpPeriod), nil
+}
+
+// ValidateTOTP checks the code for the base32 encoded secret at the given time,
+// allowing one period of clock skew in both directions.
+// Returns the time step the code matched.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := t.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI used by authenticator apps
+// to enroll the secret, usually rendered as a QR code.
+func TOTPProvisioningURI(secret, issuer, account string) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// NewRecoveryCodes generates a new set of one-time recovery codes.
+// Returns the clear text codes to show to the user and their hashes to store.
+func NewRecoveryCodes() ([]string, []Hash, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]Hash, recoveryCodesCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("%w: generating recovery codes: %w", ErrGeneral, err)
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(b))[:recoveryCodeSize]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = recoveryCodeHash(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// recoveryCodeHash normalizes the recovery code and returns its hash,
+// so codes entered in upper case or without the dash still match.
+func recoveryCodeHash(code string) Hash {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return SimpleHash(code)
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid totp secret: %w", ErrValidation, err)
+	}
+
+	return key, nil
+}
+
+func totpCode(key []byte, step int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors for the SHA1 secret, truncated to 6 digits
+func TestTOTPCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("NewTOTPSecret() error = %v", err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "current period", at: now, want: true},
+		{name: "previous period", at: now.Add(-totpPeriod * time.Second), want: true},
+		{name: "next period", at: now.Add(totpPeriod * time.Second), want: true},
+		{name: "too old", at: now.Add(-3 * totpPeriod * time.Second), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := TOTPCode(secret, tt.at)
+			if _, ok := ValidateTOTP(secret, code, now); ok != tt.want {
+				t.Errorf("ValidateTOTP() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := ValidateTOTP(secret, "abc", now); ok {
+		t.Errorf("ValidateTOTP() accepted malformed code")
+	}
+}
+
+func TestUserMFA_VerifyTOTP_Replay(t *testing.T) {
+	secret, _ := NewTOTPSecret()
+	mfa := UserMFA{TOTPSecret: secret, TOTPEnabled: true}
+	now := time.Now()
+	code, _ := TOTPCode(secret, now)
+
+	if !mfa.VerifyTOTP(code, now) {
+		t.Fatalf("VerifyTOTP() rejected valid code")
+	}
+
+	if mfa.VerifyTOTP(code, now) {
+		t.Errorf("VerifyTOTP() accepted the same code twice")
+	}
+}
+
+func TestUserMFA_UseRecoveryCode(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatalf("NewRecoveryCodes() error = %v", err)
+	}
+
+	if len(codes) != recoveryCodesCount || len(hashes) != recoveryCodesCount {
+		t.Fatalf("NewRecoveryCodes() returned %d codes and %d hashes", len(codes), len(hashes))
+	}
+
+	mfa := UserMFA{TOTPEnabled: true, RecoveryCodes: hashes}
+	if !mfa.UseRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[3], "-", ""))) {
+		t.Fatalf("UseRecoveryCode() rejected valid code")
+	}
+
+	if mfa.UseRecoveryCode(codes[3]) {
+		t.Errorf("UseRecoveryCode() accepted the same code twice")
+	}
+
+	if len(mfa.RecoveryCodes) != recoveryCodesCount-1 {
+		t.Errorf("UseRecoveryCode() left %d codes, want %d", len(mfa.RecoveryCodes), recoveryCodesCount-1)
+	}
+}
+
+func TestUserMFA_Methods(t *testing.T) {
+	tests := []struct {
+		name string
+		mfa  UserMFA
+		want []string
+	}{
+		{name: "disabled", mfa: UserMFA{TOTPSecret: "pending", RecoveryCodes: []Hash{"x"}}, want: []string{}},
+		{name: "totp", mfa: UserMFA{TOTPEnabled: true, RecoveryCodes: []Hash{"x"}}, want: []string{MFAMethodTOTP, MFAMethodRecoveryCode}},
+		{name: "webauthn", mfa: UserMFA{WebAuthn: []WebAuthnCredential{{}}}, want: []string{MFAMethodWebAuthn}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.mfa.Methods()
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Methods() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	got := TOTPProvisioningURI("JBSWY3DPEHPK3PXP", "Ovoo", "user@example.com")
+	want := "otpauth://totp/Ovoo:user@example.com?algorithm=SHA1&digits=6&issuer=Ovoo&period=30&secret=JBSWY3DPEHPK3PXP"
+	if got != want {
+		t.Errorf("TOTPProvisioningURI() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// NotificationEvent is the kind of an event users can be notified about by email.
+type NotificationEvent string
+
+const (
+	// NotificationNewSender is emitted when a sender writes to an alias for the first time
+	NotificationNewSender NotificationEvent = "new_sender"
+	// NotificationTokenExpiring is emitted once before an API token expires
+	NotificationTokenExpiring NotificationEvent = "token_expiring"
+	// NotificationDomainVerificationLost is emitted when a verified domain fails its DNS check
+	NotificationDomainVerificationLost NotificationEvent = "domain_verification_lost"
+	// NotificationKeyExpiring is emitted once before the pgp key of a protected address expires
+	NotificationKeyExpiring NotificationEvent = "pgp_key_expiring"
+	// NotificationAliasLeaked is emitted when an alias is flagged as possibly leaked
+	NotificationAliasLeaked NotificationEvent = "alias_leaked"
+)
+
+// NotificationEvents lists all known notification events.
+// There is no alias expiry event: aliases do not expire, they forward mail until
+// their owner deactivates or deletes them.
+var NotificationEvents = []NotificationEvent{
+	NotificationNewSender,
+	NotificationTokenExpiring,
+	NotificationDomainVerificationLost,
+	NotificationKeyExpiring,
+	NotificationAliasLeaked,
+}
+
+// Validate checks if the event is known.
+func (e NotificationEvent) Validate() error {
+	if !slices.Contains(NotificationEvents, e) {
+		return fmt.Errorf("unknown notification event %q", e)
+	}
+
+	return nil
+}
+
+type NotificationStatus string
+
+const (
+	NotificationQueued NotificationStatus = "queued"
+	NotificationSent   NotificationStatus = "sent"
+	NotificationFailed NotificationStatus = "failed" // delivery was given up after too many attempts
+)
+
+// Notification is an email about an event queued for delivery to a user.
+// The message is rendered from the templates of the event when it is sent.
+type Notification struct {
+	ID Id
+	// Key deduplicates notifications, e.g. a single expiry warning per token;
+	// a notification with the key of an existing one is not queued again
+	Key           string
+	UserID        Id
+	To            Email
+	Name          string
+	Event         NotificationEvent
+	Data          map[string]string
+	Status        NotificationStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        time.Time
+}
+
+// Validate checks if the Notification is valid.
+func (n Notification) Validate() error {
+	if err := n.ID.Validate(); err != nil {
+		return err
+	}
+
+	if err := n.UserID.Validate(); err != nil {
+		return err
+	}
+
+	if err := n.To.Validate(); err != nil {
+		return err
+	}
+
+	if len(n.Key) == 0 {
+		return fmt.Errorf("notification key can not be empty")
+	}
+
+	return n.Event.Validate()
+}
+
+// NotificationPrefs holds the notification settings of a user.
+// All events are notified unless disabled.
+type NotificationPrefs struct {
+	Disabled []NotificationEvent
+}
+
+// Enabled reports whether the user wants to be notified about the event.
+func (p NotificationPrefs) Enabled(e NotificationEvent) bool {
+	return !slices.Contains(p.Disabled, e)
+}
+
+// Set enables or disables the notifications about the event.
+func (p NotificationPrefs) Set(e NotificationEvent, enabled bool) NotificationPrefs {
+	disabled := slices.DeleteFunc(slices.Clone(p.Disabled), func(d NotificationEvent) bool { return d == e })
+	if !enabled {
+		disabled = append(disabled, e)
+	}
+
+	return NotificationPrefs{Disabled: disabled}
+}
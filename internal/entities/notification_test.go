@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNotificationEvent_Validate(t *testing.T) {
+	for _, e := range NotificationEvents {
+		if err := e.Validate(); err != nil {
+			t.Errorf("Validate(%q) error = %v", e, err)
+		}
+	}
+
+	if err := NotificationEvent("alias_deleted").Validate(); err == nil {
+		t.Error("Validate() expected an error for an unknown event")
+	}
+}
+
+func TestNotificationPrefs(t *testing.T) {
+	prefs := NotificationPrefs{}
+	for _, e := range NotificationEvents {
+		if !prefs.Enabled(e) {
+			t.Errorf("Enabled(%q) = false, events are enabled by default", e)
+		}
+	}
+
+	disabled := prefs.Set(NotificationNewSender, false).Set(NotificationNewSender, false)
+	if disabled.Enabled(NotificationNewSender) {
+		t.Error("Enabled() = true after disabling the event")
+	}
+
+	if !slices.Equal(disabled.Disabled, []NotificationEvent{NotificationNewSender}) {
+		t.Errorf("Set() disabled = %v, want a single entry", disabled.Disabled)
+	}
+
+	if prefs.Disabled != nil {
+		t.Error("Set() modified the original preferences")
+	}
+
+	if !disabled.Set(NotificationNewSender, true).Enabled(NotificationNewSender) {
+		t.Error("Enabled() = false after enabling the event again")
+	}
+}
+
+func TestNotification_Validate(t *testing.T) {
+	valid := Notification{
+		ID:     NewId(),
+		Key:    "new_sender:abc",
+		UserID: NewId(),
+		To:     "user@example.com",
+		Event:  NotificationNewSender,
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := map[string]func(n *Notification){
+		"no key":        func(n *Notification) { n.Key = "" },
+		"invalid email": func(n *Notification) { n.To = "milter" },
+		"unknown event": func(n *Notification) { n.Event = "unknown" },
+		"no user":       func(n *Notification) { n.UserID = "" },
+	}
+
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := valid
+			modify(&n)
+			if err := n.Validate(); err == nil {
+				t.Error("Validate() expected an error")
+			}
+		})
+	}
+}
@@ -1,6 +1,20 @@
 package entities
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultMinPasswordLength is the shortest password accepted when the policy does not set one
+	DefaultMinPasswordLength = 10
+	// maxPasswordBytes is the longest password bcrypt can hash
+	maxPasswordBytes = 72
+)
 
 func NewPasswordHash(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -15,3 +29,50 @@ func ValidPassword(password, hashedPasword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPasword), []byte(password))
 	return err == nil
 }
+
+// IsPasswordHash reports whether s already is a password hash, e.g. the password of the default admin
+// set in the configuration as a bcrypt hash.
+func IsPasswordHash(s string) bool {
+	_, err := bcrypt.Cost([]byte(s))
+	return err == nil
+}
+
+// PasswordPolicy defines the requirements new passwords of users should meet.
+// The zero value requires DefaultMinPasswordLength characters and has no breached passwords.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters of a password
+	MinLength int
+	// Breached contains the SHA-1 digests of the passwords known from data breaches
+	Breached map[[sha1.Size]byte]struct{}
+}
+
+// Check returns an error describing the requirement of the policy the password does not meet.
+func (p PasswordPolicy) Check(password string) error {
+	minLength := p.MinLength
+	if minLength <= 0 {
+		minLength = DefaultMinPasswordLength
+	}
+
+	if utf8.RuneCountInString(password) < minLength {
+		return fmt.Errorf("password should be at least %d characters long", minLength)
+	}
+
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("password should not be longer than %d bytes", maxPasswordBytes)
+	}
+
+	if _, ok := p.Breached[sha1.Sum([]byte(password))]; ok {
+		return errors.New("password appeared in a data breach, choose another one")
+	}
+
+	return nil
+}
+
+// Hash checks the password against the policy and returns its hash.
+func (p PasswordPolicy) Hash(password string) (string, error) {
+	if err := p.Check(password); err != nil {
+		return "", err
+	}
+
+	return NewPasswordHash(password)
+}
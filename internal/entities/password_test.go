@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"crypto/sha1"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength: 12,
+		Breached:  map[[sha1.Size]byte]struct{}{sha1.Sum([]byte("password1234")): {}},
+	}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		wantErr  bool
+	}{
+		{name: "valid", policy: policy, password: "correct horse battery"},
+		{name: "too short", policy: policy, password: "short pass", wantErr: true},
+		{name: "length in characters", policy: policy, password: "пароль-пароль"},
+		{name: "too long for bcrypt", policy: policy, password: strings.Repeat("a", 73), wantErr: true},
+		{name: "breached", policy: policy, password: "password1234", wantErr: true},
+		{name: "default min length", policy: PasswordPolicy{}, password: "123456789", wantErr: true},
+		{name: "default policy", policy: PasswordPolicy{}, password: "1234567890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Check(tt.password); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Hash(t *testing.T) {
+	hash, err := PasswordPolicy{}.Hash("correct horse battery")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if !ValidPassword("correct horse battery", hash) || !IsPasswordHash(hash) {
+		t.Errorf("Hash() = %q, not a hash of the password", hash)
+	}
+
+	if _, err := (PasswordPolicy{}).Hash("short"); err == nil {
+		t.Error("Hash() of a password not meeting the policy should fail")
+	}
+
+	if IsPasswordHash("correct horse battery") {
+		t.Error("IsPasswordHash() of a plain text password should be false")
+	}
+}
@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// maxPGPKeySize limits the size of an uploaded armored key
+const maxPGPKeySize = 64 << 10
+
+// PGPKey is the OpenPGP public key of a protected address, mail forwarded to the address is encrypted with it.
+type PGPKey struct {
+	// Armored is the ASCII armored public key as uploaded
+	Armored     string
+	Fingerprint string
+	// ExpiresAt is the time the key can not encrypt anymore, zero when it does not expire
+	ExpiresAt time.Time
+}
+
+// ParsePGPKey parses and validates an ASCII armored OpenPGP public key.
+// The armor should contain a single public key, which is not revoked or expired and can encrypt.
+func ParsePGPKey(armored string) (PGPKey, error) {
+	if len(armored) > maxPGPKeySize {
+		return PGPKey{}, fmt.Errorf("pgp key can not be longer than %d bytes", maxPGPKeySize)
+	}
+
+	armored = strings.TrimSpace(armored)
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return PGPKey{}, fmt.Errorf("parsing pgp key: %w", err)
+	}
+
+	if len(keys) != 1 {
+		return PGPKey{}, fmt.Errorf("pgp key armor should contain a single key, found %d", len(keys))
+	}
+
+	entity := keys[0]
+	if entity.PrivateKey != nil {
+		return PGPKey{}, fmt.Errorf("pgp key is a private key, only the public key should be uploaded")
+	}
+
+	now := time.Now()
+	if entity.Revoked(now) {
+		return PGPKey{}, fmt.Errorf("pgp key is revoked")
+	}
+
+	key, ok := entity.EncryptionKey(now)
+	if !ok {
+		return PGPKey{}, fmt.Errorf("pgp key is expired or has no key usable for encryption")
+	}
+
+	expires := keyExpiry(key.PublicKey, key.SelfSignature)
+	if sig, _ := entity.PrimarySelfSignature(); sig != nil {
+		if primary := keyExpiry(entity.PrimaryKey, sig); !primary.IsZero() && (expires.IsZero() || primary.Before(expires)) {
+			expires = primary
+		}
+	}
+
+	return PGPKey{
+		Armored:     armored,
+		Fingerprint: strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)),
+		ExpiresAt:   expires,
+	}, nil
+}
+
+// Expired reports whether the key can not encrypt anymore.
+func (k PGPKey) Expired() bool {
+	return !k.ExpiresAt.IsZero() && time.Now().After(k.ExpiresAt)
+}
+
+// keyExpiry returns the expiration time of the key set by its self-signature, zero when it does not expire
+func keyExpiry(key *packet.PublicKey, sig *packet.Signature) time.Time {
+	if sig == nil || sig.KeyLifetimeSecs == nil || *sig.KeyLifetimeSecs == 0 {
+		return time.Time{}
+	}
+
+	return key.CreationTime.Add(time.Duration(*sig.KeyLifetimeSecs) * time.Second)
+}
@@ -0,0 +1,122 @@
+package entities
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// testArmoredKey generates a key with the configuration and returns it armored, with the private key when private is set
+func testArmoredKey(t *testing.T, cfg *packet.Config, private bool) (string, *openpgp.Entity) {
+	t.Helper()
+	if cfg == nil {
+		cfg = &packet.Config{}
+	}
+	cfg.Algorithm = packet.PubKeyAlgoEdDSA
+
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", cfg)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	blockType := openpgp.PublicKeyType
+	if private {
+		blockType = openpgp.PrivateKeyType
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, blockType, nil)
+	if err != nil {
+		t.Fatalf("armoring key: %v", err)
+	}
+
+	if private {
+		err = entity.SerializePrivate(w, nil)
+	} else {
+		err = entity.Serialize(w)
+	}
+	if err != nil {
+		t.Fatalf("serializing key: %v", err)
+	}
+	w.Close()
+
+	return buf.String(), entity
+}
+
+func TestParsePGPKey(t *testing.T) {
+	armored, entity := testArmoredKey(t, nil, false)
+	key, err := ParsePGPKey("\n" + armored + "\n")
+	if err != nil {
+		t.Fatalf("ParsePGPKey() error = %v", err)
+	}
+
+	if key.Armored != strings.TrimSpace(armored) {
+		t.Errorf("ParsePGPKey() armored key was not kept")
+	}
+
+	if len(key.Fingerprint) != 40 || key.Fingerprint != strings.ToUpper(key.Fingerprint) {
+		t.Errorf("ParsePGPKey() fingerprint = %q", key.Fingerprint)
+	}
+
+	if !key.ExpiresAt.IsZero() || key.Expired() {
+		t.Errorf("ParsePGPKey() expires at = %v, key without lifetime should not expire", key.ExpiresAt)
+	}
+
+	if len(entity.Subkeys) == 0 {
+		t.Fatal("generated key has no encryption subkey")
+	}
+}
+
+func TestParsePGPKey_Expiry(t *testing.T) {
+	armored, entity := testArmoredKey(t, &packet.Config{KeyLifetimeSecs: 3600}, false)
+	key, err := ParsePGPKey(armored)
+	if err != nil {
+		t.Fatalf("ParsePGPKey() error = %v", err)
+	}
+
+	want := entity.PrimaryKey.CreationTime.Add(time.Hour)
+	if !key.ExpiresAt.Equal(want) {
+		t.Errorf("ParsePGPKey() expires at = %v, want %v", key.ExpiresAt, want)
+	}
+
+	if key.Expired() {
+		t.Error("Expired() of a valid key should be false")
+	}
+
+	if !(PGPKey{ExpiresAt: time.Now().Add(-time.Minute)}).Expired() {
+		t.Error("Expired() of a key expired a minute ago should be true")
+	}
+}
+
+func TestParsePGPKey_Invalid(t *testing.T) {
+	public, _ := testArmoredKey(t, nil, false)
+	private, _ := testArmoredKey(t, nil, true)
+	expired, _ := testArmoredKey(t, &packet.Config{
+		KeyLifetimeSecs: 3600,
+		Time:            func() time.Time { return time.Now().Add(-2 * time.Hour) },
+	}, false)
+
+	tests := []struct {
+		name    string
+		armored string
+	}{
+		{name: "empty", armored: ""},
+		{name: "garbage", armored: "not a key"},
+		{name: "private key", armored: private},
+		{name: "expired", armored: expired},
+		{name: "too long", armored: public + strings.Repeat("\n", maxPGPKeySize)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePGPKey(tt.armored); err == nil {
+				t.Error("ParsePGPKey() should fail")
+			}
+		})
+	}
+}
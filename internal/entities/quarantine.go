@@ -0,0 +1,156 @@
+package entities
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+)
+
+// maxPreviewText limits the text of a quarantined message returned in its preview
+const maxPreviewText = 64 << 10
+
+// QuarantinedMessage is a message received by an alias held in the quarantine instead of being forwarded.
+// The message is kept as the milter would forward it, with the addresses rewritten, so that it is
+// delivered as it is when released.
+type QuarantinedMessage struct {
+	ID        Id
+	Alias     Address
+	ChainHash Hash
+	Sender    Email
+	// RcptTo is the protected address the message is delivered to when released
+	RcptTo    Email
+	Subject   string
+	Size      int
+	Data      []byte
+	CreatedAt time.Time
+	// ExpiresAt is the time the message is removed from the quarantine if not released
+	ExpiresAt time.Time
+}
+
+// Validate checks if the QuarantinedMessage is valid.
+func (m QuarantinedMessage) Validate() error {
+	if err := m.ID.Validate(); err != nil {
+		return err
+	}
+
+	if err := m.Alias.ID.Validate(); err != nil {
+		return fmt.Errorf("validating alias: %w", err)
+	}
+
+	if err := m.ChainHash.Validate(); err != nil {
+		return err
+	}
+
+	if err := m.RcptTo.Validate(); err != nil {
+		return fmt.Errorf("validating recipient: %w", err)
+	}
+
+	if len(m.Data) == 0 {
+		return fmt.Errorf("message can not be empty")
+	}
+
+	return nil
+}
+
+// Expired reports whether the message should be removed from the quarantine.
+func (m QuarantinedMessage) Expired() bool {
+	return time.Now().After(m.ExpiresAt)
+}
+
+// MessagePreview is the readable part of a quarantined message.
+type MessagePreview struct {
+	From    string
+	To      string
+	Subject string
+	Date    string
+	// Text is the first plain text part of the message, empty when there is none,
+	// e.g. for the messages encrypted with the pgp key of the protected address
+	Text string
+	// Truncated is set when the text is longer than returned
+	Truncated bool
+}
+
+// Preview parses the message and returns its header fields and text. The text of parts in
+// unknown charsets is returned as it is.
+func (m QuarantinedMessage) Preview() (MessagePreview, error) {
+	mr, err := mail.CreateReader(bytes.NewReader(m.Data))
+	if err != nil && !message.IsUnknownCharset(err) {
+		return MessagePreview{}, fmt.Errorf("parsing message: %w", err)
+	}
+
+	// the fields are returned as they are when their encoded words can not be decoded
+	preview := MessagePreview{Date: mr.Header.Get("Date")}
+	for field, value := range map[string]*string{"From": &preview.From, "To": &preview.To, "Subject": &preview.Subject} {
+		if *value, err = mr.Header.Text(field); err != nil {
+			*value = mr.Header.Get(field)
+		}
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !message.IsUnknownCharset(err) {
+			return MessagePreview{}, fmt.Errorf("parsing message: %w", err)
+		}
+
+		h, ok := part.Header.(*mail.InlineHeader)
+		if !ok {
+			continue
+		}
+
+		if mediaType, _, _ := h.ContentType(); mediaType != "" && mediaType != "text/plain" {
+			continue
+		}
+
+		text, err := io.ReadAll(io.LimitReader(part.Body, maxPreviewText+1))
+		if err != nil {
+			return MessagePreview{}, fmt.Errorf("reading message text: %w", err)
+		}
+
+		preview.Truncated = len(text) > maxPreviewText
+		preview.Text = string(text[:min(len(text), maxPreviewText)])
+		break
+	}
+
+	return preview, nil
+}
+
+// QuarantineFilter selects quarantined messages.
+type QuarantineFilter struct {
+	Filter
+	Owners   []Id
+	AliasIds []Id
+	// Domains selects the messages of the aliases in the domains
+	Domains []string
+	// ExpiredBefore selects the messages of all owners expiring before the time
+	ExpiredBefore *time.Time
+}
+
+// NewQuarantineFilter parses and returns a QuarantineFilter from the given input map.
+// Populates the Owners and AliasIds fields from the "owner" and "alias_id" filter keys.
+func NewQuarantineFilter(input map[string][]string) (QuarantineFilter, error) {
+	qf := QuarantineFilter{}
+	filter, err := NewFilter(input)
+	if err != nil {
+		return QuarantineFilter{}, err
+	}
+
+	qf.Filter = filter
+	for _, val := range input["owner"] {
+		qf.Owners = append(qf.Owners, Id(val))
+	}
+
+	for _, val := range input["alias_id"] {
+		qf.AliasIds = append(qf.AliasIds, Id(val))
+	}
+
+	return qf, nil
+}
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuarantinedMessage_Preview(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		wantSubject   string
+		wantText      string
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{
+			name:        "plain text",
+			data:        "From: Sender <reply@ovoo.com>\r\nTo: alias@ovoo.com\r\nSubject: Hello\r\n\r\nHello there\r\n",
+			wantSubject: "Hello",
+			wantText:    "Hello there\r\n",
+		},
+		{
+			name: "multipart alternative",
+			data: "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\nContent-Type: multipart/alternative; boundary=b\r\n\r\n" +
+				"--b\r\nContent-Type: text/html\r\n\r\n<p>Hi</p>\r\n" +
+				"--b\r\nContent-Type: text/plain; charset=utf-8\r\n\r\nHi\r\n--b--\r\n",
+			wantSubject: "Grüße",
+			wantText:    "Hi",
+		},
+		{
+			name:        "encrypted",
+			data:        "Subject: Secret\r\nContent-Type: multipart/encrypted; protocol=\"application/pgp-encrypted\"; boundary=b\r\n\r\n--b\r\nContent-Type: application/pgp-encrypted\r\n\r\nVersion: 1\r\n--b--\r\n",
+			wantSubject: "Secret",
+		},
+		{
+			name:          "long text",
+			data:          "Subject: Long\r\n\r\n" + strings.Repeat("a", maxPreviewText+1),
+			wantSubject:   "Long",
+			wantText:      strings.Repeat("a", maxPreviewText),
+			wantTruncated: true,
+		},
+		{
+			name:    "invalid header",
+			data:    "no header\r\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QuarantinedMessage{Data: []byte(tt.data)}.Preview()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QuarantinedMessage.Preview() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Subject != tt.wantSubject || got.Text != tt.wantText || got.Truncated != tt.wantTruncated {
+				t.Errorf("QuarantinedMessage.Preview() = %q, %d bytes of text, truncated %v", got.Subject, len(got.Text), got.Truncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package entities
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultReputationHalfLife is the default time the score of a sender takes to decay to its half
+	DefaultReputationHalfLife = 14 * 24 * time.Hour
+	// DefaultReputationThreshold is the default score listing a sender, a single hit of a trap
+	// lists it for a half life
+	DefaultReputationThreshold = 0.5
+	// MaxReputationScore caps the score of a sender, so that the senders hitting the traps
+	// for a long time still fade once they stop
+	MaxReputationScore = 100.0
+)
+
+// ReputationAction is what happens to the mail of the senders listed by the sender reputation.
+type ReputationAction string
+
+const (
+	// ReputationReject rejects the mail of the listed senders
+	ReputationReject ReputationAction = "reject"
+	// ReputationQuarantine holds the mail of the listed senders in the quarantine of the aliases
+	ReputationQuarantine ReputationAction = "quarantine"
+)
+
+// Validate checks if the action is known.
+func (a ReputationAction) Validate() error {
+	switch a {
+	case ReputationReject, ReputationQuarantine:
+		return nil
+	}
+
+	return fmt.Errorf("unknown reputation action %q", a)
+}
+
+// ReputationOverride is the decision of an admin on a sender, taking precedence over its score.
+type ReputationOverride string
+
+const (
+	// ReputationOverrideNone lists the sender by its score
+	ReputationOverrideNone ReputationOverride = ""
+	// ReputationBlock always lists the sender
+	ReputationBlock ReputationOverride = "block"
+	// ReputationAllow never lists the sender
+	ReputationAllow ReputationOverride = "allow"
+)
+
+// Validate checks if the override is known.
+func (o ReputationOverride) Validate() error {
+	switch o {
+	case ReputationOverrideNone, ReputationBlock, ReputationAllow:
+		return nil
+	}
+
+	return fmt.Errorf("unknown reputation override %q", o)
+}
+
+// ReputationPolicy defines when the senders hitting the traps are listed and what happens to their mail.
+// The zero value lists a sender with DefaultReputationThreshold, decaying with DefaultReputationHalfLife,
+// and rejects its mail.
+type ReputationPolicy struct {
+	// HalfLife is the time the score of a sender takes to decay to its half
+	HalfLife time.Duration
+	// Threshold is the score listing a sender, every hit of a trap adds 1 decaying right away
+	Threshold float64
+	// Action is what happens to the mail of the listed senders
+	Action ReputationAction
+}
+
+func (p ReputationPolicy) halfLife() time.Duration {
+	if p.HalfLife <= 0 {
+		return DefaultReputationHalfLife
+	}
+
+	return p.HalfLife
+}
+
+func (p ReputationPolicy) threshold() float64 {
+	if p.Threshold <= 0 {
+		return DefaultReputationThreshold
+	}
+
+	return p.Threshold
+}
+
+// Validate checks if the ReputationPolicy is valid.
+func (p ReputationPolicy) Validate() error {
+	if p.HalfLife < 0 {
+		return fmt.Errorf("reputation half life can not be negative")
+	}
+
+	if p.Threshold < 0 || p.Threshold > MaxReputationScore {
+		return fmt.Errorf("reputation threshold should be between 0 and %v", MaxReputationScore)
+	}
+
+	if p.Action == "" {
+		return nil
+	}
+
+	return p.Action.Validate()
+}
+
+// Reject reports whether the mail of the listed senders is rejected rather than held.
+func (p ReputationPolicy) Reject() bool {
+	return p.Action != ReputationQuarantine
+}
+
+// Score returns the score of the sender decayed until the time.
+func (p ReputationPolicy) Score(r SenderReputation, at time.Time) float64 {
+	if r.Score <= 0 || r.LastHitAt.IsZero() {
+		return 0
+	}
+
+	elapsed := max(at.Sub(r.LastHitAt), 0)
+	return r.Score * math.Exp2(-float64(elapsed)/float64(p.halfLife()))
+}
+
+// Listed reports whether the mail of the sender is rejected or held at the time, the override of an admin
+// takes precedence over the score.
+func (p ReputationPolicy) Listed(r SenderReputation, at time.Time) bool {
+	switch r.Override {
+	case ReputationBlock:
+		return true
+	case ReputationAllow:
+		return false
+	}
+
+	return p.Score(r, at) >= p.threshold()
+}
+
+// Hit adds a hit of a trap by the sender at the time to its decayed score.
+func (p ReputationPolicy) Hit(r *SenderReputation, at time.Time) {
+	r.Score = min(p.Score(*r, at)+1, MaxReputationScore)
+	r.Hits++
+	r.LastHitAt = at
+}
+
+// FadedBefore returns the time before which the last hit of a sender without an override no longer lists it,
+// whatever its score: even the highest score decays below the threshold since.
+func (p ReputationPolicy) FadedBefore(at time.Time) time.Time {
+	halfLives := math.Log2(MaxReputationScore / p.threshold())
+	return at.Add(-time.Duration(halfLives * float64(p.halfLife())))
+}
+
+// SenderReputation is the record of a sender in the sender reputation: the senders writing to the traps
+// collect a score decaying with time, admins can block or allow a sender whatever its score.
+type SenderReputation struct {
+	// Sender is the normalized envelope sender, see ReputationSender
+	Sender Email
+	// Hits counts the messages of the sender to the traps
+	Hits int
+	// Score is the score of the sender at its last hit
+	Score     float64
+	LastHitAt time.Time
+	Override  ReputationOverride
+	Comment   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Validate checks if the SenderReputation is valid.
+func (r SenderReputation) Validate() error {
+	if err := r.Sender.Validate(); err != nil {
+		return fmt.Errorf("validating sender: %w", err)
+	}
+
+	if r.Sender != ReputationSender(r.Sender) {
+		return fmt.Errorf("sender should be normalized")
+	}
+
+	if r.Hits < 0 || r.Score < 0 || r.Score > MaxReputationScore {
+		return fmt.Errorf("sender score should be between 0 and %v", MaxReputationScore)
+	}
+
+	return r.Override.Validate()
+}
+
+// ReputationSender normalizes the envelope sender recorded in the sender reputation, the senders are
+// compared case insensitively.
+func ReputationSender(email Email) Email {
+	return Email(strings.ToLower(strings.TrimSpace(email.String())))
+}
+
+// ReputationFilter selects the records of the sender reputation.
+type ReputationFilter struct {
+	Filter
+	Senders   []Email
+	Overrides []ReputationOverride
+}
+
+// NewReputationFilter parses and returns a ReputationFilter from the given input map.
+// Populates the Senders and Overrides fields from the "sender" and "override" filter keys,
+// "override" accepts "none" for the senders without an override.
+func NewReputationFilter(input map[string][]string) (ReputationFilter, error) {
+	rf := ReputationFilter{}
+	filter, err := NewFilter(input)
+	if err != nil {
+		return ReputationFilter{}, err
+	}
+
+	rf.Filter = filter
+	for _, val := range input["sender"] {
+		rf.Senders = append(rf.Senders, ReputationSender(Email(val)))
+	}
+
+	for _, val := range input["override"] {
+		override := ReputationOverride(val)
+		if val == "none" {
+			override = ReputationOverrideNone
+		}
+
+		if err := override.Validate(); err != nil {
+			return ReputationFilter{}, fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+		rf.Overrides = append(rf.Overrides, override)
+	}
+
+	return rf, nil
+}
@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReputationPolicy_Listed(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	policy := ReputationPolicy{HalfLife: day}
+	tests := []struct {
+		name string
+		rep  SenderReputation
+		want bool
+	}{
+		{name: "no hits"},
+		{name: "fresh hit", rep: SenderReputation{Hits: 1, Score: 1, LastHitAt: now}, want: true},
+		{name: "recent hit", rep: SenderReputation{Hits: 1, Score: 1, LastHitAt: now.Add(-time.Hour)}, want: true},
+		{name: "decayed hit", rep: SenderReputation{Hits: 1, Score: 1, LastHitAt: now.Add(-2 * day)}},
+		{name: "decayed hits", rep: SenderReputation{Hits: 4, Score: 4, LastHitAt: now.Add(-day)}, want: true},
+		{name: "blocked", rep: SenderReputation{Override: ReputationBlock}, want: true},
+		{name: "allowed", rep: SenderReputation{Hits: 10, Score: 10, LastHitAt: now, Override: ReputationAllow}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Listed(tt.rep, now); got != tt.want {
+				t.Errorf("ReputationPolicy.Listed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReputationPolicy_Hit(t *testing.T) {
+	now := time.Now()
+	policy := ReputationPolicy{HalfLife: time.Hour}
+	rep := SenderReputation{Hits: 1, Score: 4, LastHitAt: now.Add(-time.Hour)}
+
+	policy.Hit(&rep, now)
+	if rep.Hits != 2 || rep.Score != 3 || !rep.LastHitAt.Equal(now) {
+		t.Errorf("ReputationPolicy.Hit() = %+v, want 2 hits with score 3", rep)
+	}
+
+	rep.Score = MaxReputationScore
+	policy.Hit(&rep, now)
+	if rep.Score != MaxReputationScore {
+		t.Errorf("ReputationPolicy.Hit() score = %v, want it capped at %v", rep.Score, MaxReputationScore)
+	}
+}
+
+func TestReputationPolicy_FadedBefore(t *testing.T) {
+	now := time.Now()
+	policy := ReputationPolicy{HalfLife: time.Hour, Threshold: 2}
+	before := policy.FadedBefore(now)
+
+	// the highest score decays to the threshold at the returned time
+	rep := SenderReputation{Hits: 100, Score: MaxReputationScore, LastHitAt: before}
+	if score := policy.Score(rep, now); math.Abs(score-2) > 1e-6 {
+		t.Errorf("ReputationPolicy.Score() = %v, want 2", score)
+	}
+
+	rep.LastHitAt = before.Add(-time.Minute)
+	if policy.Listed(rep, now) {
+		t.Errorf("ReputationPolicy.Listed() = true for a sender last hitting a trap before %v", before)
+	}
+}
+
+func TestReputationPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ReputationPolicy
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "quarantine", policy: ReputationPolicy{HalfLife: time.Hour, Threshold: 3, Action: ReputationQuarantine}},
+		{name: "negative half life", policy: ReputationPolicy{HalfLife: -time.Hour}, wantErr: true},
+		{name: "threshold above the highest score", policy: ReputationPolicy{Threshold: MaxReputationScore + 1}, wantErr: true},
+		{name: "unknown action", policy: ReputationPolicy{Action: "bounce"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ReputationPolicy.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSenderReputation_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rep     SenderReputation
+		wantErr bool
+	}{
+		{name: "hit sender", rep: SenderReputation{Sender: "spam@spam.com", Hits: 1, Score: 1}},
+		{name: "blocked sender", rep: SenderReputation{Sender: "spam@spam.com", Override: ReputationBlock}},
+		{name: "invalid sender", rep: SenderReputation{Sender: "spam"}, wantErr: true},
+		{name: "sender not normalized", rep: SenderReputation{Sender: "Spam@Spam.com"}, wantErr: true},
+		{name: "score above the highest", rep: SenderReputation{Sender: "spam@spam.com", Score: MaxReputationScore + 1}, wantErr: true},
+		{name: "unknown override", rep: SenderReputation{Sender: "spam@spam.com", Override: "maybe"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rep.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("SenderReputation.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewReputationFilter(t *testing.T) {
+	rf, err := NewReputationFilter(map[string][]string{
+		"sender":   {" Spam@Spam.com"},
+		"override": {"block", "none"},
+	})
+	if err != nil {
+		t.Fatalf("NewReputationFilter() error = %v", err)
+	}
+
+	if len(rf.Senders) != 1 || rf.Senders[0] != "spam@spam.com" {
+		t.Errorf("NewReputationFilter() senders = %v, want the normalized sender", rf.Senders)
+	}
+
+	if len(rf.Overrides) != 2 || rf.Overrides[0] != ReputationBlock || rf.Overrides[1] != ReputationOverrideNone {
+		t.Errorf("NewReputationFilter() overrides = %v", rf.Overrides)
+	}
+
+	if _, err := NewReputationFilter(map[string][]string{"override": {"maybe"}}); err == nil {
+		t.Errorf("NewReputationFilter() accepted an unknown override")
+	}
+}
@@ -0,0 +1,172 @@
+package entities
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Permission is a single named capability which can be granted to a user through a role.
+// Permissions without the ":all" suffix apply to objects owned by the user only,
+// while ":all" variants apply to objects of any owner.
+type Permission string
+
+const (
+	PermAliasesRead        Permission = "aliases:read"
+	PermAliasesWrite       Permission = "aliases:write"
+	PermAliasesReadAll     Permission = "aliases:read:all"
+	PermAliasesWriteAll    Permission = "aliases:write:all"
+	PermPrAddrsRead        Permission = "praddrs:read"
+	PermPrAddrsWrite       Permission = "praddrs:write"
+	PermPrAddrsReadAll     Permission = "praddrs:read:all"
+	PermPrAddrsWriteAll    Permission = "praddrs:write:all"
+	PermChainsRead         Permission = "chains:read"
+	PermChainsCreate       Permission = "chains:create"
+	PermChainsDelete       Permission = "chains:delete"
+	PermUsersRead          Permission = "users:read"
+	PermUsersWrite         Permission = "users:write"
+	PermUsersReadAll       Permission = "users:read:all"
+	PermUsersWriteAll      Permission = "users:write:all"
+	PermApiTokensRead      Permission = "apitokens:read"
+	PermApiTokensWrite     Permission = "apitokens:write"
+	PermApiTokensReadAll   Permission = "apitokens:read:all"
+	PermApiTokensWriteAll  Permission = "apitokens:write:all"
+	PermDomainsRead        Permission = "domains:read"
+	PermDomainsWrite       Permission = "domains:write"
+	PermDomainsReadAll     Permission = "domains:read:all"
+	PermDomainsWriteAll    Permission = "domains:write:all"
+	PermDomainsWriteGlobal Permission = "domains:write:global"
+	PermRolesRead          Permission = "roles:read"
+	PermRolesWrite         Permission = "roles:write"
+	PermReputationRead     Permission = "reputation:read"
+	PermReputationWrite    Permission = "reputation:write"
+)
+
+var allPermissions = []Permission{
+	PermAliasesRead, PermAliasesWrite, PermAliasesReadAll, PermAliasesWriteAll,
+	PermPrAddrsRead, PermPrAddrsWrite, PermPrAddrsReadAll, PermPrAddrsWriteAll,
+	PermChainsRead, PermChainsCreate, PermChainsDelete,
+	PermUsersRead, PermUsersWrite, PermUsersReadAll, PermUsersWriteAll,
+	PermApiTokensRead, PermApiTokensWrite, PermApiTokensReadAll, PermApiTokensWriteAll,
+	PermDomainsRead, PermDomainsWrite, PermDomainsReadAll, PermDomainsWriteAll, PermDomainsWriteGlobal,
+	PermRolesRead, PermRolesWrite,
+	PermReputationRead, PermReputationWrite,
+}
+
+// built-in permission sets matching the behavior of the legacy user types
+var defaultPermissions = map[UserType][]Permission{
+	RegularUser: {
+		PermAliasesRead, PermAliasesWrite,
+		PermPrAddrsRead, PermPrAddrsWrite,
+		PermUsersRead, PermUsersWrite,
+		PermApiTokensRead, PermApiTokensWrite,
+		PermDomainsRead, PermDomainsWrite,
+	},
+	AdminUser: allPermissions,
+	MilterUser: {
+		PermChainsRead, PermChainsCreate, PermChainsDelete,
+		PermApiTokensRead, PermApiTokensWrite,
+		PermDomainsRead, PermDomainsReadAll,
+	},
+}
+
+// AllPermissions returns the list of all permissions known to the system.
+func AllPermissions() []Permission {
+	return slices.Clone(allPermissions)
+}
+
+// DefaultPermissions returns the built-in permission set for the given user type.
+// Unknown user types get no permissions.
+func DefaultPermissions(t UserType) []Permission {
+	return slices.Clone(defaultPermissions[t])
+}
+
+// Validate checks if the permission is known to the system.
+func (p Permission) Validate() error {
+	if !slices.Contains(allPermissions, p) {
+		return fmt.Errorf("unknown permission '%s'", p)
+	}
+
+	return nil
+}
+
+var roleNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Role is a named set of permissions which can be assigned to users.
+// A user with a role assigned is granted the role permissions instead
+// of the built-in permissions of the user type.
+type Role struct {
+	ID          Id
+	Name        string
+	Description string
+	Permissions []Permission
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UpdatedBy   User
+}
+
+// Validate checks if the Role object is valid and returns an error if not.
+func (r Role) Validate() error {
+	if err := r.ID.Validate(); err != nil {
+		return err
+	}
+
+	if !roleNameRe.MatchString(r.Name) {
+		return fmt.Errorf("role name must be 1-64 lowercase alphanumeric characters, '-' or '_'")
+	}
+
+	for _, p := range r.Permissions {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasPermission checks if the role grants the given permission.
+func (r Role) HasPermission(p Permission) bool {
+	return slices.Contains(r.Permissions, p)
+}
+
+// Permissions returns the effective permissions of the user: the permissions of
+// the assigned role if any, or the built-in permissions of the user type otherwise,
+// limited by the API token scope if the user was authenticated with a scoped token.
+func (u User) Permissions() []Permission {
+	perms := DefaultPermissions(u.Type)
+	if u.Role != nil {
+		perms = slices.Clone(u.Role.Permissions)
+	}
+
+	if u.Scope != nil {
+		perms = slices.DeleteFunc(perms, func(p Permission) bool { return !u.Scope.Allows(p) })
+	}
+
+	return perms
+}
+
+// HasPermission checks if the user is granted the given permission.
+func (u User) HasPermission(p Permission) bool {
+	if u.Scope != nil && !u.Scope.Allows(p) {
+		return false
+	}
+
+	if u.Role != nil {
+		return u.Role.HasPermission(p)
+	}
+
+	return slices.Contains(defaultPermissions[u.Type], p)
+}
+
+// AllowsDomain checks if the user is allowed to operate on the given domain
+// by the API token scope used for authentication.
+func (u User) AllowsDomain(domain string) bool {
+	return u.Scope == nil || u.Scope.AllowsDomain(domain)
+}
+
+// AllowsEmail checks if the user is allowed to operate on addresses of the email domain
+// by the API token scope used for authentication.
+func (u User) AllowsEmail(email Email) bool {
+	return u.Scope == nil || u.Scope.AllowsEmail(email)
+}
@@ -0,0 +1,112 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRole_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		wantErr bool
+	}{
+		{
+			name:    "valid role",
+			role:    Role{ID: NewId(), Name: "auditor", Permissions: []Permission{PermAliasesReadAll, PermUsersReadAll}},
+			wantErr: false,
+		},
+		{
+			name:    "valid role - no permissions",
+			role:    Role{ID: NewId(), Name: "nobody"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid role - id",
+			role:    Role{ID: "some string", Name: "auditor"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid role - empty name",
+			role:    Role{ID: NewId(), Name: ""},
+			wantErr: true,
+		},
+		{
+			name:    "invalid role - name with spaces",
+			role:    Role{ID: NewId(), Name: "read only"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid role - unknown permission",
+			role:    Role{ID: NewId(), Name: "auditor", Permissions: []Permission{"aliases:delete"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.role.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Role.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUser_HasPermission(t *testing.T) {
+	tests := []struct {
+		name string
+		user User
+		perm Permission
+		want bool
+	}{
+		{name: "admin default", user: User{Type: AdminUser}, perm: PermRolesWrite, want: true},
+		{name: "regular default own", user: User{Type: RegularUser}, perm: PermAliasesWrite, want: true},
+		{name: "regular default all", user: User{Type: RegularUser}, perm: PermAliasesReadAll, want: false},
+		{name: "milter default chains", user: User{Type: MilterUser}, perm: PermChainsCreate, want: true},
+		{name: "milter default aliases", user: User{Type: MilterUser}, perm: PermAliasesRead, want: false},
+		{name: "unknown type", user: User{Type: 42}, perm: PermAliasesRead, want: false},
+		{
+			name: "role replaces type defaults",
+			user: User{Type: AdminUser, Role: &Role{Permissions: []Permission{PermAliasesReadAll}}},
+			perm: PermRolesWrite,
+			want: false,
+		},
+		{
+			name: "role grants permission",
+			user: User{Type: RegularUser, Role: &Role{Permissions: []Permission{PermAliasesReadAll}}},
+			perm: PermAliasesReadAll,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.HasPermission(tt.perm); got != tt.want {
+				t.Errorf("User.HasPermission() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUser_Permissions(t *testing.T) {
+	admin := User{Type: AdminUser}
+	if !slices.Equal(admin.Permissions(), AllPermissions()) {
+		t.Errorf("admin should have all permissions, got %v", admin.Permissions())
+	}
+
+	perms := []Permission{PermChainsRead}
+	custom := User{Type: AdminUser, Role: &Role{Permissions: perms}}
+	if !slices.Equal(custom.Permissions(), perms) {
+		t.Errorf("User.Permissions() = %v, want %v", custom.Permissions(), perms)
+	}
+}
+
+func TestPermission_Validate(t *testing.T) {
+	for _, p := range AllPermissions() {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Permission.Validate() unexpected error for %s: %v", p, err)
+		}
+	}
+
+	if err := Permission("unknown").Validate(); err == nil {
+		t.Errorf("Permission.Validate() expected error for unknown permission")
+	}
+}
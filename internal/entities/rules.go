@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"fmt"
+
+	"github.com/Burmuley/ovoo/internal/sieve"
+)
+
+// maxRulesSize limits the size of the rules script
+const maxRulesSize = 64 << 10
+
+// Rules are the filtering rules of an alias or a protected address, written in the Sieve subset of the sieve package.
+// The rules run on the mail forwarded to the address before it is rewritten.
+type Rules struct {
+	Script string
+}
+
+// ParseRules parses and validates the rules script.
+func ParseRules(script string) (Rules, error) {
+	if len(script) > maxRulesSize {
+		return Rules{}, fmt.Errorf("rules can not be longer than %d bytes", maxRulesSize)
+	}
+
+	if _, err := sieve.Parse(script); err != nil {
+		return Rules{}, fmt.Errorf("parsing rules: %w", err)
+	}
+
+	return Rules{Script: script}, nil
+}
+
+// Redirects returns the addresses the rules can redirect the mail to.
+func (r Rules) Redirects() ([]Email, error) {
+	script, err := sieve.Parse(r.Script)
+	if err != nil {
+		return nil, fmt.Errorf("parsing rules: %w", err)
+	}
+
+	redirects := make([]Email, 0, len(script.Redirects()))
+	for _, addr := range script.Redirects() {
+		redirects = append(redirects, Email(addr))
+	}
+
+	return redirects, nil
+}
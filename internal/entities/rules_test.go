@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{name: "valid", script: `if header :contains "subject" "sale" { discard; }`},
+		{name: "empty", script: ""},
+		{name: "syntax error", script: `if header :contains "subject" { discard; }`, wantErr: true},
+		{name: "unsupported command", script: `keep;`, wantErr: true},
+		{name: "too long", script: "#" + strings.Repeat("x", maxRulesSize), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseRules(tt.script)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && rules.Script != tt.script {
+				t.Errorf("ParseRules() script = %q, want %q", rules.Script, tt.script)
+			}
+		})
+	}
+}
+
+func TestRules_Redirects(t *testing.T) {
+	rules, err := ParseRules(`if address :domain "from" "shop.example" { redirect "Shop@Gmail.com"; } else { redirect "work@gmail.com"; }`)
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+
+	got, err := rules.Redirects()
+	if err != nil {
+		t.Fatalf("Rules.Redirects() error = %v", err)
+	}
+
+	if want := []Email{"shop@gmail.com", "work@gmail.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Rules.Redirects() = %v, want %v", got, want)
+	}
+}
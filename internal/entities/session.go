@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Session represents a server-side session of a user who logged in with a password.
+// The session secret is only known to the client, the session is identified by its hash.
+type Session struct {
+	ID         Hash
+	UserID     Id
+	RemoteAddr string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+// NewSession creates a new session for the user expiring at the given time.
+// It returns the session and the opaque value to be handed to the client.
+func NewSession(userId Id, expiresAt time.Time) (Session, string, error) {
+	secret, err := RandString(32)
+	if err != nil {
+		return Session{}, "", err
+	}
+
+	now := time.Now()
+	session := Session{
+		ID:         SimpleHash(secret),
+		UserID:     userId,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+
+	return session, strings.Join([]string{string(userId), secret}, "."), nil
+}
+
+// ParseSessionValue splits the opaque session value into the user id and the session id.
+func ParseSessionValue(value string) (Id, Hash, error) {
+	userId, secret, ok := strings.Cut(value, ".")
+	if !ok || len(secret) == 0 {
+		return "", "", fmt.Errorf("malformed session value")
+	}
+
+	if err := Id(userId).Validate(); err != nil {
+		return "", "", fmt.Errorf("malformed session value: %w", err)
+	}
+
+	return Id(userId), SimpleHash(secret), nil
+}
+
+// Expired checks if the session reached its absolute expiration or
+// was not used for longer than the idle timeout at the given time.
+func (s Session) Expired(idleTimeout time.Duration, t time.Time) bool {
+	return !t.Before(s.ExpiresAt) || !t.Before(s.LastSeenAt.Add(idleTimeout))
+}
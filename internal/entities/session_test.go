@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSession(t *testing.T) {
+	userId := NewId()
+	expires := time.Now().Add(time.Hour)
+
+	session, value, err := NewSession(userId, expires)
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+
+	if session.UserID != userId || !session.ExpiresAt.Equal(expires) {
+		t.Errorf("NewSession() = %+v, unexpected owner or expiration", session)
+	}
+
+	if err := session.ID.Validate(); err != nil {
+		t.Errorf("NewSession() id is not a valid hash: %v", err)
+	}
+
+	gotUser, gotId, err := ParseSessionValue(value)
+	if err != nil {
+		t.Fatalf("ParseSessionValue() error = %v", err)
+	}
+
+	if gotUser != userId || gotId != session.ID {
+		t.Errorf("ParseSessionValue() = %s, %s, want %s, %s", gotUser, gotId, userId, session.ID)
+	}
+}
+
+func TestParseSessionValue_Malformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "empty", value: ""},
+		{name: "no separator", value: string(NewId())},
+		{name: "empty secret", value: string(NewId()) + "."},
+		{name: "invalid user id", value: "user.secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseSessionValue(tt.value); err == nil {
+				t.Errorf("ParseSessionValue(%q) expected error", tt.value)
+			}
+		})
+	}
+}
+
+func TestSession_Expired(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		session Session
+		want    bool
+	}{
+		{
+			name:    "active",
+			session: Session{LastSeenAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)},
+			want:    false,
+		},
+		{
+			name:    "idle",
+			session: Session{LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+			want:    true,
+		},
+		{
+			name:    "absolute",
+			session: Session{LastSeenAt: now, ExpiresAt: now.Add(-time.Second)},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.session.Expired(30*time.Minute, now); got != tt.want {
+				t.Errorf("Session.Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -59,6 +59,14 @@ type User struct {
 	UpdatedBy      *User
 	CreatedBy      *User
 	Active         bool
+	Role           *Role
+	MFA            UserMFA
+	Notifications  NotificationPrefs
+	// PrivacyFilter enables stripping trackers from the mail received by the user aliases by default
+	PrivacyFilter bool
+	// Scope restricts the user rights for the current request when it was
+	// authenticated with a scoped API token, it is never stored
+	Scope *ApiTokenScope `json:"-"`
 }
 
 // Validate checks if the User object is valid and returns an error if not.
@@ -78,6 +86,12 @@ func (u User) Validate() error {
 	return nil
 }
 
+// IsServiceAccount reports whether the user is a service account used by the
+// mail server integrations (milter, socketmap) rather than by a person.
+func (u User) IsServiceAccount() bool {
+	return u.Type == MilterUser
+}
+
 // String returns a string representation of the User, combining FirstName and LastName.
 func (u User) String() string {
 	return strings.TrimSpace(strings.Join([]string{u.FirstName, u.LastName}, " "))
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	// maxWebhookURLSize limits the length of the webhook url
+	maxWebhookURLSize = 2048
+	// webhookSecretSize is the number of random bytes of a webhook secret
+	webhookSecretSize = 32
+)
+
+// Webhook is the HTTP endpoint the mail received by an alias is posted to instead of being forwarded to a mailbox.
+type Webhook struct {
+	URL string
+	// Secret is the key of the HMAC-SHA256 signature of the delivered messages
+	Secret string
+}
+
+// NewWebhook validates the url of the endpoint and returns the webhook with a new secret.
+func NewWebhook(rawURL string) (Webhook, error) {
+	if err := ValidateWebhookURL(rawURL); err != nil {
+		return Webhook{}, err
+	}
+
+	secret, err := RandString(webhookSecretSize)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("generating webhook secret: %w", err)
+	}
+
+	return Webhook{URL: rawURL, Secret: secret}, nil
+}
+
+// ValidateWebhookURL checks the webhook url is an absolute http or https url without credentials.
+func ValidateWebhookURL(rawURL string) error {
+	if len(rawURL) > maxWebhookURLSize {
+		return fmt.Errorf("webhook url can not be longer than %d characters", maxWebhookURLSize)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parsing webhook url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook url should use the http or https scheme")
+	}
+
+	if u.Hostname() == "" {
+		return fmt.Errorf("webhook url should have a host")
+	}
+
+	if u.User != nil {
+		return fmt.Errorf("webhook url can not contain credentials")
+	}
+
+	return nil
+}
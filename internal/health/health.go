@@ -0,0 +1,187 @@
+// Package health provides the liveness and readiness probes of the Ovoo applications.
+//
+// The liveness probe (/healthz) reports that the process is serving requests. The readiness
+// probe (/readyz) runs the checks of the dependencies of the application, e.g. the database
+// or the Ovoo API, and fails when any of them fails or when the application is shutting down,
+// so that load balancers stop sending new requests before the listeners are closed.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCheckTimeout bounds the time of all readiness checks of a probe
+	DefaultCheckTimeout = 5 * time.Second
+	// DefaultShutdownDelay is the time the application keeps serving after the readiness
+	// probe starts failing on shutdown, so load balancers notice it and drain the instance
+	DefaultShutdownDelay = 5 * time.Second
+)
+
+// probe statuses
+const (
+	statusOK          = "ok"
+	statusFailed      = "failed"
+	statusUnavailable = "unavailable"
+	statusShutdown    = "shutting down"
+)
+
+// Check reports an error when a dependency of the application is not usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of an application and serves the probes.
+type Checker struct {
+	mu            sync.RWMutex
+	checks        []namedCheck
+	draining      atomic.Bool
+	timeout       time.Duration
+	shutdownDelay time.Duration
+}
+
+// ProbeResponse is the body of the probe responses, with the result of each readiness check.
+type ProbeResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// New creates a Checker without checks, the application is ready until checks are added.
+// The application keeps serving for shutdownDelay after the readiness probe starts failing
+// on shutdown, DefaultShutdownDelay when it is zero and not at all when it is negative.
+func New(shutdownDelay time.Duration) *Checker {
+	if shutdownDelay == 0 {
+		shutdownDelay = DefaultShutdownDelay
+	}
+
+	return &Checker{timeout: DefaultCheckTimeout, shutdownDelay: max(shutdownDelay, 0)}
+}
+
+// Add registers the readiness check of a dependency under its name, e.g. "database".
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes the readiness probe fail and waits for the shutdown delay, it is called when the
+// application starts shutting down, before its listeners are closed. It returns early when ctx is done.
+func (c *Checker) Drain(ctx context.Context) {
+	c.draining.Store(true)
+	if c.shutdownDelay == 0 {
+		return
+	}
+
+	t := time.NewTimer(c.shutdownDelay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// Ready runs all checks concurrently and returns the result of each of them by name,
+// and whether all of them succeeded. The errors are logged, not returned, as the probes
+// are not authenticated.
+func (c *Checker) Ready(ctx context.Context) (map[string]string, bool) {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make(map[string]string, len(checks))
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Go(func() {
+			errs[i] = nc.check(ctx)
+		})
+	}
+	wg.Wait()
+
+	ready := true
+	for i, nc := range checks {
+		results[nc.name] = statusOK
+		if errs[i] != nil {
+			slog.Warn("readiness check failed", "check", nc.name, "error", errs[i].Error())
+			results[nc.name] = statusFailed
+			ready = false
+		}
+	}
+
+	return results, ready
+}
+
+// Liveness serves the liveness probe, it succeeds as long as the process serves requests.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, ProbeResponse{Status: statusOK})
+}
+
+// Readiness serves the readiness probe, it fails with 503 Service Unavailable when a check fails
+// or when the application is shutting down.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	if c.draining.Load() {
+		writeProbe(w, http.StatusServiceUnavailable, ProbeResponse{Status: statusShutdown})
+		return
+	}
+
+	results, ready := c.Ready(r.Context())
+	if !ready {
+		writeProbe(w, http.StatusServiceUnavailable, ProbeResponse{Status: statusUnavailable, Checks: results})
+		return
+	}
+
+	writeProbe(w, http.StatusOK, ProbeResponse{Status: statusOK, Checks: results})
+}
+
+// Handler returns the handler of the /healthz and /readyz probes.
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", c.Liveness)
+	mux.HandleFunc("GET /readyz", c.Readiness)
+	return mux
+}
+
+// Serve serves the probes on a plain HTTP listener until ctx is done. It is used by the applications
+// without an HTTP server of their own, the milter and the socketmap.
+func (c *Checker) Serve(ctx context.Context, listenAddr string, logger *slog.Logger) {
+	srv := &http.Server{
+		Addr:              listenAddr,
+		Handler:           c.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      15 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("health server shutdown failed", "err", err.Error())
+		}
+	}()
+
+	logger.Info("started health server", "addr", listenAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("error starting health server", "err", err.Error())
+	}
+}
+
+func writeProbe(w http.ResponseWriter, status int, resp ProbeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, c *Checker, path string) (int, ProbeResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var resp ProbeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	return rec.Code, resp
+}
+
+func TestNew_ShutdownDelay(t *testing.T) {
+	assert.Equal(t, DefaultShutdownDelay, New(0).shutdownDelay)
+	assert.Equal(t, 2*time.Second, New(2*time.Second).shutdownDelay)
+	assert.Equal(t, time.Duration(0), New(-1).shutdownDelay)
+}
+
+func TestChecker_Liveness(t *testing.T) {
+	c := New(-1)
+	c.Add("database", func(context.Context) error { return errors.New("down") })
+
+	code, resp := probe(t, c, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ProbeResponse{Status: statusOK}, resp)
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		dbErr      error
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{
+			name:       "all checks pass",
+			wantCode:   http.StatusOK,
+			wantStatus: statusOK,
+			wantChecks: map[string]string{"database": statusOK, "cache": statusOK},
+		},
+		{
+			name:       "a check fails",
+			dbErr:      errors.New("dial tcp 10.0.0.1:5432: connection refused"),
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: statusUnavailable,
+			wantChecks: map[string]string{"database": statusFailed, "cache": statusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(-1)
+			c.Add("database", func(context.Context) error { return tt.dbErr })
+			c.Add("cache", func(context.Context) error { return nil })
+
+			code, resp := probe(t, c, "/readyz")
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			assert.Equal(t, tt.wantChecks, resp.Checks)
+		})
+	}
+}
+
+func TestChecker_Ready_Timeout(t *testing.T) {
+	c := New(-1)
+	c.timeout = 10 * time.Millisecond
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	results, ready := c.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, map[string]string{"slow": statusFailed}, results)
+}
+
+func TestChecker_Drain(t *testing.T) {
+	c := New(50 * time.Millisecond)
+	code, _ := probe(t, c, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	start := time.Now()
+	c.Drain(context.Background())
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	code, resp := probe(t, c, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, statusShutdown, resp.Status)
+
+	// the process is still alive while it is drained
+	code, _ = probe(t, c, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestChecker_Drain_Canceled(t *testing.T) {
+	c := New(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		c.Drain(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not stop when the context was canceled")
+	}
+}
+
+func TestChecker_Serve(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		New(-1).Serve(ctx, addr, slog.New(slog.DiscardHandler))
+		close(done)
+	}()
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + addr + "/readyz")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("health server did not stop")
+	}
+}
@@ -0,0 +1,287 @@
+// Package mailer sends the messages of the Ovoo API to users, e.g. the confirmation links
+// of protected addresses, over SMTP.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// DefaultTimeout bounds the delivery of a message to the SMTP server when none is configured
+const DefaultTimeout = 10 * time.Second
+
+// TLS modes of the connection to the SMTP server
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+// Message is a message to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	// Body is the plain text version of the message
+	Body string
+	// HTMLBody is the optional HTML version of the message, the message is sent
+	// as multipart/alternative with both versions when it is set
+	HTMLBody string
+}
+
+// Mailer sends messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Relayer delivers complete messages received from elsewhere, e.g. the mail released from the quarantine.
+type Relayer interface {
+	Relay(ctx context.Context, to string, data []byte) error
+}
+
+// SMTPMailer is a Mailer submitting messages to an SMTP server.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	from     mail.Address
+	username string
+	password string
+	tlsMode  string
+	timeout  time.Duration
+}
+
+// New creates an SMTPMailer with the configuration of the SMTP server.
+func New(cfg config.ConfigSMTP) (*SMTPMailer, error) {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil || host == "" {
+		return nil, fmt.Errorf("%w: smtp address must be host:port", entities.ErrConfiguration)
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid smtp from address: %w", entities.ErrConfiguration, err)
+	}
+
+	tlsMode := strings.ToLower(cfg.TLS)
+	switch tlsMode {
+	case "":
+		tlsMode = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("%w: smtp tls must be one of %q, %q or %q", entities.ErrConfiguration, TLSStartTLS, TLSImplicit, TLSNone)
+	}
+
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("%w: smtp timeout can not be negative", entities.ErrConfiguration)
+	}
+
+	timeout := DefaultTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	return &SMTPMailer{
+		addr:     cfg.Address,
+		host:     host,
+		from:     *from,
+		username: cfg.Username,
+		password: cfg.Password,
+		tlsMode:  tlsMode,
+		timeout:  timeout,
+	}, nil
+}
+
+// Send delivers the message to the SMTP server.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: invalid recipient: %w", entities.ErrValidation, err)
+	}
+
+	data, err := m.render(*to, msg)
+	if err != nil {
+		return err
+	}
+
+	return m.deliver(ctx, to.Address, data)
+}
+
+// Relay delivers the complete message as it is to the SMTP server, with the configured sender address
+// as the envelope sender. The message is not rendered, its headers are kept.
+func (m *SMTPMailer) Relay(ctx context.Context, to string, data []byte) error {
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("%w: invalid recipient: %w", entities.ErrValidation, err)
+	}
+
+	return m.deliver(ctx, rcpt.Address, data)
+}
+
+// deliver connects to the SMTP server and submits the message data to the recipient.
+func (m *SMTPMailer) deliver(ctx context.Context, to string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	var err error
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if m.tlsMode == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: m.tlsConfig()}).DialContext(ctx, "tcp", m.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", m.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to smtp server: %w", err)
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("connecting to smtp server: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if err := m.submit(c, to, data); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+
+	return nil
+}
+
+// submit runs the SMTP transaction of the message.
+func (m *SMTPMailer) submit(c *smtp.Client, to string, data []byte) error {
+	if m.tlsMode == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+
+		if err := c.StartTLS(m.tlsConfig()); err != nil {
+			return err
+		}
+	}
+
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.from.Address); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	return &tls.Config{ServerName: m.host, MinVersion: tls.VersionTLS12}
+}
+
+// render formats the message with its headers, the bodies are encoded as quoted-printable UTF-8 text.
+func (m *SMTPMailer) render(to mail.Address, msg Message) ([]byte, error) {
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, fmt.Errorf("%w: subject can not contain line breaks", entities.ErrValidation)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	headers := [][2]string{
+		{"From", m.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), m.host)},
+		{"MIME-Version", "1.0"},
+		{"Auto-Submitted", "auto-generated"},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(buf, "%s: %s\r\n", h[0], h[1])
+	}
+
+	if msg.HTMLBody == "" {
+		fmt.Fprintf(buf, "Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(buf, msg.Body); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(buf)
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	parts := [][2]string{{"text/plain", msg.Body}, {"text/html", msg.HTMLBody}}
+	for _, p := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p[0] + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeQuotedPrintable(w, p[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable writes the body with CRLF line breaks encoded as quoted-printable.
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
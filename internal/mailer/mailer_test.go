@@ -0,0 +1,281 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// sinkMessage is a message received by the smtpSink.
+type sinkMessage struct {
+	From string
+	To   []string
+	Auth string
+	Data string
+}
+
+// smtpSink is a minimal SMTP server recording the messages it receives.
+type smtpSink struct {
+	addr     string
+	starttls bool
+	mu       sync.Mutex
+	messages []sinkMessage
+}
+
+func newSMTPSink(t *testing.T, starttls bool) *smtpSink {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	s := &smtpSink{addr: l.Addr().String(), starttls: starttls}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	msg := sinkMessage{}
+	reply("220 sink ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case cmd == "EHLO":
+			reply("250-sink")
+			if s.starttls {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case cmd == "AUTH":
+			msg.Auth = line
+			reply("235 2.7.0 Authentication successful")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg.From = line[len("MAIL FROM:"):]
+			reply("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			msg.To = append(msg.To, line[len("RCPT TO:"):])
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			data := strings.Builder{}
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = sinkMessage{}
+			reply("250 OK queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpSink) received() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.messages...)
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ConfigSMTP
+	}{
+		{name: "missing port", cfg: config.ConfigSMTP{Address: "smtp.example.com", From: "ovoo@example.com"}},
+		{name: "invalid from", cfg: config.ConfigSMTP{Address: "smtp.example.com:587", From: "not an address"}},
+		{name: "unknown tls mode", cfg: config.ConfigSMTP{Address: "smtp.example.com:587", From: "ovoo@example.com", TLS: "ssl"}},
+		{name: "negative timeout", cfg: config.ConfigSMTP{Address: "smtp.example.com:587", From: "ovoo@example.com", Timeout: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			assert.ErrorIs(t, err, entities.ErrConfiguration)
+		})
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	m, err := New(config.ConfigSMTP{Address: "smtp.example.com:587", From: "Ovoo <ovoo@example.com>"})
+	require.NoError(t, err)
+	assert.Equal(t, TLSStartTLS, m.tlsMode)
+	assert.Equal(t, DefaultTimeout, m.timeout)
+	assert.Equal(t, "smtp.example.com", m.host)
+}
+
+func TestSend(t *testing.T) {
+	sink := newSMTPSink(t, false)
+	m, err := New(config.ConfigSMTP{
+		Address:  sink.addr,
+		From:     "Ovoo <ovoo@example.com>",
+		Username: "ovoo",
+		Password: "secret",
+		TLS:      TLSNone,
+	})
+	require.NoError(t, err)
+
+	body := "Confirm your address: https://ovoo.example.com/confirm?token=" + strings.Repeat("x", 100) + "\nThanks"
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "Confirm ✓", Body: body})
+	require.NoError(t, err)
+
+	msgs := sink.received()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "<ovoo@example.com>", msgs[0].From)
+	assert.Equal(t, []string{"<user@example.com>"}, msgs[0].To)
+	assert.Contains(t, msgs[0].Auth, "AUTH PLAIN")
+
+	parsed, err := mail.ReadMessage(strings.NewReader(msgs[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, `"Ovoo" <ovoo@example.com>`, parsed.Header.Get("From"))
+	assert.Equal(t, "<user@example.com>", parsed.Header.Get("To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Confirm ✓", subject)
+	assert.NotEmpty(t, parsed.Header.Get("Message-ID"))
+
+	decoded, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(body, "\n", "\r\n"), strings.TrimSuffix(string(decoded), "\r\n"))
+}
+
+func TestSend_StartTLSRequired(t *testing.T) {
+	sink := newSMTPSink(t, false)
+	m, err := New(config.ConfigSMTP{Address: sink.addr, From: "ovoo@example.com"})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "s", Body: "b"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STARTTLS")
+	assert.Empty(t, sink.received())
+}
+
+func TestSend_InvalidMessage(t *testing.T) {
+	m, err := New(config.ConfigSMTP{Address: "127.0.0.1:1", From: "ovoo@example.com", TLS: TLSNone})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{To: "not an address", Subject: "s", Body: "b"})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "s\r\nBcc: other@example.com", Body: "b"})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestSend_ServerUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	m, err := New(config.ConfigSMTP{Address: addr, From: "ovoo@example.com", TLS: TLSNone, Timeout: 1})
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "s", Body: "b"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestSend_HTML(t *testing.T) {
+	sink := newSMTPSink(t, false)
+	m, err := New(config.ConfigSMTP{Address: sink.addr, From: "ovoo@example.com", TLS: TLSNone})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{
+		To:       "user@example.com",
+		Subject:  "New sender",
+		Body:     "A new sender wrote to your alias",
+		HTMLBody: "<p>A new sender wrote to your alias</p>",
+	})
+	require.NoError(t, err)
+
+	msgs := sink.received()
+	require.Len(t, msgs, 1)
+	parsed, err := mail.ReadMessage(strings.NewReader(msgs[0].Data))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	want := [][2]string{
+		{"text/plain; charset=utf-8", "A new sender wrote to your alias"},
+		{"text/html; charset=utf-8", "<p>A new sender wrote to your alias</p>"},
+	}
+	for _, w := range want {
+		part, err := mr.NextRawPart()
+		require.NoError(t, err)
+		assert.Equal(t, w[0], part.Header.Get("Content-Type"))
+		assert.Equal(t, "quoted-printable", part.Header.Get("Content-Transfer-Encoding"))
+		decoded, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		assert.Equal(t, w[1], string(decoded))
+	}
+
+	_, err = mr.NextPart()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestRelay(t *testing.T) {
+	sink := newSMTPSink(t, false)
+	m, err := New(config.ConfigSMTP{Address: sink.addr, From: "Ovoo <ovoo@example.com>", TLS: TLSNone})
+	require.NoError(t, err)
+
+	data := "From: Shop <news@shop.com>\r\nTo: alias@ovoo.com\r\nSubject: Sale\r\n\r\nHello\r\n.dotted line\r\n"
+	require.NoError(t, m.Relay(context.Background(), "user@example.com", []byte(data)))
+
+	msgs := sink.received()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "<ovoo@example.com>", msgs[0].From)
+	assert.Equal(t, []string{"<user@example.com>"}, msgs[0].To)
+	// the message is delivered as it is, dot-stuffed on the wire
+	assert.Equal(t, strings.Replace(data, "\r\n.dotted", "\r\n..dotted", 1), msgs[0].Data)
+
+	err = m.Relay(context.Background(), "not an address", []byte(data))
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
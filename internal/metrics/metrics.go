@@ -0,0 +1,195 @@
+// Package metrics holds the Prometheus metrics of the Ovoo applications
+// and serves them in the Prometheus text format on a dedicated listener.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ovoo"
+
+// cache request results
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "API requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "API request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cached repository reads by entity and result (hit or miss).",
+	}, []string{"entity", "result"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	milterDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "milter_decisions_total",
+		Help:      "Milter decisions on messages by outcome.",
+	}, []string{"outcome"})
+
+	apiClientRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_client_request_duration_seconds",
+		Help:      "Latency of the requests of the milter and socketmap to the Ovoo API by operation and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "status"})
+
+	socketmapLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "socketmap_lookups_total",
+		Help:      "Socketmap lookups by type and result.",
+	}, []string{"lookup", "result"})
+)
+
+// localCache is the bounded in-memory cache of the process whose counters are exported
+var (
+	localCache         atomic.Pointer[memory.LRUCache]
+	localCacheRegister sync.Once
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		cacheRequests,
+		dbQueryDuration,
+		milterDecisions,
+		apiClientRequestDuration,
+		socketmapLookups,
+	)
+}
+
+// ObserveHTTPRequest records an API request, route is the pattern of the matched route.
+func ObserveHTTPRequest(method, route, status string, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpRequestDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// ObserveCacheRequest records a cached repository read with CacheHit or CacheMiss result.
+func ObserveCacheRequest(entity, result string) {
+	cacheRequests.WithLabelValues(entity, result).Inc()
+}
+
+// ObserveDBQuery records the latency of a database query.
+func ObserveDBQuery(operation string, duration time.Duration) {
+	dbQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// ObserveMilterDecision records the outcome of the milter for a message.
+func ObserveMilterDecision(outcome string) {
+	milterDecisions.WithLabelValues(outcome).Inc()
+}
+
+// ObserveAPIClientRequest records a request to the Ovoo API, status is the response
+// status code, "error" when no response was received or "circuit_open" when the request
+// was not sent because the circuit breaker is open.
+func ObserveAPIClientRequest(operation, status string, duration time.Duration) {
+	apiClientRequestDuration.WithLabelValues(operation, status).Observe(duration.Seconds())
+}
+
+// ObserveSocketmapLookup records a socketmap lookup.
+func ObserveSocketmapLookup(lookup, result string) {
+	socketmapLookups.WithLabelValues(lookup, result).Inc()
+}
+
+// RegisterLocalCache exports the counters of the bounded in-memory cache of the process,
+// the cache registered last replaces the previous one.
+func RegisterLocalCache(c *memory.LRUCache) {
+	localCache.Store(c)
+	localCacheRegister.Do(func() {
+		registry.MustRegister(
+			localCacheCounter("local_cache_hits_total", "Reads of the bounded in-memory cache returning a value.",
+				func(s memory.Stats) uint64 { return s.Hits }),
+			localCacheCounter("local_cache_misses_total", "Reads of the bounded in-memory cache finding no value.",
+				func(s memory.Stats) uint64 { return s.Misses }),
+			localCacheCounter("local_cache_evictions_total", "Entries evicted from the bounded in-memory cache to stay within its limits.",
+				func(s memory.Stats) uint64 { return s.Evictions }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "local_cache_entries",
+				Help:      "Entries of the bounded in-memory cache, including expired ones not yet removed.",
+			}, func() float64 { return float64(localCache.Load().Stats().Entries) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "local_cache_bytes",
+				Help:      "Size of the keys and values of the bounded in-memory cache.",
+			}, func() float64 { return float64(localCache.Load().Stats().Bytes) }),
+		)
+	})
+}
+
+// localCacheCounter creates the counter reporting the value of the local cache stats.
+func localCacheCounter(name, help string, value func(memory.Stats) uint64) prometheus.CounterFunc {
+	return prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, func() float64 { return float64(value(localCache.Load().Stats())) })
+}
+
+// Handler returns the handler serving the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves the metrics on "/metrics" of the listen address until the context is done.
+// Errors are logged, metrics are not essential for the application.
+func Serve(ctx context.Context, listenAddr string, logger *slog.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler())
+	srv := &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      15 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("metrics server shutdown failed", "err", err.Error())
+		}
+	}()
+
+	logger.Info("started metrics server", "addr", listenAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("error starting metrics server", "err", err.Error())
+	}
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	require.NoError(t, c.Write(m))
+	return m.GetCounter().GetValue()
+}
+
+func TestObserveHTTPRequest(t *testing.T) {
+	before := counterValue(t, httpRequests.WithLabelValues("GET", "/api/v1/users/{id}", "200"))
+	ObserveHTTPRequest("GET", "/api/v1/users/{id}", "200", 10*time.Millisecond)
+
+	assert.Equal(t, before+1, counterValue(t, httpRequests.WithLabelValues("GET", "/api/v1/users/{id}", "200")))
+	body := scrape(t)
+	assert.Contains(t, body, `ovoo_http_requests_total{method="GET",route="/api/v1/users/{id}",status="200"}`)
+	assert.Contains(t, body, `ovoo_http_request_duration_seconds_bucket{method="GET",route="/api/v1/users/{id}",status="200",le="0.01"}`)
+}
+
+func TestObserveCacheRequest(t *testing.T) {
+	hits := counterValue(t, cacheRequests.WithLabelValues("addr", CacheHit))
+	misses := counterValue(t, cacheRequests.WithLabelValues("addr", CacheMiss))
+
+	ObserveCacheRequest("addr", CacheHit)
+	ObserveCacheRequest("addr", CacheHit)
+	ObserveCacheRequest("addr", CacheMiss)
+
+	assert.Equal(t, hits+2, counterValue(t, cacheRequests.WithLabelValues("addr", CacheHit)))
+	assert.Equal(t, misses+1, counterValue(t, cacheRequests.WithLabelValues("addr", CacheMiss)))
+}
+
+func TestHandler_ExportsAllMetrics(t *testing.T) {
+	ObserveDBQuery("query", time.Millisecond)
+	ObserveMilterDecision("rewritten")
+	ObserveAPIClientRequest("get_domains", "200", time.Millisecond)
+	ObserveSocketmapLookup("relay_domain", "found")
+
+	body := scrape(t)
+	for _, name := range []string{
+		"ovoo_db_query_duration_seconds_bucket",
+		`ovoo_milter_decisions_total{outcome="rewritten"}`,
+		`ovoo_api_client_request_duration_seconds_count{operation="get_domains",status="200"}`,
+		`ovoo_socketmap_lookups_total{lookup="relay_domain",result="found"}`,
+		"go_goroutines",
+	} {
+		assert.Contains(t, body, name)
+	}
+}
+
+func TestRegisterLocalCache(t *testing.T) {
+	c, err := memory.NewLRU(1, 0)
+	require.NoError(t, err)
+	RegisterLocalCache(c)
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "a", []byte("v"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("v"), time.Minute))
+	_, _ = c.Get(ctx, "a")
+	_, _ = c.Get(ctx, "b")
+
+	body := scrape(t)
+	for _, name := range []string{
+		"ovoo_local_cache_hits_total 1",
+		"ovoo_local_cache_misses_total 1",
+		"ovoo_local_cache_evictions_total 1",
+		"ovoo_local_cache_entries 1",
+		"ovoo_local_cache_bytes 2",
+	} {
+		assert.Contains(t, body, name)
+	}
+}
+
+func TestServe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Serve(ctx, addr, slog.New(slog.DiscardHandler))
+		close(done)
+	}()
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + addr + "/metrics")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "go_goroutines")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("metrics server did not stop")
+	}
+}
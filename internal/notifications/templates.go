@@ -0,0 +1,155 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// Suffixes of the template files of an event, e.g. new_sender.subject.tmpl
+const (
+	subjectSuffix = ".subject.tmpl"
+	textSuffix    = ".txt.tmpl"
+	htmlSuffix    = ".html.tmpl"
+)
+
+// TemplateData is passed to the templates of a notification.
+type TemplateData struct {
+	// Name is the first name of the recipient, the email when the name is unknown
+	Name string
+	// Data contains the values of the event, e.g. .Data.alias and .Data.sender of new_sender
+	Data map[string]string
+}
+
+// eventTemplates holds the parsed templates of an event, html is nil when the event
+// has no HTML template and its messages are sent as plain text only.
+type eventTemplates struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// Templates renders the notifications into messages.
+type Templates struct {
+	events map[entities.NotificationEvent]eventTemplates
+}
+
+// LoadTemplates parses the built-in templates of all events, the files of dir with the same
+// names (e.g. new_sender.txt.tmpl) replace them. dir is optional.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{events: make(map[entities.NotificationEvent]eventTemplates, len(entities.NotificationEvents))}
+	for _, event := range entities.NotificationEvents {
+		subject, err := readTemplate(dir, string(event)+subjectSuffix)
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := readTemplate(dir, string(event)+textSuffix)
+		if err != nil {
+			return nil, err
+		}
+
+		html, err := readTemplate(dir, string(event)+htmlSuffix)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		et := eventTemplates{}
+		if et.subject, err = texttemplate.New(string(event) + subjectSuffix).Option("missingkey=zero").Parse(subject); err != nil {
+			return nil, fmt.Errorf("%w: parsing template: %w", entities.ErrConfiguration, err)
+		}
+
+		if et.text, err = texttemplate.New(string(event) + textSuffix).Option("missingkey=zero").Parse(text); err != nil {
+			return nil, fmt.Errorf("%w: parsing template: %w", entities.ErrConfiguration, err)
+		}
+
+		if html != "" {
+			if et.html, err = htmltemplate.New(string(event) + htmlSuffix).Option("missingkey=zero").Parse(html); err != nil {
+				return nil, fmt.Errorf("%w: parsing template: %w", entities.ErrConfiguration, err)
+			}
+		}
+
+		t.events[event] = et
+	}
+
+	return t, nil
+}
+
+// readTemplate returns the content of the named template from dir, or the built-in one
+// when dir is not set or does not contain it.
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: reading template: %w", entities.ErrConfiguration, err)
+		}
+	}
+
+	data, err := builtinTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: template %s: %w", entities.ErrConfiguration, name, err)
+		}
+
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// Render formats the notification into a message to its recipient.
+func (t *Templates) Render(n entities.Notification) (mailer.Message, error) {
+	et, ok := t.events[n.Event]
+	if !ok {
+		return mailer.Message{}, fmt.Errorf("%w: no templates for notification event %q", entities.ErrValidation, n.Event)
+	}
+
+	data := TemplateData{Name: n.Name, Data: n.Data}
+	if data.Name == "" {
+		data.Name = n.To.String()
+	}
+
+	buf := &bytes.Buffer{}
+	if err := et.subject.Execute(buf, data); err != nil {
+		return mailer.Message{}, fmt.Errorf("rendering subject: %w", err)
+	}
+
+	msg := mailer.Message{
+		To: n.To.String(),
+		// line breaks are not allowed in the subject, the trailing one of the file is dropped
+		Subject: strings.Join(strings.Fields(buf.String()), " "),
+	}
+
+	buf.Reset()
+	if err := et.text.Execute(buf, data); err != nil {
+		return mailer.Message{}, fmt.Errorf("rendering text body: %w", err)
+	}
+	msg.Body = buf.String()
+
+	if et.html != nil {
+		buf.Reset()
+		if err := et.html.Execute(buf, data); err != nil {
+			return mailer.Message{}, fmt.Errorf("rendering html body: %w", err)
+		}
+		msg.HTMLBody = buf.String()
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+func testNotification() entities.Notification {
+	return entities.Notification{
+		ID:     entities.NewId(),
+		Key:    "new_sender:hash",
+		UserID: entities.NewId(),
+		To:     "owner@example.com",
+		Name:   "Owner",
+		Event:  entities.NotificationNewSender,
+		Data:   map[string]string{"alias": "alias@example.com", "sender": "<b>sender@example.com</b>"},
+		Status: entities.NotificationQueued,
+	}
+}
+
+func TestLoadTemplates_Builtin(t *testing.T) {
+	templates, err := LoadTemplates("")
+	require.NoError(t, err)
+
+	for _, event := range entities.NotificationEvents {
+		n := testNotification()
+		n.Event = event
+		msg, err := templates.Render(n)
+		require.NoError(t, err, event)
+		assert.NotEmpty(t, msg.Subject, event)
+		assert.NotContains(t, msg.Subject, "\n", event)
+		assert.Contains(t, msg.Body, "Hello Owner", event)
+		assert.NotEmpty(t, msg.HTMLBody, event)
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := LoadTemplates("")
+	require.NoError(t, err)
+
+	msg, err := templates.Render(testNotification())
+	require.NoError(t, err)
+	assert.Equal(t, "owner@example.com", msg.To)
+	assert.Equal(t, "New sender for alias@example.com", msg.Subject)
+	assert.Contains(t, msg.Body, "<b>sender@example.com</b> has sent a message to your alias alias@example.com")
+	// values are escaped in the HTML version only
+	assert.Contains(t, msg.HTMLBody, "&lt;b&gt;sender@example.com&lt;/b&gt;")
+}
+
+func TestTemplates_Render_NoName(t *testing.T) {
+	templates, err := LoadTemplates("")
+	require.NoError(t, err)
+
+	n := testNotification()
+	n.Name = ""
+	msg, err := templates.Render(n)
+	require.NoError(t, err)
+	assert.Contains(t, msg.Body, "Hello owner@example.com")
+}
+
+func TestTemplates_Render_UnknownEvent(t *testing.T) {
+	templates, err := LoadTemplates("")
+	require.NoError(t, err)
+
+	n := testNotification()
+	n.Event = "unknown"
+	_, err = templates.Render(n)
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestLoadTemplates_Override(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new_sender.subject.tmpl"), []byte("[Ovoo] {{.Data.sender}}\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new_sender.txt.tmpl"), []byte("Hi {{.Name}}"), 0o600))
+
+	templates, err := LoadTemplates(dir)
+	require.NoError(t, err)
+
+	msg, err := templates.Render(testNotification())
+	require.NoError(t, err)
+	assert.Equal(t, "[Ovoo] <b>sender@example.com</b>", msg.Subject)
+	assert.Equal(t, "Hi Owner", msg.Body)
+	// templates missing in the directory are the built-in ones
+	assert.Contains(t, msg.HTMLBody, "has sent a message to your alias")
+}
+
+func TestLoadTemplates_InvalidOverride(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token_expiring.html.tmpl"), []byte("{{.Data.token"), 0o600))
+
+	_, err := LoadTemplates(dir)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
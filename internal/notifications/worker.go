@@ -0,0 +1,206 @@
+// Package notifications delivers the email notifications of Ovoo users, e.g. about a new
+// sender of an alias, from the persistent queue filled by the services.
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+	"github.com/Burmuley/ovoo/internal/repositories"
+)
+
+const (
+	// DefaultInterval is the period between the deliveries of the queued notifications
+	DefaultInterval = 30 * time.Second
+	// DefaultCheckInterval is the period between the runs of the checks emitting notifications
+	DefaultCheckInterval = time.Hour
+	// DefaultBatchSize is the number of notifications sent per delivery at most
+	DefaultBatchSize = 50
+	// DefaultMaxAttempts is the number of delivery attempts of a notification before it is given up
+	DefaultMaxAttempts = 10
+	// DefaultRetention is the period the sent and failed notifications are kept for
+	DefaultRetention = 7 * 24 * time.Hour
+	// claimLease keeps a notification being sent from being claimed by another API instance
+	claimLease = 5 * time.Minute
+	// maxRetryDelay bounds the exponential backoff between the delivery attempts
+	maxRetryDelay = time.Hour
+)
+
+// check is run periodically to emit the notifications not bound to a request,
+// e.g. about expiring API tokens.
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Worker sends the queued notifications with a Mailer.
+// Several API instances can run a Worker on the same queue, each notification is sent by one of them.
+type Worker struct {
+	queue         repositories.NotificationsQueue
+	mailer        mailer.Mailer
+	templates     *Templates
+	logger        *slog.Logger
+	interval      time.Duration
+	checkInterval time.Duration
+	batchSize     int
+	maxAttempts   int
+	retention     time.Duration
+	checks        []check
+}
+
+// NewWorker creates a Worker delivering the notifications of the queue.
+func NewWorker(queue repositories.NotificationsQueue, m mailer.Mailer, cfg config.ConfigNotifications, logger *slog.Logger) (*Worker, error) {
+	if queue == nil || m == nil {
+		return nil, fmt.Errorf("%w: notifications queue and mailer should be defined", entities.ErrConfiguration)
+	}
+
+	if cfg.Interval < 0 || cfg.CheckInterval < 0 || cfg.BatchSize < 0 || cfg.MaxAttempts < 0 || cfg.Retention < 0 {
+		return nil, fmt.Errorf("%w: notifications settings can not be negative", entities.ErrConfiguration)
+	}
+
+	templates, err := LoadTemplates(cfg.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Worker{
+		queue:         queue,
+		mailer:        m,
+		templates:     templates,
+		logger:        logger,
+		interval:      DefaultInterval,
+		checkInterval: DefaultCheckInterval,
+		batchSize:     DefaultBatchSize,
+		maxAttempts:   DefaultMaxAttempts,
+		retention:     DefaultRetention,
+	}
+
+	if cfg.Interval > 0 {
+		w.interval = time.Duration(cfg.Interval) * time.Second
+	}
+
+	if cfg.CheckInterval > 0 {
+		w.checkInterval = time.Duration(cfg.CheckInterval) * time.Second
+	}
+
+	if cfg.BatchSize > 0 {
+		w.batchSize = cfg.BatchSize
+	}
+
+	if cfg.MaxAttempts > 0 {
+		w.maxAttempts = cfg.MaxAttempts
+	}
+
+	if cfg.Retention > 0 {
+		w.retention = time.Duration(cfg.Retention) * time.Second
+	}
+
+	if w.logger == nil {
+		w.logger = slog.Default()
+	}
+
+	return w, nil
+}
+
+// AddCheck registers a function run every check interval, before the delivery.
+// Checks should not be added after Run was called.
+func (w *Worker) AddCheck(name string, fn func(ctx context.Context) error) {
+	w.checks = append(w.checks, check{name: name, fn: fn})
+}
+
+// Run delivers the queued notifications every interval until the context is canceled.
+// The checks and the removal of the old notifications run every check interval.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var lastCheck time.Time
+	for {
+		if time.Since(lastCheck) >= w.checkInterval {
+			w.runChecks(ctx)
+			lastCheck = time.Now()
+		}
+
+		if err := w.Deliver(ctx); err != nil {
+			w.logger.Error("delivering notifications", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runChecks runs all checks and removes the sent and failed notifications older than the retention.
+func (w *Worker) runChecks(ctx context.Context) {
+	for _, c := range w.checks {
+		if err := c.fn(ctx); err != nil {
+			w.logger.Error("running notifications check", "check", c.name, "error", err)
+		}
+	}
+
+	if err := w.queue.DeleteFinished(ctx, time.Now().Add(-w.retention)); err != nil {
+		w.logger.Error("removing finished notifications", "error", err)
+	}
+}
+
+// Deliver sends the notifications due for delivery, up to the batch size.
+// Failed notifications are retried with exponential backoff until the attempts are exhausted.
+func (w *Worker) Deliver(ctx context.Context) error {
+	batch, err := w.queue.Claim(ctx, w.batchSize, claimLease)
+	if err != nil {
+		return fmt.Errorf("claiming notifications: %w", err)
+	}
+
+	for _, n := range batch {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		n.Attempts++
+		if err := w.send(ctx, n); err != nil {
+			n.LastError = err.Error()
+			n.NextAttemptAt = time.Now().Add(retryDelay(n.Attempts))
+			if n.Attempts >= w.maxAttempts {
+				n.Status = entities.NotificationFailed
+			}
+			w.logger.Warn("sending notification", "notification_id", n.ID, "event", n.Event, "attempts", n.Attempts, "error", err)
+		} else {
+			n.Status = entities.NotificationSent
+			n.SentAt = time.Now().UTC()
+			n.LastError = ""
+		}
+
+		if err := w.queue.Update(ctx, n); err != nil {
+			return fmt.Errorf("updating notification %s: %w", n.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *Worker) send(ctx context.Context, n entities.Notification) error {
+	msg, err := w.templates.Render(n)
+	if err != nil {
+		return err
+	}
+
+	return w.mailer.Send(ctx, msg)
+}
+
+// retryDelay returns the delay before the next delivery attempt, doubling from a minute up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
@@ -0,0 +1,165 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+)
+
+// memoryQueue is an in-memory NotificationsQueue returning the queued notifications due for delivery.
+type memoryQueue struct {
+	notifications map[entities.Id]entities.Notification
+	deletedBefore time.Time
+}
+
+func newMemoryQueue(ns ...entities.Notification) *memoryQueue {
+	q := &memoryQueue{notifications: make(map[entities.Id]entities.Notification)}
+	for _, n := range ns {
+		q.notifications[n.ID] = n
+	}
+
+	return q
+}
+
+func (q *memoryQueue) Enqueue(ctx context.Context, n entities.Notification) error {
+	q.notifications[n.ID] = n
+	return nil
+}
+
+func (q *memoryQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]entities.Notification, error) {
+	res := make([]entities.Notification, 0)
+	for id, n := range q.notifications {
+		if len(res) == limit {
+			break
+		}
+
+		if n.Status == entities.NotificationQueued && !n.NextAttemptAt.After(time.Now()) {
+			n.NextAttemptAt = time.Now().Add(lease)
+			q.notifications[id] = n
+			res = append(res, n)
+		}
+	}
+
+	return res, nil
+}
+
+func (q *memoryQueue) Update(ctx context.Context, n entities.Notification) error {
+	q.notifications[n.ID] = n
+	return nil
+}
+
+func (q *memoryQueue) DeleteFinished(ctx context.Context, before time.Time) error {
+	q.deletedBefore = before
+	return nil
+}
+
+type recordingMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestNewWorker_Validation(t *testing.T) {
+	_, err := NewWorker(nil, &recordingMailer{}, config.ConfigNotifications{}, nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewWorker(newMemoryQueue(), &recordingMailer{}, config.ConfigNotifications{Interval: -1}, nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewWorker(newMemoryQueue(), &recordingMailer{}, config.ConfigNotifications{TemplatesDir: t.TempDir()}, nil)
+	assert.NoError(t, err, "an empty templates directory keeps the built-in templates")
+}
+
+func TestWorker_Deliver(t *testing.T) {
+	n := testNotification()
+	queue := newMemoryQueue(n)
+	m := &recordingMailer{}
+	w, err := NewWorker(queue, m, config.ConfigNotifications{}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Deliver(context.Background()))
+
+	require.Len(t, m.sent, 1)
+	assert.Equal(t, "owner@example.com", m.sent[0].To)
+	sent := queue.notifications[n.ID]
+	assert.Equal(t, entities.NotificationSent, sent.Status)
+	assert.Equal(t, 1, sent.Attempts)
+	assert.False(t, sent.SentAt.IsZero())
+
+	// sent notifications are not delivered again
+	require.NoError(t, w.Deliver(context.Background()))
+	assert.Len(t, m.sent, 1)
+}
+
+func TestWorker_Deliver_Retry(t *testing.T) {
+	n := testNotification()
+	queue := newMemoryQueue(n)
+	m := &recordingMailer{err: errors.New("connection refused")}
+	w, err := NewWorker(queue, m, config.ConfigNotifications{MaxAttempts: 2}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Deliver(context.Background()))
+	failed := queue.notifications[n.ID]
+	assert.Equal(t, entities.NotificationQueued, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "connection refused", failed.LastError)
+	assert.True(t, failed.NextAttemptAt.After(time.Now().Add(50*time.Second)), "the next attempt is delayed")
+
+	// the last attempt gives the notification up
+	failed.NextAttemptAt = time.Now()
+	queue.notifications[n.ID] = failed
+	require.NoError(t, w.Deliver(context.Background()))
+	assert.Equal(t, entities.NotificationFailed, queue.notifications[n.ID].Status)
+	assert.Equal(t, 2, queue.notifications[n.ID].Attempts)
+}
+
+func TestWorker_Run(t *testing.T) {
+	queue := newMemoryQueue(testNotification())
+	m := &recordingMailer{}
+	w, err := NewWorker(queue, m, config.ConfigNotifications{Retention: 3600}, nil)
+	require.NoError(t, err)
+
+	checked := make(chan struct{}, 1)
+	w.AddCheck("test", func(ctx context.Context) error {
+		checked <- struct{}{}
+		return errors.New("check errors are logged")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	<-checked
+	cancel()
+	<-done
+
+	assert.Len(t, m.sent, 1)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), queue.deletedBefore, time.Minute)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, retryDelay(1))
+	assert.Equal(t, 2*time.Minute, retryDelay(2))
+	assert.Equal(t, 32*time.Minute, retryDelay(6))
+	assert.Equal(t, maxRetryDelay, retryDelay(7))
+	assert.Equal(t, maxRetryDelay, retryDelay(100))
+}
@@ -103,6 +103,11 @@ func (a *AddrsRepo) Update(ctx context.Context, address entities.Address) error
 	}
 	evict(ctx, a.cache, addrIdKey(address.ID), addrEmailKey(address.Email))
 	evictPrefix(ctx, a.cache, addrListPrefix())
+	// cached chains embed the aliases and the protected addresses or webhooks mail is forwarded to,
+	// with their privacy filter settings, pgp keys, rules and webhook endpoints
+	if address.Type == entities.ProtectedAddress || address.Type == entities.AliasAddress || address.Type == entities.WebhookAddress {
+		evictPrefix(ctx, a.cache, "chain:")
+	}
 	return nil
 }
 
@@ -3,10 +3,15 @@ package cached
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/cache/drivers/redis"
+	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
 	gormdriver "github.com/Burmuley/ovoo/internal/repositories/drivers/gorm"
 )
@@ -241,6 +246,121 @@ func TestAddrsRepo_Update_EvictsCache(t *testing.T) {
 	assert.Equal(t, "after-update", byEmail[0].Metadata.Comment)
 }
 
+// Updating a protected address evicts the cached chains embedding it,
+// so a new pgp key is used for the next forwarded message.
+func TestAddrsRepo_Update_ProtectedEvictsChains(t *testing.T) {
+	db := newDB(t)
+	c := newMemoryCache(t)
+	ctx := context.Background()
+	rawU, err := gormdriver.NewUserGORMRepo(db)
+	require.NoError(t, err)
+	rawA, err := gormdriver.NewAddressGORMRepo(db)
+	require.NoError(t, err)
+	rawC, err := gormdriver.NewChainsGORMRepo(db)
+	require.NoError(t, err)
+	cachedAddrs, err := NewCachedAddrsRepo(c, rawA, &cacheCfg)
+	require.NoError(t, err)
+	cachedChains, err := NewCachedChainsRepo(c, rawC, &cacheCfg)
+	require.NoError(t, err)
+
+	chain := insertChain(t, rawC, insertUser(t, rawU))
+	cached, err := cachedChains.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	require.Nil(t, cached.ToAddress.PGPKey)
+
+	praddr := chain.ToAddress
+	praddr.PGPKey = &entities.PGPKey{Armored: "armored key", Fingerprint: "ABCDEF"}
+	require.NoError(t, cachedAddrs.Update(ctx, praddr))
+
+	result, err := cachedChains.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	require.NotNil(t, result.ToAddress.PGPKey)
+	assert.Equal(t, "ABCDEF", result.ToAddress.PGPKey.Fingerprint)
+}
+
+// Updating a webhook address evicts the cached chains forwarding to it,
+// so a new endpoint is used for the next delivered message.
+func TestAddrsRepo_Update_WebhookEvictsChains(t *testing.T) {
+	db := newDB(t)
+	c := newMemoryCache(t)
+	ctx := context.Background()
+	rawU, err := gormdriver.NewUserGORMRepo(db)
+	require.NoError(t, err)
+	rawA, err := gormdriver.NewAddressGORMRepo(db)
+	require.NoError(t, err)
+	rawC, err := gormdriver.NewChainsGORMRepo(db)
+	require.NoError(t, err)
+	cachedAddrs, err := NewCachedAddrsRepo(c, rawA, &cacheCfg)
+	require.NoError(t, err)
+	cachedChains, err := NewCachedChainsRepo(c, rawC, &cacheCfg)
+	require.NoError(t, err)
+
+	user := insertUser(t, rawU)
+	webhook := entities.Address{
+		ID:        entities.NewId(),
+		Type:      entities.WebhookAddress,
+		Owner:     user,
+		UpdatedBy: user,
+		Active:    true,
+		Webhook:   &entities.Webhook{URL: "https://hooks.example.com/mail", Secret: "secret"},
+	}
+	require.NoError(t, rawA.Create(ctx, webhook))
+
+	chain := insertChain(t, rawC, user)
+	chain.Hash = entities.NewHash(string(chain.OrigFromAddress.Email), "webhook-"+string(chain.OrigToAddress.Email))
+	chain.ToAddress = webhook
+	require.NoError(t, rawC.Create(ctx, chain))
+
+	cached, err := cachedChains.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	require.NotNil(t, cached.ToAddress.Webhook)
+	require.Equal(t, "https://hooks.example.com/mail", cached.ToAddress.Webhook.URL)
+
+	webhook.Webhook = &entities.Webhook{URL: "https://hooks.example.com/new", Secret: "secret"}
+	require.NoError(t, cachedAddrs.Update(ctx, webhook))
+
+	result, err := cachedChains.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	require.NotNil(t, result.ToAddress.Webhook)
+	assert.Equal(t, "https://hooks.example.com/new", result.ToAddress.Webhook.URL)
+}
+
+// With the tiered cache an update handled by one node evicts the entries
+// cached in memory of the other nodes.
+func TestAddrsRepo_Update_EvictsCacheOnAllNodes(t *testing.T) {
+	e := setupAddrsTest(t)
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	cfg := cacheCfg
+	cfg.Config.Redis = &config.ConfigCacheDriverRedis{Addr: &addr}
+
+	nodes := make([]*AddrsRepo, 2)
+	for i := range nodes {
+		local, err := memory.New()
+		require.NoError(t, err)
+		c, err := redis.NewTiered(cfg, local)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = c.Close() })
+		nodes[i], err = NewCachedAddrsRepo(c, e.rawAddrs, &cfg)
+		require.NoError(t, err)
+	}
+
+	owner := insertUser(t, e.rawUsers)
+	stored := insertAddress(t, e.rawAddrs, owner)
+	_, err := nodes[1].GetById(ctx, stored.ID)
+	require.NoError(t, err)
+
+	updated := stored
+	updated.Metadata.Comment = "after-update"
+	require.NoError(t, nodes[0].Update(ctx, updated))
+
+	assert.Eventually(t, func() bool {
+		result, err := nodes[1].GetById(ctx, stored.ID)
+		return err == nil && result.Metadata.Comment == "after-update"
+	}, time.Second, 5*time.Millisecond)
+}
+
 // --- DeleteById ---
 
 func TestAddrsRepo_DeleteById_NoCachedEntry(t *testing.T) {
@@ -96,3 +96,12 @@ func (c *ChainsRepo) BatchDelete(ctx context.Context, cuser entities.User, hashe
 	evictPrefix(ctx, c.cache, "chain:")
 	return nil
 }
+
+func (c *ChainsRepo) Update(ctx context.Context, chain entities.Chain) error {
+	if err := c.repo.Update(ctx, chain); err != nil {
+		return err
+	}
+	evict(ctx, c.cache, chainHashKey(chain.Hash))
+	evictPrefix(ctx, c.cache, chainListPrefix())
+	return nil
+}
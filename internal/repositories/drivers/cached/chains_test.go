@@ -153,6 +153,28 @@ func TestChainsRepo_BatchCreate_EvictsAll(t *testing.T) {
 	assert.Len(t, result, 2)
 }
 
+// --- Update ---
+
+func TestChainsRepo_Update_EvictsHash(t *testing.T) {
+	e := setupChainsTest(t)
+	ctx := context.Background()
+	user := insertUser(t, e.rawUsers)
+	chain := insertChain(t, e.rawChains, user)
+
+	cachedChain, err := e.cachedChains.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	require.False(t, cachedChain.Quarantined)
+
+	chain.Quarantined = true
+	chain.UpdatedBy = user
+	require.NoError(t, e.cachedChains.Update(ctx, chain))
+
+	// Hash-key evicted: the updated chain is read from the DB.
+	result, err := e.cachedChains.GetByHash(ctx, chain.Hash)
+	assert.NoError(t, err)
+	assert.True(t, result.Quarantined)
+}
+
 // --- Delete ---
 
 func TestChainsRepo_Delete_Success(t *testing.T) {
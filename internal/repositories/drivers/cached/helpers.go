@@ -6,10 +6,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/Burmuley/ovoo/internal/cache"
 	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func durationSeconds(d int) time.Duration {
@@ -17,21 +21,33 @@ func durationSeconds(d int) time.Duration {
 }
 
 // getFromCache deserializes a cached value into T. Returns (zero, false) on any miss or error.
+// Hits and misses are recorded in the metrics by the entity namespace of the key, e.g. "addr",
+// and as events of the current span.
 func getFromCache[T any](ctx context.Context, c cache.Cache, key string) (T, bool) {
 	var zero T
+	entity, _, _ := strings.Cut(key, ":")
 	data, err := c.Get(ctx, key)
 	if err != nil {
 		slog.Debug("error getting value from cache", "error", err.Error())
+		observeCache(ctx, entity, metrics.CacheMiss)
 		return zero, false
 	}
 	var result T
 	if err := json.Unmarshal(data, &result); err != nil {
 		slog.Debug("error unmarshaling cache value", "error", err.Error())
+		observeCache(ctx, entity, metrics.CacheMiss)
 		return zero, false
 	}
+	observeCache(ctx, entity, metrics.CacheHit)
 	return result, true
 }
 
+// observeCache records the result of a cache lookup of the entity.
+func observeCache(ctx context.Context, entity, result string) {
+	metrics.ObserveCacheRequest(entity, result)
+	trace.SpanFromContext(ctx).AddEvent("cache "+result, trace.WithAttributes(attribute.String("cache.entity", entity)))
+}
+
 // setInCache serializes val and stores it; errors are silently ignored so callers
 // always receive the value that was already fetched from the underlying repo.
 func setInCache[T any](ctx context.Context, c cache.Cache, key string, val T, ttl time.Duration) {
@@ -148,3 +164,19 @@ func customDomainListPrefix() string { return "domain:list" }
 func customDomainKeyList(filter entities.CustomDomainFilter) string {
 	return filterKey(customDomainListPrefix(), filter)
 }
+
+// --- Role key builders ---
+
+func roleIdKey(id entities.Id) string {
+	return "role:id:" + id.String()
+}
+
+func roleNameKey(name string) string {
+	return "role:name:" + name
+}
+
+func roleListPrefix() string { return "role:list:" }
+
+func roleListKey(filter entities.RoleFilter) string {
+	return filterKey(roleListPrefix(), filter)
+}
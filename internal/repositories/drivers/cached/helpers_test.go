@@ -2,6 +2,7 @@ package cached
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -254,3 +255,37 @@ func insertToken(t *testing.T, repo repositories.TokensReadWriter, owner entitie
 	require.NoError(t, repo.Create(context.Background(), tok))
 	return tok
 }
+
+type rolesTestEnv struct {
+	rawUsers    repositories.UsersReadWriter
+	rawRoles    repositories.RolesReadWriter
+	cachedUsers *UsersRepo
+	cachedRoles *RolesRepo
+}
+
+func setupRolesTest(t *testing.T) rolesTestEnv {
+	t.Helper()
+	db := newDB(t)
+	c := newMemoryCache(t)
+	rawU, err := gormrepo.NewUserGORMRepo(db)
+	require.NoError(t, err)
+	rawR, err := gormrepo.NewRoleGORMRepo(db)
+	require.NoError(t, err)
+	cu, err := NewCachedUsersRepo(c, rawU, &cacheCfg)
+	require.NoError(t, err)
+	cr, err := NewCachedRolesRepo(c, rawR, &cacheCfg)
+	require.NoError(t, err)
+	return rolesTestEnv{rawU, rawR, cu, cr}
+}
+
+func insertRole(t *testing.T, repo repositories.RolesReadWriter, perms ...entities.Permission) entities.Role {
+	t.Helper()
+	id := entities.NewId()
+	r := entities.Role{
+		ID:          id,
+		Name:        "role-" + strings.ToLower(string(id)),
+		Permissions: perms,
+	}
+	require.NoError(t, repo.Create(context.Background(), r))
+	return r
+}
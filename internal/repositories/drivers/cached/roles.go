@@ -0,0 +1,128 @@
+package cached
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Burmuley/ovoo/internal/cache"
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories"
+)
+
+type RolesRepo struct {
+	cache  cache.Cache
+	config *config.ConfigCache
+	repo   repositories.RolesReadWriter
+}
+
+func NewCachedRolesRepo(cache cache.Cache, repo repositories.RolesReadWriter, config *config.ConfigCache) (*RolesRepo, error) {
+	if cache == nil {
+		return nil, fmt.Errorf("%w: cache instance can not be empty", entities.ErrValidation)
+	}
+
+	if repo == nil {
+		return nil, fmt.Errorf("%w: repository instance can not be empty", entities.ErrValidation)
+	}
+
+	if config == nil {
+		return nil, fmt.Errorf("%w: cache config can not be empty", entities.ErrValidation)
+	}
+
+	return &RolesRepo{
+		cache:  cache,
+		repo:   repo,
+		config: config,
+	}, nil
+}
+
+type roleListResult struct {
+	Roles []entities.Role             `json:"roles"`
+	Meta  entities.PaginationMetadata `json:"meta"`
+}
+
+func (r RolesRepo) GetById(ctx context.Context, id entities.Id) (entities.Role, error) {
+	key := roleIdKey(id)
+	if role, ok := getFromCache[entities.Role](ctx, r.cache, key); ok {
+		return role, nil
+	}
+	role, err := r.repo.GetById(ctx, id)
+	if err != nil {
+		return entities.Role{}, err
+	}
+	setInCache(ctx, r.cache, key, role, durationSeconds(r.config.SingleItemTTL))
+	return role, nil
+}
+
+func (r RolesRepo) GetByName(ctx context.Context, name string) (entities.Role, error) {
+	key := roleNameKey(name)
+	if role, ok := getFromCache[entities.Role](ctx, r.cache, key); ok {
+		return role, nil
+	}
+	role, err := r.repo.GetByName(ctx, name)
+	if err != nil {
+		return entities.Role{}, err
+	}
+	setInCache(ctx, r.cache, key, role, durationSeconds(r.config.SingleItemTTL))
+	return role, nil
+}
+
+func (r RolesRepo) GetAll(ctx context.Context, filter entities.RoleFilter) ([]entities.Role, entities.PaginationMetadata, error) {
+	key := roleListKey(filter)
+	if result, ok := getFromCache[roleListResult](ctx, r.cache, key); ok {
+		return result.Roles, result.Meta, nil
+	}
+	roles, meta, err := r.repo.GetAll(ctx, filter)
+	if err != nil {
+		return nil, entities.PaginationMetadata{}, err
+	}
+	setInCache(ctx, r.cache, key, roleListResult{Roles: roles, Meta: meta}, durationSeconds(r.config.ListTTL))
+	return roles, meta, nil
+}
+
+func (r RolesRepo) Create(ctx context.Context, role entities.Role) error {
+	if err := r.repo.Create(ctx, role); err != nil {
+		return err
+	}
+	evictPrefix(ctx, r.cache, roleListPrefix())
+	return nil
+}
+
+func (r RolesRepo) Update(ctx context.Context, role entities.Role) (entities.Role, error) {
+	// the old name key has to be evicted as well when the role is renamed
+	old, hasOld := getFromCache[entities.Role](ctx, r.cache, roleIdKey(role.ID))
+
+	var err error
+	if role, err = r.repo.Update(ctx, role); err != nil {
+		return entities.Role{}, err
+	}
+	evict(ctx, r.cache, roleIdKey(role.ID), roleNameKey(role.Name))
+	if hasOld {
+		evict(ctx, r.cache, roleNameKey(old.Name))
+	}
+	evictPrefix(ctx, r.cache, roleListPrefix())
+	r.evictDependents(ctx)
+	return role, nil
+}
+
+func (r RolesRepo) Delete(ctx context.Context, cuser entities.User, id entities.Id) error {
+	role, err := r.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.repo.Delete(ctx, cuser, id); err != nil {
+		return err
+	}
+	evict(ctx, r.cache, roleIdKey(id), roleNameKey(role.Name))
+	evictPrefix(ctx, r.cache, roleListPrefix())
+	r.evictDependents(ctx)
+	return nil
+}
+
+// evictDependents drops cached users and tokens, since they embed
+// the permissions of the role assigned to the user.
+func (r RolesRepo) evictDependents(ctx context.Context) {
+	evictPrefix(ctx, r.cache, "user:")
+	evictPrefix(ctx, r.cache, "token:")
+}
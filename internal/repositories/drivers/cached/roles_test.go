@@ -0,0 +1,94 @@
+package cached
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+// --- Constructor ---
+
+func TestNewCachedRolesRepo_NilCache(t *testing.T) {
+	e := setupRolesTest(t)
+	repo, err := NewCachedRolesRepo(nil, e.rawRoles, &cacheCfg)
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	assert.Nil(t, repo)
+}
+
+func TestNewCachedRolesRepo_NilRepo(t *testing.T) {
+	repo, err := NewCachedRolesRepo(newMemoryCache(t), nil, &cacheCfg)
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	assert.Nil(t, repo)
+}
+
+// --- GetById ---
+
+// CacheHit: a backstage update via rawRoles is invisible to the cached repo.
+func TestRolesRepo_GetById_CacheHit(t *testing.T) {
+	e := setupRolesTest(t)
+	ctx := context.Background()
+	role := insertRole(t, e.rawRoles, entities.PermAliasesRead)
+
+	_, err := e.cachedRoles.GetById(ctx, role.ID)
+	require.NoError(t, err)
+
+	modified := role
+	modified.Permissions = []entities.Permission{entities.PermRolesRead}
+	_, err = e.rawRoles.Update(ctx, modified)
+	require.NoError(t, err)
+
+	result, err := e.cachedRoles.GetById(ctx, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, role.Permissions, result.Permissions)
+}
+
+// --- Update ---
+
+func TestRolesRepo_Update_EvictsRoleAndUsers(t *testing.T) {
+	e := setupRolesTest(t)
+	ctx := context.Background()
+	role := insertRole(t, e.rawRoles, entities.PermAliasesRead)
+	user := insertUser(t, e.rawUsers)
+	user.Role = &role
+	require.NoError(t, e.rawUsers.Update(ctx, user))
+
+	// warm both caches
+	_, err := e.cachedRoles.GetById(ctx, role.ID)
+	require.NoError(t, err)
+	cachedUser, err := e.cachedUsers.GetById(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, cachedUser.Role)
+
+	role.Permissions = []entities.Permission{entities.PermAliasesReadAll}
+	_, err = e.cachedRoles.Update(ctx, role)
+	require.NoError(t, err)
+
+	result, err := e.cachedRoles.GetById(ctx, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, role.Permissions, result.Permissions)
+
+	cachedUser, err = e.cachedUsers.GetById(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, cachedUser.Role)
+	assert.Equal(t, role.Permissions, cachedUser.Role.Permissions)
+}
+
+// --- Delete ---
+
+func TestRolesRepo_Delete_EvictsCache(t *testing.T) {
+	e := setupRolesTest(t)
+	ctx := context.Background()
+	role := insertRole(t, e.rawRoles)
+
+	_, err := e.cachedRoles.GetByName(ctx, role.Name)
+	require.NoError(t, err)
+
+	require.NoError(t, e.cachedRoles.Delete(ctx, entities.User{ID: entities.NewId()}, role.ID))
+
+	_, err = e.cachedRoles.GetByName(ctx, role.Name)
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+}
@@ -50,6 +50,11 @@ func (t *TokensRepo) GetById(ctx context.Context, tokenId entities.Id) (entities
 }
 
 func (t *TokensRepo) GetAll(ctx context.Context, filter entities.ApiTokenFilter) ([]entities.ApiToken, error) {
+	// expiry scans cover the tokens of all users and are never repeated with the same time
+	if filter.ExpiresBefore != nil {
+		return t.repo.GetAll(ctx, filter)
+	}
+
 	key := tokenUserListKey(filter)
 	if tokens, ok := getFromCache[[]entities.ApiToken](ctx, t.cache, key); ok {
 		return tokens, nil
@@ -102,6 +102,8 @@ func (u *UsersRepo) Update(ctx context.Context, user entities.User) error {
 	}
 	evict(ctx, u.cache, userIdKey(user.ID), userLoginKey(user.Login))
 	evictPrefix(ctx, u.cache, userListPrefix())
+	// cached chains embed the address owners with their privacy filter defaults
+	evictPrefix(ctx, u.cache, "chain:")
 	return nil
 }
 
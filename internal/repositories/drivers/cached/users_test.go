@@ -269,3 +269,31 @@ func TestUsersRepo_Delete_LoginCacheStaleWhenIdNotCached(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, user.ID, byLogin.ID)
 }
+
+// Cached chains embed the address owners, updating a user evicts them.
+func TestUsersRepo_Update_EvictsChains(t *testing.T) {
+	db := newDB(t)
+	c := newMemoryCache(t)
+	ctx := context.Background()
+	rawU, err := gormdriver.NewUserGORMRepo(db)
+	require.NoError(t, err)
+	rawC, err := gormdriver.NewChainsGORMRepo(db)
+	require.NoError(t, err)
+	cachedUsers, err := NewCachedUsersRepo(c, rawU, &cacheCfg)
+	require.NoError(t, err)
+	cachedChains, err := NewCachedChainsRepo(c, rawC, &cacheCfg)
+	require.NoError(t, err)
+
+	user := insertUser(t, rawU)
+	chain := insertChain(t, rawC, user)
+	cached, err := cachedChains.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	require.False(t, cached.OrigToAddress.Owner.PrivacyFilter)
+
+	user.PrivacyFilter = true
+	require.NoError(t, cachedUsers.Update(ctx, user))
+
+	result, err := cachedChains.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	assert.True(t, result.OrigToAddress.Owner.PrivacyFilter)
+}
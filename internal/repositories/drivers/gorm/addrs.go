@@ -181,6 +181,8 @@ func (a *AddressGORMRepo) GetAll(ctx context.Context, filter entities.AddressFil
 //   - Ids, Emails, Types, Owners, ForwardAddressIds — IN-list predicates.
 //   - ServiceNames — per-value OR LIKE against metadata.service_name (JSON).
 //   - Active — equality predicate; skipped when nil.
+//   - KeyExpiresBefore — addresses with a pgp key expiring before the time; skipped when nil.
+//   - Leaked — aliases flagged as possibly leaked, or the ones which are not; skipped when nil.
 //   - Search — wildcard OR-group across email, metadata.service_name, and
 //     metadata.comment; isolated in a sub-session to preserve correct grouping.
 //   - Page / PageSize — Limit+Offset pagination, applied only when both are > 0.
@@ -220,6 +222,26 @@ func applyAddressFilter(stmt *gorm.DB, filter entities.AddressFilter, doCount bo
 		stmt.Where("active = ?", *filter.Active)
 	}
 
+	if filter.KeyExpiresBefore != nil {
+		stmt.Where("pgp_key_expires_at < ?", *filter.KeyExpiresBefore)
+	}
+
+	if filter.Leaked != nil {
+		if *filter.Leaked {
+			stmt.Where("leak_detected_at IS NOT NULL")
+		} else {
+			stmt.Where("leak_detected_at IS NULL")
+		}
+	}
+
+	if len(filter.Domains) > 0 {
+		group := stmt.Session(&gorm.Session{NewDB: true})
+		for _, domain := range filter.Domains {
+			group = group.Or("email LIKE ?", "%@"+domain)
+		}
+		stmt.Where(group)
+	}
+
 	if filter.Search != "" {
 		pattern := "%" + filter.Search + "%"
 		group := stmt.Session(&gorm.Session{NewDB: true}).
@@ -209,6 +209,212 @@ func TestAddressGORMRepo_Update(t *testing.T) {
 	assert.Equal(t, "UpdatedService", retrieved.Metadata.ServiceName)
 }
 
+func TestAddressGORMRepo_Update_Pending(t *testing.T) {
+	repo, user := setupAddressTestDB(t)
+	ctx := context.Background()
+
+	address := entities.Address{
+		ID:        entities.NewId(),
+		Type:      entities.ProtectedAddress,
+		Email:     entities.Email("pending@example.com"),
+		Owner:     user,
+		UpdatedBy: user,
+		Active:    true,
+		Pending:   true,
+	}
+	require.NoError(t, repo.Create(ctx, address))
+
+	retrieved, err := repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	assert.True(t, retrieved.Pending)
+
+	// confirming the address
+	address.Pending = false
+	require.NoError(t, repo.Update(ctx, address))
+
+	retrieved, err = repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	assert.False(t, retrieved.Pending)
+}
+
+func TestAddressGORMRepo_Update_PGPKey(t *testing.T) {
+	repo, user := setupAddressTestDB(t)
+	ctx := context.Background()
+
+	expires := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	address := entities.Address{
+		ID:        entities.NewId(),
+		Type:      entities.ProtectedAddress,
+		Email:     entities.Email("pgp@example.com"),
+		Owner:     user,
+		UpdatedBy: user,
+		Active:    true,
+		PGPKey:    &entities.PGPKey{Armored: "armored key", Fingerprint: "ABCDEF", ExpiresAt: expires},
+	}
+	require.NoError(t, repo.Create(ctx, address))
+
+	retrieved, err := repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved.PGPKey)
+	assert.Equal(t, "armored key", retrieved.PGPKey.Armored)
+	assert.Equal(t, "ABCDEF", retrieved.PGPKey.Fingerprint)
+	assert.True(t, expires.Equal(retrieved.PGPKey.ExpiresAt))
+
+	addrs, _, err := repo.GetAll(ctx, entities.AddressFilter{KeyExpiresBefore: new(expires.Add(time.Hour))})
+	require.NoError(t, err)
+	assert.Len(t, addrs, 1)
+
+	addrs, _, err = repo.GetAll(ctx, entities.AddressFilter{KeyExpiresBefore: new(expires.Add(-time.Hour))})
+	require.NoError(t, err)
+	assert.Empty(t, addrs)
+
+	// removing the key
+	address.PGPKey = nil
+	require.NoError(t, repo.Update(ctx, address))
+
+	retrieved, err = repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	assert.Nil(t, retrieved.PGPKey)
+}
+
+func TestAddressGORMRepo_Update_PrivacyFilter(t *testing.T) {
+	repo, user := setupAddressTestDB(t)
+	ctx := context.Background()
+
+	address := entities.Address{
+		ID:        entities.NewId(),
+		Type:      entities.AliasAddress,
+		Email:     entities.Email("alias@example.com"),
+		Owner:     user,
+		UpdatedBy: user,
+		Active:    true,
+	}
+	require.NoError(t, repo.Create(ctx, address))
+
+	retrieved, err := repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	assert.Nil(t, retrieved.PrivacyFilter)
+
+	address.PrivacyFilter = new(false)
+	require.NoError(t, repo.Update(ctx, address))
+
+	retrieved, err = repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved.PrivacyFilter)
+	assert.False(t, *retrieved.PrivacyFilter)
+
+	// clearing the setting inherits the owner default again
+	address.PrivacyFilter = nil
+	require.NoError(t, repo.Update(ctx, address))
+
+	retrieved, err = repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	assert.Nil(t, retrieved.PrivacyFilter)
+}
+
+func TestAddressGORMRepo_Update_Rules(t *testing.T) {
+	repo, user := setupAddressTestDB(t)
+	ctx := context.Background()
+
+	address := entities.Address{
+		ID:        entities.NewId(),
+		Type:      entities.AliasAddress,
+		Email:     entities.Email("alias@example.com"),
+		Owner:     user,
+		UpdatedBy: user,
+		Active:    true,
+		Rules:     &entities.Rules{Script: `if header :contains "subject" "sale" { discard; }`},
+	}
+	require.NoError(t, repo.Create(ctx, address))
+
+	retrieved, err := repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved.Rules)
+	assert.Equal(t, address.Rules.Script, retrieved.Rules.Script)
+
+	// removing the rules
+	address.Rules = nil
+	require.NoError(t, repo.Update(ctx, address))
+
+	retrieved, err = repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	assert.Nil(t, retrieved.Rules)
+}
+
+func TestAddressGORMRepo_Create_Webhook(t *testing.T) {
+	repo, user := setupAddressTestDB(t)
+	ctx := context.Background()
+
+	webhook := entities.Address{
+		ID:        entities.NewId(),
+		Type:      entities.WebhookAddress,
+		Owner:     user,
+		UpdatedBy: user,
+		Active:    true,
+		Webhook:   &entities.Webhook{URL: "https://hooks.example.com/mail", Secret: "secret"},
+	}
+	require.NoError(t, repo.Create(ctx, webhook))
+
+	alias := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          entities.Email("alias@example.com"),
+		ForwardAddress: &webhook,
+		Owner:          user,
+		UpdatedBy:      user,
+		Active:         true,
+	}
+	require.NoError(t, repo.Create(ctx, alias))
+
+	retrieved, err := repo.GetById(ctx, alias.ID)
+	require.NoError(t, err)
+	require.NotNil(t, retrieved.ForwardAddress)
+	assert.Equal(t, entities.WebhookAddress, retrieved.ForwardAddress.Type)
+	require.NotNil(t, retrieved.ForwardAddress.Webhook)
+	assert.Equal(t, *webhook.Webhook, *retrieved.ForwardAddress.Webhook)
+}
+
+func TestAddressGORMRepo_Update_Leak(t *testing.T) {
+	repo, user := setupAddressTestDB(t)
+	ctx := context.Background()
+
+	address := entities.Address{
+		ID:            entities.NewId(),
+		Type:          entities.AliasAddress,
+		Email:         entities.Email("alias@example.com"),
+		Owner:         user,
+		UpdatedBy:     user,
+		Active:        true,
+		SenderDomains: []string{"shop.com"},
+	}
+	require.NoError(t, repo.Create(ctx, address))
+
+	detected := time.Now().UTC().Truncate(time.Second)
+	address.Leak = &entities.AliasLeak{Sender: "spam@spam.com", DetectedAt: detected}
+	address.LeakRestrict = true
+	require.NoError(t, repo.Update(ctx, address))
+
+	retrieved, err := repo.GetById(ctx, address.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shop.com"}, retrieved.SenderDomains)
+	assert.True(t, retrieved.LeakRestrict)
+	require.NotNil(t, retrieved.Leak)
+	assert.Equal(t, entities.Email("spam@spam.com"), retrieved.Leak.Sender)
+	assert.True(t, detected.Equal(retrieved.Leak.DetectedAt))
+
+	leaked, _, err := repo.GetAll(ctx, entities.AddressFilter{Leaked: new(true)})
+	require.NoError(t, err)
+	assert.Len(t, leaked, 1)
+
+	// resolving the leak
+	address.Leak = nil
+	require.NoError(t, repo.Update(ctx, address))
+
+	leaked, _, err = repo.GetAll(ctx, entities.AddressFilter{Leaked: new(true)})
+	require.NoError(t, err)
+	assert.Empty(t, leaked)
+}
+
 func TestAddressGORMRepo_DeleteById(t *testing.T) {
 	repo, user := setupAddressTestDB(t)
 	ctx := context.Background()
@@ -558,6 +764,54 @@ func TestAddressGORMRepo_GetAll_FilterByEmails(t *testing.T) {
 	assert.Equal(t, 1, metadata.TotalRecords)
 }
 
+func TestAddressGORMRepo_GetAll_FilterByDomains(t *testing.T) {
+	repo, user := setupAddressTestDB(t)
+	ctx := context.Background()
+
+	addresses := []entities.Address{
+		{
+			ID:        entities.NewId(),
+			Type:      entities.AliasAddress,
+			Email:     entities.Email("alpha@example.com"),
+			Owner:     user,
+			UpdatedBy: user,
+		},
+		{
+			ID:        entities.NewId(),
+			Type:      entities.AliasAddress,
+			Email:     entities.Email("beta@example.org"),
+			Owner:     user,
+			UpdatedBy: user,
+		},
+		{
+			ID:        entities.NewId(),
+			Type:      entities.ProtectedAddress,
+			Email:     entities.Email("gamma@example.net"),
+			Owner:     user,
+			UpdatedBy: user,
+		},
+	}
+
+	err := repo.BatchCreate(ctx, addresses)
+	require.NoError(t, err)
+
+	filter := entities.AddressFilter{
+		Filter: entities.Filter{
+			Page:     1,
+			PageSize: 10,
+		},
+		Types:   []entities.AddressType{entities.AliasAddress},
+		Domains: []string{"example.com", "example.net"},
+	}
+
+	retrieved, metadata, err := repo.GetAll(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, retrieved, 1)
+	assert.Equal(t, entities.Email("alpha@example.com"), retrieved[0].Email)
+	assert.Equal(t, 1, metadata.TotalRecords)
+}
+
 func TestAddressGORMRepo_GetAll_FilterByIds(t *testing.T) {
 	repo, user := setupAddressTestDB(t)
 	ctx := context.Background()
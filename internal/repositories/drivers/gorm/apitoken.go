@@ -106,7 +106,7 @@ func (t *TokenGORMRepo) BatchDeleteForUser(ctx context.Context, cuser entities.U
 // Returns the token as an entity and an error if the token doesn't exist or if the query fails.
 func (t *TokenGORMRepo) GetById(ctx context.Context, token_id entities.Id) (entities.ApiToken, error) {
 	token := ApiToken{}
-	if err := t.db.WithContext(ctx).Model(&ApiToken{}).Where("id = ?", token_id).Preload(clause.Associations).First(&token).Error; err != nil {
+	if err := t.db.WithContext(ctx).Model(&ApiToken{}).Where("id = ?", token_id).Preload(clause.Associations).Preload("Owner.Role").First(&token).Error; err != nil {
 		return entities.ApiToken{}, wrapGormError(err)
 	}
 
@@ -119,18 +119,24 @@ func (t *TokenGORMRepo) GetById(ctx context.Context, token_id entities.Id) (enti
 // Returns a slice of API token entities and an error if the query fails.
 func (t *TokenGORMRepo) GetAll(ctx context.Context, filter entities.ApiTokenFilter) ([]entities.ApiToken, error) {
 	gorm_tokens := make([]ApiToken, 0)
-	if len(filter.UserIds) == 0 {
+	if len(filter.UserIds) == 0 && filter.ExpiresBefore == nil {
 		return nil, fmt.Errorf("%w: at least one user id has to be defined", entities.ErrValidation)
 	}
-	uids := make([]string, 0, len(filter.UserIds))
-	for _, val := range filter.UserIds {
-		uids = append(uids, val.String())
+	stmt := t.db.WithContext(ctx).Model(&ApiToken{})
+	if len(filter.UserIds) > 0 {
+		uids := make([]string, 0, len(filter.UserIds))
+		for _, val := range filter.UserIds {
+			uids = append(uids, val.String())
+		}
+		stmt = stmt.Where("owner_id IN ?", uids)
+	}
+	if filter.ExpiresBefore != nil {
+		stmt = stmt.Where("expiration < ?", *filter.ExpiresBefore)
 	}
-	stmt := t.db.WithContext(ctx).Model(&ApiToken{}).Where("owner_id IN ?", uids)
 	if filter.Active != nil {
 		stmt = stmt.Where("active = ?", *filter.Active)
 	}
-	if err := stmt.Preload(clause.Associations).Find(&gorm_tokens).Error; err != nil {
+	if err := stmt.Preload(clause.Associations).Preload("Owner.Role").Find(&gorm_tokens).Error; err != nil {
 		return nil, wrapGormError(err)
 	}
 
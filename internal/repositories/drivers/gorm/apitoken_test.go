@@ -302,6 +302,34 @@ func TestTokenGORMRepo_GetById(t *testing.T) {
 	assert.Equal(t, user.ID, retrieved.UpdatedBy.ID)
 }
 
+func TestTokenGORMRepo_GetById_Scoped(t *testing.T) {
+	repo, user := setupTokenTestDB(t)
+	ctx := context.Background()
+
+	token := entities.ApiToken{
+		ID:         entities.NewId(),
+		Name:       "Scoped Token",
+		TokenHash:  "hashvalue123",
+		Salt:       "saltvalue",
+		Owner:      user,
+		Expiration: time.Now().Add(24 * time.Hour),
+		Active:     true,
+		UpdatedBy:  user,
+		Scopes:     []entities.Permission{entities.PermAliasesRead, entities.PermChainsCreate},
+		Domains:    []string{"example.com"},
+	}
+
+	err := repo.Create(ctx, token)
+	require.NoError(t, err)
+
+	retrieved, err := repo.GetById(ctx, token.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, token.Scopes, retrieved.Scopes)
+	assert.Equal(t, token.Domains, retrieved.Domains)
+	assert.NotNil(t, retrieved.Scope())
+}
+
 func TestTokenGORMRepo_GetById_NotFound(t *testing.T) {
 	repo, _ := setupTokenTestDB(t)
 	ctx := context.Background()
@@ -372,6 +400,36 @@ func TestTokenGORMRepo_GetAllForUser_EmptyUserIds(t *testing.T) {
 	assert.Nil(t, retrieved)
 }
 
+func TestTokenGORMRepo_GetAll_ExpiresBefore(t *testing.T) {
+	repo, user := setupTokenTestDB(t)
+	ctx := context.Background()
+
+	soon := entities.ApiToken{
+		ID:         entities.NewId(),
+		Name:       "Soon",
+		TokenHash:  "hash1",
+		Salt:       "salt1",
+		Owner:      user,
+		Expiration: time.Now().Add(24 * time.Hour),
+		Active:     true,
+		UpdatedBy:  user,
+	}
+	later := soon
+	later.ID = entities.NewId()
+	later.Name = "Later"
+	later.TokenHash = "hash2"
+	later.Expiration = time.Now().Add(30 * 24 * time.Hour)
+	require.NoError(t, repo.BatchCreate(ctx, []entities.ApiToken{soon, later}))
+
+	// the tokens of all users are selected without user ids
+	retrieved, err := repo.GetAll(ctx, entities.ApiTokenFilter{ExpiresBefore: new(time.Now().Add(7 * 24 * time.Hour))})
+
+	require.NoError(t, err)
+	require.Len(t, retrieved, 1)
+	assert.Equal(t, soon.ID, retrieved[0].ID)
+	assert.Equal(t, user.ID, retrieved[0].Owner.ID)
+}
+
 func TestTokenGORMRepo_GetAllForUser_MultipleUsers(t *testing.T) {
 	repo, user := setupTokenTestDB(t)
 	ctx := context.Background()
@@ -128,6 +128,21 @@ func (c *ChainsGORMRepo) BatchDelete(ctx context.Context, cuser entities.User, h
 	return nil
 }
 
+// Update saves the quarantine state of the chain, the addresses of the chain are not changed.
+func (c *ChainsGORMRepo) Update(ctx context.Context, chain entities.Chain) error {
+	res := c.db.WithContext(ctx).Model(&Chain{}).Where("hash = ?", chain.Hash.String()).
+		Updates(map[string]any{"quarantined": chain.Quarantined, "updated_by_id": chain.UpdatedBy.ID.String()})
+	if res.Error != nil {
+		return wrapGormError(res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: chain %s", entities.ErrNotFound, chain.Hash)
+	}
+
+	return nil
+}
+
 func applyChainFilter(stmt *gorm.DB, filter entities.ChainFilter) {
 	if len(filter.OrigFromAddrIds) > 0 {
 		stmt.Where("orig_from_address_id IN ?", filter.OrigFromAddrIds)
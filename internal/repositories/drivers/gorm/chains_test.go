@@ -455,3 +455,22 @@ func TestApplyChainFilter_WithPagination(t *testing.T) {
 		applyChainFilter(stmt, filter)
 	})
 }
+
+func TestChainsGORMRepo_Update(t *testing.T) {
+	repo, user := setupChainsTestDB(t)
+	ctx := context.Background()
+
+	chain := createTestChain(user)
+	chain.Quarantined = true
+	require.NoError(t, repo.Create(ctx, chain))
+
+	chain.Quarantined = false
+	require.NoError(t, repo.Update(ctx, chain))
+
+	got, err := repo.GetByHash(ctx, chain.Hash)
+	require.NoError(t, err)
+	assert.False(t, got.Quarantined)
+
+	err = repo.Update(ctx, entities.Chain{Hash: "nonexistent", UpdatedBy: user})
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+}
@@ -46,7 +46,15 @@ func NewDatabase(config config.ConfigDB) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	if err := gdb.AutoMigrate(&User{}, &ApiToken{}, &Address{}, &Chain{}, &CustomDomain{}); err != nil {
+	if err := registerMetricsCallbacks(gdb); err != nil {
+		return nil, fmt.Errorf("registering metrics callbacks: %w", err)
+	}
+
+	if err := registerTracingCallbacks(gdb); err != nil {
+		return nil, fmt.Errorf("registering tracing callbacks: %w", err)
+	}
+
+	if err := gdb.AutoMigrate(&Role{}, &User{}, &ApiToken{}, &Address{}, &Chain{}, &CustomDomain{}, &Notification{}, &QuarantinedMessage{}, &SenderReputation{}); err != nil {
 		return nil, err
 	}
 
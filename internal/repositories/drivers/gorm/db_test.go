@@ -1,12 +1,20 @@
 package gorm
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 func TestNewGORMDatabase_SQLite(t *testing.T) {
@@ -128,3 +136,67 @@ func TestNewGORMDatabase_DefaultLogLevel(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, db)
 }
+
+func TestNewGORMDatabase_RecordsQueryMetrics(t *testing.T) {
+	config := config.ConfigDB{
+		Driver:   "gorm",
+		LogLevel: "silent",
+		Config: config.ConfigDBDriver{
+			GORM: config.ConfigDBDriverGORM{
+				Driver:           "sqlite",
+				ConnectionString: ":memory:",
+			},
+		},
+	}
+
+	db, err := NewDatabase(config)
+	require.NoError(t, err)
+
+	users := []User{}
+	require.NoError(t, db.Find(&users).Error)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `ovoo_db_query_duration_seconds_count{operation="query"}`)
+}
+
+func TestNewGORMDatabase_TracesQueries(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	config := config.ConfigDB{
+		Driver:   "gorm",
+		LogLevel: "silent",
+		Config: config.ConfigDBDriver{
+			GORM: config.ConfigDBDriverGORM{
+				Driver:           "sqlite",
+				ConnectionString: ":memory:",
+			},
+		},
+	}
+
+	db, err := NewDatabase(config)
+	require.NoError(t, err)
+	exporter.Reset() // spans of the migrations
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "service")
+	users := []User{}
+	require.NoError(t, db.WithContext(ctx).Where("login = ?", "secret").Find(&users).Error)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "db.query", span.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+
+	attrs := map[string]string{}
+	for _, a := range span.Attributes {
+		attrs[string(a.Key)] = a.Value.Emit()
+	}
+	assert.Equal(t, "users", attrs["db.collection.name"])
+	assert.Contains(t, attrs["db.query.text"], "login = ?")
+	assert.NotContains(t, attrs["db.query.text"], "secret")
+}
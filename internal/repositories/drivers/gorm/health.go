@@ -0,0 +1,39 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// HealthGORMRepo checks the connection of the GORM database.
+type HealthGORMRepo struct {
+	db *gorm.DB
+}
+
+// NewHealthGORMRepo creates a new instance of HealthGORMRepo.
+// It returns an error if the provided database connection is nil.
+func NewHealthGORMRepo(db *gorm.DB) (repositories.DatabasePinger, error) {
+	if db == nil {
+		return &HealthGORMRepo{}, fmt.Errorf("%w: database can not be nil", entities.ErrConfiguration)
+	}
+
+	return &HealthGORMRepo{db: db}, nil
+}
+
+// Ping checks that the database is reachable.
+func (r *HealthGORMRepo) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return wrapGormError(err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return wrapGormError(err)
+	}
+
+	return nil
+}
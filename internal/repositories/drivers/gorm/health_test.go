@@ -0,0 +1,41 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthGORMRepo_Ping(t *testing.T) {
+	config := config.ConfigDB{
+		Driver:   "gorm",
+		LogLevel: "silent",
+		Config: config.ConfigDBDriver{
+			GORM: config.ConfigDBDriverGORM{
+				Driver:           "sqlite",
+				ConnectionString: ":memory:",
+			},
+		},
+	}
+
+	db, err := NewDatabase(config)
+	require.NoError(t, err)
+
+	repo, err := NewHealthGORMRepo(db)
+	require.NoError(t, err)
+	assert.NoError(t, repo.Ping(context.Background()))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	assert.ErrorIs(t, repo.Ping(context.Background()), entities.ErrGeneral)
+}
+
+func TestNewHealthGORMRepo_NilDB(t *testing.T) {
+	_, err := NewHealthGORMRepo(nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
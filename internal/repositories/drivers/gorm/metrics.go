@@ -0,0 +1,46 @@
+package gorm
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/metrics"
+	"gorm.io/gorm"
+)
+
+// metricsStartKey is the statement instance key of the query start time
+const metricsStartKey = "ovoo:metrics_start"
+
+// registerMetricsCallbacks records the latency of the database queries in the metrics,
+// measured around the GORM callbacks executing the statements.
+func registerMetricsCallbacks(db *gorm.DB) error {
+	start := func(db *gorm.DB) {
+		db.InstanceSet(metricsStartKey, time.Now())
+	}
+
+	observe := func(operation string) func(db *gorm.DB) {
+		return func(db *gorm.DB) {
+			if v, ok := db.InstanceGet(metricsStartKey); ok {
+				if startedAt, ok := v.(time.Time); ok {
+					metrics.ObserveDBQuery(operation, time.Since(startedAt))
+				}
+			}
+		}
+	}
+
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("ovoo:metrics_start_create", start),
+		cb.Create().After("gorm:create").Register("ovoo:metrics_observe_create", observe("create")),
+		cb.Query().Before("gorm:query").Register("ovoo:metrics_start_query", start),
+		cb.Query().After("gorm:query").Register("ovoo:metrics_observe_query", observe("query")),
+		cb.Update().Before("gorm:update").Register("ovoo:metrics_start_update", start),
+		cb.Update().After("gorm:update").Register("ovoo:metrics_observe_update", observe("update")),
+		cb.Delete().Before("gorm:delete").Register("ovoo:metrics_start_delete", start),
+		cb.Delete().After("gorm:delete").Register("ovoo:metrics_observe_delete", observe("delete")),
+		cb.Row().Before("gorm:row").Register("ovoo:metrics_start_row", start),
+		cb.Row().After("gorm:row").Register("ovoo:metrics_observe_row", observe("row")),
+		cb.Raw().Before("gorm:raw").Register("ovoo:metrics_start_raw", start),
+		cb.Raw().After("gorm:raw").Register("ovoo:metrics_observe_raw", observe("raw")),
+	)
+}
@@ -17,16 +17,21 @@ type Model struct {
 // User represents a user in the system
 type User struct {
 	Model
-	FirstName      string    `gorm:"column:first_name"`
-	LastName       string    `gorm:"column:last_name"`
-	Login          string    `gorm:"column:login;uniqueIndex"`
-	Type           int       `gorm:"column:type"`
-	PwdHash        string    `gorm:"column:pwd_hash"`
-	FailedAttempts int       `gorm:"column:failed_attempts"`
-	LockoutUntil   time.Time `gorm:"column:lockout_until"`
-	UpdatedByID    string    `gorm:"column:updated_by_id"`
-	UpdatedBy      *User     `gorm:"foreignKey:UpdatedByID"`
-	Active         bool      `gorm:"column:active;default:true"`
+	FirstName      string                `gorm:"column:first_name"`
+	LastName       string                `gorm:"column:last_name"`
+	Login          string                `gorm:"column:login;uniqueIndex"`
+	Type           int                   `gorm:"column:type"`
+	PwdHash        string                `gorm:"column:pwd_hash"`
+	FailedAttempts int                   `gorm:"column:failed_attempts"`
+	LockoutUntil   time.Time             `gorm:"column:lockout_until"`
+	UpdatedByID    string                `gorm:"column:updated_by_id"`
+	UpdatedBy      *User                 `gorm:"foreignKey:UpdatedByID"`
+	Active         bool                  `gorm:"column:active;default:true"`
+	RoleID         string                `gorm:"column:role_id;index"`
+	Role           *Role                 `gorm:"foreignKey:RoleID"`
+	MFA            UserMFA               `gorm:"column:mfa;serializer:json"`
+	Notifications  UserNotificationPrefs `gorm:"column:notifications;serializer:json"`
+	PrivacyFilter  bool                  `gorm:"column:privacy_filter"`
 }
 
 // TableName specifies the table name for User
@@ -34,6 +39,36 @@ func (u User) TableName() string {
 	return "users"
 }
 
+// UserMFA contains the second factor settings of a user
+type UserMFA struct {
+	TOTPSecret    string               `json:"totp_secret,omitempty"`
+	TOTPEnabled   bool                 `json:"totp_enabled,omitempty"`
+	TOTPLastStep  int64                `json:"totp_last_step,omitempty"`
+	RecoveryCodes []string             `json:"recovery_codes,omitempty"`
+	WebAuthn      []WebAuthnCredential `json:"webauthn,omitempty"`
+}
+
+// UserNotificationPrefs contains the notification settings of a user
+type UserNotificationPrefs struct {
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// WebAuthnCredential contains a WebAuthn public key credential registered by a user
+type WebAuthnCredential struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	CredentialID    []byte    `json:"credential_id"`
+	PublicKey       []byte    `json:"public_key"`
+	AttestationType string    `json:"attestation_type,omitempty"`
+	Transports      []string  `json:"transports,omitempty"`
+	AAGUID          []byte    `json:"aaguid,omitempty"`
+	SignCount       uint32    `json:"sign_count"`
+	BackupEligible  bool      `json:"backup_eligible,omitempty"`
+	BackupState     bool      `json:"backup_state,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastUsedAt      time.Time `json:"last_used_at,omitempty"`
+}
+
 // AddressMetadata contains additional information about an address
 type AddressMetadata struct {
 	Comment     string `json:"comment"`
@@ -53,6 +88,19 @@ type Address struct {
 	UpdatedByID      string          `gorm:"column:updated_by_id"`
 	UpdatedBy        User            `gorm:"foreignKey:UpdatedByID"`
 	Active           bool            `gorm:"column:active;default:true"`
+	Pending          bool            `gorm:"column:pending"`
+	PGPKey           string          `gorm:"column:pgp_key"`
+	PGPFingerprint   string          `gorm:"column:pgp_fingerprint"`
+	PGPKeyExpiresAt  *time.Time      `gorm:"column:pgp_key_expires_at;index"`
+	PrivacyFilter    *bool           `gorm:"column:privacy_filter"`
+	Quarantine       string          `gorm:"column:quarantine"`
+	Rules            *string         `gorm:"column:rules"`
+	WebhookURL       string          `gorm:"column:webhook_url"`
+	WebhookSecret    string          `gorm:"column:webhook_secret"`
+	SenderDomains    []string        `gorm:"column:sender_domains;serializer:json"`
+	LeakSender       string          `gorm:"column:leak_sender"`
+	LeakDetectedAt   *time.Time      `gorm:"column:leak_detected_at;index"`
+	LeakRestrict     bool            `gorm:"column:leak_restrict"`
 }
 
 // TableName specifies the table name for Address
@@ -71,6 +119,7 @@ type Chain struct {
 	OrigFromAddress   Address        `gorm:"foreignKey:OrigFromAddressID"`
 	OrigToAddressID   string         `gorm:"column:orig_to_address_id"`
 	OrigToAddress     Address        `gorm:"foreignKey:OrigToAddressID"`
+	Quarantined       bool           `gorm:"column:quarantined"`
 	CreatedAt         time.Time      `gorm:"column:created_at"`
 	UpdatedAt         time.Time      `gorm:"column:updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"column:deleted_at"`
@@ -96,6 +145,8 @@ type ApiToken struct {
 	Active      bool      `gorm:"column:active;default:true"`
 	UpdatedByID string    `gorm:"column:updated_by_id"`
 	UpdatedBy   User      `gorm:"foreignKey:UpdatedByID"`
+	Scopes      []string  `gorm:"column:scopes;serializer:json"`
+	Domains     []string  `gorm:"column:domains;serializer:json"`
 }
 
 // TableName specifies the table name for ApiToken
@@ -128,3 +179,77 @@ type CustomDomain struct {
 func (cd CustomDomain) TableName() string {
 	return "custom_domains"
 }
+
+// Role represents a named set of permissions assignable to users
+type Role struct {
+	Model
+	Name        string   `gorm:"column:name;uniqueIndex"`
+	Description string   `gorm:"column:description"`
+	Permissions []string `gorm:"column:permissions;serializer:json"`
+	UpdatedByID string   `gorm:"column:updated_by_id"`
+	UpdatedBy   User     `gorm:"foreignKey:UpdatedByID"`
+}
+
+// TableName specifies the table name for Role
+func (r Role) TableName() string {
+	return "roles"
+}
+
+// Notification represents an email notification queued for delivery
+type Notification struct {
+	ID            string            `gorm:"column:id;primaryKey"`
+	Key           string            `gorm:"column:key;uniqueIndex"`
+	UserID        string            `gorm:"column:user_id;index"`
+	To            string            `gorm:"column:to"`
+	Name          string            `gorm:"column:name"`
+	Event         string            `gorm:"column:event"`
+	Data          map[string]string `gorm:"column:data;serializer:json"`
+	Status        string            `gorm:"column:status;index"`
+	Attempts      int               `gorm:"column:attempts"`
+	NextAttemptAt time.Time         `gorm:"column:next_attempt_at;index"`
+	LastError     string            `gorm:"column:last_error"`
+	CreatedAt     time.Time         `gorm:"column:created_at"`
+	SentAt        time.Time         `gorm:"column:sent_at"`
+}
+
+// TableName specifies the table name for Notification
+func (n Notification) TableName() string {
+	return "notifications"
+}
+
+// QuarantinedMessage represents a message held in the quarantine
+type QuarantinedMessage struct {
+	ID        string    `gorm:"column:id;primaryKey"`
+	AliasID   string    `gorm:"column:alias_id;index"`
+	Alias     Address   `gorm:"foreignKey:AliasID"`
+	ChainHash string    `gorm:"column:chain_hash"`
+	Sender    string    `gorm:"column:sender"`
+	RcptTo    string    `gorm:"column:rcpt_to"`
+	Subject   string    `gorm:"column:subject"`
+	Size      int       `gorm:"column:size"`
+	Data      []byte    `gorm:"column:data"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index"`
+}
+
+// TableName specifies the table name for QuarantinedMessage
+func (q QuarantinedMessage) TableName() string {
+	return "quarantine"
+}
+
+// SenderReputation represents the record of a sender in the sender reputation
+type SenderReputation struct {
+	Sender    string    `gorm:"column:sender;primaryKey"`
+	Hits      int       `gorm:"column:hits"`
+	Score     float64   `gorm:"column:score"`
+	LastHitAt time.Time `gorm:"column:last_hit_at;index"`
+	Override  string    `gorm:"column:override;index"`
+	Comment   string    `gorm:"column:comment"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// TableName specifies the table name for SenderReputation
+func (r SenderReputation) TableName() string {
+	return "sender_reputation"
+}
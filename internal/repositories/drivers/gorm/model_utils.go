@@ -20,6 +20,9 @@ func userFromEntity(e entities.User) User {
 		FailedAttempts: e.FailedAttempts,
 		LockoutUntil:   e.LockoutUntil,
 		Active:         e.Active,
+		MFA:            userMFAFromEntity(e.MFA),
+		Notifications:  userNotificationPrefsFromEntity(e.Notifications),
+		PrivacyFilter:  e.PrivacyFilter,
 	}
 
 	if e.UpdatedBy != nil {
@@ -28,6 +31,11 @@ func userFromEntity(e entities.User) User {
 		u.UpdatedByID = e.UpdatedBy.ID.String()
 	}
 
+	// only the reference is stored, roles are managed by their own repository
+	if e.Role != nil {
+		u.RoleID = e.Role.ID.String()
+	}
+
 	return u
 }
 
@@ -55,6 +63,9 @@ func userToEntity(u User) entities.User {
 		UpdatedAt:      u.UpdatedAt,
 		CreatedAt:      u.CreatedAt,
 		Active:         u.Active,
+		MFA:            userMFAToEntity(u.MFA),
+		Notifications:  userNotificationPrefsToEntity(u.Notifications),
+		PrivacyFilter:  u.PrivacyFilter,
 	}
 
 	if u.UpdatedBy != nil {
@@ -62,9 +73,100 @@ func userToEntity(u User) entities.User {
 		eu.UpdatedBy = &updatedBy
 	}
 
+	if u.Role != nil {
+		role := roleToEntity(*u.Role)
+		eu.Role = &role
+	}
+
 	return eu
 }
 
+// userMFAFromEntity converts an entities.UserMFA to a UserMFA
+func userMFAFromEntity(e entities.UserMFA) UserMFA {
+	m := UserMFA{
+		TOTPSecret:    e.TOTPSecret,
+		TOTPEnabled:   e.TOTPEnabled,
+		TOTPLastStep:  e.TOTPLastStep,
+		RecoveryCodes: make([]string, 0, len(e.RecoveryCodes)),
+		WebAuthn:      make([]WebAuthnCredential, 0, len(e.WebAuthn)),
+	}
+
+	for _, code := range e.RecoveryCodes {
+		m.RecoveryCodes = append(m.RecoveryCodes, code.String())
+	}
+
+	for _, cred := range e.WebAuthn {
+		m.WebAuthn = append(m.WebAuthn, WebAuthnCredential{
+			ID:              cred.ID.String(),
+			Name:            cred.Name,
+			CredentialID:    cred.CredentialID,
+			PublicKey:       cred.PublicKey,
+			AttestationType: cred.AttestationType,
+			Transports:      cred.Transports,
+			AAGUID:          cred.AAGUID,
+			SignCount:       cred.SignCount,
+			BackupEligible:  cred.BackupEligible,
+			BackupState:     cred.BackupState,
+			CreatedAt:       cred.CreatedAt,
+			LastUsedAt:      cred.LastUsedAt,
+		})
+	}
+
+	return m
+}
+
+// userMFAToEntity converts a UserMFA to an entities.UserMFA
+func userMFAToEntity(m UserMFA) entities.UserMFA {
+	e := entities.UserMFA{
+		TOTPSecret:   m.TOTPSecret,
+		TOTPEnabled:  m.TOTPEnabled,
+		TOTPLastStep: m.TOTPLastStep,
+	}
+
+	for _, code := range m.RecoveryCodes {
+		e.RecoveryCodes = append(e.RecoveryCodes, entities.Hash(code))
+	}
+
+	for _, cred := range m.WebAuthn {
+		e.WebAuthn = append(e.WebAuthn, entities.WebAuthnCredential{
+			ID:              entities.Id(cred.ID),
+			Name:            cred.Name,
+			CredentialID:    cred.CredentialID,
+			PublicKey:       cred.PublicKey,
+			AttestationType: cred.AttestationType,
+			Transports:      cred.Transports,
+			AAGUID:          cred.AAGUID,
+			SignCount:       cred.SignCount,
+			BackupEligible:  cred.BackupEligible,
+			BackupState:     cred.BackupState,
+			CreatedAt:       cred.CreatedAt,
+			LastUsedAt:      cred.LastUsedAt,
+		})
+	}
+
+	return e
+}
+
+// userNotificationPrefsFromEntity converts an entities.NotificationPrefs to a UserNotificationPrefs
+func userNotificationPrefsFromEntity(e entities.NotificationPrefs) UserNotificationPrefs {
+	p := UserNotificationPrefs{}
+	for _, event := range e.Disabled {
+		p.Disabled = append(p.Disabled, string(event))
+	}
+
+	return p
+}
+
+// userNotificationPrefsToEntity converts a UserNotificationPrefs to an entities.NotificationPrefs
+func userNotificationPrefsToEntity(p UserNotificationPrefs) entities.NotificationPrefs {
+	e := entities.NotificationPrefs{}
+	for _, event := range p.Disabled {
+		e.Disabled = append(e.Disabled, entities.NotificationEvent(event))
+	}
+
+	return e
+}
+
 func userToEntityList(users []User) []entities.User {
 	eusers := make([]entities.User, 0, len(users))
 	for _, user := range users {
@@ -91,10 +193,37 @@ func addressFromEntity(e entities.Address) Address {
 			Comment:     e.Metadata.Comment,
 			ServiceName: e.Metadata.ServiceName,
 		},
-		UpdatedBy:   userFromEntity(e.UpdatedBy),
-		UpdatedByID: e.UpdatedBy.ID.String(),
-		Active:      e.Active,
+		UpdatedBy:     userFromEntity(e.UpdatedBy),
+		UpdatedByID:   e.UpdatedBy.ID.String(),
+		Active:        e.Active,
+		Pending:       e.Pending,
+		PrivacyFilter: e.PrivacyFilter,
+		Quarantine:    string(e.Quarantine),
+		SenderDomains: e.SenderDomains,
+		LeakRestrict:  e.LeakRestrict,
+	}
+	if e.PGPKey != nil {
+		addr.PGPKey = e.PGPKey.Armored
+		addr.PGPFingerprint = e.PGPKey.Fingerprint
+		if !e.PGPKey.ExpiresAt.IsZero() {
+			addr.PGPKeyExpiresAt = &e.PGPKey.ExpiresAt
+		}
+	}
+
+	if e.Rules != nil {
+		addr.Rules = &e.Rules.Script
+	}
+
+	if e.Webhook != nil {
+		addr.WebhookURL = e.Webhook.URL
+		addr.WebhookSecret = e.Webhook.Secret
+	}
+
+	if e.Leak != nil {
+		addr.LeakSender = e.Leak.Sender.String()
+		addr.LeakDetectedAt = &e.Leak.DetectedAt
 	}
+
 	if e.ForwardAddress != nil {
 		fa := addressFromEntity(*e.ForwardAddress)
 		addr.ForwardAddress = &fa
@@ -126,10 +255,34 @@ func addressToEntity(a Address) entities.Address {
 			Comment:     a.Metadata.Comment,
 			ServiceName: a.Metadata.ServiceName,
 		},
-		UpdatedAt: a.UpdatedAt,
-		CreatedAt: a.CreatedAt,
-		UpdatedBy: userToEntity(a.UpdatedBy),
-		Active:    a.Active,
+		UpdatedAt:     a.UpdatedAt,
+		CreatedAt:     a.CreatedAt,
+		UpdatedBy:     userToEntity(a.UpdatedBy),
+		Active:        a.Active,
+		Pending:       a.Pending,
+		PrivacyFilter: a.PrivacyFilter,
+		Quarantine:    entities.QuarantineMode(a.Quarantine),
+		SenderDomains: a.SenderDomains,
+		LeakRestrict:  a.LeakRestrict,
+	}
+
+	if a.PGPKey != "" {
+		addr.PGPKey = &entities.PGPKey{Armored: a.PGPKey, Fingerprint: a.PGPFingerprint}
+		if a.PGPKeyExpiresAt != nil {
+			addr.PGPKey.ExpiresAt = *a.PGPKeyExpiresAt
+		}
+	}
+
+	if a.Rules != nil {
+		addr.Rules = &entities.Rules{Script: *a.Rules}
+	}
+
+	if a.WebhookURL != "" {
+		addr.Webhook = &entities.Webhook{URL: a.WebhookURL, Secret: a.WebhookSecret}
+	}
+
+	if a.LeakDetectedAt != nil {
+		addr.Leak = &entities.AliasLeak{Sender: entities.Email(a.LeakSender), DetectedAt: *a.LeakDetectedAt}
 	}
 
 	if a.ForwardAddress != nil {
@@ -162,6 +315,7 @@ func chainFromEntity(e entities.Chain) Chain {
 		OrigFromAddressID: e.OrigFromAddress.ID.String(),
 		OrigToAddress:     addressFromEntity(e.OrigToAddress),
 		OrigToAddressID:   e.OrigToAddress.ID.String(),
+		Quarantined:       e.Quarantined,
 		UpdatedAt:         e.UpdatedAt,
 		UpdatedBy:         userFromEntity(e.UpdatedBy),
 		UpdatedByID:       e.UpdatedBy.ID.String(),
@@ -185,6 +339,7 @@ func chainToEntity(e Chain) entities.Chain {
 		ToAddress:       addressToEntity(e.ToAddress),
 		OrigFromAddress: addressToEntity(e.OrigFromAddress),
 		OrigToAddress:   addressToEntity(e.OrigToAddress),
+		Quarantined:     e.Quarantined,
 		CreatedAt:       e.CreatedAt,
 		UpdatedAt:       e.UpdatedAt,
 		UpdatedBy:       userToEntity(e.UpdatedBy),
@@ -201,6 +356,7 @@ func chainToEntityList(chains []Chain) []entities.Chain {
 			ToAddress:       addressToEntity(chain.ToAddress),
 			OrigFromAddress: addressToEntity(chain.OrigFromAddress),
 			OrigToAddress:   addressToEntity(chain.OrigToAddress),
+			Quarantined:     chain.Quarantined,
 			CreatedAt:       chain.CreatedAt,
 			UpdatedAt:       chain.UpdatedAt,
 			UpdatedBy:       userToEntity(chain.UpdatedBy),
@@ -228,6 +384,8 @@ func apiTokenFromEntity(e entities.ApiToken) ApiToken {
 		Active:      e.Active,
 		UpdatedBy:   userFromEntity(e.UpdatedBy),
 		UpdatedByID: e.UpdatedBy.ID.String(),
+		Scopes:      permissionsToStrings(e.Scopes),
+		Domains:     e.Domains,
 	}
 }
 
@@ -254,6 +412,8 @@ func apiTokenToEntity(t ApiToken) entities.ApiToken {
 		CreatedAt:   t.CreatedAt,
 		UpdatedAt:   t.UpdatedAt,
 		UpdatedBy:   userToEntity(t.UpdatedBy),
+		Scopes:      permissionsFromStrings(t.Scopes),
+		Domains:     t.Domains,
 	}
 }
 
@@ -327,3 +487,186 @@ func customDomainToEntityList(domains []CustomDomain) []entities.CustomDomain {
 
 	return edomains
 }
+
+// roleFromEntity converts an entities.Role to a Role
+func roleFromEntity(e entities.Role) Role {
+	return Role{
+		Model: Model{
+			ID:        e.ID.String(),
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.UpdatedAt,
+		},
+		Name:        e.Name,
+		Description: e.Description,
+		Permissions: permissionsToStrings(e.Permissions),
+		UpdatedByID: e.UpdatedBy.ID.String(),
+		UpdatedBy:   userFromEntity(e.UpdatedBy),
+	}
+}
+
+// roleToEntity converts a Role to an entities.Role
+func roleToEntity(r Role) entities.Role {
+	return entities.Role{
+		ID:          entities.Id(r.ID),
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: permissionsFromStrings(r.Permissions),
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+		UpdatedBy:   userToEntity(r.UpdatedBy),
+	}
+}
+
+func roleToEntityList(roles []Role) []entities.Role {
+	eroles := make([]entities.Role, 0, len(roles))
+	for _, role := range roles {
+		eroles = append(eroles, roleToEntity(role))
+	}
+
+	return eroles
+}
+
+// permissionsToStrings converts a list of entities.Permission to a list of strings
+func permissionsToStrings(perms []entities.Permission) []string {
+	res := make([]string, 0, len(perms))
+	for _, p := range perms {
+		res = append(res, string(p))
+	}
+
+	return res
+}
+
+// permissionsFromStrings converts a list of strings to a list of entities.Permission
+func permissionsFromStrings(perms []string) []entities.Permission {
+	res := make([]entities.Permission, 0, len(perms))
+	for _, p := range perms {
+		res = append(res, entities.Permission(p))
+	}
+
+	return res
+}
+
+// notificationFromEntity converts an entities.Notification to a Notification
+func notificationFromEntity(e entities.Notification) Notification {
+	return Notification{
+		ID:            e.ID.String(),
+		Key:           e.Key,
+		UserID:        e.UserID.String(),
+		To:            e.To.String(),
+		Name:          e.Name,
+		Event:         string(e.Event),
+		Data:          e.Data,
+		Status:        string(e.Status),
+		Attempts:      e.Attempts,
+		NextAttemptAt: e.NextAttemptAt,
+		LastError:     e.LastError,
+		CreatedAt:     e.CreatedAt,
+		SentAt:        e.SentAt,
+	}
+}
+
+// notificationToEntity converts a Notification to an entities.Notification
+func notificationToEntity(n Notification) entities.Notification {
+	return entities.Notification{
+		ID:            entities.Id(n.ID),
+		Key:           n.Key,
+		UserID:        entities.Id(n.UserID),
+		To:            entities.Email(n.To),
+		Name:          n.Name,
+		Event:         entities.NotificationEvent(n.Event),
+		Data:          n.Data,
+		Status:        entities.NotificationStatus(n.Status),
+		Attempts:      n.Attempts,
+		NextAttemptAt: n.NextAttemptAt,
+		LastError:     n.LastError,
+		CreatedAt:     n.CreatedAt,
+		SentAt:        n.SentAt,
+	}
+}
+
+func notificationToEntityList(notifications []Notification) []entities.Notification {
+	enotifications := make([]entities.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		enotifications = append(enotifications, notificationToEntity(n))
+	}
+
+	return enotifications
+}
+
+// quarantinedMessageFromEntity converts an entities.QuarantinedMessage to a QuarantinedMessage
+func quarantinedMessageFromEntity(e entities.QuarantinedMessage) QuarantinedMessage {
+	return QuarantinedMessage{
+		ID:        e.ID.String(),
+		AliasID:   e.Alias.ID.String(),
+		ChainHash: e.ChainHash.String(),
+		Sender:    e.Sender.String(),
+		RcptTo:    e.RcptTo.String(),
+		Subject:   e.Subject,
+		Size:      e.Size,
+		Data:      e.Data,
+		CreatedAt: e.CreatedAt,
+		ExpiresAt: e.ExpiresAt,
+	}
+}
+
+// quarantinedMessageToEntity converts a QuarantinedMessage to an entities.QuarantinedMessage
+func quarantinedMessageToEntity(q QuarantinedMessage) entities.QuarantinedMessage {
+	return entities.QuarantinedMessage{
+		ID:        entities.Id(q.ID),
+		Alias:     addressToEntity(q.Alias),
+		ChainHash: entities.Hash(q.ChainHash),
+		Sender:    entities.Email(q.Sender),
+		RcptTo:    entities.Email(q.RcptTo),
+		Subject:   q.Subject,
+		Size:      q.Size,
+		Data:      q.Data,
+		CreatedAt: q.CreatedAt,
+		ExpiresAt: q.ExpiresAt,
+	}
+}
+
+func quarantinedMessageToEntityList(msgs []QuarantinedMessage) []entities.QuarantinedMessage {
+	emsgs := make([]entities.QuarantinedMessage, 0, len(msgs))
+	for _, m := range msgs {
+		emsgs = append(emsgs, quarantinedMessageToEntity(m))
+	}
+
+	return emsgs
+}
+
+// senderReputationFromEntity converts an entities.SenderReputation to a SenderReputation
+func senderReputationFromEntity(e entities.SenderReputation) SenderReputation {
+	return SenderReputation{
+		Sender:    e.Sender.String(),
+		Hits:      e.Hits,
+		Score:     e.Score,
+		LastHitAt: e.LastHitAt,
+		Override:  string(e.Override),
+		Comment:   e.Comment,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+// senderReputationToEntity converts a SenderReputation to an entities.SenderReputation
+func senderReputationToEntity(r SenderReputation) entities.SenderReputation {
+	return entities.SenderReputation{
+		Sender:    entities.Email(r.Sender),
+		Hits:      r.Hits,
+		Score:     r.Score,
+		LastHitAt: r.LastHitAt,
+		Override:  entities.ReputationOverride(r.Override),
+		Comment:   r.Comment,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+func senderReputationToEntityList(reps []SenderReputation) []entities.SenderReputation {
+	ereps := make([]entities.SenderReputation, 0, len(reps))
+	for _, r := range reps {
+		ereps = append(ereps, senderReputationToEntity(r))
+	}
+
+	return ereps
+}
@@ -0,0 +1,95 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// NotificationGORMRepo implements the NotificationsQueue interface using GORM.
+type NotificationGORMRepo struct {
+	db *gorm.DB
+}
+
+// NewNotificationGORMRepo creates a new NotificationGORMRepo instance.
+// It returns an error if the provided database connection is nil.
+func NewNotificationGORMRepo(db *gorm.DB) (repositories.NotificationsQueue, error) {
+	if db == nil {
+		return &NotificationGORMRepo{}, fmt.Errorf("%w: database can not be nil", entities.ErrConfiguration)
+	}
+
+	return &NotificationGORMRepo{db: db}, nil
+}
+
+// Enqueue adds a new notification to the database.
+// Returns entities.ErrDuplicateEntry if a notification with the same key exists.
+func (r *NotificationGORMRepo) Enqueue(ctx context.Context, n entities.Notification) error {
+	gorm_notification := notificationFromEntity(n)
+	if err := r.db.WithContext(ctx).Model(&Notification{}).Create(&gorm_notification).Error; err != nil {
+		return wrapGormError(err)
+	}
+
+	return nil
+}
+
+// Claim returns up to limit queued notifications which next attempt is due and moves
+// their next attempt forward by lease.
+// Each notification is claimed with a conditional update, so a notification is only
+// returned to one of the API instances sharing the database.
+func (r *NotificationGORMRepo) Claim(ctx context.Context, limit int, lease time.Duration) ([]entities.Notification, error) {
+	now := time.Now()
+	candidates := make([]Notification, 0, limit)
+	if err := r.db.WithContext(ctx).Model(&Notification{}).
+		Where("status = ? AND next_attempt_at <= ?", string(entities.NotificationQueued), now).
+		Order("next_attempt_at").Limit(limit).
+		Find(&candidates).Error; err != nil {
+		return nil, wrapGormError(err)
+	}
+
+	claimed := make([]Notification, 0, len(candidates))
+	for _, n := range candidates {
+		res := r.db.WithContext(ctx).Model(&Notification{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", n.ID, string(entities.NotificationQueued), n.NextAttemptAt).
+			Update("next_attempt_at", now.Add(lease))
+		if res.Error != nil {
+			return nil, wrapGormError(res.Error)
+		}
+
+		if res.RowsAffected == 1 {
+			n.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, n)
+		}
+	}
+
+	return notificationToEntityList(claimed), nil
+}
+
+// Update saves the delivery state of a notification.
+func (r *NotificationGORMRepo) Update(ctx context.Context, n entities.Notification) error {
+	gorm_notification := notificationFromEntity(n)
+	res := r.db.WithContext(ctx).Model(&Notification{}).Select("*").Where("id = ?", n.ID.String()).Updates(&gorm_notification)
+	if res.Error != nil {
+		return wrapGormError(res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: notification %s", entities.ErrNotFound, n.ID)
+	}
+
+	return nil
+}
+
+// DeleteFinished removes the sent and failed notifications created before the given time.
+func (r *NotificationGORMRepo) DeleteFinished(ctx context.Context, before time.Time) error {
+	if err := r.db.WithContext(ctx).
+		Where("status IN ? AND created_at < ?", []string{string(entities.NotificationSent), string(entities.NotificationFailed)}, before).
+		Delete(&Notification{}).Error; err != nil {
+		return wrapGormError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupNotificationTestDB(t *testing.T) *NotificationGORMRepo {
+	config := config.ConfigDB{
+		Driver:   "gorm",
+		LogLevel: "silent",
+		Config: config.ConfigDBDriver{
+			GORM: config.ConfigDBDriverGORM{
+				Driver:           "sqlite",
+				ConnectionString: ":memory:",
+			},
+		},
+	}
+
+	db, err := NewDatabase(config)
+	require.NoError(t, err)
+
+	repo, err := NewNotificationGORMRepo(db)
+	require.NoError(t, err)
+
+	return repo.(*NotificationGORMRepo)
+}
+
+func newTestNotification(key string, nextAttempt time.Time) entities.Notification {
+	return entities.Notification{
+		ID:            entities.NewId(),
+		Key:           key,
+		UserID:        entities.NewId(),
+		To:            "user@example.com",
+		Event:         entities.NotificationNewSender,
+		Data:          map[string]string{"sender": "sender@example.com"},
+		Status:        entities.NotificationQueued,
+		NextAttemptAt: nextAttempt,
+		CreatedAt:     time.Now(),
+	}
+}
+
+func TestNewNotificationGORMRepo_NilDB(t *testing.T) {
+	_, err := NewNotificationGORMRepo(nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestNotificationGORMRepo_Enqueue_Duplicate(t *testing.T) {
+	repo := setupNotificationTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Enqueue(ctx, newTestNotification("key", time.Now())))
+	err := repo.Enqueue(ctx, newTestNotification("key", time.Now()))
+	assert.ErrorIs(t, err, entities.ErrDuplicateEntry)
+}
+
+func TestNotificationGORMRepo_Claim(t *testing.T) {
+	repo := setupNotificationTestDB(t)
+	ctx := context.Background()
+
+	due := newTestNotification("due", time.Now().Add(-time.Minute))
+	later := newTestNotification("later", time.Now().Add(time.Hour))
+	sent := newTestNotification("sent", time.Now().Add(-time.Minute))
+	sent.Status = entities.NotificationSent
+	for _, n := range []entities.Notification{due, later, sent} {
+		require.NoError(t, repo.Enqueue(ctx, n))
+	}
+
+	claimed, err := repo.Claim(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, due.ID, claimed[0].ID)
+	assert.Equal(t, due.Data, claimed[0].Data)
+	assert.True(t, claimed[0].NextAttemptAt.After(time.Now()))
+
+	// the lease keeps the notification from being claimed again
+	claimed, err = repo.Claim(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestNotificationGORMRepo_Claim_Limit(t *testing.T) {
+	repo := setupNotificationTestDB(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, repo.Enqueue(ctx, newTestNotification(key, time.Now().Add(-time.Minute))))
+	}
+
+	claimed, err := repo.Claim(ctx, 2, time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 2)
+}
+
+func TestNotificationGORMRepo_Update(t *testing.T) {
+	repo := setupNotificationTestDB(t)
+	ctx := context.Background()
+
+	n := newTestNotification("key", time.Now().Add(-time.Minute))
+	require.NoError(t, repo.Enqueue(ctx, n))
+
+	n.Status = entities.NotificationSent
+	n.Attempts = 1
+	n.SentAt = time.Now()
+	require.NoError(t, repo.Update(ctx, n))
+
+	claimed, err := repo.Claim(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	missing := newTestNotification("missing", time.Now())
+	assert.ErrorIs(t, repo.Update(ctx, missing), entities.ErrNotFound)
+}
+
+func TestNotificationGORMRepo_DeleteFinished(t *testing.T) {
+	repo := setupNotificationTestDB(t)
+	ctx := context.Background()
+
+	old := newTestNotification("old", time.Now())
+	old.Status = entities.NotificationSent
+	old.CreatedAt = time.Now().Add(-48 * time.Hour)
+	oldQueued := newTestNotification("old-queued", time.Now().Add(-time.Minute))
+	oldQueued.CreatedAt = time.Now().Add(-48 * time.Hour)
+	for _, n := range []entities.Notification{old, oldQueued} {
+		require.NoError(t, repo.Enqueue(ctx, n))
+	}
+
+	require.NoError(t, repo.DeleteFinished(ctx, time.Now().Add(-24*time.Hour)))
+
+	var count int64
+	require.NoError(t, repo.db.Model(&Notification{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// the key of a deleted notification can be queued again
+	assert.NoError(t, repo.Enqueue(ctx, newTestNotification("old", time.Now())))
+}
@@ -0,0 +1,131 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuarantineGORMRepo implements the QuarantineReadWriter interface using GORM.
+type QuarantineGORMRepo struct {
+	db *gorm.DB
+}
+
+// NewQuarantineGORMRepo creates a new QuarantineGORMRepo instance.
+// It returns an error if the provided database connection is nil.
+func NewQuarantineGORMRepo(db *gorm.DB) (repositories.QuarantineReadWriter, error) {
+	if db == nil {
+		return &QuarantineGORMRepo{}, fmt.Errorf("%w: database can not be nil", entities.ErrConfiguration)
+	}
+
+	return &QuarantineGORMRepo{db: db}, nil
+}
+
+// GetById retrieves a quarantined message with its data by id.
+func (r *QuarantineGORMRepo) GetById(ctx context.Context, id entities.Id) (entities.QuarantinedMessage, error) {
+	msg := QuarantinedMessage{}
+	if err := r.db.WithContext(ctx).Model(&QuarantinedMessage{}).
+		Preload(clause.Associations).
+		Preload("Alias."+clause.Associations).
+		First(&msg, "id = ?", id.String()).Error; err != nil {
+		return entities.QuarantinedMessage{}, wrapGormError(err)
+	}
+
+	return quarantinedMessageToEntity(msg), nil
+}
+
+// GetAll retrieves the quarantined messages matching the filter, newest first.
+// The data of the messages is not loaded.
+func (r *QuarantineGORMRepo) GetAll(ctx context.Context, filter entities.QuarantineFilter) ([]entities.QuarantinedMessage, entities.PaginationMetadata, error) {
+	msgs := make([]QuarantinedMessage, 0)
+	stmt := r.db.WithContext(ctx).Model(&QuarantinedMessage{})
+	count := applyQuarantineFilter(stmt, filter)
+	if err := stmt.Omit("data").
+		Preload(clause.Associations).
+		Preload("Alias." + clause.Associations).
+		Order("created_at DESC").
+		Find(&msgs).Error; err != nil {
+		return nil, entities.PaginationMetadata{}, wrapGormError(err)
+	}
+
+	return quarantinedMessageToEntityList(msgs), entities.GetPaginationMetadata(filter.Page, filter.PageSize, count), nil
+}
+
+// Create adds a new message to the quarantine.
+func (r *QuarantineGORMRepo) Create(ctx context.Context, msg entities.QuarantinedMessage) error {
+	gorm_msg := quarantinedMessageFromEntity(msg)
+	if err := r.db.WithContext(ctx).Model(&QuarantinedMessage{}).Omit(clause.Associations).Create(&gorm_msg).Error; err != nil {
+		return wrapGormError(err)
+	}
+
+	return nil
+}
+
+// Delete removes the message from the quarantine.
+func (r *QuarantineGORMRepo) Delete(ctx context.Context, id entities.Id) error {
+	res := r.db.WithContext(ctx).Delete(&QuarantinedMessage{}, "id = ?", id.String())
+	if res.Error != nil {
+		return wrapGormError(res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: quarantined message %s", entities.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the messages expired before the given time.
+func (r *QuarantineGORMRepo) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	res := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&QuarantinedMessage{})
+	if res.Error != nil {
+		return 0, wrapGormError(res.Error)
+	}
+
+	return int(res.RowsAffected), nil
+}
+
+func applyQuarantineFilter(stmt *gorm.DB, filter entities.QuarantineFilter) int64 {
+	if len(filter.Ids) > 0 {
+		stmt = stmt.Where("id IN ?", filter.Ids)
+	}
+
+	// owners and domains select the messages through their aliases
+	if len(filter.Owners) > 0 || len(filter.Domains) > 0 {
+		aliases := stmt.Session(&gorm.Session{NewDB: true}).Model(&Address{}).Select("id")
+		if len(filter.Owners) > 0 {
+			aliases = aliases.Where("owner_id IN ?", filter.Owners)
+		}
+
+		if len(filter.Domains) > 0 {
+			group := stmt.Session(&gorm.Session{NewDB: true})
+			for _, domain := range filter.Domains {
+				group = group.Or("email LIKE ?", "%@"+domain)
+			}
+			aliases = aliases.Where(group)
+		}
+
+		stmt = stmt.Where("alias_id IN (?)", aliases)
+	}
+
+	if len(filter.AliasIds) > 0 {
+		stmt = stmt.Where("alias_id IN ?", filter.AliasIds)
+	}
+
+	if filter.ExpiredBefore != nil {
+		stmt = stmt.Where("expires_at < ?", *filter.ExpiredBefore)
+	}
+
+	var count int64
+	stmt = stmt.Count(&count)
+	if filter.Page != 0 && filter.PageSize != 0 {
+		stmt = stmt.Limit(filter.PageSize).Offset((filter.Page - 1) * filter.PageSize)
+	}
+
+	return count
+}
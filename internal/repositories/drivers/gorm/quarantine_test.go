@@ -0,0 +1,158 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupQuarantineTestDB(t *testing.T) (*QuarantineGORMRepo, entities.Address) {
+	config := config.ConfigDB{
+		Driver:   "gorm",
+		LogLevel: "silent",
+		Config: config.ConfigDBDriver{
+			GORM: config.ConfigDBDriverGORM{
+				Driver:           "sqlite",
+				ConnectionString: ":memory:",
+			},
+		},
+	}
+
+	db, err := NewDatabase(config)
+	require.NoError(t, err)
+
+	userRepo, err := NewUserGORMRepo(db)
+	require.NoError(t, err)
+
+	user := entities.User{
+		ID:           entities.NewId(),
+		Login:        "test@example.com",
+		Type:         entities.RegularUser,
+		PasswordHash: "hash",
+	}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	addrRepo, err := NewAddressGORMRepo(db)
+	require.NoError(t, err)
+
+	alias := entities.Address{
+		ID:         entities.NewId(),
+		Type:       entities.AliasAddress,
+		Email:      "alias@ovoo.com",
+		Owner:      user,
+		UpdatedBy:  user,
+		Quarantine: entities.QuarantineAll,
+	}
+	require.NoError(t, addrRepo.Create(context.Background(), alias))
+
+	repo, err := NewQuarantineGORMRepo(db)
+	require.NoError(t, err)
+
+	return repo.(*QuarantineGORMRepo), alias
+}
+
+func newTestQuarantinedMessage(alias entities.Address, expiresAt time.Time) entities.QuarantinedMessage {
+	return entities.QuarantinedMessage{
+		ID:        entities.NewId(),
+		Alias:     alias,
+		ChainHash: entities.NewHash("sender@example.com", alias.Email.String()),
+		Sender:    "sender@example.com",
+		RcptTo:    "user@gmail.com",
+		Subject:   "Hello",
+		Size:      5,
+		Data:      []byte("Hello"),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+func TestNewQuarantineGORMRepo_NilDB(t *testing.T) {
+	_, err := NewQuarantineGORMRepo(nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestQuarantineGORMRepo_CreateGetById(t *testing.T) {
+	repo, alias := setupQuarantineTestDB(t)
+	ctx := context.Background()
+
+	msg := newTestQuarantinedMessage(alias, time.Now().Add(time.Hour))
+	require.NoError(t, repo.Create(ctx, msg))
+
+	got, err := repo.GetById(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, msg.Data, got.Data)
+	assert.Equal(t, alias.ID, got.Alias.ID)
+	assert.Equal(t, alias.Owner.ID, got.Alias.Owner.ID)
+	assert.Equal(t, entities.QuarantineAll, got.Alias.Quarantine)
+
+	_, err = repo.GetById(ctx, entities.NewId())
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func TestQuarantineGORMRepo_GetAll(t *testing.T) {
+	repo, alias := setupQuarantineTestDB(t)
+	ctx := context.Background()
+
+	msg := newTestQuarantinedMessage(alias, time.Now().Add(time.Hour))
+	require.NoError(t, repo.Create(ctx, msg))
+
+	tests := []struct {
+		name   string
+		filter entities.QuarantineFilter
+		wanted int
+	}{
+		{name: "all messages", wanted: 1},
+		{name: "owner", filter: entities.QuarantineFilter{Owners: []entities.Id{alias.Owner.ID}}, wanted: 1},
+		{name: "other owner", filter: entities.QuarantineFilter{Owners: []entities.Id{entities.NewId()}}, wanted: 0},
+		{name: "alias", filter: entities.QuarantineFilter{AliasIds: []entities.Id{alias.ID}}, wanted: 1},
+		{name: "domain", filter: entities.QuarantineFilter{Domains: []string{"ovoo.com"}}, wanted: 1},
+		{name: "other domain", filter: entities.QuarantineFilter{Domains: []string{"other.com"}}, wanted: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Page, tt.filter.PageSize = 1, 10
+			msgs, pgm, err := repo.GetAll(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Len(t, msgs, tt.wanted)
+			assert.Equal(t, tt.wanted, pgm.TotalRecords)
+			for _, m := range msgs {
+				assert.Empty(t, m.Data)
+				assert.Equal(t, "Hello", m.Subject)
+			}
+		})
+	}
+}
+
+func TestQuarantineGORMRepo_Delete(t *testing.T) {
+	repo, alias := setupQuarantineTestDB(t)
+	ctx := context.Background()
+
+	msg := newTestQuarantinedMessage(alias, time.Now().Add(time.Hour))
+	require.NoError(t, repo.Create(ctx, msg))
+
+	require.NoError(t, repo.Delete(ctx, msg.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, msg.ID), entities.ErrNotFound)
+}
+
+func TestQuarantineGORMRepo_DeleteExpired(t *testing.T) {
+	repo, alias := setupQuarantineTestDB(t)
+	ctx := context.Background()
+
+	expired := newTestQuarantinedMessage(alias, time.Now().Add(-time.Minute))
+	kept := newTestQuarantinedMessage(alias, time.Now().Add(time.Hour))
+	require.NoError(t, repo.Create(ctx, expired))
+	require.NoError(t, repo.Create(ctx, kept))
+
+	n, err := repo.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = repo.GetById(ctx, kept.ID)
+	assert.NoError(t, err)
+}
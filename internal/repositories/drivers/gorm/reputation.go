@@ -0,0 +1,102 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// ReputationGORMRepo implements the ReputationReadWriter interface using GORM.
+type ReputationGORMRepo struct {
+	db *gorm.DB
+}
+
+// NewReputationGORMRepo creates a new ReputationGORMRepo instance.
+// It returns an error if the provided database connection is nil.
+func NewReputationGORMRepo(db *gorm.DB) (repositories.ReputationReadWriter, error) {
+	if db == nil {
+		return &ReputationGORMRepo{}, fmt.Errorf("%w: database can not be nil", entities.ErrConfiguration)
+	}
+
+	return &ReputationGORMRepo{db: db}, nil
+}
+
+// GetBySender retrieves the record of the sender.
+func (r *ReputationGORMRepo) GetBySender(ctx context.Context, sender entities.Email) (entities.SenderReputation, error) {
+	rep := SenderReputation{}
+	if err := r.db.WithContext(ctx).First(&rep, "sender = ?", sender.String()).Error; err != nil {
+		return entities.SenderReputation{}, wrapGormError(err)
+	}
+
+	return senderReputationToEntity(rep), nil
+}
+
+// GetAll retrieves the records matching the filter, the senders hitting the traps last first.
+func (r *ReputationGORMRepo) GetAll(ctx context.Context, filter entities.ReputationFilter) ([]entities.SenderReputation, entities.PaginationMetadata, error) {
+	reps := make([]SenderReputation, 0)
+	stmt := r.db.WithContext(ctx).Model(&SenderReputation{})
+	count := applyReputationFilter(stmt, filter)
+	if err := stmt.Order("last_hit_at DESC").Order("sender").Find(&reps).Error; err != nil {
+		return nil, entities.PaginationMetadata{}, wrapGormError(err)
+	}
+
+	return senderReputationToEntityList(reps), entities.GetPaginationMetadata(filter.Page, filter.PageSize, count), nil
+}
+
+// Save creates the record of the sender or replaces the existing one.
+func (r *ReputationGORMRepo) Save(ctx context.Context, rep entities.SenderReputation) error {
+	gorm_rep := senderReputationFromEntity(rep)
+	if err := r.db.WithContext(ctx).Save(&gorm_rep).Error; err != nil {
+		return wrapGormError(err)
+	}
+
+	return nil
+}
+
+// Delete removes the record of the sender.
+func (r *ReputationGORMRepo) Delete(ctx context.Context, sender entities.Email) error {
+	res := r.db.WithContext(ctx).Delete(&SenderReputation{}, "sender = ?", sender.String())
+	if res.Error != nil {
+		return wrapGormError(res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: sender %s", entities.ErrNotFound, sender)
+	}
+
+	return nil
+}
+
+// DeleteFaded removes the senders without an override last hitting a trap before the given time.
+func (r *ReputationGORMRepo) DeleteFaded(ctx context.Context, before time.Time) (int, error) {
+	res := r.db.WithContext(ctx).
+		Where("override = ? AND last_hit_at < ?", string(entities.ReputationOverrideNone), before).
+		Delete(&SenderReputation{})
+	if res.Error != nil {
+		return 0, wrapGormError(res.Error)
+	}
+
+	return int(res.RowsAffected), nil
+}
+
+func applyReputationFilter(stmt *gorm.DB, filter entities.ReputationFilter) int64 {
+	if len(filter.Senders) > 0 {
+		stmt = stmt.Where("sender IN ?", filter.Senders)
+	}
+
+	if len(filter.Overrides) > 0 {
+		stmt = stmt.Where("override IN ?", filter.Overrides)
+	}
+
+	var count int64
+	stmt = stmt.Count(&count)
+	if filter.Page != 0 && filter.PageSize != 0 {
+		stmt = stmt.Limit(filter.PageSize).Offset((filter.Page - 1) * filter.PageSize)
+	}
+
+	return count
+}
@@ -0,0 +1,122 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReputationTestDB(t *testing.T) *ReputationGORMRepo {
+	config := config.ConfigDB{
+		Driver:   "gorm",
+		LogLevel: "silent",
+		Config: config.ConfigDBDriver{
+			GORM: config.ConfigDBDriverGORM{
+				Driver:           "sqlite",
+				ConnectionString: ":memory:",
+			},
+		},
+	}
+
+	db, err := NewDatabase(config)
+	require.NoError(t, err)
+
+	repo, err := NewReputationGORMRepo(db)
+	require.NoError(t, err)
+
+	return repo.(*ReputationGORMRepo)
+}
+
+func TestNewReputationGORMRepo_NilDB(t *testing.T) {
+	_, err := NewReputationGORMRepo(nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestReputationGORMRepo_SaveAndGet(t *testing.T) {
+	repo := setupReputationTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	rep := entities.SenderReputation{Sender: "spam@spam.com", Hits: 1, Score: 1, LastHitAt: now, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.Save(ctx, rep))
+
+	got, err := repo.GetBySender(ctx, "spam@spam.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Hits)
+	assert.True(t, now.Equal(got.LastHitAt))
+
+	// saving the sender again replaces its record
+	rep.Hits, rep.Score, rep.Override = 2, 1.5, entities.ReputationBlock
+	require.NoError(t, repo.Save(ctx, rep))
+	got, err = repo.GetBySender(ctx, "spam@spam.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Hits)
+	assert.Equal(t, 1.5, got.Score)
+	assert.Equal(t, entities.ReputationBlock, got.Override)
+
+	_, err = repo.GetBySender(ctx, "other@spam.com")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func TestReputationGORMRepo_GetAll(t *testing.T) {
+	repo := setupReputationTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	require.NoError(t, repo.Save(ctx, entities.SenderReputation{Sender: "old@spam.com", Hits: 1, Score: 1, LastHitAt: now.Add(-time.Hour)}))
+	require.NoError(t, repo.Save(ctx, entities.SenderReputation{Sender: "new@spam.com", Hits: 1, Score: 1, LastHitAt: now}))
+	require.NoError(t, repo.Save(ctx, entities.SenderReputation{Sender: "friend@shop.com", Override: entities.ReputationAllow}))
+
+	reps, _, err := repo.GetAll(ctx, entities.ReputationFilter{})
+	require.NoError(t, err)
+	require.Len(t, reps, 3)
+	assert.Equal(t, entities.Email("new@spam.com"), reps[0].Sender)
+
+	reps, _, err = repo.GetAll(ctx, entities.ReputationFilter{Overrides: []entities.ReputationOverride{entities.ReputationAllow}})
+	require.NoError(t, err)
+	require.Len(t, reps, 1)
+	assert.Equal(t, entities.Email("friend@shop.com"), reps[0].Sender)
+
+	reps, _, err = repo.GetAll(ctx, entities.ReputationFilter{Senders: []entities.Email{"old@spam.com"}})
+	require.NoError(t, err)
+	require.Len(t, reps, 1)
+
+	reps, pgm, err := repo.GetAll(ctx, entities.ReputationFilter{Filter: entities.Filter{Page: 2, PageSize: 2}})
+	require.NoError(t, err)
+	assert.Len(t, reps, 1)
+	assert.Equal(t, 3, pgm.TotalRecords)
+}
+
+func TestReputationGORMRepo_Delete(t *testing.T) {
+	repo := setupReputationTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Save(ctx, entities.SenderReputation{Sender: "spam@spam.com", Override: entities.ReputationBlock}))
+	require.NoError(t, repo.Delete(ctx, "spam@spam.com"))
+	assert.ErrorIs(t, repo.Delete(ctx, "spam@spam.com"), entities.ErrNotFound)
+}
+
+func TestReputationGORMRepo_DeleteFaded(t *testing.T) {
+	repo := setupReputationTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	require.NoError(t, repo.Save(ctx, entities.SenderReputation{Sender: "faded@spam.com", Hits: 1, Score: 1, LastHitAt: now.Add(-48 * time.Hour)}))
+	require.NoError(t, repo.Save(ctx, entities.SenderReputation{Sender: "recent@spam.com", Hits: 1, Score: 1, LastHitAt: now}))
+	// the senders with an override are kept whenever they hit the traps
+	require.NoError(t, repo.Save(ctx, entities.SenderReputation{Sender: "blocked@spam.com", Hits: 1, Score: 1, LastHitAt: now.Add(-48 * time.Hour), Override: entities.ReputationBlock}))
+
+	n, err := repo.DeleteFaded(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = repo.GetBySender(ctx, "faded@spam.com")
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	_, err = repo.GetBySender(ctx, "blocked@spam.com")
+	assert.NoError(t, err)
+}
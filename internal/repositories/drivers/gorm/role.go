@@ -0,0 +1,114 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleGORMRepo represents a GORM-based repository for managing Role entities.
+type RoleGORMRepo struct {
+	db *gorm.DB
+}
+
+// NewRoleGORMRepo creates a new instance of RoleGORMRepo.
+// It returns an error if the provided database connection is nil.
+func NewRoleGORMRepo(db *gorm.DB) (repositories.RolesReadWriter, error) {
+	if db == nil {
+		return &RoleGORMRepo{}, fmt.Errorf("%w: database can not be nil", entities.ErrConfiguration)
+	}
+
+	return &RoleGORMRepo{db: db}, nil
+}
+
+// Create adds a new role to the database.
+func (r *RoleGORMRepo) Create(ctx context.Context, role entities.Role) error {
+	gorm_role := roleFromEntity(role)
+	if err := r.db.WithContext(ctx).Model(&Role{}).Create(&gorm_role).Error; err != nil {
+		return wrapGormError(err)
+	}
+
+	return nil
+}
+
+// Update modifies an existing role in the database.
+func (r *RoleGORMRepo) Update(ctx context.Context, role entities.Role) (entities.Role, error) {
+	gorm_role := roleFromEntity(role)
+	if err := r.db.WithContext(ctx).Model(&Role{}).Select("*").Where("id = ?", role.ID).Updates(&gorm_role).Error; err != nil {
+		return entities.Role{}, wrapGormError(err)
+	}
+
+	return roleToEntity(gorm_role), nil
+}
+
+// Delete removes a role from the database by ID.
+func (r *RoleGORMRepo) Delete(ctx context.Context, cuser entities.User, id entities.Id) error {
+	if _, err := r.GetById(ctx, id); err != nil {
+		return err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&Role{}).Where("id = ?", id).
+		Updates(map[string]any{"updated_by_id": cuser.ID.String()}).Error; err != nil {
+		return wrapGormError(err)
+	}
+
+	if err := r.db.WithContext(ctx).Model(&Role{}).Unscoped().
+		Delete(&Role{}, "id = ?", id.String()).Error; err != nil {
+		return wrapGormError(err)
+	}
+	return nil
+}
+
+// GetById retrieves a role from the database by ID.
+func (r *RoleGORMRepo) GetById(ctx context.Context, id entities.Id) (entities.Role, error) {
+	role := Role{}
+	if err := r.db.WithContext(ctx).Preload(clause.Associations).Model(&Role{}).Where("id = ?", id).First(&role).Error; err != nil {
+		return entities.Role{}, wrapGormError(err)
+	}
+
+	return roleToEntity(role), nil
+}
+
+// GetByName retrieves a role from the database by its unique name.
+func (r *RoleGORMRepo) GetByName(ctx context.Context, name string) (entities.Role, error) {
+	role := Role{}
+	if err := r.db.WithContext(ctx).Preload(clause.Associations).Model(&Role{}).Where("name = ?", name).First(&role).Error; err != nil {
+		return entities.Role{}, wrapGormError(err)
+	}
+
+	return roleToEntity(role), nil
+}
+
+// GetAll retrieves all roles matching the filter from the database.
+func (r *RoleGORMRepo) GetAll(ctx context.Context, filter entities.RoleFilter) ([]entities.Role, entities.PaginationMetadata, error) {
+	gorm_roles := make([]Role, 0)
+	stmt := r.db.WithContext(ctx).Model(&Role{})
+	count := applyRoleFilter(stmt, filter)
+	if err := stmt.Preload(clause.Associations).Order("name").Find(&gorm_roles).Error; err != nil {
+		return nil, entities.PaginationMetadata{}, wrapGormError(err)
+	}
+
+	return roleToEntityList(gorm_roles), entities.GetPaginationMetadata(filter.Page, filter.PageSize, count), nil
+}
+
+func applyRoleFilter(stmt *gorm.DB, filter entities.RoleFilter) int64 {
+	if len(filter.Ids) > 0 {
+		stmt = stmt.Where("id IN ?", filter.Ids)
+	}
+
+	if len(filter.Names) > 0 {
+		stmt = stmt.Where("name IN ?", filter.Names)
+	}
+
+	var count int64
+	stmt = stmt.Count(&count)
+	if filter.Page != 0 && filter.PageSize != 0 {
+		stmt = stmt.Limit(filter.PageSize).Offset((filter.Page - 1) * filter.PageSize)
+	}
+
+	return count
+}
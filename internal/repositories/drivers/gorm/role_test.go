@@ -0,0 +1,130 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRoleTestDB(t *testing.T) (*RoleGORMRepo, *UserGORMRepo, entities.User) {
+	t.Helper()
+	cfg := config.ConfigDB{
+		Driver:   "gorm",
+		LogLevel: "silent",
+		Config: config.ConfigDBDriver{
+			GORM: config.ConfigDBDriverGORM{
+				Driver:           "sqlite",
+				ConnectionString: ":memory:",
+			},
+		},
+	}
+
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+
+	userRepo, err := NewUserGORMRepo(db)
+	require.NoError(t, err)
+
+	admin := entities.User{
+		ID:           entities.NewId(),
+		Login:        "admin@example.com",
+		Type:         entities.AdminUser,
+		PasswordHash: "hash",
+	}
+	require.NoError(t, userRepo.Create(context.Background(), admin))
+
+	repo, err := NewRoleGORMRepo(db)
+	require.NoError(t, err)
+
+	return repo.(*RoleGORMRepo), userRepo.(*UserGORMRepo), admin
+}
+
+func TestNewRoleGORMRepo_NilDB(t *testing.T) {
+	_, err := NewRoleGORMRepo(nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestRoleGORMRepo_CRUD(t *testing.T) {
+	repo, _, admin := setupRoleTestDB(t)
+	ctx := context.Background()
+
+	role := entities.Role{
+		ID:          entities.NewId(),
+		Name:        "auditor",
+		Description: "read-only access",
+		Permissions: []entities.Permission{entities.PermAliasesReadAll, entities.PermUsersReadAll},
+		UpdatedBy:   admin,
+	}
+	require.NoError(t, repo.Create(ctx, role))
+
+	got, err := repo.GetById(ctx, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, role.Name, got.Name)
+	assert.Equal(t, role.Permissions, got.Permissions)
+	assert.Equal(t, admin.ID, got.UpdatedBy.ID)
+
+	got, err = repo.GetByName(ctx, "auditor")
+	require.NoError(t, err)
+	assert.Equal(t, role.ID, got.ID)
+
+	err = repo.Create(ctx, entities.Role{ID: entities.NewId(), Name: "auditor", UpdatedBy: admin})
+	assert.ErrorIs(t, err, entities.ErrDuplicateEntry)
+
+	role.Permissions = []entities.Permission{entities.PermRolesRead}
+	_, err = repo.Update(ctx, role)
+	require.NoError(t, err)
+	got, err = repo.GetById(ctx, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []entities.Permission{entities.PermRolesRead}, got.Permissions)
+
+	roles, pgm, err := repo.GetAll(ctx, entities.RoleFilter{Filter: entities.Filter{Page: 1, PageSize: 10}})
+	require.NoError(t, err)
+	assert.Len(t, roles, 1)
+	assert.Equal(t, 1, pgm.TotalRecords)
+
+	require.NoError(t, repo.Delete(ctx, admin, role.ID))
+	_, err = repo.GetById(ctx, role.ID)
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func TestRoleGORMRepo_AssignedToUser(t *testing.T) {
+	repo, userRepo, admin := setupRoleTestDB(t)
+	ctx := context.Background()
+
+	role := entities.Role{
+		ID:          entities.NewId(),
+		Name:        "aliases-only",
+		Permissions: []entities.Permission{entities.PermAliasesRead, entities.PermAliasesWrite},
+		UpdatedBy:   admin,
+	}
+	require.NoError(t, repo.Create(ctx, role))
+
+	user := entities.User{
+		ID:           entities.NewId(),
+		Login:        "user@example.com",
+		Type:         entities.RegularUser,
+		PasswordHash: "hash",
+		Role:         &role,
+	}
+	require.NoError(t, userRepo.Create(ctx, user))
+
+	got, err := userRepo.GetById(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Role)
+	assert.Equal(t, role.ID, got.Role.ID)
+	assert.Equal(t, role.Permissions, got.Role.Permissions)
+
+	users, _, err := userRepo.GetAll(ctx, entities.UserFilter{RoleIds: []entities.Id{role.ID}})
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	got.Role = nil
+	require.NoError(t, userRepo.Update(ctx, got))
+	got, err = userRepo.GetByLogin(ctx, user.Login)
+	require.NoError(t, err)
+	assert.Nil(t, got.Role)
+}
@@ -0,0 +1,74 @@
+package gorm
+
+import (
+	"errors"
+
+	"github.com/Burmuley/ovoo/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey is the statement instance key of the span of the query
+const tracingSpanKey = "ovoo:tracing_span"
+
+// registerTracingCallbacks traces the database queries as client spans, children of the span in
+// the context of the statement. The SQL is recorded with placeholders, without the query values.
+func registerTracingCallbacks(db *gorm.DB) error {
+	start := func(operation string) func(db *gorm.DB) {
+		return func(db *gorm.DB) {
+			ctx, span := tracing.Start(db.Statement.Context, "db."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system.name", db.Dialector.Name()),
+					attribute.String("db.operation.name", operation),
+				),
+			)
+			db.Statement.Context = ctx
+			db.InstanceSet(tracingSpanKey, span)
+		}
+	}
+
+	end := func(db *gorm.DB) {
+		v, ok := db.InstanceGet(tracingSpanKey)
+		if !ok {
+			return
+		}
+
+		span, ok := v.(trace.Span)
+		if !ok {
+			return
+		}
+
+		if db.Statement.Table != "" {
+			span.SetAttributes(attribute.String("db.collection.name", db.Statement.Table))
+		}
+		span.SetAttributes(attribute.String("db.query.text", db.Statement.SQL.String()))
+		if db.RowsAffected >= 0 {
+			span.SetAttributes(attribute.Int64("db.response.returned_rows", db.RowsAffected))
+		}
+
+		// a missing record is an expected result of a lookup, not a failure of the query
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		tracing.End(span, err)
+	}
+
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("ovoo:tracing_start_create", start("create")),
+		cb.Create().After("gorm:create").Register("ovoo:tracing_end_create", end),
+		cb.Query().Before("gorm:query").Register("ovoo:tracing_start_query", start("query")),
+		cb.Query().After("gorm:query").Register("ovoo:tracing_end_query", end),
+		cb.Update().Before("gorm:update").Register("ovoo:tracing_start_update", start("update")),
+		cb.Update().After("gorm:update").Register("ovoo:tracing_end_update", end),
+		cb.Delete().Before("gorm:delete").Register("ovoo:tracing_start_delete", start("delete")),
+		cb.Delete().After("gorm:delete").Register("ovoo:tracing_end_delete", end),
+		cb.Row().Before("gorm:row").Register("ovoo:tracing_start_row", start("row")),
+		cb.Row().After("gorm:row").Register("ovoo:tracing_end_row", end),
+		cb.Raw().Before("gorm:raw").Register("ovoo:tracing_start_raw", start("raw")),
+		cb.Raw().After("gorm:raw").Register("ovoo:tracing_end_raw", end),
+	)
+}
@@ -73,7 +73,7 @@ func (u *UserGORMRepo) Delete(ctx context.Context, cuser entities.User, id entit
 // GetById retrieves a user from the database by ID.
 func (u *UserGORMRepo) GetById(ctx context.Context, id entities.Id) (entities.User, error) {
 	user := User{}
-	if err := u.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).First(&user).Error; err != nil {
+	if err := u.db.WithContext(ctx).Model(&User{}).Preload("Role").Where("id = ?", id).First(&user).Error; err != nil {
 		return entities.User{}, wrapGormError(err)
 	}
 
@@ -83,7 +83,7 @@ func (u *UserGORMRepo) GetById(ctx context.Context, id entities.Id) (entities.Us
 // GetByLogin retrieves a user from the database by login (email).
 func (u *UserGORMRepo) GetByLogin(ctx context.Context, login string) (entities.User, error) {
 	user := User{}
-	if err := u.db.WithContext(ctx).Model(&User{}).Where("login = ?", login).First(&user).Error; err != nil {
+	if err := u.db.WithContext(ctx).Model(&User{}).Preload("Role").Where("login = ?", login).First(&user).Error; err != nil {
 		return entities.User{}, wrapGormError(err)
 	}
 
@@ -95,7 +95,7 @@ func (u *UserGORMRepo) GetAll(ctx context.Context, filter entities.UserFilter) (
 	gorm_users := make([]User, 0)
 	stmt := u.db.WithContext(ctx).Model(&User{})
 	count := applyUserFilter(stmt, filter)
-	if err := stmt.Find(&gorm_users).Error; err != nil {
+	if err := stmt.Preload("Role").Find(&gorm_users).Error; err != nil {
 		return nil, entities.PaginationMetadata{}, wrapGormError(err)
 	}
 
@@ -121,6 +121,10 @@ func applyUserFilter(stmt *gorm.DB, filter entities.UserFilter) *int64 {
 		stmt.Where("active = ?", *filter.Active)
 	}
 
+	if len(filter.RoleIds) > 0 {
+		stmt.Where("role_id IN ?", filter.RoleIds)
+	}
+
 	var count int64
 	stmt.Count(&count)
 	if filter.Page != 0 && filter.PageSize != 0 {
@@ -180,6 +180,67 @@ func TestUserGORMRepo_Update(t *testing.T) {
 	assert.Equal(t, "Smith", retrieved.LastName)
 }
 
+func TestUserGORMRepo_Update_MFA(t *testing.T) {
+	repo := setupUserTestDB(t)
+	ctx := context.Background()
+
+	user := entities.User{
+		ID:    entities.NewId(),
+		Login: "mfa@example.com",
+		Type:  entities.AdminUser,
+	}
+	require.NoError(t, repo.Create(ctx, user))
+
+	_, hashes, err := entities.NewRecoveryCodes()
+	require.NoError(t, err)
+	user.MFA = entities.UserMFA{
+		TOTPSecret:    "JBSWY3DPEHPK3PXP",
+		TOTPEnabled:   true,
+		TOTPLastStep:  42,
+		RecoveryCodes: hashes,
+		WebAuthn: []entities.WebAuthnCredential{{
+			ID:           entities.NewId(),
+			Name:         "key",
+			CredentialID: []byte{1, 2, 3},
+			PublicKey:    []byte{4, 5, 6},
+			SignCount:    7,
+			CreatedAt:    time.Now().UTC().Truncate(time.Second),
+		}},
+	}
+	require.NoError(t, repo.Update(ctx, user))
+
+	retrieved, err := repo.GetById(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, retrieved.MFAEnabled())
+	assert.Equal(t, user.MFA.TOTPSecret, retrieved.MFA.TOTPSecret)
+	assert.Equal(t, int64(42), retrieved.MFA.TOTPLastStep)
+	assert.Equal(t, hashes, retrieved.MFA.RecoveryCodes)
+	require.Len(t, retrieved.MFA.WebAuthn, 1)
+	assert.Equal(t, user.MFA.WebAuthn[0].CredentialID, retrieved.MFA.WebAuthn[0].CredentialID)
+	assert.Equal(t, uint32(7), retrieved.MFA.WebAuthn[0].SignCount)
+	assert.True(t, user.MFA.WebAuthn[0].CreatedAt.Equal(retrieved.MFA.WebAuthn[0].CreatedAt))
+}
+
+func TestUserGORMRepo_Update_Notifications(t *testing.T) {
+	repo := setupUserTestDB(t)
+	ctx := context.Background()
+
+	user := entities.User{
+		ID:    entities.NewId(),
+		Login: "notify@example.com",
+		Type:  entities.RegularUser,
+	}
+	require.NoError(t, repo.Create(ctx, user))
+
+	user.Notifications = user.Notifications.Set(entities.NotificationNewSender, false)
+	require.NoError(t, repo.Update(ctx, user))
+
+	retrieved, err := repo.GetById(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, retrieved.Notifications.Enabled(entities.NotificationNewSender))
+	assert.True(t, retrieved.Notifications.Enabled(entities.NotificationTokenExpiring))
+}
+
 func TestUserGORMRepo_Delete(t *testing.T) {
 	repo := setupUserTestDB(t)
 	ctx := context.Background()
@@ -44,5 +44,16 @@ func newCachedRepoFactory(cache cache.Cache, repoFactory *RepoFactory, config *c
 		}
 	}
 
+	{
+		var err error
+		if cachedRF.Roles, err = cached.NewCachedRolesRepo(cache, repoFactory.Roles, config); err != nil {
+			return nil, err
+		}
+	}
+
+	cachedRF.Notifications = repoFactory.Notifications
+	cachedRF.Quarantine = repoFactory.Quarantine
+	cachedRF.Reputation = repoFactory.Reputation
+	cachedRF.Database = repoFactory.Database
 	return cachedRF, nil
 }
@@ -7,8 +7,6 @@ import (
 	"log/slog"
 
 	"github.com/Burmuley/ovoo/internal/cache"
-	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
-	"github.com/Burmuley/ovoo/internal/cache/drivers/redis"
 	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/repositories"
@@ -21,6 +19,21 @@ type RepoFactory struct {
 	ApiTokens repositories.TokensReadWriter
 	Chain     repositories.ChainReadWriter
 	Domain    repositories.CustomDomainsReadWriter
+	Roles     repositories.RolesReadWriter
+	// Notifications queues the email notifications, it is not cached.
+	// It is nil when the notifications are disabled.
+	Notifications repositories.NotificationsQueue
+	// Quarantine keeps the messages held for the aliases, it is not cached.
+	// It is nil when the quarantine is disabled.
+	Quarantine repositories.QuarantineReadWriter
+	// Reputation keeps the sender reputation, it is not cached so that the hits and overrides
+	// apply on all API instances at once.
+	Reputation repositories.ReputationReadWriter
+	// Database checks the connection to the database, it is not cached.
+	Database repositories.DatabasePinger
+	// Cache keeps ephemeral state, like server-side sessions, shared between the API instances.
+	// It is an in-memory cache when no cache is configured.
+	Cache cache.Cache
 }
 
 // New creates a new RepoFactory instance based on the provided repository type and configuration.
@@ -50,24 +63,19 @@ func New(
 		return nil, fmt.Errorf("%w: unknown repository type", entities.ErrConfiguration)
 	}
 
-	if cacheConfig != nil {
-		var cache cache.Cache
-		var err error
-		switch cacheConfig.CacheDriver {
-		case "memory":
-			cache, _ = memory.New()
-		case "redis":
-			cache, err = redis.New(*cacheConfig)
-		default:
-			return nil, fmt.Errorf("%w: unknown cache driver '%s'", entities.ErrConfiguration, cacheConfig.CacheDriver)
-		}
+	repoCache, err := cache.New(cacheConfig)
+	if err != nil {
+		return nil, err
+	}
 
-		repoFactory, err = newCachedRepoFactory(cache, repoFactory, cacheConfig)
+	if cacheConfig != nil {
+		repoFactory, err = newCachedRepoFactory(repoCache, repoFactory, cacheConfig)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %w", entities.ErrConfiguration, err)
 		}
 	}
 
+	repoFactory.Cache = repoCache
 	return repoFactory, nil
 
 }
@@ -82,6 +90,15 @@ func handleDefaultAdmin(logger *slog.Logger, repo *RepoFactory, defAdminCfg *con
 		PasswordHash: defAdminCfg.Password,
 	}
 
+	// the password can be configured in plain text or already hashed
+	if defAdminCfg.Password != "" && !entities.IsPasswordHash(defAdminCfg.Password) {
+		hash, err := entities.NewPasswordHash(defAdminCfg.Password)
+		if err != nil {
+			return fmt.Errorf("hashing default admin password: %w", err)
+		}
+		adminUser.PasswordHash = hash
+	}
+
 	if err := repo.Users.Create(context.Background(), adminUser); err != nil {
 		if errors.Is(err, entities.ErrDuplicateEntry) {
 			logger.Info("default admin user already present in the repository, not creating")
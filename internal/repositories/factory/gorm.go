@@ -38,5 +38,25 @@ func newGormRepoFactory(config config.ConfigDB) (*RepoFactory, error) {
 		return nil, err
 	}
 
+	if repoFactory.Roles, err = gorm.NewRoleGORMRepo(db); err != nil {
+		return nil, err
+	}
+
+	if repoFactory.Notifications, err = gorm.NewNotificationGORMRepo(db); err != nil {
+		return nil, err
+	}
+
+	if repoFactory.Quarantine, err = gorm.NewQuarantineGORMRepo(db); err != nil {
+		return nil, err
+	}
+
+	if repoFactory.Reputation, err = gorm.NewReputationGORMRepo(db); err != nil {
+		return nil, err
+	}
+
+	if repoFactory.Database, err = gorm.NewHealthGORMRepo(db); err != nil {
+		return nil, err
+	}
+
 	return repoFactory, nil
 }
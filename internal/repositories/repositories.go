@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/Burmuley/ovoo/internal/entities"
 )
@@ -41,6 +42,7 @@ type ChainWriter interface {
 	BatchCreate(ctx context.Context, chains []entities.Chain) error
 	Delete(ctx context.Context, cuser entities.User, hash entities.Hash) (entities.Chain, error)
 	BatchDelete(ctx context.Context, cuser entities.User, hashes []entities.Hash) error
+	Update(ctx context.Context, chain entities.Chain) error
 }
 
 // ChainReadWriter combines ChainReader and ChainWriter interfaces.
@@ -109,3 +111,63 @@ type CustomDomainsReadWriter interface {
 	CustomDomainsReader
 	CustomDomainWriter
 }
+
+// RolesReader defines methods for reading role data.
+type RolesReader interface {
+	GetById(ctx context.Context, id entities.Id) (entities.Role, error)
+	GetByName(ctx context.Context, name string) (entities.Role, error)
+	GetAll(ctx context.Context, filter entities.RoleFilter) ([]entities.Role, entities.PaginationMetadata, error)
+}
+
+// RolesWriter defines methods for writing role data.
+type RolesWriter interface {
+	Create(ctx context.Context, role entities.Role) error
+	Update(ctx context.Context, role entities.Role) (entities.Role, error)
+	Delete(ctx context.Context, cuser entities.User, id entities.Id) error
+}
+
+// RolesReadWriter combines RolesReader and RolesWriter interfaces.
+type RolesReadWriter interface {
+	RolesReader
+	RolesWriter
+}
+
+// DatabasePinger checks the connection to the database, it is used by the readiness probe of the API.
+type DatabasePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// NotificationsQueue keeps the email notifications until they are delivered.
+type NotificationsQueue interface {
+	// Enqueue adds the notification to the queue, a notification with the key of
+	// an already queued one is rejected with entities.ErrDuplicateEntry.
+	Enqueue(ctx context.Context, n entities.Notification) error
+	// Claim returns up to limit queued notifications due for delivery, postponing their
+	// next attempt by lease so that they are not claimed by another instance meanwhile.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]entities.Notification, error)
+	Update(ctx context.Context, n entities.Notification) error
+	// DeleteFinished removes the sent and failed notifications created before the time.
+	DeleteFinished(ctx context.Context, before time.Time) error
+}
+
+// QuarantineReadWriter keeps the messages held in the quarantine until they are released, deleted or expire.
+type QuarantineReadWriter interface {
+	GetById(ctx context.Context, id entities.Id) (entities.QuarantinedMessage, error)
+	// GetAll returns the messages without their data.
+	GetAll(ctx context.Context, filter entities.QuarantineFilter) ([]entities.QuarantinedMessage, entities.PaginationMetadata, error)
+	Create(ctx context.Context, msg entities.QuarantinedMessage) error
+	Delete(ctx context.Context, id entities.Id) error
+	// DeleteExpired removes the messages expired before the time and returns their number.
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
+
+// ReputationReadWriter keeps the sender reputation built from the mail to the trap addresses.
+type ReputationReadWriter interface {
+	GetBySender(ctx context.Context, sender entities.Email) (entities.SenderReputation, error)
+	GetAll(ctx context.Context, filter entities.ReputationFilter) ([]entities.SenderReputation, entities.PaginationMetadata, error)
+	// Save creates the record of the sender or replaces the existing one.
+	Save(ctx context.Context, rep entities.SenderReputation) error
+	Delete(ctx context.Context, sender entities.Email) error
+	// DeleteFaded removes the senders without an override last hitting a trap before the time and returns their number.
+	DeleteFaded(ctx context.Context, before time.Time) (int, error)
+}
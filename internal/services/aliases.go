@@ -19,6 +19,15 @@ type AliasCreateCmd struct {
 		ServiceName *string
 	}
 	Prefix *string
+	// PrivacyFilter is one of the PrivacyFilter* modes
+	PrivacyFilter *string
+	Quarantine    *entities.QuarantineMode
+	// Rules is the rules script filtering the mail of the alias
+	Rules *string
+	// Webhook is the url of the endpoint the mail of the alias is posted to, it replaces the protected address
+	Webhook *string
+	// LeakRestrict makes the alias refuse the mail of unknown senders once it is leaked
+	LeakRestrict *bool
 }
 
 type AliasUpdateCmd struct {
@@ -27,9 +36,26 @@ type AliasUpdateCmd struct {
 		Comment     *string
 		ServiceName *string
 	}
-	Active *bool
+	Active        *bool
+	PrivacyFilter *string
+	Quarantine    *entities.QuarantineMode
+	// Rules replaces the rules script of the alias, an empty script removes it
+	Rules *string
+	// Webhook replaces the url of the endpoint of an alias delivering to a webhook, the secret is kept
+	Webhook *string
+	// LeakRestrict makes the alias refuse the mail of unknown senders once it is leaked
+	LeakRestrict *bool
+	// ResolveLeak clears the leak of the alias, the sender revealing it becomes known
+	ResolveLeak *bool
 }
 
+// Privacy filter modes of an alias, the inherit mode follows the owner default
+const (
+	PrivacyFilterInherit  = "inherit"
+	PrivacyFilterEnabled  = "enabled"
+	PrivacyFilterDisabled = "disabled"
+)
+
 // AliasesService handles operations related to alias addresses.
 type AliasesService struct {
 	repof           *factory.RepoFactory
@@ -59,21 +85,52 @@ func (als *AliasesService) Create(
 		return entities.Address{}, entities.ErrNotAuthorized
 	}
 
-	protAddr, err := als.repof.Address.GetById(ctx, entities.Id(cmd.ProtectedAddressId))
-	if err != nil {
-		if errors.Is(err, entities.ErrNotFound) {
+	if (cmd.Webhook != nil) == (cmd.ProtectedAddressId != "") {
+		return entities.Address{}, fmt.Errorf("%w: either protected address or webhook should be set", entities.ErrValidation)
+	}
+
+	var target entities.Address
+	if cmd.Webhook != nil {
+		webhook, err := entities.NewWebhook(*cmd.Webhook)
+		if err != nil {
 			return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
 		}
 
-		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrDatabase, err)
-	}
+		target = entities.Address{
+			Type:      entities.WebhookAddress,
+			ID:        entities.NewId(),
+			Owner:     cuser,
+			UpdatedBy: cuser,
+			Active:    true,
+			Webhook:   &webhook,
+		}
+	} else {
+		protAddr, err := als.repof.Address.GetById(ctx, entities.Id(cmd.ProtectedAddressId))
+		if err != nil {
+			if errors.Is(err, entities.ErrNotFound) {
+				return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+			}
+
+			return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrDatabase, err)
+		}
 
-	if err := protAddr.Validate(); err != nil {
-		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		if protAddr.Type != entities.ProtectedAddress {
+			return entities.Address{}, fmt.Errorf("%w: %s is not a protected address", entities.ErrValidation, protAddr.ID)
+		}
+
+		if !protAddr.Active {
+			return entities.Address{}, fmt.Errorf("%w: can not create alias for inactive protected address", entities.ErrValidation)
+		}
+
+		if protAddr.Pending {
+			return entities.Address{}, fmt.Errorf("%w: protected address is not verified", entities.ErrValidation)
+		}
+
+		target = protAddr
 	}
 
-	if !protAddr.Active {
-		return entities.Address{}, fmt.Errorf("%w: can not create alias for inactive protected address", entities.ErrValidation)
+	if err := target.Validate(); err != nil {
+		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
 	}
 
 	if err := cmd.DomainId.Validate(); err != nil {
@@ -85,6 +142,10 @@ func (als *AliasesService) Create(
 		return entities.Address{}, fmt.Errorf("%w: unknown or inactive domain %q", entities.ErrValidation, cmd.DomainId)
 	}
 
+	if !cuser.AllowsDomain(domain.Name) {
+		return entities.Address{}, entities.ErrNotAuthorized
+	}
+
 	aliasEmail, err := entities.GenAliasEmail(domain.Name, als.wordsDictionary, cmd.Prefix)
 	if err != nil {
 		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
@@ -103,17 +164,47 @@ func (als *AliasesService) Create(
 		Type:           entities.AliasAddress,
 		ID:             entities.NewId(),
 		Email:          aliasEmail,
-		ForwardAddress: &protAddr,
+		ForwardAddress: &target,
 		Metadata:       metadata,
 		Owner:          cuser,
 		UpdatedBy:      cuser,
 		Active:         true,
 	}
 
+	if cmd.PrivacyFilter != nil {
+		if alias.PrivacyFilter, err = privacyFilterSetting(*cmd.PrivacyFilter); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
+	if cmd.Quarantine != nil {
+		if err := cmd.Quarantine.Validate(); err != nil {
+			return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+		alias.Quarantine = *cmd.Quarantine
+	}
+
+	if cmd.Rules != nil {
+		if alias.Rules, err = parseRules(ctx, als.repof, cuser, *cmd.Rules); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
+	if cmd.LeakRestrict != nil {
+		alias.LeakRestrict = *cmd.LeakRestrict
+	}
+
 	if err := alias.Validate(); err != nil {
 		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
 	}
 
+	// the webhook address is created with the alias, it is not shared with other aliases
+	if target.Type == entities.WebhookAddress {
+		if err := als.repof.Address.Create(ctx, target); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
 	if err := als.repof.Address.Create(ctx, alias); err != nil {
 		return entities.Address{}, err
 	}
@@ -153,10 +244,58 @@ func (als *AliasesService) Update(ctx context.Context, cuser entities.User, cmd
 		}
 	}
 
+	if cmd.PrivacyFilter != nil {
+		if alias.PrivacyFilter, err = privacyFilterSetting(*cmd.PrivacyFilter); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
+	if cmd.Quarantine != nil {
+		if err := cmd.Quarantine.Validate(); err != nil {
+			return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+		alias.Quarantine = *cmd.Quarantine
+	}
+
+	if cmd.Rules != nil {
+		if alias.Rules, err = parseRules(ctx, als.repof, alias.Owner, *cmd.Rules); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
+	if cmd.LeakRestrict != nil {
+		alias.LeakRestrict = *cmd.LeakRestrict
+	}
+
+	if cmd.ResolveLeak != nil && *cmd.ResolveLeak {
+		resolveLeak(&alias)
+	}
+
+	var webhook *entities.Address
+	if cmd.Webhook != nil {
+		if alias.ForwardAddress == nil || alias.ForwardAddress.Type != entities.WebhookAddress {
+			return entities.Address{}, fmt.Errorf("%w: alias does not deliver to a webhook", entities.ErrValidation)
+		}
+
+		if err := entities.ValidateWebhookURL(*cmd.Webhook); err != nil {
+			return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+
+		webhook = alias.ForwardAddress
+		webhook.Webhook = &entities.Webhook{URL: *cmd.Webhook, Secret: webhook.Webhook.Secret}
+		webhook.UpdatedBy = cuser
+	}
+
 	if err := alias.Validate(); err != nil {
 		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
 	}
 
+	if webhook != nil {
+		if err := als.repof.Address.Update(ctx, *webhook); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
 	if err := als.repof.Address.Update(ctx, alias); err != nil {
 		return entities.Address{}, err
 	}
@@ -171,15 +310,19 @@ func (als *AliasesService) GetAll(ctx context.Context, cuser entities.User, filt
 	}
 
 	filter.Types = []entities.AddressType{entities.AliasAddress}
-	// reset Owners filter for non-admins
-	if cuser.Type != entities.AdminUser {
+	// reset Owners filter for users not allowed to read addresses of other users
+	if !cuser.HasPermission(entities.PermAliasesReadAll) {
 		filter.Owners = []entities.Id{cuser.ID}
-	} else if slices.Contains(filter.Owners, "all") && cuser.Type == entities.AdminUser {
+	} else if slices.Contains(filter.Owners, "all") {
 		filter.Owners = nil
 	} else if filter.Owners == nil {
 		filter.Owners = []entities.Id{cuser.ID}
 	}
 
+	if cuser.Scope != nil && len(cuser.Scope.Domains) > 0 {
+		filter.Domains = cuser.Scope.Domains
+	}
+
 	return als.repof.Address.GetAll(ctx, filter)
 }
 
@@ -219,5 +362,25 @@ func (als *AliasesService) DeleteById(ctx context.Context, cuser entities.User,
 		return err
 	}
 
+	if alias.ForwardAddress != nil && alias.ForwardAddress.Type == entities.WebhookAddress {
+		if err := als.repof.Address.DeleteById(ctx, cuser, alias.ForwardAddress.ID); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// privacyFilterSetting converts the privacy filter mode to the alias setting, nil for the inherit mode
+func privacyFilterSetting(mode string) (*bool, error) {
+	switch mode {
+	case PrivacyFilterInherit:
+		return nil, nil
+	case PrivacyFilterEnabled:
+		return new(true), nil
+	case PrivacyFilterDisabled:
+		return new(false), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown privacy filter mode %q", entities.ErrValidation, mode)
+	}
+}
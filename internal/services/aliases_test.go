@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -940,3 +941,356 @@ func TestAliasesService_Update_SetActive_NotAuthorized(t *testing.T) {
 	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
 	addressRepo.AssertNotCalled(t, "Update")
 }
+
+func TestAliasesService_Create_PendingProtectedAddress(t *testing.T) {
+	service, repof := setupAliasesService(t)
+	addressRepo := repof.Address.(*MockAddressRepo)
+	ctx := context.Background()
+
+	user := entities.User{
+		ID:    entities.NewId(),
+		Type:  entities.RegularUser,
+		Login: "user@test.com",
+	}
+
+	prAddrId := entities.NewId()
+	pendingPrAddr := entities.Address{
+		ID:      prAddrId,
+		Type:    entities.ProtectedAddress,
+		Email:   "protected@example.com",
+		Owner:   user,
+		Active:  true,
+		Pending: true,
+	}
+
+	cmd := AliasCreateCmd{
+		ProtectedAddressId: string(prAddrId),
+	}
+
+	addressRepo.On("GetById", ctx, prAddrId).Return(pendingPrAddr, nil)
+
+	alias, err := service.Create(ctx, user, cmd)
+
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	assert.Contains(t, err.Error(), "not verified")
+	assert.Equal(t, entities.Address{}, alias)
+	addressRepo.AssertNotCalled(t, "Create")
+}
+
+func TestAliasesService_Update_PrivacyFilter(t *testing.T) {
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: user}
+
+	tests := []struct {
+		name    string
+		current *bool
+		mode    string
+		want    *bool
+		wantErr error
+	}{
+		{name: "enable", mode: PrivacyFilterEnabled, want: new(true)},
+		{name: "disable", current: new(true), mode: PrivacyFilterDisabled, want: new(false)},
+		{name: "inherit", current: new(false), mode: PrivacyFilterInherit, want: nil},
+		{name: "unknown mode", mode: "always", wantErr: entities.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repof := setupAliasesService(t)
+			addressRepo := repof.Address.(*MockAddressRepo)
+			ctx := context.Background()
+
+			alias := entities.Address{
+				ID:             entities.NewId(),
+				Type:           entities.AliasAddress,
+				Email:          "alias123@test.com",
+				ForwardAddress: &protectedAddr,
+				Owner:          user,
+				PrivacyFilter:  tt.current,
+			}
+
+			addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+			if tt.wantErr == nil {
+				addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+			}
+
+			updated, err := service.Update(ctx, user, AliasUpdateCmd{AliasId: alias.ID, PrivacyFilter: &tt.mode})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				addressRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, updated.PrivacyFilter)
+			addressRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAliasesService_Update_Quarantine(t *testing.T) {
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: user}
+
+	tests := []struct {
+		name    string
+		current entities.QuarantineMode
+		mode    entities.QuarantineMode
+		want    entities.QuarantineMode
+		wantErr error
+	}{
+		{name: "hold new senders", mode: entities.QuarantineNewSenders, want: entities.QuarantineNewSenders},
+		{name: "hold all", current: entities.QuarantineNewSenders, mode: entities.QuarantineAll, want: entities.QuarantineAll},
+		{name: "off", current: entities.QuarantineAll, mode: entities.QuarantineOff, want: entities.QuarantineOff},
+		{name: "unknown mode", mode: "always", wantErr: entities.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repof := setupAliasesService(t)
+			addressRepo := repof.Address.(*MockAddressRepo)
+			ctx := context.Background()
+
+			alias := entities.Address{
+				ID:             entities.NewId(),
+				Type:           entities.AliasAddress,
+				Email:          "alias123@test.com",
+				ForwardAddress: &protectedAddr,
+				Owner:          user,
+				Quarantine:     tt.current,
+			}
+
+			addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+			if tt.wantErr == nil {
+				addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+			}
+
+			updated, err := service.Update(ctx, user, AliasUpdateCmd{AliasId: alias.ID, Quarantine: &tt.mode})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				addressRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, updated.Quarantine)
+			addressRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAliasesService_Update_Rules(t *testing.T) {
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	other := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "other@test.com"}
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: user}
+	workAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "work@example.com", Owner: user}
+	foreignAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "foreign@example.com", Owner: other}
+
+	tests := []struct {
+		name      string
+		current   *entities.Rules
+		script    string
+		wantRules bool
+		wantErr   error
+	}{
+		{name: "set rules", script: `if header :contains "subject" "sale" { discard; }`, wantRules: true},
+		{name: "redirect to own protected address", script: `redirect "Work@example.com";`, wantRules: true},
+		{name: "redirect to foreign protected address", script: `redirect "foreign@example.com";`, wantErr: entities.ErrValidation},
+		{name: "redirect to unknown address", script: `redirect "unknown@example.com";`, wantErr: entities.ErrValidation},
+		{name: "syntax error", script: `if header "subject" { discard; }`, wantErr: entities.ErrValidation},
+		{name: "remove rules", current: &entities.Rules{Script: "discard;"}, script: " "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repof := setupAliasesService(t)
+			addressRepo := repof.Address.(*MockAddressRepo)
+			ctx := context.Background()
+
+			alias := entities.Address{
+				ID:             entities.NewId(),
+				Type:           entities.AliasAddress,
+				Email:          "alias123@test.com",
+				ForwardAddress: &protectedAddr,
+				Owner:          user,
+				Rules:          tt.current,
+			}
+
+			addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+			addressRepo.On("GetByEmail", ctx, workAddr.Email).Return([]entities.Address{workAddr}, nil).Maybe()
+			addressRepo.On("GetByEmail", ctx, foreignAddr.Email).Return([]entities.Address{foreignAddr}, nil).Maybe()
+			addressRepo.On("GetByEmail", ctx, entities.Email("unknown@example.com")).Return(nil, entities.ErrNotFound).Maybe()
+			if tt.wantErr == nil {
+				addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+			}
+
+			updated, err := service.Update(ctx, user, AliasUpdateCmd{AliasId: alias.ID, Rules: &tt.script})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				addressRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.wantRules {
+				require.NotNil(t, updated.Rules)
+				assert.Equal(t, tt.script, updated.Rules.Script)
+			} else {
+				assert.Nil(t, updated.Rules)
+			}
+		})
+	}
+}
+
+func TestAliasesService_Create_Webhook(t *testing.T) {
+	service, repof := setupAliasesService(t)
+	addressRepo := repof.Address.(*MockAddressRepo)
+	ctx := context.Background()
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	url := "https://hooks.example.com/mail"
+
+	// the webhook address is created before the alias
+	addressRepo.On("Create", ctx, mock.MatchedBy(func(addr entities.Address) bool {
+		return addr.Type == entities.WebhookAddress
+	})).Return(nil).Once()
+	addressRepo.On("Create", ctx, mock.MatchedBy(func(addr entities.Address) bool {
+		return addr.Type == entities.AliasAddress
+	})).Return(nil).Once()
+
+	alias, err := service.Create(ctx, user, AliasCreateCmd{DomainId: entities.NewId(), Webhook: &url})
+	require.NoError(t, err)
+	require.NotNil(t, alias.ForwardAddress)
+	assert.Equal(t, entities.WebhookAddress, alias.ForwardAddress.Type)
+	require.NotNil(t, alias.ForwardAddress.Webhook)
+	assert.Equal(t, url, alias.ForwardAddress.Webhook.URL)
+	assert.NotEmpty(t, alias.ForwardAddress.Webhook.Secret)
+	addressRepo.AssertExpectations(t)
+}
+
+func TestAliasesService_Create_Webhook_Invalid(t *testing.T) {
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	valid := "https://hooks.example.com/mail"
+	invalid := "ftp://hooks.example.com/mail"
+
+	tests := []struct {
+		name string
+		cmd  AliasCreateCmd
+	}{
+		{name: "invalid url", cmd: AliasCreateCmd{DomainId: entities.NewId(), Webhook: &invalid}},
+		{name: "protected address and webhook", cmd: AliasCreateCmd{DomainId: entities.NewId(), ProtectedAddressId: string(entities.NewId()), Webhook: &valid}},
+		{name: "no target", cmd: AliasCreateCmd{DomainId: entities.NewId()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repof := setupAliasesService(t)
+			addressRepo := repof.Address.(*MockAddressRepo)
+
+			_, err := service.Create(context.Background(), user, tt.cmd)
+			assert.ErrorIs(t, err, entities.ErrValidation)
+			addressRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAliasesService_Update_Webhook(t *testing.T) {
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: user}
+	webhookAddr := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.WebhookAddress,
+		Owner:   user,
+		Webhook: &entities.Webhook{URL: "https://hooks.example.com/old", Secret: "secret"},
+	}
+	url := "https://hooks.example.com/new"
+
+	t.Run("url replaced", func(t *testing.T) {
+		service, repof := setupAliasesService(t)
+		addressRepo := repof.Address.(*MockAddressRepo)
+		ctx := context.Background()
+
+		target := webhookAddr
+		alias := entities.Address{ID: entities.NewId(), Type: entities.AliasAddress, Email: "alias123@test.com", ForwardAddress: &target, Owner: user}
+		addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+		addressRepo.On("Update", ctx, mock.MatchedBy(func(addr entities.Address) bool {
+			return addr.Type == entities.WebhookAddress && addr.Webhook.URL == url && addr.Webhook.Secret == "secret"
+		})).Return(nil).Once()
+		addressRepo.On("Update", ctx, mock.MatchedBy(func(addr entities.Address) bool {
+			return addr.Type == entities.AliasAddress
+		})).Return(nil).Once()
+
+		updated, err := service.Update(ctx, user, AliasUpdateCmd{AliasId: alias.ID, Webhook: &url})
+		require.NoError(t, err)
+		assert.Equal(t, url, updated.ForwardAddress.Webhook.URL)
+		addressRepo.AssertExpectations(t)
+	})
+
+	t.Run("alias forwarding to protected address", func(t *testing.T) {
+		service, repof := setupAliasesService(t)
+		addressRepo := repof.Address.(*MockAddressRepo)
+		ctx := context.Background()
+
+		alias := entities.Address{ID: entities.NewId(), Type: entities.AliasAddress, Email: "alias123@test.com", ForwardAddress: &protectedAddr, Owner: user}
+		addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+
+		_, err := service.Update(ctx, user, AliasUpdateCmd{AliasId: alias.ID, Webhook: &url})
+		assert.ErrorIs(t, err, entities.ErrValidation)
+		addressRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAliasesService_DeleteById_Webhook(t *testing.T) {
+	service, repof := setupAliasesService(t)
+	addressRepo := repof.Address.(*MockAddressRepo)
+	chainRepo := repof.Chain.(*MockChainRepo)
+	ctx := context.Background()
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	webhookAddr := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.WebhookAddress,
+		Owner:   user,
+		Webhook: &entities.Webhook{URL: "https://hooks.example.com/mail", Secret: "secret"},
+	}
+	alias := entities.Address{ID: entities.NewId(), Type: entities.AliasAddress, Email: "alias123@test.com", ForwardAddress: &webhookAddr, Owner: user}
+
+	addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+	chainRepo.On("GetByFilters", ctx, mock.AnythingOfType("entities.ChainFilter")).Return([]entities.Chain{}, nil).Twice()
+	chainRepo.On("BatchDelete", ctx, mock.Anything, mock.AnythingOfType("[]entities.Hash")).Return(nil)
+	addressRepo.On("BatchDeleteById", ctx, mock.Anything, mock.AnythingOfType("[]entities.Id")).Return(nil).Twice()
+	// the webhook address is deleted with the alias
+	addressRepo.On("DeleteById", ctx, mock.Anything, webhookAddr.ID).Return(nil)
+
+	require.NoError(t, service.DeleteById(ctx, user, alias.ID))
+	addressRepo.AssertExpectations(t)
+}
+
+func TestAliasesService_Update_Leak(t *testing.T) {
+	service, repof := setupAliasesService(t)
+	addressRepo := repof.Address.(*MockAddressRepo)
+	ctx := context.Background()
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: user}
+	alias := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          "alias123@test.com",
+		ForwardAddress: &protectedAddr,
+		Owner:          user,
+		SenderDomains:  []string{"shop.com"},
+		Leak:           &entities.AliasLeak{Sender: "news@partner.com", DetectedAt: time.Now()},
+	}
+
+	addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+	addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+
+	updated, err := service.Update(ctx, user, AliasUpdateCmd{AliasId: alias.ID, LeakRestrict: new(true), ResolveLeak: new(true)})
+	require.NoError(t, err)
+	assert.True(t, updated.LeakRestrict)
+	assert.Nil(t, updated.Leak)
+	assert.Equal(t, []string{"shop.com", "partner.com"}, updated.SenderDomains)
+	addressRepo.AssertExpectations(t)
+}
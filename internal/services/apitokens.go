@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,10 +12,15 @@ import (
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
 )
 
+// DefaultTokenExpiryWarning is the default period before the expiration of an API token its owner is notified
+const DefaultTokenExpiryWarning = 7 * 24 * time.Hour
+
 type ApiTokenCreateCmd struct {
 	Description string
 	ExpireIn    int
 	Name        string
+	Scopes      []entities.Permission
+	Domains     []string
 }
 
 type ApiTokenUpdateCmd struct {
@@ -97,6 +103,34 @@ func (t *ApiTokensService) Create(ctx context.Context, cuser entities.User, cmd
 		return entities.ApiToken{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
 	}
 
+	token.Scopes = compactPermissions(cmd.Scopes)
+	token.Domains = make([]string, 0, len(cmd.Domains))
+	for _, domain := range cmd.Domains {
+		token.Domains = append(token.Domains, strings.ToLower(strings.TrimSpace(domain)))
+	}
+	slices.Sort(token.Domains)
+	token.Domains = slices.Compact(token.Domains)
+
+	// tokens created with a scoped token can not exceed the scope of the latter
+	if cuser.Scope != nil {
+		if len(token.Scopes) == 0 {
+			token.Scopes = slices.Clone(cuser.Scope.Permissions)
+		}
+		if len(token.Domains) == 0 {
+			token.Domains = slices.Clone(cuser.Scope.Domains)
+		}
+		for _, scope := range token.Scopes {
+			if !cuser.Scope.Allows(scope) {
+				return entities.ApiToken{}, entities.ErrNotAuthorized
+			}
+		}
+		for _, domain := range token.Domains {
+			if !cuser.AllowsDomain(domain) {
+				return entities.ApiToken{}, entities.ErrNotAuthorized
+			}
+		}
+	}
+
 	token.UpdatedBy = cuser
 	if err := token.Validate(); err != nil {
 		return entities.ApiToken{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
@@ -171,3 +205,29 @@ func (t *ApiTokensService) Delete(ctx context.Context, cuser entities.User, toke
 	err = t.repof.ApiTokens.Delete(ctx, cuser, tokenId)
 	return entities.ApiToken{}, err
 }
+
+// NotifyExpiring notifies the owners of the active API tokens expiring within the given period.
+// Every token is reported once; tokens issued for a shorter period than the warning one are not reported.
+func (t *ApiTokensService) NotifyExpiring(ctx context.Context, within time.Duration) error {
+	now := time.Now()
+	tokens, err := t.repof.ApiTokens.GetAll(ctx, entities.ApiTokenFilter{
+		Active:        new(true),
+		ExpiresBefore: new(now.Add(within)),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.Expired() || token.Expiration.Sub(token.CreatedAt) <= within {
+			continue
+		}
+
+		notify(ctx, t.repof, token.Owner, entities.NotificationTokenExpiring, token.ID.String(), map[string]string{
+			"token":   token.Name,
+			"expires": token.Expiration.UTC().Format(time.RFC1123),
+		})
+	}
+
+	return nil
+}
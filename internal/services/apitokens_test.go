@@ -328,6 +328,94 @@ func TestApiTokensService_Create_NegativeExpireIn(t *testing.T) {
 	assert.Equal(t, entities.ApiToken{}, token)
 }
 
+func TestApiTokensService_Create_Scoped(t *testing.T) {
+	service, tokensRepo := setupApiTokensService(t)
+	ctx := context.Background()
+
+	user := entities.User{
+		ID:    entities.NewId(),
+		Type:  entities.RegularUser,
+		Login: "user@test.com",
+	}
+
+	cmd := ApiTokenCreateCmd{
+		Name:     "Scoped Token",
+		ExpireIn: 30,
+		Scopes:   []entities.Permission{entities.PermAliasesRead, entities.PermAliasesRead},
+		Domains:  []string{" Example.COM "},
+	}
+
+	tokensRepo.On("Create", ctx, mock.AnythingOfType("entities.ApiToken")).Return(nil)
+
+	token, err := service.Create(ctx, user, cmd)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Permission{entities.PermAliasesRead}, token.Scopes)
+	assert.Equal(t, []string{"example.com"}, token.Domains)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestApiTokensService_Create_UnknownScope(t *testing.T) {
+	service, _ := setupApiTokensService(t)
+	ctx := context.Background()
+
+	user := entities.User{
+		ID:    entities.NewId(),
+		Type:  entities.RegularUser,
+		Login: "user@test.com",
+	}
+
+	cmd := ApiTokenCreateCmd{
+		Name:     "Scoped Token",
+		ExpireIn: 30,
+		Scopes:   []entities.Permission{"aliases:delete"},
+	}
+
+	_, err := service.Create(ctx, user, cmd)
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestApiTokensService_Create_ScopedCreator(t *testing.T) {
+	service, tokensRepo := setupApiTokensService(t)
+	ctx := context.Background()
+
+	user := entities.User{
+		ID:    entities.NewId(),
+		Type:  entities.RegularUser,
+		Login: "user@test.com",
+		Scope: &entities.ApiTokenScope{
+			Permissions: []entities.Permission{entities.PermApiTokensWrite, entities.PermAliasesRead},
+			Domains:     []string{"example.com"},
+		},
+	}
+
+	// scope exceeding the creator token scope
+	_, err := service.Create(ctx, user, ApiTokenCreateCmd{
+		Name:     "Wider Token",
+		ExpireIn: 30,
+		Scopes:   []entities.Permission{entities.PermAliasesWrite},
+	})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	_, err = service.Create(ctx, user, ApiTokenCreateCmd{
+		Name:     "Other Domain Token",
+		ExpireIn: 30,
+		Domains:  []string{"example.org"},
+	})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	// scope inherited from the creator token
+	tokensRepo.On("Create", ctx, mock.AnythingOfType("entities.ApiToken")).Return(nil)
+	token, err := service.Create(ctx, user, ApiTokenCreateCmd{
+		Name:     "Inherited Token",
+		ExpireIn: 30,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, user.Scope.Permissions, token.Scopes)
+	assert.Equal(t, user.Scope.Domains, token.Domains)
+}
+
 func TestApiTokensService_Update_Success(t *testing.T) {
 	service, tokensRepo := setupApiTokensService(t)
 	ctx := context.Background()
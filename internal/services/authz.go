@@ -1,149 +1,92 @@
 package services
 
 import (
-	"slices"
-
 	"github.com/Burmuley/ovoo/internal/entities"
 )
 
+// Checks of aliases and custom domains additionally take into account domain restrictions
+// of the API token scope the user was authenticated with, see entities.User.AllowsDomain.
+
+// canAccessOwned determines if the given user can access an object owned by the user with id ownerId.
+// Returns true if the user holds the anyPerm permission, or if the user is the owner and holds the ownPerm permission.
+func canAccessOwned(cuser entities.User, ownerId entities.Id, ownPerm, anyPerm entities.Permission) bool {
+	if cuser.HasPermission(anyPerm) {
+		return true
+	}
+
+	return ownerId == cuser.ID && cuser.HasPermission(ownPerm)
+}
+
 // canGetAliases determines if the given user can retrieve the list of aliases.
-// Returns true if the user is not of type MilterUser.
+// Returns true if the user holds either own or global aliases read permission.
 func canGetAliases(cuser entities.User) bool {
-	return cuser.Type != entities.MilterUser
+	return cuser.HasPermission(entities.PermAliasesRead) || cuser.HasPermission(entities.PermAliasesReadAll)
 }
 
 // canGetAlias determines if the given user can retrieve the specific alias (address).
-// Returns true if the user is an Admin, or if the address is owned by a RegularUser whose id matches the user's id.
+// Returns true if the user can read all aliases, or owns the address and can read own aliases.
 func canGetAlias(cuser entities.User, addr entities.Address) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if addr.Owner.ID == cuser.ID && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return cuser.AllowsEmail(addr.Email) && canAccessOwned(cuser, addr.Owner.ID, entities.PermAliasesRead, entities.PermAliasesReadAll)
 }
 
 // canCreateAlias determines if the given user can create a new alias.
-// Returns true if the user is an Admin or RegularUser.
+// Returns true if the user holds the aliases write permission.
 func canCreateAlias(cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser || cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return cuser.HasPermission(entities.PermAliasesWrite) || cuser.HasPermission(entities.PermAliasesWriteAll)
 }
 
 // canDeleteAlias determines if the user can delete the given alias (address).
-// Returns true if the user is an Admin, or if the address is owned by a RegularUser with the same id.
+// Returns true if the user can modify all aliases, or owns the address and can modify own aliases.
 func canDeleteAlias(cuser entities.User, addr entities.Address) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if addr.Owner.ID == cuser.ID && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return cuser.AllowsEmail(addr.Email) && canAccessOwned(cuser, addr.Owner.ID, entities.PermAliasesWrite, entities.PermAliasesWriteAll)
 }
 
 // canUpdateAlias determines if the given user can update the specific alias (address).
-// Returns true if the user is an Admin, or if the address is owned by a RegularUser with the same id.
+// Returns true if the user can modify all aliases, or owns the address and can modify own aliases.
 func canUpdateAlias(cuser entities.User, addr entities.Address) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if addr.Owner.ID == cuser.ID && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return cuser.AllowsEmail(addr.Email) && canAccessOwned(cuser, addr.Owner.ID, entities.PermAliasesWrite, entities.PermAliasesWriteAll)
 }
 
 // canGetPrAddr determines if the given user can retrieve the specified primary address.
-// Returns true if the user is an Admin, or if the address is owned by a RegularUser with the same id.
+// Returns true if the user can read all protected addresses, or owns the address and can read own ones.
 func canGetPrAddr(cuser entities.User, addr entities.Address) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if addr.Owner.ID == cuser.ID && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return canAccessOwned(cuser, addr.Owner.ID, entities.PermPrAddrsRead, entities.PermPrAddrsReadAll)
 }
 
 // canCreatePrAddr determines if the given user can create a new primary address.
-// Returns true if the user is an Admin or RegularUser.
+// Returns true if the user holds the protected addresses write permission.
 func canCreatePrAddr(cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser || cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return cuser.HasPermission(entities.PermPrAddrsWrite) || cuser.HasPermission(entities.PermPrAddrsWriteAll)
 }
 
 // canUpdatePrAddr determines if the given user can update the specified primary address.
-// Returns true if the user is an Admin, or if the address is owned by a RegularUser with the same id.
+// Returns true if the user can modify all protected addresses, or owns the address and can modify own ones.
 func canUpdatePrAddr(cuser entities.User, addr entities.Address) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if addr.Owner.ID == cuser.ID && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return canAccessOwned(cuser, addr.Owner.ID, entities.PermPrAddrsWrite, entities.PermPrAddrsWriteAll)
 }
 
 // canDeletePrAddr determines if the given user can delete the specified primary address.
-// Returns true if the user is an Admin, or if the address is owned by a RegularUser with the same id.
+// Returns true if the user can modify all protected addresses, or owns the address and can modify own ones.
 func canDeletePrAddr(cuser entities.User, addr entities.Address) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if addr.Owner.ID == cuser.ID && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return canAccessOwned(cuser, addr.Owner.ID, entities.PermPrAddrsWrite, entities.PermPrAddrsWriteAll)
 }
 
 // canGetChain determines if the user can retrieve chain-related data.
-// Returns true if the user is an Admin or a MilterUser.
+// Returns true if the user holds the chains read permission.
 func canGetChain(cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser || cuser.Type == entities.MilterUser {
-		return true
-	}
-
-	return false
+	return cuser.HasPermission(entities.PermChainsRead)
 }
 
 // canCreateChain determines if the user can create a new chain entry.
-// Returns true if the user is an Admin or a MilterUser.
+// Returns true if the user holds the chains create permission.
 func canCreateChain(cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser || cuser.Type == entities.MilterUser {
-		return true
-	}
-
-	return false
+	return cuser.HasPermission(entities.PermChainsCreate)
 }
 
 // canDeleteChain determines if the user can delete a chain entry.
-// Returns true if the user is an Admin or a MilterUser.
+// Returns true if the user holds the chains delete permission.
 func canDeleteChain(cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser || cuser.Type == entities.MilterUser {
-		return true
-	}
-
-	return false
+	return cuser.HasPermission(entities.PermChainsDelete)
 }
 
 // canGetUsers is a stub for determining if fetching all users is permitted.
@@ -153,181 +96,191 @@ func canGetUsers() bool {
 }
 
 // canGetUser determines if cuser can get access to a user with id user_id.
-// Returns true if the user is an Admin, or if cuser is a RegularUser accessing their own user_id.
+// Returns true if cuser can read all users, or accesses their own user_id and can read own profile.
 func canGetUser(cuser entities.User, user_id entities.Id) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if cuser.ID == user_id && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return canAccessOwned(cuser, user_id, entities.PermUsersRead, entities.PermUsersReadAll)
 }
 
 // canCreateUser determines if the given user can create a new user account.
-// Returns true if the user is an Admin.
+// Returns true if the user can modify all users.
 func canCreateUser(cuser entities.User) bool {
-	return cuser.Type == entities.AdminUser
+	return cuser.HasPermission(entities.PermUsersWriteAll)
 }
 
 // canUpdateUser determines if cuser can update a user with id user_id.
-// Returns true if the user is an Admin, or if cuser is a RegularUser updating their own account.
+// Returns true if cuser can modify all users, or updates their own account and can modify own profile.
 func canUpdateUser(cuser entities.User, user_id entities.Id) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if cuser.ID == user_id && cuser.Type == entities.RegularUser {
-		return true
-	}
-
-	return false
+	return canAccessOwned(cuser, user_id, entities.PermUsersWrite, entities.PermUsersWriteAll)
 }
 
 // canDeleteUser determines if cuser can delete the given target user.
-// Returns true if cuser is an Admin and the target user is not themselves.
+// Returns true if cuser can modify all users and the target user is not themselves.
 func canDeleteUser(cuser, targetU entities.User) bool {
-	if cuser.Type == entities.AdminUser && targetU.ID != cuser.ID {
-		return true
-	}
-
-	return false
+	return cuser.HasPermission(entities.PermUsersWriteAll) && targetU.ID != cuser.ID
 }
 
 // canCreateApiToken determines if the given user can create a new API token.
-// Always returns true.
+// Returns true if the user holds the API tokens write permission.
 func canCreateApiToken(cuser entities.User) bool {
-	return true
+	return cuser.HasPermission(entities.PermApiTokensWrite) || cuser.HasPermission(entities.PermApiTokensWriteAll)
 }
 
 // canGetApiToken determines if the user can access the specific API token.
-// Returns true if the user is an Admin or if they are the owner of the token.
+// Returns true if the user can read all tokens, or owns the token and can read own tokens.
 func canGetApiToken(cuser entities.User, token entities.ApiToken) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if cuser.ID == token.Owner.ID {
-		return true
-	}
-
-	return false
+	return canAccessOwned(cuser, token.Owner.ID, entities.PermApiTokensRead, entities.PermApiTokensReadAll)
 }
 
 // canUpdateApiToken determines if the user can update the given API token.
-// Returns true if the user is an Admin or if they are the owner of the token.
+// Returns true if the user can modify all tokens, or owns the token and can modify own tokens.
 func canUpdateApiToken(cuser entities.User, token entities.ApiToken) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
-
-	if cuser.ID == token.Owner.ID {
-		return true
-	}
-
-	return false
+	return canAccessOwned(cuser, token.Owner.ID, entities.PermApiTokensWrite, entities.PermApiTokensWriteAll)
 }
 
 // canDeleteApiToken determines if the user can delete the given API token.
-// Returns true if the user is an Admin or if they are the owner of the token.
+// Returns true if the user can modify all tokens, or owns the token and can modify own tokens.
 func canDeleteApiToken(cuser entities.User, token entities.ApiToken) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+	return canAccessOwned(cuser, token.Owner.ID, entities.PermApiTokensWrite, entities.PermApiTokensWriteAll)
+}
 
-	if cuser.ID == token.Owner.ID {
-		return true
-	}
+// canSetActiveAlias determines if the user can activate or deactivate the given alias.
+func canSetActiveAlias(alias entities.Address, cuser entities.User) bool {
+	return cuser.AllowsEmail(alias.Email) && canAccessOwned(cuser, alias.Owner.ID, entities.PermAliasesWrite, entities.PermAliasesWriteAll)
+}
 
-	return false
+// canSetActivePrAddr determines if the user can activate or deactivate the given protected address.
+func canSetActivePrAddr(praddr entities.Address, cuser entities.User) bool {
+	return canAccessOwned(cuser, praddr.Owner.ID, entities.PermPrAddrsWrite, entities.PermPrAddrsWriteAll)
 }
 
-func canSetActiveAlias(alias entities.Address, cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+// canSetActiveApiToken determines if the user can activate or deactivate the given API token.
+func canSetActiveApiToken(token entities.ApiToken, cuser entities.User) bool {
+	return canAccessOwned(cuser, token.Owner.ID, entities.PermApiTokensWrite, entities.PermApiTokensWriteAll)
+}
 
-	if alias.Owner.ID == cuser.ID {
-		return true
-	}
+// canSetActiveUser determines if the user can activate or deactivate the given user account.
+// Users can not change activity state of their own account.
+func canSetActiveUser(user entities.User, cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermUsersWriteAll) && user.ID != cuser.ID
+}
 
-	return false
+// canGetDomains determines if the user can retrieve the list of custom domains.
+func canGetDomains(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermDomainsRead) || cuser.HasPermission(entities.PermDomainsReadAll)
 }
 
-func canSetActivePrAddr(praddr entities.Address, cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+// canGetDomain determines if the user can retrieve the given custom domain.
+func canGetDomain(cuser entities.User, domain entities.CustomDomain) bool {
+	return cuser.AllowsDomain(domain.Name) && canAccessOwned(cuser, domain.Owner.ID, entities.PermDomainsRead, entities.PermDomainsReadAll)
+}
 
-	if praddr.Owner.ID == cuser.ID {
-		return true
-	}
+// canCreateDomain determines if the user can create a new custom domain.
+func canCreateDomain(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermDomainsWrite) || cuser.HasPermission(entities.PermDomainsWriteAll)
+}
 
-	return false
+// canCreateGlobalDomain determines if the user can create a domain available to all users.
+func canCreateGlobalDomain(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermDomainsWriteGlobal)
 }
 
-func canSetActiveApiToken(token entities.ApiToken, cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+// canUpdateDomain determines if the user can update the given custom domain.
+func canUpdateDomain(cuser entities.User, domain entities.CustomDomain) bool {
+	return cuser.AllowsDomain(domain.Name) && canAccessOwned(cuser, domain.Owner.ID, entities.PermDomainsWrite, entities.PermDomainsWriteAll)
+}
 
-	if token.Owner.ID == cuser.ID {
-		return true
-	}
+// canDeleteDomain determines if the user can delete the given custom domain.
+func canDeleteDomain(cuser entities.User, domain entities.CustomDomain) bool {
+	return cuser.AllowsDomain(domain.Name) && canAccessOwned(cuser, domain.Owner.ID, entities.PermDomainsWrite, entities.PermDomainsWriteAll)
+}
 
-	return false
+// canVerifyDomain determines if the user can run ownership verification of the given custom domain.
+func canVerifyDomain(cuser entities.User, domain entities.CustomDomain) bool {
+	return cuser.AllowsDomain(domain.Name) && canAccessOwned(cuser, domain.Owner.ID, entities.PermDomainsWrite, entities.PermDomainsWriteAll)
 }
 
-func canSetActiveUser(user entities.User, cuser entities.User) bool {
-	if cuser.Type == entities.AdminUser && user.ID != cuser.ID {
-		return true
-	}
+// canGetRoles determines if the user can retrieve roles and the list of available permissions.
+func canGetRoles(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermRolesRead)
+}
 
-	return false
+// canManageRoles determines if the user can create, update, delete and assign roles.
+func canManageRoles(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermRolesWrite)
 }
 
-func canGetDomains(cuser entities.User) bool {
-	return true
+// canGetServiceAccounts determines if the user can list service accounts.
+func canGetServiceAccounts(cuser entities.User) bool {
+	return !cuser.IsServiceAccount() && cuser.HasPermission(entities.PermUsersReadAll)
 }
 
-func canGetDomain(cuser entities.User, domain entities.CustomDomain) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+// canManageServiceAccounts determines if the user can create service accounts and rotate their tokens.
+func canManageServiceAccounts(cuser entities.User) bool {
+	return !cuser.IsServiceAccount() &&
+		cuser.HasPermission(entities.PermUsersWriteAll) &&
+		cuser.HasPermission(entities.PermApiTokensWriteAll)
+}
 
-	return cuser.ID == domain.Owner.ID
+// canManageMFA determines if the user can manage own second factor settings.
+func canManageMFA(cuser entities.User) bool {
+	return !cuser.IsServiceAccount() && cuser.HasPermission(entities.PermUsersWrite)
 }
 
-func canCreateDomain(cuser entities.User) bool {
-	return slices.Contains([]entities.UserType{entities.AdminUser, entities.RegularUser}, cuser.Type)
+// canResetMFA determines if the user can remove second factor settings of any user.
+func canResetMFA(cuser entities.User) bool {
+	return !cuser.IsServiceAccount() && cuser.HasPermission(entities.PermUsersWriteAll)
 }
 
-func canCreateGlobalDomain(cuser entities.User) bool {
-	return cuser.Type == entities.AdminUser
+// canManageSessions determines if the user can list and revoke own server-side sessions.
+func canManageSessions(cuser entities.User) bool {
+	return !cuser.IsServiceAccount()
 }
 
-func canUpdateDomain(cuser entities.User, domain entities.CustomDomain) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+// canManageNotifications determines if the user can manage own email notification settings.
+func canManageNotifications(cuser entities.User) bool {
+	return !cuser.IsServiceAccount() && cuser.HasPermission(entities.PermUsersWrite)
+}
 
-	return cuser.ID == domain.Owner.ID
+// canChangePassword determines if the user can change own password.
+func canChangePassword(cuser entities.User) bool {
+	return !cuser.IsServiceAccount() && cuser.HasPermission(entities.PermUsersWrite)
 }
 
-func canDeleteDomain(cuser entities.User, domain entities.CustomDomain) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+// canResetPassword determines if the user can set or remove the password of any user.
+func canResetPassword(cuser entities.User) bool {
+	return !cuser.IsServiceAccount() && cuser.HasPermission(entities.PermUsersWriteAll)
+}
 
-	return cuser.ID == domain.Owner.ID
+// canHoldQuarantined determines if the user can hold messages in the quarantine, the milter does it for the chains.
+func canHoldQuarantined(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermChainsCreate)
 }
 
-func canVerifyDomain(cuser entities.User, domain entities.CustomDomain) bool {
-	if cuser.Type == entities.AdminUser {
-		return true
-	}
+// canGetReputation determines if the user can read the trap addresses and the sender reputation.
+// Returns true if the user holds the reputation read permission.
+func canGetReputation(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermReputationRead)
+}
+
+// canManageReputation determines if the user can manage the trap addresses and override the reputation of senders.
+// Returns true if the user holds the reputation write permission.
+func canManageReputation(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermReputationWrite)
+}
+
+// canLookupSender determines if the user can look up the reputation of a sender, the socketmap does it for the MTA.
+func canLookupSender(cuser entities.User) bool {
+	return cuser.HasPermission(entities.PermChainsRead) || canGetReputation(cuser)
+}
+
+// canGetQuarantined determines if the user can read the message held for an alias.
+// Returns true if the user can read the alias.
+func canGetQuarantined(cuser entities.User, msg entities.QuarantinedMessage) bool {
+	return canGetAlias(cuser, msg.Alias)
+}
 
-	return cuser.ID == domain.Owner.ID
+// canManageQuarantined determines if the user can release or delete the message held for an alias.
+// Returns true if the user can modify the alias.
+func canManageQuarantined(cuser entities.User, msg entities.QuarantinedMessage) bool {
+	return canUpdateAlias(cuser, msg.Alias)
 }
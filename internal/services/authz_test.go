@@ -657,3 +657,95 @@ func TestCanSetActiveUser_RegularUser(t *testing.T) {
 
 	assert.False(t, canSetActiveUser(targetUser, user))
 }
+
+// Tests for custom roles
+func TestCustomRole_OverridesUserType(t *testing.T) {
+	auditor := createTestUser(entities.AdminUser)
+	auditor.Role = &entities.Role{
+		ID:          entities.NewId(),
+		Name:        "auditor",
+		Permissions: []entities.Permission{entities.PermAliasesReadAll, entities.PermUsersReadAll},
+	}
+	owner := createTestUser(entities.RegularUser)
+	addr := createTestAddress(owner)
+
+	assert.True(t, canGetAlias(auditor, addr))
+	assert.True(t, canGetUser(auditor, owner.ID))
+	assert.False(t, canUpdateAlias(auditor, addr))
+	assert.False(t, canCreateUser(auditor))
+	assert.False(t, canManageRoles(auditor))
+}
+
+func TestCustomRole_OwnScopeOnly(t *testing.T) {
+	user := createTestUser(entities.RegularUser)
+	user.Role = &entities.Role{
+		ID:          entities.NewId(),
+		Name:        "aliases-only",
+		Permissions: []entities.Permission{entities.PermAliasesRead, entities.PermAliasesWrite},
+	}
+	other := createTestUser(entities.RegularUser)
+
+	assert.True(t, canUpdateAlias(user, createTestAddress(user)))
+	assert.False(t, canUpdateAlias(user, createTestAddress(other)))
+	assert.False(t, canGetPrAddr(user, createTestAddress(user)))
+	assert.False(t, canCreateDomain(user))
+}
+
+func TestCanManageRoles(t *testing.T) {
+	assert.True(t, canManageRoles(createTestUser(entities.AdminUser)))
+	assert.False(t, canManageRoles(createTestUser(entities.RegularUser)))
+	assert.False(t, canManageRoles(createTestUser(entities.MilterUser)))
+	assert.True(t, canGetRoles(createTestUser(entities.AdminUser)))
+	assert.False(t, canGetRoles(createTestUser(entities.RegularUser)))
+}
+
+// Tests for scoped API tokens
+func TestScopedToken_LimitsPermissions(t *testing.T) {
+	admin := createTestUser(entities.AdminUser)
+	admin.Scope = &entities.ApiTokenScope{Permissions: []entities.Permission{entities.PermAliasesRead}}
+	other := createTestUser(entities.RegularUser)
+
+	assert.True(t, canGetAlias(admin, createTestAddress(other)))
+	assert.False(t, canUpdateAlias(admin, createTestAddress(other)))
+	assert.False(t, canCreateUser(admin))
+	assert.False(t, canManageRoles(admin))
+}
+
+func TestScopedToken_LimitsDomains(t *testing.T) {
+	user := createTestUser(entities.RegularUser)
+	user.Scope = &entities.ApiTokenScope{Domains: []string{"example.com"}}
+	allowed := createTestAddress(user)
+	denied := createTestAddress(user)
+	denied.Email = "test@example.org"
+
+	assert.True(t, canGetAlias(user, allowed))
+	assert.True(t, canUpdateAlias(user, allowed))
+	assert.False(t, canGetAlias(user, denied))
+	assert.False(t, canDeleteAlias(user, denied))
+
+	domain := entities.CustomDomain{ID: entities.NewId(), Name: "example.org", Owner: user}
+	assert.False(t, canGetDomain(user, domain))
+	domain.Name = "example.com"
+	assert.True(t, canGetDomain(user, domain))
+}
+
+// Tests for service accounts management
+func TestCanManageServiceAccounts(t *testing.T) {
+	assert.True(t, canManageServiceAccounts(createTestUser(entities.AdminUser)))
+	assert.False(t, canManageServiceAccounts(createTestUser(entities.RegularUser)))
+	assert.False(t, canManageServiceAccounts(createTestUser(entities.MilterUser)))
+	assert.True(t, canGetServiceAccounts(createTestUser(entities.AdminUser)))
+	assert.False(t, canGetServiceAccounts(createTestUser(entities.RegularUser)))
+
+	// service accounts can not manage other service accounts even with a role granting it
+	svc := createTestUser(entities.MilterUser)
+	svc.Role = &entities.Role{ID: entities.NewId(), Name: "svc-admin", Permissions: entities.AllPermissions()}
+	assert.False(t, canManageServiceAccounts(svc))
+	assert.False(t, canGetServiceAccounts(svc))
+}
+
+func TestCanManageSessions(t *testing.T) {
+	assert.True(t, canManageSessions(createTestUser(entities.RegularUser)))
+	assert.True(t, canManageSessions(createTestUser(entities.AdminUser)))
+	assert.False(t, canManageSessions(createTestUser(entities.MilterUser)))
+}
@@ -9,23 +9,29 @@ import (
 
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
+	"github.com/Burmuley/ovoo/internal/tracing"
 )
 
 // ChainsService represents a use case for managing chains
 type ChainsService struct {
 	repof *factory.RepoFactory
+	// policy lists the senders hitting the trap addresses
+	policy entities.ReputationPolicy
 }
 
 // NewChainsService creates a new instance of ChainsUsecase
-func NewChainsService(repof *factory.RepoFactory) (*ChainsService, error) {
+func NewChainsService(repof *factory.RepoFactory, policy entities.ReputationPolicy) (*ChainsService, error) {
 	if repof == nil {
 		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
 	}
 
-	return &ChainsService{repof: repof}, nil
+	return &ChainsService{repof: repof, policy: policy}, nil
 }
 
-func (cs *ChainsService) GetByHash(ctx context.Context, cuser entities.User, hash entities.Hash) (entities.Chain, error) {
+func (cs *ChainsService) GetByHash(ctx context.Context, cuser entities.User, hash entities.Hash) (_ entities.Chain, err error) {
+	ctx, span := tracing.Start(ctx, "ChainsService.GetByHash")
+	defer func() { tracing.End(span, err) }()
+
 	if !canGetChain(cuser) {
 		return entities.Chain{}, entities.ErrNotAuthorized
 	}
@@ -44,14 +50,31 @@ func (cs *ChainsService) GetByHash(ctx context.Context, cuser entities.User, has
 		return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
 	}
 
-	if chain.OrigToAddress.ForwardAddress != nil && !chain.OrigToAddress.ForwardAddress.Active {
+	if chain.OrigToAddress.ForwardAddress != nil && !forwardable(*chain.OrigToAddress.ForwardAddress) {
+		return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
+	}
+
+	// leaked aliases restricted to their known senders refuse the others
+	if !chain.OrigToAddress.AcceptsSender(chain.OrigFromAddress.Email) {
 		return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
 	}
 
+	// the mail of the senders listed by the sender reputation is refused or held for every alias
+	if err := screenSender(ctx, cs.repof, cs.policy, &chain); err != nil {
+		return entities.Chain{}, err
+	}
+
+	if chain.Redirects, err = chainRedirects(ctx, cs.repof, chain); err != nil {
+		return entities.Chain{}, err
+	}
+
 	return chain, nil
 }
 
-func (cs *ChainsService) DeleteByHash(ctx context.Context, cuser entities.User, hash entities.Hash) (entities.Chain, error) {
+func (cs *ChainsService) DeleteByHash(ctx context.Context, cuser entities.User, hash entities.Hash) (_ entities.Chain, err error) {
+	ctx, span := tracing.Start(ctx, "ChainsService.DeleteByHash")
+	defer func() { tracing.End(span, err) }()
+
 	if !canDeleteChain(cuser) {
 		return entities.Chain{}, entities.ErrNotAuthorized
 	}
@@ -68,11 +91,19 @@ func (cs *ChainsService) DeleteByHash(ctx context.Context, cuser entities.User,
 	return chain, nil
 }
 
-func (cs *ChainsService) Create(ctx context.Context, cuser entities.User, fromEmail, toEmail string, owner entities.User) (entities.Chain, error) {
+func (cs *ChainsService) Create(ctx context.Context, cuser entities.User, fromEmail, toEmail string, owner entities.User) (_ entities.Chain, err error) {
+	ctx, span := tracing.Start(ctx, "ChainsService.Create")
+	defer func() { tracing.End(span, err) }()
+
 	if !canCreateChain(cuser) {
 		return entities.Chain{}, entities.ErrNotAuthorized
 	}
 
+	// scoped tokens can only create chains towards aliases in the allowed domains
+	if !cuser.AllowsEmail(entities.Email(toEmail)) {
+		return entities.Chain{}, entities.ErrNotAuthorized
+	}
+
 	// calculate hash and return corresponding chain if found in the DB
 	hash := entities.NewHash(fromEmail, toEmail)
 	if chain, err := cs.repof.Chain.GetByHash(ctx, hash); err == nil {
@@ -81,10 +112,22 @@ func (cs *ChainsService) Create(ctx context.Context, cuser entities.User, fromEm
 			return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
 		}
 
-		if chain.OrigToAddress.ForwardAddress != nil && !chain.OrigToAddress.ForwardAddress.Active {
+		if chain.OrigToAddress.ForwardAddress != nil && !forwardable(*chain.OrigToAddress.ForwardAddress) {
+			return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
+		}
+
+		if !chain.OrigToAddress.AcceptsSender(chain.OrigFromAddress.Email) {
 			return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
 		}
 
+		if err := screenSender(ctx, cs.repof, cs.policy, &chain); err != nil {
+			return entities.Chain{}, err
+		}
+
+		if chain.Redirects, err = chainRedirects(ctx, cs.repof, chain); err != nil {
+			return entities.Chain{}, err
+		}
+
 		return chain, nil
 	}
 
@@ -99,12 +142,42 @@ func (cs *ChainsService) Create(ctx context.Context, cuser entities.User, fromEm
 
 	var alias *entities.Address
 	for _, addr := range addrs {
+		// the senders writing to the trap addresses are added to the sender reputation, their mail is refused
+		if addr.Type == entities.TrapAddress && addr.Active {
+			if err := recordTrapHit(ctx, cs.repof, cs.policy, entities.Email(fromEmail)); err != nil {
+				return entities.Chain{}, fmt.Errorf("recording trap hit: %w", err)
+			}
+
+			return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
+		}
+
 		if addr.Type == entities.AliasAddress && addr.Active {
 			alias = &addr
 		}
 	}
 
-	if alias == nil {
+	// aliases of inactive protected addresses are deactivated with them, pending ones are not
+	if alias == nil || (alias.ForwardAddress != nil && alias.ForwardAddress.Pending) {
+		return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
+	}
+
+	// a listed sender gets no chain when its mail is refused
+	screened := entities.Chain{
+		ToAddress:       *alias.ForwardAddress,
+		OrigFromAddress: entities.Address{Email: entities.Email(fromEmail)},
+		OrigToAddress:   *alias,
+	}
+
+	if err := screenSender(ctx, cs.repof, cs.policy, &screened); err != nil {
+		return entities.Chain{}, err
+	}
+
+	// new senders may reveal a leak of the alias, leaked aliases restricted to their known senders refuse the others
+	if err := checkSenderLeak(ctx, cs.repof, alias, entities.Email(fromEmail)); err != nil {
+		return entities.Chain{}, fmt.Errorf("checking sender leak: %w", err)
+	}
+
+	if !alias.AcceptsSender(entities.Email(fromEmail)) {
 		return entities.Chain{}, fmt.Errorf("%w: destination alias not found", entities.ErrNotFound)
 	}
 
@@ -130,27 +203,47 @@ func (cs *ChainsService) Create(ctx context.Context, cuser entities.User, fromEm
 		ToAddress:       *alias.ForwardAddress,
 		OrigFromAddress: src,
 		OrigToAddress:   *alias,
-		CreatedAt:       time.Now().UTC(),
-		UpdatedBy:       cuser,
+		// the mail of the new sender is held until the owner releases a message of the chain
+		Quarantined: alias.Quarantine == entities.QuarantineNewSenders,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedBy:   cuser,
+		Listed:      screened.Listed,
 	}
 
-	// reply chain
-	rhash := entities.NewHash(string(alias.ForwardAddress.Email), string(ralias.Email))
-	rchain := entities.Chain{
-		Hash:            rhash,
-		FromAddress:     *alias,
-		ToAddress:       src,
-		OrigFromAddress: *alias.ForwardAddress,
-		OrigToAddress:   ralias,
-		CreatedAt:       time.Now().UTC(),
-		UpdatedBy:       cuser,
+	chains := []entities.Chain{fchain}
+
+	// reply chain, webhooks do not reply
+	if alias.ForwardAddress.Type != entities.WebhookAddress {
+		rhash := entities.NewHash(string(alias.ForwardAddress.Email), string(ralias.Email))
+		chains = append(chains, entities.Chain{
+			Hash:            rhash,
+			FromAddress:     *alias,
+			ToAddress:       src,
+			OrigFromAddress: *alias.ForwardAddress,
+			OrigToAddress:   ralias,
+			CreatedAt:       time.Now().UTC(),
+			UpdatedBy:       cuser,
+		})
+	}
+
+	// the protected addresses the rules redirect to are resolved for the mail filter, they are not stored
+	redirects, err := chainRedirects(ctx, cs.repof, fchain)
+	if err != nil {
+		return entities.Chain{}, err
 	}
 
 	// create chains
-	if err := cs.repof.Chain.BatchCreate(ctx, []entities.Chain{fchain, rchain}); err != nil {
+	if err := cs.repof.Chain.BatchCreate(ctx, chains); err != nil {
 		return entities.Chain{}, err
 	}
 
+	// a new forward chain means the sender writes to the alias for the first time
+	notify(ctx, cs.repof, alias.Owner, entities.NotificationNewSender, hash.String(), map[string]string{
+		"alias":  toEmail,
+		"sender": fromEmail,
+	})
+
+	fchain.Redirects = redirects
 	return fchain, nil
 }
 
@@ -202,3 +295,9 @@ func checkCreateSrcAddr(ctx context.Context, repof *factory.RepoFactory, faddr s
 
 	return srcAddr, nil
 }
+
+// forwardable reports whether mail can be forwarded to the protected address:
+// it is active and its owner confirmed the mailbox
+func forwardable(praddr entities.Address) bool {
+	return praddr.Active && !praddr.Pending
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
@@ -22,7 +23,7 @@ func setupChainsService(t *testing.T) (*ChainsService, *MockChainRepo, *MockAddr
 		Address: addressRepo,
 	}
 
-	service, err := NewChainsService(repof)
+	service, err := NewChainsService(repof, entities.ReputationPolicy{})
 	require.NoError(t, err)
 
 	return service, chainRepo, addressRepo
@@ -30,14 +31,14 @@ func setupChainsService(t *testing.T) (*ChainsService, *MockChainRepo, *MockAddr
 
 func TestNewChainsService(t *testing.T) {
 	repof := &factory.RepoFactory{}
-	service, err := NewChainsService(repof)
+	service, err := NewChainsService(repof, entities.ReputationPolicy{})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, service)
 }
 
 func TestNewChainsService_NilRepoFactory(t *testing.T) {
-	service, err := NewChainsService(nil)
+	service, err := NewChainsService(nil, entities.ReputationPolicy{})
 
 	assert.Error(t, err)
 	assert.Nil(t, service)
@@ -559,6 +560,11 @@ func TestChainsService_Create_Success(t *testing.T) {
 		return addr.Type == entities.ExternalAddress && addr.Email == entities.Email(fromEmail)
 	})).Return(nil)
 
+	// the domain of the first sender becomes known to the alias
+	addressRepo.On("Update", ctx, mock.MatchedBy(func(addr entities.Address) bool {
+		return addr.ID == aliasAddr.ID && slices.Equal(addr.SenderDomains, []string{"external.com"})
+	})).Return(nil)
+
 	// Create reply alias
 	addressRepo.On("Create", ctx, mock.MatchedBy(func(addr entities.Address) bool {
 		return addr.Type == entities.ReplyAliasAddress
@@ -575,6 +581,84 @@ func TestChainsService_Create_Success(t *testing.T) {
 	addressRepo.AssertExpectations(t)
 }
 
+func TestChainsService_Create_NotifiesNewSender(t *testing.T) {
+	service, chainRepo, addressRepo := setupChainsService(t)
+	queue := &recordingQueue{}
+	service.repof.Notifications = queue
+	ctx := context.Background()
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+	owner := notifiedUser()
+
+	fromEmail := "sender@external.com"
+	toEmail := "alias@test.com"
+	hash := entities.NewHash(fromEmail, toEmail)
+
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: owner}
+	aliasAddr := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          entities.Email(toEmail),
+		ForwardAddress: &protectedAddr,
+		Owner:          owner,
+		Active:         true,
+	}
+
+	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{}, entities.ErrNotFound)
+	addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+	addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{aliasAddr}, nil)
+	addressRepo.On("GetByEmail", ctx, entities.Email(fromEmail)).Return(nil, entities.ErrNotFound)
+	addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+	chainRepo.On("BatchCreate", ctx, mock.AnythingOfType("[]entities.Chain")).Return(nil)
+
+	_, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+	require.NoError(t, err)
+
+	require.Len(t, queue.queued, 1)
+	n := queue.queued[0]
+	assert.Equal(t, entities.NotificationNewSender, n.Event)
+	assert.Equal(t, owner.ID, n.UserID)
+	assert.Equal(t, map[string]string{"alias": toEmail, "sender": fromEmail}, n.Data)
+}
+
+func TestChainsService_Create_QuarantinesNewSender(t *testing.T) {
+	service, chainRepo, addressRepo := setupChainsService(t)
+	ctx := context.Background()
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "owner@test.com"}
+
+	fromEmail := "sender@external.com"
+	toEmail := "alias@test.com"
+	hash := entities.NewHash(fromEmail, toEmail)
+
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: owner}
+	aliasAddr := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          entities.Email(toEmail),
+		ForwardAddress: &protectedAddr,
+		Owner:          owner,
+		Active:         true,
+		Quarantine:     entities.QuarantineNewSenders,
+	}
+
+	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{}, entities.ErrNotFound)
+	addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+	addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{aliasAddr}, nil)
+	addressRepo.On("GetByEmail", ctx, entities.Email(fromEmail)).Return(nil, entities.ErrNotFound)
+	addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+	// only the forward chain holds the mail, replies of the owner are not held
+	chainRepo.On("BatchCreate", ctx, mock.MatchedBy(func(chains []entities.Chain) bool {
+		return len(chains) == 2 && chains[0].Quarantined && !chains[1].Quarantined
+	})).Return(nil)
+
+	chain, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+	require.NoError(t, err)
+	assert.True(t, chain.Held())
+	chainRepo.AssertExpectations(t)
+}
+
 func TestChainsService_Create_ExistingExternalAddress(t *testing.T) {
 	service, chainRepo, addressRepo := setupChainsService(t)
 	ctx := context.Background()
@@ -620,6 +704,7 @@ func TestChainsService_Create_ExistingExternalAddress(t *testing.T) {
 
 	// Chain doesn't exist
 	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{}, entities.ErrNotFound)
+	addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
 
 	// Return alias address for toEmail
 	addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{aliasAddr}, nil)
@@ -852,6 +937,7 @@ func TestChainsService_Create_AdminUser(t *testing.T) {
 
 	// Chain doesn't exist
 	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{}, entities.ErrNotFound)
+	addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
 
 	// Return alias address for toEmail
 	addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{aliasAddr}, nil)
@@ -879,3 +965,354 @@ func TestChainsService_Create_AdminUser(t *testing.T) {
 	chainRepo.AssertExpectations(t)
 	addressRepo.AssertExpectations(t)
 }
+
+func TestChainsService_GetByHash_PendingForwardAddress(t *testing.T) {
+	service, chainRepo, _ := setupChainsService(t)
+	ctx := context.Background()
+
+	milter := entities.User{
+		ID:    entities.NewId(),
+		Type:  entities.MilterUser,
+		Login: "milter@test.com",
+	}
+
+	hash := entities.NewHash("from@example.com", "to@test.com")
+
+	owner := entities.User{
+		ID:     entities.NewId(),
+		Type:   entities.RegularUser,
+		Login:  "owner@test.com",
+		Active: true,
+	}
+
+	pendingPrAddr := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.ProtectedAddress,
+		Email:   "protected@example.com",
+		Owner:   owner,
+		Active:  true,
+		Pending: true,
+	}
+
+	chain := entities.Chain{
+		Hash: hash,
+		OrigToAddress: entities.Address{
+			ID:             entities.NewId(),
+			Type:           entities.AliasAddress,
+			Email:          "alias@test.com",
+			Owner:          owner,
+			Active:         true,
+			ForwardAddress: &pendingPrAddr,
+		},
+	}
+
+	chainRepo.On("GetByHash", ctx, hash).Return(chain, nil)
+
+	result, err := service.GetByHash(ctx, milter, hash)
+
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	assert.Equal(t, entities.Chain{}, result)
+}
+
+func TestChainsService_Create_PendingForwardAddress(t *testing.T) {
+	service, chainRepo, addressRepo := setupChainsService(t)
+	ctx := context.Background()
+
+	milter := entities.User{
+		ID:    entities.NewId(),
+		Type:  entities.MilterUser,
+		Login: "milter@test.com",
+	}
+
+	owner := entities.User{
+		ID:    entities.NewId(),
+		Type:  entities.RegularUser,
+		Login: "owner@test.com",
+	}
+
+	fromEmail := "from@example.com"
+	toEmail := "to@test.com"
+	hash := entities.NewHash(fromEmail, toEmail)
+
+	alias := entities.Address{
+		ID:     entities.NewId(),
+		Type:   entities.AliasAddress,
+		Email:  entities.Email(toEmail),
+		Owner:  owner,
+		Active: true,
+		ForwardAddress: &entities.Address{
+			ID:      entities.NewId(),
+			Type:    entities.ProtectedAddress,
+			Email:   "protected@example.com",
+			Owner:   owner,
+			Active:  true,
+			Pending: true,
+		},
+	}
+
+	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{}, entities.ErrNotFound)
+	addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{alias}, nil)
+
+	chain, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	assert.Contains(t, err.Error(), "destination alias not found")
+	assert.Equal(t, entities.Chain{}, chain)
+	addressRepo.AssertNotCalled(t, "Create")
+}
+
+func TestChainsService_Create_ResolvesRedirects(t *testing.T) {
+	service, chainRepo, addressRepo := setupChainsService(t)
+	ctx := context.Background()
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "owner@test.com", Active: true}
+
+	fromEmail := "sender@external.com"
+	toEmail := "alias@test.com"
+	hash := entities.NewHash(fromEmail, toEmail)
+
+	protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: owner, Active: true}
+	workAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "work@example.com", Owner: owner, Active: true}
+	pendingAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "pending@example.com", Owner: owner, Active: true, Pending: true}
+	aliasAddr := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          entities.Email(toEmail),
+		ForwardAddress: &protectedAddr,
+		Owner:          owner,
+		Active:         true,
+		Rules: &entities.Rules{Script: `if header :contains "subject" "invoice" { redirect "work@example.com"; }
+			elsif size :over 1M { redirect "pending@example.com"; }`},
+	}
+
+	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{
+		Hash:          hash,
+		ToAddress:     protectedAddr,
+		OrigToAddress: aliasAddr,
+	}, nil)
+	addressRepo.On("GetByEmail", ctx, workAddr.Email).Return([]entities.Address{workAddr}, nil)
+	addressRepo.On("GetByEmail", ctx, pendingAddr.Email).Return([]entities.Address{pendingAddr}, nil)
+
+	chain, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+	require.NoError(t, err)
+	// mail is not redirected to the pending address
+	require.Len(t, chain.Redirects, 1)
+	assert.Equal(t, workAddr.ID, chain.Redirects[0].ID)
+}
+
+func TestChainsService_Create_Webhook(t *testing.T) {
+	service, chainRepo, addressRepo := setupChainsService(t)
+	ctx := context.Background()
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "owner@test.com"}
+
+	fromEmail := "sender@external.com"
+	toEmail := "alias@test.com"
+	hash := entities.NewHash(fromEmail, toEmail)
+
+	webhookAddr := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.WebhookAddress,
+		Owner:   owner,
+		Active:  true,
+		Webhook: &entities.Webhook{URL: "https://hooks.example.com/mail", Secret: "secret"},
+	}
+	aliasAddr := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          entities.Email(toEmail),
+		ForwardAddress: &webhookAddr,
+		Owner:          owner,
+		Active:         true,
+	}
+
+	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{}, entities.ErrNotFound)
+	addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+	addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{aliasAddr}, nil)
+	addressRepo.On("GetByEmail", ctx, entities.Email(fromEmail)).Return(nil, entities.ErrNotFound)
+	addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+	// webhooks do not reply, only the forward chain is created
+	chainRepo.On("BatchCreate", ctx, mock.MatchedBy(func(chains []entities.Chain) bool {
+		return len(chains) == 1 && chains[0].Hash == hash
+	})).Return(nil)
+
+	chain, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+	require.NoError(t, err)
+	assert.Equal(t, entities.WebhookAddress, chain.ToAddress.Type)
+	chainRepo.AssertExpectations(t)
+}
+
+func TestChainsService_Create_LeakedAlias(t *testing.T) {
+	tests := []struct {
+		name     string
+		restrict bool
+		wantErr  error
+	}{
+		{name: "not restricted"},
+		{name: "restricted to known senders", restrict: true, wantErr: entities.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, chainRepo, addressRepo := setupChainsService(t)
+			ctx := context.Background()
+
+			milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+			owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "owner@test.com", Active: true}
+
+			fromEmail := "offers@spam.com"
+			toEmail := "alias@test.com"
+			hash := entities.NewHash(fromEmail, toEmail)
+
+			protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: owner, Active: true}
+			aliasAddr := entities.Address{
+				ID:             entities.NewId(),
+				Type:           entities.AliasAddress,
+				Email:          entities.Email(toEmail),
+				ForwardAddress: &protectedAddr,
+				Owner:          owner,
+				Active:         true,
+				SenderDomains:  []string{"shop.com"},
+				LeakRestrict:   tt.restrict,
+			}
+
+			chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{}, entities.ErrNotFound)
+			addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{aliasAddr}, nil)
+			// the leak is recorded whether the sender is refused or not
+			addressRepo.On("Update", ctx, mock.MatchedBy(func(a entities.Address) bool {
+				return a.Leak != nil && a.Leak.Sender == entities.Email(fromEmail)
+			})).Return(nil).Once()
+			if tt.wantErr == nil {
+				addressRepo.On("GetByEmail", ctx, entities.Email(fromEmail)).Return(nil, entities.ErrNotFound)
+				addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+				chainRepo.On("BatchCreate", ctx, mock.AnythingOfType("[]entities.Chain")).Return(nil)
+			}
+
+			_, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			addressRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// The existing chains of unknown senders are refused once the alias is restricted.
+func TestChainsService_Create_ExistingChainOfRestrictedAlias(t *testing.T) {
+	service, chainRepo, _ := setupChainsService(t)
+	ctx := context.Background()
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "owner@test.com", Active: true}
+
+	fromEmail := "offers@spam.com"
+	toEmail := "alias@test.com"
+	hash := entities.NewHash(fromEmail, toEmail)
+
+	chainRepo.On("GetByHash", ctx, hash).Return(entities.Chain{
+		Hash:            hash,
+		OrigFromAddress: entities.Address{ID: entities.NewId(), Type: entities.ExternalAddress, Email: entities.Email(fromEmail), Owner: owner},
+		OrigToAddress: entities.Address{
+			ID:            entities.NewId(),
+			Type:          entities.AliasAddress,
+			Email:         entities.Email(toEmail),
+			Owner:         owner,
+			Active:        true,
+			SenderDomains: []string{"shop.com"},
+			Leak:          &entities.AliasLeak{Sender: entities.Email(fromEmail), DetectedAt: time.Now()},
+			LeakRestrict:  true,
+		},
+	}, nil)
+
+	_, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+// The senders writing to a trap address are added to the sender reputation and refused.
+func TestChainsService_Create_TrapAddress(t *testing.T) {
+	service, chainRepo, addressRepo := setupChainsService(t)
+	reputation := &memoryReputation{senders: map[entities.Email]entities.SenderReputation{}}
+	service.repof.Reputation = reputation
+	ctx := context.Background()
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+	admin := entities.User{ID: entities.NewId(), Type: entities.AdminUser, Login: "admin@test.com", Active: true}
+
+	fromEmail := "Harvester@Spam.com"
+	toEmail := "trap@test.com"
+	trap := entities.Address{ID: entities.NewId(), Type: entities.TrapAddress, Email: entities.Email(toEmail), Owner: admin, Active: true}
+
+	chainRepo.On("GetByHash", ctx, entities.NewHash(fromEmail, toEmail)).Return(entities.Chain{}, entities.ErrNotFound)
+	addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{trap}, nil)
+
+	_, err := service.Create(ctx, milter, fromEmail, toEmail, admin)
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	assert.Equal(t, 1, reputation.senders["harvester@spam.com"].Hits)
+	chainRepo.AssertNotCalled(t, "BatchCreate", mock.Anything, mock.Anything)
+}
+
+// The mail of the listed senders is refused for every alias, or held when the policy quarantines it.
+func TestChainsService_Create_ListedSender(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     entities.ReputationAction
+		wantErr    error
+		wantListed bool
+	}{
+		{name: "rejected", action: entities.ReputationReject, wantErr: entities.ErrNotFound},
+		{name: "held", action: entities.ReputationQuarantine, wantListed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, chainRepo, addressRepo := setupChainsService(t)
+			service.policy = entities.ReputationPolicy{Action: tt.action}
+			service.repof.Reputation = &memoryReputation{senders: map[entities.Email]entities.SenderReputation{
+				"offers@spam.com": listedSender("offers@spam.com"),
+			}}
+			service.repof.Quarantine = &memoryQuarantine{}
+			ctx := context.Background()
+
+			milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter@test.com"}
+			owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "owner@test.com", Active: true}
+
+			fromEmail := "offers@spam.com"
+			toEmail := "alias@test.com"
+			protectedAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "protected@example.com", Owner: owner, Active: true}
+			aliasAddr := entities.Address{
+				ID:             entities.NewId(),
+				Type:           entities.AliasAddress,
+				Email:          entities.Email(toEmail),
+				ForwardAddress: &protectedAddr,
+				Owner:          owner,
+				Active:         true,
+			}
+
+			chainRepo.On("GetByHash", ctx, entities.NewHash(fromEmail, toEmail)).Return(entities.Chain{}, entities.ErrNotFound)
+			addressRepo.On("GetByEmail", ctx, entities.Email(toEmail)).Return([]entities.Address{aliasAddr}, nil)
+			if tt.wantErr == nil {
+				addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+				addressRepo.On("GetByEmail", ctx, entities.Email(fromEmail)).Return(nil, entities.ErrNotFound)
+				addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+				chainRepo.On("BatchCreate", ctx, mock.AnythingOfType("[]entities.Chain")).Return(nil)
+			}
+
+			chain, err := service.Create(ctx, milter, fromEmail, toEmail, owner)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				// a refused sender gets no chain nor addresses
+				addressRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				chainRepo.AssertNotCalled(t, "BatchCreate", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantListed, chain.Listed)
+			assert.True(t, chain.Held())
+		})
+	}
+}
@@ -2,14 +2,17 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
+	"github.com/Burmuley/ovoo/internal/tracing"
 )
 
 type DomainCreateCmd struct {
@@ -45,14 +48,17 @@ func NewDomainsService(repoFabric *factory.RepoFactory) (*DomainsService, error)
 	return &DomainsService{repof: repoFabric}, nil
 }
 
-func (d *DomainsService) GetAll(ctx context.Context, cuser entities.User, filters entities.CustomDomainFilter) ([]entities.CustomDomain, entities.PaginationMetadata, error) {
+func (d *DomainsService) GetAll(ctx context.Context, cuser entities.User, filters entities.CustomDomainFilter) (_ []entities.CustomDomain, _ entities.PaginationMetadata, err error) {
+	ctx, span := tracing.Start(ctx, "DomainsService.GetAll")
+	defer func() { tracing.End(span, err) }()
+
 	if !canGetDomains(cuser) {
 		return nil, entities.PaginationMetadata{}, entities.ErrNotAuthorized
 	}
 
-	// admin and milter users can read all domains in the system
-	// regular users are limited to only read domains they own
-	if cuser.Type != entities.AdminUser && cuser.Type != entities.MilterUser {
+	// users with global read permission (admins and milters by default) can read all domains in the system
+	// other users are limited to only read domains they own
+	if !cuser.HasPermission(entities.PermDomainsReadAll) {
 		filters.Owners = []entities.Id{cuser.ID}
 	}
 
@@ -63,6 +69,20 @@ func (d *DomainsService) GetAll(ctx context.Context, cuser entities.User, filter
 		filters.IncludeGlobal = true
 	}
 
+	// scoped tokens only see domains they are restricted to
+	if cuser.Scope != nil && len(cuser.Scope.Domains) > 0 {
+		if len(filters.DomainNames) == 0 {
+			filters.DomainNames = cuser.Scope.Domains
+		} else {
+			filters.DomainNames = slices.DeleteFunc(filters.DomainNames, func(name string) bool {
+				return !cuser.AllowsDomain(name)
+			})
+			if len(filters.DomainNames) == 0 {
+				return []entities.CustomDomain{}, entities.PaginationMetadata{}, nil
+			}
+		}
+	}
+
 	domains, pgm, err := d.repof.Domain.GetAll(ctx, filters)
 	if err != nil {
 		return nil, entities.PaginationMetadata{}, err
@@ -203,6 +223,41 @@ func (d *DomainsService) Verify(ctx context.Context, cuser entities.User, id ent
 	return domain, nil
 }
 
+// RecheckVerified runs the DNS check of the verified custom domains again. Domains whose
+// verification record is gone or changed are marked as not verified and their owners are notified.
+// Global domains are not checked, and neither are domains whose DNS lookup failed temporarily.
+func (d *DomainsService) RecheckVerified(ctx context.Context) error {
+	domains, _, err := d.repof.Domain.GetAll(ctx, entities.CustomDomainFilter{Verified: new(true)})
+	if err != nil {
+		return err
+	}
+
+	for _, domain := range domains {
+		err := verifyDomainDNS(ctx, domain)
+		var dnsErr *net.DNSError
+		if err == nil || (errors.As(err, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout)) {
+			continue
+		}
+
+		domain.Verified = false
+		domain.VerificationData.LastVerificationResult = err.Error()
+		domain.UpdatedAt = time.Now()
+		if _, err := d.repof.Domain.Update(ctx, domain); err != nil {
+			return err
+		}
+
+		notify(ctx, d.repof, domain.Owner, entities.NotificationDomainVerificationLost,
+			domain.ID.String()+":"+strconv.FormatInt(domain.VerifiedAt.Unix(), 10),
+			map[string]string{
+				"domain": domain.Name,
+				"reason": err.Error(),
+			},
+		)
+	}
+
+	return nil
+}
+
 func verifyDomainDNS(ctx context.Context, domain entities.CustomDomain) error {
 	targetName := strings.Join([]string{domain.VerificationData.Name, domain.Name}, ".")
 	targetValue := domain.VerificationData.Value
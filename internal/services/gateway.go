@@ -8,12 +8,20 @@ import (
 )
 
 type ServiceGateway struct {
-	Aliases *AliasesService
-	Users   *UsersService
-	PrAddrs *ProtectedAddrService
-	Chains  *ChainsService
-	Tokens  *ApiTokensService
-	Domains *DomainsService
+	Aliases       *AliasesService
+	Users         *UsersService
+	PrAddrs       *ProtectedAddrService
+	Chains        *ChainsService
+	Tokens        *ApiTokensService
+	Domains       *DomainsService
+	Roles         *RolesService
+	SvcAccs       *ServiceAccountsService
+	MFA           *MFAService
+	Sessions      *SessionsService
+	Notifications *NotificationsService
+	Passwords     *PasswordsService
+	Quarantine    *QuarantineService
+	Reputation    *ReputationService
 }
 
 // New creates a new ServiceGateway instance with the provided service implementations.
@@ -42,6 +50,22 @@ func New(services ...any) (*ServiceGateway, error) {
 			f.Tokens = t
 		case *DomainsService:
 			f.Domains = t
+		case *RolesService:
+			f.Roles = t
+		case *ServiceAccountsService:
+			f.SvcAccs = t
+		case *MFAService:
+			f.MFA = t
+		case *SessionsService:
+			f.Sessions = t
+		case *NotificationsService:
+			f.Notifications = t
+		case *PasswordsService:
+			f.Passwords = t
+		case *QuarantineService:
+			f.Quarantine = t
+		case *ReputationService:
+			f.Reputation = t
 		default:
 			return nil, fmt.Errorf("%w: unknown service type %T", entities.ErrConfiguration, t)
 		}
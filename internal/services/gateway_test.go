@@ -18,8 +18,16 @@ func TestNew_AllServicesProvided(t *testing.T) {
 	chainsService := &ChainsService{repof: repof}
 	tokensService := &ApiTokensService{repof: repof}
 	domainsService := &DomainsService{repof: repof}
+	rolesService := &RolesService{repof: repof}
+	svcAccsService := &ServiceAccountsService{repof: repof}
+	mfaService := &MFAService{repof: repof}
+	sessionsService := &SessionsService{repof: repof}
+	notificationsService := &NotificationsService{repof: repof}
+	passwordsService := &PasswordsService{repof: repof}
+	quarantineService := &QuarantineService{repof: repof}
+	reputationService := &ReputationService{repof: repof}
 
-	gateway, err := New(aliasesService, usersService, prAddrsService, chainsService, tokensService, domainsService)
+	gateway, err := New(aliasesService, usersService, prAddrsService, chainsService, tokensService, domainsService, rolesService, svcAccsService, mfaService, sessionsService, notificationsService, passwordsService, quarantineService, reputationService)
 
 	require.NoError(t, err)
 	assert.NotNil(t, gateway)
@@ -29,6 +37,14 @@ func TestNew_AllServicesProvided(t *testing.T) {
 	assert.Equal(t, chainsService, gateway.Chains)
 	assert.Equal(t, tokensService, gateway.Tokens)
 	assert.Equal(t, domainsService, gateway.Domains)
+	assert.Equal(t, rolesService, gateway.Roles)
+	assert.Equal(t, svcAccsService, gateway.SvcAccs)
+	assert.Equal(t, mfaService, gateway.MFA)
+	assert.Equal(t, sessionsService, gateway.Sessions)
+	assert.Equal(t, notificationsService, gateway.Notifications)
+	assert.Equal(t, passwordsService, gateway.Passwords)
+	assert.Equal(t, quarantineService, gateway.Quarantine)
+	assert.Equal(t, reputationService, gateway.Reputation)
 }
 
 func TestNew_MissingService(t *testing.T) {
@@ -73,9 +89,17 @@ func TestNew_DuplicateServices(t *testing.T) {
 	chainsService := &ChainsService{repof: repof}
 	tokensService := &ApiTokensService{repof: repof}
 	domainsService := &DomainsService{repof: repof}
+	rolesService := &RolesService{repof: repof}
+	svcAccsService := &ServiceAccountsService{repof: repof}
+	mfaService := &MFAService{repof: repof}
+	sessionsService := &SessionsService{repof: repof}
+	notificationsService := &NotificationsService{repof: repof}
+	passwordsService := &PasswordsService{repof: repof}
+	quarantineService := &QuarantineService{repof: repof}
+	reputationService := &ReputationService{repof: repof}
 
 	// Second aliases service should override the first one
-	gateway, err := New(aliasesService1, aliasesService2, usersService, prAddrsService, chainsService, tokensService, domainsService)
+	gateway, err := New(aliasesService1, aliasesService2, usersService, prAddrsService, chainsService, tokensService, domainsService, rolesService, svcAccsService, mfaService, sessionsService, notificationsService, passwordsService, quarantineService, reputationService)
 
 	require.NoError(t, err)
 	assert.NotNil(t, gateway)
@@ -87,12 +111,20 @@ func TestCheckNilServices_AllFieldsSet(t *testing.T) {
 	repof := &factory.RepoFactory{}
 
 	gw := &ServiceGateway{
-		Aliases: &AliasesService{repof: repof, wordsDictionary: []string{"word"}},
-		Users:   &UsersService{repof: repof},
-		PrAddrs: &ProtectedAddrService{repof: repof},
-		Chains:  &ChainsService{repof: repof},
-		Tokens:  &ApiTokensService{repof: repof},
-		Domains: &DomainsService{repof: repof},
+		Aliases:       &AliasesService{repof: repof, wordsDictionary: []string{"word"}},
+		Users:         &UsersService{repof: repof},
+		PrAddrs:       &ProtectedAddrService{repof: repof},
+		Chains:        &ChainsService{repof: repof},
+		Tokens:        &ApiTokensService{repof: repof},
+		Domains:       &DomainsService{repof: repof},
+		Roles:         &RolesService{repof: repof},
+		SvcAccs:       &ServiceAccountsService{repof: repof},
+		MFA:           &MFAService{repof: repof},
+		Sessions:      &SessionsService{repof: repof},
+		Notifications: &NotificationsService{repof: repof},
+		Passwords:     &PasswordsService{repof: repof},
+		Quarantine:    &QuarantineService{repof: repof},
+		Reputation:    &ReputationService{repof: repof},
 	}
 
 	err := checkNilServices(gw)
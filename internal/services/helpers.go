@@ -2,8 +2,17 @@ package services
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
@@ -56,6 +65,33 @@ func deletePrAddrsForUser(ctx context.Context, repof *factory.RepoFactory, cuser
 	return nil
 }
 
+// deleteWebhooksForUser deletes all Webhook addresses belonging to a given user with the aliases delivering to them
+// and their chains.
+func deleteWebhooksForUser(ctx context.Context, repof *factory.RepoFactory, cuser entities.User, userId entities.Id) error {
+	webhooks, _, err := repof.Address.GetAll(ctx, entities.AddressFilter{
+		Filter: entities.Filter{Page: 0, PageSize: 0},
+		Types:  []entities.AddressType{entities.WebhookAddress},
+		Owners: []entities.Id{userId},
+	})
+	if err != nil {
+		return err
+	}
+
+	webhookIds := make([]entities.Id, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		if err := deleteAliasesForPrAddr(ctx, repof, cuser, webhook); err != nil {
+			return err
+		}
+		webhookIds = append(webhookIds, webhook.ID)
+	}
+
+	if len(webhookIds) == 0 {
+		return nil
+	}
+
+	return repof.Address.BatchDeleteById(ctx, cuser, webhookIds)
+}
+
 /*
 deleteAliasesForPrAddr deletes all Alias addresses that forward to a specific Protected address and cleans up their chains.
 
@@ -272,6 +308,15 @@ func deactivateTokensForUser(ctx context.Context, repof *factory.RepoFactory, cu
 	return nil
 }
 
+// revokeSessionsForUser ends all server-side sessions of the user.
+func revokeSessionsForUser(ctx context.Context, repof *factory.RepoFactory, userId entities.Id) error {
+	if err := repof.Cache.DeleteByPrefix(ctx, sessionUserPrefix(userId)); err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
 func fillVerificationData(vd entities.DomainVerificationData) (entities.DomainVerificationData, error) {
 	evd := entities.DomainVerificationData{
 		RecordType: entities.DNSRecordType(strings.ToLower(string(vd.RecordType))),
@@ -302,3 +347,74 @@ func fillVerificationData(vd entities.DomainVerificationData) (entities.DomainVe
 
 	return evd, nil
 }
+
+// newSignedToken returns a stateless token of the link mailed to a user, formatted as
+// base64url("<id>:<expiry unix time>") "." base64url(HMAC-SHA256(secret, "<id>:<expiry>:<binding>")).
+// The binding is not part of the token, it ties the token to the current state of the entity.
+func newSignedToken(secret []byte, id entities.Id, expires time.Time, binding string) string {
+	payload := string(id) + ":" + strconv.FormatInt(expires.Unix(), 10)
+	mac := signedTokenMAC(secret, id, expires, binding)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac)
+}
+
+// parseSignedToken extracts the id, the expiry time and the signature from a token of newSignedToken,
+// the signature is checked by the caller once the binding is known
+func parseSignedToken(token string) (entities.Id, time.Time, []byte, bool) {
+	encPayload, encMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", time.Time{}, nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return "", time.Time{}, nil, false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil {
+		return "", time.Time{}, nil, false
+	}
+
+	id, unix, ok := strings.Cut(string(payload), ":")
+	if !ok {
+		return "", time.Time{}, nil, false
+	}
+
+	exp, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || entities.Id(id).Validate() != nil {
+		return "", time.Time{}, nil, false
+	}
+
+	return entities.Id(id), time.Unix(exp, 0), mac, true
+}
+
+func signedTokenMAC(secret []byte, id entities.Id, expires time.Time, binding string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(string(id) + ":" + strconv.FormatInt(expires.Unix(), 10) + ":" + binding))
+	return h.Sum(nil)
+}
+
+// linkBaseURL validates the public URL of the API prefixing the links mailed to users
+func linkBaseURL(raw string) (string, error) {
+	base, err := url.Parse(raw)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return "", fmt.Errorf("%w: verification base_url should be an absolute http(s) URL", entities.ErrConfiguration)
+	}
+
+	return strings.TrimSuffix(raw, "/"), nil
+}
+
+// newTokenSecret returns the key signing the tokens of mailed links, a random one when the secret is not configured
+func newTokenSecret(secret string) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), nil
+	}
+
+	slog.Warn("verification secret is not set, links mailed to users will not survive a restart of the API")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -680,3 +680,29 @@ func TestDeactivateTokensForUser_ErrorGettingTokens(t *testing.T) {
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, entities.ErrDatabase)
 }
+
+func TestDeleteWebhooksForUser(t *testing.T) {
+	repof, addressRepo, _ := setupHelpersTest()
+	ctx := context.Background()
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	webhookAddr := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.WebhookAddress,
+		Owner:   user,
+		Webhook: &entities.Webhook{URL: "https://hooks.example.com/mail", Secret: "secret"},
+	}
+
+	addressRepo.On("GetAll", ctx, mock.MatchedBy(func(filter entities.AddressFilter) bool {
+		return len(filter.Types) == 1 && filter.Types[0] == entities.WebhookAddress
+	})).Return([]entities.Address{webhookAddr}, entities.PaginationMetadata{}, nil).Once()
+	// no aliases deliver to the webhook
+	addressRepo.On("GetAll", ctx, mock.MatchedBy(func(filter entities.AddressFilter) bool {
+		return len(filter.ForwardAddressIds) == 1 && filter.ForwardAddressIds[0] == webhookAddr.ID
+	})).Return([]entities.Address{}, entities.PaginationMetadata{}, nil).Once()
+	addressRepo.On("BatchDeleteById", ctx, mock.Anything, []entities.Id{webhookAddr.ID}).Return(nil)
+
+	cuser := entities.User{ID: entities.NewId(), Type: entities.AdminUser}
+	assert.NoError(t, deleteWebhooksForUser(ctx, repof, cuser, user.ID))
+	addressRepo.AssertExpectations(t)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+// checkSenderLeak checks a new sender of the alias: a sender matching neither the service name of the alias nor
+// its known sender domains flags the alias as possibly leaked and the owner is notified. The domain of the first
+// sender is recorded as known, unless the alias has a service name the sender does not match. Later senders do not
+// replace the recorded leak until the owner resolves it.
+func checkSenderLeak(ctx context.Context, repof *factory.RepoFactory, alias *entities.Address, sender entities.Email) error {
+	domain := entities.SenderDomain(sender)
+	if domain == "" {
+		return nil
+	}
+
+	switch {
+	case alias.Leak != nil:
+		return nil
+	case len(alias.SenderDomains) == 0 && (alias.Metadata.ServiceName == "" || alias.KnownSender(sender)):
+		alias.SenderDomains = []string{domain}
+		return repof.Address.Update(ctx, *alias)
+	case alias.KnownSender(sender):
+		return nil
+	}
+
+	alias.Leak = &entities.AliasLeak{Sender: sender, DetectedAt: time.Now().UTC()}
+	if err := repof.Address.Update(ctx, *alias); err != nil {
+		return err
+	}
+
+	known := alias.Metadata.ServiceName
+	if known == "" {
+		known = strings.Join(alias.SenderDomains, ", ")
+	}
+
+	// the templates test the value, an empty one is false
+	restricted := ""
+	if alias.LeakRestrict {
+		restricted = "true"
+	}
+
+	notify(ctx, repof, alias.Owner, entities.NotificationAliasLeaked, alias.ID.String()+":"+sender.String(), map[string]string{
+		"alias":      alias.Email.String(),
+		"sender":     sender.String(),
+		"known":      known,
+		"restricted": restricted,
+	})
+
+	return nil
+}
+
+// resolveLeak clears the leak of the alias, the domain of the sender revealing it becomes a known sender domain
+func resolveLeak(alias *entities.Address) {
+	if alias.Leak == nil {
+		return
+	}
+
+	if domain := entities.SenderDomain(alias.Leak.Sender); domain != "" && !slices.Contains(alias.SenderDomains, domain) {
+		alias.SenderDomains = append(alias.SenderDomains, domain)
+	}
+	alias.Leak = nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+)
+
+func leakTestAlias(owner entities.User) entities.Address {
+	return entities.Address{
+		ID:     entities.NewId(),
+		Type:   entities.AliasAddress,
+		Email:  "alias@ovoo.com",
+		Owner:  owner,
+		Active: true,
+	}
+}
+
+func TestCheckSenderLeak(t *testing.T) {
+	leak := &entities.AliasLeak{Sender: "first@spam.com", DetectedAt: time.Now()}
+	tests := []struct {
+		name          string
+		serviceName   string
+		senderDomains []string
+		leak          *entities.AliasLeak
+		sender        entities.Email
+		wantUpdate    bool
+		wantDomains   []string
+		wantLeak      bool
+	}{
+		{
+			name:        "first sender",
+			sender:      "news@mail.shop.com",
+			wantUpdate:  true,
+			wantDomains: []string{"shop.com"},
+		},
+		{
+			name:        "first sender of the service",
+			serviceName: "Shop",
+			sender:      "news@mail.shop.com",
+			wantUpdate:  true,
+			wantDomains: []string{"shop.com"},
+		},
+		{
+			name:        "first sender not of the service",
+			serviceName: "Shop",
+			sender:      "offers@spam.com",
+			wantUpdate:  true,
+			wantLeak:    true,
+		},
+		{
+			name:          "known sender domain",
+			senderDomains: []string{"shop.com"},
+			sender:        "billing@shop.com",
+			wantDomains:   []string{"shop.com"},
+		},
+		{
+			name:          "sender of the service",
+			serviceName:   "Store",
+			senderDomains: []string{"shop.com"},
+			sender:        "news@store.example",
+			wantDomains:   []string{"shop.com"},
+		},
+		{
+			name:          "unknown sender",
+			senderDomains: []string{"shop.com"},
+			sender:        "offers@spam.com",
+			wantUpdate:    true,
+			wantDomains:   []string{"shop.com"},
+			wantLeak:      true,
+		},
+		{
+			name:          "already leaked",
+			senderDomains: []string{"shop.com"},
+			leak:          leak,
+			sender:        "offers@other-spam.com",
+			wantDomains:   []string{"shop.com"},
+			wantLeak:      true,
+		},
+		{
+			name:   "bounce without sender",
+			sender: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repof, addressRepo, _ := setupHelpersTest()
+			queue := &recordingQueue{}
+			repof.Notifications = queue
+			ctx := context.Background()
+
+			alias := leakTestAlias(notifiedUser())
+			alias.Metadata.ServiceName = tt.serviceName
+			alias.SenderDomains = tt.senderDomains
+			alias.Leak = tt.leak
+			if tt.wantUpdate {
+				addressRepo.On("Update", ctx, mock.AnythingOfType("entities.Address")).Return(nil).Once()
+			}
+
+			require.NoError(t, checkSenderLeak(ctx, repof, &alias, tt.sender))
+			addressRepo.AssertExpectations(t)
+			assert.Equal(t, tt.wantDomains, alias.SenderDomains)
+			assert.Equal(t, tt.wantLeak, alias.Leak != nil)
+
+			// only a new leak is notified
+			if tt.wantLeak && tt.leak == nil {
+				known := "shop.com"
+				if tt.serviceName != "" {
+					known = tt.serviceName
+				}
+
+				assert.Equal(t, tt.sender, alias.Leak.Sender)
+				require.Len(t, queue.queued, 1)
+				n := queue.queued[0]
+				assert.Equal(t, entities.NotificationAliasLeaked, n.Event)
+				assert.Equal(t, map[string]string{
+					"alias":      "alias@ovoo.com",
+					"sender":     tt.sender.String(),
+					"known":      known,
+					"restricted": "",
+				}, n.Data)
+			} else {
+				assert.Empty(t, queue.queued)
+			}
+		})
+	}
+}
+
+func TestResolveLeak(t *testing.T) {
+	alias := leakTestAlias(notifiedUser())
+	alias.SenderDomains = []string{"shop.com"}
+	alias.Leak = &entities.AliasLeak{Sender: "news@partner.co.uk", DetectedAt: time.Now()}
+
+	resolveLeak(&alias)
+	assert.Nil(t, alias.Leak)
+	assert.Equal(t, []string{"shop.com", "partner.co.uk"}, alias.SenderDomains)
+
+	// resolving an alias without a leak changes nothing
+	resolveLeak(&alias)
+	assert.Equal(t, []string{"shop.com", "partner.co.uk"}, alias.SenderDomains)
+}
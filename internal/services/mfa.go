@@ -0,0 +1,641 @@
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	// DefaultMFAIssuer is the issuer name shown in authenticator apps
+	DefaultMFAIssuer = "Ovoo"
+	// MFAChallengeTTL is the period the user has to provide the second factor after the password check
+	MFAChallengeTTL = 5 * time.Minute
+	// mfaChallengeMaxAttempts limits the number of second factor guesses per challenge
+	mfaChallengeMaxAttempts = 5
+	mfaChallengeKeyPrefix   = "mfa:challenge:"
+	mfaRegistrationPrefix   = "mfa:registration:"
+)
+
+// MFAChallenge is issued after a successful password check for users with a second factor
+// configured and has to be exchanged for a session with MFAService.VerifyLogin.
+type MFAChallenge struct {
+	Token     string
+	Methods   []string
+	WebAuthn  *protocol.CredentialAssertion
+	ExpiresAt time.Time
+}
+
+// MFAVerifyCmd contains the second factor provided for the MFA challenge,
+// exactly one of Code, RecoveryCode or WebAuthn should be set.
+type MFAVerifyCmd struct {
+	Token        string
+	Code         string
+	RecoveryCode string
+	WebAuthn     []byte // JSON encoded PublicKeyCredential assertion
+}
+
+// TOTPEnrollment contains the pending TOTP secret to be added to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// mfaSession holds the state of a pending login challenge or WebAuthn registration.
+type mfaSession struct {
+	UserID    entities.Id           `json:"user_id"`
+	WebAuthn  *webauthn.SessionData `json:"webauthn,omitempty"`
+	Attempts  int                   `json:"attempts"`
+	ExpiresAt time.Time             `json:"expires_at"`
+}
+
+// MFAService represents the use case for managing second factor settings of users
+// and verifying the second factor during password logins.
+//
+// Pending challenges and WebAuthn registrations are stored in the shared cache under
+// "mfa:challenge:<token>" and "mfa:registration:<user id>" keys until they expire, so
+// the second factor can be provided to any API instance.
+type MFAService struct {
+	repof    *factory.RepoFactory
+	issuer   string
+	enforce  bool
+	webAuthn *webauthn.WebAuthn
+}
+
+// NewMFAService creates a new MFAService instance.
+// WebAuthn credentials are only available when the WebAuthn relying party is configured.
+func NewMFAService(repoFactory *factory.RepoFactory, cfg config.ConfigMFA) (*MFAService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	if repoFactory.Cache == nil {
+		return nil, fmt.Errorf("%w: cache should be defined for mfa challenges", entities.ErrConfiguration)
+	}
+
+	s := &MFAService{
+		repof:   repoFactory,
+		issuer:  cmp.Or(cfg.Issuer, DefaultMFAIssuer),
+		enforce: cfg.EnforceAdmins,
+	}
+
+	if cfg.WebAuthn != nil {
+		wa, err := webauthn.New(&webauthn.Config{
+			RPID:          cfg.WebAuthn.RPID,
+			RPDisplayName: cmp.Or(cfg.WebAuthn.RPDisplayName, s.issuer),
+			RPOrigins:     cfg.WebAuthn.RPOrigins,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: webauthn: %w", entities.ErrConfiguration, err)
+		}
+		s.webAuthn = wa
+	}
+
+	return s, nil
+}
+
+// EnforcementRequired reports whether the user has to enroll a second factor
+// before being allowed to use the API with a password login.
+func (s *MFAService) EnforcementRequired(user entities.User) bool {
+	return s.enforce && user.Type == entities.AdminUser && !user.MFAEnabled()
+}
+
+// Get returns the current second factor settings of the user.
+func (s *MFAService) Get(ctx context.Context, cuser entities.User) (entities.UserMFA, error) {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return entities.UserMFA{}, err
+	}
+
+	return user.MFA, nil
+}
+
+// EnrollTOTP generates a new TOTP secret for the user. The secret stays pending
+// until confirmed with a valid code using ConfirmTOTP.
+func (s *MFAService) EnrollTOTP(ctx context.Context, cuser entities.User) (TOTPEnrollment, error) {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	if user.MFA.TOTPEnabled {
+		return TOTPEnrollment{}, fmt.Errorf("%w: totp is already enabled", entities.ErrValidation)
+	}
+
+	secret, err := entities.NewTOTPSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	user.MFA.TOTPSecret = secret
+	user.MFA.TOTPLastStep = 0
+	if err := s.save(ctx, cuser, user); err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	return TOTPEnrollment{
+		Secret: secret,
+		URI:    entities.TOTPProvisioningURI(secret, s.issuer, user.Login),
+	}, nil
+}
+
+// ConfirmTOTP enables the pending TOTP secret after checking the code generated from it.
+// Recovery codes are returned when TOTP is the first second factor of the user.
+func (s *MFAService) ConfirmTOTP(ctx context.Context, cuser entities.User, code string) ([]string, error) {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFA.TOTPEnabled || user.MFA.TOTPSecret == "" {
+		return nil, fmt.Errorf("%w: no pending totp enrollment", entities.ErrValidation)
+	}
+
+	if !user.MFA.VerifyTOTP(strings.TrimSpace(code), time.Now()) {
+		return nil, fmt.Errorf("%w: invalid totp code", entities.ErrValidation)
+	}
+
+	codes, err := s.enableFactor(&user, func() { user.MFA.TOTPEnabled = true })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.save(ctx, cuser, user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP removes the TOTP secret of the user after checking a current code or a recovery code.
+func (s *MFAService) DisableTOTP(ctx context.Context, cuser entities.User, code string) error {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return err
+	}
+
+	if !user.MFA.TOTPEnabled {
+		return fmt.Errorf("%w: totp is not enabled", entities.ErrValidation)
+	}
+
+	code = strings.TrimSpace(code)
+	if !user.MFA.VerifyTOTP(code, time.Now()) && !user.MFA.UseRecoveryCode(code) {
+		return fmt.Errorf("%w: invalid totp or recovery code", entities.ErrValidation)
+	}
+
+	user.MFA.TOTPEnabled = false
+	user.MFA.TOTPSecret = ""
+	user.MFA.TOTPLastStep = 0
+	if err := s.disableFactor(&user); err != nil {
+		return err
+	}
+
+	return s.save(ctx, cuser, user)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user with a new set.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, cuser entities.User) ([]string, error) {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MFAEnabled() {
+		return nil, fmt.Errorf("%w: two-factor authentication is not enabled", entities.ErrValidation)
+	}
+
+	codes, hashes, err := entities.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFA.RecoveryCodes = hashes
+	if err := s.save(ctx, cuser, user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// BeginWebAuthnRegistration starts registration of a new WebAuthn credential and
+// returns the options to pass to navigator.credentials.create() in the browser.
+func (s *MFAService) BeginWebAuthnRegistration(ctx context.Context, cuser entities.User) (*protocol.CredentialCreation, error) {
+	if s.webAuthn == nil {
+		return nil, fmt.Errorf("%w: webauthn is not configured", entities.ErrValidation)
+	}
+
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return nil, err
+	}
+
+	wuser := webAuthnUser{user}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.MFA.WebAuthn))
+	for _, cred := range wuser.WebAuthnCredentials() {
+		exclusions = append(exclusions, cred.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(wuser, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	reg := mfaSession{UserID: user.ID, WebAuthn: session, ExpiresAt: time.Now().Add(MFAChallengeTTL)}
+	if err := s.storeSession(ctx, mfaRegistrationPrefix+user.ID.String(), reg); err != nil {
+		return nil, err
+	}
+
+	return creation, nil
+}
+
+// FinishWebAuthnRegistration verifies the attestation returned by the browser and stores
+// the new credential. Recovery codes are returned when it is the first second factor of the user.
+func (s *MFAService) FinishWebAuthnRegistration(ctx context.Context, cuser entities.User, name string, response []byte) (entities.WebAuthnCredential, []string, error) {
+	if s.webAuthn == nil {
+		return entities.WebAuthnCredential{}, nil, fmt.Errorf("%w: webauthn is not configured", entities.ErrValidation)
+	}
+
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return entities.WebAuthnCredential{}, nil, err
+	}
+
+	reg, err := s.takeSession(ctx, mfaRegistrationPrefix+user.ID.String())
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return entities.WebAuthnCredential{}, nil, fmt.Errorf("%w: no pending webauthn registration", entities.ErrValidation)
+		}
+		return entities.WebAuthnCredential{}, nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return entities.WebAuthnCredential{}, nil, fmt.Errorf("%w: parsing webauthn response: %w", entities.ErrValidation, err)
+	}
+
+	wcred, err := s.webAuthn.CreateCredential(webAuthnUser{user}, *reg.WebAuthn, parsed)
+	if err != nil {
+		return entities.WebAuthnCredential{}, nil, fmt.Errorf("%w: webauthn registration: %w", entities.ErrValidation, err)
+	}
+
+	cred := entities.WebAuthnCredential{
+		ID:              entities.NewId(),
+		Name:            cmp.Or(strings.TrimSpace(name), fmt.Sprintf("key-%d", len(user.MFA.WebAuthn)+1)),
+		CredentialID:    wcred.ID,
+		PublicKey:       wcred.PublicKey,
+		AttestationType: wcred.AttestationType,
+		AAGUID:          wcred.Authenticator.AAGUID,
+		SignCount:       wcred.Authenticator.SignCount,
+		BackupEligible:  wcred.Flags.BackupEligible,
+		BackupState:     wcred.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+	for _, t := range wcred.Transport {
+		cred.Transports = append(cred.Transports, string(t))
+	}
+
+	codes, err := s.enableFactor(&user, func() { user.MFA.WebAuthn = append(user.MFA.WebAuthn, cred) })
+	if err != nil {
+		return entities.WebAuthnCredential{}, nil, err
+	}
+
+	if err := s.save(ctx, cuser, user); err != nil {
+		return entities.WebAuthnCredential{}, nil, err
+	}
+
+	return cred, codes, nil
+}
+
+// DeleteWebAuthnCredential removes the WebAuthn credential of the user.
+func (s *MFAService) DeleteWebAuthnCredential(ctx context.Context, cuser entities.User, id entities.Id) error {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(user.MFA.WebAuthn, func(c entities.WebAuthnCredential) bool { return c.ID == id })
+	if idx < 0 {
+		return fmt.Errorf("%w: webauthn credential '%s'", entities.ErrNotFound, id)
+	}
+
+	user.MFA.WebAuthn = slices.Delete(user.MFA.WebAuthn, idx, idx+1)
+	if err := s.disableFactor(&user); err != nil {
+		return err
+	}
+
+	return s.save(ctx, cuser, user)
+}
+
+// Reset removes all second factor settings of the user, e.g. when the user lost access to them.
+func (s *MFAService) Reset(ctx context.Context, cuser entities.User, userId entities.Id) error {
+	if !canResetMFA(cuser) {
+		return entities.ErrNotAuthorized
+	}
+
+	user, err := s.repof.Users.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	user.MFA = entities.UserMFA{}
+	return s.save(ctx, cuser, user)
+}
+
+// BeginLogin issues a second factor challenge for the user who passed the password check.
+func (s *MFAService) BeginLogin(ctx context.Context, user entities.User) (MFAChallenge, error) {
+	token, err := newChallengeToken()
+	if err != nil {
+		return MFAChallenge{}, err
+	}
+
+	challenge := MFAChallenge{
+		Token:     token,
+		Methods:   user.MFA.Methods(),
+		ExpiresAt: time.Now().Add(MFAChallengeTTL),
+	}
+	session := mfaSession{UserID: user.ID, ExpiresAt: challenge.ExpiresAt}
+
+	if s.webAuthn != nil && len(user.MFA.WebAuthn) > 0 {
+		assertion, wsession, err := s.webAuthn.BeginLogin(webAuthnUser{user})
+		if err != nil {
+			return MFAChallenge{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+		}
+		challenge.WebAuthn = assertion
+		session.WebAuthn = wsession
+	} else {
+		challenge.Methods = slices.DeleteFunc(challenge.Methods, func(m string) bool { return m == entities.MFAMethodWebAuthn })
+	}
+
+	if err := s.storeSession(ctx, mfaChallengeKeyPrefix+token, session); err != nil {
+		return MFAChallenge{}, err
+	}
+
+	return challenge, nil
+}
+
+// VerifyLogin checks the second factor provided for the challenge and returns the
+// authenticated user. The challenge is consumed on success or after too many failed attempts.
+func (s *MFAService) VerifyLogin(ctx context.Context, cmd MFAVerifyCmd) (entities.User, error) {
+	key := mfaChallengeKeyPrefix + cmd.Token
+	session, err := s.loadSession(ctx, key)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return entities.User{}, fmt.Errorf("%w: unknown or expired mfa challenge", entities.ErrNotAuthorized)
+		}
+		return entities.User{}, err
+	}
+
+	// the attempt is counted before the second factor is checked
+	session.Attempts++
+	if session.Attempts > mfaChallengeMaxAttempts {
+		_ = s.repof.Cache.Delete(ctx, key)
+		return entities.User{}, fmt.Errorf("%w: unknown or expired mfa challenge", entities.ErrNotAuthorized)
+	}
+
+	if err := s.storeSession(ctx, key, session); err != nil {
+		return entities.User{}, err
+	}
+
+	user, err := s.repof.Users.GetById(ctx, session.UserID)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	if !user.Active {
+		_ = s.repof.Cache.Delete(ctx, key)
+		return entities.User{}, fmt.Errorf("%w: inactive user", entities.ErrNotAuthorized)
+	}
+
+	switch {
+	case cmd.Code != "":
+		if !user.MFA.TOTPEnabled || !user.MFA.VerifyTOTP(strings.TrimSpace(cmd.Code), time.Now()) {
+			return entities.User{}, fmt.Errorf("%w: invalid totp code", entities.ErrNotAuthorized)
+		}
+	case cmd.RecoveryCode != "":
+		if !user.MFAEnabled() || !user.MFA.UseRecoveryCode(cmd.RecoveryCode) {
+			return entities.User{}, fmt.Errorf("%w: invalid recovery code", entities.ErrNotAuthorized)
+		}
+	case len(cmd.WebAuthn) > 0:
+		if err := s.validateAssertion(&user, &session, cmd.WebAuthn); err != nil {
+			return entities.User{}, fmt.Errorf("%w: %w", entities.ErrNotAuthorized, err)
+		}
+	default:
+		return entities.User{}, fmt.Errorf("%w: second factor is not provided", entities.ErrValidation)
+	}
+
+	if err := s.repof.Cache.Delete(ctx, key); err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return entities.User{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	// persist the consumed recovery code, last TOTP step or WebAuthn sign counter
+	if err := s.repof.Users.Update(ctx, user); err != nil {
+		return entities.User{}, err
+	}
+
+	return user, nil
+}
+
+// WebAuthnAvailable reports whether the WebAuthn relying party is configured.
+func (s *MFAService) WebAuthnAvailable() bool {
+	return s.webAuthn != nil
+}
+
+// validateAssertion checks the WebAuthn assertion against the challenge and
+// updates the sign counter of the credential used.
+func (s *MFAService) validateAssertion(user *entities.User, session *mfaSession, response []byte) error {
+	if s.webAuthn == nil || session.WebAuthn == nil {
+		return errors.New("webauthn is not available for the challenge")
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return fmt.Errorf("parsing webauthn response: %w", err)
+	}
+
+	wcred, err := s.webAuthn.ValidateLogin(webAuthnUser{*user}, *session.WebAuthn, parsed)
+	if err != nil {
+		return fmt.Errorf("webauthn assertion: %w", err)
+	}
+
+	if wcred.Authenticator.CloneWarning {
+		return errors.New("webauthn sign counter mismatch, the authenticator may be cloned")
+	}
+
+	for i := range user.MFA.WebAuthn {
+		if bytes.Equal(user.MFA.WebAuthn[i].CredentialID, wcred.ID) {
+			user.MFA.WebAuthn[i].SignCount = wcred.Authenticator.SignCount
+			user.MFA.WebAuthn[i].BackupState = wcred.Flags.BackupState
+			user.MFA.WebAuthn[i].LastUsedAt = time.Now()
+		}
+	}
+
+	return nil
+}
+
+// currentUser checks the user can manage own second factor settings and loads
+// the up to date user record, the one from the request context may be cached.
+func (s *MFAService) currentUser(ctx context.Context, cuser entities.User) (entities.User, error) {
+	if !canManageMFA(cuser) {
+		return entities.User{}, entities.ErrNotAuthorized
+	}
+
+	return s.repof.Users.GetById(ctx, cuser.ID)
+}
+
+// enableFactor applies the change enabling a second factor and generates
+// recovery codes if the user had no second factor before.
+func (s *MFAService) enableFactor(user *entities.User, enable func()) ([]string, error) {
+	first := !user.MFAEnabled()
+	enable()
+	if !first {
+		return nil, nil
+	}
+
+	codes, hashes, err := entities.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFA.RecoveryCodes = hashes
+	return codes, nil
+}
+
+// disableFactor drops the recovery codes once the last second factor is removed
+// and prevents it for admin users when the second factor is enforced.
+func (s *MFAService) disableFactor(user *entities.User) error {
+	if user.MFAEnabled() {
+		return nil
+	}
+
+	if s.enforce && user.Type == entities.AdminUser {
+		return fmt.Errorf("%w: two-factor authentication is enforced for admin users", entities.ErrValidation)
+	}
+
+	user.MFA.RecoveryCodes = nil
+	return nil
+}
+
+func (s *MFAService) save(ctx context.Context, cuser, user entities.User) error {
+	user.UpdatedBy = &cuser
+	return s.repof.Users.Update(ctx, user)
+}
+
+// storeSession writes the pending challenge or registration to the cache until it expires.
+func (s *MFAService) storeSession(ctx context.Context, key string, session mfaSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	if err := s.repof.Cache.Set(ctx, key, data, time.Until(session.ExpiresAt)); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	return nil
+}
+
+// loadSession reads the pending challenge or registration from the cache,
+// entities.ErrNotFound is returned when it is unknown or expired.
+func (s *MFAService) loadSession(ctx context.Context, key string) (mfaSession, error) {
+	data, err := s.repof.Cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return mfaSession{}, err
+		}
+		return mfaSession{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	session := mfaSession{}
+	if err := json.Unmarshal(data, &session); err != nil {
+		return mfaSession{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return mfaSession{}, entities.ErrNotFound
+	}
+
+	return session, nil
+}
+
+// takeSession reads the pending challenge or registration and removes it from the cache.
+func (s *MFAService) takeSession(ctx context.Context, key string) (mfaSession, error) {
+	session, err := s.loadSession(ctx, key)
+	if err != nil {
+		return mfaSession{}, err
+	}
+
+	if err := s.repof.Cache.Delete(ctx, key); err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return mfaSession{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	return session, nil
+}
+
+func newChallengeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: generating mfa challenge: %w", entities.ErrGeneral, err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// webAuthnUser adapts entities.User to the webauthn.User interface.
+type webAuthnUser struct {
+	user entities.User
+}
+
+func (u webAuthnUser) WebAuthnID() []byte {
+	return []byte(u.user.ID)
+}
+
+func (u webAuthnUser) WebAuthnName() string {
+	return u.user.Login
+}
+
+func (u webAuthnUser) WebAuthnDisplayName() string {
+	return cmp.Or(u.user.String(), u.user.Login)
+}
+
+func (u webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, 0, len(u.user.MFA.WebAuthn))
+	for _, c := range u.user.MFA.WebAuthn {
+		cred := webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		}
+		for _, t := range c.Transports {
+			cred.Transport = append(cred.Transport, protocol.AuthenticatorTransport(t))
+		}
+		creds = append(creds, cred)
+	}
+
+	return creds
+}
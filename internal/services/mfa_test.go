@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+func setupMFAService(t *testing.T, cfg config.ConfigMFA) (*MFAService, *MockUsersRepo, *MockApiTokensRepo) {
+	usersRepo := new(MockUsersRepo)
+	tokensRepo := new(MockApiTokensRepo)
+	cache, err := memory.New()
+	require.NoError(t, err)
+
+	repof := &factory.RepoFactory{
+		Users:     usersRepo,
+		ApiTokens: tokensRepo,
+		Cache:     cache,
+	}
+
+	service, err := NewMFAService(repof, cfg)
+	require.NoError(t, err)
+
+	return service, usersRepo, tokensRepo
+}
+
+func TestNewMFAService_NilRepoFactory(t *testing.T) {
+	service, err := NewMFAService(nil, config.ConfigMFA{})
+
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+	assert.Nil(t, service)
+}
+
+func TestNewMFAService_NoCache(t *testing.T) {
+	service, err := NewMFAService(&factory.RepoFactory{}, config.ConfigMFA{})
+
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+	assert.Nil(t, service)
+}
+
+func TestNewMFAService_WebAuthn(t *testing.T) {
+	cache, _ := memory.New()
+	service, err := NewMFAService(&factory.RepoFactory{Cache: cache}, config.ConfigMFA{
+		WebAuthn: &config.ConfigWebAuthn{RPID: "ovoo.example.com", RPOrigins: []string{"https://ovoo.example.com"}},
+	})
+
+	require.NoError(t, err)
+	assert.True(t, service.WebAuthnAvailable())
+}
+
+func TestMFAService_EnrollAndConfirmTOTP(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Login = "user@example.com"
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil).Once()
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return u.MFA.TOTPSecret != "" && !u.MFA.TOTPEnabled
+	})).Return(nil).Once()
+
+	enrollment, err := service.EnrollTOTP(ctx, user)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Ovoo:user@example.com?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	pending := user
+	pending.MFA.TOTPSecret = enrollment.Secret
+	usersRepo.On("GetById", ctx, user.ID).Return(pending, nil).Once()
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return u.MFA.TOTPEnabled && len(u.MFA.RecoveryCodes) == 10
+	})).Return(nil).Once()
+
+	code, err := entities.TOTPCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+
+	codes, err := service.ConfirmTOTP(ctx, user, code)
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestMFAService_ConfirmTOTP_InvalidCode(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	secret, _ := entities.NewTOTPSecret()
+	user.MFA.TOTPSecret = secret
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	_, err := service.ConfirmTOTP(ctx, user, "000000x")
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	usersRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestMFAService_NotAuthorized(t *testing.T) {
+	service, _, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+
+	_, err := service.EnrollTOTP(ctx, createTestUser(entities.MilterUser))
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	err = service.Reset(ctx, createTestUser(entities.RegularUser), entities.NewId())
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestMFAService_DisableTOTP_EnforcedForAdmins(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{EnforceAdmins: true})
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	secret, _ := entities.NewTOTPSecret()
+	admin.MFA = entities.UserMFA{TOTPSecret: secret, TOTPEnabled: true}
+
+	usersRepo.On("GetById", ctx, admin.ID).Return(admin, nil)
+	code, _ := entities.TOTPCode(secret, time.Now())
+
+	err := service.DisableTOTP(ctx, admin, code)
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	usersRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestMFAService_EnforcementRequired(t *testing.T) {
+	service, _, _ := setupMFAService(t, config.ConfigMFA{EnforceAdmins: true})
+	admin := createTestUser(entities.AdminUser)
+
+	assert.True(t, service.EnforcementRequired(admin))
+	assert.False(t, service.EnforcementRequired(createTestUser(entities.RegularUser)))
+
+	admin.MFA.TOTPEnabled = true
+	assert.False(t, service.EnforcementRequired(admin))
+}
+
+func TestMFAService_Login_TOTP(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Login = "user@example.com"
+	user.Active = true
+	secret, _ := entities.NewTOTPSecret()
+	user.MFA = entities.UserMFA{TOTPSecret: secret, TOTPEnabled: true, RecoveryCodes: []entities.Hash{"x"}}
+
+	challenge, err := service.BeginLogin(ctx, user)
+	require.NoError(t, err)
+	assert.NotEmpty(t, challenge.Token)
+	assert.Equal(t, []string{entities.MFAMethodTOTP, entities.MFAMethodRecoveryCode}, challenge.Methods)
+	assert.Nil(t, challenge.WebAuthn)
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return u.MFA.TOTPLastStep > 0
+	})).Return(nil).Once()
+
+	_, err = service.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, Code: "123"})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	code, _ := entities.TOTPCode(secret, time.Now())
+	verified, err := service.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, Code: code})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, verified.ID)
+
+	// the challenge is consumed
+	_, err = service.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, Code: code})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	usersRepo.AssertExpectations(t)
+}
+
+func TestMFAService_Login_OtherInstance(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+	secret, _ := entities.NewTOTPSecret()
+	user.MFA = entities.UserMFA{TOTPSecret: secret, TOTPEnabled: true}
+
+	challenge, err := service.BeginLogin(ctx, user)
+	require.NoError(t, err)
+
+	// another API instance sharing the cache verifies the second factor
+	other, err := NewMFAService(service.repof, config.ConfigMFA{})
+	require.NoError(t, err)
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.AnythingOfType("entities.User")).Return(nil).Once()
+
+	code, _ := entities.TOTPCode(secret, time.Now())
+	verified, err := other.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, Code: code})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, verified.ID)
+
+	_, err = service.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, Code: code})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestMFAService_Login_RecoveryCode(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+	codes, hashes, _ := entities.NewRecoveryCodes()
+	user.MFA = entities.UserMFA{TOTPSecret: "JBSWY3DPEHPK3PXP", TOTPEnabled: true, RecoveryCodes: hashes}
+
+	challenge, err := service.BeginLogin(ctx, user)
+	require.NoError(t, err)
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return len(u.MFA.RecoveryCodes) == len(hashes)-1
+	})).Return(nil).Once()
+
+	_, err = service.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, RecoveryCode: codes[0]})
+
+	require.NoError(t, err)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestMFAService_Login_TooManyAttempts(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+	secret, _ := entities.NewTOTPSecret()
+	user.MFA = entities.UserMFA{TOTPSecret: secret, TOTPEnabled: true}
+
+	challenge, err := service.BeginLogin(ctx, user)
+	require.NoError(t, err)
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	for range mfaChallengeMaxAttempts {
+		_, err := service.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, Code: "000000"})
+		require.Error(t, err)
+	}
+
+	code, _ := entities.TOTPCode(secret, time.Now())
+	_, err = service.VerifyLogin(ctx, MFAVerifyCmd{Token: challenge.Token, Code: code})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestMFAService_BeginWebAuthnRegistration_NotConfigured(t *testing.T) {
+	service, _, _ := setupMFAService(t, config.ConfigMFA{})
+
+	_, err := service.BeginWebAuthnRegistration(context.Background(), createTestUser(entities.RegularUser))
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestMFAService_BeginWebAuthnRegistration(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{
+		WebAuthn: &config.ConfigWebAuthn{RPID: "ovoo.example.com", RPOrigins: []string{"https://ovoo.example.com"}},
+	})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Login = "user@example.com"
+	user.MFA.WebAuthn = []entities.WebAuthnCredential{{ID: entities.NewId(), CredentialID: []byte{1, 2, 3}}}
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	creation, err := service.BeginWebAuthnRegistration(ctx, user)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ovoo.example.com", creation.Response.RelyingParty.ID)
+	assert.Equal(t, "user@example.com", creation.Response.User.Name)
+	require.Len(t, creation.Response.CredentialExcludeList, 1)
+}
+
+func TestMFAService_DeleteWebAuthnCredential(t *testing.T) {
+	service, usersRepo, _ := setupMFAService(t, config.ConfigMFA{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	cred := entities.WebAuthnCredential{ID: entities.NewId(), CredentialID: []byte{1}}
+	user.MFA = entities.UserMFA{WebAuthn: []entities.WebAuthnCredential{cred}, RecoveryCodes: []entities.Hash{"x"}}
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		// recovery codes are dropped along with the last second factor
+		return len(u.MFA.WebAuthn) == 0 && len(u.MFA.RecoveryCodes) == 0
+	})).Return(nil).Once()
+
+	err := service.DeleteWebAuthnCredential(ctx, user, entities.NewId())
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+
+	err = service.DeleteWebAuthnCredential(ctx, user, cred.ID)
+	require.NoError(t, err)
+	usersRepo.AssertExpectations(t)
+}
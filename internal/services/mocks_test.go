@@ -187,6 +187,11 @@ func (m *MockChainRepo) BatchDelete(ctx context.Context, cuser entities.User, ha
 	return args.Error(0)
 }
 
+func (m *MockChainRepo) Update(ctx context.Context, chain entities.Chain) error {
+	args := m.Called(ctx, chain)
+	return args.Error(0)
+}
+
 // MockDomainRepo is a mock implementation of repositories.CustomDomainsReadWriter
 type MockDomainRepo struct {
 	mock.Mock
@@ -221,3 +226,38 @@ func (m *MockDomainRepo) Delete(ctx context.Context, cuser entities.User, id ent
 	args := m.Called(ctx, cuser, id)
 	return args.Error(0)
 }
+
+// MockRolesRepo is a mock implementation of repositories.RolesReadWriter
+type MockRolesRepo struct {
+	mock.Mock
+}
+
+func (m *MockRolesRepo) GetById(ctx context.Context, id entities.Id) (entities.Role, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(entities.Role), args.Error(1)
+}
+
+func (m *MockRolesRepo) GetByName(ctx context.Context, name string) (entities.Role, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(entities.Role), args.Error(1)
+}
+
+func (m *MockRolesRepo) GetAll(ctx context.Context, filter entities.RoleFilter) ([]entities.Role, entities.PaginationMetadata, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entities.Role), args.Get(1).(entities.PaginationMetadata), args.Error(2)
+}
+
+func (m *MockRolesRepo) Create(ctx context.Context, role entities.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRolesRepo) Update(ctx context.Context, role entities.Role) (entities.Role, error) {
+	args := m.Called(ctx, role)
+	return args.Get(0).(entities.Role), args.Error(1)
+}
+
+func (m *MockRolesRepo) Delete(ctx context.Context, cuser entities.User, id entities.Id) error {
+	args := m.Called(ctx, cuser, id)
+	return args.Error(0)
+}
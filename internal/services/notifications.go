@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+// NotificationPrefsUpdateCmd enables or disables the notifications about the events.
+type NotificationPrefsUpdateCmd struct {
+	Events map[entities.NotificationEvent]bool
+}
+
+// NotificationsService represents the use case for managing the email notification
+// settings of users. The notifications are emitted by the other services and
+// delivered by the notifications worker.
+type NotificationsService struct {
+	repof *factory.RepoFactory
+}
+
+// NewNotificationsService creates a new NotificationsService instance
+func NewNotificationsService(repoFactory *factory.RepoFactory) (*NotificationsService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	return &NotificationsService{repof: repoFactory}, nil
+}
+
+// Available reports whether notifications are sent, they are only queued when outgoing mail is configured.
+func (s *NotificationsService) Available() bool {
+	return s.repof.Notifications != nil
+}
+
+// GetPrefs returns the notification settings of the current user.
+func (s *NotificationsService) GetPrefs(ctx context.Context, cuser entities.User) (entities.NotificationPrefs, error) {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return entities.NotificationPrefs{}, err
+	}
+
+	return user.Notifications, nil
+}
+
+// UpdatePrefs changes the notification settings of the current user, events missing in the command are not changed.
+func (s *NotificationsService) UpdatePrefs(ctx context.Context, cuser entities.User, cmd NotificationPrefsUpdateCmd) (entities.NotificationPrefs, error) {
+	user, err := s.currentUser(ctx, cuser)
+	if err != nil {
+		return entities.NotificationPrefs{}, err
+	}
+
+	for event, enabled := range cmd.Events {
+		if err := event.Validate(); err != nil {
+			return entities.NotificationPrefs{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+
+		user.Notifications = user.Notifications.Set(event, enabled)
+	}
+
+	user.UpdatedBy = &cuser
+	if err := s.repof.Users.Update(ctx, user); err != nil {
+		return entities.NotificationPrefs{}, err
+	}
+
+	return user.Notifications, nil
+}
+
+func (s *NotificationsService) currentUser(ctx context.Context, cuser entities.User) (entities.User, error) {
+	if !canManageNotifications(cuser) {
+		return entities.User{}, entities.ErrNotAuthorized
+	}
+
+	return s.repof.Users.GetById(ctx, cuser.ID)
+}
+
+// notify queues the notification about the event for the user, unless notifications are disabled,
+// the user turned the event off or can not receive mail. A notification with the key of an already
+// queued one is dropped. Failures are logged, they never fail the operation emitting the event.
+func notify(ctx context.Context, repof *factory.RepoFactory, user entities.User, event entities.NotificationEvent, key string, data map[string]string) {
+	if repof.Notifications == nil || !user.Active || user.IsServiceAccount() || !user.Notifications.Enabled(event) {
+		return
+	}
+
+	to := entities.Email(user.Login)
+	if to.Validate() != nil {
+		return
+	}
+
+	n := entities.Notification{
+		ID:            entities.NewId(),
+		Key:           string(event) + ":" + key,
+		UserID:        user.ID,
+		To:            to,
+		Name:          user.FirstName,
+		Event:         event,
+		Data:          data,
+		Status:        entities.NotificationQueued,
+		NextAttemptAt: time.Now(),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if err := n.Validate(); err != nil {
+		slog.Error("queueing notification", "event", event, "user_id", user.ID, "error", err)
+		return
+	}
+
+	if err := repof.Notifications.Enqueue(ctx, n); err != nil && !errors.Is(err, entities.ErrDuplicateEntry) {
+		slog.Error("queueing notification", "event", event, "user_id", user.ID, "error", err)
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+// recordingQueue keeps the queued notifications and rejects duplicate keys like the database.
+type recordingQueue struct {
+	queued []entities.Notification
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, n entities.Notification) error {
+	for _, e := range q.queued {
+		if e.Key == n.Key {
+			return entities.ErrDuplicateEntry
+		}
+	}
+
+	q.queued = append(q.queued, n)
+	return nil
+}
+
+func (q *recordingQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]entities.Notification, error) {
+	return nil, nil
+}
+
+func (q *recordingQueue) Update(ctx context.Context, n entities.Notification) error {
+	return nil
+}
+
+func (q *recordingQueue) DeleteFinished(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func notifiedUser() entities.User {
+	return entities.User{
+		ID:        entities.NewId(),
+		Type:      entities.RegularUser,
+		Login:     "owner@example.com",
+		FirstName: "Owner",
+		Active:    true,
+	}
+}
+
+func setupNotificationsService(t *testing.T) (*NotificationsService, *MockUsersRepo) {
+	usersRepo := new(MockUsersRepo)
+	service, err := NewNotificationsService(&factory.RepoFactory{Users: usersRepo, Notifications: &recordingQueue{}})
+	require.NoError(t, err)
+
+	return service, usersRepo
+}
+
+func TestNewNotificationsService_NilRepoFactory(t *testing.T) {
+	_, err := NewNotificationsService(nil)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestNotificationsService_Available(t *testing.T) {
+	service, _ := setupNotificationsService(t)
+	assert.True(t, service.Available())
+
+	service.repof.Notifications = nil
+	assert.False(t, service.Available())
+}
+
+func TestNotificationsService_UpdatePrefs(t *testing.T) {
+	service, usersRepo := setupNotificationsService(t)
+	ctx := context.Background()
+	user := notifiedUser()
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return !u.Notifications.Enabled(entities.NotificationNewSender) && u.UpdatedBy != nil
+	})).Return(nil)
+
+	prefs, err := service.UpdatePrefs(ctx, user, NotificationPrefsUpdateCmd{
+		Events: map[entities.NotificationEvent]bool{
+			entities.NotificationNewSender:     false,
+			entities.NotificationTokenExpiring: true,
+		},
+	})
+	require.NoError(t, err)
+	assert.False(t, prefs.Enabled(entities.NotificationNewSender))
+	assert.True(t, prefs.Enabled(entities.NotificationTokenExpiring))
+	usersRepo.AssertExpectations(t)
+}
+
+func TestNotificationsService_UpdatePrefs_UnknownEvent(t *testing.T) {
+	service, usersRepo := setupNotificationsService(t)
+	ctx := context.Background()
+	user := notifiedUser()
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	_, err := service.UpdatePrefs(ctx, user, NotificationPrefsUpdateCmd{
+		Events: map[entities.NotificationEvent]bool{"alias_deleted": false},
+	})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	usersRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestNotificationsService_GetPrefs_ServiceAccount(t *testing.T) {
+	service, _ := setupNotificationsService(t)
+
+	_, err := service.GetPrefs(context.Background(), entities.User{ID: entities.NewId(), Type: entities.MilterUser})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestNotify(t *testing.T) {
+	ctx := context.Background()
+	data := map[string]string{"alias": "alias@example.com"}
+
+	tests := map[string]struct {
+		modify func(u *entities.User)
+		queued bool
+	}{
+		"enabled":         {modify: func(u *entities.User) {}, queued: true},
+		"event disabled":  {modify: func(u *entities.User) { u.Notifications = u.Notifications.Set(entities.NotificationNewSender, false) }},
+		"inactive user":   {modify: func(u *entities.User) { u.Active = false }},
+		"service account": {modify: func(u *entities.User) { u.Type = entities.MilterUser }},
+		"login not email": {modify: func(u *entities.User) { u.Login = "owner" }},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			queue := &recordingQueue{}
+			user := notifiedUser()
+			tt.modify(&user)
+
+			notify(ctx, &factory.RepoFactory{Notifications: queue}, user, entities.NotificationNewSender, "hash", data)
+			if !tt.queued {
+				assert.Empty(t, queue.queued)
+				return
+			}
+
+			require.Len(t, queue.queued, 1)
+			n := queue.queued[0]
+			assert.Equal(t, "new_sender:hash", n.Key)
+			assert.Equal(t, user.ID, n.UserID)
+			assert.Equal(t, entities.Email("owner@example.com"), n.To)
+			assert.Equal(t, "Owner", n.Name)
+			assert.Equal(t, entities.NotificationQueued, n.Status)
+			assert.Equal(t, data, n.Data)
+		})
+	}
+}
+
+func TestNotify_Duplicate(t *testing.T) {
+	queue := &recordingQueue{}
+	repof := &factory.RepoFactory{Notifications: queue}
+	user := notifiedUser()
+
+	notify(context.Background(), repof, user, entities.NotificationNewSender, "hash", nil)
+	notify(context.Background(), repof, user, entities.NotificationNewSender, "hash", nil)
+	assert.Len(t, queue.queued, 1)
+}
+
+func TestNotify_Disabled(t *testing.T) {
+	// no queue when outgoing mail is not configured
+	assert.NotPanics(t, func() {
+		notify(context.Background(), &factory.RepoFactory{}, notifiedUser(), entities.NotificationNewSender, "hash", nil)
+	})
+}
+
+func TestApiTokensService_NotifyExpiring(t *testing.T) {
+	service, tokensRepo := setupApiTokensService(t)
+	queue := &recordingQueue{}
+	service.repof.Notifications = queue
+	ctx := context.Background()
+	owner := notifiedUser()
+	now := time.Now()
+
+	expiring := entities.ApiToken{ID: entities.NewId(), Name: "ci", Owner: owner, Active: true, CreatedAt: now.AddDate(0, 0, -30), Expiration: now.Add(48 * time.Hour)}
+	expired := entities.ApiToken{ID: entities.NewId(), Name: "old", Owner: owner, Active: true, CreatedAt: now.AddDate(0, 0, -30), Expiration: now.Add(-time.Hour)}
+	session := entities.ApiToken{ID: entities.NewId(), Name: "session", Owner: owner, Active: true, CreatedAt: now, Expiration: now.Add(12 * time.Hour)}
+
+	tokensRepo.On("GetAll", ctx, mock.MatchedBy(func(f entities.ApiTokenFilter) bool {
+		return len(f.UserIds) == 0 && f.ExpiresBefore != nil && f.Active != nil && *f.Active
+	})).Return([]entities.ApiToken{expiring, expired, session}, nil)
+
+	require.NoError(t, service.NotifyExpiring(ctx, 7*24*time.Hour))
+	require.NoError(t, service.NotifyExpiring(ctx, 7*24*time.Hour))
+
+	require.Len(t, queue.queued, 1, "the token is reported once")
+	n := queue.queued[0]
+	assert.Equal(t, entities.NotificationTokenExpiring, n.Event)
+	assert.Equal(t, "ci", n.Data["token"])
+	assert.Equal(t, expiring.Expiration.UTC().Format(time.RFC1123), n.Data["expires"])
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+const (
+	// DefaultPasswordResetTTL is the default period a password reset link is valid
+	DefaultPasswordResetTTL = time.Hour
+	// PasswordResetPath is the API path setting a new password with a reset link, appended to the base URL in the links
+	PasswordResetPath = "/api/v1/auth/password/reset"
+	// passwordResetInterval limits how often a reset link is mailed to the same user on request
+	passwordResetInterval  = time.Minute
+	passwordResetKeyPrefix = "password_reset:"
+)
+
+// PasswordChangeCmd changes the password of the current user.
+type PasswordChangeCmd struct {
+	CurrentPassword string
+	NewPassword     string
+}
+
+// PasswordResetCmd resets the password of a user on behalf of an administrator.
+type PasswordResetCmd struct {
+	UserID entities.Id
+	// Password is the new password of the user, the current one is removed and
+	// the user receives a reset link when not set
+	Password *string
+}
+
+// PasswordsService represents the use case for changing and resetting passwords of users.
+//
+// Forgotten passwords are reset with links mailed to the login of the user. The links carry a
+// token signed with the verification secret over the user id, the expiry time and the current
+// password hash, so a link stops working once the password is changed and no state is kept for them.
+type PasswordsService struct {
+	repof    *factory.RepoFactory
+	policy   entities.PasswordPolicy
+	mailer   mailer.Mailer
+	baseURL  string
+	secret   []byte
+	tokenTTL time.Duration
+}
+
+// NewPasswordsService creates a new PasswordsService instance.
+// Reset links are disabled when m is nil, administrators can still set new passwords of users.
+func NewPasswordsService(repoFactory *factory.RepoFactory, policy entities.PasswordPolicy, m mailer.Mailer, cfg config.ConfigPasswords, vcfg config.ConfigVerification) (*PasswordsService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	if cfg.ResetTTL < 0 {
+		return nil, fmt.Errorf("%w: password reset ttl can not be negative", entities.ErrConfiguration)
+	}
+
+	s := &PasswordsService{repof: repoFactory, policy: policy, tokenTTL: DefaultPasswordResetTTL}
+	if cfg.ResetTTL > 0 {
+		s.tokenTTL = time.Duration(cfg.ResetTTL) * time.Second
+	}
+
+	if m == nil {
+		return s, nil
+	}
+
+	var err error
+	if s.baseURL, err = linkBaseURL(vcfg.BaseURL); err != nil {
+		return nil, err
+	}
+
+	s.mailer = m
+	if s.secret, err = newTokenSecret(vcfg.Secret); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// LoadPasswordPolicy returns the password policy of the configuration, reading the list of breached passwords.
+// Every line of the list is a password, or the SHA-1 hex digest of one optionally followed by ":<count>"
+// as in the downloads of Have I Been Pwned. The list is kept in memory.
+func LoadPasswordPolicy(cfg config.ConfigPasswords) (entities.PasswordPolicy, error) {
+	if cfg.MinLength < 0 {
+		return entities.PasswordPolicy{}, fmt.Errorf("%w: password min_length can not be negative", entities.ErrConfiguration)
+	}
+
+	policy := entities.PasswordPolicy{MinLength: cfg.MinLength}
+	if cfg.BreachedList == "" {
+		return policy, nil
+	}
+
+	f, err := os.Open(cfg.BreachedList)
+	if err != nil {
+		return entities.PasswordPolicy{}, fmt.Errorf("%w: reading breached passwords: %w", entities.ErrConfiguration, err)
+	}
+	defer f.Close()
+
+	policy.Breached = make(map[[sha1.Size]byte]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		digest, _, _ := strings.Cut(line, ":")
+		var sum [sha1.Size]byte
+		if n, err := hex.Decode(sum[:], []byte(digest)); err == nil && n == sha1.Size {
+			policy.Breached[sum] = struct{}{}
+			continue
+		}
+
+		policy.Breached[sha1.Sum([]byte(line))] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return entities.PasswordPolicy{}, fmt.Errorf("%w: reading breached passwords: %w", entities.ErrConfiguration, err)
+	}
+
+	return policy, nil
+}
+
+// ResetAvailable reports whether forgotten passwords can be reset with links, they are only sent when outgoing mail is configured.
+func (s *PasswordsService) ResetAvailable() bool {
+	return s.mailer != nil
+}
+
+// Change sets a new password of the current user, the current password should be provided.
+func (s *PasswordsService) Change(ctx context.Context, cuser entities.User, cmd PasswordChangeCmd) error {
+	if !canChangePassword(cuser) {
+		return entities.ErrNotAuthorized
+	}
+
+	user, err := s.repof.Users.GetById(ctx, cuser.ID)
+	if err != nil {
+		return err
+	}
+
+	if user.PasswordHash == "" {
+		return fmt.Errorf("%w: user has no password, it logs in with an identity provider", entities.ErrValidation)
+	}
+
+	if !entities.ValidPassword(cmd.CurrentPassword, user.PasswordHash) {
+		return fmt.Errorf("%w: current password is invalid", entities.ErrValidation)
+	}
+
+	hash, err := s.policy.Hash(cmd.NewPassword)
+	if err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	user.PasswordHash = hash
+	user.UpdatedBy = &cuser
+	return s.repof.Users.Update(ctx, user)
+}
+
+// Reset replaces the password of a user on behalf of an administrator. When the command has no new
+// password, the current one is removed and a reset link is mailed to the user instead.
+// All API tokens and sessions of the user are revoked.
+func (s *PasswordsService) Reset(ctx context.Context, cuser entities.User, cmd PasswordResetCmd) error {
+	if !canResetPassword(cuser) {
+		return entities.ErrNotAuthorized
+	}
+
+	if err := cmd.UserID.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	user, err := s.repof.Users.GetById(ctx, cmd.UserID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsServiceAccount() {
+		return fmt.Errorf("%w: service accounts have no password", entities.ErrValidation)
+	}
+
+	if cmd.Password != nil {
+		hash, err := s.policy.Hash(*cmd.Password)
+		if err != nil {
+			return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+
+		return s.setPassword(ctx, cuser, user, hash)
+	}
+
+	if s.mailer == nil {
+		return fmt.Errorf("%w: password reset links are not configured, a new password should be set", entities.ErrValidation)
+	}
+
+	if err := entities.Email(user.Login).Validate(); err != nil {
+		return fmt.Errorf("%w: reset link can not be sent to login %q, a new password should be set", entities.ErrValidation, user.Login)
+	}
+
+	if err := s.setPassword(ctx, cuser, user, ""); err != nil {
+		return err
+	}
+
+	user.PasswordHash = ""
+	return s.sendReset(ctx, user, "An administrator reset the password of your Ovoo account %s, "+
+		"you can not log in with the previous one anymore.")
+}
+
+// RequestReset mails a reset link to the user with the login. Nothing is sent, and no error is returned,
+// when there is no active user with a password and the login, so that the requests do not reveal
+// the existing users. A link is sent to a user once a minute at most.
+func (s *PasswordsService) RequestReset(ctx context.Context, login string) error {
+	if s.mailer == nil {
+		return fmt.Errorf("%w: password reset is not configured", entities.ErrValidation)
+	}
+
+	user, err := s.repof.Users.GetByLogin(ctx, strings.TrimSpace(login))
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !user.Active || user.IsServiceAccount() || user.PasswordHash == "" || entities.Email(user.Login).Validate() != nil {
+		return nil
+	}
+
+	if s.repof.Cache != nil {
+		key := passwordResetKeyPrefix + user.ID.String()
+		if _, err := s.repof.Cache.Get(ctx, key); err == nil {
+			return nil
+		}
+
+		if err := s.repof.Cache.Set(ctx, key, []byte{1}, passwordResetInterval); err != nil {
+			return err
+		}
+	}
+
+	return s.sendReset(ctx, user, "A password reset was requested for your Ovoo account %s.")
+}
+
+// ConfirmReset sets the new password with the token of a reset link and revokes all API tokens
+// and sessions of the user. It does not require a user: the token proves access to the mailbox of the user.
+func (s *PasswordsService) ConfirmReset(ctx context.Context, token, password string) error {
+	invalid := fmt.Errorf("%w: invalid password reset token", entities.ErrValidation)
+	if s.mailer == nil {
+		return fmt.Errorf("%w: password reset is not configured", entities.ErrValidation)
+	}
+
+	id, expires, mac, ok := parseSignedToken(token)
+	if !ok {
+		return invalid
+	}
+
+	user, err := s.repof.Users.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return invalid
+		}
+
+		return err
+	}
+
+	// the signature covers the password hash, a token is useless once the password is changed
+	if !hmac.Equal(s.tokenMAC(user, expires), mac) {
+		return invalid
+	}
+
+	if time.Now().After(expires) {
+		return fmt.Errorf("%w: password reset link expired, request a new one", entities.ErrValidation)
+	}
+
+	if !user.Active {
+		return fmt.Errorf("%w: user is not active", entities.ErrValidation)
+	}
+
+	hash, err := s.policy.Hash(password)
+	if err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	return s.setPassword(ctx, user, user, hash)
+}
+
+// setPassword stores the password hash of the user and revokes its API tokens and sessions.
+func (s *PasswordsService) setPassword(ctx context.Context, cuser, user entities.User, hash string) error {
+	user.PasswordHash = hash
+	user.FailedAttempts = 0
+	user.LockoutUntil = time.Time{}
+	user.UpdatedBy = &cuser
+	if err := s.repof.Users.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.repof.ApiTokens.BatchDeleteForUser(ctx, cuser, user.ID); err != nil {
+		return err
+	}
+
+	if err := revokeSessionsForUser(ctx, s.repof, user.ID); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	return nil
+}
+
+// sendReset mails the reset link to the user, intro is the first sentence of the message with the login as argument
+func (s *PasswordsService) sendReset(ctx context.Context, user entities.User, intro string) error {
+	expires := time.Now().Add(s.tokenTTL).Truncate(time.Second)
+	link := s.baseURL + PasswordResetPath + "?token=" + url.QueryEscape(newSignedToken(s.secret, user.ID, expires, s.tokenBinding(user)))
+
+	body := fmt.Sprintf("Hello,\n\n"+
+		intro+" To choose a new password, open the link below:\n\n"+
+		"%s\n\n"+
+		"The link is valid until %s and works once. Setting the new password signs you out "+
+		"everywhere and removes your API tokens.\n"+
+		"If you did not expect this message, you can ignore it.\n",
+		user.Login, link, expires.UTC().Format(time.RFC1123))
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Login,
+		Subject: "Reset your Ovoo password",
+		Body:    body,
+	})
+}
+
+func (s *PasswordsService) tokenMAC(user entities.User, expires time.Time) []byte {
+	return signedTokenMAC(s.secret, user.ID, expires, s.tokenBinding(user))
+}
+
+// tokenBinding ties a reset token to the login and the current password of the user,
+// the prefix keeps confirmation tokens of protected addresses from being accepted
+func (s *PasswordsService) tokenBinding(user entities.User) string {
+	return passwordResetKeyPrefix + user.Login + ":" + user.PasswordHash
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+func setupPasswordsService(t *testing.T, m mailer.Mailer) (*PasswordsService, *MockUsersRepo, *MockApiTokensRepo, *memory.MemoryCache) {
+	usersRepo := new(MockUsersRepo)
+	tokensRepo := new(MockApiTokensRepo)
+	cache, err := memory.New()
+	require.NoError(t, err)
+
+	repof := &factory.RepoFactory{Users: usersRepo, ApiTokens: tokensRepo, Cache: cache}
+	service, err := NewPasswordsService(repof, entities.PasswordPolicy{}, m, config.ConfigPasswords{}, config.ConfigVerification{
+		BaseURL: "https://ovoo.example.com",
+		Secret:  "test-secret",
+	})
+	require.NoError(t, err)
+
+	return service, usersRepo, tokensRepo, cache
+}
+
+// passwordUser returns an active regular user with the password
+func passwordUser(t *testing.T, password string) entities.User {
+	t.Helper()
+	hash, err := entities.NewPasswordHash(password)
+	require.NoError(t, err)
+
+	return entities.User{
+		ID:           entities.NewId(),
+		Type:         entities.RegularUser,
+		Login:        "user@example.com",
+		PasswordHash: hash,
+		Active:       true,
+	}
+}
+
+var resetLinkRe = regexp.MustCompile(`https://ovoo\.example\.com/api/v1/auth/password/reset\?token=\S+`)
+
+// resetTokenFromMessage extracts the token of the password reset link in the message.
+func resetTokenFromMessage(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	link := resetLinkRe.FindString(msg.Body)
+	require.NotEmpty(t, link, "reset link not found in %q", msg.Body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func TestNewPasswordsService_Config(t *testing.T) {
+	repof := &factory.RepoFactory{}
+
+	_, err := NewPasswordsService(nil, entities.PasswordPolicy{}, nil, config.ConfigPasswords{}, config.ConfigVerification{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewPasswordsService(repof, entities.PasswordPolicy{}, nil, config.ConfigPasswords{ResetTTL: -1}, config.ConfigVerification{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewPasswordsService(repof, entities.PasswordPolicy{}, &recordingMailer{}, config.ConfigPasswords{}, config.ConfigVerification{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration, "base_url is required with a mailer")
+
+	service, err := NewPasswordsService(repof, entities.PasswordPolicy{}, nil, config.ConfigPasswords{ResetTTL: 600}, config.ConfigVerification{})
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, service.tokenTTL)
+	assert.False(t, service.ResetAvailable())
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	// plain text password, HIBP formatted digest of "password1234" and a CRLF line ending
+	content := "qwertyuiop123\n" +
+		"E6B6AFBD6D76BB5D2041542D7D2E3FAC5BB05593:4242\r\n" +
+		"\n"
+	require.NoError(t, os.WriteFile(list, []byte(content), 0o600))
+
+	policy, err := LoadPasswordPolicy(config.ConfigPasswords{MinLength: 12, BreachedList: list})
+	require.NoError(t, err)
+	assert.Equal(t, 12, policy.MinLength)
+	assert.Len(t, policy.Breached, 2)
+	assert.Error(t, policy.Check("qwertyuiop123"))
+	assert.Error(t, policy.Check("password1234"))
+	assert.NoError(t, policy.Check("correct horse battery"))
+
+	_, err = LoadPasswordPolicy(config.ConfigPasswords{BreachedList: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = LoadPasswordPolicy(config.ConfigPasswords{MinLength: -1})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestPasswordsService_Change(t *testing.T) {
+	service, usersRepo, _, _ := setupPasswordsService(t, nil)
+	ctx := context.Background()
+	user := passwordUser(t, "old password 1")
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return entities.ValidPassword("new password 1", u.PasswordHash)
+	})).Return(nil).Once()
+
+	err := service.Change(ctx, user, PasswordChangeCmd{CurrentPassword: "wrong password", NewPassword: "new password 1"})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	err = service.Change(ctx, user, PasswordChangeCmd{CurrentPassword: "old password 1", NewPassword: "short"})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	err = service.Change(ctx, user, PasswordChangeCmd{CurrentPassword: "old password 1", NewPassword: "new password 1"})
+	require.NoError(t, err)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestPasswordsService_Change_NoPassword(t *testing.T) {
+	service, usersRepo, _, _ := setupPasswordsService(t, nil)
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	err := service.Change(ctx, user, PasswordChangeCmd{NewPassword: "new password 1"})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	usersRepo.AssertNotCalled(t, "Update")
+
+	err = service.Change(ctx, createTestUser(entities.MilterUser), PasswordChangeCmd{})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestPasswordsService_Reset_WithPassword(t *testing.T) {
+	service, usersRepo, tokensRepo, cache := setupPasswordsService(t, nil)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	user := passwordUser(t, "old password 1")
+	require.NoError(t, cache.Set(ctx, sessionKey(user.ID, "session"), []byte("{}"), time.Hour))
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return entities.ValidPassword("new password 1", u.PasswordHash)
+	})).Return(nil).Once()
+	tokensRepo.On("BatchDeleteForUser", ctx, admin, user.ID).Return(nil).Once()
+
+	err := service.Reset(ctx, admin, PasswordResetCmd{UserID: user.ID, Password: new("new password 1")})
+	require.NoError(t, err)
+	usersRepo.AssertExpectations(t)
+	tokensRepo.AssertExpectations(t)
+
+	_, err = cache.Get(ctx, sessionKey(user.ID, "session"))
+	assert.ErrorIs(t, err, entities.ErrNotFound, "sessions of the user should be revoked")
+}
+
+func TestPasswordsService_Reset_Link(t *testing.T) {
+	m := &recordingMailer{}
+	service, usersRepo, tokensRepo, _ := setupPasswordsService(t, m)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	user := passwordUser(t, "old password 1")
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil).Once()
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool { return u.PasswordHash == "" })).Return(nil).Once()
+	tokensRepo.On("BatchDeleteForUser", ctx, admin, user.ID).Return(nil).Once()
+
+	err := service.Reset(ctx, admin, PasswordResetCmd{UserID: user.ID})
+	require.NoError(t, err)
+	require.Len(t, m.sent, 1)
+	assert.Equal(t, user.Login, m.sent[0].To)
+
+	// the link sets the password of the user without one
+	user.PasswordHash = ""
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil).Once()
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return entities.ValidPassword("new password 1", u.PasswordHash)
+	})).Return(nil).Once()
+	tokensRepo.On("BatchDeleteForUser", ctx, mock.Anything, user.ID).Return(nil).Once()
+
+	require.NoError(t, service.ConfirmReset(ctx, resetTokenFromMessage(t, m.sent[0]), "new password 1"))
+	usersRepo.AssertExpectations(t)
+}
+
+func TestPasswordsService_Reset_Errors(t *testing.T) {
+	service, usersRepo, _, _ := setupPasswordsService(t, nil)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	user := passwordUser(t, "old password 1")
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	err := service.Reset(ctx, createTestUser(entities.RegularUser), PasswordResetCmd{UserID: user.ID, Password: new("new password 1")})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	err = service.Reset(ctx, admin, PasswordResetCmd{UserID: user.ID})
+	assert.ErrorIs(t, err, entities.ErrValidation, "a password is required without reset links")
+
+	err = service.Reset(ctx, admin, PasswordResetCmd{UserID: user.ID, Password: new("short")})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	svcAcc := createTestUser(entities.MilterUser)
+	usersRepo.On("GetById", ctx, svcAcc.ID).Return(svcAcc, nil)
+	err = service.Reset(ctx, admin, PasswordResetCmd{UserID: svcAcc.ID, Password: new("new password 1")})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	usersRepo.AssertNotCalled(t, "Update")
+}
+
+func TestPasswordsService_RequestAndConfirmReset(t *testing.T) {
+	m := &recordingMailer{}
+	service, usersRepo, tokensRepo, _ := setupPasswordsService(t, m)
+	ctx := context.Background()
+	user := passwordUser(t, "old password 1")
+
+	usersRepo.On("GetByLogin", ctx, user.Login).Return(user, nil)
+	require.NoError(t, service.RequestReset(ctx, user.Login))
+	require.NoError(t, service.RequestReset(ctx, user.Login))
+	require.Len(t, m.sent, 1, "a link is sent once a minute at most")
+	token := resetTokenFromMessage(t, m.sent[0])
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil).Once()
+	err := service.ConfirmReset(ctx, token, "short")
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil).Once()
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return entities.ValidPassword("new password 1", u.PasswordHash)
+	})).Run(func(args mock.Arguments) {
+		user = args.Get(1).(entities.User)
+	}).Return(nil).Once()
+	tokensRepo.On("BatchDeleteForUser", ctx, mock.Anything, user.ID).Return(nil).Once()
+	require.NoError(t, service.ConfirmReset(ctx, token, "new password 1"))
+
+	// the link works once, the token is bound to the previous password
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil).Once()
+	err = service.ConfirmReset(ctx, token, "another password 1")
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	usersRepo.AssertExpectations(t)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestPasswordsService_RequestReset_NoLink(t *testing.T) {
+	m := &recordingMailer{}
+	service, usersRepo, _, _ := setupPasswordsService(t, m)
+	ctx := context.Background()
+
+	usersRepo.On("GetByLogin", ctx, "unknown@example.com").Return(entities.User{}, entities.ErrNotFound)
+	assert.NoError(t, service.RequestReset(ctx, "unknown@example.com"), "unknown users are not revealed")
+
+	inactive := passwordUser(t, "old password 1")
+	inactive.Login = "inactive@example.com"
+	inactive.Active = false
+	usersRepo.On("GetByLogin", ctx, inactive.Login).Return(inactive, nil)
+	assert.NoError(t, service.RequestReset(ctx, inactive.Login))
+
+	oidc := createTestUser(entities.RegularUser)
+	oidc.Login = "oidc@example.com"
+	oidc.Active = true
+	usersRepo.On("GetByLogin", ctx, oidc.Login).Return(oidc, nil)
+	assert.NoError(t, service.RequestReset(ctx, oidc.Login))
+
+	assert.Empty(t, m.sent)
+
+	noMail, _, _, _ := setupPasswordsService(t, nil)
+	assert.ErrorIs(t, noMail.RequestReset(ctx, "unknown@example.com"), entities.ErrValidation)
+}
+
+func TestPasswordsService_ConfirmReset_InvalidToken(t *testing.T) {
+	m := &recordingMailer{}
+	service, usersRepo, _, _ := setupPasswordsService(t, m)
+	ctx := context.Background()
+	user := passwordUser(t, "old password 1")
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	assert.ErrorIs(t, service.ConfirmReset(ctx, "bogus", "new password 1"), entities.ErrValidation)
+
+	expired := newSignedToken(service.secret, user.ID, time.Now().Add(-time.Minute).Truncate(time.Second), service.tokenBinding(user))
+	assert.ErrorIs(t, service.ConfirmReset(ctx, expired, "new password 1"), entities.ErrValidation)
+
+	// a confirmation token of a protected address signed with the same secret
+	praddrToken := newSignedToken(service.secret, user.ID, time.Now().Add(time.Hour), user.Login)
+	assert.ErrorIs(t, service.ConfirmReset(ctx, praddrToken, "new password 1"), entities.ErrValidation)
+
+	usersRepo.AssertNotCalled(t, "Update")
+}
@@ -2,21 +2,40 @@ package services
 
 import (
 	"context"
+	"crypto/hmac"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
 )
 
+const (
+	// DefaultVerificationTTL is the default period a confirmation link of a protected address is valid
+	DefaultVerificationTTL = 24 * time.Hour
+	// VerificationConfirmPath is the API path confirming protected addresses, appended to the base URL in the links
+	VerificationConfirmPath = "/api/v1/praddrs/confirm"
+	// DefaultKeyExpiryWarning is the default period before the expiration of the pgp key of a protected address its owner is notified
+	DefaultKeyExpiryWarning = 14 * 24 * time.Hour
+)
+
 type PrAddrCreateCmd struct {
 	Email    entities.Email
 	Metadata struct {
 		Comment     *string
 		ServiceName *string
 	}
+	// PGPKey is the ASCII armored public key the mail forwarded to the address is encrypted with
+	PGPKey *string
+	// Rules is the rules script filtering the mail forwarded to the address
+	Rules *string
 }
 
 type PrAddrUpdateCmd struct {
@@ -26,20 +45,63 @@ type PrAddrUpdateCmd struct {
 		ServiceName *string
 	}
 	Active *bool
+	// PGPKey replaces the public key of the address, an empty key removes it
+	PGPKey *string
+	// Rules replaces the rules script of the address, an empty script removes it
+	Rules *string
 }
 
 // ProtectedAddrService handles operations related to protected addresses
+//
+// New protected addresses stay pending until the owner of the mailbox opens the confirmation link sent
+// to it. The links carry a token signed with the verification secret, binding the address id, its email
+// and the expiry time, so no state is kept for them.
 type ProtectedAddrService struct {
-	repof *factory.RepoFactory
+	repof    *factory.RepoFactory
+	mailer   mailer.Mailer
+	disabled bool // ownership verification is disabled, new addresses are confirmed right away
+	baseURL  string
+	secret   []byte
+	tokenTTL time.Duration
 }
 
-// NewProtectedAddrService creates a new ProtectedAddrUsecase
-func NewProtectedAddrService(repoFactory *factory.RepoFactory) (*ProtectedAddrService, error) {
+// NewProtectedAddrService creates a new ProtectedAddrUsecase.
+// The mailer sending the confirmation links is required unless ownership verification
+// is disabled explicitly with cfg.Disabled.
+func NewProtectedAddrService(repoFactory *factory.RepoFactory, m mailer.Mailer, cfg config.ConfigVerification) (*ProtectedAddrService, error) {
 	if repoFactory == nil {
 		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
 	}
 
-	return &ProtectedAddrService{repof: repoFactory}, nil
+	if cfg.TTL < 0 {
+		return nil, fmt.Errorf("%w: verification ttl can not be negative", entities.ErrConfiguration)
+	}
+
+	prs := &ProtectedAddrService{repof: repoFactory, tokenTTL: DefaultVerificationTTL}
+	if cfg.TTL > 0 {
+		prs.tokenTTL = time.Duration(cfg.TTL) * time.Second
+	}
+
+	if cfg.Disabled {
+		prs.disabled = true
+		return prs, nil
+	}
+
+	if m == nil {
+		return nil, fmt.Errorf("%w: protected address verification requires smtp, set verification.disabled to confirm new addresses right away", entities.ErrConfiguration)
+	}
+
+	var err error
+	if prs.baseURL, err = linkBaseURL(cfg.BaseURL); err != nil {
+		return nil, err
+	}
+
+	prs.mailer = m
+	if prs.secret, err = newTokenSecret(cfg.Secret); err != nil {
+		return nil, err
+	}
+
+	return prs, nil
 }
 
 // Create creates a new protected address
@@ -77,6 +139,23 @@ func (prs *ProtectedAddrService) Create(ctx context.Context, cuser entities.User
 		Owner:     cuser,
 		UpdatedBy: cuser,
 		Active:    true,
+		Pending:   !prs.disabled,
+	}
+
+	if cmd.PGPKey != nil {
+		key, err := parsePGPKey(*cmd.PGPKey)
+		if err != nil {
+			return entities.Address{}, err
+		}
+		praddr.PGPKey = key
+	}
+
+	if cmd.Rules != nil {
+		rules, err := parseRules(ctx, prs.repof, cuser, *cmd.Rules)
+		if err != nil {
+			return entities.Address{}, err
+		}
+		praddr.Rules = rules
 	}
 
 	if err := praddr.Validate(); err != nil {
@@ -87,6 +166,13 @@ func (prs *ProtectedAddrService) Create(ctx context.Context, cuser entities.User
 		return entities.Address{}, err
 	}
 
+	// the address is created anyway, the owner can request another confirmation link
+	if praddr.Pending {
+		if err := prs.sendVerification(ctx, praddr); err != nil {
+			slog.Error("sending protected address confirmation", "praddr_id", praddr.ID, "error", err)
+		}
+	}
+
 	return praddr, nil
 }
 
@@ -113,6 +199,18 @@ func (prs *ProtectedAddrService) Update(ctx context.Context, cuser entities.User
 		praddr.Metadata.ServiceName = *cmd.Metadata.ServiceName
 	}
 
+	if cmd.PGPKey != nil {
+		if praddr.PGPKey, err = parsePGPKey(*cmd.PGPKey); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
+	if cmd.Rules != nil {
+		if praddr.Rules, err = parseRules(ctx, prs.repof, praddr.Owner, *cmd.Rules); err != nil {
+			return entities.Address{}, err
+		}
+	}
+
 	if cmd.Active != nil {
 		if canSetActivePrAddr(praddr, cuser) {
 			if *cmd.Active && !praddr.Active {
@@ -142,10 +240,10 @@ func (prs *ProtectedAddrService) Update(ctx context.Context, cuser entities.User
 // GetAll retrieves all protected addresses for a given owner
 func (prs *ProtectedAddrService) GetAll(ctx context.Context, cuser entities.User, filter entities.AddressFilter) ([]entities.Address, entities.PaginationMetadata, error) {
 	filter.Types = []entities.AddressType{entities.ProtectedAddress}
-	// reset Owners filter for non-admins
-	if cuser.Type != entities.AdminUser {
+	// reset Owners filter for users not allowed to read addresses of other users
+	if !cuser.HasPermission(entities.PermPrAddrsReadAll) {
 		filter.Owners = []entities.Id{cuser.ID}
-	} else if slices.Contains(filter.Owners, "all") && cuser.Type == entities.AdminUser {
+	} else if slices.Contains(filter.Owners, "all") {
 		filter.Owners = nil
 	} else if filter.Owners == nil {
 		filter.Owners = []entities.Id{cuser.ID}
@@ -220,3 +318,156 @@ func (prs *ProtectedAddrService) DeleteById(ctx context.Context, cuser entities.
 
 	return nil
 }
+
+// SendVerification sends a new confirmation link to a pending protected address
+func (prs *ProtectedAddrService) SendVerification(ctx context.Context, cuser entities.User, id entities.Id) error {
+	if err := id.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	praddr, err := prs.repof.Address.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if praddr.Type != entities.ProtectedAddress {
+		return fmt.Errorf("%w: protected address %s", entities.ErrNotFound, id)
+	}
+
+	if !canUpdatePrAddr(cuser, praddr) {
+		return entities.ErrNotAuthorized
+	}
+
+	if !praddr.Pending {
+		return fmt.Errorf("%w: protected address is already verified", entities.ErrValidation)
+	}
+
+	if prs.disabled {
+		return fmt.Errorf("%w: ownership verification is disabled", entities.ErrValidation)
+	}
+
+	return prs.sendVerification(ctx, praddr)
+}
+
+// Confirm verifies the token of a confirmation link and marks the protected address as confirmed.
+// It does not require a user: the token proves access to the mailbox of the address.
+func (prs *ProtectedAddrService) Confirm(ctx context.Context, token string) (entities.Address, error) {
+	id, expires, mac, err := prs.parseToken(token)
+	if err != nil {
+		return entities.Address{}, err
+	}
+
+	praddr, err := prs.repof.Address.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return entities.Address{}, fmt.Errorf("%w: invalid confirmation token", entities.ErrValidation)
+		}
+
+		return entities.Address{}, err
+	}
+
+	// the signature covers the email as well, a token is useless once the address is recreated
+	if praddr.Type != entities.ProtectedAddress || !hmac.Equal(prs.tokenMAC(id, string(praddr.Email), expires), mac) {
+		return entities.Address{}, fmt.Errorf("%w: invalid confirmation token", entities.ErrValidation)
+	}
+
+	if time.Now().After(expires) {
+		return entities.Address{}, fmt.Errorf("%w: confirmation link expired, request a new one", entities.ErrValidation)
+	}
+
+	if !praddr.Pending {
+		return praddr, nil
+	}
+
+	praddr.Pending = false
+	if err := prs.repof.Address.Update(ctx, praddr); err != nil {
+		return entities.Address{}, err
+	}
+
+	return praddr, nil
+}
+
+// NotifyKeysExpiring notifies the owners of the active protected addresses with pgp keys expiring within the given period.
+// Every key is reported once, mail forwarded to an address is not encrypted after its key expires.
+func (prs *ProtectedAddrService) NotifyKeysExpiring(ctx context.Context, within time.Duration) error {
+	praddrs, _, err := prs.repof.Address.GetAll(ctx, entities.AddressFilter{
+		Types:            []entities.AddressType{entities.ProtectedAddress},
+		Active:           new(true),
+		KeyExpiresBefore: new(time.Now().Add(within)),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, praddr := range praddrs {
+		if praddr.PGPKey == nil {
+			continue
+		}
+
+		key := praddr.ID.String() + ":" + praddr.PGPKey.Fingerprint
+		notify(ctx, prs.repof, praddr.Owner, entities.NotificationKeyExpiring, key, map[string]string{
+			"address":     string(praddr.Email),
+			"fingerprint": praddr.PGPKey.Fingerprint,
+			"expires":     praddr.PGPKey.ExpiresAt.UTC().Format(time.RFC1123),
+		})
+	}
+
+	return nil
+}
+
+// sendVerification mails the confirmation link to the protected address
+func (prs *ProtectedAddrService) sendVerification(ctx context.Context, praddr entities.Address) error {
+	expires := time.Now().Add(prs.tokenTTL).Truncate(time.Second)
+	link := prs.baseURL + VerificationConfirmPath + "?token=" + url.QueryEscape(prs.newToken(praddr, expires))
+
+	body := fmt.Sprintf("Hello,\n\n"+
+		"%s was added as a protected address to Ovoo. To confirm you own this mailbox, open the link below:\n\n"+
+		"%s\n\n"+
+		"The link is valid until %s. No mail is forwarded to this address until it is confirmed.\n"+
+		"If you did not expect this message, you can ignore it.\n",
+		praddr.Email, link, expires.UTC().Format(time.RFC1123))
+
+	return prs.mailer.Send(ctx, mailer.Message{
+		To:      string(praddr.Email),
+		Subject: "Confirm your Ovoo protected address",
+		Body:    body,
+	})
+}
+
+// newToken returns the confirmation token of the protected address, signed over its email
+func (prs *ProtectedAddrService) newToken(praddr entities.Address, expires time.Time) string {
+	return newSignedToken(prs.secret, praddr.ID, expires, string(praddr.Email))
+}
+
+// parseToken extracts the protected address id, the expiry time and the signature from the token,
+// the signature is checked by the caller once the email of the address is known
+func (prs *ProtectedAddrService) parseToken(token string) (entities.Id, time.Time, []byte, error) {
+	if prs.disabled {
+		return "", time.Time{}, nil, fmt.Errorf("%w: ownership verification is disabled", entities.ErrValidation)
+	}
+
+	id, expires, mac, ok := parseSignedToken(token)
+	if !ok {
+		return "", time.Time{}, nil, fmt.Errorf("%w: invalid confirmation token", entities.ErrValidation)
+	}
+
+	return id, expires, mac, nil
+}
+
+func (prs *ProtectedAddrService) tokenMAC(id entities.Id, email string, expires time.Time) []byte {
+	return signedTokenMAC(prs.secret, id, expires, email)
+}
+
+// parsePGPKey validates the uploaded armored key, an empty key removes the current one
+func parsePGPKey(armored string) (*entities.PGPKey, error) {
+	if strings.TrimSpace(armored) == "" {
+		return nil, nil
+	}
+
+	key, err := entities.ParsePGPKey(armored)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	return &key, nil
+}
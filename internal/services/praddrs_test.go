@@ -1,14 +1,25 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/Burmuley/ovoo/internal/config"
 	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
 )
 
@@ -21,7 +32,7 @@ func setupProtectedAddrService(t *testing.T) (*ProtectedAddrService, *MockAddres
 		Chain:   chainRepo,
 	}
 
-	service, err := NewProtectedAddrService(repof)
+	service, err := NewProtectedAddrService(repof, nil, config.ConfigVerification{Disabled: true})
 	require.NoError(t, err)
 
 	return service, addressRepo, chainRepo
@@ -29,14 +40,14 @@ func setupProtectedAddrService(t *testing.T) (*ProtectedAddrService, *MockAddres
 
 func TestNewProtectedAddrService(t *testing.T) {
 	repof := &factory.RepoFactory{}
-	service, err := NewProtectedAddrService(repof)
+	service, err := NewProtectedAddrService(repof, nil, config.ConfigVerification{Disabled: true})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, service)
 }
 
 func TestNewProtectedAddrService_NilRepoFactory(t *testing.T) {
-	service, err := NewProtectedAddrService(nil)
+	service, err := NewProtectedAddrService(nil, nil, config.ConfigVerification{})
 
 	assert.Error(t, err)
 	assert.Nil(t, service)
@@ -979,3 +990,335 @@ func TestProtectedAddrService_Update_SetActive_NotAuthorized(t *testing.T) {
 	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
 	addressRepo.AssertNotCalled(t, "Update")
 }
+
+// recordingMailer keeps the sent messages, it fails every message when err is set.
+type recordingMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var confirmLinkRe = regexp.MustCompile(`https://ovoo\.example\.com/api/v1/praddrs/confirm\?token=\S+`)
+
+// tokenFromMessage extracts the token of the confirmation link in the message.
+func tokenFromMessage(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	link := confirmLinkRe.FindString(msg.Body)
+	require.NotEmpty(t, link, "confirmation link not found in %q", msg.Body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func setupVerifyingPrAddrService(t *testing.T) (*ProtectedAddrService, *MockAddressRepo, *recordingMailer) {
+	addressRepo := new(MockAddressRepo)
+	m := &recordingMailer{}
+	service, err := NewProtectedAddrService(&factory.RepoFactory{Address: addressRepo}, m, config.ConfigVerification{
+		BaseURL: "https://ovoo.example.com/",
+		Secret:  "test-secret",
+	})
+	require.NoError(t, err)
+
+	return service, addressRepo, m
+}
+
+func TestNewProtectedAddrService_VerificationConfig(t *testing.T) {
+	repof := &factory.RepoFactory{}
+	m := &recordingMailer{}
+
+	_, err := NewProtectedAddrService(repof, m, config.ConfigVerification{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration, "base_url is required with a mailer")
+
+	_, err = NewProtectedAddrService(repof, m, config.ConfigVerification{BaseURL: "ovoo.example.com"})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewProtectedAddrService(repof, nil, config.ConfigVerification{TTL: -1})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewProtectedAddrService(repof, nil, config.ConfigVerification{BaseURL: "https://ovoo.example.com"})
+	assert.ErrorIs(t, err, entities.ErrConfiguration, "a mailer is required unless verification is disabled")
+
+	service, err := NewProtectedAddrService(repof, m, config.ConfigVerification{BaseURL: "https://ovoo.example.com", TTL: 60})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, service.tokenTTL)
+	assert.Len(t, service.secret, 32, "a random secret is generated when none is configured")
+}
+
+func TestProtectedAddrService_Create_Pending(t *testing.T) {
+	service, addressRepo, m := setupVerifyingPrAddrService(t)
+	ctx := context.Background()
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+
+	addressRepo.On("GetByEmail", ctx, entities.Email("protected@example.com")).Return(nil, entities.ErrNotFound)
+	addressRepo.On("Create", ctx, mock.MatchedBy(func(a entities.Address) bool { return a.Pending })).Return(nil)
+
+	praddr, err := service.Create(ctx, user, PrAddrCreateCmd{Email: "protected@example.com"})
+	require.NoError(t, err)
+	assert.True(t, praddr.Pending)
+	assert.True(t, praddr.Active)
+	addressRepo.AssertExpectations(t)
+
+	require.Len(t, m.sent, 1)
+	assert.Equal(t, "protected@example.com", m.sent[0].To)
+	assert.NotEmpty(t, tokenFromMessage(t, m.sent[0]))
+}
+
+func TestProtectedAddrService_Create_PendingMailerError(t *testing.T) {
+	service, addressRepo, m := setupVerifyingPrAddrService(t)
+	m.err = errors.New("connection refused")
+	ctx := context.Background()
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+
+	addressRepo.On("GetByEmail", ctx, entities.Email("protected@example.com")).Return(nil, entities.ErrNotFound)
+	addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+
+	praddr, err := service.Create(ctx, user, PrAddrCreateCmd{Email: "protected@example.com"})
+	require.NoError(t, err, "the address is created, the link can be sent again")
+	assert.True(t, praddr.Pending)
+}
+
+func TestProtectedAddrService_Create_VerificationDisabled(t *testing.T) {
+	service, addressRepo, _ := setupProtectedAddrService(t)
+	ctx := context.Background()
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+
+	addressRepo.On("GetByEmail", ctx, entities.Email("protected@example.com")).Return(nil, entities.ErrNotFound)
+	addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+
+	praddr, err := service.Create(ctx, user, PrAddrCreateCmd{Email: "protected@example.com"})
+	require.NoError(t, err)
+	assert.False(t, praddr.Pending)
+}
+
+func TestProtectedAddrService_Confirm(t *testing.T) {
+	service, addressRepo, m := setupVerifyingPrAddrService(t)
+	ctx := context.Background()
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	praddr := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.ProtectedAddress,
+		Email:   "protected@example.com",
+		Owner:   owner,
+		Active:  true,
+		Pending: true,
+	}
+
+	addressRepo.On("GetById", ctx, praddr.ID).Return(praddr, nil).Twice()
+	require.NoError(t, service.SendVerification(ctx, owner, praddr.ID))
+	require.Len(t, m.sent, 1)
+	token := tokenFromMessage(t, m.sent[0])
+
+	addressRepo.On("Update", ctx, mock.MatchedBy(func(a entities.Address) bool { return a.ID == praddr.ID && !a.Pending })).Return(nil).Once()
+	confirmed, err := service.Confirm(ctx, token)
+	require.NoError(t, err)
+	assert.False(t, confirmed.Pending)
+	addressRepo.AssertExpectations(t)
+
+	// confirming again is a no-op
+	confirmed.Pending = false
+	addressRepo.On("GetById", ctx, praddr.ID).Return(confirmed, nil).Once()
+	_, err = service.Confirm(ctx, token)
+	require.NoError(t, err)
+	addressRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestProtectedAddrService_Confirm_InvalidToken(t *testing.T) {
+	service, addressRepo, _ := setupVerifyingPrAddrService(t)
+	ctx := context.Background()
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	praddr := entities.Address{
+		ID:      entities.NewId(),
+		Type:    entities.ProtectedAddress,
+		Email:   "protected@example.com",
+		Owner:   owner,
+		Active:  true,
+		Pending: true,
+	}
+	addressRepo.On("GetById", ctx, praddr.ID).Return(praddr, nil)
+
+	other, err := NewProtectedAddrService(&factory.RepoFactory{Address: addressRepo}, &recordingMailer{}, config.ConfigVerification{
+		BaseURL: "https://ovoo.example.com",
+		Secret:  "other-secret",
+	})
+	require.NoError(t, err)
+
+	// the payload of a token with a later expiry and the signature of a valid one
+	valid := service.newToken(praddr, time.Now().Add(time.Hour))
+	extended := service.newToken(praddr, time.Now().Add(time.Hour*48))
+	tampered := extended[:strings.Index(extended, ".")] + valid[strings.Index(valid, "."):]
+	recreated := praddr
+	recreated.Email = "other@example.com"
+
+	tests := []struct {
+		name    string
+		token   string
+		message string
+	}{
+		{name: "empty", token: "", message: "invalid confirmation token"},
+		{name: "garbage", token: "not-a-token", message: "invalid confirmation token"},
+		{name: "extended expiry", token: tampered, message: "invalid confirmation token"},
+		{name: "other secret", token: other.newToken(praddr, time.Now().Add(time.Hour)), message: "invalid confirmation token"},
+		{name: "other email", token: service.newToken(recreated, time.Now().Add(time.Hour)), message: "invalid confirmation token"},
+		{name: "expired", token: service.newToken(praddr, time.Now().Add(-time.Minute)), message: "expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Confirm(ctx, tt.token)
+			assert.ErrorIs(t, err, entities.ErrValidation)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+	addressRepo.AssertNotCalled(t, "Update")
+}
+
+func TestProtectedAddrService_Confirm_VerificationDisabled(t *testing.T) {
+	service, _, _ := setupProtectedAddrService(t)
+
+	_, err := service.Confirm(context.Background(), "token")
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestProtectedAddrService_SendVerification(t *testing.T) {
+	service, addressRepo, m := setupVerifyingPrAddrService(t)
+	ctx := context.Background()
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "owner@test.com"}
+	other := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "other@test.com"}
+
+	pending := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "pending@example.com", Owner: owner, Active: true, Pending: true}
+	confirmed := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "confirmed@example.com", Owner: owner, Active: true}
+	addressRepo.On("GetById", ctx, pending.ID).Return(pending, nil)
+	addressRepo.On("GetById", ctx, confirmed.ID).Return(confirmed, nil)
+
+	assert.ErrorIs(t, service.SendVerification(ctx, other, pending.ID), entities.ErrNotAuthorized)
+	assert.ErrorIs(t, service.SendVerification(ctx, owner, confirmed.ID), entities.ErrValidation)
+	assert.Empty(t, m.sent)
+
+	require.NoError(t, service.SendVerification(ctx, owner, pending.ID))
+	require.Len(t, m.sent, 1)
+	assert.Equal(t, "pending@example.com", m.sent[0].To)
+}
+
+// testPGPKey returns an ASCII armored public key valid for encryption
+func testPGPKey(t *testing.T) string {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test", "", "protected@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestProtectedAddrService_Create_PGPKey(t *testing.T) {
+	service, addressRepo, _ := setupProtectedAddrService(t)
+	ctx := context.Background()
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+
+	addressRepo.On("GetByEmail", ctx, entities.Email("protected@example.com")).Return(nil, entities.ErrNotFound)
+	addressRepo.On("Create", ctx, mock.MatchedBy(func(a entities.Address) bool {
+		return a.PGPKey != nil && len(a.PGPKey.Fingerprint) == 40
+	})).Return(nil).Once()
+
+	praddr, err := service.Create(ctx, user, PrAddrCreateCmd{Email: "protected@example.com", PGPKey: new(testPGPKey(t))})
+	require.NoError(t, err)
+	require.NotNil(t, praddr.PGPKey)
+	assert.True(t, praddr.PGPKey.ExpiresAt.IsZero())
+	addressRepo.AssertExpectations(t)
+}
+
+func TestProtectedAddrService_Create_InvalidPGPKey(t *testing.T) {
+	service, addressRepo, _ := setupProtectedAddrService(t)
+	ctx := context.Background()
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+
+	addressRepo.On("GetByEmail", ctx, entities.Email("protected@example.com")).Return(nil, entities.ErrNotFound)
+
+	_, err := service.Create(ctx, user, PrAddrCreateCmd{Email: "protected@example.com", PGPKey: new("not a key")})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	addressRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProtectedAddrService_Update_RemovePGPKey(t *testing.T) {
+	service, addressRepo, _ := setupProtectedAddrService(t)
+	ctx := context.Background()
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	praddr := entities.Address{
+		ID:     entities.NewId(),
+		Type:   entities.ProtectedAddress,
+		Email:  "protected@example.com",
+		Owner:  user,
+		PGPKey: &entities.PGPKey{Armored: "key", Fingerprint: "ABCDEF"},
+	}
+
+	addressRepo.On("GetById", ctx, praddr.ID).Return(praddr, nil)
+	addressRepo.On("Update", ctx, mock.MatchedBy(func(a entities.Address) bool { return a.PGPKey == nil })).Return(nil).Once()
+
+	updated, err := service.Update(ctx, user, PrAddrUpdateCmd{PrAddrId: praddr.ID, PGPKey: new("")})
+	require.NoError(t, err)
+	assert.Nil(t, updated.PGPKey)
+	addressRepo.AssertExpectations(t)
+}
+
+func TestProtectedAddrService_NotifyKeysExpiring(t *testing.T) {
+	service, addressRepo, _ := setupProtectedAddrService(t)
+	queue := &recordingQueue{}
+	service.repof.Notifications = queue
+	ctx := context.Background()
+	expires := time.Now().Add(48 * time.Hour)
+	praddr := entities.Address{
+		ID:     entities.NewId(),
+		Type:   entities.ProtectedAddress,
+		Email:  "protected@example.com",
+		Owner:  notifiedUser(),
+		Active: true,
+		PGPKey: &entities.PGPKey{Armored: "key", Fingerprint: "ABCDEF", ExpiresAt: expires},
+	}
+
+	addressRepo.On("GetAll", ctx, mock.MatchedBy(func(f entities.AddressFilter) bool {
+		return f.KeyExpiresBefore != nil && f.Active != nil && *f.Active && len(f.Types) == 1 && f.Types[0] == entities.ProtectedAddress
+	})).Return([]entities.Address{praddr}, entities.PaginationMetadata{}, nil)
+
+	require.NoError(t, service.NotifyKeysExpiring(ctx, DefaultKeyExpiryWarning))
+	require.NoError(t, service.NotifyKeysExpiring(ctx, DefaultKeyExpiryWarning))
+
+	require.Len(t, queue.queued, 1, "the key is reported once")
+	n := queue.queued[0]
+	assert.Equal(t, entities.NotificationKeyExpiring, n.Event)
+	assert.Equal(t, "protected@example.com", n.Data["address"])
+	assert.Equal(t, "ABCDEF", n.Data["fingerprint"])
+	assert.Equal(t, expires.UTC().Format(time.RFC1123), n.Data["expires"])
+}
+
+func TestProtectedAddrService_Create_Rules(t *testing.T) {
+	service, addressRepo, _ := setupProtectedAddrService(t)
+	ctx := context.Background()
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	workAddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "work@example.com", Owner: user}
+
+	addressRepo.On("GetByEmail", ctx, entities.Email("protected@example.com")).Return(nil, entities.ErrNotFound)
+	addressRepo.On("GetByEmail", ctx, workAddr.Email).Return([]entities.Address{workAddr}, nil)
+	addressRepo.On("Create", ctx, mock.AnythingOfType("entities.Address")).Return(nil)
+
+	script := `if address :domain "from" "work.example" { redirect "work@example.com"; }`
+	praddr, err := service.Create(ctx, user, PrAddrCreateCmd{Email: "protected@example.com", Rules: &script})
+	require.NoError(t, err)
+	require.NotNil(t, praddr.Rules)
+	assert.Equal(t, script, praddr.Rules.Script)
+
+	// rules not accepted by the parser are rejected
+	invalid := `keep;`
+	_, err = service.Create(ctx, user, PrAddrCreateCmd{Email: "protected@example.com", Rules: &invalid})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
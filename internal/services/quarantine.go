@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/mailer"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+const (
+	// DefaultQuarantineMaxSize is the default size limit of a held message
+	DefaultQuarantineMaxSize = 10 << 20
+	// DefaultQuarantineRetention is the default period a held message is kept
+	DefaultQuarantineRetention = 14 * 24 * time.Hour
+)
+
+// QuarantineHoldCmd holds a message of the chain in the quarantine.
+type QuarantineHoldCmd struct {
+	ChainHash entities.Hash
+	// Data is the message as the milter would forward it
+	Data []byte
+}
+
+// QuarantineService represents the use case for the mail held for the aliases.
+//
+// The milter holds the messages of the chains which entities.Chain.Held reports instead of forwarding them.
+// The owners of the aliases review the held messages and either release them, which delivers the message
+// to the protected address over SMTP and lets the following mail of a new sender pass, or delete them.
+// Messages neither released nor deleted are removed once they expire.
+type QuarantineService struct {
+	repof     *factory.RepoFactory
+	relayer   mailer.Relayer
+	maxSize   int
+	retention time.Duration
+	// policy lists the senders whose mail is held for every alias
+	policy entities.ReputationPolicy
+}
+
+// NewQuarantineService creates a new QuarantineService instance.
+// The quarantine is disabled when r is nil, as the held messages could not be released.
+func NewQuarantineService(repoFactory *factory.RepoFactory, r mailer.Relayer, cfg config.ConfigQuarantine, policy entities.ReputationPolicy) (*QuarantineService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	if cfg.MaxSize < 0 || cfg.Retention < 0 {
+		return nil, fmt.Errorf("%w: quarantine max_size and retention can not be negative", entities.ErrConfiguration)
+	}
+
+	s := &QuarantineService{repof: repoFactory, relayer: r, maxSize: DefaultQuarantineMaxSize, retention: DefaultQuarantineRetention, policy: policy}
+	if cfg.MaxSize > 0 {
+		s.maxSize = cfg.MaxSize
+	}
+
+	if cfg.Retention > 0 {
+		s.retention = time.Duration(cfg.Retention) * time.Second
+	}
+
+	return s, nil
+}
+
+// Available reports whether mail is held, the quarantine is only enabled when outgoing mail is configured.
+func (s *QuarantineService) Available() bool {
+	return s.repof.Quarantine != nil && s.relayer != nil
+}
+
+// Hold stores the message of the chain in the quarantine, the chain should have its mail held.
+func (s *QuarantineService) Hold(ctx context.Context, cuser entities.User, cmd QuarantineHoldCmd) (entities.QuarantinedMessage, error) {
+	if !canHoldQuarantined(cuser) {
+		return entities.QuarantinedMessage{}, entities.ErrNotAuthorized
+	}
+
+	if !s.Available() {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: quarantine is not configured", entities.ErrValidation)
+	}
+
+	if err := cmd.ChainHash.Validate(); err != nil {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if len(cmd.Data) > s.maxSize {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: message is larger than %d bytes", entities.ErrValidation, s.maxSize)
+	}
+
+	chain, err := s.repof.Chain.GetByHash(ctx, cmd.ChainHash)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return entities.QuarantinedMessage{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+
+		return entities.QuarantinedMessage{}, err
+	}
+
+	// the mail of a sender listed by the sender reputation is held whatever the quarantine of the alias
+	if err := screenSender(ctx, s.repof, s.policy, &chain); err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return entities.QuarantinedMessage{}, err
+	}
+
+	if !chain.Held() {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: mail of the chain is not held", entities.ErrValidation)
+	}
+
+	now := time.Now().UTC()
+	msg := entities.QuarantinedMessage{
+		ID:        entities.NewId(),
+		Alias:     chain.OrigToAddress,
+		ChainHash: chain.Hash,
+		Sender:    chain.OrigFromAddress.Email,
+		RcptTo:    chain.ToAddress.Email,
+		Size:      len(cmd.Data),
+		Data:      cmd.Data,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.retention),
+	}
+
+	// a message which can not be parsed is held anyway, it is listed without a subject
+	if preview, err := msg.Preview(); err == nil {
+		msg.Subject = preview.Subject
+	}
+
+	if err := msg.Validate(); err != nil {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if err := s.repof.Quarantine.Create(ctx, msg); err != nil {
+		return entities.QuarantinedMessage{}, err
+	}
+
+	return msg, nil
+}
+
+// GetAll returns the held messages of the aliases of the current user, without their data.
+func (s *QuarantineService) GetAll(ctx context.Context, cuser entities.User, filter entities.QuarantineFilter) ([]entities.QuarantinedMessage, entities.PaginationMetadata, error) {
+	if !canGetAliases(cuser) {
+		return nil, entities.PaginationMetadata{}, entities.ErrNotAuthorized
+	}
+
+	if !s.Available() {
+		return []entities.QuarantinedMessage{}, entities.GetPaginationMetadata(filter.Page, filter.PageSize, 0), nil
+	}
+
+	// reset Owners filter for users not allowed to read aliases of other users
+	if !cuser.HasPermission(entities.PermAliasesReadAll) {
+		filter.Owners = []entities.Id{cuser.ID}
+	} else if slices.Contains(filter.Owners, "all") {
+		filter.Owners = nil
+	} else if filter.Owners == nil {
+		filter.Owners = []entities.Id{cuser.ID}
+	}
+
+	if cuser.Scope != nil && len(cuser.Scope.Domains) > 0 {
+		filter.Domains = cuser.Scope.Domains
+	}
+
+	filter.ExpiredBefore = nil
+	return s.repof.Quarantine.GetAll(ctx, filter)
+}
+
+// GetById returns the held message with its data.
+func (s *QuarantineService) GetById(ctx context.Context, cuser entities.User, id entities.Id) (entities.QuarantinedMessage, error) {
+	msg, err := s.get(ctx, id)
+	if err != nil {
+		return entities.QuarantinedMessage{}, err
+	}
+
+	if !canGetQuarantined(cuser, msg) {
+		return entities.QuarantinedMessage{}, entities.ErrNotAuthorized
+	}
+
+	return msg, nil
+}
+
+// Release delivers the held message to the protected address of the alias and removes it from the quarantine.
+// The following mail of the sender is not held anymore, unless the alias holds all mail.
+func (s *QuarantineService) Release(ctx context.Context, cuser entities.User, id entities.Id) (entities.QuarantinedMessage, error) {
+	msg, err := s.get(ctx, id)
+	if err != nil {
+		return entities.QuarantinedMessage{}, err
+	}
+
+	if !canManageQuarantined(cuser, msg) {
+		return entities.QuarantinedMessage{}, entities.ErrNotAuthorized
+	}
+
+	alias := msg.Alias
+	if !alias.Active || !alias.Owner.Active || alias.ForwardAddress == nil || !forwardable(*alias.ForwardAddress) {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: alias or its protected address can not receive mail", entities.ErrValidation)
+	}
+
+	if err := s.relayer.Relay(ctx, msg.RcptTo.String(), msg.Data); err != nil {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: delivering released message: %w", entities.ErrGeneral, err)
+	}
+
+	if err := s.repof.Quarantine.Delete(ctx, msg.ID); err != nil {
+		return entities.QuarantinedMessage{}, err
+	}
+
+	// the message is delivered already, a failure only keeps the following mail of the sender held
+	chain, err := s.repof.Chain.GetByHash(ctx, msg.ChainHash)
+	if err == nil && chain.Quarantined {
+		chain.Quarantined = false
+		chain.UpdatedBy = cuser
+		err = s.repof.Chain.Update(ctx, chain)
+	}
+	if err != nil && !errors.Is(err, entities.ErrNotFound) {
+		slog.Error("clearing quarantine of released message chain", "message_id", msg.ID, "error", err)
+	}
+
+	return msg, nil
+}
+
+// Delete removes the held message from the quarantine without delivering it.
+func (s *QuarantineService) Delete(ctx context.Context, cuser entities.User, id entities.Id) error {
+	msg, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !canManageQuarantined(cuser, msg) {
+		return entities.ErrNotAuthorized
+	}
+
+	return s.repof.Quarantine.Delete(ctx, msg.ID)
+}
+
+// PurgeExpired removes the expired messages from the quarantine.
+func (s *QuarantineService) PurgeExpired(ctx context.Context) error {
+	if !s.Available() {
+		return nil
+	}
+
+	n, err := s.repof.Quarantine.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		slog.Info("removed expired quarantined messages", "count", n)
+	}
+
+	return nil
+}
+
+func (s *QuarantineService) get(ctx context.Context, id entities.Id) (entities.QuarantinedMessage, error) {
+	if err := id.Validate(); err != nil {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if !s.Available() {
+		return entities.QuarantinedMessage{}, fmt.Errorf("%w: quarantined message %s", entities.ErrNotFound, id)
+	}
+
+	return s.repof.Quarantine.GetById(ctx, id)
+}
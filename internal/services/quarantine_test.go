@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+const heldMessage = "From: Sender <reply@ovoo.com>\r\nTo: alias@ovoo.com\r\nSubject: Hello\r\n\r\nHello there\r\n"
+
+// memoryQuarantine keeps the quarantined messages in memory.
+type memoryQuarantine struct {
+	msgs   map[entities.Id]entities.QuarantinedMessage
+	filter entities.QuarantineFilter
+}
+
+func (q *memoryQuarantine) GetById(ctx context.Context, id entities.Id) (entities.QuarantinedMessage, error) {
+	msg, ok := q.msgs[id]
+	if !ok {
+		return entities.QuarantinedMessage{}, entities.ErrNotFound
+	}
+	return msg, nil
+}
+
+func (q *memoryQuarantine) GetAll(ctx context.Context, filter entities.QuarantineFilter) ([]entities.QuarantinedMessage, entities.PaginationMetadata, error) {
+	q.filter = filter
+	msgs := make([]entities.QuarantinedMessage, 0, len(q.msgs))
+	for _, msg := range q.msgs {
+		msgs = append(msgs, msg)
+	}
+	return msgs, entities.PaginationMetadata{}, nil
+}
+
+func (q *memoryQuarantine) Create(ctx context.Context, msg entities.QuarantinedMessage) error {
+	q.msgs[msg.ID] = msg
+	return nil
+}
+
+func (q *memoryQuarantine) Delete(ctx context.Context, id entities.Id) error {
+	if _, ok := q.msgs[id]; !ok {
+		return entities.ErrNotFound
+	}
+	delete(q.msgs, id)
+	return nil
+}
+
+func (q *memoryQuarantine) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	n := 0
+	for id, msg := range q.msgs {
+		if msg.ExpiresAt.Before(before) {
+			delete(q.msgs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// recordingRelayer keeps the relayed messages, it fails every message when err is set.
+type recordingRelayer struct {
+	relayed map[string][]byte
+	err     error
+}
+
+func (r *recordingRelayer) Relay(ctx context.Context, to string, data []byte) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.relayed[to] = data
+	return nil
+}
+
+func setupQuarantineService(t *testing.T) (*QuarantineService, *memoryQuarantine, *recordingRelayer, *MockChainRepo) {
+	quarantine := &memoryQuarantine{msgs: map[entities.Id]entities.QuarantinedMessage{}}
+	relayer := &recordingRelayer{relayed: map[string][]byte{}}
+	chainRepo := new(MockChainRepo)
+
+	service, err := NewQuarantineService(&factory.RepoFactory{Chain: chainRepo, Quarantine: quarantine}, relayer, config.ConfigQuarantine{}, entities.ReputationPolicy{})
+	require.NoError(t, err)
+
+	return service, quarantine, relayer, chainRepo
+}
+
+// heldChain returns a forward chain of an alias holding the mail of new senders.
+func heldChain(owner entities.User) entities.Chain {
+	praddr := entities.Address{ID: entities.NewId(), Type: entities.ProtectedAddress, Email: "user@gmail.com", Owner: owner, Active: true}
+	alias := entities.Address{
+		ID:             entities.NewId(),
+		Type:           entities.AliasAddress,
+		Email:          "alias@ovoo.com",
+		ForwardAddress: &praddr,
+		Owner:          owner,
+		Active:         true,
+		Quarantine:     entities.QuarantineNewSenders,
+	}
+
+	return entities.Chain{
+		Hash:            entities.NewHash("sender@ext.com", "alias@ovoo.com"),
+		FromAddress:     entities.Address{ID: entities.NewId(), Type: entities.ReplyAliasAddress, Email: "reply@ovoo.com", Owner: owner},
+		ToAddress:       praddr,
+		OrigFromAddress: entities.Address{ID: entities.NewId(), Type: entities.ExternalAddress, Email: "sender@ext.com"},
+		OrigToAddress:   alias,
+		Quarantined:     true,
+	}
+}
+
+func TestNewQuarantineService_Config(t *testing.T) {
+	_, err := NewQuarantineService(nil, nil, config.ConfigQuarantine{}, entities.ReputationPolicy{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewQuarantineService(&factory.RepoFactory{}, nil, config.ConfigQuarantine{Retention: -1}, entities.ReputationPolicy{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	service, err := NewQuarantineService(&factory.RepoFactory{}, nil, config.ConfigQuarantine{MaxSize: 1024, Retention: 60}, entities.ReputationPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, 1024, service.maxSize)
+	assert.Equal(t, time.Minute, service.retention)
+	assert.False(t, service.Available())
+}
+
+func TestQuarantineService_Hold(t *testing.T) {
+	service, quarantine, _, chainRepo := setupQuarantineService(t)
+	ctx := context.Background()
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser}
+	chain := heldChain(entities.User{ID: entities.NewId(), Type: entities.RegularUser, Active: true})
+
+	chainRepo.On("GetByHash", ctx, chain.Hash).Return(chain, nil)
+
+	msg, err := service.Hold(ctx, milter, QuarantineHoldCmd{ChainHash: chain.Hash, Data: []byte(heldMessage)})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", msg.Subject)
+	assert.Equal(t, entities.Email("user@gmail.com"), msg.RcptTo)
+	assert.Equal(t, entities.Email("sender@ext.com"), msg.Sender)
+	assert.Equal(t, chain.OrigToAddress.ID, msg.Alias.ID)
+	assert.WithinDuration(t, time.Now().Add(DefaultQuarantineRetention), msg.ExpiresAt, time.Minute)
+	assert.Contains(t, quarantine.msgs, msg.ID)
+}
+
+// The mail of a listed sender is held whatever the quarantine of the alias.
+func TestQuarantineService_Hold_ListedSender(t *testing.T) {
+	service, quarantine, _, chainRepo := setupQuarantineService(t)
+	service.policy = entities.ReputationPolicy{Action: entities.ReputationQuarantine}
+	service.repof.Reputation = &memoryReputation{senders: map[entities.Email]entities.SenderReputation{
+		"sender@ext.com": listedSender("sender@ext.com"),
+	}}
+	ctx := context.Background()
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser}
+	chain := heldChain(entities.User{ID: entities.NewId(), Type: entities.RegularUser, Active: true})
+	chain.OrigToAddress.Quarantine = entities.QuarantineOff
+	chain.Quarantined = false
+
+	chainRepo.On("GetByHash", ctx, chain.Hash).Return(chain, nil)
+
+	msg, err := service.Hold(ctx, milter, QuarantineHoldCmd{ChainHash: chain.Hash, Data: []byte(heldMessage)})
+	require.NoError(t, err)
+	assert.Contains(t, quarantine.msgs, msg.ID)
+}
+
+func TestQuarantineService_Hold_Errors(t *testing.T) {
+	ctx := context.Background()
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser}
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Active: true}
+	released := heldChain(owner)
+	released.Quarantined = false
+
+	tests := []struct {
+		name    string
+		cuser   entities.User
+		chain   entities.Chain
+		data    string
+		wantErr error
+	}{
+		{name: "regular user", cuser: owner, chain: heldChain(owner), data: heldMessage, wantErr: entities.ErrNotAuthorized},
+		{name: "sender released", cuser: milter, chain: released, data: heldMessage, wantErr: entities.ErrValidation},
+		{name: "message too large", cuser: milter, chain: heldChain(owner), data: string(make([]byte, DefaultQuarantineMaxSize+1)), wantErr: entities.ErrValidation},
+		{name: "empty message", cuser: milter, chain: heldChain(owner), data: "", wantErr: entities.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, quarantine, _, chainRepo := setupQuarantineService(t)
+			chainRepo.On("GetByHash", ctx, tt.chain.Hash).Return(tt.chain, nil)
+
+			_, err := service.Hold(ctx, tt.cuser, QuarantineHoldCmd{ChainHash: tt.chain.Hash, Data: []byte(tt.data)})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Empty(t, quarantine.msgs)
+		})
+	}
+}
+
+func TestQuarantineService_Hold_Unavailable(t *testing.T) {
+	service, _, _, _ := setupQuarantineService(t)
+	service.relayer = nil
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser}
+	_, err := service.Hold(context.Background(), milter, QuarantineHoldCmd{ChainHash: entities.NewHash("a@x.com", "b@x.com"), Data: []byte(heldMessage)})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestQuarantineService_GetAll_Owners(t *testing.T) {
+	ctx := context.Background()
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Active: true}
+	admin := entities.User{ID: entities.NewId(), Type: entities.AdminUser, Active: true}
+
+	tests := []struct {
+		name   string
+		cuser  entities.User
+		owners []entities.Id
+		wanted []entities.Id
+	}{
+		{name: "user reading other owner", cuser: user, owners: []entities.Id{admin.ID}, wanted: []entities.Id{user.ID}},
+		{name: "admin default", cuser: admin, wanted: []entities.Id{admin.ID}},
+		{name: "admin reading all", cuser: admin, owners: []entities.Id{"all"}, wanted: nil},
+		{name: "admin reading other owner", cuser: admin, owners: []entities.Id{user.ID}, wanted: []entities.Id{user.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, quarantine, _, _ := setupQuarantineService(t)
+			_, _, err := service.GetAll(ctx, tt.cuser, entities.QuarantineFilter{Owners: tt.owners})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wanted, quarantine.filter.Owners)
+		})
+	}
+}
+
+func TestQuarantineService_Release(t *testing.T) {
+	service, quarantine, relayer, chainRepo := setupQuarantineService(t)
+	ctx := context.Background()
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Active: true}
+	chain := heldChain(owner)
+
+	chainRepo.On("GetByHash", ctx, chain.Hash).Return(chain, nil)
+	chainRepo.On("Update", ctx, mock.MatchedBy(func(c entities.Chain) bool {
+		return c.Hash == chain.Hash && !c.Quarantined && c.UpdatedBy.ID == owner.ID
+	})).Return(nil).Once()
+
+	milter := entities.User{ID: entities.NewId(), Type: entities.MilterUser}
+	msg, err := service.Hold(ctx, milter, QuarantineHoldCmd{ChainHash: chain.Hash, Data: []byte(heldMessage)})
+	require.NoError(t, err)
+
+	_, err = service.Release(ctx, owner, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(heldMessage), relayer.relayed["user@gmail.com"])
+	assert.Empty(t, quarantine.msgs)
+	chainRepo.AssertExpectations(t)
+}
+
+func TestQuarantineService_Release_Errors(t *testing.T) {
+	ctx := context.Background()
+	owner := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Active: true}
+	other := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Active: true}
+
+	tests := []struct {
+		name       string
+		cuser      entities.User
+		modify     func(alias *entities.Address)
+		relayErr   error
+		wantErr    error
+		wantRelays int
+	}{
+		{name: "other user", cuser: other, wantErr: entities.ErrNotAuthorized},
+		{name: "inactive alias", cuser: owner, modify: func(a *entities.Address) { a.Active = false }, wantErr: entities.ErrValidation},
+		{name: "pending protected address", cuser: owner, modify: func(a *entities.Address) { a.ForwardAddress.Pending = true }, wantErr: entities.ErrValidation},
+		{name: "delivery fails", cuser: owner, relayErr: errors.New("connection refused"), wantErr: entities.ErrGeneral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, quarantine, relayer, _ := setupQuarantineService(t)
+			relayer.err = tt.relayErr
+
+			chain := heldChain(owner)
+			if tt.modify != nil {
+				tt.modify(&chain.OrigToAddress)
+			}
+			msg := entities.QuarantinedMessage{ID: entities.NewId(), Alias: chain.OrigToAddress, ChainHash: chain.Hash, RcptTo: "user@gmail.com", Data: []byte(heldMessage)}
+			quarantine.msgs[msg.ID] = msg
+
+			_, err := service.Release(ctx, tt.cuser, msg.ID)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Contains(t, quarantine.msgs, msg.ID)
+			assert.Empty(t, relayer.relayed)
+		})
+	}
+}
+
+func TestQuarantineService_PurgeExpired(t *testing.T) {
+	service, quarantine, _, _ := setupQuarantineService(t)
+	expired := entities.QuarantinedMessage{ID: entities.NewId(), ExpiresAt: time.Now().Add(-time.Minute)}
+	kept := entities.QuarantinedMessage{ID: entities.NewId(), ExpiresAt: time.Now().Add(time.Hour)}
+	quarantine.msgs[expired.ID] = expired
+	quarantine.msgs[kept.ID] = kept
+
+	require.NoError(t, service.PurgeExpired(context.Background()))
+	assert.NotContains(t, quarantine.msgs, expired.ID)
+	assert.Contains(t, quarantine.msgs, kept.ID)
+}
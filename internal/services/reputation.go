@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+// TrapCreateCmd creates a trap address.
+type TrapCreateCmd struct {
+	// Email is the address in an active domain, it should not be used by another address
+	Email   string
+	Comment *string
+}
+
+// ReputationOverrideCmd sets the override of an admin on a sender.
+type ReputationOverrideCmd struct {
+	Sender   string
+	Override entities.ReputationOverride
+	Comment  *string
+}
+
+// ReputationService represents the use case for the trap addresses and the sender reputation.
+//
+// Trap addresses are addresses of the Ovoo domains which are never given out, so only the senders harvesting
+// or guessing addresses write to them. Every message to a trap adds a hit to the score of its sender, which
+// decays with the half life of the policy. The senders scoring the threshold of the policy are listed: their
+// mail to any alias is rejected or held in the quarantine, and the socketmap exports them to the MTA. Admins
+// block or allow a sender whatever its score.
+type ReputationService struct {
+	repof  *factory.RepoFactory
+	policy entities.ReputationPolicy
+}
+
+// NewReputationService creates a new ReputationService instance.
+func NewReputationService(repoFactory *factory.RepoFactory, policy entities.ReputationPolicy) (*ReputationService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	return &ReputationService{repof: repoFactory, policy: policy}, nil
+}
+
+// LoadReputationPolicy returns the sender reputation policy of the configuration.
+func LoadReputationPolicy(cfg config.ConfigReputation) (entities.ReputationPolicy, error) {
+	policy := entities.ReputationPolicy{
+		HalfLife:  time.Duration(cfg.HalfLife) * time.Second,
+		Threshold: cfg.Threshold,
+		Action:    entities.ReputationAction(cfg.Action),
+	}
+
+	if err := policy.Validate(); err != nil {
+		return entities.ReputationPolicy{}, fmt.Errorf("%w: %w", entities.ErrConfiguration, err)
+	}
+
+	return policy, nil
+}
+
+// Policy returns the policy listing the senders.
+func (s *ReputationService) Policy() entities.ReputationPolicy {
+	return s.policy
+}
+
+// GetTraps returns the trap addresses.
+func (s *ReputationService) GetTraps(ctx context.Context, cuser entities.User, filter entities.AddressFilter) ([]entities.Address, entities.PaginationMetadata, error) {
+	if !canGetReputation(cuser) {
+		return nil, entities.PaginationMetadata{}, entities.ErrNotAuthorized
+	}
+
+	filter.Types = []entities.AddressType{entities.TrapAddress}
+	filter.Owners = nil
+	return s.repof.Address.GetAll(ctx, filter)
+}
+
+// CreateTrap creates a trap address, the mail to it is refused and its senders are added to the sender reputation.
+func (s *ReputationService) CreateTrap(ctx context.Context, cuser entities.User, cmd TrapCreateCmd) (entities.Address, error) {
+	if !canManageReputation(cuser) {
+		return entities.Address{}, entities.ErrNotAuthorized
+	}
+
+	email := entities.Email(strings.ToLower(strings.TrimSpace(cmd.Email)))
+	if err := email.Validate(); err != nil {
+		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	name := email.String()[strings.LastIndex(email.String(), "@")+1:]
+	domain, err := s.repof.Domain.GetByName(ctx, name)
+	if err != nil || !domain.Active || !domain.Verified {
+		return entities.Address{}, fmt.Errorf("%w: unknown or inactive domain %q", entities.ErrValidation, name)
+	}
+
+	existing, err := s.repof.Address.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return entities.Address{}, err
+	}
+
+	if len(existing) > 0 {
+		return entities.Address{}, fmt.Errorf("%w: address %s is already used", entities.ErrDuplicateEntry, email)
+	}
+
+	trap := entities.Address{
+		Type:      entities.TrapAddress,
+		ID:        entities.NewId(),
+		Email:     email,
+		Owner:     cuser,
+		UpdatedBy: cuser,
+		Active:    true,
+	}
+
+	if cmd.Comment != nil {
+		trap.Metadata.Comment = strings.TrimSpace(*cmd.Comment)
+	}
+
+	if err := trap.Validate(); err != nil {
+		return entities.Address{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if err := s.repof.Address.Create(ctx, trap); err != nil {
+		return entities.Address{}, err
+	}
+
+	return trap, nil
+}
+
+// DeleteTrap deletes the trap address, the reputation of its senders is kept.
+func (s *ReputationService) DeleteTrap(ctx context.Context, cuser entities.User, id entities.Id) error {
+	if !canManageReputation(cuser) {
+		return entities.ErrNotAuthorized
+	}
+
+	if err := id.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	trap, err := s.repof.Address.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if trap.Type != entities.TrapAddress {
+		return fmt.Errorf("%w: trap address %s", entities.ErrNotFound, id)
+	}
+
+	return s.repof.Address.DeleteById(ctx, cuser, id)
+}
+
+// GetAll returns the senders of the sender reputation, the senders hitting the traps last first.
+func (s *ReputationService) GetAll(ctx context.Context, cuser entities.User, filter entities.ReputationFilter) ([]entities.SenderReputation, entities.PaginationMetadata, error) {
+	if !canGetReputation(cuser) {
+		return nil, entities.PaginationMetadata{}, entities.ErrNotAuthorized
+	}
+
+	return s.repof.Reputation.GetAll(ctx, filter)
+}
+
+// Lookup returns the reputation of the sender, a sender unknown to the sender reputation has an empty one.
+// The milter and the socketmap look the senders up.
+func (s *ReputationService) Lookup(ctx context.Context, cuser entities.User, sender string) (entities.SenderReputation, error) {
+	if !canLookupSender(cuser) {
+		return entities.SenderReputation{}, entities.ErrNotAuthorized
+	}
+
+	email := entities.ReputationSender(entities.Email(sender))
+	if err := email.Validate(); err != nil {
+		return entities.SenderReputation{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	rep, err := s.repof.Reputation.GetBySender(ctx, email)
+	if errors.Is(err, entities.ErrNotFound) {
+		return entities.SenderReputation{Sender: email}, nil
+	}
+
+	return rep, err
+}
+
+// Override blocks or allows the sender whatever its score, the sender is added to the sender reputation
+// when it is not known to it. The none override lists the sender by its score again.
+func (s *ReputationService) Override(ctx context.Context, cuser entities.User, cmd ReputationOverrideCmd) (entities.SenderReputation, error) {
+	if !canManageReputation(cuser) {
+		return entities.SenderReputation{}, entities.ErrNotAuthorized
+	}
+
+	if err := cmd.Override.Validate(); err != nil {
+		return entities.SenderReputation{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	sender := entities.ReputationSender(entities.Email(cmd.Sender))
+	if err := sender.Validate(); err != nil {
+		return entities.SenderReputation{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	now := time.Now().UTC()
+	rep, err := s.repof.Reputation.GetBySender(ctx, sender)
+	if errors.Is(err, entities.ErrNotFound) {
+		rep = entities.SenderReputation{Sender: sender, CreatedAt: now}
+	} else if err != nil {
+		return entities.SenderReputation{}, err
+	}
+
+	rep.Override = cmd.Override
+	rep.UpdatedAt = now
+	if cmd.Comment != nil {
+		rep.Comment = strings.TrimSpace(*cmd.Comment)
+	}
+
+	if err := rep.Validate(); err != nil {
+		return entities.SenderReputation{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if err := s.repof.Reputation.Save(ctx, rep); err != nil {
+		return entities.SenderReputation{}, err
+	}
+
+	return rep, nil
+}
+
+// Delete removes the sender from the sender reputation, forgetting its hits and override.
+func (s *ReputationService) Delete(ctx context.Context, cuser entities.User, sender string) error {
+	if !canManageReputation(cuser) {
+		return entities.ErrNotAuthorized
+	}
+
+	return s.repof.Reputation.Delete(ctx, entities.ReputationSender(entities.Email(sender)))
+}
+
+// PurgeFaded removes the senders without an override whose score decayed below the threshold for good.
+func (s *ReputationService) PurgeFaded(ctx context.Context) error {
+	n, err := s.repof.Reputation.DeleteFaded(ctx, s.policy.FadedBefore(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		slog.Info("removed faded senders from the sender reputation", "count", n)
+	}
+
+	return nil
+}
+
+// recordTrapHit adds a hit of a trap address to the score of the sender, bounces have no sender to record.
+func recordTrapHit(ctx context.Context, repof *factory.RepoFactory, policy entities.ReputationPolicy, sender entities.Email) error {
+	sender = entities.ReputationSender(sender)
+	if repof.Reputation == nil || sender.Validate() != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	rep, err := repof.Reputation.GetBySender(ctx, sender)
+	if errors.Is(err, entities.ErrNotFound) {
+		rep = entities.SenderReputation{Sender: sender, CreatedAt: now}
+	} else if err != nil {
+		return err
+	}
+
+	policy.Hit(&rep, now)
+	rep.UpdatedAt = now
+	return repof.Reputation.Save(ctx, rep)
+}
+
+// screenSender checks the sender of the forward chain against the sender reputation. The mail of a listed
+// sender is refused with entities.ErrNotFound, or held when the policy quarantines it: the chain is marked
+// as listed then. The mail is refused when it can not be held, as the quarantine is disabled or the alias
+// delivers to a webhook.
+func screenSender(ctx context.Context, repof *factory.RepoFactory, policy entities.ReputationPolicy, chain *entities.Chain) error {
+	if repof.Reputation == nil || chain.OrigToAddress.Type != entities.AliasAddress {
+		return nil
+	}
+
+	sender := entities.ReputationSender(chain.OrigFromAddress.Email)
+	if sender.Validate() != nil {
+		return nil
+	}
+
+	rep, err := repof.Reputation.GetBySender(ctx, sender)
+	if errors.Is(err, entities.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !policy.Listed(rep, time.Now()) {
+		return nil
+	}
+
+	webhook := chain.ToAddress.Type == entities.WebhookAddress
+	if policy.Reject() || repof.Quarantine == nil || webhook {
+		return fmt.Errorf("%w: sender is listed by the sender reputation", entities.ErrNotFound)
+	}
+
+	chain.Listed = true
+	return nil
+}
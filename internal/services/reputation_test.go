@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+// memoryReputation keeps the sender reputation in memory.
+type memoryReputation struct {
+	senders map[entities.Email]entities.SenderReputation
+}
+
+func (r *memoryReputation) GetBySender(ctx context.Context, sender entities.Email) (entities.SenderReputation, error) {
+	rep, ok := r.senders[sender]
+	if !ok {
+		return entities.SenderReputation{}, entities.ErrNotFound
+	}
+	return rep, nil
+}
+
+func (r *memoryReputation) GetAll(ctx context.Context, filter entities.ReputationFilter) ([]entities.SenderReputation, entities.PaginationMetadata, error) {
+	reps := make([]entities.SenderReputation, 0, len(r.senders))
+	for _, rep := range r.senders {
+		reps = append(reps, rep)
+	}
+	return reps, entities.PaginationMetadata{}, nil
+}
+
+func (r *memoryReputation) Save(ctx context.Context, rep entities.SenderReputation) error {
+	r.senders[rep.Sender] = rep
+	return nil
+}
+
+func (r *memoryReputation) Delete(ctx context.Context, sender entities.Email) error {
+	if _, ok := r.senders[sender]; !ok {
+		return entities.ErrNotFound
+	}
+	delete(r.senders, sender)
+	return nil
+}
+
+func (r *memoryReputation) DeleteFaded(ctx context.Context, before time.Time) (int, error) {
+	n := 0
+	for sender, rep := range r.senders {
+		if rep.Override == entities.ReputationOverrideNone && rep.LastHitAt.Before(before) {
+			delete(r.senders, sender)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func setupReputationService(t *testing.T) (*ReputationService, *memoryReputation, *MockAddressRepo, *MockDomainRepo) {
+	reputation := &memoryReputation{senders: map[entities.Email]entities.SenderReputation{}}
+	addressRepo := new(MockAddressRepo)
+	domainRepo := new(MockDomainRepo)
+
+	repof := &factory.RepoFactory{Address: addressRepo, Domain: domainRepo, Reputation: reputation}
+	service, err := NewReputationService(repof, entities.ReputationPolicy{})
+	require.NoError(t, err)
+
+	return service, reputation, addressRepo, domainRepo
+}
+
+// listedSender returns the reputation of a sender hitting a trap a moment ago.
+func listedSender(sender entities.Email) entities.SenderReputation {
+	return entities.SenderReputation{Sender: sender, Hits: 1, Score: 1, LastHitAt: time.Now()}
+}
+
+func TestNewReputationService_NilRepoFactory(t *testing.T) {
+	service, err := NewReputationService(nil, entities.ReputationPolicy{})
+	assert.Nil(t, service)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestLoadReputationPolicy(t *testing.T) {
+	policy, err := LoadReputationPolicy(config.ConfigReputation{HalfLife: 3600, Threshold: 3, Action: "quarantine"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, policy.HalfLife)
+	assert.Equal(t, 3.0, policy.Threshold)
+	assert.False(t, policy.Reject())
+
+	policy, err = LoadReputationPolicy(config.ConfigReputation{})
+	require.NoError(t, err)
+	assert.True(t, policy.Reject())
+
+	_, err = LoadReputationPolicy(config.ConfigReputation{Action: "bounce"})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = LoadReputationPolicy(config.ConfigReputation{HalfLife: -1})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestReputationService_CreateTrap(t *testing.T) {
+	admin := createTestUser(entities.AdminUser)
+	ctx := context.Background()
+	domain := entities.CustomDomain{Name: "ovoo.com", Active: true, Verified: true}
+
+	t.Run("success", func(t *testing.T) {
+		service, _, addressRepo, domainRepo := setupReputationService(t)
+		domainRepo.On("GetByName", ctx, "ovoo.com").Return(domain, nil)
+		addressRepo.On("GetByEmail", ctx, entities.Email("trap@ovoo.com")).Return(nil, entities.ErrNotFound)
+		addressRepo.On("Create", ctx, mock.MatchedBy(func(a entities.Address) bool {
+			return a.Type == entities.TrapAddress && a.Active && a.ForwardAddress == nil
+		})).Return(nil)
+
+		trap, err := service.CreateTrap(ctx, admin, TrapCreateCmd{Email: " Trap@ovoo.com", Comment: new("scraped pages")})
+		require.NoError(t, err)
+		assert.Equal(t, entities.Email("trap@ovoo.com"), trap.Email)
+		assert.Equal(t, "scraped pages", trap.Metadata.Comment)
+		assert.Equal(t, admin.ID, trap.Owner.ID)
+		addressRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown domain", func(t *testing.T) {
+		service, _, _, domainRepo := setupReputationService(t)
+		domainRepo.On("GetByName", ctx, "other.com").Return(entities.CustomDomain{}, entities.ErrNotFound)
+
+		_, err := service.CreateTrap(ctx, admin, TrapCreateCmd{Email: "trap@other.com"})
+		assert.ErrorIs(t, err, entities.ErrValidation)
+	})
+
+	t.Run("address in use", func(t *testing.T) {
+		service, _, addressRepo, domainRepo := setupReputationService(t)
+		domainRepo.On("GetByName", ctx, "ovoo.com").Return(domain, nil)
+		addressRepo.On("GetByEmail", ctx, entities.Email("alias@ovoo.com")).Return([]entities.Address{{Type: entities.AliasAddress}}, nil)
+
+		_, err := service.CreateTrap(ctx, admin, TrapCreateCmd{Email: "alias@ovoo.com"})
+		assert.ErrorIs(t, err, entities.ErrDuplicateEntry)
+	})
+
+	t.Run("not authorized", func(t *testing.T) {
+		service, _, _, _ := setupReputationService(t)
+
+		_, err := service.CreateTrap(ctx, createTestUser(entities.RegularUser), TrapCreateCmd{Email: "trap@ovoo.com"})
+		assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+	})
+}
+
+func TestReputationService_DeleteTrap(t *testing.T) {
+	service, _, addressRepo, _ := setupReputationService(t)
+	admin := createTestUser(entities.AdminUser)
+	ctx := context.Background()
+
+	trap := entities.Address{ID: entities.NewId(), Type: entities.TrapAddress, Email: "trap@ovoo.com"}
+	alias := entities.Address{ID: entities.NewId(), Type: entities.AliasAddress, Email: "alias@ovoo.com"}
+	addressRepo.On("GetById", ctx, trap.ID).Return(trap, nil)
+	addressRepo.On("GetById", ctx, alias.ID).Return(alias, nil)
+	addressRepo.On("DeleteById", ctx, admin, trap.ID).Return(nil).Once()
+
+	require.NoError(t, service.DeleteTrap(ctx, admin, trap.ID))
+	// only the traps are deleted
+	assert.ErrorIs(t, service.DeleteTrap(ctx, admin, alias.ID), entities.ErrNotFound)
+	addressRepo.AssertExpectations(t)
+}
+
+func TestReputationService_Override(t *testing.T) {
+	service, reputation, _, _ := setupReputationService(t)
+	admin := createTestUser(entities.AdminUser)
+	ctx := context.Background()
+
+	// an unknown sender is added to the sender reputation
+	rep, err := service.Override(ctx, admin, ReputationOverrideCmd{Sender: "Spam@Bulk.com", Override: entities.ReputationBlock, Comment: new("spammer")})
+	require.NoError(t, err)
+	assert.Equal(t, entities.Email("spam@bulk.com"), rep.Sender)
+	assert.Equal(t, entities.ReputationBlock, reputation.senders["spam@bulk.com"].Override)
+	assert.True(t, service.Policy().Listed(rep, time.Now()))
+
+	reputation.senders["news@shop.com"] = listedSender("news@shop.com")
+	rep, err = service.Override(ctx, admin, ReputationOverrideCmd{Sender: "news@shop.com", Override: entities.ReputationAllow})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rep.Hits)
+	assert.False(t, service.Policy().Listed(rep, time.Now()))
+
+	// the sender is listed by its score again
+	rep, err = service.Override(ctx, admin, ReputationOverrideCmd{Sender: "news@shop.com", Override: entities.ReputationOverrideNone})
+	require.NoError(t, err)
+	assert.True(t, service.Policy().Listed(rep, time.Now()))
+
+	_, err = service.Override(ctx, admin, ReputationOverrideCmd{Sender: "news@shop.com", Override: "ignore"})
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	_, err = service.Override(ctx, createTestUser(entities.RegularUser), ReputationOverrideCmd{Sender: "news@shop.com", Override: entities.ReputationAllow})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestReputationService_Lookup(t *testing.T) {
+	service, reputation, _, _ := setupReputationService(t)
+	milter := createTestUser(entities.MilterUser)
+	ctx := context.Background()
+	reputation.senders["spam@bulk.com"] = listedSender("spam@bulk.com")
+
+	rep, err := service.Lookup(ctx, milter, "SPAM@bulk.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, rep.Hits)
+
+	// unknown senders have an empty reputation
+	rep, err = service.Lookup(ctx, milter, "news@shop.com")
+	require.NoError(t, err)
+	assert.Equal(t, entities.Email("news@shop.com"), rep.Sender)
+	assert.False(t, service.Policy().Listed(rep, time.Now()))
+
+	_, err = service.Lookup(ctx, milter, "not an email")
+	assert.ErrorIs(t, err, entities.ErrValidation)
+
+	_, err = service.Lookup(ctx, createTestUser(entities.RegularUser), "news@shop.com")
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestReputationService_PurgeFaded(t *testing.T) {
+	service, reputation, _, _ := setupReputationService(t)
+	ctx := context.Background()
+
+	long := time.Now().Add(-365 * 24 * time.Hour)
+	reputation.senders["old@spam.com"] = entities.SenderReputation{Sender: "old@spam.com", Hits: 1, Score: 1, LastHitAt: long}
+	reputation.senders["blocked@spam.com"] = entities.SenderReputation{Sender: "blocked@spam.com", Hits: 1, Score: 1, LastHitAt: long, Override: entities.ReputationBlock}
+	reputation.senders["new@spam.com"] = listedSender("new@spam.com")
+
+	require.NoError(t, service.PurgeFaded(ctx))
+	assert.NotContains(t, reputation.senders, entities.Email("old@spam.com"))
+	assert.Contains(t, reputation.senders, entities.Email("blocked@spam.com"))
+	assert.Contains(t, reputation.senders, entities.Email("new@spam.com"))
+}
+
+func TestRecordTrapHit(t *testing.T) {
+	reputation := &memoryReputation{senders: map[entities.Email]entities.SenderReputation{}}
+	repof := &factory.RepoFactory{Reputation: reputation}
+	ctx := context.Background()
+
+	require.NoError(t, recordTrapHit(ctx, repof, entities.ReputationPolicy{}, "Spam@Bulk.com"))
+	require.NoError(t, recordTrapHit(ctx, repof, entities.ReputationPolicy{}, "spam@bulk.com"))
+	rep := reputation.senders["spam@bulk.com"]
+	assert.Equal(t, 2, rep.Hits)
+	assert.InDelta(t, 2.0, rep.Score, 0.01)
+	assert.False(t, rep.CreatedAt.IsZero())
+
+	// bounces have no sender
+	require.NoError(t, recordTrapHit(ctx, repof, entities.ReputationPolicy{}, ""))
+	assert.Len(t, reputation.senders, 1)
+}
+
+func TestScreenSender(t *testing.T) {
+	quarantine := entities.ReputationPolicy{Action: entities.ReputationQuarantine}
+	tests := []struct {
+		name       string
+		policy     entities.ReputationPolicy
+		rep        *entities.SenderReputation
+		webhook    bool
+		noQuarant  bool
+		wantErr    error
+		wantListed bool
+	}{
+		{name: "unknown sender"},
+		{name: "listed sender rejected", rep: new(listedSender("spam@bulk.com")), wantErr: entities.ErrNotFound},
+		{name: "listed sender held", policy: quarantine, rep: new(listedSender("spam@bulk.com")), wantListed: true},
+		{name: "held without quarantine", policy: quarantine, rep: new(listedSender("spam@bulk.com")), noQuarant: true, wantErr: entities.ErrNotFound},
+		{name: "held for webhook", policy: quarantine, rep: new(listedSender("spam@bulk.com")), webhook: true, wantErr: entities.ErrNotFound},
+		{
+			name: "allowed sender",
+			rep:  &entities.SenderReputation{Sender: "spam@bulk.com", Hits: 5, Score: 5, LastHitAt: time.Now(), Override: entities.ReputationAllow},
+		},
+		{
+			name: "faded sender",
+			rep:  &entities.SenderReputation{Sender: "spam@bulk.com", Hits: 1, Score: 1, LastHitAt: time.Now().Add(-30 * 24 * time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reputation := &memoryReputation{senders: map[entities.Email]entities.SenderReputation{}}
+			if tt.rep != nil {
+				reputation.senders[tt.rep.Sender] = *tt.rep
+			}
+
+			repof := &factory.RepoFactory{Reputation: reputation, Quarantine: &memoryQuarantine{}}
+			if tt.noQuarant {
+				repof.Quarantine = nil
+			}
+
+			chain := heldChain(createTestUser(entities.RegularUser))
+			chain.Quarantined = false
+			chain.OrigFromAddress.Email = "Spam@bulk.com"
+			if tt.webhook {
+				chain.ToAddress.Type = entities.WebhookAddress
+			}
+
+			err := screenSender(context.Background(), repof, tt.policy, &chain)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantListed, chain.Listed)
+			assert.Equal(t, tt.wantListed, chain.Held())
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+type RoleCreateCmd struct {
+	Name        string
+	Description string
+	Permissions []entities.Permission
+}
+
+type RoleUpdateCmd struct {
+	RoleId      entities.Id
+	Name        *string
+	Description *string
+	Permissions *[]entities.Permission
+}
+
+// RolesService represents the use case for role management operations
+type RolesService struct {
+	repof *factory.RepoFactory
+}
+
+// NewRolesService creates a new RolesService instance
+func NewRolesService(repoFactory *factory.RepoFactory) (*RolesService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	return &RolesService{repof: repoFactory}, nil
+}
+
+// GetAll retrieves all roles matching the filter
+func (r *RolesService) GetAll(ctx context.Context, cuser entities.User, filter entities.RoleFilter) ([]entities.Role, entities.PaginationMetadata, error) {
+	if !canGetRoles(cuser) {
+		return nil, entities.PaginationMetadata{}, entities.ErrNotAuthorized
+	}
+
+	return r.repof.Roles.GetAll(ctx, filter)
+}
+
+// GetById retrieves a role by its ID
+func (r *RolesService) GetById(ctx context.Context, cuser entities.User, id entities.Id) (entities.Role, error) {
+	if !canGetRoles(cuser) {
+		return entities.Role{}, entities.ErrNotAuthorized
+	}
+
+	if err := id.Validate(); err != nil {
+		return entities.Role{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	return r.repof.Roles.GetById(ctx, id)
+}
+
+// Permissions returns the list of all permissions which can be granted by roles
+func (r *RolesService) Permissions(ctx context.Context, cuser entities.User) ([]entities.Permission, error) {
+	if !canGetRoles(cuser) {
+		return nil, entities.ErrNotAuthorized
+	}
+
+	return entities.AllPermissions(), nil
+}
+
+// Create creates a new role
+func (r *RolesService) Create(ctx context.Context, cuser entities.User, cmd RoleCreateCmd) (entities.Role, error) {
+	if !canManageRoles(cuser) {
+		return entities.Role{}, entities.ErrNotAuthorized
+	}
+
+	now := time.Now()
+	role := entities.Role{
+		ID:          entities.NewId(),
+		Name:        strings.TrimSpace(cmd.Name),
+		Description: cmd.Description,
+		Permissions: compactPermissions(cmd.Permissions),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		UpdatedBy:   cuser,
+	}
+
+	if err := role.Validate(); err != nil {
+		return entities.Role{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if _, err := r.repof.Roles.GetByName(ctx, role.Name); err == nil {
+		return entities.Role{}, fmt.Errorf("%w: role '%s' already exists", entities.ErrDuplicateEntry, role.Name)
+	}
+
+	if err := r.repof.Roles.Create(ctx, role); err != nil {
+		return entities.Role{}, err
+	}
+
+	return role, nil
+}
+
+// Update updates an existing role
+func (r *RolesService) Update(ctx context.Context, cuser entities.User, cmd RoleUpdateCmd) (entities.Role, error) {
+	if !canManageRoles(cuser) {
+		return entities.Role{}, entities.ErrNotAuthorized
+	}
+
+	role, err := r.repof.Roles.GetById(ctx, cmd.RoleId)
+	if err != nil {
+		return entities.Role{}, err
+	}
+
+	if cmd.Name != nil && strings.TrimSpace(*cmd.Name) != role.Name {
+		name := strings.TrimSpace(*cmd.Name)
+		if _, err := r.repof.Roles.GetByName(ctx, name); err == nil {
+			return entities.Role{}, fmt.Errorf("%w: role '%s' already exists", entities.ErrDuplicateEntry, name)
+		}
+		role.Name = name
+	}
+
+	if cmd.Description != nil {
+		role.Description = *cmd.Description
+	}
+
+	if cmd.Permissions != nil {
+		role.Permissions = compactPermissions(*cmd.Permissions)
+	}
+
+	role.UpdatedBy = cuser
+	role.UpdatedAt = time.Now()
+	if err := role.Validate(); err != nil {
+		return entities.Role{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	return r.repof.Roles.Update(ctx, role)
+}
+
+// Delete removes a role by its ID.
+// Roles still assigned to any user can not be deleted.
+func (r *RolesService) Delete(ctx context.Context, cuser entities.User, id entities.Id) error {
+	if !canManageRoles(cuser) {
+		return entities.ErrNotAuthorized
+	}
+
+	if err := id.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	_, pgm, err := r.repof.Users.GetAll(ctx, entities.UserFilter{
+		Filter:  entities.Filter{Page: 1, PageSize: 1},
+		RoleIds: []entities.Id{id},
+	})
+	if err != nil {
+		return err
+	}
+
+	if pgm.TotalRecords > 0 {
+		return fmt.Errorf("%w: role is assigned to %d user(s)", entities.ErrValidation, pgm.TotalRecords)
+	}
+
+	return r.repof.Roles.Delete(ctx, cuser, id)
+}
+
+// resolveRole fetches the role to be assigned to a user.
+// An empty id means no role, so nil is returned.
+func resolveRole(ctx context.Context, repof *factory.RepoFactory, id entities.Id) (*entities.Role, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	role, err := repof.Roles.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return nil, fmt.Errorf("%w: role '%s' does not exist", entities.ErrValidation, id)
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// compactPermissions returns sorted list of unique permissions
+func compactPermissions(perms []entities.Permission) []entities.Permission {
+	res := slices.Clone(perms)
+	slices.Sort(res)
+	return slices.Compact(res)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+func setupRolesService(t *testing.T) (*RolesService, *MockRolesRepo, *MockUsersRepo) {
+	rolesRepo := new(MockRolesRepo)
+	usersRepo := new(MockUsersRepo)
+
+	repof := &factory.RepoFactory{
+		Roles: rolesRepo,
+		Users: usersRepo,
+	}
+
+	service, err := NewRolesService(repof)
+	require.NoError(t, err)
+
+	return service, rolesRepo, usersRepo
+}
+
+func TestNewRolesService_NilRepoFactory(t *testing.T) {
+	service, err := NewRolesService(nil)
+
+	assert.Nil(t, service)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+}
+
+func TestRolesService_Create_Success(t *testing.T) {
+	service, rolesRepo, _ := setupRolesService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+
+	rolesRepo.On("GetByName", ctx, "auditor").Return(entities.Role{}, entities.ErrNotFound)
+	rolesRepo.On("Create", ctx, mock.AnythingOfType("entities.Role")).Return(nil)
+
+	role, err := service.Create(ctx, admin, RoleCreateCmd{
+		Name:        " auditor ",
+		Description: "read-only access",
+		Permissions: []entities.Permission{entities.PermUsersReadAll, entities.PermAliasesReadAll, entities.PermUsersReadAll},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "auditor", role.Name)
+	assert.Equal(t, []entities.Permission{entities.PermAliasesReadAll, entities.PermUsersReadAll}, role.Permissions)
+	assert.NotEmpty(t, role.ID)
+	rolesRepo.AssertExpectations(t)
+}
+
+func TestRolesService_Create_NotAuthorized(t *testing.T) {
+	service, _, _ := setupRolesService(t)
+
+	_, err := service.Create(context.Background(), createTestUser(entities.RegularUser), RoleCreateCmd{Name: "auditor"})
+
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestRolesService_Create_UnknownPermission(t *testing.T) {
+	service, _, _ := setupRolesService(t)
+
+	_, err := service.Create(context.Background(), createTestUser(entities.AdminUser), RoleCreateCmd{
+		Name:        "auditor",
+		Permissions: []entities.Permission{"everything:all"},
+	})
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestRolesService_Create_Duplicate(t *testing.T) {
+	service, rolesRepo, _ := setupRolesService(t)
+	ctx := context.Background()
+
+	rolesRepo.On("GetByName", ctx, "auditor").Return(entities.Role{ID: entities.NewId(), Name: "auditor"}, nil)
+
+	_, err := service.Create(ctx, createTestUser(entities.AdminUser), RoleCreateCmd{Name: "auditor"})
+
+	assert.ErrorIs(t, err, entities.ErrDuplicateEntry)
+}
+
+func TestRolesService_Update_Success(t *testing.T) {
+	service, rolesRepo, _ := setupRolesService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	existing := entities.Role{ID: entities.NewId(), Name: "auditor"}
+	perms := []entities.Permission{entities.PermRolesRead}
+
+	rolesRepo.On("GetById", ctx, existing.ID).Return(existing, nil)
+	rolesRepo.On("Update", ctx, mock.MatchedBy(func(r entities.Role) bool {
+		return r.UpdatedBy.ID == admin.ID && len(r.Permissions) == 1 && r.Permissions[0] == entities.PermRolesRead
+	})).Return(entities.Role{ID: existing.ID, Name: "auditor", Permissions: perms}, nil)
+
+	role, err := service.Update(ctx, admin, RoleUpdateCmd{RoleId: existing.ID, Permissions: &perms})
+
+	require.NoError(t, err)
+	assert.Equal(t, perms, role.Permissions)
+	rolesRepo.AssertExpectations(t)
+}
+
+func TestRolesService_Delete_Assigned(t *testing.T) {
+	service, _, usersRepo := setupRolesService(t)
+	ctx := context.Background()
+	id := entities.NewId()
+
+	usersRepo.On("GetAll", ctx, mock.MatchedBy(func(f entities.UserFilter) bool {
+		return len(f.RoleIds) == 1 && f.RoleIds[0] == id
+	})).Return([]entities.User{{ID: entities.NewId()}}, entities.PaginationMetadata{TotalRecords: 1}, nil)
+
+	err := service.Delete(ctx, createTestUser(entities.AdminUser), id)
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestRolesService_Delete_Success(t *testing.T) {
+	service, rolesRepo, usersRepo := setupRolesService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	id := entities.NewId()
+
+	usersRepo.On("GetAll", ctx, mock.AnythingOfType("entities.UserFilter")).
+		Return([]entities.User{}, entities.PaginationMetadata{}, nil)
+	rolesRepo.On("Delete", ctx, admin, id).Return(nil)
+
+	assert.NoError(t, service.Delete(ctx, admin, id))
+	rolesRepo.AssertExpectations(t)
+}
+
+func TestRolesService_Permissions(t *testing.T) {
+	service, _, _ := setupRolesService(t)
+
+	perms, err := service.Permissions(context.Background(), createTestUser(entities.AdminUser))
+	require.NoError(t, err)
+	assert.Equal(t, entities.AllPermissions(), perms)
+
+	_, err = service.Permissions(context.Background(), createTestUser(entities.RegularUser))
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+// parseRules validates the rules script of an address of the owner, an empty script removes the rules.
+// The rules can only redirect the mail to the protected addresses of the same owner.
+func parseRules(ctx context.Context, repof *factory.RepoFactory, owner entities.User, script string) (*entities.Rules, error) {
+	if strings.TrimSpace(script) == "" {
+		return nil, nil
+	}
+
+	rules, err := entities.ParseRules(script)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	redirects, err := rules.Redirects()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	for _, email := range redirects {
+		praddr, err := ownedPrAddr(ctx, repof, owner, email)
+		if err != nil {
+			return nil, err
+		}
+
+		if praddr == nil {
+			return nil, fmt.Errorf("%w: rules can only redirect mail to own protected addresses, %s is not one", entities.ErrValidation, email)
+		}
+	}
+
+	return &rules, nil
+}
+
+// chainRedirects returns the protected addresses the rules of the chain can redirect the mail to,
+// the addresses deleted, deactivated or pending since the rules were saved are left out
+func chainRedirects(ctx context.Context, repof *factory.RepoFactory, chain entities.Chain) ([]entities.Address, error) {
+	rules := chain.Rules()
+	if rules == nil {
+		return nil, nil
+	}
+
+	redirects, err := rules.Redirects()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	praddrs := make([]entities.Address, 0, len(redirects))
+	for _, email := range redirects {
+		praddr, err := ownedPrAddr(ctx, repof, chain.OrigToAddress.Owner, email)
+		if err != nil {
+			return nil, err
+		}
+
+		if praddr != nil && forwardable(*praddr) {
+			praddrs = append(praddrs, *praddr)
+		}
+	}
+
+	return praddrs, nil
+}
+
+// ownedPrAddr returns the protected address with the email owned by the owner, nil when there is none
+func ownedPrAddr(ctx context.Context, repof *factory.RepoFactory, owner entities.User, email entities.Email) (*entities.Address, error) {
+	addrs, err := repof.Address.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		if addr.Type == entities.ProtectedAddress && addr.Owner.ID == owner.ID {
+			return &addr, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+const (
+	// DefaultServiceTokenExpireIn is the default validity of service account tokens in days
+	DefaultServiceTokenExpireIn = 365
+	// DefaultServiceTokenOverlap is the default period the previous tokens stay valid after rotation
+	DefaultServiceTokenOverlap = 24 * time.Hour
+)
+
+type ServiceAccountCreateCmd struct {
+	Login       string
+	Description string
+	ExpireIn    int
+}
+
+type ServiceAccountRotateCmd struct {
+	AccountId entities.Id
+	ExpireIn  int
+	// Overlap is the period the currently active tokens stay valid for after the rotation,
+	// zero value revokes them immediately
+	Overlap time.Duration
+}
+
+// ServiceAccountsService represents the use case for managing service accounts
+// used by the milter and socketmap to access the private API.
+type ServiceAccountsService struct {
+	repof *factory.RepoFactory
+}
+
+// NewServiceAccountsService creates a new ServiceAccountsService instance
+func NewServiceAccountsService(repoFactory *factory.RepoFactory) (*ServiceAccountsService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	return &ServiceAccountsService{repof: repoFactory}, nil
+}
+
+// GetAll retrieves all service accounts matching the filter
+func (s *ServiceAccountsService) GetAll(ctx context.Context, cuser entities.User, filter entities.UserFilter) ([]entities.User, entities.PaginationMetadata, error) {
+	if !canGetServiceAccounts(cuser) {
+		return nil, entities.PaginationMetadata{}, entities.ErrNotAuthorized
+	}
+
+	filter.Types = []entities.UserType{entities.MilterUser}
+	return s.repof.Users.GetAll(ctx, filter)
+}
+
+// Create creates a new service account along with its first API token.
+// The clear text token value is only available in the returned token.
+func (s *ServiceAccountsService) Create(ctx context.Context, cuser entities.User, cmd ServiceAccountCreateCmd) (entities.User, entities.ApiToken, error) {
+	if !canManageServiceAccounts(cuser) {
+		return entities.User{}, entities.ApiToken{}, entities.ErrNotAuthorized
+	}
+
+	if cmd.ExpireIn == 0 {
+		cmd.ExpireIn = DefaultServiceTokenExpireIn
+	}
+
+	if cmd.ExpireIn < 1 {
+		return entities.User{}, entities.ApiToken{}, fmt.Errorf("%w: expire_in value cannot be less than 1", entities.ErrValidation)
+	}
+
+	account := entities.User{
+		ID:        entities.NewId(),
+		Type:      entities.MilterUser,
+		Login:     strings.TrimSpace(cmd.Login),
+		FirstName: strings.TrimSpace(cmd.Description),
+		Active:    true,
+		UpdatedBy: &cuser,
+		CreatedBy: &cuser,
+	}
+
+	if err := account.Validate(); err != nil {
+		return entities.User{}, entities.ApiToken{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if _, err := s.repof.Users.GetByLogin(ctx, account.Login); err == nil {
+		return entities.User{}, entities.ApiToken{}, fmt.Errorf("%w: user '%s' already exists", entities.ErrDuplicateEntry, account.Login)
+	}
+
+	token, err := newServiceToken(cuser, account, cmd.ExpireIn)
+	if err != nil {
+		return entities.User{}, entities.ApiToken{}, err
+	}
+
+	if err := s.repof.Users.Create(ctx, account); err != nil {
+		return entities.User{}, entities.ApiToken{}, err
+	}
+
+	if err := s.repof.ApiTokens.Create(ctx, token); err != nil {
+		return entities.User{}, entities.ApiToken{}, err
+	}
+
+	return account, token, nil
+}
+
+// RotateToken issues a new API token for the service account and shortens the validity
+// of its currently active tokens to the overlap period, so the clients have time
+// to pick up the new token before the old ones expire.
+func (s *ServiceAccountsService) RotateToken(ctx context.Context, cuser entities.User, cmd ServiceAccountRotateCmd) (entities.ApiToken, error) {
+	if !canManageServiceAccounts(cuser) {
+		return entities.ApiToken{}, entities.ErrNotAuthorized
+	}
+
+	if cmd.ExpireIn == 0 {
+		cmd.ExpireIn = DefaultServiceTokenExpireIn
+	}
+
+	if cmd.ExpireIn < 1 {
+		return entities.ApiToken{}, fmt.Errorf("%w: expire_in value cannot be less than 1", entities.ErrValidation)
+	}
+
+	if cmd.Overlap < 0 {
+		return entities.ApiToken{}, fmt.Errorf("%w: overlap value cannot be negative", entities.ErrValidation)
+	}
+
+	account, err := s.repof.Users.GetById(ctx, cmd.AccountId)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return entities.ApiToken{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+
+		return entities.ApiToken{}, err
+	}
+
+	if !account.IsServiceAccount() {
+		return entities.ApiToken{}, fmt.Errorf("%w: user '%s' is not a service account", entities.ErrValidation, account.Login)
+	}
+
+	active := true
+	tokens, err := s.repof.ApiTokens.GetAll(ctx, entities.ApiTokenFilter{UserIds: []entities.Id{account.ID}, Active: &active})
+	if err != nil {
+		return entities.ApiToken{}, err
+	}
+
+	token, err := newServiceToken(cuser, account, cmd.ExpireIn)
+	if err != nil {
+		return entities.ApiToken{}, err
+	}
+
+	if err := s.repof.ApiTokens.Create(ctx, token); err != nil {
+		return entities.ApiToken{}, err
+	}
+
+	// previous tokens remain valid for the overlap period only
+	overlapEnd := time.Now().Add(cmd.Overlap)
+	for _, old := range tokens {
+		if old.Expired() || old.Expiration.Before(overlapEnd) {
+			continue
+		}
+
+		old.Expiration = overlapEnd
+		if cmd.Overlap == 0 {
+			old.Active = false
+		}
+		old.UpdatedBy = cuser
+		if _, err := s.repof.ApiTokens.Update(ctx, old); err != nil {
+			return entities.ApiToken{}, fmt.Errorf("shortening validity of token '%s': %w", old.ID, err)
+		}
+	}
+
+	return token, nil
+}
+
+// newServiceToken generates a new API token for the service account
+func newServiceToken(cuser, account entities.User, expireIn int) (entities.ApiToken, error) {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s", account.Login, now.UTC().Format("20060102150405"))
+	token, err := entities.NewToken(now.Add(time.Duration(expireIn*24)*time.Hour), name, "service account token", account)
+	if err != nil {
+		return entities.ApiToken{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	token.UpdatedBy = cuser
+	if err := token.Validate(); err != nil {
+		return entities.ApiToken{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	return *token, nil
+}
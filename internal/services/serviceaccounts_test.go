@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+func setupServiceAccountsService(t *testing.T) (*ServiceAccountsService, *MockUsersRepo, *MockApiTokensRepo) {
+	usersRepo := new(MockUsersRepo)
+	tokensRepo := new(MockApiTokensRepo)
+
+	repof := &factory.RepoFactory{
+		Users:     usersRepo,
+		ApiTokens: tokensRepo,
+	}
+
+	service, err := NewServiceAccountsService(repof)
+	require.NoError(t, err)
+
+	return service, usersRepo, tokensRepo
+}
+
+func TestNewServiceAccountsService_NilRepoFactory(t *testing.T) {
+	service, err := NewServiceAccountsService(nil)
+
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+	assert.Nil(t, service)
+}
+
+func TestServiceAccountsService_GetAll_ForcesType(t *testing.T) {
+	service, usersRepo, _ := setupServiceAccountsService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+
+	usersRepo.On("GetAll", ctx, mock.MatchedBy(func(f entities.UserFilter) bool {
+		return len(f.Types) == 1 && f.Types[0] == entities.MilterUser
+	})).Return([]entities.User{}, entities.PaginationMetadata{}, nil)
+
+	_, _, err := service.GetAll(ctx, admin, entities.UserFilter{Types: []entities.UserType{entities.AdminUser}})
+
+	assert.NoError(t, err)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestServiceAccountsService_Create_Success(t *testing.T) {
+	service, usersRepo, tokensRepo := setupServiceAccountsService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+
+	usersRepo.On("GetByLogin", ctx, "milter").Return(entities.User{}, entities.ErrNotFound)
+	usersRepo.On("Create", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return u.Type == entities.MilterUser && u.Login == "milter" && u.PasswordHash == ""
+	})).Return(nil)
+	tokensRepo.On("Create", ctx, mock.AnythingOfType("entities.ApiToken")).Return(nil)
+
+	account, token, err := service.Create(ctx, admin, ServiceAccountCreateCmd{Login: " milter ", Description: "Milter"})
+
+	require.NoError(t, err)
+	assert.True(t, account.IsServiceAccount())
+	assert.Equal(t, account.ID, token.Owner.ID)
+	assert.NotEmpty(t, token.Token)
+	assert.WithinDuration(t, time.Now().Add(DefaultServiceTokenExpireIn*24*time.Hour), token.Expiration, time.Minute)
+	usersRepo.AssertExpectations(t)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestServiceAccountsService_Create_Duplicate(t *testing.T) {
+	service, usersRepo, _ := setupServiceAccountsService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+
+	usersRepo.On("GetByLogin", ctx, "milter").Return(entities.User{Login: "milter"}, nil)
+
+	_, _, err := service.Create(ctx, admin, ServiceAccountCreateCmd{Login: "milter"})
+
+	assert.ErrorIs(t, err, entities.ErrDuplicateEntry)
+}
+
+func TestServiceAccountsService_Create_NotAuthorized(t *testing.T) {
+	service, _, _ := setupServiceAccountsService(t)
+	ctx := context.Background()
+
+	for _, utype := range []entities.UserType{entities.RegularUser, entities.MilterUser} {
+		_, _, err := service.Create(ctx, createTestUser(utype), ServiceAccountCreateCmd{Login: "milter"})
+		assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+	}
+}
+
+func TestServiceAccountsService_RotateToken_Overlap(t *testing.T) {
+	service, usersRepo, tokensRepo := setupServiceAccountsService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	account := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter", Active: true}
+
+	current := entities.ApiToken{ID: entities.NewId(), Owner: account, Active: true, Expiration: time.Now().Add(30 * 24 * time.Hour)}
+	expiring := entities.ApiToken{ID: entities.NewId(), Owner: account, Active: true, Expiration: time.Now().Add(10 * time.Minute)}
+
+	usersRepo.On("GetById", ctx, account.ID).Return(account, nil)
+	tokensRepo.On("GetAll", ctx, mock.MatchedBy(func(f entities.ApiTokenFilter) bool {
+		return len(f.UserIds) == 1 && f.UserIds[0] == account.ID && f.Active != nil && *f.Active
+	})).Return([]entities.ApiToken{current, expiring}, nil)
+	tokensRepo.On("Create", ctx, mock.AnythingOfType("entities.ApiToken")).Return(nil)
+	// only the token outliving the overlap period gets shortened
+	tokensRepo.On("Update", ctx, mock.MatchedBy(func(tok entities.ApiToken) bool {
+		return tok.ID == current.ID && tok.Active &&
+			tok.Expiration.Before(time.Now().Add(time.Hour+time.Minute)) &&
+			tok.Expiration.After(time.Now().Add(time.Hour-time.Minute))
+	})).Return(current, nil).Once()
+
+	token, err := service.RotateToken(ctx, admin, ServiceAccountRotateCmd{AccountId: account.ID, Overlap: time.Hour})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.Token)
+	assert.Equal(t, account.ID, token.Owner.ID)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestServiceAccountsService_RotateToken_NoOverlap(t *testing.T) {
+	service, usersRepo, tokensRepo := setupServiceAccountsService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	account := entities.User{ID: entities.NewId(), Type: entities.MilterUser, Login: "milter", Active: true}
+	current := entities.ApiToken{ID: entities.NewId(), Owner: account, Active: true, Expiration: time.Now().Add(time.Hour)}
+
+	usersRepo.On("GetById", ctx, account.ID).Return(account, nil)
+	tokensRepo.On("GetAll", ctx, mock.Anything).Return([]entities.ApiToken{current}, nil)
+	tokensRepo.On("Create", ctx, mock.AnythingOfType("entities.ApiToken")).Return(nil)
+	tokensRepo.On("Update", ctx, mock.MatchedBy(func(tok entities.ApiToken) bool {
+		return tok.ID == current.ID && !tok.Active
+	})).Return(current, nil).Once()
+
+	_, err := service.RotateToken(ctx, admin, ServiceAccountRotateCmd{AccountId: account.ID})
+
+	require.NoError(t, err)
+	tokensRepo.AssertExpectations(t)
+}
+
+func TestServiceAccountsService_RotateToken_NotServiceAccount(t *testing.T) {
+	service, usersRepo, _ := setupServiceAccountsService(t)
+	ctx := context.Background()
+	admin := createTestUser(entities.AdminUser)
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user"}
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	_, err := service.RotateToken(ctx, admin, ServiceAccountRotateCmd{AccountId: user.ID})
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
+
+func TestServiceAccountsService_RotateToken_NegativeOverlap(t *testing.T) {
+	service, _, _ := setupServiceAccountsService(t)
+
+	_, err := service.RotateToken(context.Background(), createTestUser(entities.AdminUser),
+		ServiceAccountRotateCmd{AccountId: entities.NewId(), Overlap: -time.Second})
+
+	assert.ErrorIs(t, err, entities.ErrValidation)
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+const (
+	// DefaultSessionIdleTimeout is the default period after which an unused session expires
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	// DefaultSessionAbsoluteTimeout is the default period after which a session expires regardless of use
+	DefaultSessionAbsoluteTimeout = 12 * time.Hour
+	// sessionTouchInterval limits how often the last use of a session is written to the cache
+	sessionTouchInterval = time.Minute
+	sessionKeyPrefix     = "session:"
+)
+
+type SessionCreateCmd struct {
+	RemoteAddr string
+	UserAgent  string
+}
+
+// SessionsService represents the use case for managing server-side sessions
+// of users who logged in with a password.
+//
+// Sessions are stored in the shared cache under "session:<user id>:<session id>" keys,
+// so all sessions of a user can be listed or dropped by the key prefix.
+type SessionsService struct {
+	repof           *factory.RepoFactory
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+// NewSessionsService creates a new SessionsService instance
+func NewSessionsService(repoFactory *factory.RepoFactory, cfg config.ConfigSessions) (*SessionsService, error) {
+	if repoFactory == nil {
+		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
+	}
+
+	if repoFactory.Cache == nil {
+		return nil, fmt.Errorf("%w: cache should be defined for sessions", entities.ErrConfiguration)
+	}
+
+	if cfg.IdleTimeout < 0 || cfg.AbsoluteTimeout < 0 {
+		return nil, fmt.Errorf("%w: session timeouts can not be negative", entities.ErrConfiguration)
+	}
+
+	s := &SessionsService{
+		repof:           repoFactory,
+		idleTimeout:     DefaultSessionIdleTimeout,
+		absoluteTimeout: DefaultSessionAbsoluteTimeout,
+	}
+
+	if cfg.IdleTimeout > 0 {
+		s.idleTimeout = time.Duration(cfg.IdleTimeout) * time.Second
+	}
+
+	if cfg.AbsoluteTimeout > 0 {
+		s.absoluteTimeout = time.Duration(cfg.AbsoluteTimeout) * time.Second
+	}
+
+	if s.idleTimeout > s.absoluteTimeout {
+		return nil, fmt.Errorf("%w: session idle_timeout can not exceed absolute_timeout", entities.ErrConfiguration)
+	}
+
+	return s, nil
+}
+
+// Create starts a new session for the user who passed the password (and second factor) check.
+// It returns the session and the opaque value to be set as the session cookie.
+func (s *SessionsService) Create(ctx context.Context, user entities.User, cmd SessionCreateCmd) (entities.Session, string, error) {
+	if !user.Active || user.IsServiceAccount() {
+		return entities.Session{}, "", entities.ErrNotAuthorized
+	}
+
+	session, value, err := entities.NewSession(user.ID, time.Now().Add(s.absoluteTimeout))
+	if err != nil {
+		return entities.Session{}, "", fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+	session.RemoteAddr = cmd.RemoteAddr
+	session.UserAgent = cmd.UserAgent
+
+	if err := s.store(ctx, session); err != nil {
+		return entities.Session{}, "", err
+	}
+
+	return session, value, nil
+}
+
+// Validate resolves the session cookie value to the session owner.
+// The last use of the session is updated, so the idle timeout starts over.
+func (s *SessionsService) Validate(ctx context.Context, value string) (entities.User, entities.Session, error) {
+	userId, id, err := entities.ParseSessionValue(value)
+	if err != nil {
+		return entities.User{}, entities.Session{}, fmt.Errorf("%w: %w", entities.ErrNotAuthorized, err)
+	}
+
+	session, err := s.get(ctx, userId, id)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return entities.User{}, entities.Session{}, fmt.Errorf("%w: unknown or expired session", entities.ErrNotAuthorized)
+		}
+		return entities.User{}, entities.Session{}, err
+	}
+
+	now := time.Now()
+	if session.Expired(s.idleTimeout, now) {
+		_ = s.repof.Cache.Delete(ctx, sessionKey(userId, id))
+		return entities.User{}, entities.Session{}, fmt.Errorf("%w: unknown or expired session", entities.ErrNotAuthorized)
+	}
+
+	user, err := s.repof.Users.GetById(ctx, userId)
+	if err != nil {
+		return entities.User{}, entities.Session{}, err
+	}
+
+	if !user.Active {
+		if err := revokeSessionsForUser(ctx, s.repof, user.ID); err != nil {
+			return entities.User{}, entities.Session{}, err
+		}
+		return entities.User{}, entities.Session{}, fmt.Errorf("%w: inactive user", entities.ErrNotAuthorized)
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		session.LastSeenAt = now
+		if err := s.store(ctx, session); err != nil {
+			return entities.User{}, entities.Session{}, err
+		}
+	}
+
+	return user, session, nil
+}
+
+// GetAll returns active sessions of the current user, the oldest first.
+func (s *SessionsService) GetAll(ctx context.Context, cuser entities.User) ([]entities.Session, error) {
+	if !canManageSessions(cuser) {
+		return nil, entities.ErrNotAuthorized
+	}
+
+	prefix := sessionUserPrefix(cuser.ID)
+	keys, err := s.repof.Cache.KeysByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	now := time.Now()
+	sessions := make([]entities.Session, 0, len(keys))
+	for _, key := range keys {
+		session, err := s.get(ctx, cuser.ID, entities.Hash(strings.TrimPrefix(key, prefix)))
+		if err != nil {
+			if errors.Is(err, entities.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		if !session.Expired(s.idleTimeout, now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b entities.Session) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return sessions, nil
+}
+
+// Delete revokes the session of the current user.
+func (s *SessionsService) Delete(ctx context.Context, cuser entities.User, id entities.Hash) error {
+	if !canManageSessions(cuser) {
+		return entities.ErrNotAuthorized
+	}
+
+	if err := id.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrValidation, err)
+	}
+
+	if err := s.repof.Cache.Delete(ctx, sessionKey(cuser.ID, id)); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return fmt.Errorf("%w: session '%s'", entities.ErrNotFound, id)
+		}
+		return fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	return nil
+}
+
+// Revoke ends the session identified by the session cookie value, used on logout.
+// Unknown or already expired sessions are ignored.
+func (s *SessionsService) Revoke(ctx context.Context, value string) error {
+	userId, id, err := entities.ParseSessionValue(value)
+	if err != nil {
+		return nil
+	}
+
+	if err := s.repof.Cache.Delete(ctx, sessionKey(userId, id)); err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	return nil
+}
+
+// IdleTimeout returns the period after which an unused session expires.
+func (s *SessionsService) IdleTimeout() time.Duration {
+	return s.idleTimeout
+}
+
+// AbsoluteTimeout returns the period after which a session expires regardless of use.
+func (s *SessionsService) AbsoluteTimeout() time.Duration {
+	return s.absoluteTimeout
+}
+
+// store writes the session to the cache, the entry expires together with the session.
+func (s *SessionsService) store(ctx context.Context, session entities.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	ttl := min(time.Until(session.LastSeenAt.Add(s.idleTimeout)), time.Until(session.ExpiresAt))
+	if err := s.repof.Cache.Set(ctx, sessionKey(session.UserID, session.ID), data, ttl); err != nil {
+		return fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	return nil
+}
+
+func (s *SessionsService) get(ctx context.Context, userId entities.Id, id entities.Hash) (entities.Session, error) {
+	data, err := s.repof.Cache.Get(ctx, sessionKey(userId, id))
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			return entities.Session{}, err
+		}
+		return entities.Session{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	session := entities.Session{}
+	if err := json.Unmarshal(data, &session); err != nil {
+		return entities.Session{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
+	return session, nil
+}
+
+func sessionUserPrefix(userId entities.Id) string {
+	return sessionKeyPrefix + userId.String() + ":"
+}
+
+func sessionKey(userId entities.Id, id entities.Hash) string {
+	return sessionUserPrefix(userId) + id.String()
+}
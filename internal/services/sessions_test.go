@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/Burmuley/ovoo/internal/repositories/factory"
+)
+
+func setupSessionsService(t *testing.T, cfg config.ConfigSessions) (*SessionsService, *MockUsersRepo, *memory.MemoryCache) {
+	usersRepo := new(MockUsersRepo)
+	cache, err := memory.New()
+	require.NoError(t, err)
+
+	repof := &factory.RepoFactory{
+		Users: usersRepo,
+		Cache: cache,
+	}
+
+	service, err := NewSessionsService(repof, cfg)
+	require.NoError(t, err)
+
+	return service, usersRepo, cache
+}
+
+func TestNewSessionsService_Validation(t *testing.T) {
+	_, err := NewSessionsService(nil, config.ConfigSessions{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	_, err = NewSessionsService(&factory.RepoFactory{}, config.ConfigSessions{})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	cache, _ := memory.New()
+	_, err = NewSessionsService(&factory.RepoFactory{Cache: cache}, config.ConfigSessions{IdleTimeout: 7200, AbsoluteTimeout: 3600})
+	assert.ErrorIs(t, err, entities.ErrConfiguration)
+
+	service, err := NewSessionsService(&factory.RepoFactory{Cache: cache}, config.ConfigSessions{IdleTimeout: 600})
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, service.IdleTimeout())
+	assert.Equal(t, DefaultSessionAbsoluteTimeout, service.AbsoluteTimeout())
+}
+
+func TestSessionsService_CreateAndValidate(t *testing.T) {
+	service, usersRepo, _ := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+
+	session, value, err := service.Create(ctx, user, SessionCreateCmd{RemoteAddr: "192.0.2.1:1234", UserAgent: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, session.UserID)
+	assert.WithinDuration(t, time.Now().Add(DefaultSessionAbsoluteTimeout), session.ExpiresAt, time.Minute)
+
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+
+	got, gotSession, err := service.Validate(ctx, value)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	assert.Equal(t, session.ID, gotSession.ID)
+	assert.Equal(t, "test", gotSession.UserAgent)
+
+	_, _, err = service.Validate(ctx, string(user.ID)+".forged")
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestSessionsService_Create_NotAuthorized(t *testing.T) {
+	service, _, _ := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+
+	_, _, err := service.Create(ctx, createTestUser(entities.RegularUser), SessionCreateCmd{})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	svc := createTestUser(entities.MilterUser)
+	svc.Active = true
+	_, _, err = service.Create(ctx, svc, SessionCreateCmd{})
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestSessionsService_Validate_IdleExpired(t *testing.T) {
+	service, usersRepo, cache := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+
+	session, value, err := service.Create(ctx, user, SessionCreateCmd{})
+	require.NoError(t, err)
+
+	// simulate a session that was not used for longer than the idle timeout,
+	// but is still present in the cache
+	session.LastSeenAt = time.Now().Add(-2 * DefaultSessionIdleTimeout)
+	data, _ := json.Marshal(session)
+	require.NoError(t, cache.Set(ctx, sessionKey(user.ID, session.ID), data, time.Hour))
+
+	_, _, err = service.Validate(ctx, value)
+
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+	usersRepo.AssertNotCalled(t, "GetById")
+}
+
+func TestSessionsService_Validate_InactiveUser(t *testing.T) {
+	service, usersRepo, _ := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+
+	_, value, err := service.Create(ctx, user, SessionCreateCmd{})
+	require.NoError(t, err)
+	_, other, err := service.Create(ctx, user, SessionCreateCmd{})
+	require.NoError(t, err)
+
+	inactive := user
+	inactive.Active = false
+	usersRepo.On("GetById", ctx, user.ID).Return(inactive, nil).Once()
+
+	_, _, err = service.Validate(ctx, value)
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+
+	// all sessions of the user are revoked
+	_, _, err = service.Validate(ctx, other)
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestSessionsService_GetAllAndDelete(t *testing.T) {
+	service, usersRepo, _ := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+
+	first, value, err := service.Create(ctx, user, SessionCreateCmd{})
+	require.NoError(t, err)
+	second, _, err := service.Create(ctx, user, SessionCreateCmd{})
+	require.NoError(t, err)
+
+	sessions, err := service.GetAll(ctx, user)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, first.ID, sessions[0].ID)
+	assert.Equal(t, second.ID, sessions[1].ID)
+
+	// sessions of other users are not visible
+	other := createTestUser(entities.RegularUser)
+	sessions, err = service.GetAll(ctx, other)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	assert.ErrorIs(t, service.Delete(ctx, other, first.ID), entities.ErrNotFound)
+
+	require.NoError(t, service.Delete(ctx, user, first.ID))
+	assert.ErrorIs(t, service.Delete(ctx, user, first.ID), entities.ErrNotFound)
+
+	sessions, err = service.GetAll(ctx, user)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, second.ID, sessions[0].ID)
+
+	_, _, err = service.Validate(ctx, value)
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+	usersRepo.AssertNotCalled(t, "GetById")
+}
+
+func TestSessionsService_Revoke(t *testing.T) {
+	service, _, _ := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+
+	_, value, err := service.Create(ctx, user, SessionCreateCmd{})
+	require.NoError(t, err)
+
+	require.NoError(t, service.Revoke(ctx, value))
+	// revoking unknown or malformed sessions is not an error
+	require.NoError(t, service.Revoke(ctx, value))
+	require.NoError(t, service.Revoke(ctx, "garbage"))
+
+	_, _, err = service.Validate(ctx, value)
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestSessionsService_NotAuthorized(t *testing.T) {
+	service, _, _ := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+	svc := createTestUser(entities.MilterUser)
+
+	_, err := service.GetAll(ctx, svc)
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+	assert.ErrorIs(t, service.Delete(ctx, svc, entities.SimpleHash("x")), entities.ErrNotAuthorized)
+}
+
+func TestRevokeSessionsForUser(t *testing.T) {
+	service, _, cache := setupSessionsService(t, config.ConfigSessions{})
+	ctx := context.Background()
+	user := createTestUser(entities.RegularUser)
+	user.Active = true
+
+	// no sessions is not an error
+	require.NoError(t, revokeSessionsForUser(ctx, service.repof, user.ID))
+
+	session, _, err := service.Create(ctx, user, SessionCreateCmd{})
+	require.NoError(t, err)
+
+	require.NoError(t, revokeSessionsForUser(ctx, service.repof, user.ID))
+
+	_, err = cache.Get(ctx, sessionKey(user.ID, session.ID))
+	assert.ErrorIs(t, err, entities.ErrNotFound)
+	sessions, err := service.GetAll(ctx, user)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
@@ -16,6 +16,7 @@ type UserCreateCmd struct {
 	Login     string
 	Password  *string
 	Type      entities.UserType
+	RoleId    *entities.Id
 }
 
 type UserUpdateCmd struct {
@@ -24,19 +25,35 @@ type UserUpdateCmd struct {
 	LastName  *string
 	Type      *entities.UserType
 	Active    *bool
+	RoleId    *entities.Id
+	// PrivacyFilter sets the default of the user aliases
+	PrivacyFilter *bool
+}
+
+// UserProvisionCmd describes the user authenticated by an external identity provider.
+type UserProvisionCmd struct {
+	Login     string
+	FirstName string
+	LastName  string
+	Type      entities.UserType
+	// AllowCreate permits creating the user when it does not exist yet
+	AllowCreate bool
+	// SyncType updates the type of the existing user to Type
+	SyncType bool
 }
 
 // UsersService represents the use case for user operations
 type UsersService struct {
-	repof *factory.RepoFactory
+	repof  *factory.RepoFactory
+	policy entities.PasswordPolicy
 }
 
-// NewUsersService creates a new UsersUsecase instance
-func NewUsersService(repoFactory *factory.RepoFactory) (*UsersService, error) {
+// NewUsersService creates a new UsersUsecase instance, passwords of new users should meet the policy
+func NewUsersService(repoFactory *factory.RepoFactory, policy entities.PasswordPolicy) (*UsersService, error) {
 	if repoFactory == nil {
 		return nil, fmt.Errorf("%w: repository fabric should be defined", entities.ErrConfiguration)
 	}
-	return &UsersService{repof: repoFactory}, nil
+	return &UsersService{repof: repoFactory, policy: policy}, nil
 }
 
 // Create creates a new user
@@ -53,6 +70,17 @@ func (u *UsersService) Create(ctx context.Context, cuser entities.User, cmd User
 		Active:    true,
 	}
 
+	if cmd.RoleId != nil {
+		if !canManageRoles(cuser) {
+			return entities.User{}, entities.ErrNotAuthorized
+		}
+
+		var err error
+		if user.Role, err = resolveRole(ctx, u.repof, *cmd.RoleId); err != nil {
+			return entities.User{}, err
+		}
+	}
+
 	user.ID = entities.NewId()
 	if err := user.Validate(); err != nil {
 		return entities.User{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
@@ -61,9 +89,9 @@ func (u *UsersService) Create(ctx context.Context, cuser entities.User, cmd User
 	{
 		if cmd.Password != nil {
 			var err error
-			user.PasswordHash, err = entities.NewPasswordHash(user.PasswordHash)
+			user.PasswordHash, err = u.policy.Hash(*cmd.Password)
 			if err != nil {
-				return entities.User{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+				return entities.User{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
 			}
 		}
 	}
@@ -106,6 +134,78 @@ func (u *UsersService) CreatePriv(ctx context.Context, user entities.User) (enti
 	return user, nil
 }
 
+// Provision returns the user authenticated by an external identity provider, creating
+// it on the first login when allowed. First and last names of the existing user are
+// synchronized with the identity provider when they are provided.
+// Should only be used in middleware, the identity provider is trusted for the login.
+func (u *UsersService) Provision(ctx context.Context, cmd UserProvisionCmd) (entities.User, error) {
+	user, err := u.repof.Users.GetByLogin(ctx, cmd.Login)
+	if err != nil {
+		if !errors.Is(err, entities.ErrNotFound) {
+			return entities.User{}, err
+		}
+
+		if !cmd.AllowCreate {
+			return entities.User{}, fmt.Errorf("%w: sign-up is not allowed for '%s'", entities.ErrNotAuthorized, cmd.Login)
+		}
+
+		if cmd.Type == entities.MilterUser {
+			return entities.User{}, fmt.Errorf("%w: service accounts can not be provisioned", entities.ErrValidation)
+		}
+
+		user = entities.User{
+			ID:        entities.NewId(),
+			Login:     cmd.Login,
+			FirstName: cmd.FirstName,
+			LastName:  cmd.LastName,
+			Type:      cmd.Type,
+			Active:    true,
+		}
+
+		if err := user.Validate(); err != nil {
+			return entities.User{}, fmt.Errorf("%w: %w", entities.ErrValidation, err)
+		}
+
+		if err := u.repof.Users.Create(ctx, user); err != nil {
+			return entities.User{}, err
+		}
+
+		return user, nil
+	}
+
+	if user.IsServiceAccount() {
+		return entities.User{}, fmt.Errorf("%w: service accounts can only authenticate with api tokens", entities.ErrNotAuthorized)
+	}
+
+	updated := false
+	if cmd.FirstName != "" && cmd.FirstName != user.FirstName {
+		user.FirstName = cmd.FirstName
+		updated = true
+	}
+
+	if cmd.LastName != "" && cmd.LastName != user.LastName {
+		user.LastName = cmd.LastName
+		updated = true
+	}
+
+	if cmd.SyncType && cmd.Type != entities.MilterUser && cmd.Type != user.Type {
+		user.Type = cmd.Type
+		updated = true
+	}
+
+	if updated {
+		// the user updates own profile on login, the copy avoids a reference cycle
+		self := user
+		self.UpdatedBy = nil
+		user.UpdatedBy = &self
+		if err := u.repof.Users.Update(ctx, user); err != nil {
+			return entities.User{}, err
+		}
+	}
+
+	return user, nil
+}
+
 // Update updates an existing user
 func (u *UsersService) Update(ctx context.Context, cuser entities.User, cmd UserUpdateCmd) (entities.User, error) {
 	user, err := u.repof.Users.GetById(ctx, cmd.UserID)
@@ -134,6 +234,21 @@ func (u *UsersService) Update(ctx context.Context, cuser entities.User, cmd User
 		user.Type = *cmd.Type
 	}
 
+	if cmd.PrivacyFilter != nil {
+		user.PrivacyFilter = *cmd.PrivacyFilter
+	}
+
+	// empty role id unassigns the role from the user
+	if cmd.RoleId != nil {
+		if !canManageRoles(cuser) {
+			return entities.User{}, entities.ErrNotAuthorized
+		}
+
+		if user.Role, err = resolveRole(ctx, u.repof, *cmd.RoleId); err != nil {
+			return entities.User{}, err
+		}
+	}
+
 	if cmd.Active != nil {
 		if canSetActiveUser(user, cuser) {
 			if *cmd.Active && !user.Active {
@@ -146,6 +261,10 @@ func (u *UsersService) Update(ctx context.Context, cuser entities.User, cmd User
 				if err := deactivateTokensForUser(ctx, u.repof, cuser, user.ID); err != nil {
 					return entities.User{}, fmt.Errorf("%w: %w", entities.ErrDatabase, err)
 				}
+
+				if err := revokeSessionsForUser(ctx, u.repof, user.ID); err != nil {
+					return entities.User{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+				}
 				user.Active = *cmd.Active
 			}
 		} else {
@@ -184,6 +303,11 @@ func (u *UsersService) Delete(ctx context.Context, cuser entities.User, id entit
 		return entities.User{}, err
 	}
 
+	// delete webhook addresses and the aliases delivering to them
+	if err := deleteWebhooksForUser(ctx, u.repof, cuser, user.ID); err != nil {
+		return entities.User{}, err
+	}
+
 	// delete API tokens
 	if err := u.repof.ApiTokens.BatchDeleteForUser(ctx, cuser, user.ID); err != nil {
 		return entities.User{}, err
@@ -194,6 +318,11 @@ func (u *UsersService) Delete(ctx context.Context, cuser entities.User, id entit
 		return entities.User{}, err
 	}
 
+	// end user sessions
+	if err := revokeSessionsForUser(ctx, u.repof, user.ID); err != nil {
+		return entities.User{}, fmt.Errorf("%w: %w", entities.ErrGeneral, err)
+	}
+
 	return user, nil
 }
 
@@ -245,7 +374,7 @@ func (u *UsersService) GetByLogin(ctx context.Context, login string) (entities.U
 
 // GetAll retrieves all users
 func (u *UsersService) GetAll(ctx context.Context, cuser entities.User, filter entities.UserFilter) ([]entities.User, entities.PaginationMetadata, error) {
-	if cuser.Type != entities.AdminUser {
+	if !cuser.HasPermission(entities.PermUsersReadAll) {
 		var err error
 		if filter, err = entities.NewUserFilter(map[string][]string{
 			"page":      {strconv.Itoa(filter.Page)},
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/Burmuley/ovoo/internal/cache/drivers/memory"
 	"github.com/Burmuley/ovoo/internal/entities"
 	"github.com/Burmuley/ovoo/internal/repositories/factory"
 )
@@ -17,15 +18,17 @@ func setupUsersService(t *testing.T) (*UsersService, *MockUsersRepo, *MockAddres
 	addressRepo := new(MockAddressRepo)
 	tokensRepo := new(MockApiTokensRepo)
 	chainRepo := new(MockChainRepo)
+	cache, _ := memory.New()
 
 	repof := &factory.RepoFactory{
 		Users:     usersRepo,
 		Address:   addressRepo,
 		ApiTokens: tokensRepo,
 		Chain:     chainRepo,
+		Cache:     cache,
 	}
 
-	service, err := NewUsersService(repof)
+	service, err := NewUsersService(repof, entities.PasswordPolicy{})
 	require.NoError(t, err)
 
 	return service, usersRepo, addressRepo, tokensRepo, chainRepo
@@ -33,14 +36,14 @@ func setupUsersService(t *testing.T) (*UsersService, *MockUsersRepo, *MockAddres
 
 func TestNewUsersService(t *testing.T) {
 	repof := &factory.RepoFactory{}
-	service, err := NewUsersService(repof)
+	service, err := NewUsersService(repof, entities.PasswordPolicy{})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, service)
 }
 
 func TestNewUsersService_NilRepoFactory(t *testing.T) {
-	service, err := NewUsersService(nil)
+	service, err := NewUsersService(nil, entities.PasswordPolicy{})
 
 	assert.Error(t, err)
 	assert.Nil(t, service)
@@ -77,6 +80,31 @@ func TestUsersService_Create_Success(t *testing.T) {
 	usersRepo.AssertExpectations(t)
 }
 
+func TestUsersService_Create_Password(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+	adminUser := createTestUser(entities.AdminUser)
+
+	cmd := UserCreateCmd{
+		FirstName: "John",
+		LastName:  "Doe",
+		Login:     "john@test.com",
+		Type:      entities.RegularUser,
+		Password:  new("correct horse battery"),
+	}
+
+	usersRepo.On("Create", ctx, mock.AnythingOfType("entities.User")).Return(nil)
+
+	user, err := service.Create(ctx, adminUser, cmd)
+	require.NoError(t, err)
+	assert.True(t, entities.ValidPassword("correct horse battery", user.PasswordHash))
+
+	cmd.Password = new("short")
+	_, err = service.Create(ctx, adminUser, cmd)
+	assert.ErrorIs(t, err, entities.ErrValidation)
+	usersRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
 func TestUsersService_Create_NotAuthorized(t *testing.T) {
 	service, _, _, _, _ := setupUsersService(t)
 	ctx := context.Background()
@@ -772,3 +800,125 @@ func TestUsersService_Update_SetActive_AdminSelf(t *testing.T) {
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
 }
+
+func TestUsersService_Provision_CreatesUser(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+
+	usersRepo.On("GetByLogin", ctx, "new@example.com").Return(entities.User{}, entities.ErrNotFound)
+	usersRepo.On("Create", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return u.Login == "new@example.com" && u.FirstName == "New" && u.Type == entities.AdminUser &&
+			u.Active && u.PasswordHash == ""
+	})).Return(nil)
+
+	user, err := service.Provision(ctx, UserProvisionCmd{
+		Login:       "new@example.com",
+		FirstName:   "New",
+		LastName:    "User",
+		Type:        entities.AdminUser,
+		AllowCreate: true,
+	})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.ID)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestUsersService_Provision_SignUpNotAllowed(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+
+	usersRepo.On("GetByLogin", ctx, "new@example.org").Return(entities.User{}, entities.ErrNotFound)
+
+	_, err := service.Provision(ctx, UserProvisionCmd{Login: "new@example.org"})
+
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+	usersRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUsersService_Provision_SyncsProfile(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+	existing := entities.User{
+		ID:        entities.NewId(),
+		Login:     "john@example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+		Type:      entities.AdminUser,
+		Active:    true,
+	}
+
+	usersRepo.On("GetByLogin", ctx, existing.Login).Return(existing, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		// user type is kept unless synchronization is requested
+		return u.FirstName == "Johnny" && u.LastName == "Doe" && u.Type == entities.AdminUser &&
+			u.UpdatedBy != nil && u.UpdatedBy.ID == existing.ID && u.UpdatedBy.UpdatedBy == nil
+	})).Return(nil).Once()
+
+	user, err := service.Provision(ctx, UserProvisionCmd{
+		Login:     existing.Login,
+		FirstName: "Johnny",
+		Type:      entities.RegularUser,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Johnny", user.FirstName)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestUsersService_Provision_SyncsType(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+	existing := entities.User{ID: entities.NewId(), Login: "john@example.com", FirstName: "John", Type: entities.AdminUser, Active: true}
+
+	usersRepo.On("GetByLogin", ctx, existing.Login).Return(existing, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool {
+		return u.Type == entities.RegularUser
+	})).Return(nil).Once()
+
+	user, err := service.Provision(ctx, UserProvisionCmd{Login: existing.Login, FirstName: "John", Type: entities.RegularUser, SyncType: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, entities.RegularUser, user.Type)
+	usersRepo.AssertExpectations(t)
+}
+
+func TestUsersService_Provision_UnchangedProfile(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+	existing := entities.User{ID: entities.NewId(), Login: "john@example.com", FirstName: "John", LastName: "Doe", Active: true}
+
+	usersRepo.On("GetByLogin", ctx, existing.Login).Return(existing, nil)
+
+	_, err := service.Provision(ctx, UserProvisionCmd{Login: existing.Login, FirstName: "John", LastName: "Doe"})
+
+	require.NoError(t, err)
+	usersRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUsersService_Provision_ServiceAccount(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+	account := entities.User{ID: entities.NewId(), Login: "milter", Type: entities.MilterUser, Active: true}
+
+	usersRepo.On("GetByLogin", ctx, account.Login).Return(account, nil)
+
+	_, err := service.Provision(ctx, UserProvisionCmd{Login: account.Login, AllowCreate: true})
+
+	assert.ErrorIs(t, err, entities.ErrNotAuthorized)
+}
+
+func TestUsersService_Update_PrivacyFilter(t *testing.T) {
+	service, usersRepo, _, _, _ := setupUsersService(t)
+	ctx := context.Background()
+
+	user := entities.User{ID: entities.NewId(), Type: entities.RegularUser, Login: "user@test.com"}
+	usersRepo.On("GetById", ctx, user.ID).Return(user, nil)
+	usersRepo.On("Update", ctx, mock.MatchedBy(func(u entities.User) bool { return u.PrivacyFilter })).Return(nil)
+
+	updatedUser, err := service.Update(ctx, user, UserUpdateCmd{UserID: user.ID, PrivacyFilter: new(true)})
+
+	assert.NoError(t, err)
+	assert.True(t, updatedUser.PrivacyFilter)
+	usersRepo.AssertExpectations(t)
+}
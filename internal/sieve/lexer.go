@@ -0,0 +1,255 @@
+package sieve
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdentifier
+	tokTag
+	tokNumber
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  int64
+	line int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of script"
+	case tokTag:
+		return ":" + t.text
+	case tokNumber:
+		return strconv.FormatInt(t.num, 10)
+	default:
+		return strconv.Quote(t.text)
+	}
+}
+
+// lexer splits the script into tokens, skipping the white space and comments (RFC 5228, section 8.1).
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", l.line, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) tokens() ([]token, error) {
+	l.line = 1
+	var toks []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+
+		toks = append(toks, tok)
+		if tok.kind == tokEOF {
+			return toks, nil
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpace(); err != nil {
+		return token{}, err
+	}
+
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, line: l.line}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte(";,[](){}", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, text: string(c), line: l.line}, nil
+	case c == '"':
+		return l.quoted()
+	case c == ':':
+		l.pos++
+		name := l.identifier()
+		if name == "" {
+			return token{}, l.errorf("tag name expected after ':'")
+		}
+		return token{kind: tokTag, text: strings.ToLower(name), line: l.line}, nil
+	case isDigit(c):
+		return l.number()
+	case isIdentStart(c):
+		line := l.line
+		name := l.identifier()
+		if strings.EqualFold(name, "text") && l.pos < len(l.src) && l.src[l.pos] == ':' {
+			l.pos++
+			return l.multiline(line)
+		}
+		return token{kind: tokIdentifier, text: strings.ToLower(name), line: line}, nil
+	default:
+		return token{}, l.errorf("unexpected character %q", c)
+	}
+}
+
+func (l *lexer) skipSpace() error {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return l.errorf("unterminated comment")
+			}
+			l.line += strings.Count(l.src[l.pos:l.pos+2+end], "\n")
+			l.pos += end + 4
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (l *lexer) identifier() string {
+	start := l.pos
+	for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+		if l.pos == start && isDigit(l.src[l.pos]) {
+			break
+		}
+		l.pos++
+	}
+
+	return l.src[start:l.pos]
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+
+	n, err := strconv.ParseInt(l.src[start:l.pos], 10, 64)
+	if err != nil {
+		return token{}, l.errorf("invalid number %q", l.src[start:l.pos])
+	}
+
+	if l.pos < len(l.src) {
+		shift := 0
+		switch l.src[l.pos] {
+		case 'K', 'k':
+			shift = 10
+		case 'M', 'm':
+			shift = 20
+		case 'G', 'g':
+			shift = 30
+		}
+		if shift > 0 {
+			l.pos++
+			if n > (1<<62)>>shift {
+				return token{}, l.errorf("number is too large")
+			}
+			n <<= shift
+		}
+	}
+
+	return token{kind: tokNumber, num: n, line: l.line}, nil
+}
+
+// quoted reads a quoted string, only the quote and the backslash are escaped by a backslash
+func (l *lexer) quoted() (token, error) {
+	line := l.line
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return token{kind: tokString, text: b.String(), line: line}, nil
+		case '\\':
+			if l.pos+1 < len(l.src) {
+				l.pos++
+				c = l.src[l.pos]
+			}
+		case '\n':
+			l.line++
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+
+	return token{}, fmt.Errorf("line %d: unterminated string", line)
+}
+
+// multiline reads a string started by "text:", which ends with a line holding a single dot
+func (l *lexer) multiline(line int) (token, error) {
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+
+	if l.pos < len(l.src) && l.src[l.pos] == '#' {
+		for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+			l.pos++
+		}
+	}
+
+	if l.pos < len(l.src) && l.src[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos >= len(l.src) || l.src[l.pos] != '\n' {
+		return token{}, fmt.Errorf("line %d: line break expected after \"text:\"", line)
+	}
+	l.pos++
+	l.line++
+
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		if end < 0 {
+			end = len(l.src) - l.pos
+		}
+
+		text := strings.TrimSuffix(l.src[l.pos:l.pos+end], "\r")
+		l.pos = min(l.pos+end+1, len(l.src))
+		l.line++
+		if text == "." {
+			return token{kind: tokString, text: b.String(), line: line}, nil
+		}
+
+		// a line starting with a dot is stuffed with another one
+		if strings.HasPrefix(text, "..") {
+			text = text[1:]
+		}
+		b.WriteString(text)
+		b.WriteString("\r\n")
+	}
+
+	return token{}, fmt.Errorf("line %d: unterminated multi-line string", line)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
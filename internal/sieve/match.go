@@ -0,0 +1,108 @@
+package sieve
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// comparatorASCIICaseMap compares the strings ignoring the case of ASCII letters, the default comparator
+	comparatorASCIICaseMap = "i;ascii-casemap"
+	// comparatorOctet compares the strings as they are
+	comparatorOctet = "i;octet"
+)
+
+// matcher compares the values of the header and address tests to their keys (RFC 5228, section 2.7)
+type matcher struct {
+	comparator string
+	// match is the match type, "is", "contains" or "matches"
+	match string
+}
+
+// any reports whether the value matches any of the keys
+func (m matcher) any(value string, keys []string) bool {
+	if m.comparator == comparatorASCIICaseMap {
+		value = asciiLower(value)
+	}
+
+	for _, key := range keys {
+		if m.comparator == comparatorASCIICaseMap {
+			key = asciiLower(key)
+		}
+
+		switch m.match {
+		case "is":
+			if value == key {
+				return true
+			}
+		case "contains":
+			if strings.Contains(value, key) {
+				return true
+			}
+		case "matches":
+			if wildcardMatch(value, key) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// asciiLower maps the ASCII letters of s to lower case, leaving the other characters as they are
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// wildcardMatch matches the value to the pattern, where "*" matches any sequence of characters,
+// "?" a single character and a backslash escapes the following character
+func wildcardMatch(value, pattern string) bool {
+	// star and match are the positions to go back to when the characters after a "*" do not match
+	star, match := -1, 0
+	v, p := 0, 0
+	for v < len(value) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				star, match = p, v
+				p++
+				continue
+			case '?':
+				_, size := utf8.DecodeRuneInString(value[v:])
+				v += size
+				p++
+				continue
+			}
+
+			pc, psize := pattern[p], 1
+			if pc == '\\' && p+1 < len(pattern) {
+				pc, psize = pattern[p+1], 2
+			}
+			if pc == value[v] {
+				v++
+				p += psize
+				continue
+			}
+		}
+
+		if star < 0 {
+			return false
+		}
+
+		// the "*" takes one more character
+		_, size := utf8.DecodeRuneInString(value[match:])
+		match += size
+		v, p = match, star+1
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
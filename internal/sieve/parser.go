@@ -0,0 +1,441 @@
+package sieve
+
+import (
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+)
+
+// maxNesting limits the depth of the nested blocks and tests of a script
+const maxNesting = 16
+
+// protectedFields are the header fields which can not be added by the scripts: the ones RFC 5293 forbids,
+// the ones the Ovoo milter rewrites and the subject, which a message should have once
+var protectedFields = []string{"received", "auto-submitted", "from", "to", "reply-to", "subject"}
+
+// argument is a tag, a number or a string list of a command or a test
+type argument struct {
+	tag  string
+	num  int64
+	strs []string
+	kind tokenKind
+	line int
+}
+
+func (a argument) String() string {
+	switch a.kind {
+	case tokTag:
+		return ":" + a.tag
+	case tokNumber:
+		return fmt.Sprint(a.num)
+	default:
+		return fmt.Sprintf("%q", a.strs)
+	}
+}
+
+// parser builds the commands of the script from its tokens (RFC 5228, section 8.2)
+type parser struct {
+	toks       []token
+	pos        int
+	extensions []string
+	redirects  []string
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	tok := p.advance()
+	if tok.kind != tokPunct || tok.text != text {
+		return fmt.Errorf("line %d: %q expected, found %s", tok.line, text, tok)
+	}
+	return nil
+}
+
+// commands parses the commands until the end of the block or the script
+func (p *parser) commands(depth int, top bool) ([]command, error) {
+	if depth > maxNesting {
+		return nil, fmt.Errorf("line %d: blocks are nested too deep", p.peek().line)
+	}
+
+	var cmds []command
+	requireAllowed := top
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF || (tok.kind == tokPunct && tok.text == "}") {
+			return cmds, nil
+		}
+
+		if tok.kind != tokIdentifier {
+			return nil, fmt.Errorf("line %d: command expected, found %s", tok.line, tok)
+		}
+		p.advance()
+
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+
+		tests, err := p.testList(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		var block []command
+		hasBlock := p.isPunct("{")
+		if hasBlock {
+			p.advance()
+			if block, err = p.commands(depth+1, false); err != nil {
+				return nil, err
+			}
+			if err := p.expect("}"); err != nil {
+				return nil, err
+			}
+		} else if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+
+		name := tok.text
+		if name == "require" {
+			if !requireAllowed {
+				return nil, fmt.Errorf("line %d: require should be placed before the other commands", tok.line)
+			}
+		} else {
+			requireAllowed = false
+		}
+
+		// elsif and else extend the preceding if
+		if name == "elsif" || name == "else" {
+			var prev *ifCommand
+			if len(cmds) > 0 {
+				prev, _ = cmds[len(cmds)-1].(*ifCommand)
+			}
+			if prev == nil || prev.elseBlock != nil {
+				return nil, fmt.Errorf("line %d: %s without if", tok.line, name)
+			}
+		}
+
+		if !hasBlock && slices.Contains([]string{"if", "elsif", "else"}, name) {
+			return nil, fmt.Errorf("line %d: %s requires a block", tok.line, name)
+		}
+		if hasBlock && !slices.Contains([]string{"if", "elsif", "else"}, name) {
+			return nil, fmt.Errorf("line %d: %s does not take a block", tok.line, name)
+		}
+
+		cmd, err := p.command(name, tok.line, args, tests, block, cmds)
+		if err != nil {
+			return nil, err
+		}
+
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+}
+
+// command builds the command, elsif and else are added to the preceding if and return nil
+func (p *parser) command(name string, line int, args []argument, tests []test, block []command, prev []command) (command, error) {
+	switch name {
+	case "require":
+		if len(tests) > 0 || len(args) != 1 || args[0].kind != tokString {
+			return nil, fmt.Errorf("line %d: require expects a list of extensions", line)
+		}
+		for _, ext := range args[0].strs {
+			if ext != "editheader" {
+				return nil, fmt.Errorf("line %d: unsupported extension %q", line, ext)
+			}
+			p.extensions = append(p.extensions, ext)
+		}
+		return nil, nil
+	case "if", "elsif":
+		if len(args) > 0 || len(tests) != 1 {
+			return nil, fmt.Errorf("line %d: %s expects a single test", line, name)
+		}
+		if name == "if" {
+			return &ifCommand{conds: tests, blocks: [][]command{block}}, nil
+		}
+		ic := prev[len(prev)-1].(*ifCommand)
+		ic.conds = append(ic.conds, tests[0])
+		ic.blocks = append(ic.blocks, block)
+		return nil, nil
+	case "else":
+		if len(args) > 0 || len(tests) > 0 {
+			return nil, fmt.Errorf("line %d: else does not take arguments", line)
+		}
+		ic := prev[len(prev)-1].(*ifCommand)
+		ic.elseBlock = append([]command{}, block...)
+		return nil, nil
+	case "stop", "discard":
+		if len(args) > 0 || len(tests) > 0 {
+			return nil, fmt.Errorf("line %d: %s does not take arguments", line, name)
+		}
+		if name == "stop" {
+			return stopCommand{}, nil
+		}
+		return discardCommand{}, nil
+	case "redirect":
+		if len(tests) > 0 || len(args) != 1 || args[0].kind != tokString || len(args[0].strs) != 1 {
+			return nil, fmt.Errorf("line %d: redirect expects an address", line)
+		}
+		addr, err := mail.ParseAddress(args[0].strs[0])
+		if err != nil || addr.Name != "" {
+			return nil, fmt.Errorf("line %d: invalid redirect address %q", line, args[0].strs[0])
+		}
+		if !slices.Contains(p.redirects, strings.ToLower(addr.Address)) {
+			p.redirects = append(p.redirects, strings.ToLower(addr.Address))
+		}
+		return redirectCommand{address: addr.Address}, nil
+	case "addheader":
+		return p.addHeader(line, args, tests)
+	default:
+		return nil, fmt.Errorf("line %d: unsupported command %q", line, name)
+	}
+}
+
+func (p *parser) addHeader(line int, args []argument, tests []test) (command, error) {
+	if !slices.Contains(p.extensions, "editheader") {
+		return nil, fmt.Errorf("line %d: addheader requires the \"editheader\" extension", line)
+	}
+
+	cmd := addHeaderCommand{}
+	if len(args) > 0 && args[0].kind == tokTag {
+		if args[0].tag != "last" {
+			return nil, fmt.Errorf("line %d: unknown addheader tag %s", line, args[0])
+		}
+		cmd.last = true
+		args = args[1:]
+	}
+
+	if len(tests) > 0 || len(args) != 2 || !singleString(args[0]) || !singleString(args[1]) {
+		return nil, fmt.Errorf("line %d: addheader expects a field name and a value", line)
+	}
+
+	cmd.name, cmd.value = args[0].strs[0], args[1].strs[0]
+	if !validFieldName(cmd.name) {
+		return nil, fmt.Errorf("line %d: invalid header field name %q", line, cmd.name)
+	}
+	if slices.Contains(protectedFields, strings.ToLower(cmd.name)) {
+		return nil, fmt.Errorf("line %d: header field %q can not be added", line, cmd.name)
+	}
+	if strings.ContainsAny(cmd.value, "\r\n") {
+		return nil, fmt.Errorf("line %d: header field value can not contain line breaks", line)
+	}
+
+	return cmd, nil
+}
+
+// arguments parses the tags, numbers and string lists preceding the tests of a command or test
+func (p *parser) arguments() ([]argument, error) {
+	var args []argument
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokTag:
+			p.advance()
+			args = append(args, argument{kind: tokTag, tag: tok.text, line: tok.line})
+		case tok.kind == tokNumber:
+			p.advance()
+			args = append(args, argument{kind: tokNumber, num: tok.num, line: tok.line})
+		case tok.kind == tokString:
+			p.advance()
+			args = append(args, argument{kind: tokString, strs: []string{tok.text}, line: tok.line})
+		case tok.kind == tokPunct && tok.text == "[":
+			p.advance()
+			list, err := p.stringList()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, argument{kind: tokString, strs: list, line: tok.line})
+		default:
+			return args, nil
+		}
+	}
+}
+
+func (p *parser) stringList() ([]string, error) {
+	var list []string
+	for {
+		tok := p.advance()
+		if tok.kind != tokString {
+			return nil, fmt.Errorf("line %d: string expected in list, found %s", tok.line, tok)
+		}
+		list = append(list, tok.text)
+
+		if p.isPunct("]") {
+			p.advance()
+			return list, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// testList parses a single test or a parenthesized list of tests, if any
+func (p *parser) testList(depth int) ([]test, error) {
+	if depth > maxNesting {
+		return nil, fmt.Errorf("line %d: tests are nested too deep", p.peek().line)
+	}
+
+	if p.peek().kind == tokIdentifier {
+		t, err := p.test(depth)
+		if err != nil {
+			return nil, err
+		}
+		return []test{t}, nil
+	}
+
+	if !p.isPunct("(") {
+		return nil, nil
+	}
+	p.advance()
+
+	var tests []test
+	for {
+		if p.peek().kind != tokIdentifier {
+			return nil, fmt.Errorf("line %d: test expected, found %s", p.peek().line, p.peek())
+		}
+		t, err := p.test(depth)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, t)
+
+		if p.isPunct(")") {
+			p.advance()
+			return tests, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) test(depth int) (test, error) {
+	tok := p.advance()
+	args, err := p.arguments()
+	if err != nil {
+		return nil, err
+	}
+
+	var tests []test
+	if tok.text == "not" || tok.text == "allof" || tok.text == "anyof" {
+		if tests, err = p.testList(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+
+	switch tok.text {
+	case "true", "false":
+		if len(args) > 0 {
+			return nil, fmt.Errorf("line %d: %s does not take arguments", tok.line, tok.text)
+		}
+		return constTest(tok.text == "true"), nil
+	case "not":
+		if len(args) > 0 || len(tests) != 1 {
+			return nil, fmt.Errorf("line %d: not expects a single test", tok.line)
+		}
+		return notTest{tests[0]}, nil
+	case "allof", "anyof":
+		if len(args) > 0 || len(tests) == 0 {
+			return nil, fmt.Errorf("line %d: %s expects a list of tests", tok.line, tok.text)
+		}
+		return listTest{all: tok.text == "allof", tests: tests}, nil
+	case "header", "address":
+		return compareTest(tok.text, tok.line, args)
+	case "size":
+		if len(args) != 2 || args[0].kind != tokTag || args[1].kind != tokNumber || (args[0].tag != "over" && args[0].tag != "under") {
+			return nil, fmt.Errorf("line %d: size expects :over or :under and a limit", tok.line)
+		}
+		return sizeTest{over: args[0].tag == "over", limit: args[1].num}, nil
+	default:
+		return nil, fmt.Errorf("line %d: unsupported test %q", tok.line, tok.text)
+	}
+}
+
+// compareTest builds the header and address tests: the tags, the header names and the keys
+func compareTest(name string, line int, args []argument) (test, error) {
+	m := matcher{comparator: comparatorASCIICaseMap, match: "is"}
+	part := "all"
+	var seen []string
+	for len(args) > 0 && args[0].kind == tokTag {
+		tag := args[0].tag
+		group := tag
+		switch tag {
+		case "is", "contains", "matches":
+			m.match, group = tag, "match"
+		case "all", "localpart", "domain":
+			if name != "address" {
+				return nil, fmt.Errorf("line %d: unknown %s tag :%s", line, name, tag)
+			}
+			part, group = tag, "part"
+		case "comparator":
+			if len(args) < 2 || !singleString(args[1]) {
+				return nil, fmt.Errorf("line %d: :comparator expects a comparator name", line)
+			}
+			m.comparator = args[1].strs[0]
+			if m.comparator != comparatorASCIICaseMap && m.comparator != comparatorOctet {
+				return nil, fmt.Errorf("line %d: unsupported comparator %q", line, m.comparator)
+			}
+			args = args[1:]
+		default:
+			return nil, fmt.Errorf("line %d: unknown %s tag :%s", line, name, tag)
+		}
+
+		if slices.Contains(seen, group) {
+			return nil, fmt.Errorf("line %d: %s tag :%s conflicts with a preceding tag", line, name, tag)
+		}
+		seen = append(seen, group)
+		args = args[1:]
+	}
+
+	if len(args) != 2 || args[0].kind != tokString || args[1].kind != tokString {
+		return nil, fmt.Errorf("line %d: %s expects a list of header names and a list of keys", line, name)
+	}
+
+	for _, field := range args[0].strs {
+		if !validFieldName(field) {
+			return nil, fmt.Errorf("line %d: invalid header field name %q", line, field)
+		}
+	}
+
+	if name == "address" {
+		return addressTest{matcher: m, part: part, fields: args[0].strs, keys: args[1].strs}, nil
+	}
+
+	return headerTest{matcher: m, fields: args[0].strs, keys: args[1].strs}, nil
+}
+
+func singleString(a argument) bool {
+	return a.kind == tokString && len(a.strs) == 1
+}
+
+// validFieldName reports whether the header field name is made of printable ASCII characters except the colon
+func validFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		if name[i] < 33 || name[i] > 126 || name[i] == ':' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,285 @@
+// Package sieve parses and runs the filtering rules of the Ovoo aliases, written in a subset of Sieve (RFC 5228).
+//
+// The scripts support the control commands if, elsif, else, require and stop, the tests header, address, size,
+// allof, anyof, not, true and false with the "i;ascii-casemap" and "i;octet" comparators, and the actions discard,
+// redirect and addheader of the "editheader" extension (RFC 5293). A message is redirected once at most.
+package sieve
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+
+	"github.com/emersion/go-message/charset"
+)
+
+// errStop ends the script run by the stop command
+var errStop = errors.New("stop")
+
+var wordDecoder = mime.WordDecoder{CharsetReader: charset.Reader}
+
+// Message is the message the script runs on.
+type Message interface {
+	// Header returns the raw values of all header fields with the name
+	Header(name string) []string
+	// Size returns the size of the message in bytes
+	Size() int64
+}
+
+// HeaderField is a header field added to the message.
+type HeaderField struct {
+	Name  string
+	Value string
+	// Last is set when the field is added after the other fields, it is added before them otherwise
+	Last bool
+}
+
+// Result holds the actions the script took on the message.
+type Result struct {
+	// Keep is set when the message is delivered as usual, the discard and redirect actions cancel it
+	Keep bool
+	// Redirect is the address the message is delivered to instead, if any
+	Redirect string
+	// Headers are the fields added to the message in order
+	Headers []HeaderField
+}
+
+// Discarded reports whether the message is not delivered at all.
+func (r Result) Discarded() bool {
+	return !r.Keep && r.Redirect == ""
+}
+
+// Script is a parsed script.
+type Script struct {
+	commands  []command
+	redirects []string
+}
+
+// Parse parses and validates the script.
+func Parse(src string) (*Script, error) {
+	toks, err := (&lexer{src: src}).tokens()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+	cmds, err := p.commands(0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("line %d: unexpected %s", tok.line, tok)
+	}
+
+	return &Script{commands: cmds, redirects: p.redirects}, nil
+}
+
+// Redirects returns the addresses the script can redirect the messages to, in lower case.
+func (s *Script) Redirects() []string {
+	return append([]string{}, s.redirects...)
+}
+
+// Run runs the script on the message. On an error, e.g. when the message is redirected twice,
+// the actions of the script should be ignored and the message kept.
+func (s *Script) Run(msg Message) (Result, error) {
+	e := &execution{msg: msg, result: Result{Keep: true}}
+	if err := e.run(s.commands); err != nil && !errors.Is(err, errStop) {
+		return Result{Keep: true}, err
+	}
+
+	return e.result, nil
+}
+
+type execution struct {
+	msg    Message
+	result Result
+}
+
+func (e *execution) run(cmds []command) error {
+	for _, cmd := range cmds {
+		if err := cmd.exec(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type command interface {
+	exec(e *execution) error
+}
+
+type ifCommand struct {
+	conds     []test
+	blocks    [][]command
+	elseBlock []command
+}
+
+func (c *ifCommand) exec(e *execution) error {
+	for i, cond := range c.conds {
+		if cond.eval(e) {
+			return e.run(c.blocks[i])
+		}
+	}
+
+	return e.run(c.elseBlock)
+}
+
+type stopCommand struct{}
+
+func (stopCommand) exec(e *execution) error {
+	return errStop
+}
+
+type discardCommand struct{}
+
+func (discardCommand) exec(e *execution) error {
+	e.result.Keep = false
+	return nil
+}
+
+type redirectCommand struct {
+	address string
+}
+
+func (c redirectCommand) exec(e *execution) error {
+	if e.result.Redirect != "" && !strings.EqualFold(e.result.Redirect, c.address) {
+		return fmt.Errorf("message is redirected to %s already", e.result.Redirect)
+	}
+
+	e.result.Keep = false
+	e.result.Redirect = c.address
+	return nil
+}
+
+type addHeaderCommand struct {
+	name  string
+	value string
+	last  bool
+}
+
+func (c addHeaderCommand) exec(e *execution) error {
+	e.result.Headers = append(e.result.Headers, HeaderField{Name: c.name, Value: c.value, Last: c.last})
+	return nil
+}
+
+type test interface {
+	eval(e *execution) bool
+}
+
+type constTest bool
+
+func (t constTest) eval(e *execution) bool {
+	return bool(t)
+}
+
+type notTest struct {
+	test test
+}
+
+func (t notTest) eval(e *execution) bool {
+	return !t.test.eval(e)
+}
+
+type listTest struct {
+	all   bool
+	tests []test
+}
+
+func (t listTest) eval(e *execution) bool {
+	for _, sub := range t.tests {
+		if sub.eval(e) != t.all {
+			return !t.all
+		}
+	}
+
+	return t.all
+}
+
+type sizeTest struct {
+	over  bool
+	limit int64
+}
+
+func (t sizeTest) eval(e *execution) bool {
+	if t.over {
+		return e.msg.Size() > t.limit
+	}
+
+	return e.msg.Size() < t.limit
+}
+
+type headerTest struct {
+	matcher
+	fields []string
+	keys   []string
+}
+
+func (t headerTest) eval(e *execution) bool {
+	for _, field := range t.fields {
+		for _, value := range e.msg.Header(field) {
+			if t.any(decodeHeader(value), t.keys) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type addressTest struct {
+	matcher
+	part   string
+	fields []string
+	keys   []string
+}
+
+func (t addressTest) eval(e *execution) bool {
+	for _, field := range t.fields {
+		for _, value := range e.msg.Header(field) {
+			addrs, err := mail.ParseAddressList(value)
+			if err != nil {
+				// invalid addresses only match as a whole (RFC 5228, section 5.1)
+				if t.part == "all" && t.any(strings.TrimSpace(decodeHeader(value)), t.keys) {
+					return true
+				}
+				continue
+			}
+
+			for _, addr := range addrs {
+				if t.any(addressPart(addr.Address, t.part), t.keys) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// addressPart returns the part of the address compared by the address test
+func addressPart(addr, part string) string {
+	at := strings.LastIndexByte(addr, '@')
+	switch {
+	case part == "localpart" && at >= 0:
+		return addr[:at]
+	case part == "domain" && at >= 0:
+		return addr[at+1:]
+	}
+
+	return addr
+}
+
+// decodeHeader decodes the encoded words of the header field value, it is returned unfolded as it is
+// when they can not be decoded
+func decodeHeader(value string) string {
+	value = strings.NewReplacer("\r\n", "", "\n", "").Replace(value)
+	if decoded, err := wordDecoder.DecodeHeader(value); err == nil {
+		return decoded
+	}
+
+	return value
+}
@@ -0,0 +1,225 @@
+package sieve
+
+import (
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMessage is a message with the header fields and size of the test
+type testMessage struct {
+	header textproto.MIMEHeader
+	size   int64
+}
+
+func (m testMessage) Header(name string) []string {
+	return m.header.Values(name)
+}
+
+func (m testMessage) Size() int64 {
+	return m.size
+}
+
+func newTestMessage() testMessage {
+	return testMessage{
+		header: textproto.MIMEHeader{
+			"From":    {`"Shop" <News@Shop.example>`},
+			"To":      {"alias@ovoo.com, other@ovoo.com"},
+			"Subject": {"=?utf-8?q?Gro=C3=9Fe_Sale?= today"},
+			"X-Spam":  {"YES"},
+		},
+		size: 2048,
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unknown command":           `fileinto "INBOX";`,
+		"unknown test":              `if exists "x" { discard; }`,
+		"unsupported extension":     `require "fileinto";`,
+		"require after command":     `discard; require "editheader";`,
+		"addheader without require": `addheader "X-Tag" "shop";`,
+		"protected header":          `require "editheader"; addheader "From" "me@ovoo.com";`,
+		"duplicate subject":         `require "editheader"; addheader "Subject" "[shop]";`,
+		"header value line break":   "require \"editheader\"; addheader \"X-Tag\" text:\r\na\r\nb\r\n.\r\n;",
+		"invalid header name":       `if header :is "x y" "1" { discard; }`,
+		"missing semicolon":         `discard`,
+		"missing block":             `if true discard;`,
+		"else without if":           `else { discard; }`,
+		"elsif after else":          `if true { stop; } else { stop; } elsif false { stop; }`,
+		"invalid redirect":          `redirect "Me <me@example.com>";`,
+		"size without limit":        `if size :over { discard; }`,
+		"conflicting match types":   `if header :is :contains "subject" "x" { discard; }`,
+		"address part on header":    `if header :domain "from" "x" { discard; }`,
+		"unknown comparator":        `if header :comparator "i;unicode-casemap" "subject" "x" { discard; }`,
+		"unterminated string":       `if header "subject "x { discard; }`,
+		"unterminated comment":      `/* discard;`,
+		"nested too deep":           strings.Repeat("if true { ", maxNesting+2) + strings.Repeat("}", maxNesting+2),
+	}
+
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(src)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParse_Redirects(t *testing.T) {
+	script, err := Parse(`
+		# forward the shop mail to the other mailbox
+		if address :domain "from" "shop.example" {
+			redirect "Other@Example.com";
+		} elsif header :contains "subject" "invoice" {
+			redirect "billing@example.com";
+		} else {
+			redirect "other@example.com";
+		}
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other@example.com", "billing@example.com"}, script.Redirects())
+}
+
+func TestScript_Run(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   Result
+	}{
+		{
+			name:   "empty script keeps",
+			script: "",
+			want:   Result{Keep: true},
+		},
+		{
+			name:   "header contains decoded subject",
+			script: `if header :contains "subject" "große" { discard; }`,
+			want:   Result{},
+		},
+		{
+			name:   "header is case-insensitive",
+			script: `if header :is "x-spam" "yes" { discard; }`,
+			want:   Result{},
+		},
+		{
+			name:   "header octet comparator",
+			script: `if header :comparator "i;octet" :is "x-spam" "yes" { discard; }`,
+			want:   Result{Keep: true},
+		},
+		{
+			name:   "header matches wildcards",
+			script: `if header :matches "subject" "*sale?today" { discard; }`,
+			want:   Result{},
+		},
+		{
+			name:   "missing header does not match",
+			script: `if header :contains "cc" "" { discard; }`,
+			want:   Result{Keep: true},
+		},
+		{
+			name:   "address domain",
+			script: `if address :domain :is "from" "shop.example" { redirect "other@example.com"; }`,
+			want:   Result{Redirect: "other@example.com"},
+		},
+		{
+			name:   "address localpart of any recipient",
+			script: `if address :localpart "to" ["nobody", "other"] { discard; }`,
+			want:   Result{},
+		},
+		{
+			name:   "address all",
+			script: `if not address :all "from" "news@shop.example" { discard; }`,
+			want:   Result{Keep: true},
+		},
+		{
+			name:   "size over",
+			script: `if size :over 1K { discard; }`,
+			want:   Result{},
+		},
+		{
+			name:   "size under",
+			script: `if size :under 1K { discard; }`,
+			want:   Result{Keep: true},
+		},
+		{
+			name:   "allof and anyof",
+			script: `if allof (true, anyof (false, header :contains "x-spam" "y")) { discard; }`,
+			want:   Result{},
+		},
+		{
+			name: "addheader and stop",
+			script: `require ["editheader"];
+				addheader "X-Tag" "shop";
+				addheader :last "X-Filtered" "yes";
+				stop;
+				discard;`,
+			want: Result{Keep: true, Headers: []HeaderField{{Name: "X-Tag", Value: "shop"}, {Name: "X-Filtered", Value: "yes", Last: true}}},
+		},
+		{
+			name:   "elsif branch",
+			script: `if false { discard; } elsif true { redirect "a@example.com"; } else { discard; }`,
+			want:   Result{Redirect: "a@example.com"},
+		},
+		{
+			name:   "redirect after discard",
+			script: `discard; redirect "a@example.com";`,
+			want:   Result{Redirect: "a@example.com"},
+		},
+		{
+			name:   "multi-line string",
+			script: "if header :contains \"subject\" text: # comment\r\nsale\r\n.\r\n{ discard; }",
+			want:   Result{Keep: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := Parse(tt.script)
+			require.NoError(t, err)
+
+			got, err := script.Run(newTestMessage())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Redirect == "" && !tt.want.Keep, got.Discarded())
+		})
+	}
+}
+
+// A message redirected twice is a runtime error, the message is kept without the actions.
+func TestScript_Run_RedirectedTwice(t *testing.T) {
+	script, err := Parse(`require "editheader"; addheader "X-Tag" "a"; redirect "a@example.com"; redirect "b@example.com";`)
+	require.NoError(t, err)
+
+	got, err := script.Run(newTestMessage())
+	assert.Error(t, err)
+	assert.Equal(t, Result{Keep: true}, got)
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		value   string
+		pattern string
+		want    bool
+	}{
+		{"", "", true},
+		{"", "*", true},
+		{"abc", "*", true},
+		{"abc", "a*c", true},
+		{"abc", "a?c", true},
+		{"abc", "a?", false},
+		{"abcbc", "*bc", true},
+		{"aüc", "a?c", true},
+		{"a*c", `a\*c`, true},
+		{"abc", `a\*c`, false},
+		{"abc", "b*", false},
+	}
+
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.value, tt.pattern); got != tt.want {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", tt.value, tt.pattern, got, tt.want)
+		}
+	}
+}
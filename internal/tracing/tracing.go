@@ -0,0 +1,134 @@
+// Package tracing configures OpenTelemetry tracing of the Ovoo applications and provides
+// helpers to start spans and to propagate the trace context over HTTP (W3C traceparent).
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the Ovoo spans
+const tracerName = "github.com/Burmuley/ovoo"
+
+// defaultExportTimeout bounds a request to the collector when no timeout is configured
+const defaultExportTimeout = 10 * time.Second
+
+// tracesPath is the OTLP/HTTP path of the trace export requests
+const tracesPath = "/v1/traces"
+
+// Setup configures the global tracer provider exporting spans of the service to the OTLP/HTTP
+// endpoint of the configuration, and the W3C trace context propagation. Tracing is disabled when
+// cfg is nil or has no endpoint, the trace context of incoming requests is propagated anyway.
+//
+// The returned function flushes the pending spans and stops the exporter, it should be called
+// when the application stops.
+func Setup(cfg *config.ConfigTracing, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg == nil || cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	ratio := 1.0
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+
+	if ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("%w: tracing sample_ratio must be between 0 and 1", entities.ErrConfiguration)
+	}
+
+	if cfg.ServiceName != "" {
+		serviceName = cfg.ServiceName
+	}
+
+	timeout := defaultExportTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	exporter, err := newExporter(cfg.Endpoint, cfg.Headers, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("creating tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// newExporter creates the OTLP/HTTP exporter sending spans to the endpoint, e.g. "http://127.0.0.1:4318".
+// The headers are added to every request, e.g. for the authentication with the collector.
+func newExporter(endpoint string, headers map[string]string, timeout time.Duration) (*otlptrace.Exporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: tracing endpoint must be an http(s) URL", entities.ErrConfiguration)
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+tracesPath),
+		otlptracehttp.WithHeaders(headers),
+		otlptracehttp.WithTimeout(timeout),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating tracing exporter: %w", err)
+	}
+
+	return exporter, nil
+}
+
+// Start starts a span named after the traced operation as a child of the span in ctx.
+// The context is returned unchanged when tracing is disabled, so untraced calls keep the
+// context of the caller.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	spanCtx, span := otel.Tracer(tracerName).Start(ctx, name, opts...)
+	if !span.IsRecording() && span.SpanContext().Equal(trace.SpanContextFromContext(ctx)) {
+		return ctx, span
+	}
+
+	return spanCtx, span
+}
+
+// End records the error, if any, on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject writes the trace context of ctx into the headers of an outgoing request.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx with the trace context of the headers of an incoming request.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Burmuley/ovoo/internal/config"
+	"github.com/Burmuley/ovoo/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	ratio := func(r float64) *float64 { return &r }
+
+	tests := []struct {
+		name    string
+		cfg     *config.ConfigTracing
+		wantErr error
+	}{
+		{name: "no configuration", cfg: nil},
+		{name: "no endpoint", cfg: &config.ConfigTracing{}},
+		{name: "valid", cfg: &config.ConfigTracing{Endpoint: "http://127.0.0.1:4318", SampleRatio: ratio(0.5)}},
+		{name: "invalid endpoint", cfg: &config.ConfigTracing{Endpoint: "127.0.0.1:4318"}, wantErr: entities.ErrConfiguration},
+		{name: "invalid sample ratio", cfg: &config.ConfigTracing{Endpoint: "http://127.0.0.1:4318", SampleRatio: ratio(2)}, wantErr: entities.ErrConfiguration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Setup(tt.cfg, "ovoo-test")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NoError(t, shutdown(context.Background()))
+		})
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	_, err := Setup(nil, "ovoo-test")
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	ctx, span := Start(context.Background(), "client")
+	header := http.Header{}
+	Inject(ctx, header)
+	End(span, errors.New("boom"))
+
+	remote := trace.SpanContextFromContext(Extract(context.Background(), header))
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "boom", spans[0].Status.Description)
+}
+
+func TestSetup_ExportsToCollector(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	requests := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+	}))
+	defer srv.Close()
+
+	shutdown, err := Setup(&config.ConfigTracing{Endpoint: srv.URL + "/", Headers: map[string]string{"Authorization": "Bearer token"}}, "ovoo-test")
+	require.NoError(t, err)
+
+	_, span := Start(context.Background(), "span")
+	End(span, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	select {
+	case r := <-requests:
+		assert.Equal(t, tracesPath, r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	case <-time.After(time.Second):
+		t.Fatal("no spans exported")
+	}
+}
@@ -0,0 +1,40 @@
+// Helpers converting WebAuthn options and credentials between the JSON
+// representation used by the API (base64url strings) and the browser API (ArrayBuffers).
+
+function b64urlToBuffer(value) {
+  const b64 = value.replace(/-/g, '+').replace(/_/g, '/')
+  const padded = b64 + '='.repeat((4 - (b64.length % 4)) % 4)
+  return Uint8Array.from(atob(padded), c => c.charCodeAt(0)).buffer
+}
+
+function bufferToB64url(buffer) {
+  const bytes = new Uint8Array(buffer)
+  let binary = ''
+  bytes.forEach(b => { binary += String.fromCharCode(b) })
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '')
+}
+
+export function webauthnSupported() {
+  return !!window.PublicKeyCredential
+}
+
+// getAssertion runs navigator.credentials.get() with the login challenge options
+// and returns the assertion ready to be sent with the second factor login request.
+export async function getAssertion(options) {
+  const publicKey = { ...options.publicKey }
+  publicKey.challenge = b64urlToBuffer(publicKey.challenge)
+  publicKey.allowCredentials = (publicKey.allowCredentials || []).map(c => ({ ...c, id: b64urlToBuffer(c.id) }))
+
+  const cred = await navigator.credentials.get({ publicKey })
+  return {
+    id: cred.id,
+    rawId: bufferToB64url(cred.rawId),
+    type: cred.type,
+    response: {
+      clientDataJSON: bufferToB64url(cred.response.clientDataJSON),
+      authenticatorData: bufferToB64url(cred.response.authenticatorData),
+      signature: bufferToB64url(cred.response.signature),
+      userHandle: cred.response.userHandle ? bufferToB64url(cred.response.userHandle) : undefined,
+    },
+  }
+}